@@ -18,6 +18,10 @@ type Attorney struct {
 	Address     place.Address
 }
 
+func (a Attorney) FullName() string {
+	return fmt.Sprintf("%s %s", a.FirstNames, a.LastName)
+}
+
 type Attorneys []Attorney
 
 func (as Attorneys) Get(id string) (Attorney, bool) {
@@ -52,7 +56,7 @@ func (as *Attorneys) Delete(attorney Attorney) bool {
 func (as Attorneys) FullNames() string {
 	names := make([]string, len(as))
 	for i, a := range as {
-		names[i] = fmt.Sprintf("%s %s", a.FirstNames, a.LastName)
+		names[i] = a.FullName()
 	}
 
 	return concatSentence(names)
@@ -6,6 +6,10 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+func TestAttorneyFullName(t *testing.T) {
+	assert.Equal(t, "Bob Smith", Attorney{FirstNames: "Bob", LastName: "Smith"}.FullName())
+}
+
 func TestAttorneysGet(t *testing.T) {
 	testCases := map[string]struct {
 		attorneys        Attorneys
@@ -1,6 +1,8 @@
 package actor
 
 import (
+	"fmt"
+
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/place"
 	"golang.org/x/exp/slices"
 )
@@ -13,6 +15,10 @@ type PersonToNotify struct {
 	ID         string
 }
 
+func (p PersonToNotify) FullName() string {
+	return fmt.Sprintf("%s %s", p.FirstNames, p.LastName)
+}
+
 type PeopleToNotify []PersonToNotify
 
 func (ps PeopleToNotify) Get(id string) (PersonToNotify, bool) {
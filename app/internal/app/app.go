@@ -1,16 +1,21 @@
 package app
 
 import (
+	"context"
 	"fmt"
-	"math/rand"
 	"net/http"
+	"time"
 
 	"github.com/ministryofjustice/opg-go-common/template"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/encryption"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/localize"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page/attorney"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/page/certificateprovider"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/page/donor"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page/support"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/random"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/reference"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/sesh"
 )
 
@@ -21,18 +26,29 @@ func App(
 	tmpls template.Templates,
 	sessionStore sesh.Store,
 	dataStore page.DataStore,
+	keyProvider encryption.KeyProvider,
 	appPublicUrl string,
 	payClient page.PayClient,
 	yotiClient page.YotiClient,
 	yotiScenarioID string,
 	notifyClient page.NotifyClient,
+	eventPublisher page.EventPublisher,
 	addressClient page.AddressClient,
 	rumConfig page.RumConfig,
 	staticHash string,
 	paths page.AppPaths,
 	oneLoginClient page.OneLoginClient,
+	supportEmails []string,
 ) http.Handler {
-	lpaStore := &lpaStore{dataStore: dataStore, randomInt: rand.Intn}
+	outbox := newEventOutbox(logger, dataStore, eventPublisher)
+	lpaStore := &lpaStore{logger: logger, dataStore: dataStore, envelope: encryption.New(keyProvider), newReference: reference.Generate, outbox: outbox}
+	shareCodeStore := &shareCodeStore{dataStore: dataStore, randomString: random.String, now: time.Now}
+	paymentStore := &paymentStore{dataStore: dataStore, now: time.Now}
+	messageStore := &messageStore{dataStore: dataStore, now: time.Now}
+	noticeStore := &noticeStore{dataStore: dataStore, now: time.Now}
+	registrationStore := &registrationStore{dataStore: dataStore, now: time.Now}
+	requestLpaStore := page.UnitOfWorkLpaStore(lpaStore)
+	requestEventPublisher := page.UnitOfWorkEventPublisher(outbox)
 
 	rootMux := http.NewServeMux()
 
@@ -48,9 +64,20 @@ func App(
 		logger,
 		tmpls,
 		sessionStore,
-		lpaStore,
+		requestLpaStore,
+		oneLoginClient,
+		shareCodeStore,
+		requestEventPublisher,
+	)
+
+	attorney.Register(
+		rootMux,
+		logger,
+		tmpls,
+		sessionStore,
+		requestLpaStore,
 		oneLoginClient,
-		dataStore,
+		shareCodeStore,
 	)
 
 	donor.Register(
@@ -58,7 +85,7 @@ func App(
 		logger,
 		tmpls,
 		sessionStore,
-		lpaStore,
+		requestLpaStore,
 		oneLoginClient,
 		addressClient,
 		appPublicUrl,
@@ -66,12 +93,82 @@ func App(
 		yotiClient,
 		yotiScenarioID,
 		notifyClient,
-		dataStore,
+		shareCodeStore,
+		paymentStore,
+		messageStore,
+		noticeStore,
+		requestEventPublisher,
+		registrationStore,
+	)
+
+	support.Register(
+		rootMux,
+		logger,
+		tmpls,
+		sessionStore,
+		lpaStore,
+		supportEmails,
 	)
 
 	return withAppData(page.ValidateCsrf(rootMux, sessionStore, random.String), localizer, lang, rumConfig, staticHash)
 }
 
+// ReconcilePayments records the state of payments that were in-flight when
+// last checked, see donor.ReconcilePayments.
+func ReconcilePayments(ctx context.Context, logger page.Logger, dataStore page.DataStore, keyProvider encryption.KeyProvider, payClient page.PayClient, notifyClient page.NotifyClient, eventPublisher page.EventPublisher, appPublicUrl string) error {
+	outbox := newEventOutbox(logger, dataStore, eventPublisher)
+	lpaStore := &lpaStore{logger: logger, dataStore: dataStore, envelope: encryption.New(keyProvider), newReference: reference.Generate, outbox: outbox}
+	shareCodeStore := &shareCodeStore{dataStore: dataStore, randomString: random.String, now: time.Now}
+	paymentStore := &paymentStore{dataStore: dataStore, now: time.Now}
+	messageStore := &messageStore{dataStore: dataStore, now: time.Now}
+
+	return donor.ReconcilePayments(ctx, logger, payClient, notifyClient, page.UnitOfWorkEventPublisher(outbox), page.UnitOfWorkLpaStore(lpaStore), shareCodeStore, paymentStore, messageStore, appPublicUrl, time.Now)
+}
+
+// SendPendingNotices sends the notices to people to notify and the invites to
+// attorneys for submitted LPAs, see donor.SendPendingNotices.
+func SendPendingNotices(ctx context.Context, logger page.Logger, dataStore page.DataStore, keyProvider encryption.KeyProvider, notifyClient page.NotifyClient, appPublicUrl string) error {
+	lpaStore := &lpaStore{logger: logger, dataStore: dataStore, envelope: encryption.New(keyProvider), newReference: reference.Generate}
+	messageStore := &messageStore{dataStore: dataStore, now: time.Now}
+	noticeStore := &noticeStore{dataStore: dataStore, now: time.Now}
+	shareCodeStore := &shareCodeStore{dataStore: dataStore, randomString: random.String, now: time.Now}
+
+	return donor.SendPendingNotices(ctx, logger, notifyClient, lpaStore, messageStore, noticeStore, shareCodeStore, appPublicUrl, time.Now)
+}
+
+// RegisterLpas sends submitted LPAs to the LPA store, see
+// donor.RegisterPendingLpas.
+func RegisterLpas(ctx context.Context, logger page.Logger, dataStore page.DataStore, keyProvider encryption.KeyProvider, registrationClient page.RegistrationClient) error {
+	lpaStore := &lpaStore{logger: logger, dataStore: dataStore, envelope: encryption.New(keyProvider), newReference: reference.Generate}
+	registrationStore := &registrationStore{dataStore: dataStore, now: time.Now}
+
+	return donor.RegisterPendingLpas(ctx, logger, registrationClient, lpaStore, registrationStore, time.Now)
+}
+
+// ApproveFeeEvidence records that the evidence sent for the LPA's fee
+// reduction has been accepted, see donor.ApproveFeeEvidence. It is made as a
+// system change, as no one is signed in.
+func ApproveFeeEvidence(ctx context.Context, dataStore page.DataStore, keyProvider encryption.KeyProvider, notifyClient page.NotifyClient, appPublicUrl, lpaID string) error {
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: encryption.New(keyProvider), newReference: reference.Generate}
+	shareCodeStore := &shareCodeStore{dataStore: dataStore, randomString: random.String, now: time.Now}
+	messageStore := &messageStore{dataStore: dataStore, now: time.Now}
+	ctx = page.ContextWithSessionData(ctx, &page.SessionData{LpaID: lpaID})
+
+	lpa, err := lpaStore.get(ctx, lpaID)
+	if err != nil {
+		return err
+	}
+
+	return donor.ApproveFeeEvidence(ctx, lpaStore, notifyClient, shareCodeStore, messageStore, appPublicUrl, lpa)
+}
+
+// NotifyCallback receives delivery receipts from GOV.UK Notify, see
+// page.NotifyCallback. It is not part of App, as Notify cannot send a CSRF
+// token.
+func NotifyCallback(logger page.Logger, dataStore page.DataStore, token string) http.Handler {
+	return page.NotifyCallback(logger, token, &messageStore{dataStore: dataStore, now: time.Now})
+}
+
 func withAppData(next http.Handler, localizer localize.Localizer, lang localize.Lang, rumConfig page.RumConfig, staticHash string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -84,6 +181,7 @@ func withAppData(next http.Handler, localizer localize.Localizer, lang localize.
 		appData.StaticHash = staticHash
 		appData.Paths = page.Paths
 		appData.Localizer.ShowTranslationKeys = r.FormValue("showTranslationKeys") == "1"
+		appData.LpaWithdrawn = r.URL.Query().Get("lpaWithdrawn") == "1"
 
 		_, cookieErr := r.Cookie("cookies-consent")
 		appData.CookieConsentSet = cookieErr != http.ErrNoCookie
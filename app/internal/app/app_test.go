@@ -17,11 +17,17 @@ import (
 )
 
 func TestApp(t *testing.T) {
-	app := App(&log.Logger{}, localize.Localizer{}, localize.En, template.Templates{}, nil, nil, "http://public.url", &pay.Client{}, &identity.YotiClient{}, "yoti-scenario-id", &notify.Client{}, &place.Client{}, page.RumConfig{}, "?%3fNEI0t9MN", page.Paths, &onelogin.Client{})
+	app := App(&log.Logger{}, localize.Localizer{}, localize.En, template.Templates{}, nil, nil, nil, "http://public.url", &pay.Client{}, &identity.YotiClient{}, "yoti-scenario-id", &notify.Client{}, nil, &place.Client{}, page.RumConfig{}, "?%3fNEI0t9MN", page.Paths, &onelogin.Client{}, nil)
 
 	assert.Implements(t, (*http.Handler)(nil), app)
 }
 
+func TestNotifyCallback(t *testing.T) {
+	handler := NotifyCallback(&log.Logger{}, nil, "a-token")
+
+	assert.Implements(t, (*http.Handler)(nil), handler)
+}
+
 func TestQueryString(t *testing.T) {
 	testCases := map[string]struct {
 		url           string
@@ -0,0 +1,121 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/event"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/random"
+)
+
+// Events are published through an outbox. Each is first stored in the OUTBOX
+// partition (SK <created>#<id>), and only deleted once it has been published,
+// so an event that cannot be published is kept for PublishOutbox to retry.
+// Delivery is at-least-once: an event may be published again if deleting it
+// fails. Events about a change to an LPA are stored in the same transaction as
+// the LPA, see lpaStore.PutWithEvents.
+
+const outboxPK = "OUTBOX"
+
+type outboxEvent struct {
+	ID         string
+	DetailType string
+	Detail     json.RawMessage
+	CreatedAt  time.Time
+}
+
+func outboxSK(e outboxEvent) string {
+	return e.CreatedAt.UTC().Format(time.RFC3339Nano) + "#" + e.ID
+}
+
+type eventOutbox struct {
+	logger    page.Logger
+	dataStore page.DataStore
+	publisher page.EventPublisher
+	newID     func() string
+	now       func() time.Time
+}
+
+func newEventOutbox(logger page.Logger, dataStore page.DataStore, publisher page.EventPublisher) *eventOutbox {
+	return &eventOutbox{
+		logger:    logger,
+		dataStore: dataStore,
+		publisher: publisher,
+		newID:     func() string { return random.String(12) },
+		now:       time.Now,
+	}
+}
+
+// Publish stores e in the outbox then publishes it. A failure to publish is
+// logged rather than returned, as the event will be retried.
+func (o *eventOutbox) Publish(ctx context.Context, e event.Event) error {
+	item, err := o.item(e)
+	if err != nil {
+		return err
+	}
+
+	if err := o.dataStore.Create(ctx, outboxPK, outboxSK(item), item); err != nil {
+		return err
+	}
+
+	o.send(ctx, item)
+	return nil
+}
+
+// item returns e as it is stored in the outbox.
+func (o *eventOutbox) item(e event.Event) (outboxEvent, error) {
+	detail, err := json.Marshal(e)
+	if err != nil {
+		return outboxEvent{}, err
+	}
+
+	return outboxEvent{
+		ID:         o.newID(),
+		DetailType: e.DetailType(),
+		Detail:     detail,
+		CreatedAt:  o.now(),
+	}, nil
+}
+
+func (o *eventOutbox) send(ctx context.Context, item outboxEvent) bool {
+	if err := o.publisher.Publish(ctx, event.Raw{Type: item.DetailType, Detail: item.Detail}); err != nil {
+		o.logger.Print(fmt.Sprintf("unable to publish %s event %s: %s", item.DetailType, item.ID, err.Error()))
+		return false
+	}
+
+	if err := o.dataStore.Delete(ctx, outboxPK, outboxSK(item)); err != nil {
+		o.logger.Print(fmt.Sprintf("unable to remove published %s event %s from outbox: %s", item.DetailType, item.ID, err.Error()))
+		return false
+	}
+
+	return true
+}
+
+// PublishOutbox publishes the events left in the outbox because they could not
+// be published when they happened, oldest first.
+func PublishOutbox(ctx context.Context, logger page.Logger, dataStore page.DataStore, publisher page.EventPublisher) error {
+	outbox := newEventOutbox(logger, dataStore, publisher)
+
+	var items []outboxEvent
+	if err := dataStore.GetAll(ctx, outboxPK, &items); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, item := range items {
+		if !outbox.send(ctx, item) {
+			failed++
+		}
+	}
+
+	logger.Print(fmt.Sprintf("%d events published from outbox, %d failed", len(items)-failed, failed))
+
+	if failed > 0 {
+		return fmt.Errorf("unable to publish %d of %d events", failed, len(items))
+	}
+
+	return nil
+}
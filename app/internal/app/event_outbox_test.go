@@ -0,0 +1,160 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockEventPublisher struct {
+	mock.Mock
+}
+
+func (m *mockEventPublisher) Publish(ctx context.Context, e event.Event) error {
+	return m.Called(ctx, e).Error(0)
+}
+
+var (
+	testOutboxNow   = time.Date(2023, time.January, 2, 3, 4, 5, 6, time.UTC)
+	testOutboxSK    = "2023-01-02T03:04:05.000000006Z#event-id"
+	testOutboxEvent = outboxEvent{
+		ID:         "event-id",
+		DetailType: "lpa-signed",
+		Detail:     json.RawMessage(`{"lpaId":"lpa-id"}`),
+		CreatedAt:  testOutboxNow,
+	}
+	testRawEvent = event.Raw{Type: "lpa-signed", Detail: json.RawMessage(`{"lpaId":"lpa-id"}`)}
+)
+
+func newTestEventOutbox(logger *log.Logger, dataStore *mockDataStore, publisher *mockEventPublisher) *eventOutbox {
+	outbox := newEventOutbox(logger, dataStore, publisher)
+	outbox.newID = func() string { return "event-id" }
+	outbox.now = func() time.Time { return testOutboxNow }
+	return outbox
+}
+
+func TestEventOutboxPublish(t *testing.T) {
+	ctx := context.Background()
+
+	dataStore := &mockDataStore{}
+	dataStore.On("Create", ctx, "OUTBOX", testOutboxSK, testOutboxEvent).Return(nil)
+	dataStore.On("Delete", ctx, "OUTBOX", testOutboxSK).Return(nil)
+
+	publisher := &mockEventPublisher{}
+	publisher.On("Publish", ctx, testRawEvent).Return(nil)
+
+	var buf bytes.Buffer
+	err := newTestEventOutbox(log.New(&buf, "", 0), dataStore, publisher).Publish(ctx, event.LpaSigned{LpaID: "lpa-id"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "", buf.String())
+	mock.AssertExpectationsForObjects(t, dataStore, publisher)
+}
+
+func TestEventOutboxPublishWhenCreateErrors(t *testing.T) {
+	ctx := context.Background()
+
+	dataStore := &mockDataStore{}
+	dataStore.On("Create", ctx, "OUTBOX", testOutboxSK, testOutboxEvent).Return(expectedError)
+
+	err := newTestEventOutbox(log.New(&bytes.Buffer{}, "", 0), dataStore, nil).Publish(ctx, event.LpaSigned{LpaID: "lpa-id"})
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestEventOutboxPublishWhenPublishErrors(t *testing.T) {
+	ctx := context.Background()
+
+	dataStore := &mockDataStore{}
+	dataStore.On("Create", ctx, "OUTBOX", testOutboxSK, testOutboxEvent).Return(nil)
+
+	publisher := &mockEventPublisher{}
+	publisher.On("Publish", ctx, testRawEvent).Return(expectedError)
+
+	var buf bytes.Buffer
+	err := newTestEventOutbox(log.New(&buf, "", 0), dataStore, publisher).Publish(ctx, event.LpaSigned{LpaID: "lpa-id"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "unable to publish lpa-signed event event-id: err\n", buf.String())
+	mock.AssertExpectationsForObjects(t, dataStore, publisher)
+}
+
+func TestEventOutboxPublishWhenDeleteErrors(t *testing.T) {
+	ctx := context.Background()
+
+	dataStore := &mockDataStore{}
+	dataStore.On("Create", ctx, "OUTBOX", testOutboxSK, testOutboxEvent).Return(nil)
+	dataStore.On("Delete", ctx, "OUTBOX", testOutboxSK).Return(expectedError)
+
+	publisher := &mockEventPublisher{}
+	publisher.On("Publish", ctx, testRawEvent).Return(nil)
+
+	var buf bytes.Buffer
+	err := newTestEventOutbox(log.New(&buf, "", 0), dataStore, publisher).Publish(ctx, event.LpaSigned{LpaID: "lpa-id"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "unable to remove published lpa-signed event event-id from outbox: err\n", buf.String())
+	mock.AssertExpectationsForObjects(t, dataStore, publisher)
+}
+
+func TestPublishOutbox(t *testing.T) {
+	ctx := context.Background()
+	otherEvent := outboxEvent{
+		ID:         "other-id",
+		DetailType: "lpa-witnessed",
+		Detail:     json.RawMessage(`{"lpaId":"other-id"}`),
+		CreatedAt:  testOutboxNow,
+	}
+
+	dataStore := &mockDataStore{}
+	dataStore.On("GetAll", ctx, "OUTBOX").Return(nil, []outboxEvent{testOutboxEvent, otherEvent})
+	dataStore.On("Delete", ctx, "OUTBOX", testOutboxSK).Return(nil)
+	dataStore.On("Delete", ctx, "OUTBOX", "2023-01-02T03:04:05.000000006Z#other-id").Return(nil)
+
+	publisher := &mockEventPublisher{}
+	publisher.On("Publish", ctx, testRawEvent).Return(nil)
+	publisher.On("Publish", ctx, event.Raw{Type: "lpa-witnessed", Detail: json.RawMessage(`{"lpaId":"other-id"}`)}).Return(nil)
+
+	var buf bytes.Buffer
+	err := PublishOutbox(ctx, log.New(&buf, "", 0), dataStore, publisher)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "2 events published from outbox, 0 failed\n", buf.String())
+	mock.AssertExpectationsForObjects(t, dataStore, publisher)
+}
+
+func TestPublishOutboxWhenGetAllErrors(t *testing.T) {
+	ctx := context.Background()
+
+	dataStore := &mockDataStore{}
+	dataStore.On("GetAll", ctx, "OUTBOX").Return(expectedError)
+
+	err := PublishOutbox(ctx, log.New(&bytes.Buffer{}, "", 0), dataStore, nil)
+
+	assert.Equal(t, expectedError, err)
+}
+
+func TestPublishOutboxWhenPublishErrors(t *testing.T) {
+	ctx := context.Background()
+
+	dataStore := &mockDataStore{}
+	dataStore.On("GetAll", ctx, "OUTBOX").Return(nil, []outboxEvent{testOutboxEvent})
+
+	publisher := &mockEventPublisher{}
+	publisher.On("Publish", ctx, testRawEvent).Return(expectedError)
+
+	var buf bytes.Buffer
+	err := PublishOutbox(ctx, log.New(&buf, "", 0), dataStore, publisher)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "unable to publish lpa-signed event event-id: err\n0 events published from outbox, 1 failed\n", buf.String())
+	mock.AssertExpectationsForObjects(t, dataStore, publisher)
+}
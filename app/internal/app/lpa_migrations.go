@@ -0,0 +1,176 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/dynamo"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/encryption"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/pay"
+)
+
+// An lpaMigration upgrades an LPA from the previous schema version to Version.
+type lpaMigration struct {
+	Version     int
+	Description string
+	Migrate     func(*page.Lpa) error
+}
+
+// lpaMigrations are applied, in order, to LPAs stored with an older schema
+// version when they are read. LPAs stored before versioning have version 0. To
+// change the meaning of stored data add a migration to the end with the next
+// version; migrations that have been released must not be changed.
+var lpaMigrations = []lpaMigration{
+	{
+		Version:     1,
+		Description: "record the status of payments made before it was stored",
+		Migrate: func(lpa *page.Lpa) error {
+			if lpa.Tasks.PayForLpa.Completed() && lpa.PaymentDetails.PaymentId != "" && lpa.PaymentDetails.Status == "" {
+				lpa.PaymentDetails.Status = pay.StatusSuccess
+			}
+
+			return nil
+		},
+	},
+	{
+		Version:     2,
+		Description: "seal the signature and witness codes, and the legacy copy, with the personal data",
+		Migrate: func(lpa *page.Lpa) error {
+			return nil
+		},
+	},
+	{
+		Version:     3,
+		Description: "record when the links to the LPA were made",
+		Migrate: func(lpa *page.Lpa) error {
+			return nil
+		},
+	},
+	{
+		Version:     4,
+		Description: "key the links to the LPA by actor type as well as subject",
+		Migrate: func(lpa *page.Lpa) error {
+			return nil
+		},
+	},
+}
+
+// lpaSchemaVersion is the version stamped on LPAs when they are written.
+var lpaSchemaVersion = lpaMigrations[len(lpaMigrations)-1].Version
+
+// migrateLpa applies the migrations after version to lpa. LPAs from a later
+// version, written by a newer release, are left as they are.
+func migrateLpa(lpa *page.Lpa, version int) error {
+	for _, migration := range lpaMigrations {
+		if migration.Version <= version {
+			continue
+		}
+
+		if err := migration.Migrate(lpa); err != nil {
+			return fmt.Errorf("migrating lpa %s to version %d: %w", lpa.ID, migration.Version, err)
+		}
+	}
+
+	return nil
+}
+
+// upgradeRelated brings the items kept alongside the LPA in stored up to date,
+// for the migrations after the version it was stored at. Links are moved to
+// their new key before anything else writes them.
+func (s *lpaStore) upgradeRelated(ctx context.Context, stored storedLpa) error {
+	if stored.SchemaVersion < 2 {
+		if err := s.resealLegacy(ctx, stored.ID); err != nil {
+			return err
+		}
+	}
+
+	if stored.SchemaVersion < 4 {
+		if err := s.relinkAll(ctx, stored.ID); err != nil {
+			return err
+		}
+	}
+
+	if stored.SchemaVersion < 3 {
+		if err := s.orderLinks(ctx, stored.ID, stored.UpdatedAt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MigrateLpas rewrites every stored LPA with an older schema version at the
+// latest version, so that they no longer need migrating when read. Each rewrite
+// is recorded as an event. When dryRun is set nothing is written, and the
+// fields that would change are logged instead. An LPA that is changed while
+// running is skipped, as it will have been written at the latest version.
+func MigrateLpas(ctx context.Context, logger page.Logger, dataStore page.DataStore, keyProvider encryption.KeyProvider, dryRun bool) error {
+	store := &lpaStore{logger: logger, dataStore: dataStore, envelope: encryption.New(keyProvider)}
+	ctx = page.ContextWithSessionData(ctx, &page.SessionData{})
+
+	var scanned, upgraded, failed int
+	cursor := ""
+	for {
+		var items []storedLpa
+		next, err := dataStore.ScanByKeyPrefix(ctx, lpaPK(""), lpaSK(""), cursor, &items)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			scanned++
+			if item.SchemaVersion >= lpaSchemaVersion || !item.DeletedAt.IsZero() {
+				continue
+			}
+
+			changes, err := store.upgrade(ctx, item, dryRun)
+			if err == nil && !dryRun {
+				err = store.upgradeRelated(ctx, item)
+			}
+			if errors.As(err, &dynamo.ConflictError{}) {
+				logger.Print(fmt.Sprintf("skipped lpa %s as it changed while migrating", item.ID))
+				continue
+			}
+			if err != nil {
+				logger.Print(fmt.Sprintf("unable to migrate lpa %s from version %d: %s", item.ID, item.SchemaVersion, err.Error()))
+				failed++
+				continue
+			}
+
+			message := fmt.Sprintf("migrated lpa %s from version %d", item.ID, item.SchemaVersion)
+			if dryRun {
+				message = "would have " + message
+			}
+			if len(changes) > 0 {
+				fields := make([]string, len(changes))
+				for i, change := range changes {
+					fields[i] = change.Field
+				}
+				message += ", changing: " + strings.Join(fields, ", ")
+			}
+
+			logger.Print(message)
+			upgraded++
+		}
+
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if dryRun {
+		logger.Print(fmt.Sprintf("dry run: %d lpas scanned, %d would be migrated to version %d, %d failed", scanned, upgraded, lpaSchemaVersion, failed))
+	} else {
+		logger.Print(fmt.Sprintf("%d lpas scanned, %d migrated to version %d, %d failed", scanned, upgraded, lpaSchemaVersion, failed))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("unable to migrate %d of %d lpas", failed, upgraded+failed)
+	}
+
+	return nil
+}
@@ -0,0 +1,280 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/dynamo"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/encryption"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/pay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLpaMigrationsAreOrdered(t *testing.T) {
+	for i, migration := range lpaMigrations {
+		assert.Equal(t, i+1, migration.Version)
+		assert.NotEmpty(t, migration.Description)
+	}
+}
+
+func TestMigrateLpa(t *testing.T) {
+	testCases := map[string]struct {
+		lpa      *page.Lpa
+		version  int
+		expected *page.Lpa
+	}{
+		"paid before status stored": {
+			lpa:      &page.Lpa{Tasks: page.Tasks{PayForLpa: page.TaskCompleted}, PaymentDetails: page.PaymentDetails{PaymentId: "abc"}},
+			expected: &page.Lpa{Tasks: page.Tasks{PayForLpa: page.TaskCompleted}, PaymentDetails: page.PaymentDetails{PaymentId: "abc", Status: pay.StatusSuccess}},
+		},
+		"not paid": {
+			lpa:      &page.Lpa{PaymentDetails: page.PaymentDetails{PaymentId: "abc"}},
+			expected: &page.Lpa{PaymentDetails: page.PaymentDetails{PaymentId: "abc"}},
+		},
+		"no fee": {
+			lpa:      &page.Lpa{Tasks: page.Tasks{PayForLpa: page.TaskCompleted}},
+			expected: &page.Lpa{Tasks: page.Tasks{PayForLpa: page.TaskCompleted}},
+		},
+		"already at version": {
+			lpa:      &page.Lpa{Tasks: page.Tasks{PayForLpa: page.TaskCompleted}, PaymentDetails: page.PaymentDetails{PaymentId: "abc"}},
+			version:  1,
+			expected: &page.Lpa{Tasks: page.Tasks{PayForLpa: page.TaskCompleted}, PaymentDetails: page.PaymentDetails{PaymentId: "abc"}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Nil(t, migrateLpa(tc.lpa, tc.version))
+			assert.Equal(t, tc.expected, tc.lpa)
+		})
+	}
+}
+
+func TestLpaStoreGetMigrates(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "123", ActorType: page.ActorTypeDonor, Subject: "a-sub"})
+
+	dataStore := &mockDataStore{}
+	dataStore.On("Get", ctx, "LPA#123", "SUB#a-sub#donor").Return(nil, lpaLink{LpaID: "123", Sub: "a-sub", ActorType: page.ActorTypeDonor})
+	dataStore.On("Get", ctx, "LPA#123", "#METADATA#123").Return(nil, &page.Lpa{ID: "123", Tasks: page.Tasks{PayForLpa: page.TaskCompleted}, PaymentDetails: page.PaymentDetails{PaymentId: "abc"}})
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	lpa, err := lpaStore.Get(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, pay.StatusSuccess, lpa.PaymentDetails.Status)
+}
+
+func TestLpaStoreSealStampsSchemaVersion(t *testing.T) {
+	stored, err := (&lpaStore{envelope: testEnvelope}).seal(context.Background(), &page.Lpa{ID: "123"})
+	assert.Nil(t, err)
+	assert.Equal(t, lpaSchemaVersion, stored.SchemaVersion)
+}
+
+func TestMigrateLpas(t *testing.T) {
+	ctx := context.Background()
+	updatedAt := time.Date(2023, time.January, 2, 3, 4, 5, 6, time.UTC)
+	paid := page.Lpa{ID: "1", Version: 2, UpdatedAt: updatedAt, Tasks: page.Tasks{PayForLpa: page.TaskCompleted}, PaymentDetails: page.PaymentDetails{PaymentId: "abc"}}
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("ScanByKeyPrefix", mock.Anything, "LPA#", "#METADATA#", "").
+		Return(nil, []storedLpa{{Lpa: paid}, {Lpa: page.Lpa{ID: "2"}, SchemaVersion: lpaSchemaVersion}}, "next")
+	dataStore.
+		On("ScanByKeyPrefix", mock.Anything, "LPA#", "#METADATA#", "next").
+		Return(nil, []storedLpa{{Lpa: page.Lpa{ID: "3", Version: 1}}}, "")
+	dataStore.
+		On("WriteTransaction", mock.Anything, transactionOf(
+			dynamo.TransactionWrite{Kind: dynamo.TransactionPutVersioned, PK: "LPA#1", SK: "#METADATA#1", Version: 3, Value: func(v interface{}) bool {
+				stored := v.(storedLpa)
+				return stored.SchemaVersion == lpaSchemaVersion && stored.PaymentDetails.Status == pay.StatusSuccess
+			}},
+			dynamo.TransactionWrite{Kind: dynamo.TransactionPut, PK: "LPA#1", SK: "EVENT#0000000003", Value: func(v interface{}) bool {
+				return assert.Equal(t, []page.LpaChange{{Field: "PaymentDetails", Value: `{"PaymentReference":"","PaymentId":"abc","Amount":0,"Status":"success","StatusHistory":null,"SettledAt":"0001-01-01T00:00:00Z"}`}}, v.(storedLpaEvent).Changes)
+			}},
+		)).
+		Return(nil)
+	dataStore.
+		On("GetAllByKeyPrefix", mock.Anything, "LPA#1", "SUB#").
+		Return(nil, []lpaLink{{LpaID: "1", Sub: "a-sub", ActorType: page.ActorTypeDonor}, {LpaID: "1", Sub: "b-sub", ActorType: page.ActorTypeAttorney, LinkedAt: updatedAt.Add(time.Hour)}})
+	dataStore.
+		On("Get", mock.Anything, legacyPK("a-sub"), "1").
+		Return(nil, storedLpa{Lpa: page.Lpa{ID: "1", You: actor.Person{FirstNames: "John"}, SignatureCode: "1234"}})
+	dataStore.
+		On("Put", mock.Anything, legacyPK("a-sub"), "1", mock.MatchedBy(func(stored storedLpa) bool {
+			return stored.Personal != nil && stored.You == actor.Person{} && stored.SignatureCode == "" &&
+				openLpa(stored).You.FirstNames == "John"
+		})).
+		Return(nil)
+	dataStore.
+		On("Get", mock.Anything, "LPA#1", "SUB#a-sub").
+		Return(nil, lpaLink{LpaID: "1", Sub: "a-sub", ActorType: page.ActorTypeDonor})
+	dataStore.
+		On("Get", mock.Anything, "LPA#1", "SUB#b-sub").
+		Return(nil, lpaLink{})
+	dataStore.
+		On("WriteTransaction", mock.Anything, transactionOf(
+			dynamo.TransactionWrite{Kind: dynamo.TransactionPut, PK: "LPA#1", SK: "SUB#a-sub#donor", Value: lpaLink{LpaID: "1", Sub: "a-sub", ActorType: page.ActorTypeDonor}},
+			dynamo.TransactionWrite{Kind: dynamo.TransactionDelete, PK: "LPA#1", SK: "SUB#a-sub"},
+		)).
+		Return(nil)
+	dataStore.
+		On("Put", mock.Anything, "LPA#1", "SUB#a-sub#donor", lpaLink{LpaID: "1", Sub: "a-sub", ActorType: page.ActorTypeDonor, LinkedAt: updatedAt}).
+		Return(nil)
+	dataStore.
+		On("WriteTransaction", mock.Anything, transactionOf(
+			dynamo.TransactionWrite{Kind: dynamo.TransactionPutVersioned, PK: "LPA#3", SK: "#METADATA#3", Version: 2, Value: mock.Anything},
+			dynamo.TransactionWrite{Kind: dynamo.TransactionPut, PK: "LPA#3", SK: "EVENT#0000000002", Value: mock.Anything},
+		)).
+		Return(dynamo.ConflictError{})
+
+	var buf bytes.Buffer
+	err := MigrateLpas(ctx, log.New(&buf, "", 0), dataStore, testKeyProvider, false)
+	assert.Nil(t, err)
+	assert.Equal(t, `migrated lpa 1 from version 0, changing: PaymentDetails
+skipped lpa 3 as it changed while migrating
+3 lpas scanned, 1 migrated to version 4, 0 failed
+`, buf.String())
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestMigrateLpasWhenDryRun(t *testing.T) {
+	ctx := context.Background()
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("ScanByKeyPrefix", mock.Anything, "LPA#", "#METADATA#", "").
+		Return(nil, []storedLpa{{Lpa: page.Lpa{ID: "1", Tasks: page.Tasks{PayForLpa: page.TaskCompleted}, PaymentDetails: page.PaymentDetails{PaymentId: "abc"}}}, {Lpa: page.Lpa{ID: "2"}}}, "")
+
+	var buf bytes.Buffer
+	err := MigrateLpas(ctx, log.New(&buf, "", 0), dataStore, testKeyProvider, true)
+	assert.Nil(t, err)
+	assert.Equal(t, `would have migrated lpa 1 from version 0, changing: PaymentDetails
+would have migrated lpa 2 from version 0
+dry run: 2 lpas scanned, 2 would be migrated to version 4, 0 failed
+`, buf.String())
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestMigrateLpasWhenResealLegacyErrors(t *testing.T) {
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("ScanByKeyPrefix", mock.Anything, "LPA#", "#METADATA#", "").
+		Return(nil, []storedLpa{{Lpa: page.Lpa{ID: "1"}, SchemaVersion: 1}}, "")
+	dataStore.
+		On("WriteTransaction", mock.Anything, mock.Anything).
+		Return(nil)
+	dataStore.
+		On("GetAllByKeyPrefix", mock.Anything, "LPA#1", "SUB#").
+		Return(expectedError)
+
+	var buf bytes.Buffer
+	err := MigrateLpas(context.Background(), log.New(&buf, "", 0), dataStore, testKeyProvider, false)
+	assert.NotNil(t, err)
+	assert.Contains(t, buf.String(), "unable to migrate lpa 1 from version 1: err")
+}
+
+func TestMigrateLpasWhenOrderLinksErrors(t *testing.T) {
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("ScanByKeyPrefix", mock.Anything, "LPA#", "#METADATA#", "").
+		Return(nil, []storedLpa{{Lpa: page.Lpa{ID: "1"}, SchemaVersion: 2}}, "")
+	dataStore.
+		On("WriteTransaction", mock.Anything, mock.Anything).
+		Return(nil)
+	dataStore.
+		On("GetAllByKeyPrefix", mock.Anything, "LPA#1", "SUB#").
+		Return(nil, []lpaLink{{LpaID: "1", Sub: "a-sub", ActorType: page.ActorTypeDonor}})
+	dataStore.
+		On("Get", mock.Anything, "LPA#1", "SUB#a-sub").
+		Return(nil, lpaLink{})
+	dataStore.
+		On("Put", mock.Anything, "LPA#1", "SUB#a-sub#donor", mock.Anything).
+		Return(expectedError)
+
+	var buf bytes.Buffer
+	err := MigrateLpas(context.Background(), log.New(&buf, "", 0), dataStore, testKeyProvider, false)
+	assert.NotNil(t, err)
+	assert.Contains(t, buf.String(), "unable to migrate lpa 1 from version 2: err")
+}
+
+func TestMigrateLpasWhenRelinkErrors(t *testing.T) {
+	testCases := map[string]func(*mockDataStore){
+		"links": func(dataStore *mockDataStore) {
+			dataStore.
+				On("GetAllByKeyPrefix", mock.Anything, "LPA#1", "SUB#").
+				Return(expectedError)
+		},
+		"legacy link": func(dataStore *mockDataStore) {
+			dataStore.
+				On("GetAllByKeyPrefix", mock.Anything, "LPA#1", "SUB#").
+				Return(nil, []lpaLink{{LpaID: "1", Sub: "a-sub", ActorType: page.ActorTypeDonor}})
+			dataStore.
+				On("Get", mock.Anything, "LPA#1", "SUB#a-sub").
+				Return(expectedError)
+		},
+		"relink": func(dataStore *mockDataStore) {
+			dataStore.
+				On("GetAllByKeyPrefix", mock.Anything, "LPA#1", "SUB#").
+				Return(nil, []lpaLink{{LpaID: "1", Sub: "a-sub", ActorType: page.ActorTypeDonor}})
+			dataStore.
+				On("Get", mock.Anything, "LPA#1", "SUB#a-sub").
+				Return(nil, lpaLink{LpaID: "1", Sub: "a-sub", ActorType: page.ActorTypeDonor})
+			dataStore.
+				On("WriteTransaction", mock.Anything, transactionOf(
+					dynamo.TransactionWrite{Kind: dynamo.TransactionPut, PK: "LPA#1", SK: "SUB#a-sub#donor", Value: mock.Anything},
+					dynamo.TransactionWrite{Kind: dynamo.TransactionDelete, PK: "LPA#1", SK: "SUB#a-sub"},
+				)).
+				Return(expectedError)
+		},
+	}
+
+	for name, setup := range testCases {
+		t.Run(name, func(t *testing.T) {
+			dataStore := &mockDataStore{}
+			dataStore.
+				On("ScanByKeyPrefix", mock.Anything, "LPA#", "#METADATA#", "").
+				Return(nil, []storedLpa{{Lpa: page.Lpa{ID: "1"}, SchemaVersion: 3}}, "")
+			setup(dataStore)
+			dataStore.
+				On("WriteTransaction", mock.Anything, mock.Anything).
+				Return(nil)
+
+			var buf bytes.Buffer
+			err := MigrateLpas(context.Background(), log.New(&buf, "", 0), dataStore, testKeyProvider, false)
+			assert.NotNil(t, err)
+			assert.Contains(t, buf.String(), "unable to migrate lpa 1 from version 3: err")
+		})
+	}
+}
+
+func TestMigrateLpasWhenScanErrors(t *testing.T) {
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("ScanByKeyPrefix", mock.Anything, "LPA#", "#METADATA#", "").
+		Return(expectedError, nil, "")
+
+	err := MigrateLpas(context.Background(), log.New(&bytes.Buffer{}, "", 0), dataStore, testKeyProvider, false)
+	assert.Equal(t, expectedError, err)
+}
+
+func TestMigrateLpasWhenUpgradeErrors(t *testing.T) {
+	otherKeys, _ := encryption.NewLocalKeyProvider(bytes.Repeat([]byte{2}, 32))
+	sealed, _ := (&lpaStore{envelope: encryption.New(otherKeys)}).seal(context.Background(), &page.Lpa{ID: "1"})
+	sealed.SchemaVersion = 0
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("ScanByKeyPrefix", mock.Anything, "LPA#", "#METADATA#", "").
+		Return(nil, []storedLpa{sealed}, "")
+
+	var buf bytes.Buffer
+	err := MigrateLpas(context.Background(), log.New(&buf, "", 0), dataStore, testKeyProvider, false)
+	assert.NotNil(t, err)
+	assert.Contains(t, buf.String(), "unable to migrate lpa 1 from version 0")
+}
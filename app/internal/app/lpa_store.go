@@ -2,56 +2,951 @@ package app
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
-	"strconv"
+	"fmt"
+	"reflect"
 	"time"
 
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/dynamo"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/encryption"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/event"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/identity"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
 	"golang.org/x/exp/slices"
 )
 
+// LPAs are stored in their own partition, keyed by LPA#<id>. Alongside the LPA
+// are link items (SK SUB#<sub>#<actor type>) for each role a person has on it,
+// and events (SK EVENT#<version>) recording each change. Looking up link items
+// by sort key gives the LPAs a person is involved in as a type of actor. Links
+// made before the actor type was part of their key (SK SUB#<sub>) are moved,
+// see relinkLegacy, but can still be read until then.
+//
+// The fields of an LPA, and of its events, that identify people are stored
+// encrypted, see storedLpa.
+
+var (
+	errNotLinked = errors.New("lpaStore: subject is not linked to lpa")
+	errReadOnly  = errors.New("lpaStore: support users can not change lpas")
+)
+
+// maxCreateAttempts is the number of references Create will try before giving
+// up, in case a generated reference is already in use.
+const maxCreateAttempts = 5
+
+// An lpaLink gives a subject access to an LPA as a type of actor. Links are
+// paged through most recently linked first, which for a donor is the order in
+// which their LPAs were started.
+type lpaLink struct {
+	LpaID     string
+	Sub       string
+	ActorType page.ActorType
+	LinkedAt  time.Time
+}
+
+func (l lpaLink) OrderedAt() time.Time {
+	return l.LinkedAt
+}
+
+// storedLpa is how an LPA is written, with its personal data removed and
+// sealed in Personal. LPAs written before encryption have no Personal, so are
+// read as they are. SchemaVersion is the version of lpaMigrations the LPA was
+// written at. DeletedAt is set when Delete starts removing the LPA, after which
+// it is read as if it has already gone.
+type storedLpa struct {
+	page.Lpa
+	SchemaVersion int
+	Personal      *encryption.Sealed
+	DeletedAt     time.Time
+}
+
+// storedLpaEvent is how an event is written, with the changes to personal data
+// removed and sealed in Personal.
+type storedLpaEvent struct {
+	page.LpaEvent
+	Personal *encryption.Sealed
+}
+
+// lpaPersonalData holds the fields of an LPA that identify people, and the
+// codes used to sign and witness it.
+type lpaPersonalData struct {
+	You                                actor.Person
+	Attorneys                          actor.Attorneys
+	ReplacementAttorneys               actor.Attorneys
+	CertificateProvider                actor.CertificateProvider
+	PeopleToNotify                     actor.PeopleToNotify
+	YotiUserData                       identity.UserData
+	OneLoginUserData                   identity.UserData
+	CertificateProviderUserData        identity.UserData
+	AttorneyProvidedDetails            map[string]page.AttorneyProvidedDetails
+	ReplacementAttorneyProvidedDetails map[string]page.AttorneyProvidedDetails
+	SignatureCode                      string
+	EnteredSignatureCode               string
+	WitnessCode                        page.WitnessCode
+}
+
+// personalLpaFields are the names of the fields in lpaPersonalData, so that
+// changes to them can be found.
+var personalLpaFields = func() map[string]bool {
+	fields := map[string]bool{}
+	t := reflect.TypeOf(lpaPersonalData{})
+	for i := 0; i < t.NumField(); i++ {
+		fields[t.Field(i).Name] = true
+	}
+	return fields
+}()
+
 type lpaStore struct {
-	dataStore page.DataStore
-	randomInt func(int) int
+	logger       page.Logger
+	dataStore    page.DataStore
+	envelope     *encryption.Envelope
+	newReference func() string
+	outbox       *eventOutbox
 }
 
+// Create stores a new LPA with a unique reference and links it to the current
+// subject as its donor. The LPA, link and first event are written together.
 func (s *lpaStore) Create(ctx context.Context) (*page.Lpa, error) {
-	lpa := &page.Lpa{
-		ID: "10" + strconv.Itoa(s.randomInt(100000)),
+	data := page.SessionDataFromContext(ctx)
+
+	var lpa *page.Lpa
+	for attempt := 1; ; attempt++ {
+		lpa = &page.Lpa{
+			ID:        s.newReference(),
+			UpdatedAt: time.Now(),
+			Version:   1,
+		}
+
+		stored, err := s.seal(ctx, lpa)
+		if err != nil {
+			return lpa, err
+		}
+
+		changes, err := page.DiffLpa(&page.Lpa{}, lpa)
+		if err != nil {
+			return lpa, err
+		}
+
+		event, err := s.sealEvent(ctx, lpa, changes)
+		if err != nil {
+			return lpa, err
+		}
+
+		err = s.dataStore.WriteTransaction(ctx, dynamo.NewTransaction().
+			Create(lpaPK(lpa.ID), lpaSK(lpa.ID), stored).
+			Put(lpaPK(lpa.ID), subSK(data.Subject, page.ActorTypeDonor), newLpaLink(lpa.ID, data.Subject, page.ActorTypeDonor, lpa.UpdatedAt)).
+			Put(lpaPK(lpa.ID), eventSK(lpa.Version), event))
+		if err == nil {
+			return lpa, nil
+		}
+
+		if !errors.As(err, &dynamo.ConflictError{}) {
+			return lpa, err
+		}
+
+		if attempt == maxCreateAttempts {
+			return lpa, errors.New("lpaStore.Create could not find an unused reference")
+		}
+	}
+}
+
+// GetPage returns a page of the LPAs that the current subject is linked to,
+// most recently linked first. Links are read a page at a time until enough LPAs
+// match the query, so a page is only short when it is the last. A cursor that
+// was not returned by a previous page gives dynamo.ErrInvalidCursor.
+func (s *lpaStore) GetPage(ctx context.Context, query page.LpaQuery) (page.LpaPage, error) {
+	if query.Limit < 1 {
+		return page.LpaPage{}, errors.New("lpaStore.GetPage requires a Limit")
 	}
 
-	err := s.Put(ctx, lpa)
+	// Moving what was stored under a previous model is tried again on the next
+	// read if it fails, so the LPAs that have been moved are still listed.
+	if query.Cursor == "" {
+		if err := s.relinkLegacy(ctx); err != nil {
+			s.logger.Print(fmt.Sprintf("unable to relink legacy links: %s", err.Error()))
+		}
+	}
+
+	if query.ActorType == page.ActorTypeDonor && query.Cursor == "" {
+		if err := s.migrateLegacy(ctx); err != nil {
+			s.logger.Print(fmt.Sprintf("unable to migrate legacy lpas: %s", err.Error()))
+		}
+	}
+
+	sk := subSK(page.SessionDataFromContext(ctx).Subject, query.ActorType)
+	result := page.LpaPage{Next: query.Cursor}
+
+	for {
+		var links []lpaLink
+		next, err := s.dataStore.GetPageBySK(ctx, sk, result.Next, query.Limit-len(result.Lpas), &links)
+		if err != nil {
+			return page.LpaPage{}, err
+		}
 
-	return lpa, err
+		for _, link := range links {
+			lpa, err := s.get(ctx, link.LpaID)
+			if err != nil {
+				return page.LpaPage{}, err
+			}
+
+			if lpa.ID == "" || (query.Status != "" && lpa.Status() != query.Status) {
+				continue
+			}
+
+			result.Lpas = append(result.Lpas, lpa)
+		}
+
+		result.Next = next
+		if next == "" || len(result.Lpas) >= query.Limit {
+			break
+		}
+	}
+
+	return result, nil
 }
 
-func (s *lpaStore) GetAll(ctx context.Context) ([]*page.Lpa, error) {
+// GetAllAs returns the LPAs that the current subject is linked to as the given
+// type of actor, most recently updated first.
+func (s *lpaStore) GetAllAs(ctx context.Context, actorType page.ActorType) ([]*page.Lpa, error) {
+	if err := s.relinkLegacy(ctx); err != nil {
+		s.logger.Print(fmt.Sprintf("unable to relink legacy links: %s", err.Error()))
+	}
+
+	links, err := s.links(ctx, actorType)
+	if err != nil {
+		return nil, err
+	}
+
 	var lpas []*page.Lpa
-	err := s.dataStore.GetAll(ctx, page.SessionDataFromContext(ctx).SessionID, &lpas)
+	for _, link := range links {
+		lpa, err := s.get(ctx, link.LpaID)
+		if err != nil {
+			return nil, err
+		}
+
+		lpas = append(lpas, lpa)
+	}
 
 	slices.SortFunc(lpas, func(a, b *page.Lpa) bool {
 		return a.UpdatedAt.After(b.UpdatedAt)
 	})
 
-	return lpas, err
+	return lpas, nil
 }
 
+// Get returns the LPA in the session data, as long as the current subject is
+// linked to it as the current type of actor. Support users can read any LPA.
 func (s *lpaStore) Get(ctx context.Context) (*page.Lpa, error) {
 	data := page.SessionDataFromContext(ctx)
 	if data.LpaID == "" {
 		return nil, errors.New("lpaStore.Get requires LpaID to retrieve")
 	}
 
-	var lpa page.Lpa
-	if err := s.dataStore.Get(ctx, data.SessionID, data.LpaID, &lpa); err != nil {
+	if data.ActorType == page.ActorTypeSupport {
+		return s.get(ctx, data.LpaID)
+	}
+
+	link, _, err := getLpaLink(ctx, s.dataStore, data.LpaID, data.Subject, data.ActorType)
+	if err != nil {
 		return nil, err
 	}
 
-	return &lpa, nil
+	if link.LpaID == "" {
+		return nil, errNotLinked
+	}
+
+	return s.get(ctx, data.LpaID)
 }
 
+// Put writes lpa, as long as it has not been changed, or deleted, since it was
+// read, along with an event recording the changes made to it. A withdrawn LPA
+// can not be written, so page.ErrLpaWithdrawn is returned.
 func (s *lpaStore) Put(ctx context.Context, lpa *page.Lpa) error {
+	return s.PutWithEvents(ctx, lpa, nil)
+}
+
+// PutWithEvents is Put, with events also added to the outbox in the same
+// transaction. Once written the events are published, see eventOutbox.
+func (s *lpaStore) PutWithEvents(ctx context.Context, lpa *page.Lpa, events []event.Event) error {
+	if len(events) > 0 && s.outbox == nil {
+		return errors.New("lpaStore.PutWithEvents requires an outbox")
+	}
+
+	if data := page.SessionDataFromContext(ctx); data != nil && data.ActorType == page.ActorTypeSupport {
+		return errReadOnly
+	}
+
+	previous, err := s.get(ctx, lpa.ID)
+	if err != nil {
+		return err
+	}
+
+	// The LPA has been deleted since it was read, so is not written back.
+	if previous.ID == "" {
+		return dynamo.ConflictError{PK: lpaPK(lpa.ID), SK: lpaSK(lpa.ID)}
+	}
+
+	if previous.Status() == page.LpaStatusWithdrawn {
+		return page.ErrLpaWithdrawn
+	}
+
+	changes, err := page.DiffLpa(previous, lpa)
+	if err != nil {
+		return err
+	}
+
+	items := make([]outboxEvent, len(events))
+	for i, e := range events {
+		if items[i], err = s.outbox.item(e); err != nil {
+			return err
+		}
+	}
+
 	lpa.UpdatedAt = time.Now()
+	lpa.Version++
+
+	if err := s.putWithEvent(ctx, lpa, changes, items...); err != nil {
+		lpa.Version--
+		return err
+	}
+
+	for _, item := range items {
+		s.outbox.send(ctx, item)
+	}
+
+	return nil
+}
+
+// Delete removes the LPA in the session data along with everything stored
+// for it. The LPA is first marked as deleted, with the links to it removed in
+// the same transaction, so that it is no longer listed for anyone; the rest is
+// then removed, with the LPA itself last, so that if that fails it can be
+// retried, see PurgeExpired. Only the donor, or a job which has no subject,
+// can delete an LPA. As nothing is kept, page.ErrLpaNotDeletable is returned
+// unless the LPA can be deleted and has no payment in-flight.
+func (s *lpaStore) Delete(ctx context.Context) error {
+	data := page.SessionDataFromContext(ctx)
+	if data.LpaID == "" {
+		return errors.New("lpaStore.Delete requires LpaID to delete")
+	}
+
+	if data.ActorType == page.ActorTypeSupport {
+		return errReadOnly
+	}
+
+	if data.Subject != "" {
+		link, _, err := getLpaLink(ctx, s.dataStore, data.LpaID, data.Subject, page.ActorTypeDonor)
+		if err != nil {
+			return err
+		}
+
+		if link.LpaID == "" {
+			return errNotLinked
+		}
+	}
+
+	pk := lpaPK(data.LpaID)
+
+	var stored storedLpa
+	if err := s.dataStore.Get(ctx, pk, lpaSK(data.LpaID), &stored); err != nil {
+		return err
+	}
+
+	if stored.ID == "" {
+		return nil
+	}
+
+	if stored.DeletedAt.IsZero() {
+		if err := s.markDeleted(ctx, stored); err != nil {
+			return err
+		}
+	}
+
+	var events []page.LpaEvent
+	if err := s.dataStore.GetAllByKeyPrefix(ctx, pk, "EVENT#", &events); err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		if err := s.dataStore.Delete(ctx, pk, eventSK(event.Version)); err != nil {
+			return err
+		}
+	}
+
+	var shareCodes []shareCodeLink
+	if err := s.dataStore.GetAllByKeyPrefix(ctx, pk, "SHARECODE#", &shareCodes); err != nil {
+		return err
+	}
+
+	for _, link := range shareCodes {
+		if err := s.dataStore.Delete(ctx, shareCodePK(link.ShareCode), shareCodeSK(link.ShareCode)); err != nil {
+			return err
+		}
+
+		if err := s.dataStore.Delete(ctx, pk, shareCodePK(link.ShareCode)); err != nil {
+			return err
+		}
+	}
+
+	var messages []page.Message
+	if err := s.dataStore.GetAllByKeyPrefix(ctx, pk, "MESSAGE#", &messages); err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		if err := s.dataStore.Delete(ctx, pk, messageSK(message.ID)); err != nil {
+			return err
+		}
+	}
+
+	for _, sk := range []string{inFlightPaymentSK, retentionWarningSK, pendingNoticesSK, lpaSK(data.LpaID)} {
+		if err := s.dataStore.Delete(ctx, pk, sk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// markDeleted checks that the LPA in stored can be deleted, then marks it as
+// deleted and removes the links to it. The LPA is written as a new version, so
+// is not marked if it has been changed since it was read.
+func (s *lpaStore) markDeleted(ctx context.Context, stored storedLpa) error {
+	lpa, err := s.open(ctx, stored)
+	if err != nil {
+		return err
+	}
+
+	if err := migrateLpa(lpa, stored.SchemaVersion); err != nil {
+		return err
+	}
+
+	pk := lpaPK(stored.ID)
+
+	var payment page.InFlightPayment
+	if err := s.dataStore.Get(ctx, pk, inFlightPaymentSK, &payment); err != nil {
+		return err
+	}
+
+	if !lpa.CanBeDeleted() || payment.PaymentID != "" {
+		return page.ErrLpaNotDeletable
+	}
+
+	var links []lpaLink
+	if err := s.dataStore.GetAllByKeyPrefix(ctx, pk, "SUB#", &links); err != nil {
+		return err
+	}
+
+	stored.DeletedAt = time.Now()
+	stored.Version++
+
+	transaction := dynamo.NewTransaction().
+		PutVersioned(pk, lpaSK(stored.ID), stored, stored.Version)
+
+	for _, link := range links {
+		transaction.
+			Delete(pk, subSK(link.Sub, link.ActorType)).
+			Delete(pk, legacySubSK(link.Sub))
+
+		if link.ActorType == page.ActorTypeDonor {
+			transaction.Delete(legacyPK(link.Sub), stored.ID)
+		}
+	}
+
+	return s.dataStore.WriteTransaction(ctx, transaction)
+}
+
+// Link gives the current subject access to the LPA in the session data as the
+// current type of actor. Callers must have already checked that the subject
+// should be allowed access, for example by redeeming a share code.
+func (s *lpaStore) Link(ctx context.Context) error {
+	data := page.SessionDataFromContext(ctx)
+	if data.LpaID == "" || data.Subject == "" {
+		return errors.New("lpaStore.Link requires LpaID and Subject")
+	}
+
+	if data.ActorType == page.ActorTypeSupport {
+		return errReadOnly
+	}
+
+	return s.dataStore.Put(ctx, lpaPK(data.LpaID), subSK(data.Subject, data.ActorType), newLpaLink(data.LpaID, data.Subject, data.ActorType, time.Now()))
+}
+
+// History returns the events recorded for the LPA, oldest first.
+func (s *lpaStore) History(ctx context.Context) ([]page.LpaEvent, error) {
+	data := page.SessionDataFromContext(ctx)
+	if data.LpaID == "" {
+		return nil, errors.New("lpaStore.History requires LpaID to retrieve")
+	}
+
+	var stored []storedLpaEvent
+	if err := s.dataStore.GetAllByKeyPrefix(ctx, lpaPK(data.LpaID), "EVENT#", &stored); err != nil {
+		return nil, err
+	}
+
+	events := make([]page.LpaEvent, len(stored))
+	for i, event := range stored {
+		events[i] = event.LpaEvent
+
+		if event.Personal != nil {
+			var changes []page.LpaChange
+			if err := s.envelope.Open(ctx, event.Personal, &changes); err != nil {
+				return nil, err
+			}
+
+			events[i].Changes = append(events[i].Changes, changes...)
+			slices.SortFunc(events[i].Changes, func(a, b page.LpaChange) bool {
+				return a.Field < b.Field
+			})
+		}
+	}
+
+	slices.SortFunc(events, func(a, b page.LpaEvent) bool {
+		return a.Version < b.Version
+	})
+
+	return events, nil
+}
+
+func (s *lpaStore) links(ctx context.Context, actorType page.ActorType) ([]lpaLink, error) {
+	var links []lpaLink
+	err := s.dataStore.GetAllBySK(ctx, subSK(page.SessionDataFromContext(ctx).Subject, actorType), &links)
+
+	return links, err
+}
+
+// getLpaLink returns the link giving sub access to the LPA as actorType, and
+// the sort key it is stored under. A link made before the actor type was part
+// of its key is returned when it is for the same type of actor. When there is
+// no link an empty lpaLink is returned.
+func getLpaLink(ctx context.Context, dataStore page.DataStore, lpaID, sub string, actorType page.ActorType) (lpaLink, string, error) {
+	var link lpaLink
+	if err := dataStore.Get(ctx, lpaPK(lpaID), subSK(sub, actorType), &link); err != nil {
+		return lpaLink{}, "", err
+	}
+	if link.LpaID != "" {
+		return link, subSK(sub, actorType), nil
+	}
+
+	if err := dataStore.Get(ctx, lpaPK(lpaID), legacySubSK(sub), &link); err != nil {
+		return lpaLink{}, "", err
+	}
+	if link.LpaID == "" || link.ActorType != actorType {
+		return lpaLink{}, "", nil
+	}
+
+	return link, legacySubSK(sub), nil
+}
+
+// relinkLegacy moves the current subject's links that were made before the
+// actor type was part of their key, so that they are found when looking up the
+// LPAs they are involved in as each type of actor.
+func (s *lpaStore) relinkLegacy(ctx context.Context) error {
+	var links []lpaLink
+	if err := s.dataStore.GetAllBySK(ctx, legacySubSK(page.SessionDataFromContext(ctx).Subject), &links); err != nil {
+		return err
+	}
+
+	for _, link := range links {
+		if err := s.relink(ctx, link); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// relinkAll moves the links to the LPA that were made before the actor type was
+// part of their key.
+func (s *lpaStore) relinkAll(ctx context.Context, lpaID string) error {
+	var links []lpaLink
+	if err := s.dataStore.GetAllByKeyPrefix(ctx, lpaPK(lpaID), "SUB#", &links); err != nil {
+		return err
+	}
+
+	relinked := map[string]bool{}
+	for _, link := range links {
+		if relinked[link.Sub] {
+			continue
+		}
+		relinked[link.Sub] = true
+
+		var legacy lpaLink
+		if err := s.dataStore.Get(ctx, lpaPK(lpaID), legacySubSK(link.Sub), &legacy); err != nil {
+			return err
+		}
+		if legacy.LpaID == "" {
+			continue
+		}
+
+		if err := s.relink(ctx, legacy); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *lpaStore) relink(ctx context.Context, link lpaLink) error {
+	return s.dataStore.WriteTransaction(ctx, dynamo.NewTransaction().
+		Put(lpaPK(link.LpaID), subSK(link.Sub, link.ActorType), link).
+		Delete(lpaPK(link.LpaID), legacySubSK(link.Sub)))
+}
+
+// sealEvent returns the event recording changes to lpa as it should be
+// written, with the changes to personal data encrypted. Changes made without
+// session data, by a job, are recorded as made by the system.
+func (s *lpaStore) sealEvent(ctx context.Context, lpa *page.Lpa, changes []page.LpaChange) (storedLpaEvent, error) {
+	event := storedLpaEvent{
+		LpaEvent: page.LpaEvent{
+			LpaID:     lpa.ID,
+			Version:   lpa.Version,
+			ActorType: page.ActorTypeSystem,
+			Time:      lpa.UpdatedAt,
+		},
+	}
+
+	if data := page.SessionDataFromContext(ctx); data != nil {
+		event.ActorType = data.ActorType
+		event.Subject = data.Subject
+	}
+
+	var personalChanges []page.LpaChange
+	for _, change := range changes {
+		if personalLpaFields[change.Field] {
+			personalChanges = append(personalChanges, change)
+		} else {
+			event.Changes = append(event.Changes, change)
+		}
+	}
+
+	if len(personalChanges) > 0 {
+		sealed, err := s.envelope.Seal(ctx, personalChanges)
+		if err != nil {
+			return storedLpaEvent{}, err
+		}
+		event.Personal = sealed
+	}
+
+	return event, nil
+}
+
+func newLpaLink(lpaID, sub string, actorType page.ActorType, linkedAt time.Time) lpaLink {
+	return lpaLink{
+		LpaID:     lpaID,
+		Sub:       sub,
+		ActorType: actorType,
+		LinkedAt:  linkedAt,
+	}
+}
+
+// migrateLegacy moves any LPAs the donor has stored under the previous model,
+// where the partition key was the base64 encoded subject, into their own
+// partition and links them to the donor. The legacy items are left in place,
+// until the LPA is deleted, but are sealed if they were written before
+// encryption.
+func (s *lpaStore) migrateLegacy(ctx context.Context) error {
+	sub := page.SessionDataFromContext(ctx).Subject
+
+	var legacy []storedLpa
+	if err := s.dataStore.GetAll(ctx, legacyPK(sub), &legacy); err != nil {
+		return err
+	}
+	if len(legacy) == 0 {
+		return nil
+	}
+
+	links, err := s.links(ctx, page.ActorTypeDonor)
+	if err != nil {
+		return err
+	}
+
+	linked := map[string]bool{}
+	for _, link := range links {
+		linked[link.LpaID] = true
+	}
+
+	for _, item := range legacy {
+		if linked[item.ID] && item.Personal != nil {
+			continue
+		}
+
+		lpa, err := s.open(ctx, item)
+		if err != nil {
+			return err
+		}
+
+		if err := migrateLpa(lpa, item.SchemaVersion); err != nil {
+			return err
+		}
+
+		stored, err := s.seal(ctx, lpa)
+		if err != nil {
+			return err
+		}
+
+		if linked[lpa.ID] {
+			if err := s.dataStore.Put(ctx, legacyPK(sub), lpa.ID, stored); err != nil {
+				return err
+			}
+			continue
+		}
+
+		transaction := dynamo.NewTransaction().
+			Put(lpaPK(lpa.ID), lpaSK(lpa.ID), stored).
+			Put(lpaPK(lpa.ID), subSK(sub, page.ActorTypeDonor), newLpaLink(lpa.ID, sub, page.ActorTypeDonor, lpa.UpdatedAt))
+		if item.Personal == nil {
+			transaction.Put(legacyPK(sub), lpa.ID, stored)
+		}
+
+		if err := s.dataStore.WriteTransaction(ctx, transaction); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// orderLinks sets when the links to the LPA were made, for those stored before
+// it was recorded, so that they can be paged through. As the time is not
+// known, the time the LPA was last updated is used.
+func (s *lpaStore) orderLinks(ctx context.Context, lpaID string, updatedAt time.Time) error {
+	var links []lpaLink
+	if err := s.dataStore.GetAllByKeyPrefix(ctx, lpaPK(lpaID), "SUB#", &links); err != nil {
+		return err
+	}
+
+	for _, link := range links {
+		if !link.LinkedAt.IsZero() {
+			continue
+		}
+
+		link.LinkedAt = updatedAt
+		if err := s.dataStore.Put(ctx, lpaPK(lpaID), subSK(link.Sub, link.ActorType), link); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resealLegacy seals the legacy copies of the LPA, kept under its donors' base64
+// encoded subjects, that were written before encryption.
+func (s *lpaStore) resealLegacy(ctx context.Context, lpaID string) error {
+	var links []lpaLink
+	if err := s.dataStore.GetAllByKeyPrefix(ctx, lpaPK(lpaID), "SUB#", &links); err != nil {
+		return err
+	}
+
+	for _, link := range links {
+		if link.ActorType != page.ActorTypeDonor {
+			continue
+		}
+
+		var item storedLpa
+		if err := s.dataStore.Get(ctx, legacyPK(link.Sub), lpaID, &item); err != nil {
+			return err
+		}
+		if item.ID == "" || item.Personal != nil {
+			continue
+		}
+
+		lpa := item.Lpa
+		if err := migrateLpa(&lpa, item.SchemaVersion); err != nil {
+			return err
+		}
+
+		stored, err := s.seal(ctx, &lpa)
+		if err != nil {
+			return err
+		}
+
+		if err := s.dataStore.Put(ctx, legacyPK(link.Sub), lpaID, stored); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// get reads the LPA with the given ID, decrypting its personal data and
+// migrating it to the latest schema version.
+func (s *lpaStore) get(ctx context.Context, lpaID string) (*page.Lpa, error) {
+	var stored storedLpa
+	if err := s.dataStore.Get(ctx, lpaPK(lpaID), lpaSK(lpaID), &stored); err != nil {
+		return nil, err
+	}
+
+	if !stored.DeletedAt.IsZero() {
+		return &page.Lpa{}, nil
+	}
+
+	lpa, err := s.open(ctx, stored)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := migrateLpa(lpa, stored.SchemaVersion); err != nil {
+		return nil, err
+	}
+
+	return lpa, nil
+}
+
+// upgrade rewrites stored at the latest schema version, recording the changes
+// made as an event, unless dryRun is set. UpdatedAt is kept, as the LPA has
+// not been changed by anyone.
+func (s *lpaStore) upgrade(ctx context.Context, stored storedLpa, dryRun bool) ([]page.LpaChange, error) {
+	previous, err := s.open(ctx, stored)
+	if err != nil {
+		return nil, err
+	}
+
+	lpa := *previous
+	if err := migrateLpa(&lpa, stored.SchemaVersion); err != nil {
+		return nil, err
+	}
+
+	changes, err := page.DiffLpa(previous, &lpa)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return changes, nil
+	}
+
+	return changes, s.rewrite(ctx, &lpa, changes)
+}
+
+// rewrite writes a change to lpa that was not made by anyone using the
+// service, so UpdatedAt is kept.
+func (s *lpaStore) rewrite(ctx context.Context, lpa *page.Lpa, changes []page.LpaChange) error {
+	lpa.Version++
+
+	if err := s.putWithEvent(ctx, lpa, changes); err != nil {
+		lpa.Version--
+		return err
+	}
+
+	return nil
+}
+
+// putWithEvent writes lpa at its version, the event recording changes, and any
+// outbox items, in one transaction so that an LPA is never stored without its
+// history.
+func (s *lpaStore) putWithEvent(ctx context.Context, lpa *page.Lpa, changes []page.LpaChange, items ...outboxEvent) error {
+	stored, err := s.seal(ctx, lpa)
+	if err != nil {
+		return err
+	}
+
+	event, err := s.sealEvent(ctx, lpa, changes)
+	if err != nil {
+		return err
+	}
+
+	transaction := dynamo.NewTransaction().
+		PutVersioned(lpaPK(lpa.ID), lpaSK(lpa.ID), stored, lpa.Version).
+		Put(lpaPK(lpa.ID), eventSK(lpa.Version), event)
+
+	for _, item := range items {
+		transaction.Create(outboxPK, outboxSK(item), item)
+	}
+
+	return s.dataStore.WriteTransaction(ctx, transaction)
+}
+
+// open returns the LPA in stored with its personal data decrypted.
+func (s *lpaStore) open(ctx context.Context, stored storedLpa) (*page.Lpa, error) {
+	lpa := stored.Lpa
+	if stored.Personal == nil {
+		return &lpa, nil
+	}
+
+	var personal lpaPersonalData
+	if err := s.envelope.Open(ctx, stored.Personal, &personal); err != nil {
+		return nil, err
+	}
+
+	lpa.You = personal.You
+	lpa.Attorneys = personal.Attorneys
+	lpa.ReplacementAttorneys = personal.ReplacementAttorneys
+	lpa.CertificateProvider = personal.CertificateProvider
+	lpa.PeopleToNotify = personal.PeopleToNotify
+	lpa.YotiUserData = personal.YotiUserData
+	lpa.OneLoginUserData = personal.OneLoginUserData
+	lpa.CertificateProviderUserData = personal.CertificateProviderUserData
+	lpa.AttorneyProvidedDetails = personal.AttorneyProvidedDetails
+	lpa.ReplacementAttorneyProvidedDetails = personal.ReplacementAttorneyProvidedDetails
+	lpa.SignatureCode = personal.SignatureCode
+	lpa.EnteredSignatureCode = personal.EnteredSignatureCode
+	lpa.WitnessCode = personal.WitnessCode
+
+	return &lpa, nil
+}
+
+// seal returns lpa as it should be written, with its personal data encrypted.
+func (s *lpaStore) seal(ctx context.Context, lpa *page.Lpa) (storedLpa, error) {
+	sealed, err := s.envelope.Seal(ctx, lpaPersonalData{
+		You:                                lpa.You,
+		Attorneys:                          lpa.Attorneys,
+		ReplacementAttorneys:               lpa.ReplacementAttorneys,
+		CertificateProvider:                lpa.CertificateProvider,
+		PeopleToNotify:                     lpa.PeopleToNotify,
+		YotiUserData:                       lpa.YotiUserData,
+		OneLoginUserData:                   lpa.OneLoginUserData,
+		CertificateProviderUserData:        lpa.CertificateProviderUserData,
+		AttorneyProvidedDetails:            lpa.AttorneyProvidedDetails,
+		ReplacementAttorneyProvidedDetails: lpa.ReplacementAttorneyProvidedDetails,
+		SignatureCode:                      lpa.SignatureCode,
+		EnteredSignatureCode:               lpa.EnteredSignatureCode,
+		WitnessCode:                        lpa.WitnessCode,
+	})
+	if err != nil {
+		return storedLpa{}, err
+	}
+
+	stored := storedLpa{Lpa: *lpa, SchemaVersion: lpaSchemaVersion, Personal: sealed}
+	stored.You = actor.Person{}
+	stored.Attorneys = nil
+	stored.ReplacementAttorneys = nil
+	stored.CertificateProvider = actor.CertificateProvider{}
+	stored.PeopleToNotify = nil
+	stored.YotiUserData = identity.UserData{}
+	stored.OneLoginUserData = identity.UserData{}
+	stored.CertificateProviderUserData = identity.UserData{}
+	stored.AttorneyProvidedDetails = nil
+	stored.ReplacementAttorneyProvidedDetails = nil
+	stored.SignatureCode = ""
+	stored.EnteredSignatureCode = ""
+	stored.WitnessCode = page.WitnessCode{}
+
+	return stored, nil
+}
+
+func lpaPK(lpaID string) string {
+	return "LPA#" + lpaID
+}
+
+func legacyPK(sub string) string {
+	return base64.StdEncoding.EncodeToString([]byte(sub))
+}
+
+func lpaSK(lpaID string) string {
+	return "#METADATA#" + lpaID
+}
+
+func subSK(sub string, actorType page.ActorType) string {
+	return "SUB#" + sub + "#" + string(actorType)
+}
+
+func legacySubSK(sub string) string {
+	return "SUB#" + sub
+}
 
-	return s.dataStore.Put(ctx, page.SessionDataFromContext(ctx).SessionID, lpa.ID, lpa)
+func eventSK(version int) string {
+	return fmt.Sprintf("EVENT#%010d", version)
 }
@@ -1,11 +1,19 @@
 package app
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"log"
+	"reflect"
 	"testing"
+	"time"
 
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/dynamo"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/encryption"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/event"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -13,90 +21,1212 @@ import (
 
 var expectedError = errors.New("err")
 
+var (
+	testKeyProvider, _ = encryption.NewLocalKeyProvider(bytes.Repeat([]byte{1}, 32))
+	testEnvelope       = encryption.New(testKeyProvider)
+)
+
+// openLpa returns the LPA that would be read back from stored.
+func openLpa(stored storedLpa) *page.Lpa {
+	dataStore := &mockDataStore{}
+	dataStore.On("Get", mock.Anything, mock.Anything, mock.Anything).Return(nil, stored)
+
+	lpa, _ := (&lpaStore{dataStore: dataStore, envelope: testEnvelope}).get(context.Background(), stored.ID)
+	return lpa
+}
+
 type mockDataStore struct {
 	data interface{}
 	mock.Mock
 }
 
+// unmarshal fills v with the data given as the second return value of the
+// expectation, if there is one, otherwise with m.data.
+func (m *mockDataStore) unmarshal(args mock.Arguments, v interface{}) error {
+	data := m.data
+	if len(args) > 1 {
+		data = args.Get(1)
+	}
+
+	b, _ := json.Marshal(data)
+	json.Unmarshal(b, v)
+	return args.Error(0)
+}
+
 func (m *mockDataStore) GetAll(ctx context.Context, pk string, v interface{}) error {
-	data, _ := json.Marshal(m.data)
-	json.Unmarshal(data, v)
-	return m.Called(ctx, pk).Error(0)
+	return m.unmarshal(m.Called(ctx, pk), v)
+}
+
+func (m *mockDataStore) GetAllByKeyPrefix(ctx context.Context, pk, skPrefix string, v interface{}) error {
+	return m.unmarshal(m.Called(ctx, pk, skPrefix), v)
+}
+
+func (m *mockDataStore) GetAllBySK(ctx context.Context, sk string, v interface{}) error {
+	return m.unmarshal(m.Called(ctx, sk), v)
+}
+
+// GetPageBySK returns the third return value of the expectation as the cursor
+// for the next page.
+func (m *mockDataStore) GetPageBySK(ctx context.Context, sk, cursor string, limit int, v interface{}) (string, error) {
+	args := m.Called(ctx, sk, cursor, limit)
+	return args.String(2), m.unmarshal(args, v)
+}
+
+// ScanByKeyPrefix returns the third return value of the expectation as the
+// cursor for the next page.
+func (m *mockDataStore) ScanByKeyPrefix(ctx context.Context, pkPrefix, skPrefix, cursor string, v interface{}) (string, error) {
+	args := m.Called(ctx, pkPrefix, skPrefix, cursor)
+	return args.String(2), m.unmarshal(args, v)
+}
+
+func (m *mockDataStore) ScanKeysByPrefix(ctx context.Context, pkPrefix, skPrefix, cursor string) ([]dynamo.Key, string, error) {
+	args := m.Called(ctx, pkPrefix, skPrefix, cursor)
+	keys, _ := args.Get(0).([]dynamo.Key)
+	return keys, args.String(1), args.Error(2)
 }
 
 func (m *mockDataStore) Get(ctx context.Context, pk, sk string, v interface{}) error {
-	data, _ := json.Marshal(m.data)
-	json.Unmarshal(data, v)
-	return m.Called(ctx, pk, sk).Error(0)
+	return m.unmarshal(m.Called(ctx, pk, sk), v)
 }
 
 func (m *mockDataStore) Put(ctx context.Context, pk, sk string, v interface{}) error {
 	return m.Called(ctx, pk, sk, v).Error(0)
 }
 
-func TestLpaStoreGetAll(t *testing.T) {
-	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{SessionID: "an-id", LpaID: "123"})
+func (m *mockDataStore) PutVersioned(ctx context.Context, pk, sk string, v interface{}, version int) error {
+	return m.Called(ctx, pk, sk, v, version).Error(0)
+}
 
-	lpas := []*page.Lpa{{ID: "10100000"}}
+func (m *mockDataStore) Delete(ctx context.Context, pk, sk string) error {
+	return m.Called(ctx, pk, sk).Error(0)
+}
+
+func (m *mockDataStore) Create(ctx context.Context, pk, sk string, v interface{}) error {
+	return m.Called(ctx, pk, sk, v).Error(0)
+}
+
+func (m *mockDataStore) WriteTransaction(ctx context.Context, transaction *dynamo.Transaction) error {
+	return m.Called(ctx, transaction).Error(0)
+}
+
+// transactionOf matches a transaction making writes. A write's Value may be
+// mock.Anything, or a func(interface{}) bool to check the value written.
+func transactionOf(writes ...dynamo.TransactionWrite) interface{} {
+	return mock.MatchedBy(func(transaction *dynamo.Transaction) bool {
+		actual := transaction.Writes()
+		if len(actual) != len(writes) {
+			return false
+		}
+
+		for i, write := range writes {
+			switch v := write.Value.(type) {
+			case string:
+				if v == mock.Anything {
+					write.Value = actual[i].Value
+				}
+			case func(interface{}) bool:
+				if !v(actual[i].Value) {
+					return false
+				}
+				write.Value = actual[i].Value
+			}
+
+			if !reflect.DeepEqual(write, actual[i]) {
+				return false
+			}
+		}
+
+		return true
+	})
+}
 
-	dataStore := &mockDataStore{data: lpas}
-	dataStore.On("GetAll", ctx, "an-id").Return(nil)
+func TestLpaStoreCreate(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{ActorType: page.ActorTypeDonor, Subject: "a-sub"})
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("WriteTransaction", ctx, transactionOf(
+			dynamo.TransactionWrite{Kind: dynamo.TransactionCreate, PK: "LPA#M-0000-0000-001X", SK: "#METADATA#M-0000-0000-001X", Value: func(v interface{}) bool {
+				lpa := v.(storedLpa)
+				return lpa.ID == "M-0000-0000-001X" && lpa.Version == 1 && !lpa.UpdatedAt.IsZero() && lpa.Personal != nil
+			}},
+			dynamo.TransactionWrite{Kind: dynamo.TransactionPut, PK: "LPA#M-0000-0000-001X", SK: "SUB#a-sub#donor", Value: func(v interface{}) bool {
+				link := v.(lpaLink)
+				return link.LpaID == "M-0000-0000-001X" && link.Sub == "a-sub" && link.ActorType == page.ActorTypeDonor && !link.LinkedAt.IsZero()
+			}},
+			dynamo.TransactionWrite{Kind: dynamo.TransactionPut, PK: "LPA#M-0000-0000-001X", SK: "EVENT#0000000001", Value: func(v interface{}) bool {
+				event := v.(storedLpaEvent)
+				return event.Version == 1 && event.ActorType == page.ActorTypeDonor && event.Subject == "a-sub" &&
+					assert.Contains(t, event.Changes, page.LpaChange{Field: "ID", Value: `"M-0000-0000-001X"`})
+			}},
+		)).
+		Return(nil)
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope, newReference: func() string { return "M-0000-0000-001X" }}
+
+	lpa, err := lpaStore.Create(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "M-0000-0000-001X", lpa.ID)
+	assert.Equal(t, 1, lpa.Version)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestLpaStoreCreateWhenReferenceInUse(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{ActorType: page.ActorTypeDonor, Subject: "a-sub"})
+
+	references := []string{"M-0000-0000-001X", "M-0000-0000-010Y"}
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("WriteTransaction", ctx, mock.MatchedBy(func(transaction *dynamo.Transaction) bool {
+			return transaction.Writes()[0].PK == "LPA#M-0000-0000-001X"
+		})).
+		Return(dynamo.ConflictError{PK: "LPA#M-0000-0000-001X", SK: "#METADATA#M-0000-0000-001X"})
+	dataStore.
+		On("WriteTransaction", ctx, mock.MatchedBy(func(transaction *dynamo.Transaction) bool {
+			return transaction.Writes()[0].PK == "LPA#M-0000-0000-010Y"
+		})).
+		Return(nil)
 
-	lpaStore := &lpaStore{dataStore: dataStore, randomInt: func(x int) int { return x }}
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope, newReference: func() string {
+		ref := references[0]
+		references = references[1:]
+		return ref
+	}}
 
-	result, err := lpaStore.GetAll(ctx)
+	lpa, err := lpaStore.Create(ctx)
 	assert.Nil(t, err)
-	assert.Equal(t, lpas, result)
+	assert.Equal(t, "M-0000-0000-010Y", lpa.ID)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestLpaStoreCreateWhenNoUnusedReference(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{ActorType: page.ActorTypeDonor, Subject: "a-sub"})
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("WriteTransaction", ctx, mock.Anything).
+		Return(dynamo.ConflictError{}).
+		Times(maxCreateAttempts)
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope, newReference: func() string { return "M-0000-0000-001X" }}
+
+	_, err := lpaStore.Create(ctx)
+	assert.NotNil(t, err)
+	assert.False(t, errors.As(err, &dynamo.ConflictError{}))
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestLpaStoreCreateWhenDataStoreErrors(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{ActorType: page.ActorTypeDonor, Subject: "a-sub"})
+
+	testCases := map[string]func(*mockDataStore){
+		"write": func(dataStore *mockDataStore) {
+			dataStore.On("WriteTransaction", ctx, mock.Anything).Return(expectedError)
+		},
+	}
+
+	for name, setup := range testCases {
+		t.Run(name, func(t *testing.T) {
+			dataStore := &mockDataStore{}
+			setup(dataStore)
+
+			lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope, newReference: func() string { return "M-0000-0000-001X" }}
+
+			_, err := lpaStore.Create(ctx)
+			assert.Equal(t, expectedError, err)
+		})
+	}
+}
+
+func TestLpaStoreGetPage(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{ActorType: page.ActorTypeDonor, Subject: "a-sub"})
+	now := time.Date(2023, time.January, 2, 3, 4, 5, 0, time.UTC)
+
+	dataStore := &mockDataStore{}
+	dataStore.On("GetAllBySK", ctx, "SUB#a-sub").Return(nil, []lpaLink{})
+	dataStore.On("GetAll", ctx, legacyPK("a-sub")).Return(nil, []*page.Lpa{})
+	dataStore.On("GetPageBySK", ctx, "SUB#a-sub#donor", "", 2).Return(nil, []lpaLink{{LpaID: "1", ActorType: page.ActorTypeDonor}, {LpaID: "2", ActorType: page.ActorTypeDonor}}, "next")
+	dataStore.On("Get", ctx, "LPA#1", "#METADATA#1").Return(nil, &page.Lpa{ID: "1", UpdatedAt: now})
+	dataStore.On("Get", ctx, "LPA#2", "#METADATA#2").Return(nil, &page.Lpa{ID: "2", UpdatedAt: now.Add(time.Second)})
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	result, err := lpaStore.GetPage(ctx, page.LpaQuery{ActorType: page.ActorTypeDonor, Limit: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, page.LpaPage{
+		Lpas: []*page.Lpa{{ID: "1", UpdatedAt: now}, {ID: "2", UpdatedAt: now.Add(time.Second)}},
+		Next: "next",
+	}, result)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestLpaStoreGetPageFiltersUntilFull(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{ActorType: page.ActorTypeDonor, Subject: "a-sub"})
+	paid := page.Tasks{PayForLpa: page.TaskCompleted}
+
+	dataStore := &mockDataStore{}
+	dataStore.On("GetPageBySK", ctx, "SUB#a-sub#donor", "a-cursor", 2).Return(nil, []lpaLink{{LpaID: "1", ActorType: page.ActorTypeDonor}, {LpaID: "2", ActorType: page.ActorTypeDonor}}, "cursor-2")
+	dataStore.On("Get", ctx, "LPA#1", "#METADATA#1").Return(nil, &page.Lpa{ID: "1", Tasks: paid})
+	dataStore.On("Get", ctx, "LPA#2", "#METADATA#2").Return(nil, &page.Lpa{ID: "2"})
+	dataStore.On("GetPageBySK", ctx, "SUB#a-sub#donor", "cursor-2", 1).Return(nil, []lpaLink{{LpaID: "3", ActorType: page.ActorTypeDonor}}, "cursor-3")
+	dataStore.On("Get", ctx, "LPA#3", "#METADATA#3").Return(nil, &page.Lpa{ID: "3"})
+	dataStore.On("GetPageBySK", ctx, "SUB#a-sub#donor", "cursor-3", 1).Return(nil, []lpaLink{{LpaID: "4", ActorType: page.ActorTypeDonor}}, "")
+	dataStore.On("Get", ctx, "LPA#4", "#METADATA#4").Return(nil, &page.Lpa{ID: "4", Tasks: paid})
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	result, err := lpaStore.GetPage(ctx, page.LpaQuery{ActorType: page.ActorTypeDonor, Status: page.LpaStatusPaid, Cursor: "a-cursor", Limit: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, page.LpaPage{Lpas: []*page.Lpa{{ID: "1", Tasks: paid}, {ID: "4", Tasks: paid}}}, result)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestLpaStoreGetPageMigratesLegacyLpas(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{ActorType: page.ActorTypeDonor, Subject: "a-sub"})
+
+	sealed, _ := (&lpaStore{envelope: testEnvelope}).seal(ctx, &page.Lpa{ID: "3"})
+
+	dataStore := &mockDataStore{}
+	dataStore.On("GetAllBySK", ctx, "SUB#a-sub").Return(nil, []lpaLink{})
+	dataStore.On("GetAll", ctx, legacyPK("a-sub")).Return(nil, []storedLpa{{Lpa: page.Lpa{ID: "1"}}, {Lpa: page.Lpa{ID: "2"}}, sealed})
+	dataStore.On("GetAllBySK", ctx, "SUB#a-sub#donor").Return(nil, []lpaLink{{LpaID: "1", ActorType: page.ActorTypeDonor}, {LpaID: "3", ActorType: page.ActorTypeDonor}})
+	dataStore.
+		On("Put", ctx, legacyPK("a-sub"), "1", mock.MatchedBy(func(stored storedLpa) bool {
+			return stored.Personal != nil && assert.Equal(t, &page.Lpa{ID: "1"}, openLpa(stored))
+		})).
+		Return(nil)
+	dataStore.
+		On("WriteTransaction", ctx, transactionOf(
+			dynamo.TransactionWrite{Kind: dynamo.TransactionPut, PK: "LPA#2", SK: "#METADATA#2", Value: func(v interface{}) bool {
+				return assert.Equal(t, &page.Lpa{ID: "2"}, openLpa(v.(storedLpa)))
+			}},
+			dynamo.TransactionWrite{Kind: dynamo.TransactionPut, PK: "LPA#2", SK: "SUB#a-sub#donor", Value: lpaLink{LpaID: "2", Sub: "a-sub", ActorType: page.ActorTypeDonor}},
+			dynamo.TransactionWrite{Kind: dynamo.TransactionPut, PK: legacyPK("a-sub"), SK: "2", Value: func(v interface{}) bool {
+				return v.(storedLpa).Personal != nil
+			}},
+		)).
+		Return(nil)
+	dataStore.On("GetPageBySK", ctx, "SUB#a-sub#donor", "", 10).Return(nil, []lpaLink{{LpaID: "1", ActorType: page.ActorTypeDonor}, {LpaID: "2", ActorType: page.ActorTypeDonor}}, "")
+	dataStore.On("Get", ctx, "LPA#1", "#METADATA#1").Return(nil, &page.Lpa{ID: "1"})
+	dataStore.On("Get", ctx, "LPA#2", "#METADATA#2").Return(nil, &page.Lpa{ID: "2"})
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	result, err := lpaStore.GetPage(ctx, page.LpaQuery{ActorType: page.ActorTypeDonor, Limit: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, page.LpaPage{Lpas: []*page.Lpa{{ID: "1"}, {ID: "2"}}}, result)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestLpaStoreGetPageWhenNoLimit(t *testing.T) {
+	lpaStore := &lpaStore{}
+
+	_, err := lpaStore.GetPage(context.Background(), page.LpaQuery{ActorType: page.ActorTypeDonor})
+	assert.NotNil(t, err)
+}
+
+func TestLpaStoreGetPageWhenMigrationErrors(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{ActorType: page.ActorTypeDonor, Subject: "a-sub"})
+
+	testCases := map[string]func(*mockDataStore){
+		"legacy links": func(dataStore *mockDataStore) {
+			dataStore.On("GetAllBySK", ctx, "SUB#a-sub").Return(expectedError)
+		},
+		"relink": func(dataStore *mockDataStore) {
+			dataStore.On("GetAllBySK", ctx, "SUB#a-sub").Return(nil, []lpaLink{{LpaID: "1", Sub: "a-sub", ActorType: page.ActorTypeDonor}})
+			dataStore.On("WriteTransaction", ctx, mock.Anything).Return(expectedError)
+		},
+		"legacy": func(dataStore *mockDataStore) {
+			dataStore.On("GetAllBySK", ctx, "SUB#a-sub").Return(nil, []lpaLink{})
+			dataStore.On("GetAll", ctx, legacyPK("a-sub")).Return(expectedError)
+		},
+		"links": func(dataStore *mockDataStore) {
+			dataStore.On("GetAllBySK", ctx, "SUB#a-sub").Return(nil, []lpaLink{})
+			dataStore.On("GetAll", ctx, legacyPK("a-sub")).Return(nil, []*page.Lpa{{ID: "1"}})
+			dataStore.On("GetAllBySK", ctx, "SUB#a-sub#donor").Return(expectedError)
+		},
+		"write": func(dataStore *mockDataStore) {
+			dataStore.On("GetAllBySK", ctx, "SUB#a-sub").Return(nil, []lpaLink{})
+			dataStore.On("GetAll", ctx, legacyPK("a-sub")).Return(nil, []*page.Lpa{{ID: "1"}})
+			dataStore.On("GetAllBySK", ctx, "SUB#a-sub#donor").Return(nil, []lpaLink{})
+			dataStore.On("WriteTransaction", ctx, mock.Anything).Return(expectedError)
+		},
+		"reseal": func(dataStore *mockDataStore) {
+			dataStore.On("GetAllBySK", ctx, "SUB#a-sub").Return(nil, []lpaLink{})
+			dataStore.On("GetAll", ctx, legacyPK("a-sub")).Return(nil, []*page.Lpa{{ID: "1"}})
+			dataStore.On("GetAllBySK", ctx, "SUB#a-sub#donor").Return(nil, []lpaLink{{LpaID: "1", ActorType: page.ActorTypeDonor}})
+			dataStore.On("Put", ctx, legacyPK("a-sub"), "1", mock.Anything).Return(expectedError)
+		},
+	}
+
+	for name, setup := range testCases {
+		t.Run(name, func(t *testing.T) {
+			dataStore := &mockDataStore{}
+			setup(dataStore)
+			dataStore.On("GetAll", ctx, legacyPK("a-sub")).Return(nil, []*page.Lpa{})
+			dataStore.On("GetPageBySK", ctx, "SUB#a-sub#donor", "", 10).Return(nil, []lpaLink{{LpaID: "2", ActorType: page.ActorTypeDonor}}, "")
+			dataStore.On("Get", ctx, "LPA#2", "#METADATA#2").Return(nil, &page.Lpa{ID: "2"})
+
+			var buf bytes.Buffer
+			lpaStore := &lpaStore{logger: log.New(&buf, "", 0), dataStore: dataStore, envelope: testEnvelope}
+
+			result, err := lpaStore.GetPage(ctx, page.LpaQuery{ActorType: page.ActorTypeDonor, Limit: 10})
+			assert.Nil(t, err)
+			assert.Equal(t, page.LpaPage{Lpas: []*page.Lpa{{ID: "2"}}}, result)
+			assert.Contains(t, buf.String(), ": err")
+		})
+	}
+}
+
+func TestLpaStoreGetPageWhenDataStoreErrors(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{ActorType: page.ActorTypeAttorney, Subject: "a-sub"})
+
+	testCases := map[string]func(*mockDataStore){
+		"links": func(dataStore *mockDataStore) {
+			dataStore.On("GetAllBySK", ctx, "SUB#a-sub").Return(nil, []lpaLink{})
+			dataStore.On("GetPageBySK", ctx, "SUB#a-sub#attorney", "", 10).Return(expectedError, nil, "")
+		},
+		"lpa": func(dataStore *mockDataStore) {
+			dataStore.On("GetAllBySK", ctx, "SUB#a-sub").Return(nil, []lpaLink{})
+			dataStore.On("GetPageBySK", ctx, "SUB#a-sub#attorney", "", 10).Return(nil, []lpaLink{{LpaID: "1", ActorType: page.ActorTypeAttorney}}, "")
+			dataStore.On("Get", ctx, "LPA#1", "#METADATA#1").Return(expectedError)
+		},
+	}
+
+	for name, setup := range testCases {
+		t.Run(name, func(t *testing.T) {
+			dataStore := &mockDataStore{}
+			setup(dataStore)
+
+			lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+			_, err := lpaStore.GetPage(ctx, page.LpaQuery{ActorType: page.ActorTypeAttorney, Limit: 10})
+			assert.Equal(t, expectedError, err)
+		})
+	}
+}
+
+func TestLpaStoreGetAllAs(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{ActorType: page.ActorTypeDonor, Subject: "a-sub"})
+	now := time.Now()
+
+	dataStore := &mockDataStore{}
+	dataStore.On("GetAllBySK", ctx, "SUB#a-sub").Return(nil, []lpaLink{})
+	dataStore.On("GetAllBySK", ctx, "SUB#a-sub#attorney").Return(nil, []lpaLink{
+		{LpaID: "1", ActorType: page.ActorTypeAttorney},
+		{LpaID: "3", ActorType: page.ActorTypeAttorney},
+	})
+	dataStore.On("Get", ctx, "LPA#1", "#METADATA#1").Return(nil, &page.Lpa{ID: "1", UpdatedAt: now.Add(-time.Hour)})
+	dataStore.On("Get", ctx, "LPA#3", "#METADATA#3").Return(nil, &page.Lpa{ID: "3", UpdatedAt: now})
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	result, err := lpaStore.GetAllAs(ctx, page.ActorTypeAttorney)
+	assert.Nil(t, err)
+	if assert.Len(t, result, 2) {
+		assert.Equal(t, "3", result[0].ID)
+		assert.Equal(t, "1", result[1].ID)
+	}
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestLpaStoreGetAllAsRelinksLegacyLinks(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{ActorType: page.ActorTypeAttorney, Subject: "a-sub"})
+
+	dataStore := &mockDataStore{}
+	dataStore.On("GetAllBySK", ctx, "SUB#a-sub").Return(nil, []lpaLink{
+		{LpaID: "1", Sub: "a-sub", ActorType: page.ActorTypeAttorney},
+		{LpaID: "2", Sub: "a-sub", ActorType: page.ActorTypeDonor},
+	})
+	dataStore.
+		On("WriteTransaction", ctx, transactionOf(
+			dynamo.TransactionWrite{Kind: dynamo.TransactionPut, PK: "LPA#1", SK: "SUB#a-sub#attorney", Value: lpaLink{LpaID: "1", Sub: "a-sub", ActorType: page.ActorTypeAttorney}},
+			dynamo.TransactionWrite{Kind: dynamo.TransactionDelete, PK: "LPA#1", SK: "SUB#a-sub"},
+		)).
+		Return(nil)
+	dataStore.
+		On("WriteTransaction", ctx, transactionOf(
+			dynamo.TransactionWrite{Kind: dynamo.TransactionPut, PK: "LPA#2", SK: "SUB#a-sub#donor", Value: lpaLink{LpaID: "2", Sub: "a-sub", ActorType: page.ActorTypeDonor}},
+			dynamo.TransactionWrite{Kind: dynamo.TransactionDelete, PK: "LPA#2", SK: "SUB#a-sub"},
+		)).
+		Return(nil)
+	dataStore.On("GetAllBySK", ctx, "SUB#a-sub#attorney").Return(nil, []lpaLink{{LpaID: "1", Sub: "a-sub", ActorType: page.ActorTypeAttorney}})
+	dataStore.On("Get", ctx, "LPA#1", "#METADATA#1").Return(nil, &page.Lpa{ID: "1"})
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	result, err := lpaStore.GetAllAs(ctx, page.ActorTypeAttorney)
+	assert.Nil(t, err)
+	assert.Equal(t, []*page.Lpa{{ID: "1"}}, result)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestLpaStoreGetAllAsWhenRelinkErrors(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{ActorType: page.ActorTypeAttorney, Subject: "a-sub"})
+
+	dataStore := &mockDataStore{}
+	dataStore.On("GetAllBySK", ctx, "SUB#a-sub").Return(nil, []lpaLink{{LpaID: "2", Sub: "a-sub", ActorType: page.ActorTypeAttorney}})
+	dataStore.On("WriteTransaction", ctx, mock.Anything).Return(expectedError)
+	dataStore.On("GetAllBySK", ctx, "SUB#a-sub#attorney").Return(nil, []lpaLink{{LpaID: "1", Sub: "a-sub", ActorType: page.ActorTypeAttorney}})
+	dataStore.On("Get", ctx, "LPA#1", "#METADATA#1").Return(nil, &page.Lpa{ID: "1"})
+
+	var buf bytes.Buffer
+	lpaStore := &lpaStore{logger: log.New(&buf, "", 0), dataStore: dataStore, envelope: testEnvelope}
+
+	result, err := lpaStore.GetAllAs(ctx, page.ActorTypeAttorney)
+	assert.Nil(t, err)
+	assert.Equal(t, []*page.Lpa{{ID: "1"}}, result)
+	assert.Equal(t, "unable to relink legacy links: err\n", buf.String())
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestLpaStoreGetAllAsWhenDataStoreErrors(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{Subject: "a-sub"})
+
+	testCases := map[string]func(*mockDataStore){
+		"links": func(dataStore *mockDataStore) {
+			dataStore.On("GetAllBySK", ctx, "SUB#a-sub").Return(nil, []lpaLink{})
+			dataStore.On("GetAllBySK", ctx, "SUB#a-sub#attorney").Return(expectedError)
+		},
+		"lpa": func(dataStore *mockDataStore) {
+			dataStore.On("GetAllBySK", ctx, "SUB#a-sub").Return(nil, []lpaLink{})
+			dataStore.On("GetAllBySK", ctx, "SUB#a-sub#attorney").Return(nil, []lpaLink{{LpaID: "1", ActorType: page.ActorTypeAttorney}})
+			dataStore.On("Get", ctx, "LPA#1", "#METADATA#1").Return(expectedError)
+		},
+	}
+
+	for name, setup := range testCases {
+		t.Run(name, func(t *testing.T) {
+			dataStore := &mockDataStore{}
+			setup(dataStore)
+
+			lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+			_, err := lpaStore.GetAllAs(ctx, page.ActorTypeAttorney)
+			assert.Equal(t, expectedError, err)
+		})
+	}
 }
 
 func TestLpaStoreGet(t *testing.T) {
-	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{SessionID: "an-id", LpaID: "123"})
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "123", ActorType: page.ActorTypeAttorney, Subject: "a-sub"})
 
-	dataStore := &mockDataStore{data: &page.Lpa{ID: "10100000"}}
-	dataStore.On("Get", ctx, "an-id", "123").Return(nil)
+	dataStore := &mockDataStore{}
+	dataStore.On("Get", ctx, "LPA#123", "SUB#a-sub#attorney").Return(nil, lpaLink{LpaID: "123", Sub: "a-sub", ActorType: page.ActorTypeAttorney})
+	dataStore.On("Get", ctx, "LPA#123", "#METADATA#123").Return(nil, &page.Lpa{ID: "123"})
 
-	lpaStore := &lpaStore{dataStore: dataStore, randomInt: func(x int) int { return x }}
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
 
 	lpa, err := lpaStore.Get(ctx)
 	assert.Nil(t, err)
-	assert.Equal(t, &page.Lpa{ID: "10100000"}, lpa)
+	assert.Equal(t, &page.Lpa{ID: "123"}, lpa)
 }
 
-func TestLpaStoreGetWhenExists(t *testing.T) {
-	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{SessionID: "an-id", LpaID: "123"})
-	existingLpa := &page.Lpa{ID: "an-id"}
+func TestLpaStoreGetWithLegacyLink(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "123", ActorType: page.ActorTypeAttorney, Subject: "a-sub"})
 
-	dataStore := &mockDataStore{data: existingLpa}
-	dataStore.On("Get", ctx, "an-id", "123").Return(nil)
+	dataStore := &mockDataStore{}
+	dataStore.On("Get", ctx, "LPA#123", "SUB#a-sub#attorney").Return(nil, lpaLink{})
+	dataStore.On("Get", ctx, "LPA#123", "SUB#a-sub").Return(nil, lpaLink{LpaID: "123", Sub: "a-sub", ActorType: page.ActorTypeAttorney})
+	dataStore.On("Get", ctx, "LPA#123", "#METADATA#123").Return(nil, &page.Lpa{ID: "123"})
 
-	lpaStore := &lpaStore{dataStore: dataStore, randomInt: func(x int) int { return x }}
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
 
 	lpa, err := lpaStore.Get(ctx)
 	assert.Nil(t, err)
-	assert.Equal(t, existingLpa, lpa)
+	assert.Equal(t, &page.Lpa{ID: "123"}, lpa)
 }
 
-func TestLpaStoreGetWhenDataStoreError(t *testing.T) {
-	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{SessionID: "an-id", LpaID: "123"})
+func TestLpaStoreGetDecryptsPersonalData(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "123", ActorType: page.ActorTypeDonor, Subject: "a-sub"})
+	lpa := &page.Lpa{ID: "123", Type: "pfa", You: actor.Person{FirstNames: "John"}, Attorneys: actor.Attorneys{{ID: "a", FirstNames: "Jane"}}}
+
+	stored, _ := (&lpaStore{envelope: testEnvelope}).seal(ctx, lpa)
+
+	dataStore := &mockDataStore{}
+	dataStore.On("Get", ctx, "LPA#123", "SUB#a-sub#donor").Return(nil, lpaLink{LpaID: "123", Sub: "a-sub", ActorType: page.ActorTypeDonor})
+	dataStore.On("Get", ctx, "LPA#123", "#METADATA#123").Return(nil, stored)
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	result, err := lpaStore.Get(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, lpa, result)
+}
+
+func TestLpaStoreGetWhenCannotDecrypt(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "123", ActorType: page.ActorTypeDonor, Subject: "a-sub"})
+
+	otherKeys, _ := encryption.NewLocalKeyProvider(bytes.Repeat([]byte{2}, 32))
+	stored, _ := (&lpaStore{envelope: encryption.New(otherKeys)}).seal(ctx, &page.Lpa{ID: "123"})
 
 	dataStore := &mockDataStore{}
-	dataStore.On("Get", ctx, "an-id", "123").Return(expectedError)
+	dataStore.On("Get", ctx, "LPA#123", "SUB#a-sub#donor").Return(nil, lpaLink{LpaID: "123", Sub: "a-sub", ActorType: page.ActorTypeDonor})
+	dataStore.On("Get", ctx, "LPA#123", "#METADATA#123").Return(nil, stored)
 
-	lpaStore := &lpaStore{dataStore: dataStore, randomInt: func(x int) int { return x }}
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
 
 	_, err := lpaStore.Get(ctx)
-	assert.Equal(t, expectedError, err)
+	assert.Equal(t, encryption.ErrUnknownKey, err)
+}
+
+func TestLpaStoreGetAsSupport(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "123", ActorType: page.ActorTypeSupport, Subject: "a-sub"})
+
+	dataStore := &mockDataStore{}
+	dataStore.On("Get", ctx, "LPA#123", "#METADATA#123").Return(nil, &page.Lpa{ID: "123"})
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	lpa, err := lpaStore.Get(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, &page.Lpa{ID: "123"}, lpa)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestLpaStoreWhenSupport(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "123", ActorType: page.ActorTypeSupport, Subject: "a-sub"})
+
+	dataStore := &mockDataStore{}
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	assert.Equal(t, errReadOnly, lpaStore.Put(ctx, &page.Lpa{ID: "123"}))
+	assert.Equal(t, errReadOnly, lpaStore.Delete(ctx))
+	assert.Equal(t, errReadOnly, lpaStore.Link(ctx))
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestLpaStoreGetWhenNotLinked(t *testing.T) {
+	testCases := map[string]lpaLink{
+		"no link":         {},
+		"different actor": {LpaID: "123", Sub: "a-sub", ActorType: page.ActorTypeDonor},
+	}
+
+	for name, link := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "123", ActorType: page.ActorTypeAttorney, Subject: "a-sub"})
+
+			dataStore := &mockDataStore{}
+			dataStore.On("Get", ctx, "LPA#123", "SUB#a-sub#attorney").Return(nil, lpaLink{})
+			dataStore.On("Get", ctx, "LPA#123", "SUB#a-sub").Return(nil, link)
+
+			lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+			_, err := lpaStore.Get(ctx)
+			assert.Equal(t, errNotLinked, err)
+		})
+	}
+}
+
+func TestLpaStoreGetWhenNoLpaID(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{Subject: "a-sub"})
+
+	lpaStore := &lpaStore{}
+
+	_, err := lpaStore.Get(ctx)
+	assert.NotNil(t, err)
+}
+
+func TestLpaStoreGetWhenDataStoreError(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "123", ActorType: page.ActorTypeDonor, Subject: "a-sub"})
+
+	testCases := map[string]func(*mockDataStore){
+		"link": func(dataStore *mockDataStore) {
+			dataStore.On("Get", ctx, "LPA#123", "SUB#a-sub#donor").Return(expectedError)
+		},
+		"legacy link": func(dataStore *mockDataStore) {
+			dataStore.On("Get", ctx, "LPA#123", "SUB#a-sub#donor").Return(nil, lpaLink{})
+			dataStore.On("Get", ctx, "LPA#123", "SUB#a-sub").Return(expectedError)
+		},
+		"lpa": func(dataStore *mockDataStore) {
+			dataStore.On("Get", ctx, "LPA#123", "SUB#a-sub#donor").Return(nil, lpaLink{LpaID: "123", ActorType: page.ActorTypeDonor})
+			dataStore.On("Get", ctx, "LPA#123", "#METADATA#123").Return(expectedError)
+		},
+	}
+
+	for name, setup := range testCases {
+		t.Run(name, func(t *testing.T) {
+			dataStore := &mockDataStore{}
+			setup(dataStore)
+
+			lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+			_, err := lpaStore.Get(ctx)
+			assert.Equal(t, expectedError, err)
+		})
+	}
 }
 
 func TestLpaStorePut(t *testing.T) {
-	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{SessionID: "an-id", LpaID: "123"})
-	lpa := &page.Lpa{ID: "5"}
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "123", ActorType: page.ActorTypeDonor, Subject: "a-sub"})
+	lpa := &page.Lpa{ID: "5", Version: 3, WhoFor: "me", Type: "pfa"}
 
 	dataStore := &mockDataStore{}
-	dataStore.On("Put", ctx, "an-id", "5", lpa).Return(expectedError)
+	dataStore.On("Get", ctx, "LPA#5", "#METADATA#5").Return(nil, &page.Lpa{ID: "5", Version: 3, WhoFor: "me"})
+	dataStore.
+		On("WriteTransaction", ctx, transactionOf(
+			dynamo.TransactionWrite{Kind: dynamo.TransactionPutVersioned, PK: "LPA#5", SK: "#METADATA#5", Version: 4, Value: func(v interface{}) bool {
+				stored := v.(storedLpa)
+				return stored.ID == "5" && stored.WhoFor == "me" && stored.Type == "pfa" && stored.Personal != nil
+			}},
+			dynamo.TransactionWrite{Kind: dynamo.TransactionPut, PK: "LPA#5", SK: "EVENT#0000000004", Value: func(v interface{}) bool {
+				return assert.Equal(t, storedLpaEvent{LpaEvent: page.LpaEvent{
+					LpaID:     "5",
+					Version:   4,
+					ActorType: page.ActorTypeDonor,
+					Subject:   "a-sub",
+					Changes:   []page.LpaChange{{Field: "Type", Value: `"pfa"`}},
+					Time:      lpa.UpdatedAt,
+				}}, v)
+			}},
+		)).
+		Return(nil)
 
-	lpaStore := &lpaStore{dataStore: dataStore}
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
 
 	err := lpaStore.Put(ctx, lpa)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, lpa.Version)
+	assert.False(t, lpa.UpdatedAt.IsZero())
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestLpaStorePutWithoutSessionData(t *testing.T) {
+	ctx := context.Background()
+	lpa := &page.Lpa{ID: "5", Version: 3, WhoFor: "me", Type: "pfa"}
+
+	dataStore := &mockDataStore{}
+	dataStore.On("Get", ctx, "LPA#5", "#METADATA#5").Return(nil, &page.Lpa{ID: "5", Version: 3, WhoFor: "me"})
+	dataStore.
+		On("WriteTransaction", ctx, transactionOf(
+			dynamo.TransactionWrite{Kind: dynamo.TransactionPutVersioned, PK: "LPA#5", SK: "#METADATA#5", Version: 4, Value: func(v interface{}) bool {
+				return v.(storedLpa).ID == "5"
+			}},
+			dynamo.TransactionWrite{Kind: dynamo.TransactionPut, PK: "LPA#5", SK: "EVENT#0000000004", Value: func(v interface{}) bool {
+				return assert.Equal(t, storedLpaEvent{LpaEvent: page.LpaEvent{
+					LpaID:     "5",
+					Version:   4,
+					ActorType: page.ActorTypeSystem,
+					Changes:   []page.LpaChange{{Field: "Type", Value: `"pfa"`}},
+					Time:      lpa.UpdatedAt,
+				}}, v)
+			}},
+		)).
+		Return(nil)
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	err := lpaStore.Put(ctx, lpa)
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestLpaStorePutWithEvents(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "5", ActorType: page.ActorTypeDonor, Subject: "a-sub"})
+	lpa := &page.Lpa{ID: "5", Version: 3, Type: "pfa"}
+
+	dataStore := &mockDataStore{}
+	dataStore.On("Get", ctx, "LPA#5", "#METADATA#5").Return(nil, &page.Lpa{ID: "5", Version: 3})
+	dataStore.
+		On("WriteTransaction", ctx, transactionOf(
+			dynamo.TransactionWrite{Kind: dynamo.TransactionPutVersioned, PK: "LPA#5", SK: "#METADATA#5", Version: 4, Value: mock.Anything},
+			dynamo.TransactionWrite{Kind: dynamo.TransactionPut, PK: "LPA#5", SK: "EVENT#0000000004", Value: mock.Anything},
+			dynamo.TransactionWrite{Kind: dynamo.TransactionCreate, PK: "OUTBOX", SK: testOutboxSK, Value: testOutboxEvent},
+		)).
+		Return(nil)
+	dataStore.On("Delete", ctx, "OUTBOX", testOutboxSK).Return(nil)
+
+	publisher := &mockEventPublisher{}
+	publisher.On("Publish", ctx, testRawEvent).Return(nil)
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope, outbox: newTestEventOutbox(nil, dataStore, publisher)}
+
+	err := lpaStore.PutWithEvents(ctx, lpa, []event.Event{event.LpaSigned{LpaID: "lpa-id"}})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, lpa.Version)
+	mock.AssertExpectationsForObjects(t, dataStore, publisher)
+}
+
+func TestLpaStorePutWithEventsWhenPublishErrors(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "5", ActorType: page.ActorTypeDonor, Subject: "a-sub"})
+	lpa := &page.Lpa{ID: "5", Version: 3, Type: "pfa"}
+
+	dataStore := &mockDataStore{}
+	dataStore.On("Get", ctx, "LPA#5", "#METADATA#5").Return(nil, &page.Lpa{ID: "5", Version: 3})
+	dataStore.On("WriteTransaction", ctx, mock.Anything).Return(nil)
+
+	publisher := &mockEventPublisher{}
+	publisher.On("Publish", ctx, testRawEvent).Return(expectedError)
+
+	var buf bytes.Buffer
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope, outbox: newTestEventOutbox(log.New(&buf, "", 0), dataStore, publisher)}
+
+	err := lpaStore.PutWithEvents(ctx, lpa, []event.Event{event.LpaSigned{LpaID: "lpa-id"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "unable to publish lpa-signed event event-id: err\n", buf.String())
+	mock.AssertExpectationsForObjects(t, dataStore, publisher)
+}
+
+func TestLpaStorePutWithEventsWhenWriteError(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "5"})
+	lpa := &page.Lpa{ID: "5", Version: 3}
+
+	dataStore := &mockDataStore{}
+	dataStore.On("Get", ctx, "LPA#5", "#METADATA#5").Return(nil, &page.Lpa{ID: "5", Version: 3})
+	dataStore.On("WriteTransaction", ctx, mock.Anything).Return(expectedError)
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope, outbox: newTestEventOutbox(nil, dataStore, nil)}
+
+	err := lpaStore.PutWithEvents(ctx, lpa, []event.Event{event.LpaSigned{LpaID: "lpa-id"}})
+	assert.Equal(t, expectedError, err)
+	assert.Equal(t, 3, lpa.Version)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestLpaStorePutWithEventsWithoutOutbox(t *testing.T) {
+	lpaStore := &lpaStore{}
+
+	err := lpaStore.PutWithEvents(context.Background(), &page.Lpa{ID: "5"}, []event.Event{event.LpaSigned{LpaID: "lpa-id"}})
+	assert.NotNil(t, err)
+}
+
+func TestLpaStorePutEncryptsPersonalData(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "5", ActorType: page.ActorTypeDonor, Subject: "a-sub"})
+	lpa := &page.Lpa{ID: "5", Version: 3, Type: "pfa", You: actor.Person{FirstNames: "John"}}
+
+	dataStore := &mockDataStore{}
+	dataStore.On("Get", ctx, "LPA#5", "#METADATA#5").Return(nil, &page.Lpa{ID: "5", Version: 3})
+	dataStore.
+		On("WriteTransaction", ctx, transactionOf(
+			dynamo.TransactionWrite{Kind: dynamo.TransactionPutVersioned, PK: "LPA#5", SK: "#METADATA#5", Version: 4, Value: func(v interface{}) bool {
+				stored := v.(storedLpa)
+				return assert.Equal(t, actor.Person{}, stored.You) && assert.Equal(t, "pfa", stored.Type) &&
+					assert.Equal(t, lpa.You, openLpa(stored).You)
+			}},
+			dynamo.TransactionWrite{Kind: dynamo.TransactionPut, PK: "LPA#5", SK: "EVENT#0000000004", Value: func(v interface{}) bool {
+				event := v.(storedLpaEvent)
+
+				var personal []page.LpaChange
+				testEnvelope.Open(ctx, event.Personal, &personal)
+
+				return assert.Equal(t, []page.LpaChange{{Field: "Type", Value: `"pfa"`}}, event.Changes) &&
+					assert.Len(t, personal, 1) && assert.Equal(t, "You", personal[0].Field)
+			}},
+		)).
+		Return(nil)
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	err := lpaStore.Put(ctx, lpa)
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestLpaStoreSealRemovesSigningCodes(t *testing.T) {
+	lpa := &page.Lpa{
+		ID:                   "5",
+		SignatureCode:        "1234",
+		EnteredSignatureCode: "1234",
+		WitnessCode:          page.WitnessCode{Code: "5678", Created: time.Now()},
+	}
+
+	stored, err := (&lpaStore{envelope: testEnvelope}).seal(context.Background(), lpa)
+	assert.Nil(t, err)
+	assert.Equal(t, "", stored.SignatureCode)
+	assert.Equal(t, "", stored.EnteredSignatureCode)
+	assert.Equal(t, page.WitnessCode{}, stored.WitnessCode)
+
+	opened := openLpa(stored)
+	assert.Equal(t, lpa.SignatureCode, opened.SignatureCode)
+	assert.Equal(t, lpa.EnteredSignatureCode, opened.EnteredSignatureCode)
+	assert.True(t, lpa.WitnessCode.Created.Equal(opened.WitnessCode.Created))
+	assert.Equal(t, lpa.WitnessCode.Code, opened.WitnessCode.Code)
+}
+
+func TestLpaStorePutWhenGetError(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "123"})
+	lpa := &page.Lpa{ID: "5", Version: 3}
+
+	dataStore := &mockDataStore{}
+	dataStore.On("Get", ctx, "LPA#5", "#METADATA#5").Return(expectedError)
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	err := lpaStore.Put(ctx, lpa)
+	assert.Equal(t, expectedError, err)
+	assert.Equal(t, 3, lpa.Version)
+}
+
+func TestLpaStorePutWhenWithdrawn(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "5"})
+	lpa := &page.Lpa{ID: "5", Version: 3, WithdrawnAt: time.Now()}
+
+	dataStore := &mockDataStore{}
+	dataStore.On("Get", ctx, "LPA#5", "#METADATA#5").Return(nil, &page.Lpa{ID: "5", Version: 3, WithdrawnAt: time.Now()})
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	err := lpaStore.Put(ctx, lpa)
+	assert.Equal(t, page.ErrLpaWithdrawn, err)
+	assert.Equal(t, 3, lpa.Version)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestLpaStorePutWhenDeleted(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "5"})
+	lpa := &page.Lpa{ID: "5", Version: 3}
+
+	dataStore := &mockDataStore{}
+	dataStore.On("Get", ctx, "LPA#5", "#METADATA#5").Return(nil, &page.Lpa{})
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	err := lpaStore.Put(ctx, lpa)
+	assert.Equal(t, dynamo.ConflictError{PK: "LPA#5", SK: "#METADATA#5"}, err)
+	assert.Equal(t, 3, lpa.Version)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestLpaStorePutWhenWriteError(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "123"})
+	lpa := &page.Lpa{ID: "5", Version: 3}
+
+	dataStore := &mockDataStore{}
+	dataStore.On("Get", ctx, "LPA#5", "#METADATA#5").Return(nil, &page.Lpa{ID: "5", Version: 3})
+	dataStore.On("WriteTransaction", ctx, mock.Anything).Return(expectedError)
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	err := lpaStore.Put(ctx, lpa)
+	assert.Equal(t, expectedError, err)
+	assert.Equal(t, 3, lpa.Version)
+}
+
+func TestLpaStoreDelete(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "lpa-id", Subject: "a-sub", ActorType: page.ActorTypeDonor})
+
+	dataStore := &mockDataStore{}
+	dataStore.On("Get", ctx, "LPA#lpa-id", "SUB#a-sub#donor").Return(nil, lpaLink{LpaID: "lpa-id", Sub: "a-sub", ActorType: page.ActorTypeDonor})
+	dataStore.On("Get", ctx, "LPA#lpa-id", "#METADATA#lpa-id").Return(nil, storedLpa{Lpa: page.Lpa{ID: "lpa-id", Version: 2}})
+	dataStore.On("Get", ctx, "LPA#lpa-id", "PAYMENT#IN_FLIGHT").Return(nil)
+	dataStore.On("GetAllByKeyPrefix", ctx, "LPA#lpa-id", "SUB#").Return(nil, []lpaLink{
+		{LpaID: "lpa-id", Sub: "a-sub", ActorType: page.ActorTypeDonor},
+		{LpaID: "lpa-id", Sub: "b-sub", ActorType: page.ActorTypeCertificateProvider},
+	})
+	dataStore.
+		On("WriteTransaction", ctx, transactionOf(
+			dynamo.TransactionWrite{Kind: dynamo.TransactionPutVersioned, PK: "LPA#lpa-id", SK: "#METADATA#lpa-id", Version: 3, Value: func(v interface{}) bool {
+				stored := v.(storedLpa)
+				return stored.ID == "lpa-id" && stored.Version == 3 && !stored.DeletedAt.IsZero()
+			}},
+			dynamo.TransactionWrite{Kind: dynamo.TransactionDelete, PK: "LPA#lpa-id", SK: "SUB#a-sub#donor"},
+			dynamo.TransactionWrite{Kind: dynamo.TransactionDelete, PK: "LPA#lpa-id", SK: "SUB#a-sub"},
+			dynamo.TransactionWrite{Kind: dynamo.TransactionDelete, PK: legacyPK("a-sub"), SK: "lpa-id"},
+			dynamo.TransactionWrite{Kind: dynamo.TransactionDelete, PK: "LPA#lpa-id", SK: "SUB#b-sub#certificate-provider"},
+			dynamo.TransactionWrite{Kind: dynamo.TransactionDelete, PK: "LPA#lpa-id", SK: "SUB#b-sub"},
+		)).
+		Return(nil)
+	dataStore.On("GetAllByKeyPrefix", ctx, "LPA#lpa-id", "EVENT#").Return(nil, []page.LpaEvent{{Version: 1}, {Version: 2}})
+	dataStore.On("Delete", ctx, "LPA#lpa-id", "EVENT#0000000001").Return(nil)
+	dataStore.On("Delete", ctx, "LPA#lpa-id", "EVENT#0000000002").Return(nil)
+	dataStore.On("GetAllByKeyPrefix", ctx, "LPA#lpa-id", "SHARECODE#").Return(nil, []shareCodeLink{{ShareCode: "123"}})
+	dataStore.On("Delete", ctx, "SHARECODE#123", "#METADATA#123").Return(nil)
+	dataStore.On("Delete", ctx, "LPA#lpa-id", "SHARECODE#123").Return(nil)
+	dataStore.On("GetAllByKeyPrefix", ctx, "LPA#lpa-id", "MESSAGE#").Return(nil, []page.Message{{ID: "notify-id"}})
+	dataStore.On("Delete", ctx, "LPA#lpa-id", "MESSAGE#notify-id").Return(nil)
+	dataStore.On("Delete", ctx, "LPA#lpa-id", "PAYMENT#IN_FLIGHT").Return(nil)
+	dataStore.On("Delete", ctx, "LPA#lpa-id", "RETENTION_WARNING").Return(nil)
+	dataStore.On("Delete", ctx, "LPA#lpa-id", "NOTICE#PENDING").Return(nil)
+	dataStore.On("Delete", ctx, "LPA#lpa-id", "#METADATA#lpa-id").Return(nil)
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	err := lpaStore.Delete(ctx)
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestLpaStoreDeleteWhenMarkedDeleted(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "lpa-id"})
+
+	dataStore := &mockDataStore{}
+	dataStore.On("Get", ctx, "LPA#lpa-id", "#METADATA#lpa-id").Return(nil, storedLpa{Lpa: page.Lpa{ID: "lpa-id", Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}, DeletedAt: time.Now()})
+	dataStore.On("GetAllByKeyPrefix", ctx, "LPA#lpa-id", "EVENT#").Return(nil, []page.LpaEvent{{Version: 1}})
+	dataStore.On("Delete", ctx, "LPA#lpa-id", "EVENT#0000000001").Return(nil)
+	dataStore.On("GetAllByKeyPrefix", ctx, "LPA#lpa-id", "SHARECODE#").Return(nil, []shareCodeLink{})
+	dataStore.On("GetAllByKeyPrefix", ctx, "LPA#lpa-id", "MESSAGE#").Return(nil, []page.Message{})
+	dataStore.On("Delete", ctx, "LPA#lpa-id", "PAYMENT#IN_FLIGHT").Return(nil)
+	dataStore.On("Delete", ctx, "LPA#lpa-id", "RETENTION_WARNING").Return(nil)
+	dataStore.On("Delete", ctx, "LPA#lpa-id", "NOTICE#PENDING").Return(nil)
+	dataStore.On("Delete", ctx, "LPA#lpa-id", "#METADATA#lpa-id").Return(nil)
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	err := lpaStore.Delete(ctx)
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestLpaStoreDeleteWhenAlreadyDeleted(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "lpa-id"})
+
+	dataStore := &mockDataStore{}
+	dataStore.On("Get", ctx, "LPA#lpa-id", "#METADATA#lpa-id").Return(nil)
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	err := lpaStore.Delete(ctx)
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestLpaStoreDeleteWhenNotDonor(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "lpa-id", Subject: "a-sub", ActorType: page.ActorTypeAttorney})
+
+	dataStore := &mockDataStore{}
+	dataStore.On("Get", ctx, "LPA#lpa-id", "SUB#a-sub#donor").Return(nil)
+	dataStore.On("Get", ctx, "LPA#lpa-id", "SUB#a-sub").Return(nil, lpaLink{LpaID: "lpa-id", Sub: "a-sub", ActorType: page.ActorTypeAttorney})
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	err := lpaStore.Delete(ctx)
+	assert.Equal(t, errNotLinked, err)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestLpaStoreDeleteWhenNoLpaID(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{})
+
+	lpaStore := &lpaStore{}
+
+	err := lpaStore.Delete(ctx)
+	assert.NotNil(t, err)
+}
+
+func TestLpaStoreDeleteWhenGetLinkErrors(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "lpa-id", Subject: "a-sub", ActorType: page.ActorTypeDonor})
+
+	dataStore := &mockDataStore{}
+	dataStore.On("Get", ctx, "LPA#lpa-id", "SUB#a-sub#donor").Return(expectedError)
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	err := lpaStore.Delete(ctx)
+	assert.Equal(t, expectedError, err)
+}
+
+func TestLpaStoreDeleteWhenDataStoreErrors(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "lpa-id"})
+
+	testCases := map[string]func(*mockDataStore){
+		"get lpa": func(dataStore *mockDataStore) {
+			dataStore.On("Get", ctx, "LPA#lpa-id", "#METADATA#lpa-id").Return(expectedError)
+		},
+		"get in-flight payment": func(dataStore *mockDataStore) {
+			dataStore.On("Get", ctx, "LPA#lpa-id", "PAYMENT#IN_FLIGHT").Return(expectedError)
+		},
+		"get links": func(dataStore *mockDataStore) {
+			dataStore.On("GetAllByKeyPrefix", ctx, "LPA#lpa-id", "SUB#").Return(expectedError)
+		},
+		"mark deleted": func(dataStore *mockDataStore) {
+			dataStore.On("WriteTransaction", ctx, mock.Anything).Return(expectedError)
+		},
+		"get events": func(dataStore *mockDataStore) {
+			dataStore.On("GetAllByKeyPrefix", ctx, "LPA#lpa-id", "EVENT#").Return(expectedError)
+		},
+		"delete event": func(dataStore *mockDataStore) {
+			dataStore.On("GetAllByKeyPrefix", ctx, "LPA#lpa-id", "EVENT#").Return(nil, []page.LpaEvent{{Version: 1}})
+			dataStore.On("Delete", ctx, "LPA#lpa-id", "EVENT#0000000001").Return(expectedError)
+		},
+		"get share codes": func(dataStore *mockDataStore) {
+			dataStore.On("GetAllByKeyPrefix", ctx, "LPA#lpa-id", "SHARECODE#").Return(expectedError)
+		},
+		"delete share code": func(dataStore *mockDataStore) {
+			dataStore.On("GetAllByKeyPrefix", ctx, "LPA#lpa-id", "SHARECODE#").Return(nil, []shareCodeLink{{ShareCode: "123"}})
+			dataStore.On("Delete", ctx, "SHARECODE#123", "#METADATA#123").Return(expectedError)
+		},
+		"delete share code link": func(dataStore *mockDataStore) {
+			dataStore.On("GetAllByKeyPrefix", ctx, "LPA#lpa-id", "SHARECODE#").Return(nil, []shareCodeLink{{ShareCode: "123"}})
+			dataStore.On("Delete", ctx, "LPA#lpa-id", "SHARECODE#123").Return(expectedError)
+		},
+		"get messages": func(dataStore *mockDataStore) {
+			dataStore.On("GetAllByKeyPrefix", ctx, "LPA#lpa-id", "MESSAGE#").Return(expectedError)
+		},
+		"delete message": func(dataStore *mockDataStore) {
+			dataStore.On("GetAllByKeyPrefix", ctx, "LPA#lpa-id", "MESSAGE#").Return(nil, []page.Message{{ID: "notify-id"}})
+			dataStore.On("Delete", ctx, "LPA#lpa-id", "MESSAGE#notify-id").Return(expectedError)
+		},
+		"delete payment": func(dataStore *mockDataStore) {
+			dataStore.On("Delete", ctx, "LPA#lpa-id", "PAYMENT#IN_FLIGHT").Return(expectedError)
+		},
+		"delete retention warning": func(dataStore *mockDataStore) {
+			dataStore.On("Delete", ctx, "LPA#lpa-id", "RETENTION_WARNING").Return(expectedError)
+		},
+		"delete pending notices": func(dataStore *mockDataStore) {
+			dataStore.On("Delete", ctx, "LPA#lpa-id", "NOTICE#PENDING").Return(expectedError)
+		},
+		"delete lpa": func(dataStore *mockDataStore) {
+			dataStore.On("Delete", ctx, "LPA#lpa-id", "#METADATA#lpa-id").Return(expectedError)
+		},
+	}
+
+	for name, setup := range testCases {
+		t.Run(name, func(t *testing.T) {
+			dataStore := &mockDataStore{}
+			setup(dataStore)
+			dataStore.On("Get", ctx, "LPA#lpa-id", "#METADATA#lpa-id").Return(nil, storedLpa{Lpa: page.Lpa{ID: "lpa-id"}})
+			dataStore.On("Get", ctx, "LPA#lpa-id", mock.Anything).Return(nil)
+			dataStore.On("GetAllByKeyPrefix", ctx, "LPA#lpa-id", mock.Anything).Return(nil, []string{})
+			dataStore.On("WriteTransaction", ctx, mock.Anything).Return(nil)
+			dataStore.On("Delete", ctx, mock.Anything, mock.Anything).Return(nil)
+
+			lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+			err := lpaStore.Delete(ctx)
+			assert.Equal(t, expectedError, err)
+		})
+	}
+}
+
+func TestLpaStoreDeleteWhenNotDeletable(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "lpa-id"})
+
+	testCases := map[string]struct {
+		lpa     *page.Lpa
+		payment page.InFlightPayment
+	}{
+		"paid": {
+			lpa: &page.Lpa{ID: "lpa-id", Tasks: page.Tasks{PayForLpa: page.TaskCompleted}},
+		},
+		"payment started": {
+			lpa: &page.Lpa{ID: "lpa-id", Tasks: page.Tasks{PayForLpa: page.TaskInProgress}},
+		},
+		"payment details": {
+			lpa: &page.Lpa{ID: "lpa-id", PaymentDetails: page.PaymentDetails{PaymentId: "abc123"}},
+		},
+		"evidence sent": {
+			lpa: &page.Lpa{ID: "lpa-id", FeeEvidenceReview: page.TaskInProgress},
+		},
+		"payment in-flight": {
+			lpa:     &page.Lpa{ID: "lpa-id"},
+			payment: page.InFlightPayment{LpaID: "lpa-id", PaymentID: "abc123"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			dataStore := &mockDataStore{}
+			dataStore.On("Get", ctx, "LPA#lpa-id", "#METADATA#lpa-id").Return(nil, tc.lpa)
+			dataStore.On("Get", ctx, "LPA#lpa-id", "PAYMENT#IN_FLIGHT").Return(nil, tc.payment)
+
+			lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+			err := lpaStore.Delete(ctx)
+			assert.Equal(t, page.ErrLpaNotDeletable, err)
+			mock.AssertExpectationsForObjects(t, dataStore)
+		})
+	}
+}
+
+func TestLpaStoreLink(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "123", ActorType: page.ActorTypeCertificateProvider, Subject: "a-sub"})
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("Put", ctx, "LPA#123", "SUB#a-sub#certificate-provider", mock.MatchedBy(func(link lpaLink) bool {
+			return link.LpaID == "123" && link.Sub == "a-sub" && link.ActorType == page.ActorTypeCertificateProvider && !link.LinkedAt.IsZero()
+		})).
+		Return(nil)
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	err := lpaStore.Link(ctx)
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestLpaStoreLinkWhenMissingSessionData(t *testing.T) {
+	testCases := map[string]*page.SessionData{
+		"no lpa":     {Subject: "a-sub"},
+		"no subject": {LpaID: "123"},
+	}
+
+	for name, data := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := page.ContextWithSessionData(context.Background(), data)
+
+			lpaStore := &lpaStore{}
+
+			err := lpaStore.Link(ctx)
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestLpaStoreLinkWhenDataStoreError(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "123", ActorType: page.ActorTypeCertificateProvider, Subject: "a-sub"})
+
+	dataStore := &mockDataStore{}
+	dataStore.On("Put", ctx, "LPA#123", "SUB#a-sub#certificate-provider", mock.Anything).Return(expectedError)
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	err := lpaStore.Link(ctx)
+	assert.Equal(t, expectedError, err)
+}
+
+func TestLpaStoreHistory(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "123"})
+
+	dataStore := &mockDataStore{}
+	dataStore.On("GetAllByKeyPrefix", ctx, "LPA#123", "EVENT#").Return(nil, []page.LpaEvent{{LpaID: "123", Version: 2}, {LpaID: "123", Version: 1}})
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	events, err := lpaStore.History(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []page.LpaEvent{{LpaID: "123", Version: 1}, {LpaID: "123", Version: 2}}, events)
+}
+
+func TestLpaStoreHistoryDecryptsPersonalChanges(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "123"})
+
+	personal, _ := testEnvelope.Seal(ctx, []page.LpaChange{{Field: "You", Value: `{"FirstNames":"John"}`}})
+
+	dataStore := &mockDataStore{}
+	dataStore.On("GetAllByKeyPrefix", ctx, "LPA#123", "EVENT#").Return(nil, []storedLpaEvent{{
+		LpaEvent: page.LpaEvent{LpaID: "123", Version: 1, Changes: []page.LpaChange{{Field: "Type", Value: `"pfa"`}}},
+		Personal: personal,
+	}})
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	events, err := lpaStore.History(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []page.LpaEvent{{LpaID: "123", Version: 1, Changes: []page.LpaChange{
+		{Field: "Type", Value: `"pfa"`},
+		{Field: "You", Value: `{"FirstNames":"John"}`},
+	}}}, events)
+}
+
+func TestLpaStoreHistoryWhenNoLpaID(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{})
+
+	lpaStore := &lpaStore{}
+
+	_, err := lpaStore.History(ctx)
+	assert.NotNil(t, err)
+}
+
+func TestLpaStoreHistoryWhenDataStoreError(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "123"})
+
+	dataStore := &mockDataStore{}
+	dataStore.On("GetAllByKeyPrefix", ctx, "LPA#123", "EVENT#").Return(expectedError)
+
+	lpaStore := &lpaStore{dataStore: dataStore, envelope: testEnvelope}
+
+	_, err := lpaStore.History(ctx)
 	assert.Equal(t, expectedError, err)
 }
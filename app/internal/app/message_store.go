@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/dynamo"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"golang.org/x/exp/slices"
+)
+
+// Messages are stored in the LPA's partition, keyed by MESSAGE#<notify id>, so
+// that a delivery receipt from GOV.UK Notify can find its message through the
+// SK index without knowing which LPA it was sent for.
+
+type messageStore struct {
+	dataStore page.DataStore
+	now       func() time.Time
+}
+
+// Put records a message that has been sent. A message that has already been
+// recorded is left as it is, so that a status from a delivery receipt is not
+// replaced.
+func (s *messageStore) Put(ctx context.Context, message page.Message) error {
+	if message.ID == "" || message.LpaID == "" {
+		return errors.New("messageStore.Put requires ID and LpaID")
+	}
+
+	now := s.now()
+	message.CreatedAt = now
+	message.UpdatedAt = now
+	message.Version = 1
+
+	err := s.dataStore.Create(ctx, lpaPK(message.LpaID), messageSK(message.ID), message)
+	if errors.As(err, &dynamo.ConflictError{}) {
+		return nil
+	}
+
+	return err
+}
+
+// GetAll returns the messages sent for the LPA in the session data, oldest
+// first.
+func (s *messageStore) GetAll(ctx context.Context) ([]page.Message, error) {
+	data := page.SessionDataFromContext(ctx)
+	if data.LpaID == "" {
+		return nil, errors.New("messageStore.GetAll requires LpaID")
+	}
+
+	var messages []page.Message
+	if err := s.dataStore.GetAllByKeyPrefix(ctx, lpaPK(data.LpaID), "MESSAGE#", &messages); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(messages, func(a, b page.Message) bool {
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+
+	return messages, nil
+}
+
+// UpdateStatus sets the status of the message with the given Notify ID,
+// returning page.ErrMessageNotFound if it was not sent by this service. If the
+// message has been changed since it was read a dynamo.ConflictError is
+// returned, so that the receipt can be retried.
+func (s *messageStore) UpdateStatus(ctx context.Context, id, status string, completedAt time.Time) error {
+	var messages []page.Message
+	if err := s.dataStore.GetAllBySK(ctx, messageSK(id), &messages); err != nil {
+		return err
+	}
+
+	if len(messages) == 0 {
+		return page.ErrMessageNotFound
+	}
+
+	message := messages[0]
+	message.Status = status
+	message.UpdatedAt = s.now()
+	message.CompletedAt = completedAt
+	message.Version++
+
+	return s.dataStore.PutVersioned(ctx, lpaPK(message.LpaID), messageSK(message.ID), message, message.Version)
+}
+
+func messageSK(id string) string {
+	return "MESSAGE#" + id
+}
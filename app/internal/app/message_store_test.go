@@ -0,0 +1,200 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/dynamo"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMessageStorePut(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2023, time.January, 2, 3, 4, 5, 0, time.UTC)
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("Create", ctx, "LPA#lpa-id", "MESSAGE#notify-id", page.Message{
+			ID:            "notify-id",
+			LpaID:         "lpa-id",
+			RecipientRole: page.ActorTypeCertificateProvider,
+			Template:      "certificate-provider-invite-email",
+			Status:        notify.StatusCreated,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+			Version:       1,
+		}).
+		Return(nil)
+
+	messageStore := &messageStore{dataStore: dataStore, now: func() time.Time { return now }}
+	err := messageStore.Put(ctx, page.Message{
+		ID:            "notify-id",
+		LpaID:         "lpa-id",
+		RecipientRole: page.ActorTypeCertificateProvider,
+		Template:      "certificate-provider-invite-email",
+		Status:        notify.StatusCreated,
+	})
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestMessageStorePutWhenAlreadyRecorded(t *testing.T) {
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("Create", mock.Anything, "LPA#lpa-id", "MESSAGE#notify-id", mock.Anything).
+		Return(dynamo.ConflictError{})
+
+	messageStore := &messageStore{dataStore: dataStore, now: time.Now}
+	err := messageStore.Put(context.Background(), page.Message{ID: "notify-id", LpaID: "lpa-id", Status: notify.StatusCreated})
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestMessageStorePutWhenDataStoreErrors(t *testing.T) {
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(expectedError)
+
+	messageStore := &messageStore{dataStore: dataStore, now: time.Now}
+	err := messageStore.Put(context.Background(), page.Message{ID: "notify-id", LpaID: "lpa-id"})
+
+	assert.Equal(t, expectedError, err)
+}
+
+func TestMessageStorePutWhenMissingIDs(t *testing.T) {
+	testCases := map[string]page.Message{
+		"no id":     {LpaID: "lpa-id"},
+		"no lpa id": {ID: "notify-id"},
+	}
+
+	for name, message := range testCases {
+		t.Run(name, func(t *testing.T) {
+			messageStore := &messageStore{now: time.Now}
+			err := messageStore.Put(context.Background(), message)
+
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestMessageStoreGetAll(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "lpa-id"})
+	first := time.Date(2023, time.January, 2, 3, 4, 5, 0, time.UTC)
+	second := first.Add(time.Hour)
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("GetAllByKeyPrefix", ctx, "LPA#lpa-id", "MESSAGE#").
+		Return(nil, []page.Message{{ID: "2", CreatedAt: second}, {ID: "1", CreatedAt: first}})
+
+	messageStore := &messageStore{dataStore: dataStore}
+	messages, err := messageStore.GetAll(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []page.Message{{ID: "1", CreatedAt: first}, {ID: "2", CreatedAt: second}}, messages)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestMessageStoreGetAllWhenNoLpaID(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{})
+
+	messageStore := &messageStore{}
+	_, err := messageStore.GetAll(ctx)
+
+	assert.NotNil(t, err)
+}
+
+func TestMessageStoreGetAllWhenDataStoreErrors(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "lpa-id"})
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("GetAllByKeyPrefix", ctx, mock.Anything, mock.Anything).
+		Return(expectedError)
+
+	messageStore := &messageStore{dataStore: dataStore}
+	_, err := messageStore.GetAll(ctx)
+
+	assert.Equal(t, expectedError, err)
+}
+
+func TestMessageStoreUpdateStatus(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2023, time.January, 2, 3, 4, 5, 0, time.UTC)
+	completed := created.Add(time.Minute)
+	now := created.Add(time.Hour)
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("GetAllBySK", ctx, "MESSAGE#notify-id").
+		Return(nil, []page.Message{{ID: "notify-id", LpaID: "lpa-id", Status: notify.StatusCreated, CreatedAt: created, UpdatedAt: created, Version: 1}})
+	dataStore.
+		On("PutVersioned", ctx, "LPA#lpa-id", "MESSAGE#notify-id", page.Message{
+			ID:          "notify-id",
+			LpaID:       "lpa-id",
+			Status:      notify.StatusDelivered,
+			CreatedAt:   created,
+			UpdatedAt:   now,
+			CompletedAt: completed,
+			Version:     2,
+		}, 2).
+		Return(nil)
+
+	messageStore := &messageStore{dataStore: dataStore, now: func() time.Time { return now }}
+	err := messageStore.UpdateStatus(ctx, "notify-id", notify.StatusDelivered, completed)
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestMessageStoreUpdateStatusWhenNotFound(t *testing.T) {
+	ctx := context.Background()
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("GetAllBySK", ctx, "MESSAGE#notify-id").
+		Return(nil, []page.Message{})
+
+	messageStore := &messageStore{dataStore: dataStore, now: time.Now}
+	err := messageStore.UpdateStatus(ctx, "notify-id", notify.StatusDelivered, time.Now())
+
+	assert.Equal(t, page.ErrMessageNotFound, err)
+}
+
+func TestMessageStoreUpdateStatusWhenDataStoreErrors(t *testing.T) {
+	ctx := context.Background()
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("GetAllBySK", ctx, mock.Anything).
+		Return(expectedError)
+
+	messageStore := &messageStore{dataStore: dataStore, now: time.Now}
+	err := messageStore.UpdateStatus(ctx, "notify-id", notify.StatusDelivered, time.Now())
+
+	assert.Equal(t, expectedError, err)
+}
+
+func TestMessageStoreUpdateStatusWhenChanged(t *testing.T) {
+	ctx := context.Background()
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("GetAllBySK", ctx, "MESSAGE#notify-id").
+		Return(nil, []page.Message{{ID: "notify-id", LpaID: "lpa-id", Version: 1}})
+	dataStore.
+		On("PutVersioned", ctx, "LPA#lpa-id", "MESSAGE#notify-id", mock.Anything, 2).
+		Return(dynamo.ConflictError{})
+
+	messageStore := &messageStore{dataStore: dataStore, now: time.Now}
+	err := messageStore.UpdateStatus(ctx, "notify-id", notify.StatusDelivered, time.Now())
+
+	assert.Equal(t, dynamo.ConflictError{}, err)
+}
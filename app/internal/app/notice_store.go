@@ -0,0 +1,52 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+)
+
+// Pending notices are stored in the LPA's partition, all with the same sort
+// key, so that they can be found together through the SK index.
+const pendingNoticesSK = "NOTICE#PENDING"
+
+type noticeStore struct {
+	dataStore page.DataStore
+	now       func() time.Time
+}
+
+// PutPending records that the LPA in the session data has notices to people
+// to notify that still need sending.
+func (s *noticeStore) PutPending(ctx context.Context) error {
+	data := page.SessionDataFromContext(ctx)
+	if data.LpaID == "" || data.Subject == "" {
+		return errors.New("noticeStore.PutPending requires LpaID and Subject")
+	}
+
+	return s.dataStore.Put(ctx, lpaPK(data.LpaID), pendingNoticesSK, page.PendingNotices{
+		LpaID:   data.LpaID,
+		Sub:     data.Subject,
+		Created: s.now(),
+	})
+}
+
+// DeletePending removes the pending notices marker for the LPA in the session
+// data, once there is nothing left to send.
+func (s *noticeStore) DeletePending(ctx context.Context) error {
+	data := page.SessionDataFromContext(ctx)
+	if data.LpaID == "" {
+		return errors.New("noticeStore.DeletePending requires LpaID")
+	}
+
+	return s.dataStore.Delete(ctx, lpaPK(data.LpaID), pendingNoticesSK)
+}
+
+// GetAllPending returns the pending notices markers for all LPAs.
+func (s *noticeStore) GetAllPending(ctx context.Context) ([]page.PendingNotices, error) {
+	var pending []page.PendingNotices
+	err := s.dataStore.GetAllBySK(ctx, pendingNoticesSK, &pending)
+
+	return pending, err
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNoticeStorePutPending(t *testing.T) {
+	now := time.Date(2023, time.January, 2, 3, 4, 5, 0, time.UTC)
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "lpa-id", Subject: "a-sub"})
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("Put", ctx, "LPA#lpa-id", "NOTICE#PENDING", page.PendingNotices{LpaID: "lpa-id", Sub: "a-sub", Created: now}).
+		Return(nil)
+
+	noticeStore := &noticeStore{dataStore: dataStore, now: func() time.Time { return now }}
+	err := noticeStore.PutPending(ctx)
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestNoticeStorePutPendingWhenMissingSessionData(t *testing.T) {
+	testCases := map[string]*page.SessionData{
+		"no lpa id":  {Subject: "a-sub"},
+		"no subject": {LpaID: "lpa-id"},
+	}
+
+	for name, sessionData := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := page.ContextWithSessionData(context.Background(), sessionData)
+
+			noticeStore := &noticeStore{now: time.Now}
+			err := noticeStore.PutPending(ctx)
+
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestNoticeStorePutPendingWhenDataStoreErrors(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "lpa-id", Subject: "a-sub"})
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("Put", ctx, mock.Anything, mock.Anything, mock.Anything).
+		Return(expectedError)
+
+	noticeStore := &noticeStore{dataStore: dataStore, now: time.Now}
+	err := noticeStore.PutPending(ctx)
+
+	assert.Equal(t, expectedError, err)
+}
+
+func TestNoticeStoreDeletePending(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "lpa-id"})
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("Delete", ctx, "LPA#lpa-id", "NOTICE#PENDING").
+		Return(expectedError)
+
+	noticeStore := &noticeStore{dataStore: dataStore}
+	err := noticeStore.DeletePending(ctx)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestNoticeStoreDeletePendingWhenNoLpaID(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{})
+
+	noticeStore := &noticeStore{}
+	err := noticeStore.DeletePending(ctx)
+
+	assert.NotNil(t, err)
+}
+
+func TestNoticeStoreGetAllPending(t *testing.T) {
+	ctx := context.Background()
+	pending := []page.PendingNotices{{LpaID: "lpa-id", Sub: "a-sub"}}
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("GetAllBySK", ctx, "NOTICE#PENDING").
+		Return(expectedError, pending)
+
+	noticeStore := &noticeStore{dataStore: dataStore}
+	result, err := noticeStore.GetAllPending(ctx)
+
+	assert.Equal(t, expectedError, err)
+	assert.Equal(t, pending, result)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
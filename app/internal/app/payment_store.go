@@ -0,0 +1,71 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+)
+
+// In-flight payments are stored in the LPA's partition, all with the same sort
+// key, so that they can be found together through the SK index.
+const inFlightPaymentSK = "PAYMENT#IN_FLIGHT"
+
+// GOV.UK Pay finishes a payment 90 minutes after it is created, so an
+// in-flight payment that is still around after this has been missed.
+const inFlightPaymentTTL = 7 * 24 * time.Hour
+
+type inFlightPayment struct {
+	page.InFlightPayment
+	Expires time.Time
+}
+
+func (p inFlightPayment) ExpiresAt() time.Time {
+	return p.Expires
+}
+
+type paymentStore struct {
+	dataStore page.DataStore
+	now       func() time.Time
+}
+
+// PutInFlight records that the donor in the session data has started a payment
+// for their LPA.
+func (s *paymentStore) PutInFlight(ctx context.Context, paymentID string) error {
+	data := page.SessionDataFromContext(ctx)
+	if data.LpaID == "" || data.Subject == "" {
+		return errors.New("paymentStore.PutInFlight requires LpaID and Subject")
+	}
+
+	now := s.now()
+
+	return s.dataStore.Put(ctx, lpaPK(data.LpaID), inFlightPaymentSK, inFlightPayment{
+		InFlightPayment: page.InFlightPayment{
+			LpaID:     data.LpaID,
+			Sub:       data.Subject,
+			PaymentID: paymentID,
+			Created:   now,
+		},
+		Expires: now.Add(inFlightPaymentTTL),
+	})
+}
+
+// DeleteInFlight removes the in-flight payment for the LPA in the session
+// data, once it has finished.
+func (s *paymentStore) DeleteInFlight(ctx context.Context) error {
+	data := page.SessionDataFromContext(ctx)
+	if data.LpaID == "" {
+		return errors.New("paymentStore.DeleteInFlight requires LpaID")
+	}
+
+	return s.dataStore.Delete(ctx, lpaPK(data.LpaID), inFlightPaymentSK)
+}
+
+// GetAllInFlight returns the in-flight payments for all LPAs.
+func (s *paymentStore) GetAllInFlight(ctx context.Context) ([]page.InFlightPayment, error) {
+	var payments []page.InFlightPayment
+	err := s.dataStore.GetAllBySK(ctx, inFlightPaymentSK, &payments)
+
+	return payments, err
+}
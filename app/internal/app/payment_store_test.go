@@ -0,0 +1,103 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPaymentStorePutInFlight(t *testing.T) {
+	now := time.Date(2023, time.January, 2, 3, 4, 5, 0, time.UTC)
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "lpa-id", Subject: "a-sub"})
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("Put", ctx, "LPA#lpa-id", "PAYMENT#IN_FLIGHT", inFlightPayment{
+			InFlightPayment: page.InFlightPayment{LpaID: "lpa-id", Sub: "a-sub", PaymentID: "payment-id", Created: now},
+			Expires:         now.Add(inFlightPaymentTTL),
+		}).
+		Return(nil)
+
+	paymentStore := &paymentStore{dataStore: dataStore, now: func() time.Time { return now }}
+	err := paymentStore.PutInFlight(ctx, "payment-id")
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestPaymentStorePutInFlightWhenMissingSessionData(t *testing.T) {
+	testCases := map[string]*page.SessionData{
+		"no lpa id":  {Subject: "a-sub"},
+		"no subject": {LpaID: "lpa-id"},
+	}
+
+	for name, sessionData := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := page.ContextWithSessionData(context.Background(), sessionData)
+
+			paymentStore := &paymentStore{now: time.Now}
+			err := paymentStore.PutInFlight(ctx, "payment-id")
+
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestPaymentStorePutInFlightWhenDataStoreErrors(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "lpa-id", Subject: "a-sub"})
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("Put", ctx, mock.Anything, mock.Anything, mock.Anything).
+		Return(expectedError)
+
+	paymentStore := &paymentStore{dataStore: dataStore, now: time.Now}
+	err := paymentStore.PutInFlight(ctx, "payment-id")
+
+	assert.Equal(t, expectedError, err)
+}
+
+func TestPaymentStoreDeleteInFlight(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "lpa-id"})
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("Delete", ctx, "LPA#lpa-id", "PAYMENT#IN_FLIGHT").
+		Return(expectedError)
+
+	paymentStore := &paymentStore{dataStore: dataStore}
+	err := paymentStore.DeleteInFlight(ctx)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestPaymentStoreDeleteInFlightWhenNoLpaID(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{})
+
+	paymentStore := &paymentStore{}
+	err := paymentStore.DeleteInFlight(ctx)
+
+	assert.NotNil(t, err)
+}
+
+func TestPaymentStoreGetAllInFlight(t *testing.T) {
+	ctx := context.Background()
+	payments := []page.InFlightPayment{{LpaID: "lpa-id", Sub: "a-sub", PaymentID: "payment-id"}}
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("GetAllBySK", ctx, "PAYMENT#IN_FLIGHT").
+		Return(expectedError, payments)
+
+	paymentStore := &paymentStore{dataStore: dataStore}
+	result, err := paymentStore.GetAllInFlight(ctx)
+
+	assert.Equal(t, expectedError, err)
+	assert.Equal(t, payments, result)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+)
+
+// Pending registrations are stored in the LPA's partition, all with the same
+// sort key, so that they can be found together through the SK index.
+const pendingRegistrationSK = "REGISTRATION#PENDING"
+
+type registrationStore struct {
+	dataStore page.DataStore
+	now       func() time.Time
+}
+
+// PutPending records that the LPA in the session data needs sending to the LPA
+// store.
+func (s *registrationStore) PutPending(ctx context.Context) error {
+	data := page.SessionDataFromContext(ctx)
+	if data.LpaID == "" || data.Subject == "" {
+		return errors.New("registrationStore.PutPending requires LpaID and Subject")
+	}
+
+	return s.dataStore.Put(ctx, lpaPK(data.LpaID), pendingRegistrationSK, page.PendingRegistration{
+		LpaID:   data.LpaID,
+		Sub:     data.Subject,
+		Created: s.now(),
+	})
+}
+
+// DeletePending removes the pending registration marker for the LPA in the
+// session data, once it has been registered.
+func (s *registrationStore) DeletePending(ctx context.Context) error {
+	data := page.SessionDataFromContext(ctx)
+	if data.LpaID == "" {
+		return errors.New("registrationStore.DeletePending requires LpaID")
+	}
+
+	return s.dataStore.Delete(ctx, lpaPK(data.LpaID), pendingRegistrationSK)
+}
+
+// GetAllPending returns the pending registration markers for all LPAs.
+func (s *registrationStore) GetAllPending(ctx context.Context) ([]page.PendingRegistration, error) {
+	var pending []page.PendingRegistration
+	err := s.dataStore.GetAllBySK(ctx, pendingRegistrationSK, &pending)
+
+	return pending, err
+}
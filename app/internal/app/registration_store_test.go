@@ -0,0 +1,100 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRegistrationStorePutPending(t *testing.T) {
+	now := time.Date(2023, time.January, 2, 3, 4, 5, 0, time.UTC)
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "lpa-id", Subject: "a-sub"})
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("Put", ctx, "LPA#lpa-id", "REGISTRATION#PENDING", page.PendingRegistration{LpaID: "lpa-id", Sub: "a-sub", Created: now}).
+		Return(nil)
+
+	registrationStore := &registrationStore{dataStore: dataStore, now: func() time.Time { return now }}
+	err := registrationStore.PutPending(ctx)
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestRegistrationStorePutPendingWhenMissingSessionData(t *testing.T) {
+	testCases := map[string]*page.SessionData{
+		"no lpa id":  {Subject: "a-sub"},
+		"no subject": {LpaID: "lpa-id"},
+	}
+
+	for name, sessionData := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := page.ContextWithSessionData(context.Background(), sessionData)
+
+			registrationStore := &registrationStore{now: time.Now}
+			err := registrationStore.PutPending(ctx)
+
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestRegistrationStorePutPendingWhenDataStoreErrors(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "lpa-id", Subject: "a-sub"})
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("Put", ctx, mock.Anything, mock.Anything, mock.Anything).
+		Return(expectedError)
+
+	registrationStore := &registrationStore{dataStore: dataStore, now: time.Now}
+	err := registrationStore.PutPending(ctx)
+
+	assert.Equal(t, expectedError, err)
+}
+
+func TestRegistrationStoreDeletePending(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "lpa-id"})
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("Delete", ctx, "LPA#lpa-id", "REGISTRATION#PENDING").
+		Return(expectedError)
+
+	registrationStore := &registrationStore{dataStore: dataStore}
+	err := registrationStore.DeletePending(ctx)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestRegistrationStoreDeletePendingWhenNoLpaID(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{})
+
+	registrationStore := &registrationStore{}
+	err := registrationStore.DeletePending(ctx)
+
+	assert.NotNil(t, err)
+}
+
+func TestRegistrationStoreGetAllPending(t *testing.T) {
+	ctx := context.Background()
+	pending := []page.PendingRegistration{{LpaID: "lpa-id", Sub: "a-sub"}}
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("GetAllBySK", ctx, "REGISTRATION#PENDING").
+		Return(expectedError, pending)
+
+	registrationStore := &registrationStore{dataStore: dataStore}
+	result, err := registrationStore.GetAllPending(ctx)
+
+	assert.Equal(t, expectedError, err)
+	assert.Equal(t, pending, result)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
@@ -0,0 +1,327 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/encryption"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+)
+
+// A retention warning is stored in the LPA's partition (SK RETENTION_WARNING)
+// when the donor is told their draft will be deleted. It records the UpdatedAt
+// of the draft it was sent for, so that a draft changed after the warning has
+// to be warned about again before it can be deleted.
+const retentionWarningSK = "RETENTION_WARNING"
+
+type retentionWarning struct {
+	LpaID        string
+	UpdatedAt    time.Time
+	WarnedAt     time.Time
+	DeleteAfter  time.Time
+	EmailedDonor bool
+}
+
+// Each record removed, and each warning given, is written as a retentionAudit
+// under PK RETENTION_AUDIT, sorted by when the purge ran, so that there is a
+// lasting record of what the retention policy did. It holds no personal data.
+const retentionAuditPK = "RETENTION_AUDIT"
+
+type retentionAction string
+
+const (
+	retentionWarnedDonor        = retentionAction("warned-donor")
+	retentionDeletedDraftLpa    = retentionAction("deleted-draft-lpa")
+	retentionDeletedShareCode   = retentionAction("deleted-share-code")
+	retentionDeletedWitnessCode = retentionAction("deleted-witness-code")
+)
+
+type retentionAudit struct {
+	Action retentionAction
+	LpaID  string
+	// ActorType is who a deleted share code was for.
+	ActorType page.ActorType
+	// RecordTime is when the record was last updated, expired or was created,
+	// from which it was due to be removed.
+	RecordTime time.Time
+	// DeleteAfter and EmailedDonor are set when the donor was warned.
+	DeleteAfter  time.Time
+	EmailedDonor bool
+	AuditedAt    time.Time
+}
+
+// A RetentionPolicy sets how long each type of record is kept once it is no
+// longer needed.
+type RetentionPolicy struct {
+	// DraftLpa is how long an LPA that has not been paid for is kept after it
+	// was last updated.
+	DraftLpa time.Duration
+	// DraftLpaWarning is how long before a draft is deleted that the donor is
+	// warned. A draft is never deleted sooner than this after the warning.
+	DraftLpaWarning time.Duration
+	// ShareCode is how long a share code is kept after it expires.
+	ShareCode time.Duration
+	// WitnessCode is how long a witness code is kept after it was created.
+	WitnessCode time.Duration
+}
+
+// DefaultRetentionPolicy is used for any rule that is not configured.
+var DefaultRetentionPolicy = RetentionPolicy{
+	DraftLpa:        365 * 24 * time.Hour,
+	DraftLpaWarning: 28 * 24 * time.Hour,
+	ShareCode:       7 * 24 * time.Hour,
+	WitnessCode:     24 * time.Hour,
+}
+
+type purger struct {
+	logger       page.Logger
+	dataStore    page.DataStore
+	lpaStore     *lpaStore
+	notifyClient page.NotifyClient
+	appPublicURL string
+	policy       RetentionPolicy
+	now          time.Time
+	removed      int
+	warned       int
+	audited      int
+}
+
+// PurgeExpired applies policy to every stored LPA: donors are warned by email
+// before their draft is deleted, drafts that have been warned about for long
+// enough are deleted, and expired share codes and witness codes are removed.
+// Each removal is written as an audit record, see retentionAudit, and logged.
+// An LPA that cannot be purged is logged and left to be tried again on the next
+// run.
+func PurgeExpired(ctx context.Context, logger page.Logger, dataStore page.DataStore, keyProvider encryption.KeyProvider, notifyClient page.NotifyClient, appPublicURL string, policy RetentionPolicy, now time.Time) error {
+	p := &purger{
+		logger:       logger,
+		dataStore:    dataStore,
+		lpaStore:     &lpaStore{logger: logger, dataStore: dataStore, envelope: encryption.New(keyProvider)},
+		notifyClient: notifyClient,
+		appPublicURL: appPublicURL,
+		policy:       policy,
+		now:          now,
+	}
+
+	var scanned, failed int
+	cursor := ""
+	for {
+		var items []storedLpa
+		next, err := dataStore.ScanByKeyPrefix(ctx, lpaPK(""), lpaSK(""), cursor, &items)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			scanned++
+			if err := p.purgeLpa(ctx, item); err != nil {
+				logger.Print(fmt.Sprintf("unable to purge lpa %s: %s", item.ID, err.Error()))
+				failed++
+			}
+		}
+
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	logger.Print(fmt.Sprintf("retention: %d lpas scanned, %d records removed, %d donors warned, %d failed", scanned, p.removed, p.warned, failed))
+
+	if failed > 0 {
+		return fmt.Errorf("unable to purge %d of %d lpas", failed, scanned)
+	}
+
+	return nil
+}
+
+// audit writes record, then logs what was done as described by format.
+func (p *purger) audit(ctx context.Context, record retentionAudit, format string, a ...interface{}) error {
+	p.audited++
+	record.AuditedAt = p.now
+
+	if err := p.dataStore.Put(ctx, retentionAuditPK, retentionAuditSK(p.now, p.audited), record); err != nil {
+		return err
+	}
+
+	p.logger.Print("retention: " + fmt.Sprintf(format, a...))
+	return nil
+}
+
+func retentionAuditSK(ranAt time.Time, n int) string {
+	return fmt.Sprintf("%s#%06d", ranAt.UTC().Format(time.RFC3339), n)
+}
+
+func (p *purger) purgeLpa(ctx context.Context, item storedLpa) error {
+	// The LPA was marked as deleted, but removing it did not finish.
+	if !item.DeletedAt.IsZero() {
+		return p.lpaStore.Delete(page.ContextWithSessionData(ctx, &page.SessionData{LpaID: item.ID}))
+	}
+
+	lpa, err := p.lpaStore.open(ctx, item)
+	if err != nil {
+		return err
+	}
+
+	if err := migrateLpa(lpa, item.SchemaVersion); err != nil {
+		return err
+	}
+
+	ctx = page.ContextWithSessionData(ctx, &page.SessionData{LpaID: lpa.ID})
+
+	if lpa.CanBeDeleted() {
+		deleted, err := p.purgeDraft(ctx, lpa)
+		if err != nil || deleted {
+			return err
+		}
+	}
+
+	if err := p.purgeShareCodes(ctx, lpa.ID); err != nil {
+		return err
+	}
+
+	return p.purgeWitnessCode(ctx, lpa)
+}
+
+// purgeDraft warns the donor once the draft is close to being deleted, and
+// deletes it once it is due and the warning has been given for long enough.
+// Drafts that the donor has started to pay for are kept, see
+// page.Lpa.CanBeDeleted, as is one with a payment in-flight.
+func (p *purger) purgeDraft(ctx context.Context, lpa *page.Lpa) (bool, error) {
+	deleteAfter := lpa.UpdatedAt.Add(p.policy.DraftLpa)
+	if p.now.Before(deleteAfter.Add(-p.policy.DraftLpaWarning)) {
+		return false, nil
+	}
+
+	var warning retentionWarning
+	if err := p.dataStore.Get(ctx, lpaPK(lpa.ID), retentionWarningSK, &warning); err != nil {
+		return false, err
+	}
+
+	if warning.WarnedAt.IsZero() || !warning.UpdatedAt.Equal(lpa.UpdatedAt) {
+		return false, p.warnDonor(ctx, lpa, deleteAfter)
+	}
+
+	if p.now.Before(warning.DeleteAfter) {
+		return false, nil
+	}
+
+	if err := p.lpaStore.Delete(ctx); err != nil {
+		if errors.Is(err, page.ErrLpaNotDeletable) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	p.removed++
+	return true, p.audit(ctx, retentionAudit{
+		Action:     retentionDeletedDraftLpa,
+		LpaID:      lpa.ID,
+		RecordTime: lpa.UpdatedAt,
+	}, "deleted draft lpa %s last updated %s", lpa.ID, lpa.UpdatedAt.Format(time.RFC3339))
+}
+
+func (p *purger) warnDonor(ctx context.Context, lpa *page.Lpa, deleteAfter time.Time) error {
+	if earliest := p.now.Add(p.policy.DraftLpaWarning); deleteAfter.Before(earliest) {
+		deleteAfter = earliest
+	}
+
+	warning := retentionWarning{
+		LpaID:       lpa.ID,
+		UpdatedAt:   lpa.UpdatedAt,
+		WarnedAt:    p.now,
+		DeleteAfter: deleteAfter,
+	}
+
+	if lpa.You.Email != "" {
+		if _, err := p.notifyClient.Email(ctx, notify.Email{
+			TemplateID:   p.notifyClient.TemplateID(notify.DraftLpaDeletionWarningEmail, lpa.ContactLanguagePreference),
+			EmailAddress: lpa.You.Email,
+			Reference:    lpa.ID + "/" + notify.DraftLpaDeletionWarningEmail.String() + "/" + deleteAfter.Format("2006-01-02"),
+			Personalisation: map[string]string{
+				"donorFullName": lpa.You.FullName(),
+				"deletionDate":  deleteAfter.Format("2 January 2006"),
+				"link":          p.appPublicURL + page.Paths.Dashboard,
+			},
+		}); err != nil {
+			return err
+		}
+
+		warning.EmailedDonor = true
+	}
+
+	if err := p.dataStore.Put(ctx, lpaPK(lpa.ID), retentionWarningSK, warning); err != nil {
+		return err
+	}
+
+	p.warned++
+	return p.audit(ctx, retentionAudit{
+		Action:       retentionWarnedDonor,
+		LpaID:        lpa.ID,
+		RecordTime:   lpa.UpdatedAt,
+		DeleteAfter:  deleteAfter,
+		EmailedDonor: warning.EmailedDonor,
+	}, "warned donor of lpa %s that it will be deleted after %s, emailed: %t", lpa.ID, deleteAfter.Format(time.RFC3339), warning.EmailedDonor)
+}
+
+func (p *purger) purgeShareCodes(ctx context.Context, lpaID string) error {
+	var links []shareCodeLink
+	if err := p.dataStore.GetAllByKeyPrefix(ctx, lpaPK(lpaID), "SHARECODE#", &links); err != nil {
+		return err
+	}
+
+	for _, link := range links {
+		if p.now.Before(link.Expires.Add(p.policy.ShareCode)) {
+			continue
+		}
+
+		if err := p.dataStore.Delete(ctx, shareCodePK(link.ShareCode), shareCodeSK(link.ShareCode)); err != nil {
+			return err
+		}
+
+		if err := p.dataStore.Delete(ctx, lpaPK(lpaID), shareCodePK(link.ShareCode)); err != nil {
+			return err
+		}
+
+		p.removed++
+		if err := p.audit(ctx, retentionAudit{
+			Action:     retentionDeletedShareCode,
+			LpaID:      lpaID,
+			ActorType:  link.ActorType,
+			RecordTime: link.Expires,
+		}, "deleted share code for %s of lpa %s that expired %s", link.ActorType, lpaID, link.Expires.Format(time.RFC3339)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *purger) purgeWitnessCode(ctx context.Context, lpa *page.Lpa) error {
+	if lpa.WitnessCode.Code == "" || p.now.Before(lpa.WitnessCode.Created.Add(p.policy.WitnessCode)) {
+		return nil
+	}
+
+	previous := *lpa
+	lpa.WitnessCode = page.WitnessCode{}
+
+	changes, err := page.DiffLpa(&previous, lpa)
+	if err != nil {
+		return err
+	}
+
+	if err := p.lpaStore.rewrite(ctx, lpa, changes); err != nil {
+		return err
+	}
+
+	p.removed++
+	return p.audit(ctx, retentionAudit{
+		Action:     retentionDeletedWitnessCode,
+		LpaID:      lpa.ID,
+		RecordTime: previous.WitnessCode.Created,
+	}, "deleted witness code of lpa %s created %s", lpa.ID, previous.WitnessCode.Created.Format(time.RFC3339))
+}
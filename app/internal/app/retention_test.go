@@ -0,0 +1,341 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/dynamo"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/localize"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockNotifyClient struct {
+	mock.Mock
+}
+
+func (m *mockNotifyClient) TemplateID(id notify.TemplateId, lang localize.Lang) string {
+	return m.Called(id, lang).String(0)
+}
+
+func (m *mockNotifyClient) Letter(ctx context.Context, letter notify.Letter) (string, error) {
+	args := m.Called(ctx, letter)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockNotifyClient) Email(ctx context.Context, email notify.Email) (string, error) {
+	args := m.Called(ctx, email)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockNotifyClient) Sms(ctx context.Context, sms notify.Sms) (string, error) {
+	args := m.Called(ctx, sms)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockNotifyClient) FindByReference(ctx context.Context, notificationType, reference string) (string, error) {
+	args := m.Called(ctx, notificationType, reference)
+	return args.String(0), args.Error(1)
+}
+
+var (
+	testNow    = time.Date(2023, time.June, 1, 12, 0, 0, 0, time.UTC)
+	testPolicy = RetentionPolicy{
+		DraftLpa:        365 * 24 * time.Hour,
+		DraftLpaWarning: 28 * 24 * time.Hour,
+		ShareCode:       7 * 24 * time.Hour,
+		WitnessCode:     24 * time.Hour,
+	}
+)
+
+func daysAgo(days int) time.Time {
+	return testNow.Add(-time.Duration(days) * 24 * time.Hour)
+}
+
+func sealLpa(lpa *page.Lpa) storedLpa {
+	stored, _ := (&lpaStore{envelope: testEnvelope}).seal(context.Background(), lpa)
+	return stored
+}
+
+func TestPurgeExpired(t *testing.T) {
+	ctx := context.Background()
+
+	warnable := sealLpa(&page.Lpa{ID: "1", Version: 1, UpdatedAt: daysAgo(360), You: actor.Person{FirstNames: "John", LastName: "Smith", Email: "john@example.com"}})
+	deletable := sealLpa(&page.Lpa{ID: "2", Version: 1, UpdatedAt: daysAgo(400)})
+	paid := sealLpa(&page.Lpa{ID: "3", Version: 1, UpdatedAt: daysAgo(400), Tasks: page.Tasks{PayForLpa: page.TaskCompleted}, WitnessCode: page.WitnessCode{Code: "1234", Created: daysAgo(2)}})
+	noEmail := sealLpa(&page.Lpa{ID: "4", Version: 1, UpdatedAt: daysAgo(340)})
+	recent := sealLpa(&page.Lpa{ID: "5", Version: 1, UpdatedAt: daysAgo(1)})
+	markedDeleted := sealLpa(&page.Lpa{ID: "6", Version: 2, UpdatedAt: daysAgo(1)})
+	markedDeleted.DeletedAt = daysAgo(1)
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("ScanByKeyPrefix", ctx, "LPA#", "#METADATA#", "").
+		Return(nil, []storedLpa{warnable, deletable}, "next")
+	dataStore.
+		On("ScanByKeyPrefix", ctx, "LPA#", "#METADATA#", "next").
+		Return(nil, []storedLpa{paid, noEmail, recent, markedDeleted}, "")
+
+	dataStore.
+		On("Get", mock.Anything, "LPA#1", "RETENTION_WARNING").
+		Return(nil)
+	dataStore.
+		On("Put", mock.Anything, "LPA#1", "RETENTION_WARNING", retentionWarning{LpaID: "1", UpdatedAt: daysAgo(360), WarnedAt: testNow, DeleteAfter: testNow.Add(28 * 24 * time.Hour), EmailedDonor: true}).
+		Return(nil)
+	dataStore.
+		On("GetAllByKeyPrefix", mock.Anything, "LPA#1", "SHARECODE#").
+		Return(nil, []shareCodeLink{})
+
+	dataStore.
+		On("Get", mock.Anything, "LPA#2", "RETENTION_WARNING").
+		Return(nil, retentionWarning{LpaID: "2", UpdatedAt: daysAgo(400), WarnedAt: daysAgo(40), DeleteAfter: daysAgo(12), EmailedDonor: true})
+	dataStore.
+		On("Get", mock.Anything, "LPA#2", "#METADATA#2").
+		Return(nil, deletable)
+	dataStore.
+		On("Get", mock.Anything, "LPA#2", "PAYMENT#IN_FLIGHT").
+		Return(nil)
+	dataStore.
+		On("GetAllByKeyPrefix", mock.Anything, "LPA#2", mock.Anything).
+		Return(nil, []string{})
+	dataStore.
+		On("WriteTransaction", mock.Anything, transactionOf(
+			dynamo.TransactionWrite{Kind: dynamo.TransactionPutVersioned, PK: "LPA#2", SK: "#METADATA#2", Version: 2, Value: func(v interface{}) bool {
+				return !v.(storedLpa).DeletedAt.IsZero()
+			}},
+		)).
+		Return(nil)
+	dataStore.
+		On("Delete", mock.Anything, "LPA#2", mock.Anything).
+		Return(nil)
+
+	dataStore.
+		On("GetAllByKeyPrefix", mock.Anything, "LPA#3", "SHARECODE#").
+		Return(nil, []shareCodeLink{
+			{ShareCode: "expired", ActorType: page.ActorTypeCertificateProvider, Expires: daysAgo(8)},
+			{ShareCode: "current", ActorType: page.ActorTypeAttorney, Expires: daysAgo(6)},
+		})
+	dataStore.
+		On("Delete", mock.Anything, "SHARECODE#expired", "#METADATA#expired").
+		Return(nil)
+	dataStore.
+		On("Delete", mock.Anything, "LPA#3", "SHARECODE#expired").
+		Return(nil)
+	dataStore.
+		On("WriteTransaction", mock.Anything, transactionOf(
+			dynamo.TransactionWrite{Kind: dynamo.TransactionPutVersioned, PK: "LPA#3", SK: "#METADATA#3", Version: 2, Value: func(v interface{}) bool {
+				stored := v.(storedLpa)
+				return openLpa(stored).WitnessCode == page.WitnessCode{} && stored.UpdatedAt.Equal(daysAgo(400))
+			}},
+			dynamo.TransactionWrite{Kind: dynamo.TransactionPut, PK: "LPA#3", SK: "EVENT#0000000002", Value: func(v interface{}) bool {
+				event := v.(storedLpaEvent)
+
+				var personal []page.LpaChange
+				testEnvelope.Open(context.Background(), event.Personal, &personal)
+
+				return len(event.Changes) == 0 && len(personal) == 1 && personal[0].Field == "WitnessCode"
+			}},
+		)).
+		Return(nil)
+
+	dataStore.
+		On("Get", mock.Anything, "LPA#4", "RETENTION_WARNING").
+		Return(nil, retentionWarning{LpaID: "4", UpdatedAt: daysAgo(400), WarnedAt: daysAgo(80), DeleteAfter: daysAgo(35)})
+	dataStore.
+		On("Put", mock.Anything, "LPA#4", "RETENTION_WARNING", retentionWarning{LpaID: "4", UpdatedAt: daysAgo(340), WarnedAt: testNow, DeleteAfter: daysAgo(-28)}).
+		Return(nil)
+	dataStore.
+		On("GetAllByKeyPrefix", mock.Anything, "LPA#4", "SHARECODE#").
+		Return(nil, []shareCodeLink{})
+
+	dataStore.
+		On("GetAllByKeyPrefix", mock.Anything, "LPA#5", "SHARECODE#").
+		Return(nil, []shareCodeLink{})
+
+	dataStore.
+		On("Get", mock.Anything, "LPA#6", "#METADATA#6").
+		Return(nil, markedDeleted)
+	dataStore.
+		On("GetAllByKeyPrefix", mock.Anything, "LPA#6", mock.Anything).
+		Return(nil, []string{})
+	dataStore.
+		On("Delete", mock.Anything, "LPA#6", mock.Anything).
+		Return(nil)
+
+	for i, record := range []retentionAudit{
+		{Action: retentionWarnedDonor, LpaID: "1", RecordTime: daysAgo(360), DeleteAfter: daysAgo(-28), EmailedDonor: true, AuditedAt: testNow},
+		{Action: retentionDeletedDraftLpa, LpaID: "2", RecordTime: daysAgo(400), AuditedAt: testNow},
+		{Action: retentionDeletedShareCode, LpaID: "3", ActorType: page.ActorTypeCertificateProvider, RecordTime: daysAgo(8), AuditedAt: testNow},
+		{Action: retentionDeletedWitnessCode, LpaID: "3", RecordTime: daysAgo(2), AuditedAt: testNow},
+		{Action: retentionWarnedDonor, LpaID: "4", RecordTime: daysAgo(340), DeleteAfter: daysAgo(-28), AuditedAt: testNow},
+	} {
+		dataStore.
+			On("Put", mock.Anything, "RETENTION_AUDIT", fmt.Sprintf("2023-06-01T12:00:00Z#%06d", i+1), record).
+			Return(nil)
+	}
+
+	notifyClient := &mockNotifyClient{}
+	notifyClient.
+		On("TemplateID", notify.DraftLpaDeletionWarningEmail, localize.En).
+		Return("template-id")
+	notifyClient.
+		On("Email", mock.Anything, notify.Email{
+			TemplateID:   "template-id",
+			EmailAddress: "john@example.com",
+			Reference:    "1/draft-lpa-deletion-warning-email/2023-06-29",
+			Personalisation: map[string]string{
+				"donorFullName": "John Smith",
+				"deletionDate":  "29 June 2023",
+				"link":          "http://app" + page.Paths.Dashboard,
+			},
+		}).
+		Return("", nil)
+
+	var buf bytes.Buffer
+	err := PurgeExpired(ctx, log.New(&buf, "", 0), dataStore, testKeyProvider, notifyClient, "http://app", testPolicy, testNow)
+	assert.Nil(t, err)
+	assert.Equal(t, `retention: warned donor of lpa 1 that it will be deleted after 2023-06-29T12:00:00Z, emailed: true
+retention: deleted draft lpa 2 last updated 2022-04-27T12:00:00Z
+retention: deleted share code for certificate-provider of lpa 3 that expired 2023-05-24T12:00:00Z
+retention: deleted witness code of lpa 3 created 2023-05-30T12:00:00Z
+retention: warned donor of lpa 4 that it will be deleted after 2023-06-29T12:00:00Z, emailed: false
+retention: 6 lpas scanned, 3 records removed, 2 donors warned, 0 failed
+`, buf.String())
+	mock.AssertExpectationsForObjects(t, dataStore, notifyClient)
+}
+
+func TestPurgeExpiredWhenWarningNotDue(t *testing.T) {
+	ctx := context.Background()
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("ScanByKeyPrefix", ctx, "LPA#", "#METADATA#", "").
+		Return(nil, []storedLpa{sealLpa(&page.Lpa{ID: "1", UpdatedAt: daysAgo(400)})}, "")
+	dataStore.
+		On("Get", mock.Anything, "LPA#1", "RETENTION_WARNING").
+		Return(nil, retentionWarning{LpaID: "1", UpdatedAt: daysAgo(400), WarnedAt: daysAgo(10), DeleteAfter: daysAgo(-18)})
+	dataStore.
+		On("GetAllByKeyPrefix", mock.Anything, "LPA#1", "SHARECODE#").
+		Return(nil, []shareCodeLink{})
+
+	var buf bytes.Buffer
+	err := PurgeExpired(ctx, log.New(&buf, "", 0), dataStore, testKeyProvider, nil, "http://app", testPolicy, testNow)
+	assert.Nil(t, err)
+	assert.Equal(t, "retention: 1 lpas scanned, 0 records removed, 0 donors warned, 0 failed\n", buf.String())
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestPurgeExpiredKeepsDraftsBeingPaidFor(t *testing.T) {
+	ctx := context.Background()
+
+	paying := sealLpa(&page.Lpa{ID: "1", UpdatedAt: daysAgo(400), Tasks: page.Tasks{PayForLpa: page.TaskInProgress}})
+	inFlight := sealLpa(&page.Lpa{ID: "2", UpdatedAt: daysAgo(400)})
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("ScanByKeyPrefix", ctx, "LPA#", "#METADATA#", "").
+		Return(nil, []storedLpa{paying, inFlight}, "")
+	dataStore.
+		On("GetAllByKeyPrefix", mock.Anything, "LPA#1", "SHARECODE#").
+		Return(nil, []shareCodeLink{})
+	dataStore.
+		On("Get", mock.Anything, "LPA#2", "RETENTION_WARNING").
+		Return(nil, retentionWarning{LpaID: "2", UpdatedAt: daysAgo(400), WarnedAt: daysAgo(40), DeleteAfter: daysAgo(12)})
+	dataStore.
+		On("Get", mock.Anything, "LPA#2", "#METADATA#2").
+		Return(nil, inFlight)
+	dataStore.
+		On("Get", mock.Anything, "LPA#2", "PAYMENT#IN_FLIGHT").
+		Return(nil, page.InFlightPayment{LpaID: "2", PaymentID: "abc123"})
+	dataStore.
+		On("GetAllByKeyPrefix", mock.Anything, "LPA#2", "SHARECODE#").
+		Return(nil, []shareCodeLink{})
+
+	var buf bytes.Buffer
+	err := PurgeExpired(ctx, log.New(&buf, "", 0), dataStore, testKeyProvider, nil, "http://app", testPolicy, testNow)
+	assert.Nil(t, err)
+	assert.Equal(t, "retention: 2 lpas scanned, 0 records removed, 0 donors warned, 0 failed\n", buf.String())
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestPurgeExpiredWhenScanErrors(t *testing.T) {
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("ScanByKeyPrefix", mock.Anything, "LPA#", "#METADATA#", "").
+		Return(expectedError, nil, "")
+
+	err := PurgeExpired(context.Background(), log.New(&bytes.Buffer{}, "", 0), dataStore, testKeyProvider, nil, "http://app", testPolicy, testNow)
+	assert.Equal(t, expectedError, err)
+}
+
+func TestPurgeExpiredWhenLpaErrors(t *testing.T) {
+	testCases := map[string]func(*mockDataStore, *mockNotifyClient){
+		"get warning": func(dataStore *mockDataStore, _ *mockNotifyClient) {
+			dataStore.On("Get", mock.Anything, "LPA#1", "RETENTION_WARNING").Return(expectedError)
+		},
+		"email": func(dataStore *mockDataStore, notifyClient *mockNotifyClient) {
+			dataStore.On("Get", mock.Anything, "LPA#1", "RETENTION_WARNING").Return(nil)
+			notifyClient.On("TemplateID", mock.Anything, mock.Anything).Return("template-id")
+			notifyClient.On("Email", mock.Anything, mock.Anything).Return("", expectedError)
+		},
+		"put warning": func(dataStore *mockDataStore, notifyClient *mockNotifyClient) {
+			dataStore.On("Get", mock.Anything, "LPA#1", "RETENTION_WARNING").Return(nil)
+			notifyClient.On("TemplateID", mock.Anything, mock.Anything).Return("template-id")
+			notifyClient.On("Email", mock.Anything, mock.Anything).Return("", nil)
+			dataStore.On("Put", mock.Anything, "LPA#1", "RETENTION_WARNING", mock.Anything).Return(expectedError)
+		},
+		"audit": func(dataStore *mockDataStore, notifyClient *mockNotifyClient) {
+			dataStore.On("Get", mock.Anything, "LPA#1", "RETENTION_WARNING").Return(nil)
+			notifyClient.On("TemplateID", mock.Anything, mock.Anything).Return("template-id")
+			notifyClient.On("Email", mock.Anything, mock.Anything).Return("", nil)
+			dataStore.On("Put", mock.Anything, "LPA#1", "RETENTION_WARNING", mock.Anything).Return(nil)
+			dataStore.On("Put", mock.Anything, "RETENTION_AUDIT", mock.Anything, mock.Anything).Return(expectedError)
+		},
+		"delete": func(dataStore *mockDataStore, _ *mockNotifyClient) {
+			dataStore.On("Get", mock.Anything, "LPA#1", "RETENTION_WARNING").Return(nil, retentionWarning{UpdatedAt: daysAgo(360), WarnedAt: daysAgo(30), DeleteAfter: daysAgo(2)})
+			dataStore.On("Get", mock.Anything, "LPA#1", "#METADATA#1").Return(nil, sealLpa(&page.Lpa{ID: "1", UpdatedAt: daysAgo(360)}))
+			dataStore.On("Get", mock.Anything, "LPA#1", mock.Anything).Return(nil)
+			dataStore.On("GetAllByKeyPrefix", mock.Anything, "LPA#1", "SUB#").Return(expectedError)
+		},
+	}
+
+	for name, setup := range testCases {
+		t.Run(name, func(t *testing.T) {
+			dataStore := &mockDataStore{}
+			dataStore.
+				On("ScanByKeyPrefix", mock.Anything, "LPA#", "#METADATA#", "").
+				Return(nil, []storedLpa{sealLpa(&page.Lpa{ID: "1", UpdatedAt: daysAgo(360), You: actor.Person{Email: "john@example.com"}})}, "")
+
+			notifyClient := &mockNotifyClient{}
+			setup(dataStore, notifyClient)
+
+			var buf bytes.Buffer
+			err := PurgeExpired(context.Background(), log.New(&buf, "", 0), dataStore, testKeyProvider, notifyClient, "http://app", testPolicy, testNow)
+			assert.Equal(t, "unable to purge 1 of 1 lpas", err.Error())
+			assert.Contains(t, buf.String(), "unable to purge lpa 1: err")
+		})
+	}
+}
+
+func TestPurgeExpiredWhenShareCodeErrors(t *testing.T) {
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("ScanByKeyPrefix", mock.Anything, "LPA#", "#METADATA#", "").
+		Return(nil, []storedLpa{sealLpa(&page.Lpa{ID: "1", UpdatedAt: daysAgo(1)})}, "")
+	dataStore.
+		On("GetAllByKeyPrefix", mock.Anything, "LPA#1", "SHARECODE#").
+		Return(nil, []shareCodeLink{{ShareCode: "abc", Expires: daysAgo(10)}})
+	dataStore.
+		On("Delete", mock.Anything, "SHARECODE#abc", "#METADATA#abc").
+		Return(expectedError)
+
+	err := PurgeExpired(context.Background(), log.New(&bytes.Buffer{}, "", 0), dataStore, testKeyProvider, nil, "http://app", testPolicy, testNow)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,293 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/dynamo"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+)
+
+// Share codes are stored under SHARECODE#<code>, so they can be looked up from
+// the link that was sent. A link item (SK SHARECODE#<code>) is also stored in
+// the LPA's partition so that the codes sent for an LPA can be revoked.
+
+const (
+	shareCodeTTL = 30 * 24 * time.Hour
+
+	// shareCodeCreateAttempts is how many codes are tried when the code made is
+	// already in use.
+	shareCodeCreateAttempts = 3
+)
+
+var (
+	errShareCodeNotFound = errors.New("shareCodeStore: share code not found")
+	errShareCodeRedeemed = errors.New("shareCodeStore: share code has been redeemed by someone else")
+)
+
+type shareCodeLink struct {
+	ShareCode string
+	ActorType page.ActorType
+	ActorID   string
+	Expires   time.Time
+}
+
+func (l shareCodeLink) ExpiresAt() time.Time {
+	return l.Expires
+}
+
+type shareCodeStore struct {
+	dataStore    page.DataStore
+	randomString func(int) string
+	now          func() time.Time
+}
+
+// Create stores a new share code for the LPA and actor in data. If the code
+// made is already in use another is tried.
+func (s *shareCodeStore) Create(ctx context.Context, data page.ShareCodeData) (string, error) {
+	data.Expires = s.now().Add(shareCodeTTL)
+	data.RedeemedBy = ""
+	data.Revoked = false
+	data.Version = 1
+
+	var shareCode string
+	for attempt := 1; ; attempt++ {
+		shareCode = s.randomString(12)
+
+		err := s.dataStore.Create(ctx, shareCodePK(shareCode), shareCodeSK(shareCode), data)
+		if err == nil {
+			break
+		}
+
+		if !errors.As(err, &dynamo.ConflictError{}) || attempt == shareCodeCreateAttempts {
+			return "", err
+		}
+	}
+
+	if err := s.dataStore.Put(ctx, lpaPK(data.LpaID), shareCodePK(shareCode), shareCodeLink{
+		ShareCode: shareCode,
+		ActorType: data.ActorType,
+		ActorID:   data.ActorID,
+		Expires:   data.Expires,
+	}); err != nil {
+		return "", err
+	}
+
+	return shareCode, nil
+}
+
+// Get returns the data for a share code that has not expired or been revoked.
+// Codes stored before they expired have no expiry until MigrateShareCodes gives
+// them one, so are not treated as expired.
+func (s *shareCodeStore) Get(ctx context.Context, shareCode string) (page.ShareCodeData, error) {
+	var data page.ShareCodeData
+	if err := s.dataStore.Get(ctx, shareCodePK(shareCode), shareCodeSK(shareCode), &data); err != nil {
+		return page.ShareCodeData{}, err
+	}
+
+	if data.LpaID == "" || data.Revoked || (!data.Expires.IsZero() && !s.now().Before(data.Expires)) {
+		return page.ShareCodeData{}, errShareCodeNotFound
+	}
+
+	return data, nil
+}
+
+// Redeem binds the share code to the subject in the session data. A code can
+// be redeemed again by the same subject, but not by anyone else.
+func (s *shareCodeStore) Redeem(ctx context.Context, shareCode string) (page.ShareCodeData, error) {
+	sub := page.SessionDataFromContext(ctx).Subject
+	if sub == "" {
+		return page.ShareCodeData{}, errors.New("shareCodeStore.Redeem requires Subject")
+	}
+
+	data, err := s.Get(ctx, shareCode)
+	if err != nil {
+		return page.ShareCodeData{}, err
+	}
+
+	if data.RedeemedBy == sub {
+		return data, nil
+	}
+
+	if data.RedeemedBy != "" {
+		return page.ShareCodeData{}, errShareCodeRedeemed
+	}
+
+	data.RedeemedBy = sub
+	data.Version++
+
+	if err := s.dataStore.PutVersioned(ctx, shareCodePK(shareCode), shareCodeSK(shareCode), data, data.Version); err != nil {
+		if errors.As(err, &dynamo.ConflictError{}) {
+			return page.ShareCodeData{}, errShareCodeRedeemed
+		}
+
+		return page.ShareCodeData{}, err
+	}
+
+	return data, nil
+}
+
+// Revoke stops any share codes sent for the LPA to the actor, other than
+// keepShareCode, from working. Whoever redeemed a code is also unlinked from
+// the LPA, so can no longer see it.
+func (s *shareCodeStore) Revoke(ctx context.Context, lpaID string, actorType page.ActorType, actorID, keepShareCode string) error {
+	return s.revoke(ctx, lpaID, func(link shareCodeLink) bool {
+		return link.ActorType == actorType && link.ActorID == actorID && link.ShareCode != keepShareCode
+	})
+}
+
+// RevokeAll stops every share code sent for the LPA from working, unlinking
+// whoever redeemed them.
+func (s *shareCodeStore) RevokeAll(ctx context.Context, lpaID string) error {
+	return s.revoke(ctx, lpaID, func(shareCodeLink) bool { return true })
+}
+
+func (s *shareCodeStore) revoke(ctx context.Context, lpaID string, match func(shareCodeLink) bool) error {
+	var links []shareCodeLink
+	if err := s.dataStore.GetAllByKeyPrefix(ctx, lpaPK(lpaID), "SHARECODE#", &links); err != nil {
+		return err
+	}
+
+	for _, link := range links {
+		if !match(link) {
+			continue
+		}
+
+		var data page.ShareCodeData
+		if err := s.dataStore.Get(ctx, shareCodePK(link.ShareCode), shareCodeSK(link.ShareCode), &data); err != nil {
+			return err
+		}
+
+		if data.LpaID == "" || data.Revoked {
+			continue
+		}
+
+		data.Revoked = true
+		data.Version++
+
+		if data.RedeemedBy == "" {
+			if err := s.dataStore.PutVersioned(ctx, shareCodePK(link.ShareCode), shareCodeSK(link.ShareCode), data, data.Version); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := s.revokeRedeemed(ctx, lpaID, link.ShareCode, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// revokeRedeemed writes the revoked share code along with removing the link
+// made when it was redeemed. The subject may also be linked to the LPA in
+// another role, which is left as it is.
+func (s *shareCodeStore) revokeRedeemed(ctx context.Context, lpaID, shareCode string, data page.ShareCodeData) error {
+	link, sk, err := getLpaLink(ctx, s.dataStore, lpaID, data.RedeemedBy, data.ActorType)
+	if err != nil {
+		return err
+	}
+
+	transaction := dynamo.NewTransaction().
+		PutVersioned(shareCodePK(shareCode), shareCodeSK(shareCode), data, data.Version)
+
+	if link.LpaID != "" {
+		transaction.Delete(lpaPK(lpaID), sk)
+	}
+
+	return s.dataStore.WriteTransaction(ctx, transaction)
+}
+
+// MigrateShareCodes gives the share codes stored before they expired an expiry,
+// counted from now as when they were made is not known, and links them from
+// their LPA so they can be revoked and purged. Codes were then only sent to
+// certificate providers. When dryRun is set nothing is written. A code that is
+// changed while running is skipped.
+func MigrateShareCodes(ctx context.Context, logger page.Logger, dataStore page.DataStore, now time.Time, dryRun bool) error {
+	var migrated, failed int
+	cursor := ""
+	for {
+		keys, next, err := dataStore.ScanKeysByPrefix(ctx, shareCodePK(""), shareCodeSK(""), cursor)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			shareCode := strings.TrimPrefix(key.PK, shareCodePK(""))
+
+			var data page.ShareCodeData
+			if err := dataStore.Get(ctx, key.PK, key.SK, &data); err != nil {
+				logger.Print(fmt.Sprintf("unable to read share code for %s: %s", key.PK, err.Error()))
+				failed++
+				continue
+			}
+
+			if data.LpaID == "" || !data.Expires.IsZero() {
+				continue
+			}
+
+			if dryRun {
+				logger.Print(fmt.Sprintf("would have migrated share code for lpa %s", data.LpaID))
+				migrated++
+				continue
+			}
+
+			if data.ActorType == "" {
+				data.ActorType = page.ActorTypeCertificateProvider
+			}
+			data.Expires = now.Add(shareCodeTTL)
+			data.Version++
+
+			err := dataStore.WriteTransaction(ctx, dynamo.NewTransaction().
+				PutVersioned(key.PK, key.SK, data, data.Version).
+				Put(lpaPK(data.LpaID), shareCodePK(shareCode), shareCodeLink{
+					ShareCode: shareCode,
+					ActorType: data.ActorType,
+					ActorID:   data.ActorID,
+					Expires:   data.Expires,
+				}))
+			if errors.As(err, &dynamo.ConflictError{}) {
+				logger.Print(fmt.Sprintf("skipped share code for lpa %s as it changed while migrating", data.LpaID))
+				continue
+			}
+			if err != nil {
+				logger.Print(fmt.Sprintf("unable to migrate share code for lpa %s: %s", data.LpaID, err.Error()))
+				failed++
+				continue
+			}
+
+			logger.Print(fmt.Sprintf("migrated share code for lpa %s", data.LpaID))
+			migrated++
+		}
+
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if dryRun {
+		logger.Print(fmt.Sprintf("dry run: %d share codes would be migrated, %d failed", migrated, failed))
+	} else {
+		logger.Print(fmt.Sprintf("%d share codes migrated, %d failed", migrated, failed))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("unable to migrate %d of %d share codes", failed, migrated+failed)
+	}
+
+	return nil
+}
+
+func shareCodePK(shareCode string) string {
+	return "SHARECODE#" + shareCode
+}
+
+func shareCodeSK(shareCode string) string {
+	return "#METADATA#" + shareCode
+}
@@ -0,0 +1,595 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/dynamo"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	shareCodeNow     = time.Date(2023, time.January, 2, 3, 4, 5, 0, time.UTC)
+	shareCodeExpires = shareCodeNow.Add(shareCodeTTL)
+)
+
+func mockShareCodeNow() time.Time { return shareCodeNow }
+
+func mockShareCodeRandom(int) string { return "123" }
+
+func TestShareCodeStoreCreate(t *testing.T) {
+	ctx := context.Background()
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("Create", ctx, "SHARECODE#123", "#METADATA#123", page.ShareCodeData{
+			LpaID:     "lpa-id",
+			ActorType: page.ActorTypeAttorney,
+			ActorID:   "attorney-id",
+			Expires:   shareCodeExpires,
+			Version:   1,
+		}).
+		Return(nil)
+	dataStore.
+		On("Put", ctx, "LPA#lpa-id", "SHARECODE#123", shareCodeLink{
+			ShareCode: "123",
+			ActorType: page.ActorTypeAttorney,
+			ActorID:   "attorney-id",
+			Expires:   shareCodeExpires,
+		}).
+		Return(nil)
+
+	shareCodeStore := &shareCodeStore{dataStore: dataStore, randomString: mockShareCodeRandom, now: mockShareCodeNow}
+	shareCode, err := shareCodeStore.Create(ctx, page.ShareCodeData{
+		LpaID:      "lpa-id",
+		ActorType:  page.ActorTypeAttorney,
+		ActorID:    "attorney-id",
+		RedeemedBy: "someone",
+		Revoked:    true,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "123", shareCode)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestShareCodeStoreCreateWhenDataStoreErrors(t *testing.T) {
+	testCases := map[string]struct {
+		createError error
+		putError    error
+	}{
+		"create": {createError: expectedError},
+		"put":    {putError: expectedError},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			dataStore := &mockDataStore{}
+			dataStore.
+				On("Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+				Return(tc.createError)
+			dataStore.
+				On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+				Return(tc.putError).
+				Maybe()
+
+			shareCodeStore := &shareCodeStore{dataStore: dataStore, randomString: mockShareCodeRandom, now: mockShareCodeNow}
+			_, err := shareCodeStore.Create(context.Background(), page.ShareCodeData{LpaID: "lpa-id"})
+
+			assert.Equal(t, expectedError, err)
+		})
+	}
+}
+
+func TestShareCodeStoreCreateWhenCodeInUse(t *testing.T) {
+	ctx := context.Background()
+	codes := []string{"123", "456"}
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("Create", ctx, "SHARECODE#123", "#METADATA#123", mock.Anything).
+		Return(dynamo.ConflictError{PK: "SHARECODE#123", SK: "#METADATA#123"})
+	dataStore.
+		On("Create", ctx, "SHARECODE#456", "#METADATA#456", mock.Anything).
+		Return(nil)
+	dataStore.
+		On("Put", ctx, "LPA#lpa-id", "SHARECODE#456", mock.Anything).
+		Return(nil)
+
+	shareCodeStore := &shareCodeStore{dataStore: dataStore, now: mockShareCodeNow, randomString: func(int) string {
+		code := codes[0]
+		codes = codes[1:]
+		return code
+	}}
+	shareCode, err := shareCodeStore.Create(ctx, page.ShareCodeData{LpaID: "lpa-id"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "456", shareCode)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestShareCodeStoreCreateWhenCodesAlwaysInUse(t *testing.T) {
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(dynamo.ConflictError{}).
+		Times(shareCodeCreateAttempts)
+
+	shareCodeStore := &shareCodeStore{dataStore: dataStore, randomString: mockShareCodeRandom, now: mockShareCodeNow}
+	_, err := shareCodeStore.Create(context.Background(), page.ShareCodeData{LpaID: "lpa-id"})
+
+	assert.Equal(t, dynamo.ConflictError{}, err)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestShareCodeStoreGet(t *testing.T) {
+	ctx := context.Background()
+	data := page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeCertificateProvider, Expires: shareCodeExpires, Version: 1}
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("Get", ctx, "SHARECODE#123", "#METADATA#123").
+		Return(nil, data)
+
+	shareCodeStore := &shareCodeStore{dataStore: dataStore, now: mockShareCodeNow}
+	result, err := shareCodeStore.Get(ctx, "123")
+
+	assert.Nil(t, err)
+	assert.Equal(t, data, result)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestShareCodeStoreGetWhenNoExpiry(t *testing.T) {
+	ctx := context.Background()
+	data := page.ShareCodeData{LpaID: "lpa-id"}
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("Get", ctx, "SHARECODE#123", "#METADATA#123").
+		Return(nil, data)
+
+	shareCodeStore := &shareCodeStore{dataStore: dataStore, now: mockShareCodeNow}
+	result, err := shareCodeStore.Get(ctx, "123")
+
+	assert.Nil(t, err)
+	assert.Equal(t, data, result)
+}
+
+func TestShareCodeStoreGetWhenNotUsable(t *testing.T) {
+	testCases := map[string]page.ShareCodeData{
+		"not found": {},
+		"expired":   {LpaID: "lpa-id", Expires: shareCodeNow},
+		"revoked":   {LpaID: "lpa-id", Expires: shareCodeExpires, Revoked: true},
+	}
+
+	for name, data := range testCases {
+		t.Run(name, func(t *testing.T) {
+			dataStore := &mockDataStore{}
+			dataStore.
+				On("Get", mock.Anything, "SHARECODE#123", "#METADATA#123").
+				Return(nil, data)
+
+			shareCodeStore := &shareCodeStore{dataStore: dataStore, now: mockShareCodeNow}
+			_, err := shareCodeStore.Get(context.Background(), "123")
+
+			assert.Equal(t, errShareCodeNotFound, err)
+		})
+	}
+}
+
+func TestShareCodeStoreGetWhenDataStoreErrors(t *testing.T) {
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("Get", mock.Anything, mock.Anything, mock.Anything).
+		Return(expectedError)
+
+	shareCodeStore := &shareCodeStore{dataStore: dataStore, now: mockShareCodeNow}
+	_, err := shareCodeStore.Get(context.Background(), "123")
+
+	assert.Equal(t, expectedError, err)
+}
+
+func TestShareCodeStoreRedeem(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{Subject: "a-sub"})
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("Get", ctx, "SHARECODE#123", "#METADATA#123").
+		Return(nil, page.ShareCodeData{LpaID: "lpa-id", Expires: shareCodeExpires, Version: 1})
+	dataStore.
+		On("PutVersioned", ctx, "SHARECODE#123", "#METADATA#123", page.ShareCodeData{LpaID: "lpa-id", Expires: shareCodeExpires, RedeemedBy: "a-sub", Version: 2}, 2).
+		Return(nil)
+
+	shareCodeStore := &shareCodeStore{dataStore: dataStore, now: mockShareCodeNow}
+	data, err := shareCodeStore.Redeem(ctx, "123")
+
+	assert.Nil(t, err)
+	assert.Equal(t, page.ShareCodeData{LpaID: "lpa-id", Expires: shareCodeExpires, RedeemedBy: "a-sub", Version: 2}, data)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestShareCodeStoreRedeemWhenAlreadyRedeemedBySubject(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{Subject: "a-sub"})
+	stored := page.ShareCodeData{LpaID: "lpa-id", Expires: shareCodeExpires, RedeemedBy: "a-sub", Version: 2}
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("Get", ctx, "SHARECODE#123", "#METADATA#123").
+		Return(nil, stored)
+
+	shareCodeStore := &shareCodeStore{dataStore: dataStore, now: mockShareCodeNow}
+	data, err := shareCodeStore.Redeem(ctx, "123")
+
+	assert.Nil(t, err)
+	assert.Equal(t, stored, data)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestShareCodeStoreRedeemWhenRedeemedBySomeoneElse(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{Subject: "a-sub"})
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("Get", ctx, "SHARECODE#123", "#METADATA#123").
+		Return(nil, page.ShareCodeData{LpaID: "lpa-id", Expires: shareCodeExpires, RedeemedBy: "other-sub", Version: 2})
+
+	shareCodeStore := &shareCodeStore{dataStore: dataStore, now: mockShareCodeNow}
+	_, err := shareCodeStore.Redeem(ctx, "123")
+
+	assert.Equal(t, errShareCodeRedeemed, err)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestShareCodeStoreRedeemWhenPutVersionedErrors(t *testing.T) {
+	testCases := map[string]struct {
+		putError      error
+		expectedError error
+	}{
+		"conflict": {
+			putError:      dynamo.ConflictError{PK: "SHARECODE#123", SK: "#METADATA#123"},
+			expectedError: errShareCodeRedeemed,
+		},
+		"other": {
+			putError:      expectedError,
+			expectedError: expectedError,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{Subject: "a-sub"})
+
+			dataStore := &mockDataStore{}
+			dataStore.
+				On("Get", ctx, "SHARECODE#123", "#METADATA#123").
+				Return(nil, page.ShareCodeData{LpaID: "lpa-id", Expires: shareCodeExpires, Version: 1})
+			dataStore.
+				On("PutVersioned", ctx, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+				Return(tc.putError)
+
+			shareCodeStore := &shareCodeStore{dataStore: dataStore, now: mockShareCodeNow}
+			_, err := shareCodeStore.Redeem(ctx, "123")
+
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestShareCodeStoreRedeemWhenGetErrors(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{Subject: "a-sub"})
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("Get", ctx, "SHARECODE#123", "#METADATA#123").
+		Return(expectedError)
+
+	shareCodeStore := &shareCodeStore{dataStore: dataStore, now: mockShareCodeNow}
+	_, err := shareCodeStore.Redeem(ctx, "123")
+
+	assert.Equal(t, expectedError, err)
+}
+
+func TestShareCodeStoreRedeemWhenMissingSubject(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{})
+
+	shareCodeStore := &shareCodeStore{}
+	_, err := shareCodeStore.Redeem(ctx, "123")
+
+	assert.NotNil(t, err)
+}
+
+func TestShareCodeStoreRevoke(t *testing.T) {
+	ctx := context.Background()
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("GetAllByKeyPrefix", ctx, "LPA#lpa-id", "SHARECODE#").
+		Return(nil, []shareCodeLink{
+			{ShareCode: "123", ActorType: page.ActorTypeCertificateProvider},
+			{ShareCode: "456", ActorType: page.ActorTypeAttorney, ActorID: "attorney-id"},
+			{ShareCode: "789", ActorType: page.ActorTypeCertificateProvider},
+			{ShareCode: "abc", ActorType: page.ActorTypeCertificateProvider},
+			{ShareCode: "new", ActorType: page.ActorTypeCertificateProvider},
+		})
+	dataStore.
+		On("Get", ctx, "SHARECODE#123", "#METADATA#123").
+		Return(nil, page.ShareCodeData{LpaID: "lpa-id", Version: 1})
+	dataStore.
+		On("PutVersioned", ctx, "SHARECODE#123", "#METADATA#123", page.ShareCodeData{LpaID: "lpa-id", Revoked: true, Version: 2}, 2).
+		Return(nil)
+	dataStore.
+		On("Get", ctx, "SHARECODE#789", "#METADATA#789").
+		Return(nil, page.ShareCodeData{LpaID: "lpa-id", Revoked: true, Version: 2})
+	dataStore.
+		On("Get", ctx, "SHARECODE#abc", "#METADATA#abc").
+		Return(nil, page.ShareCodeData{})
+
+	shareCodeStore := &shareCodeStore{dataStore: dataStore}
+	err := shareCodeStore.Revoke(ctx, "lpa-id", page.ActorTypeCertificateProvider, "", "new")
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestShareCodeStoreRevokeAll(t *testing.T) {
+	ctx := context.Background()
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("GetAllByKeyPrefix", ctx, "LPA#lpa-id", "SHARECODE#").
+		Return(nil, []shareCodeLink{
+			{ShareCode: "123", ActorType: page.ActorTypeCertificateProvider},
+			{ShareCode: "456", ActorType: page.ActorTypeAttorney, ActorID: "attorney-id"},
+		})
+	dataStore.
+		On("Get", ctx, "SHARECODE#123", "#METADATA#123").
+		Return(nil, page.ShareCodeData{LpaID: "lpa-id", Version: 1})
+	dataStore.
+		On("PutVersioned", ctx, "SHARECODE#123", "#METADATA#123", page.ShareCodeData{LpaID: "lpa-id", Revoked: true, Version: 2}, 2).
+		Return(nil)
+	dataStore.
+		On("Get", ctx, "SHARECODE#456", "#METADATA#456").
+		Return(nil, page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeAttorney, ActorID: "attorney-id", Version: 1})
+	dataStore.
+		On("PutVersioned", ctx, "SHARECODE#456", "#METADATA#456", page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeAttorney, ActorID: "attorney-id", Revoked: true, Version: 2}, 2).
+		Return(nil)
+
+	shareCodeStore := &shareCodeStore{dataStore: dataStore}
+	err := shareCodeStore.RevokeAll(ctx, "lpa-id")
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestShareCodeStoreRevokeWhenRedeemed(t *testing.T) {
+	testCases := map[string]struct {
+		link       lpaLink
+		legacyLink lpaLink
+		writes     []dynamo.TransactionWrite
+	}{
+		"linked": {
+			link: lpaLink{LpaID: "lpa-id", Sub: "a-sub", ActorType: page.ActorTypeCertificateProvider},
+			writes: []dynamo.TransactionWrite{
+				{Kind: dynamo.TransactionPutVersioned, PK: "SHARECODE#123", SK: "#METADATA#123", Value: page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeCertificateProvider, RedeemedBy: "a-sub", Revoked: true, Version: 3}, Version: 3},
+				{Kind: dynamo.TransactionDelete, PK: "LPA#lpa-id", SK: "SUB#a-sub#certificate-provider"},
+			},
+		},
+		"linked before keyed by actor type": {
+			legacyLink: lpaLink{LpaID: "lpa-id", Sub: "a-sub", ActorType: page.ActorTypeCertificateProvider},
+			writes: []dynamo.TransactionWrite{
+				{Kind: dynamo.TransactionPutVersioned, PK: "SHARECODE#123", SK: "#METADATA#123", Value: page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeCertificateProvider, RedeemedBy: "a-sub", Revoked: true, Version: 3}, Version: 3},
+				{Kind: dynamo.TransactionDelete, PK: "LPA#lpa-id", SK: "SUB#a-sub"},
+			},
+		},
+		"linked as another actor before keyed by actor type": {
+			legacyLink: lpaLink{LpaID: "lpa-id", Sub: "a-sub", ActorType: page.ActorTypeDonor},
+			writes: []dynamo.TransactionWrite{
+				{Kind: dynamo.TransactionPutVersioned, PK: "SHARECODE#123", SK: "#METADATA#123", Value: page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeCertificateProvider, RedeemedBy: "a-sub", Revoked: true, Version: 3}, Version: 3},
+			},
+		},
+		"not linked": {
+			writes: []dynamo.TransactionWrite{
+				{Kind: dynamo.TransactionPutVersioned, PK: "SHARECODE#123", SK: "#METADATA#123", Value: page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeCertificateProvider, RedeemedBy: "a-sub", Revoked: true, Version: 3}, Version: 3},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			dataStore := &mockDataStore{}
+			dataStore.
+				On("GetAllByKeyPrefix", ctx, "LPA#lpa-id", "SHARECODE#").
+				Return(nil, []shareCodeLink{{ShareCode: "123", ActorType: page.ActorTypeCertificateProvider}})
+			dataStore.
+				On("Get", ctx, "SHARECODE#123", "#METADATA#123").
+				Return(nil, page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeCertificateProvider, RedeemedBy: "a-sub", Version: 2})
+			dataStore.
+				On("Get", ctx, "LPA#lpa-id", "SUB#a-sub#certificate-provider").
+				Return(nil, tc.link)
+			dataStore.
+				On("Get", ctx, "LPA#lpa-id", "SUB#a-sub").
+				Return(nil, tc.legacyLink).
+				Maybe()
+			dataStore.
+				On("WriteTransaction", ctx, transactionOf(tc.writes...)).
+				Return(nil)
+
+			shareCodeStore := &shareCodeStore{dataStore: dataStore}
+			err := shareCodeStore.Revoke(ctx, "lpa-id", page.ActorTypeCertificateProvider, "", "new")
+
+			assert.Nil(t, err)
+			mock.AssertExpectationsForObjects(t, dataStore)
+		})
+	}
+}
+
+func TestShareCodeStoreRevokeWhenRedeemedAndDataStoreErrors(t *testing.T) {
+	testCases := map[string]struct {
+		getLinkError     error
+		transactionError error
+	}{
+		"get link":    {getLinkError: expectedError},
+		"transaction": {transactionError: expectedError},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			dataStore := &mockDataStore{}
+			dataStore.
+				On("GetAllByKeyPrefix", mock.Anything, mock.Anything, mock.Anything).
+				Return(nil, []shareCodeLink{{ShareCode: "123", ActorType: page.ActorTypeCertificateProvider}})
+			dataStore.
+				On("Get", mock.Anything, "SHARECODE#123", mock.Anything).
+				Return(nil, page.ShareCodeData{LpaID: "lpa-id", RedeemedBy: "a-sub"})
+			dataStore.
+				On("Get", mock.Anything, "LPA#lpa-id", mock.Anything).
+				Return(tc.getLinkError, lpaLink{})
+			dataStore.
+				On("WriteTransaction", mock.Anything, mock.Anything).
+				Return(tc.transactionError).
+				Maybe()
+
+			shareCodeStore := &shareCodeStore{dataStore: dataStore}
+			err := shareCodeStore.Revoke(context.Background(), "lpa-id", page.ActorTypeCertificateProvider, "", "new")
+
+			assert.Equal(t, expectedError, err)
+		})
+	}
+}
+
+func TestShareCodeStoreRevokeWhenDataStoreErrors(t *testing.T) {
+	testCases := map[string]struct {
+		getAllError       error
+		getError          error
+		putVersionedError error
+	}{
+		"get all":       {getAllError: expectedError},
+		"get":           {getError: expectedError},
+		"put versioned": {putVersionedError: expectedError},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			dataStore := &mockDataStore{}
+			dataStore.
+				On("GetAllByKeyPrefix", mock.Anything, mock.Anything, mock.Anything).
+				Return(tc.getAllError, []shareCodeLink{{ShareCode: "123", ActorType: page.ActorTypeCertificateProvider}})
+			dataStore.
+				On("Get", mock.Anything, mock.Anything, mock.Anything).
+				Return(tc.getError, page.ShareCodeData{LpaID: "lpa-id"}).
+				Maybe()
+			dataStore.
+				On("PutVersioned", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+				Return(tc.putVersionedError).
+				Maybe()
+
+			shareCodeStore := &shareCodeStore{dataStore: dataStore}
+			err := shareCodeStore.Revoke(context.Background(), "lpa-id", page.ActorTypeCertificateProvider, "", "new")
+
+			assert.Equal(t, expectedError, err)
+		})
+	}
+}
+
+func TestMigrateShareCodes(t *testing.T) {
+	ctx := context.Background()
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("ScanKeysByPrefix", ctx, "SHARECODE#", "#METADATA#", "").
+		Return([]dynamo.Key{{PK: "SHARECODE#123", SK: "#METADATA#123"}, {PK: "SHARECODE#456", SK: "#METADATA#456"}}, "next", nil)
+	dataStore.
+		On("ScanKeysByPrefix", ctx, "SHARECODE#", "#METADATA#", "next").
+		Return([]dynamo.Key{{PK: "SHARECODE#789", SK: "#METADATA#789"}}, "", nil)
+	dataStore.
+		On("Get", ctx, "SHARECODE#123", "#METADATA#123").
+		Return(nil, page.ShareCodeData{LpaID: "lpa-id"})
+	dataStore.
+		On("Get", ctx, "SHARECODE#456", "#METADATA#456").
+		Return(nil, page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeAttorney, Expires: shareCodeExpires, Version: 1})
+	dataStore.
+		On("Get", ctx, "SHARECODE#789", "#METADATA#789").
+		Return(nil, page.ShareCodeData{LpaID: "other-lpa-id"})
+	dataStore.
+		On("WriteTransaction", ctx, dynamo.NewTransaction().
+			PutVersioned("SHARECODE#123", "#METADATA#123", page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeCertificateProvider, Expires: shareCodeExpires, Version: 1}, 1).
+			Put("LPA#lpa-id", "SHARECODE#123", shareCodeLink{ShareCode: "123", ActorType: page.ActorTypeCertificateProvider, Expires: shareCodeExpires})).
+		Return(nil)
+	dataStore.
+		On("WriteTransaction", ctx, dynamo.NewTransaction().
+			PutVersioned("SHARECODE#789", "#METADATA#789", page.ShareCodeData{LpaID: "other-lpa-id", ActorType: page.ActorTypeCertificateProvider, Expires: shareCodeExpires, Version: 1}, 1).
+			Put("LPA#other-lpa-id", "SHARECODE#789", shareCodeLink{ShareCode: "789", ActorType: page.ActorTypeCertificateProvider, Expires: shareCodeExpires})).
+		Return(dynamo.ConflictError{})
+
+	var buf bytes.Buffer
+	err := MigrateShareCodes(ctx, log.New(&buf, "", 0), dataStore, shareCodeNow, false)
+	assert.Nil(t, err)
+	assert.Equal(t, `migrated share code for lpa lpa-id
+skipped share code for lpa other-lpa-id as it changed while migrating
+1 share codes migrated, 0 failed
+`, buf.String())
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestMigrateShareCodesWhenDryRun(t *testing.T) {
+	ctx := context.Background()
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("ScanKeysByPrefix", ctx, "SHARECODE#", "#METADATA#", "").
+		Return([]dynamo.Key{{PK: "SHARECODE#123", SK: "#METADATA#123"}}, "", nil)
+	dataStore.
+		On("Get", ctx, "SHARECODE#123", "#METADATA#123").
+		Return(nil, page.ShareCodeData{LpaID: "lpa-id"})
+
+	var buf bytes.Buffer
+	err := MigrateShareCodes(ctx, log.New(&buf, "", 0), dataStore, shareCodeNow, true)
+	assert.Nil(t, err)
+	assert.Equal(t, `would have migrated share code for lpa lpa-id
+dry run: 1 share codes would be migrated, 0 failed
+`, buf.String())
+	mock.AssertExpectationsForObjects(t, dataStore)
+}
+
+func TestMigrateShareCodesWhenErrors(t *testing.T) {
+	ctx := context.Background()
+
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("ScanKeysByPrefix", ctx, "SHARECODE#", "#METADATA#", "").
+		Return([]dynamo.Key{{PK: "SHARECODE#123", SK: "#METADATA#123"}, {PK: "SHARECODE#456", SK: "#METADATA#456"}}, "", nil)
+	dataStore.
+		On("Get", ctx, "SHARECODE#123", "#METADATA#123").
+		Return(expectedError)
+	dataStore.
+		On("Get", ctx, "SHARECODE#456", "#METADATA#456").
+		Return(nil, page.ShareCodeData{LpaID: "lpa-id"})
+	dataStore.
+		On("WriteTransaction", ctx, mock.Anything).
+		Return(expectedError)
+
+	var buf bytes.Buffer
+	err := MigrateShareCodes(ctx, log.New(&buf, "", 0), dataStore, shareCodeNow, false)
+	assert.Equal(t, "unable to migrate 2 of 2 share codes", err.Error())
+	assert.Equal(t, `unable to read share code for SHARECODE#123: err
+unable to migrate share code for lpa lpa-id: err
+0 share codes migrated, 2 failed
+`, buf.String())
+}
+
+func TestMigrateShareCodesWhenScanErrors(t *testing.T) {
+	dataStore := &mockDataStore{}
+	dataStore.
+		On("ScanKeysByPrefix", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, "", expectedError)
+
+	err := MigrateShareCodes(context.Background(), log.New(&bytes.Buffer{}, "", 0), dataStore, shareCodeNow, false)
+	assert.Equal(t, expectedError, err)
+}
@@ -2,6 +2,12 @@ package dynamo
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -11,10 +17,61 @@ import (
 
 type dynamoDB interface {
 	Query(context.Context, *dynamodb.QueryInput, ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	Scan(context.Context, *dynamodb.ScanInput, ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
 	GetItem(context.Context, *dynamodb.GetItemInput, ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
 	PutItem(context.Context, *dynamodb.PutItemInput, ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(context.Context, *dynamodb.DeleteItemInput, ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	TransactWriteItems(context.Context, *dynamodb.TransactWriteItemsInput, ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
 }
 
+// A ConflictError is returned by PutVersioned when the item has been written
+// since it was read, and by Create when the item already exists. From
+// WriteTransaction it names the first write whose condition failed.
+type ConflictError struct {
+	PK, SK string
+}
+
+func (e ConflictError) Error() string {
+	return fmt.Sprintf("item %s %s has been changed", e.PK, e.SK)
+}
+
+// versionedCondition only allows a versioned write to update an item that
+// exists, and that is at the previous version. Items that have never been
+// versioned, such as those made with Create, can be updated once to start
+// versioning them. An item that has been deleted is not written back.
+const versionedCondition = "attribute_exists(#PK) AND (#Version = :previousVersion OR attribute_not_exists(#Version))"
+
+// ErrInvalidCursor is returned when a cursor was not given by a previous page
+// of results.
+var ErrInvalidCursor = errors.New("dynamo: invalid cursor")
+
+// An Expirer is stored with an ExpiresAt attribute holding the time, in
+// seconds since the Unix epoch, after which the table's time to live setting
+// allows the item to be deleted. Deletion is not immediate, so readers should
+// still check whether the value has expired.
+type Expirer interface {
+	ExpiresAt() time.Time
+}
+
+// An Orderer is stored with an OrderedAt attribute holding the time, so that
+// the items with a sort key can be paged through, most recent first, by
+// GetPageBySK. Items that are not Orderers are left out of those pages.
+type Orderer interface {
+	OrderedAt() time.Time
+}
+
+// skIndexName is a global secondary index on the table using SK as its partition
+// key, so that items can be found by sort key alone.
+const skIndexName = "SKIndex"
+
+// skOrderedAtIndexName is a sparse global secondary index on the table using SK
+// as its partition key and OrderedAt as its sort key, so that the items with a
+// sort key can be paged through in the order given by Orderers.
+const skOrderedAtIndexName = "SKOrderedAtIndex"
+
+// orderedAtLayout has a fixed width, so that OrderedAt values sort as times.
+const orderedAtLayout = "2006-01-02T15:04:05.000000000Z"
+
 type Client struct {
 	table string
 	svc   dynamoDB
@@ -30,7 +87,7 @@ func (c *Client) GetAll(ctx context.Context, pk string, v interface{}) error {
 		return err
 	}
 
-	response, err := c.svc.Query(ctx, &dynamodb.QueryInput{
+	items, err := c.queryAll(ctx, &dynamodb.QueryInput{
 		TableName:                 aws.String(c.table),
 		ExpressionAttributeNames:  map[string]string{"#PK": "PK"},
 		ExpressionAttributeValues: map[string]types.AttributeValue{":PK": pkey},
@@ -40,12 +97,163 @@ func (c *Client) GetAll(ctx context.Context, pk string, v interface{}) error {
 		return err
 	}
 
-	var items []types.AttributeValue
-	for _, item := range response.Items {
-		items = append(items, item["Data"])
+	return unmarshalData(items, v)
+}
+
+// GetAllByKeyPrefix returns the data of items in the partition pk that have a
+// sort key starting with skPrefix.
+func (c *Client) GetAllByKeyPrefix(ctx context.Context, pk, skPrefix string, v interface{}) error {
+	pkey, err := attributevalue.Marshal(pk)
+	if err != nil {
+		return err
+	}
+
+	skeyPrefix, err := attributevalue.Marshal(skPrefix)
+	if err != nil {
+		return err
+	}
+
+	items, err := c.queryAll(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(c.table),
+		ExpressionAttributeNames:  map[string]string{"#PK": "PK", "#SK": "SK"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":PK": pkey, ":SK": skeyPrefix},
+		KeyConditionExpression:    aws.String("#PK = :PK and begins_with(#SK, :SK)"),
+	})
+	if err != nil {
+		return err
+	}
+
+	return unmarshalData(items, v)
+}
+
+// GetAllBySK returns the data of items, across all partitions, that have the
+// sort key sk.
+func (c *Client) GetAllBySK(ctx context.Context, sk string, v interface{}) error {
+	skey, err := attributevalue.Marshal(sk)
+	if err != nil {
+		return err
+	}
+
+	items, err := c.queryAll(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(c.table),
+		IndexName:                 aws.String(skIndexName),
+		ExpressionAttributeNames:  map[string]string{"#SK": "SK"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":SK": skey},
+		KeyConditionExpression:    aws.String("#SK = :SK"),
+	})
+	if err != nil {
+		return err
+	}
+
+	return unmarshalData(items, v)
+}
+
+// GetPageBySK returns the data of up to limit items, across all partitions,
+// that have the sort key sk and were stored as an Orderer. Items are returned
+// most recently ordered first, starting after cursor, or from the first item
+// when cursor is empty. The cursor for the next page is returned, which is
+// empty when there are no more items.
+func (c *Client) GetPageBySK(ctx context.Context, sk, cursor string, limit int, v interface{}) (string, error) {
+	skey, err := attributevalue.Marshal(sk)
+	if err != nil {
+		return "", err
+	}
+
+	startKey, err := decodeCursor(cursor, "PK", "SK", "OrderedAt")
+	if err != nil {
+		return "", err
+	}
+
+	response, err := c.svc.Query(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(c.table),
+		IndexName:                 aws.String(skOrderedAtIndexName),
+		ExpressionAttributeNames:  map[string]string{"#SK": "SK"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":SK": skey},
+		KeyConditionExpression:    aws.String("#SK = :SK"),
+		ExclusiveStartKey:         startKey,
+		Limit:                     aws.Int32(int32(limit)),
+		ScanIndexForward:          aws.Bool(false),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	next, err := encodeCursor(response.LastEvaluatedKey)
+	if err != nil {
+		return "", err
+	}
+
+	return next, unmarshalData(response.Items, v)
+}
+
+// A Key identifies an item.
+type Key struct {
+	PK, SK string
+}
+
+// ScanByKeyPrefix returns the data of a page of items that have a partition
+// key starting with pkPrefix and a sort key starting with skPrefix. Pages are limited by the size
+// of the items read, not the number matched, so a page may be empty before the
+// end. The cursor for the next page is returned, which is empty when there are
+// no more items. A scan reads the whole table, so is only for offline tasks.
+func (c *Client) ScanByKeyPrefix(ctx context.Context, pkPrefix, skPrefix, cursor string, v interface{}) (string, error) {
+	items, next, err := c.scan(ctx, pkPrefix, skPrefix, cursor)
+	if err != nil {
+		return "", err
 	}
 
-	return attributevalue.UnmarshalList(items, v)
+	return next, unmarshalData(items, v)
+}
+
+// ScanKeysByPrefix is like ScanByKeyPrefix, but returns the keys of the items
+// matched rather than their data.
+func (c *Client) ScanKeysByPrefix(ctx context.Context, pkPrefix, skPrefix, cursor string) ([]Key, string, error) {
+	items, next, err := c.scan(ctx, pkPrefix, skPrefix, cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var keys []Key
+	if err := attributevalue.UnmarshalListOfMaps(items, &keys); err != nil {
+		return nil, "", err
+	}
+
+	return keys, next, nil
+}
+
+func (c *Client) scan(ctx context.Context, pkPrefix, skPrefix, cursor string) ([]map[string]types.AttributeValue, string, error) {
+	pkeyPrefix, err := attributevalue.Marshal(pkPrefix)
+	if err != nil {
+		return nil, "", err
+	}
+
+	skeyPrefix, err := attributevalue.Marshal(skPrefix)
+	if err != nil {
+		return nil, "", err
+	}
+
+	startKey, err := decodeCursor(cursor, "PK", "SK")
+	if err != nil {
+		return nil, "", err
+	}
+
+	response, err := c.svc.Scan(ctx, &dynamodb.ScanInput{
+		TableName:                 aws.String(c.table),
+		ExpressionAttributeNames:  map[string]string{"#PK": "PK", "#SK": "SK"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":PK": pkeyPrefix, ":SK": skeyPrefix},
+		FilterExpression:          aws.String("begins_with(#PK, :PK) and begins_with(#SK, :SK)"),
+		ExclusiveStartKey:         startKey,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	next, err := encodeCursor(response.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return response.Items, next, nil
 }
 
 func (c *Client) Get(ctx context.Context, pk, sk string, v interface{}) error {
@@ -69,25 +277,278 @@ func (c *Client) Get(ctx context.Context, pk, sk string, v interface{}) error {
 }
 
 func (c *Client) Put(ctx context.Context, pk, sk string, v interface{}) error {
-	item, err := makeKey(pk, sk)
+	item, err := makeItem(pk, sk, v)
 	if err != nil {
 		return err
 	}
 
-	data, err := attributevalue.Marshal(v)
+	_, err = c.svc.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(c.table),
+		Item:      item,
+	})
+
+	return err
+}
+
+// Create writes v only if there is no item with the same keys.
+func (c *Client) Create(ctx context.Context, pk, sk string, v interface{}) error {
+	item, err := makeItem(pk, sk, v)
 	if err != nil {
 		return err
 	}
-	item["Data"] = data
 
 	_, err = c.svc.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                aws.String(c.table),
+		Item:                     item,
+		ConditionExpression:      aws.String("attribute_not_exists(#PK)"),
+		ExpressionAttributeNames: map[string]string{"#PK": "PK"},
+	})
+
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return ConflictError{PK: pk, SK: sk}
+	}
+
+	return err
+}
+
+// PutVersioned updates the item to v with the given version, only if it exists
+// and is at the previous version (or has never been versioned).
+func (c *Client) PutVersioned(ctx context.Context, pk, sk string, v interface{}, version int) error {
+	item, err := makeItem(pk, sk, v)
+	if err != nil {
+		return err
+	}
+
+	newVersion, err := attributevalue.Marshal(version)
+	if err != nil {
+		return err
+	}
+	item["Version"] = newVersion
+
+	previousVersion, err := attributevalue.Marshal(version - 1)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.svc.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                 aws.String(c.table),
+		Item:                      item,
+		ConditionExpression:       aws.String(versionedCondition),
+		ExpressionAttributeNames:  map[string]string{"#PK": "PK", "#Version": "Version"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":previousVersion": previousVersion},
+	})
+
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return ConflictError{PK: pk, SK: sk}
+	}
+
+	return err
+}
+
+// Delete removes the item with the given keys, if there is one.
+func (c *Client) Delete(ctx context.Context, pk, sk string) error {
+	key, err := makeKey(pk, sk)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.svc.DeleteItem(ctx, &dynamodb.DeleteItemInput{
 		TableName: aws.String(c.table),
-		Item:      item,
+		Key:       key,
+	})
+
+	return err
+}
+
+// WriteTransaction makes all of the writes in t, or none of them.
+func (c *Client) WriteTransaction(ctx context.Context, t *Transaction) error {
+	if err := t.validate(); err != nil {
+		return err
+	}
+
+	items := make([]types.TransactWriteItem, len(t.writes))
+	for i, w := range t.writes {
+		if w.Kind == TransactionDelete {
+			key, err := makeKey(w.PK, w.SK)
+			if err != nil {
+				return err
+			}
+
+			items[i] = types.TransactWriteItem{Delete: &types.Delete{
+				TableName: aws.String(c.table),
+				Key:       key,
+			}}
+			continue
+		}
+
+		item, err := makeItem(w.PK, w.SK, w.Value)
+		if err != nil {
+			return err
+		}
+
+		put := &types.Put{
+			TableName: aws.String(c.table),
+			Item:      item,
+		}
+
+		switch w.Kind {
+		case TransactionCreate:
+			put.ConditionExpression = aws.String("attribute_not_exists(#PK)")
+			put.ExpressionAttributeNames = map[string]string{"#PK": "PK"}
+
+		case TransactionPutVersioned:
+			newVersion, err := attributevalue.Marshal(w.Version)
+			if err != nil {
+				return err
+			}
+			item["Version"] = newVersion
+
+			previousVersion, err := attributevalue.Marshal(w.Version - 1)
+			if err != nil {
+				return err
+			}
+
+			put.ConditionExpression = aws.String(versionedCondition)
+			put.ExpressionAttributeNames = map[string]string{"#PK": "PK", "#Version": "Version"}
+			put.ExpressionAttributeValues = map[string]types.AttributeValue{":previousVersion": previousVersion}
+		}
+
+		items[i] = types.TransactWriteItem{Put: put}
+	}
+
+	_, err := c.svc.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: items,
 	})
 
+	var cancelledErr *types.TransactionCanceledException
+	if errors.As(err, &cancelledErr) {
+		for i, reason := range cancelledErr.CancellationReasons {
+			if aws.ToString(reason.Code) == "ConditionalCheckFailed" && i < len(t.writes) {
+				return ConflictError{PK: t.writes[i].PK, SK: t.writes[i].SK}
+			}
+		}
+	}
+
 	return err
 }
 
+// queryAll follows LastEvaluatedKey so that all matching items are returned,
+// rather than only those that fit in the first 1MB response.
+func (c *Client) queryAll(ctx context.Context, input *dynamodb.QueryInput) ([]map[string]types.AttributeValue, error) {
+	var items []map[string]types.AttributeValue
+
+	for {
+		response, err := c.svc.Query(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, response.Items...)
+
+		if len(response.LastEvaluatedKey) == 0 {
+			return items, nil
+		}
+
+		next := *input
+		next.ExclusiveStartKey = response.LastEvaluatedKey
+		input = &next
+	}
+}
+
+// A cursor is the key of the last item evaluated by a query, encoded so that
+// it can be passed around in a URL. All keys on the table are strings.
+func encodeCursor(key map[string]types.AttributeValue) (string, error) {
+	if len(key) == 0 {
+		return "", nil
+	}
+
+	values := map[string]string{}
+	if err := attributevalue.UnmarshalMap(key, &values); err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor returns the key in cursor, which must have exactly the given
+// attributes, so that a cursor that was changed is not sent to Dynamo.
+func decodeCursor(cursor string, keys ...string) (map[string]types.AttributeValue, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var values map[string]string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if len(values) != len(keys) {
+		return nil, ErrInvalidCursor
+	}
+	for _, key := range keys {
+		if values[key] == "" {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	return attributevalue.MarshalMap(values)
+}
+
+func unmarshalData(items []map[string]types.AttributeValue, v interface{}) error {
+	var data []types.AttributeValue
+	for _, item := range items {
+		data = append(data, item["Data"])
+	}
+
+	return attributevalue.UnmarshalList(data, v)
+}
+
+func makeItem(pk, sk string, v interface{}) (map[string]types.AttributeValue, error) {
+	item, err := makeKey(pk, sk)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := attributevalue.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	item["Data"] = data
+
+	if expirer, ok := v.(Expirer); ok {
+		item["ExpiresAt"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(expirer.ExpiresAt().Unix(), 10)}
+	}
+
+	if orderedAt := orderedAt(v); orderedAt != "" {
+		item["OrderedAt"] = &types.AttributeValueMemberS{Value: orderedAt}
+	}
+
+	return item, nil
+}
+
+// orderedAt returns the OrderedAt attribute to store with v, or an empty string
+// if it is not an Orderer.
+func orderedAt(v interface{}) string {
+	orderer, ok := v.(Orderer)
+	if !ok {
+		return ""
+	}
+
+	return orderer.OrderedAt().UTC().Format(orderedAtLayout)
+}
+
 func makeKey(pk, sk string) (map[string]types.AttributeValue, error) {
 	pkey, err := attributevalue.Marshal(pk)
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -24,6 +25,11 @@ func (m *mockDynamoDB) Query(ctx context.Context, input *dynamodb.QueryInput, op
 	return args.Get(0).(*dynamodb.QueryOutput), args.Error(1)
 }
 
+func (m *mockDynamoDB) Scan(ctx context.Context, input *dynamodb.ScanInput, opts ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*dynamodb.ScanOutput), args.Error(1)
+}
+
 func (m *mockDynamoDB) GetItem(ctx context.Context, input *dynamodb.GetItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
 	args := m.Called(ctx, input)
 	return args.Get(0).(*dynamodb.GetItemOutput), args.Error(1)
@@ -34,6 +40,16 @@ func (m *mockDynamoDB) PutItem(ctx context.Context, input *dynamodb.PutItemInput
 	return args.Get(0).(*dynamodb.PutItemOutput), args.Error(1)
 }
 
+func (m *mockDynamoDB) DeleteItem(ctx context.Context, input *dynamodb.DeleteItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*dynamodb.DeleteItemOutput), args.Error(1)
+}
+
+func (m *mockDynamoDB) TransactWriteItems(ctx context.Context, input *dynamodb.TransactWriteItemsInput, opts ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*dynamodb.TransactWriteItemsOutput), args.Error(1)
+}
+
 func TestGetAll(t *testing.T) {
 	ctx := context.Background()
 
@@ -58,6 +74,44 @@ func TestGetAll(t *testing.T) {
 	assert.Equal(t, []string{"hello"}, v)
 }
 
+func TestGetAllWhenPaged(t *testing.T) {
+	ctx := context.Background()
+
+	pkey, _ := attributevalue.Marshal("a-pk")
+	data, _ := attributevalue.Marshal("hello")
+	moreData, _ := attributevalue.Marshal("world")
+	lastKey := map[string]types.AttributeValue{"PK": pkey, "SK": &types.AttributeValueMemberS{Value: "an-sk"}}
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("Query", ctx, &dynamodb.QueryInput{
+			TableName:                 aws.String("this"),
+			ExpressionAttributeNames:  map[string]string{"#PK": "PK"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{":PK": pkey},
+			KeyConditionExpression:    aws.String("#PK = :PK"),
+		}).
+		Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{{"Data": data}}, LastEvaluatedKey: lastKey}, nil).
+		Once()
+	dynamoDB.
+		On("Query", ctx, &dynamodb.QueryInput{
+			TableName:                 aws.String("this"),
+			ExpressionAttributeNames:  map[string]string{"#PK": "PK"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{":PK": pkey},
+			KeyConditionExpression:    aws.String("#PK = :PK"),
+			ExclusiveStartKey:         lastKey,
+		}).
+		Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{{"Data": moreData}}}, nil).
+		Once()
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	var v []string
+	err := c.GetAll(ctx, "a-pk", &v)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"hello", "world"}, v)
+	mock.AssertExpectationsForObjects(t, dynamoDB)
+}
+
 func TestGetAllWhenError(t *testing.T) {
 	ctx := context.Background()
 
@@ -74,6 +128,274 @@ func TestGetAllWhenError(t *testing.T) {
 	assert.Empty(t, v)
 }
 
+func TestGetAllByKeyPrefix(t *testing.T) {
+	ctx := context.Background()
+
+	pkey, _ := attributevalue.Marshal("a-pk")
+	skey, _ := attributevalue.Marshal("a-prefix")
+	data, _ := attributevalue.Marshal("hello")
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("Query", ctx, &dynamodb.QueryInput{
+			TableName:                 aws.String("this"),
+			ExpressionAttributeNames:  map[string]string{"#PK": "PK", "#SK": "SK"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{":PK": pkey, ":SK": skey},
+			KeyConditionExpression:    aws.String("#PK = :PK and begins_with(#SK, :SK)"),
+		}).
+		Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{{"Data": data}}}, nil)
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	var v []string
+	err := c.GetAllByKeyPrefix(ctx, "a-pk", "a-prefix", &v)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"hello"}, v)
+}
+
+func TestGetAllByKeyPrefixWhenError(t *testing.T) {
+	ctx := context.Background()
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("Query", ctx, mock.Anything).
+		Return(&dynamodb.QueryOutput{}, expectedError)
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	var v []string
+	err := c.GetAllByKeyPrefix(ctx, "a-pk", "a-prefix", &v)
+	assert.Equal(t, expectedError, err)
+}
+
+func TestGetAllBySK(t *testing.T) {
+	ctx := context.Background()
+
+	skey, _ := attributevalue.Marshal("a-sk")
+	data, _ := attributevalue.Marshal("hello")
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("Query", ctx, &dynamodb.QueryInput{
+			TableName:                 aws.String("this"),
+			IndexName:                 aws.String("SKIndex"),
+			ExpressionAttributeNames:  map[string]string{"#SK": "SK"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{":SK": skey},
+			KeyConditionExpression:    aws.String("#SK = :SK"),
+		}).
+		Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{{"Data": data}}}, nil)
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	var v []string
+	err := c.GetAllBySK(ctx, "a-sk", &v)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"hello"}, v)
+}
+
+func TestGetAllBySKWhenError(t *testing.T) {
+	ctx := context.Background()
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("Query", ctx, mock.Anything).
+		Return(&dynamodb.QueryOutput{}, expectedError)
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	var v []string
+	err := c.GetAllBySK(ctx, "a-sk", &v)
+	assert.Equal(t, expectedError, err)
+}
+
+func TestGetPageBySK(t *testing.T) {
+	ctx := context.Background()
+
+	skey, _ := attributevalue.Marshal("a-sk")
+	data, _ := attributevalue.Marshal("hello")
+	startKey := map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: "a-pk"}, "SK": skey, "OrderedAt": &types.AttributeValueMemberS{Value: "2023-01-02T03:04:05.000000006Z"}}
+	lastKey := map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: "b-pk"}, "SK": skey, "OrderedAt": &types.AttributeValueMemberS{Value: "2023-01-01T03:04:05.000000006Z"}}
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("Query", ctx, &dynamodb.QueryInput{
+			TableName:                 aws.String("this"),
+			IndexName:                 aws.String("SKOrderedAtIndex"),
+			ExpressionAttributeNames:  map[string]string{"#SK": "SK"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{":SK": skey},
+			KeyConditionExpression:    aws.String("#SK = :SK"),
+			ExclusiveStartKey:         startKey,
+			Limit:                     aws.Int32(2),
+			ScanIndexForward:          aws.Bool(false),
+		}).
+		Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{{"Data": data}}, LastEvaluatedKey: lastKey}, nil)
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	cursor, _ := encodeCursor(startKey)
+
+	var v []string
+	next, err := c.GetPageBySK(ctx, "a-sk", cursor, 2, &v)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"hello"}, v)
+
+	nextKey, _ := decodeCursor(next, "PK", "SK", "OrderedAt")
+	assert.Equal(t, lastKey, nextKey)
+}
+
+func TestGetPageBySKWhenLastPage(t *testing.T) {
+	ctx := context.Background()
+
+	data, _ := attributevalue.Marshal("hello")
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("Query", ctx, mock.MatchedBy(func(input *dynamodb.QueryInput) bool {
+			return input.ExclusiveStartKey == nil && *input.Limit == 10
+		})).
+		Return(&dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{{"Data": data}}}, nil)
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	var v []string
+	next, err := c.GetPageBySK(ctx, "a-sk", "", 10, &v)
+	assert.Nil(t, err)
+	assert.Equal(t, "", next)
+	assert.Equal(t, []string{"hello"}, v)
+}
+
+func TestScanByKeyPrefix(t *testing.T) {
+	ctx := context.Background()
+
+	pkey, _ := attributevalue.Marshal("a-pk-prefix")
+	skey, _ := attributevalue.Marshal("a-prefix")
+	data, _ := attributevalue.Marshal("hello")
+	startKey := map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: "a-pk"}, "SK": &types.AttributeValueMemberS{Value: "a-prefix#1"}}
+	lastKey := map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: "b-pk"}, "SK": &types.AttributeValueMemberS{Value: "a-prefix#2"}}
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("Scan", ctx, &dynamodb.ScanInput{
+			TableName:                 aws.String("this"),
+			ExpressionAttributeNames:  map[string]string{"#PK": "PK", "#SK": "SK"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{":PK": pkey, ":SK": skey},
+			FilterExpression:          aws.String("begins_with(#PK, :PK) and begins_with(#SK, :SK)"),
+			ExclusiveStartKey:         startKey,
+		}).
+		Return(&dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{{"Data": data}}, LastEvaluatedKey: lastKey}, nil)
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	cursor, _ := encodeCursor(startKey)
+
+	var v []string
+	next, err := c.ScanByKeyPrefix(ctx, "a-pk-prefix", "a-prefix", cursor, &v)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"hello"}, v)
+
+	nextKey, _ := decodeCursor(next, "PK", "SK")
+	assert.Equal(t, lastKey, nextKey)
+}
+
+func TestScanByKeyPrefixWhenError(t *testing.T) {
+	ctx := context.Background()
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("Scan", ctx, mock.Anything).
+		Return(&dynamodb.ScanOutput{}, expectedError)
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	var v []string
+	_, err := c.ScanByKeyPrefix(ctx, "a-pk-prefix", "a-prefix", "", &v)
+	assert.Equal(t, expectedError, err)
+}
+
+func TestScanKeysByPrefix(t *testing.T) {
+	ctx := context.Background()
+
+	pkey, _ := attributevalue.Marshal("a-pk-prefix")
+	skey, _ := attributevalue.Marshal("a-prefix")
+	item := map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: "a-pk"}, "SK": &types.AttributeValueMemberS{Value: "a-prefix#1"}, "Data": &types.AttributeValueMemberS{Value: "hello"}}
+	lastKey := map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: "a-pk"}, "SK": &types.AttributeValueMemberS{Value: "a-prefix#1"}}
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("Scan", ctx, &dynamodb.ScanInput{
+			TableName:                 aws.String("this"),
+			ExpressionAttributeNames:  map[string]string{"#PK": "PK", "#SK": "SK"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{":PK": pkey, ":SK": skey},
+			FilterExpression:          aws.String("begins_with(#PK, :PK) and begins_with(#SK, :SK)"),
+		}).
+		Return(&dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{item}, LastEvaluatedKey: lastKey}, nil)
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	keys, next, err := c.ScanKeysByPrefix(ctx, "a-pk-prefix", "a-prefix", "")
+	assert.Nil(t, err)
+	assert.Equal(t, []Key{{PK: "a-pk", SK: "a-prefix#1"}}, keys)
+
+	nextKey, _ := decodeCursor(next, "PK", "SK")
+	assert.Equal(t, lastKey, nextKey)
+}
+
+func TestScanKeysByPrefixWhenError(t *testing.T) {
+	ctx := context.Background()
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("Scan", ctx, mock.Anything).
+		Return(&dynamodb.ScanOutput{}, expectedError)
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	_, _, err := c.ScanKeysByPrefix(ctx, "a-pk-prefix", "a-prefix", "")
+	assert.Equal(t, expectedError, err)
+}
+
+func TestGetPageBySKWhenInvalidCursor(t *testing.T) {
+	missingKey, _ := encodeCursor(map[string]types.AttributeValue{"PK": &types.AttributeValueMemberS{Value: "a-pk"}, "SK": &types.AttributeValueMemberS{Value: "a-sk"}})
+	extraKey, _ := encodeCursor(map[string]types.AttributeValue{
+		"PK":        &types.AttributeValueMemberS{Value: "a-pk"},
+		"SK":        &types.AttributeValueMemberS{Value: "a-sk"},
+		"OrderedAt": &types.AttributeValueMemberS{Value: "2023-01-02T03:04:05.000000006Z"},
+		"Other":     &types.AttributeValueMemberS{Value: "x"},
+	})
+
+	testCases := map[string]string{
+		"not base64":  "!!!",
+		"not json":    "aGVsbG8",
+		"missing key": missingKey,
+		"extra key":   extraKey,
+	}
+
+	for name, cursor := range testCases {
+		t.Run(name, func(t *testing.T) {
+			c := &Client{table: "this", svc: &mockDynamoDB{}}
+
+			var v []string
+			_, err := c.GetPageBySK(context.Background(), "a-sk", cursor, 10, &v)
+			assert.Equal(t, ErrInvalidCursor, err)
+		})
+	}
+}
+
+func TestGetPageBySKWhenError(t *testing.T) {
+	ctx := context.Background()
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("Query", ctx, mock.Anything).
+		Return(&dynamodb.QueryOutput{}, expectedError)
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	var v []string
+	_, err := c.GetPageBySK(ctx, "a-sk", "", 10, &v)
+	assert.Equal(t, expectedError, err)
+}
+
 func TestGet(t *testing.T) {
 	ctx := context.Background()
 
@@ -164,6 +486,72 @@ func TestPut(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+type expiringValue struct {
+	Name string
+}
+
+func (expiringValue) ExpiresAt() time.Time {
+	return time.Date(2023, time.January, 2, 3, 4, 5, 6, time.UTC)
+}
+
+func TestPutWhenExpirer(t *testing.T) {
+	ctx := context.Background()
+	pkey, _ := attributevalue.Marshal("a-pk")
+	skey, _ := attributevalue.Marshal("a-sk")
+	data, _ := attributevalue.Marshal(expiringValue{Name: "hello"})
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("PutItem", ctx, &dynamodb.PutItemInput{
+			TableName: aws.String("this"),
+			Item: map[string]types.AttributeValue{
+				"PK":        pkey,
+				"SK":        skey,
+				"Data":      data,
+				"ExpiresAt": &types.AttributeValueMemberN{Value: "1672628645"},
+			},
+		}).
+		Return(&dynamodb.PutItemOutput{}, nil)
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	err := c.Put(ctx, "a-pk", "a-sk", expiringValue{Name: "hello"})
+	assert.Nil(t, err)
+}
+
+type orderedValue struct {
+	Name string
+}
+
+func (orderedValue) OrderedAt() time.Time {
+	return time.Date(2023, time.January, 2, 3, 4, 5, 6, time.FixedZone("", 3600))
+}
+
+func TestPutWhenOrderer(t *testing.T) {
+	ctx := context.Background()
+	pkey, _ := attributevalue.Marshal("a-pk")
+	skey, _ := attributevalue.Marshal("a-sk")
+	data, _ := attributevalue.Marshal(orderedValue{Name: "hello"})
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("PutItem", ctx, &dynamodb.PutItemInput{
+			TableName: aws.String("this"),
+			Item: map[string]types.AttributeValue{
+				"PK":        pkey,
+				"SK":        skey,
+				"Data":      data,
+				"OrderedAt": &types.AttributeValueMemberS{Value: "2023-01-02T02:04:05.000000006Z"},
+			},
+		}).
+		Return(&dynamodb.PutItemOutput{}, nil)
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	err := c.Put(ctx, "a-pk", "a-sk", orderedValue{Name: "hello"})
+	assert.Nil(t, err)
+}
+
 func TestPutWhenError(t *testing.T) {
 	ctx := context.Background()
 	pkey, _ := attributevalue.Marshal("a-pk")
@@ -187,3 +575,251 @@ func TestPutWhenError(t *testing.T) {
 	err := c.Put(ctx, "a-pk", "a-sk", "hello")
 	assert.Equal(t, expectedError, err)
 }
+
+func TestCreate(t *testing.T) {
+	ctx := context.Background()
+	pkey, _ := attributevalue.Marshal("a-pk")
+	skey, _ := attributevalue.Marshal("a-sk")
+	data, _ := attributevalue.Marshal("hello")
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("PutItem", ctx, &dynamodb.PutItemInput{
+			TableName: aws.String("this"),
+			Item: map[string]types.AttributeValue{
+				"PK":   pkey,
+				"SK":   skey,
+				"Data": data,
+			},
+			ConditionExpression:      aws.String("attribute_not_exists(#PK)"),
+			ExpressionAttributeNames: map[string]string{"#PK": "PK"},
+		}).
+		Return(&dynamodb.PutItemOutput{}, nil)
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	err := c.Create(ctx, "a-pk", "a-sk", "hello")
+	assert.Nil(t, err)
+}
+
+func TestCreateWhenExists(t *testing.T) {
+	ctx := context.Background()
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("PutItem", ctx, mock.Anything).
+		Return(&dynamodb.PutItemOutput{}, &types.ConditionalCheckFailedException{})
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	err := c.Create(ctx, "a-pk", "a-sk", "hello")
+	assert.Equal(t, ConflictError{PK: "a-pk", SK: "a-sk"}, err)
+}
+
+func TestCreateWhenError(t *testing.T) {
+	ctx := context.Background()
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("PutItem", ctx, mock.Anything).
+		Return(&dynamodb.PutItemOutput{}, expectedError)
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	err := c.Create(ctx, "a-pk", "a-sk", "hello")
+	assert.Equal(t, expectedError, err)
+}
+
+func TestPutVersioned(t *testing.T) {
+	ctx := context.Background()
+	pkey, _ := attributevalue.Marshal("a-pk")
+	skey, _ := attributevalue.Marshal("a-sk")
+	data, _ := attributevalue.Marshal("hello")
+	version, _ := attributevalue.Marshal(2)
+	previousVersion, _ := attributevalue.Marshal(1)
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("PutItem", ctx, &dynamodb.PutItemInput{
+			TableName: aws.String("this"),
+			Item: map[string]types.AttributeValue{
+				"PK":      pkey,
+				"SK":      skey,
+				"Data":    data,
+				"Version": version,
+			},
+			ConditionExpression:       aws.String("attribute_exists(#PK) AND (#Version = :previousVersion OR attribute_not_exists(#Version))"),
+			ExpressionAttributeNames:  map[string]string{"#PK": "PK", "#Version": "Version"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{":previousVersion": previousVersion},
+		}).
+		Return(&dynamodb.PutItemOutput{}, nil)
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	err := c.PutVersioned(ctx, "a-pk", "a-sk", "hello", 2)
+	assert.Nil(t, err)
+}
+
+func TestPutVersionedWhenConditionFails(t *testing.T) {
+	ctx := context.Background()
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("PutItem", ctx, mock.Anything).
+		Return(&dynamodb.PutItemOutput{}, &types.ConditionalCheckFailedException{})
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	err := c.PutVersioned(ctx, "a-pk", "a-sk", "hello", 2)
+	assert.Equal(t, ConflictError{PK: "a-pk", SK: "a-sk"}, err)
+}
+
+func TestPutVersionedWhenError(t *testing.T) {
+	ctx := context.Background()
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("PutItem", ctx, mock.Anything).
+		Return(&dynamodb.PutItemOutput{}, expectedError)
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	err := c.PutVersioned(ctx, "a-pk", "a-sk", "hello", 2)
+	assert.Equal(t, expectedError, err)
+}
+
+func TestDelete(t *testing.T) {
+	ctx := context.Background()
+	pkey, _ := attributevalue.Marshal("a-pk")
+	skey, _ := attributevalue.Marshal("a-sk")
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("DeleteItem", ctx, &dynamodb.DeleteItemInput{
+			TableName: aws.String("this"),
+			Key:       map[string]types.AttributeValue{"PK": pkey, "SK": skey},
+		}).
+		Return(&dynamodb.DeleteItemOutput{}, nil)
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	err := c.Delete(ctx, "a-pk", "a-sk")
+	assert.Nil(t, err)
+}
+
+func TestDeleteWhenError(t *testing.T) {
+	ctx := context.Background()
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("DeleteItem", ctx, mock.Anything).
+		Return(&dynamodb.DeleteItemOutput{}, expectedError)
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	err := c.Delete(ctx, "a-pk", "a-sk")
+	assert.Equal(t, expectedError, err)
+}
+
+func TestWriteTransaction(t *testing.T) {
+	ctx := context.Background()
+	pkey, _ := attributevalue.Marshal("a-pk")
+	skey, _ := attributevalue.Marshal("a-sk")
+	otherSkey, _ := attributevalue.Marshal("other-sk")
+	createSkey, _ := attributevalue.Marshal("create-sk")
+	deleteSkey, _ := attributevalue.Marshal("delete-sk")
+	data, _ := attributevalue.Marshal("hello")
+	version, _ := attributevalue.Marshal(2)
+	previousVersion, _ := attributevalue.Marshal(1)
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("TransactWriteItems", ctx, &dynamodb.TransactWriteItemsInput{
+			TransactItems: []types.TransactWriteItem{
+				{Put: &types.Put{
+					TableName:                 aws.String("this"),
+					Item:                      map[string]types.AttributeValue{"PK": pkey, "SK": skey, "Data": data, "Version": version},
+					ConditionExpression:       aws.String("attribute_exists(#PK) AND (#Version = :previousVersion OR attribute_not_exists(#Version))"),
+					ExpressionAttributeNames:  map[string]string{"#PK": "PK", "#Version": "Version"},
+					ExpressionAttributeValues: map[string]types.AttributeValue{":previousVersion": previousVersion},
+				}},
+				{Put: &types.Put{
+					TableName: aws.String("this"),
+					Item:      map[string]types.AttributeValue{"PK": pkey, "SK": otherSkey, "Data": data},
+				}},
+				{Put: &types.Put{
+					TableName:                aws.String("this"),
+					Item:                     map[string]types.AttributeValue{"PK": pkey, "SK": createSkey, "Data": data},
+					ConditionExpression:      aws.String("attribute_not_exists(#PK)"),
+					ExpressionAttributeNames: map[string]string{"#PK": "PK"},
+				}},
+				{Delete: &types.Delete{
+					TableName: aws.String("this"),
+					Key:       map[string]types.AttributeValue{"PK": pkey, "SK": deleteSkey},
+				}},
+			},
+		}).
+		Return(&dynamodb.TransactWriteItemsOutput{}, nil)
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	err := c.WriteTransaction(ctx, NewTransaction().
+		PutVersioned("a-pk", "a-sk", "hello", 2).
+		Put("a-pk", "other-sk", "hello").
+		Create("a-pk", "create-sk", "hello").
+		Delete("a-pk", "delete-sk"))
+	assert.Nil(t, err)
+}
+
+func TestWriteTransactionWhenConditionFails(t *testing.T) {
+	ctx := context.Background()
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("TransactWriteItems", ctx, mock.Anything).
+		Return(&dynamodb.TransactWriteItemsOutput{}, &types.TransactionCanceledException{
+			CancellationReasons: []types.CancellationReason{
+				{Code: aws.String("None")},
+				{Code: aws.String("ConditionalCheckFailed")},
+			},
+		})
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	err := c.WriteTransaction(ctx, NewTransaction().
+		Put("a-pk", "a-sk", "hello").
+		Create("a-pk", "create-sk", "hello"))
+	assert.Equal(t, ConflictError{PK: "a-pk", SK: "create-sk"}, err)
+}
+
+func TestWriteTransactionWhenError(t *testing.T) {
+	ctx := context.Background()
+
+	dynamoDB := &mockDynamoDB{}
+	dynamoDB.
+		On("TransactWriteItems", ctx, mock.Anything).
+		Return(&dynamodb.TransactWriteItemsOutput{}, expectedError)
+
+	c := &Client{table: "this", svc: dynamoDB}
+
+	err := c.WriteTransaction(ctx, NewTransaction().Put("a-pk", "a-sk", "hello"))
+	assert.Equal(t, expectedError, err)
+}
+
+func TestWriteTransactionWhenInvalid(t *testing.T) {
+	testcases := map[string]*Transaction{
+		"empty":         NewTransaction(),
+		"repeated item": NewTransaction().Put("a-pk", "a-sk", "hello").Delete("a-pk", "a-sk"),
+	}
+
+	for name, transaction := range testcases {
+		t.Run(name, func(t *testing.T) {
+			dynamoDB := &mockDynamoDB{}
+			c := &Client{table: "this", svc: dynamoDB}
+
+			err := c.WriteTransaction(context.Background(), transaction)
+			assert.NotNil(t, err)
+			mock.AssertExpectationsForObjects(t, dynamoDB)
+		})
+	}
+}
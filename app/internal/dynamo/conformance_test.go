@@ -0,0 +1,392 @@
+package dynamo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// dataStore is the behaviour shared by Client and MemoryClient, which
+// testConformance checks is the same for both.
+type dataStore interface {
+	GetAll(context.Context, string, interface{}) error
+	GetAllByKeyPrefix(context.Context, string, string, interface{}) error
+	GetAllBySK(context.Context, string, interface{}) error
+	GetPageBySK(context.Context, string, string, int, interface{}) (string, error)
+	ScanByKeyPrefix(context.Context, string, string, string, interface{}) (string, error)
+	ScanKeysByPrefix(context.Context, string, string, string) ([]Key, string, error)
+	Get(context.Context, string, string, interface{}) error
+	Put(context.Context, string, string, interface{}) error
+	PutVersioned(context.Context, string, string, interface{}, int) error
+	Create(context.Context, string, string, interface{}) error
+	Delete(context.Context, string, string) error
+	WriteTransaction(context.Context, *Transaction) error
+}
+
+type conformanceItem struct {
+	Name    string
+	Count   int
+	Tags    []string
+	Extra   map[string]string
+	Missing *string
+	At      time.Time
+}
+
+type orderedConformanceItem conformanceItem
+
+func (i orderedConformanceItem) OrderedAt() time.Time {
+	return i.At
+}
+
+func TestMemoryClientConformance(t *testing.T) {
+	testConformance(t, func(t *testing.T) dataStore {
+		c, _ := NewMemoryClient("")
+		return c
+	})
+}
+
+func TestMemoryClientWithFileConformance(t *testing.T) {
+	testConformance(t, func(t *testing.T) dataStore {
+		c, err := NewMemoryClient(filepath.Join(t.TempDir(), "data.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	})
+}
+
+// TestClientConformance runs against a table created as in
+// localstack-init.sh, when AWS_BASE_URL and DYNAMODB_TABLE_LPAS are set.
+func TestClientConformance(t *testing.T) {
+	awsBaseURL := os.Getenv("AWS_BASE_URL")
+	tableName := os.Getenv("DYNAMODB_TABLE_LPAS")
+	if awsBaseURL == "" || tableName == "" {
+		t.Skip("AWS_BASE_URL and DYNAMODB_TABLE_LPAS must be set")
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.EndpointResolverWithOptions = aws.EndpointResolverWithOptionsFunc(func(service, region string, options ...interface{}) (aws.Endpoint, error) {
+		return aws.Endpoint{PartitionID: "aws", URL: awsBaseURL, SigningRegion: "eu-west-1"}, nil
+	})
+
+	testConformance(t, func(t *testing.T) dataStore {
+		c, _ := NewClient(cfg, tableName)
+		return c
+	})
+}
+
+func TestMemoryClientPersists(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "data.json")
+
+	c, _ := NewMemoryClient(path)
+	assert.Nil(t, c.Put(ctx, "a-pk", "an-sk", conformanceItem{Name: "a", Tags: []string{}, Extra: map[string]string{}}))
+	assert.Nil(t, c.Put(ctx, "a-pk", "another-sk", conformanceItem{Name: "b"}))
+	assert.Nil(t, c.PutVersioned(ctx, "a-pk", "another-sk", conformanceItem{Name: "b"}, 1))
+
+	reloaded, err := NewMemoryClient(path)
+	assert.Nil(t, err)
+
+	var v conformanceItem
+	assert.Nil(t, reloaded.Get(ctx, "a-pk", "an-sk", &v))
+	assert.Equal(t, conformanceItem{Name: "a", Tags: []string{}, Extra: map[string]string{}}, v)
+
+	assert.Equal(t, ConflictError{PK: "a-pk", SK: "another-sk"}, reloaded.PutVersioned(ctx, "a-pk", "another-sk", conformanceItem{}, 1))
+	assert.Nil(t, reloaded.PutVersioned(ctx, "a-pk", "another-sk", conformanceItem{}, 2))
+}
+
+func TestNewMemoryClientWhenFileInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	os.WriteFile(path, []byte(`[{"PK":"a","SK":"b","Data":{"S":"x","N":"1"}}]`), 0o600)
+
+	_, err := NewMemoryClient(path)
+	assert.NotNil(t, err)
+}
+
+func testConformance(t *testing.T, newStore func(*testing.T) dataStore) {
+	ctx := context.Background()
+
+	// keys are unique to each run, so that a shared table can be used
+	prefix := time.Now().Format("20060102150405.000000000") + "#"
+
+	t.Run("Get", func(t *testing.T) {
+		store := newStore(t)
+		item := conformanceItem{
+			Name:  "a",
+			Count: 2,
+			Tags:  []string{"x", "y"},
+			Extra: map[string]string{"k": "v"},
+			At:    time.Date(2020, time.January, 2, 3, 4, 5, 6, time.UTC),
+		}
+
+		assert.Nil(t, store.Put(ctx, prefix+"get", "SK", item))
+
+		var v conformanceItem
+		assert.Nil(t, store.Get(ctx, prefix+"get", "SK", &v))
+		assert.Equal(t, item, v)
+	})
+
+	t.Run("GetWhenMissing", func(t *testing.T) {
+		store := newStore(t)
+
+		v := conformanceItem{Name: "unchanged"}
+		assert.Nil(t, store.Get(ctx, prefix+"missing", "SK", &v))
+		assert.Equal(t, conformanceItem{Name: "unchanged"}, v)
+	})
+
+	t.Run("GetAll", func(t *testing.T) {
+		store := newStore(t)
+		pk := prefix + "get-all"
+
+		for _, sk := range []string{"b", "B", "a#2", "a#10", "a"} {
+			assert.Nil(t, store.Put(ctx, pk, sk, conformanceItem{Name: sk}))
+		}
+		assert.Nil(t, store.Put(ctx, pk+"-other", "a", conformanceItem{Name: "other"}))
+
+		var v []conformanceItem
+		assert.Nil(t, store.GetAll(ctx, pk, &v))
+		assert.Equal(t, []string{"B", "a", "a#10", "a#2", "b"}, names(v))
+	})
+
+	t.Run("GetAllByKeyPrefix", func(t *testing.T) {
+		store := newStore(t)
+		pk := prefix + "get-all-by-key-prefix"
+
+		for _, sk := range []string{"b#1", "a#2", "a#1", "a"} {
+			assert.Nil(t, store.Put(ctx, pk, sk, conformanceItem{Name: sk}))
+		}
+
+		var v []conformanceItem
+		assert.Nil(t, store.GetAllByKeyPrefix(ctx, pk, "a#", &v))
+		assert.Equal(t, []string{"a#1", "a#2"}, names(v))
+	})
+
+	t.Run("GetAllBySK", func(t *testing.T) {
+		store := newStore(t)
+		sk := prefix + "get-all-by-sk"
+
+		for _, pk := range []string{"c", "a", "b"} {
+			assert.Nil(t, store.Put(ctx, prefix+pk, sk, conformanceItem{Name: pk}))
+		}
+		assert.Nil(t, store.Put(ctx, prefix+"a", sk+"-other", conformanceItem{Name: "other"}))
+
+		var v []conformanceItem
+		assert.Nil(t, store.GetAllBySK(ctx, sk, &v))
+		assert.Equal(t, []string{"a", "b", "c"}, names(v))
+	})
+
+	t.Run("GetPageBySK", func(t *testing.T) {
+		store := newStore(t)
+		sk := prefix + "get-page-by-sk"
+		at := time.Date(2023, time.January, 2, 3, 4, 5, 6, time.UTC)
+
+		for pk, days := range map[string]int{"e": 5, "c": 3, "a": 1, "d": 3, "b": 2} {
+			assert.Nil(t, store.Put(ctx, prefix+pk, sk, orderedConformanceItem{Name: pk, At: at.AddDate(0, 0, days)}))
+		}
+		assert.Nil(t, store.WriteTransaction(ctx, NewTransaction().
+			Put(prefix+"f", sk, orderedConformanceItem{Name: "f", At: at.AddDate(0, 0, 4)})))
+		assert.Nil(t, store.Put(ctx, prefix+"g", sk, conformanceItem{Name: "unordered"}))
+
+		var pages [][]string
+		cursor := ""
+		for {
+			var v []conformanceItem
+			next, err := store.GetPageBySK(ctx, sk, cursor, 2, &v)
+			assert.Nil(t, err)
+			pages = append(pages, names(v))
+
+			if next == "" {
+				break
+			}
+			cursor = next
+		}
+
+		assert.Equal(t, [][]string{{"e", "f"}, {"d", "c"}, {"b", "a"}, nil}, pages)
+	})
+
+	t.Run("GetPageBySKWhenInvalidCursor", func(t *testing.T) {
+		store := newStore(t)
+
+		var v []conformanceItem
+		_, err := store.GetPageBySK(ctx, prefix+"get-page-by-sk", "!", 2, &v)
+		assert.Equal(t, ErrInvalidCursor, err)
+	})
+
+	t.Run("ScanByKeyPrefix", func(t *testing.T) {
+		store := newStore(t)
+		pkPrefix := prefix + "scan-pk#"
+		skPrefix := prefix + "scan#"
+
+		assert.Nil(t, store.Put(ctx, pkPrefix+"b", skPrefix+"1", conformanceItem{Name: "b1"}))
+		assert.Nil(t, store.Put(ctx, pkPrefix+"a", skPrefix+"2", conformanceItem{Name: "a2"}))
+		assert.Nil(t, store.Put(ctx, pkPrefix+"a", skPrefix+"1", conformanceItem{Name: "a1"}))
+		assert.Nil(t, store.Put(ctx, pkPrefix+"a", prefix+"other", conformanceItem{Name: "other"}))
+		assert.Nil(t, store.Put(ctx, prefix+"other", skPrefix+"1", conformanceItem{Name: "other"}))
+
+		var all []string
+		cursor := ""
+		for {
+			var v []conformanceItem
+			next, err := store.ScanByKeyPrefix(ctx, pkPrefix, skPrefix, cursor, &v)
+			assert.Nil(t, err)
+			all = append(all, names(v)...)
+
+			if next == "" {
+				break
+			}
+			cursor = next
+		}
+
+		// scans are not ordered
+		assert.ElementsMatch(t, []string{"a1", "a2", "b1"}, all)
+	})
+
+	t.Run("ScanKeysByPrefix", func(t *testing.T) {
+		store := newStore(t)
+		pkPrefix := prefix + "scan-keys-pk#"
+		skPrefix := prefix + "scan-keys#"
+
+		assert.Nil(t, store.Put(ctx, pkPrefix+"b", skPrefix+"1", conformanceItem{Name: "b1"}))
+		assert.Nil(t, store.Put(ctx, pkPrefix+"a", skPrefix+"1", conformanceItem{Name: "a1"}))
+		assert.Nil(t, store.Put(ctx, pkPrefix+"a", prefix+"other", conformanceItem{Name: "other"}))
+		assert.Nil(t, store.Put(ctx, prefix+"other", skPrefix+"1", conformanceItem{Name: "other"}))
+
+		var all []Key
+		cursor := ""
+		for {
+			keys, next, err := store.ScanKeysByPrefix(ctx, pkPrefix, skPrefix, cursor)
+			assert.Nil(t, err)
+			all = append(all, keys...)
+
+			if next == "" {
+				break
+			}
+			cursor = next
+		}
+
+		// scans are not ordered
+		assert.ElementsMatch(t, []Key{{PK: pkPrefix + "a", SK: skPrefix + "1"}, {PK: pkPrefix + "b", SK: skPrefix + "1"}}, all)
+	})
+
+	t.Run("Put", func(t *testing.T) {
+		store := newStore(t)
+
+		assert.Nil(t, store.Put(ctx, prefix+"put", "SK", conformanceItem{Name: "a", Count: 1}))
+		assert.Nil(t, store.Put(ctx, prefix+"put", "SK", conformanceItem{Name: "b"}))
+
+		var v conformanceItem
+		assert.Nil(t, store.Get(ctx, prefix+"put", "SK", &v))
+		assert.Equal(t, conformanceItem{Name: "b"}, v)
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		store := newStore(t)
+
+		assert.Nil(t, store.Create(ctx, prefix+"create", "SK", conformanceItem{Name: "a"}))
+		assert.Equal(t, ConflictError{PK: prefix + "create", SK: "SK"}, store.Create(ctx, prefix+"create", "SK", conformanceItem{Name: "b"}))
+
+		var v conformanceItem
+		assert.Nil(t, store.Get(ctx, prefix+"create", "SK", &v))
+		assert.Equal(t, conformanceItem{Name: "a"}, v)
+	})
+
+	t.Run("PutVersioned", func(t *testing.T) {
+		store := newStore(t)
+		pk := prefix + "put-versioned"
+
+		assert.Nil(t, store.Put(ctx, pk, "SK", conformanceItem{Name: "unversioned"}))
+		assert.Nil(t, store.PutVersioned(ctx, pk, "SK", conformanceItem{Name: "1"}, 1))
+		assert.Nil(t, store.PutVersioned(ctx, pk, "SK", conformanceItem{Name: "2"}, 2))
+		assert.Equal(t, ConflictError{PK: pk, SK: "SK"}, store.PutVersioned(ctx, pk, "SK", conformanceItem{Name: "stale"}, 2))
+		assert.Equal(t, ConflictError{PK: pk, SK: "SK"}, store.PutVersioned(ctx, pk, "SK", conformanceItem{Name: "skipped"}, 4))
+
+		var v conformanceItem
+		assert.Nil(t, store.Get(ctx, pk, "SK", &v))
+		assert.Equal(t, conformanceItem{Name: "2"}, v)
+
+		assert.Nil(t, store.Put(ctx, pk, "SK", conformanceItem{Name: "put"}))
+		assert.Nil(t, store.PutVersioned(ctx, pk, "SK", conformanceItem{Name: "any"}, 7))
+
+		assert.Nil(t, store.Delete(ctx, pk, "SK"))
+		assert.Equal(t, ConflictError{PK: pk, SK: "SK"}, store.PutVersioned(ctx, pk, "SK", conformanceItem{Name: "deleted"}, 8))
+		assert.Equal(t, ConflictError{PK: pk, SK: "missing"}, store.PutVersioned(ctx, pk, "missing", conformanceItem{Name: "missing"}, 1))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		store := newStore(t)
+		pk := prefix + "delete"
+
+		assert.Nil(t, store.Put(ctx, pk, "a", conformanceItem{Name: "a"}))
+		assert.Nil(t, store.Put(ctx, pk, "b", conformanceItem{Name: "b"}))
+		assert.Nil(t, store.Delete(ctx, pk, "a"))
+		assert.Nil(t, store.Delete(ctx, pk, "missing"))
+
+		var v []conformanceItem
+		assert.Nil(t, store.GetAll(ctx, pk, &v))
+		assert.Equal(t, []string{"b"}, names(v))
+
+		assert.Nil(t, store.Create(ctx, pk, "a", conformanceItem{Name: "again"}))
+	})
+
+	t.Run("WriteTransaction", func(t *testing.T) {
+		store := newStore(t)
+		pk := prefix + "write-transaction"
+
+		assert.Nil(t, store.Create(ctx, pk, "versioned", conformanceItem{Name: "0"}))
+		assert.Nil(t, store.PutVersioned(ctx, pk, "versioned", conformanceItem{Name: "1"}, 1))
+		assert.Nil(t, store.Put(ctx, pk, "deleted", conformanceItem{Name: "deleted"}))
+
+		assert.Nil(t, store.WriteTransaction(ctx, NewTransaction().
+			PutVersioned(pk, "versioned", conformanceItem{Name: "2"}, 2).
+			Put(pk, "put", conformanceItem{Name: "put"}).
+			Create(pk, "created", conformanceItem{Name: "created"}).
+			Delete(pk, "deleted")))
+
+		var v []conformanceItem
+		assert.Nil(t, store.GetAll(ctx, pk, &v))
+		assert.Equal(t, []string{"created", "put", "2"}, names(v))
+	})
+
+	t.Run("WriteTransactionWhenConditionFails", func(t *testing.T) {
+		store := newStore(t)
+		pk := prefix + "write-transaction-fails"
+
+		assert.Nil(t, store.Create(ctx, pk, "versioned", conformanceItem{Name: "0"}))
+		assert.Nil(t, store.PutVersioned(ctx, pk, "versioned", conformanceItem{Name: "1"}, 1))
+		assert.Nil(t, store.Put(ctx, pk, "existing", conformanceItem{Name: "existing"}))
+
+		assert.Equal(t, ConflictError{PK: pk, SK: "versioned"}, store.WriteTransaction(ctx, NewTransaction().
+			Put(pk, "put", conformanceItem{Name: "put"}).
+			PutVersioned(pk, "versioned", conformanceItem{Name: "stale"}, 1).
+			Delete(pk, "existing")))
+
+		assert.Equal(t, ConflictError{PK: pk, SK: "existing"}, store.WriteTransaction(ctx, NewTransaction().
+			Put(pk, "put", conformanceItem{Name: "put"}).
+			Create(pk, "existing", conformanceItem{Name: "created"})))
+
+		assert.Equal(t, ConflictError{PK: pk, SK: "missing"}, store.WriteTransaction(ctx, NewTransaction().
+			Put(pk, "put", conformanceItem{Name: "put"}).
+			PutVersioned(pk, "missing", conformanceItem{Name: "missing"}, 1)))
+
+		var v []conformanceItem
+		assert.Nil(t, store.GetAll(ctx, pk, &v))
+		assert.Equal(t, []string{"existing", "1"}, names(v))
+	})
+}
+
+func names(items []conformanceItem) []string {
+	var s []string
+	for _, item := range items {
+		s = append(s, item.Name)
+	}
+	return s
+}
@@ -0,0 +1,588 @@
+package dynamo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// A MemoryClient stores items in memory, with the same key semantics as a
+// Client, so that the app can be run without Dynamo. When given a path, the
+// items are loaded from that file and written back to it after each change.
+type MemoryClient struct {
+	mu    sync.Mutex
+	path  string
+	items map[string]map[string]memoryItem
+}
+
+type memoryItem struct {
+	Data      types.AttributeValue
+	Version   *int
+	OrderedAt string
+}
+
+func NewMemoryClient(path string) (*MemoryClient, error) {
+	c := &MemoryClient{path: path, items: map[string]map[string]memoryItem{}}
+
+	if path != "" {
+		if err := c.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+func (c *MemoryClient) GetAll(ctx context.Context, pk string, v interface{}) error {
+	return c.GetAllByKeyPrefix(ctx, pk, "", v)
+}
+
+// GetAllByKeyPrefix returns the data of items in the partition pk that have a
+// sort key starting with skPrefix.
+func (c *MemoryClient) GetAllByKeyPrefix(ctx context.Context, pk, skPrefix string, v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sks := make([]string, 0, len(c.items[pk]))
+	for sk := range c.items[pk] {
+		if strings.HasPrefix(sk, skPrefix) {
+			sks = append(sks, sk)
+		}
+	}
+	sort.Strings(sks)
+
+	var items []map[string]types.AttributeValue
+	for _, sk := range sks {
+		items = append(items, map[string]types.AttributeValue{"Data": c.items[pk][sk].Data})
+	}
+
+	return unmarshalData(items, v)
+}
+
+// GetAllBySK returns the data of items, across all partitions, that have the
+// sort key sk.
+func (c *MemoryClient) GetAllBySK(ctx context.Context, sk string, v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var items []map[string]types.AttributeValue
+	for _, pk := range c.pksWithSK(sk) {
+		items = append(items, map[string]types.AttributeValue{"Data": c.items[pk][sk].Data})
+	}
+
+	return unmarshalData(items, v)
+}
+
+// GetPageBySK returns the data of up to limit items, across all partitions,
+// that have the sort key sk and were stored as an Orderer, most recently
+// ordered first. As with a Client, a cursor is returned whenever the page is
+// full, so the last page may be empty.
+func (c *MemoryClient) GetPageBySK(ctx context.Context, sk, cursor string, limit int, v interface{}) (string, error) {
+	startKey, err := decodeCursor(cursor, "PK", "SK", "OrderedAt")
+	if err != nil {
+		return "", err
+	}
+
+	var start memoryOrderedKey
+	if startKey != nil {
+		if err := attributevalue.UnmarshalMap(startKey, &start); err != nil {
+			return "", ErrInvalidCursor
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var keys []memoryOrderedKey
+	for _, pk := range c.pksWithSK(sk) {
+		if item := c.items[pk][sk]; item.OrderedAt != "" {
+			keys = append(keys, memoryOrderedKey{PK: pk, SK: sk, OrderedAt: item.OrderedAt})
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[j].before(keys[i]) })
+
+	var (
+		items   []map[string]types.AttributeValue
+		lastKey map[string]types.AttributeValue
+	)
+	for _, key := range keys {
+		if startKey != nil && !key.before(start) {
+			continue
+		}
+
+		items = append(items, map[string]types.AttributeValue{"Data": c.items[key.PK][sk].Data})
+
+		if len(items) == limit {
+			if lastKey, err = attributevalue.MarshalMap(key); err != nil {
+				return "", err
+			}
+			break
+		}
+	}
+
+	next, err := encodeCursor(lastKey)
+	if err != nil {
+		return "", err
+	}
+
+	return next, unmarshalData(items, v)
+}
+
+// A memoryOrderedKey is the key of an item in the index on SK and OrderedAt.
+type memoryOrderedKey struct {
+	PK, SK, OrderedAt string
+}
+
+func (k memoryOrderedKey) before(other memoryOrderedKey) bool {
+	if k.OrderedAt != other.OrderedAt {
+		return k.OrderedAt < other.OrderedAt
+	}
+
+	return k.PK < other.PK
+}
+
+// ScanByKeyPrefix returns the data of items that have a partition key starting
+// with pkPrefix and a sort key starting with skPrefix. All items are returned
+// in a single page.
+func (c *MemoryClient) ScanByKeyPrefix(ctx context.Context, pkPrefix, skPrefix, cursor string, v interface{}) (string, error) {
+	if _, err := decodeCursor(cursor, "PK", "SK"); err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := c.scanKeys(pkPrefix, skPrefix)
+	items := make([]map[string]types.AttributeValue, len(keys))
+	for i, key := range keys {
+		items[i] = map[string]types.AttributeValue{"Data": c.items[key.PK][key.SK].Data}
+	}
+
+	return "", unmarshalData(items, v)
+}
+
+// ScanKeysByPrefix returns the keys of items that have a partition key starting
+// with pkPrefix and a sort key starting with skPrefix. All keys are returned in
+// a single page.
+func (c *MemoryClient) ScanKeysByPrefix(ctx context.Context, pkPrefix, skPrefix, cursor string) ([]Key, string, error) {
+	if _, err := decodeCursor(cursor, "PK", "SK"); err != nil {
+		return nil, "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.scanKeys(pkPrefix, skPrefix), "", nil
+}
+
+func (c *MemoryClient) scanKeys(pkPrefix, skPrefix string) []Key {
+	var pks []string
+	for pk := range c.items {
+		if strings.HasPrefix(pk, pkPrefix) {
+			pks = append(pks, pk)
+		}
+	}
+	sort.Strings(pks)
+
+	var keys []Key
+	for _, pk := range pks {
+		sks := make([]string, 0, len(c.items[pk]))
+		for sk := range c.items[pk] {
+			if strings.HasPrefix(sk, skPrefix) {
+				sks = append(sks, sk)
+			}
+		}
+		sort.Strings(sks)
+
+		for _, sk := range sks {
+			keys = append(keys, Key{PK: pk, SK: sk})
+		}
+	}
+
+	return keys
+}
+
+func (c *MemoryClient) Get(ctx context.Context, pk, sk string, v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.items[pk][sk]
+	if !ok {
+		return nil
+	}
+
+	return attributevalue.Unmarshal(item.Data, v)
+}
+
+func (c *MemoryClient) Put(ctx context.Context, pk, sk string, v interface{}) error {
+	data, err := attributevalue.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.write(pk, sk, memoryItem{Data: data, OrderedAt: orderedAt(v)})
+}
+
+// Create writes v only if there is no item with the same keys.
+func (c *MemoryClient) Create(ctx context.Context, pk, sk string, v interface{}) error {
+	data, err := attributevalue.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.items[pk][sk]; ok {
+		return ConflictError{PK: pk, SK: sk}
+	}
+
+	return c.write(pk, sk, memoryItem{Data: data, OrderedAt: orderedAt(v)})
+}
+
+// PutVersioned updates the item to v with the given version, only if it exists
+// and is at the previous version (or has never been versioned).
+func (c *MemoryClient) PutVersioned(ctx context.Context, pk, sk string, v interface{}, version int) error {
+	data, err := attributevalue.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if item, ok := c.items[pk][sk]; !ok || item.Version != nil && *item.Version != version-1 {
+		return ConflictError{PK: pk, SK: sk}
+	}
+
+	return c.write(pk, sk, memoryItem{Data: data, Version: &version, OrderedAt: orderedAt(v)})
+}
+
+// Delete removes the item with the given keys, if there is one.
+func (c *MemoryClient) Delete(ctx context.Context, pk, sk string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.items[pk][sk]; !ok {
+		return nil
+	}
+
+	delete(c.items[pk], sk)
+	if len(c.items[pk]) == 0 {
+		delete(c.items, pk)
+	}
+
+	return c.save()
+}
+
+// WriteTransaction makes all of the writes in t, or none of them.
+func (c *MemoryClient) WriteTransaction(ctx context.Context, t *Transaction) error {
+	if err := t.validate(); err != nil {
+		return err
+	}
+
+	data := make([]types.AttributeValue, len(t.writes))
+	for i, w := range t.writes {
+		if w.Kind == TransactionDelete {
+			continue
+		}
+
+		v, err := attributevalue.Marshal(w.Value)
+		if err != nil {
+			return err
+		}
+		data[i] = v
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, w := range t.writes {
+		item, ok := c.items[w.PK][w.SK]
+
+		switch w.Kind {
+		case TransactionCreate:
+			if ok {
+				return ConflictError{PK: w.PK, SK: w.SK}
+			}
+		case TransactionPutVersioned:
+			if !ok || item.Version != nil && *item.Version != w.Version-1 {
+				return ConflictError{PK: w.PK, SK: w.SK}
+			}
+		}
+	}
+
+	for i, w := range t.writes {
+		switch w.Kind {
+		case TransactionDelete:
+			delete(c.items[w.PK], w.SK)
+			if len(c.items[w.PK]) == 0 {
+				delete(c.items, w.PK)
+			}
+		case TransactionPutVersioned:
+			version := w.Version
+			c.set(w.PK, w.SK, memoryItem{Data: data[i], Version: &version, OrderedAt: orderedAt(w.Value)})
+		default:
+			c.set(w.PK, w.SK, memoryItem{Data: data[i], OrderedAt: orderedAt(w.Value)})
+		}
+	}
+
+	return c.save()
+}
+
+// pksWithSK returns, in order, the partition keys of items with the sort key
+// sk, matching the order of the index on SK.
+func (c *MemoryClient) pksWithSK(sk string) []string {
+	var pks []string
+	for pk, partition := range c.items {
+		if _, ok := partition[sk]; ok {
+			pks = append(pks, pk)
+		}
+	}
+	sort.Strings(pks)
+
+	return pks
+}
+
+func (c *MemoryClient) write(pk, sk string, item memoryItem) error {
+	c.set(pk, sk, item)
+
+	return c.save()
+}
+
+func (c *MemoryClient) set(pk, sk string, item memoryItem) {
+	if c.items[pk] == nil {
+		c.items[pk] = map[string]memoryItem{}
+	}
+	c.items[pk][sk] = item
+}
+
+// A memoryFileItem is how an item is persisted, with its data in the JSON
+// format used by Dynamo.
+type memoryFileItem struct {
+	PK        string
+	SK        string
+	Version   *int   `json:",omitempty"`
+	OrderedAt string `json:",omitempty"`
+	Data      json.RawMessage
+}
+
+func (c *MemoryClient) load() error {
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var fileItems []memoryFileItem
+	if err := json.Unmarshal(data, &fileItems); err != nil {
+		return fmt.Errorf("dynamo: could not read %s: %w", c.path, err)
+	}
+
+	for _, fileItem := range fileItems {
+		av, err := unmarshalAttributeValueJSON(fileItem.Data)
+		if err != nil {
+			return fmt.Errorf("dynamo: could not read %s: %w", c.path, err)
+		}
+
+		if c.items[fileItem.PK] == nil {
+			c.items[fileItem.PK] = map[string]memoryItem{}
+		}
+		c.items[fileItem.PK][fileItem.SK] = memoryItem{Data: av, Version: fileItem.Version, OrderedAt: fileItem.OrderedAt}
+	}
+
+	return nil
+}
+
+// save writes all items to the file, if there is one, replacing it so that a
+// partial write is never left behind.
+func (c *MemoryClient) save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	pks := make([]string, 0, len(c.items))
+	for pk := range c.items {
+		pks = append(pks, pk)
+	}
+	sort.Strings(pks)
+
+	fileItems := []memoryFileItem{}
+	for _, pk := range pks {
+		sks := make([]string, 0, len(c.items[pk]))
+		for sk := range c.items[pk] {
+			sks = append(sks, sk)
+		}
+		sort.Strings(sks)
+
+		for _, sk := range sks {
+			item := c.items[pk][sk]
+
+			data, err := marshalAttributeValueJSON(item.Data)
+			if err != nil {
+				return err
+			}
+
+			fileItems = append(fileItems, memoryFileItem{PK: pk, SK: sk, Version: item.Version, OrderedAt: item.OrderedAt, Data: data})
+		}
+	}
+
+	data, err := json.MarshalIndent(fileItems, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), c.path)
+}
+
+// marshalAttributeValueJSON encodes av in the JSON format Dynamo uses, where
+// each value is an object with a single field naming its type.
+func marshalAttributeValueJSON(av types.AttributeValue) (json.RawMessage, error) {
+	var (
+		kind  string
+		value interface{}
+	)
+
+	switch av := av.(type) {
+	case *types.AttributeValueMemberS:
+		kind, value = "S", av.Value
+	case *types.AttributeValueMemberN:
+		kind, value = "N", av.Value
+	case *types.AttributeValueMemberB:
+		kind, value = "B", av.Value
+	case *types.AttributeValueMemberSS:
+		kind, value = "SS", av.Value
+	case *types.AttributeValueMemberNS:
+		kind, value = "NS", av.Value
+	case *types.AttributeValueMemberBS:
+		kind, value = "BS", av.Value
+	case *types.AttributeValueMemberM:
+		m := make(map[string]json.RawMessage, len(av.Value))
+		for k, v := range av.Value {
+			data, err := marshalAttributeValueJSON(v)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = data
+		}
+		kind, value = "M", m
+	case *types.AttributeValueMemberL:
+		l := make([]json.RawMessage, len(av.Value))
+		for i, v := range av.Value {
+			data, err := marshalAttributeValueJSON(v)
+			if err != nil {
+				return nil, err
+			}
+			l[i] = data
+		}
+		kind, value = "L", l
+	case *types.AttributeValueMemberNULL:
+		kind, value = "NULL", true
+	case *types.AttributeValueMemberBOOL:
+		kind, value = "BOOL", av.Value
+	default:
+		return nil, fmt.Errorf("dynamo: unsupported attribute value %T", av)
+	}
+
+	return json.Marshal(map[string]interface{}{kind: value})
+}
+
+func unmarshalAttributeValueJSON(data json.RawMessage) (types.AttributeValue, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if len(fields) != 1 {
+		return nil, fmt.Errorf("dynamo: attribute value must have one type, got %d", len(fields))
+	}
+
+	for kind, raw := range fields {
+		switch kind {
+		case "S":
+			av := &types.AttributeValueMemberS{}
+			return av, json.Unmarshal(raw, &av.Value)
+		case "N":
+			av := &types.AttributeValueMemberN{}
+			return av, json.Unmarshal(raw, &av.Value)
+		case "B":
+			av := &types.AttributeValueMemberB{}
+			return av, json.Unmarshal(raw, &av.Value)
+		case "SS":
+			av := &types.AttributeValueMemberSS{}
+			return av, json.Unmarshal(raw, &av.Value)
+		case "NS":
+			av := &types.AttributeValueMemberNS{}
+			return av, json.Unmarshal(raw, &av.Value)
+		case "BS":
+			av := &types.AttributeValueMemberBS{}
+			return av, json.Unmarshal(raw, &av.Value)
+		case "M":
+			var m map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &m); err != nil {
+				return nil, err
+			}
+
+			av := &types.AttributeValueMemberM{Value: make(map[string]types.AttributeValue, len(m))}
+			for k, v := range m {
+				value, err := unmarshalAttributeValueJSON(v)
+				if err != nil {
+					return nil, err
+				}
+				av.Value[k] = value
+			}
+			return av, nil
+		case "L":
+			var l []json.RawMessage
+			if err := json.Unmarshal(raw, &l); err != nil {
+				return nil, err
+			}
+
+			av := &types.AttributeValueMemberL{Value: make([]types.AttributeValue, len(l))}
+			for i, v := range l {
+				value, err := unmarshalAttributeValueJSON(v)
+				if err != nil {
+					return nil, err
+				}
+				av.Value[i] = value
+			}
+			return av, nil
+		case "NULL":
+			return &types.AttributeValueMemberNULL{Value: true}, nil
+		case "BOOL":
+			av := &types.AttributeValueMemberBOOL{}
+			return av, json.Unmarshal(raw, &av.Value)
+		default:
+			return nil, fmt.Errorf("dynamo: unsupported attribute value type %s", kind)
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,85 @@
+package dynamo
+
+import (
+	"errors"
+	"fmt"
+)
+
+type TransactionWriteKind string
+
+const (
+	TransactionPut          TransactionWriteKind = "Put"
+	TransactionCreate       TransactionWriteKind = "Create"
+	TransactionPutVersioned TransactionWriteKind = "PutVersioned"
+	TransactionDelete       TransactionWriteKind = "Delete"
+)
+
+// A TransactionWrite is one of the writes in a Transaction. Version is only set
+// for PutVersioned, and Value is not set for Delete.
+type TransactionWrite struct {
+	Kind    TransactionWriteKind
+	PK, SK  string
+	Value   interface{}
+	Version int
+}
+
+// A Transaction is a set of writes made with WriteTransaction, which are either
+// all made or, if any condition fails, none are. Each write has the same
+// meaning as the method of the same name on Client. An item can only be
+// written once in a transaction.
+type Transaction struct {
+	writes []TransactionWrite
+}
+
+func NewTransaction() *Transaction {
+	return &Transaction{}
+}
+
+func (t *Transaction) Put(pk, sk string, v interface{}) *Transaction {
+	t.writes = append(t.writes, TransactionWrite{Kind: TransactionPut, PK: pk, SK: sk, Value: v})
+	return t
+}
+
+func (t *Transaction) Create(pk, sk string, v interface{}) *Transaction {
+	t.writes = append(t.writes, TransactionWrite{Kind: TransactionCreate, PK: pk, SK: sk, Value: v})
+	return t
+}
+
+func (t *Transaction) PutVersioned(pk, sk string, v interface{}, version int) *Transaction {
+	t.writes = append(t.writes, TransactionWrite{Kind: TransactionPutVersioned, PK: pk, SK: sk, Value: v, Version: version})
+	return t
+}
+
+func (t *Transaction) Delete(pk, sk string) *Transaction {
+	t.writes = append(t.writes, TransactionWrite{Kind: TransactionDelete, PK: pk, SK: sk})
+	return t
+}
+
+// Writes returns the writes in the transaction, in the order they were added.
+func (t *Transaction) Writes() []TransactionWrite {
+	return t.writes
+}
+
+// maxTransactionItems is the most writes Dynamo allows in one transaction.
+const maxTransactionItems = 100
+
+func (t *Transaction) validate() error {
+	if len(t.writes) == 0 {
+		return errors.New("dynamo: transaction has no writes")
+	}
+
+	if len(t.writes) > maxTransactionItems {
+		return fmt.Errorf("dynamo: transaction has %d writes, more than %d", len(t.writes), maxTransactionItems)
+	}
+
+	seen := map[[2]string]bool{}
+	for _, w := range t.writes {
+		key := [2]string{w.PK, w.SK}
+		if seen[key] {
+			return fmt.Errorf("dynamo: transaction writes %s %s more than once", w.PK, w.SK)
+		}
+		seen[key] = true
+	}
+
+	return nil
+}
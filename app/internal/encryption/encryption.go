@@ -0,0 +1,127 @@
+// Package encryption provides envelope encryption of values before they are
+// stored.
+//
+// Each value is encrypted with a new data key, using AES-256-GCM. The data key
+// is itself encrypted ("wrapped") by a KeyProvider and stored, alongside the
+// ID of the key that wrapped it, with the ciphertext. A KeyProvider works in
+// the same way as a KMS key, so that the key encryption keys never need to be
+// held with the data. When keys are rotated the provider must still be able to
+// unwrap data keys wrapped by previous keys, so older values remain readable.
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const dataKeyLength = 32
+
+var ErrUnknownKey = errors.New("encryption: unknown key")
+
+// A DataKey is a key to encrypt a value with, and the same key wrapped by the
+// key with KeyID.
+type DataKey struct {
+	KeyID     string
+	Plaintext []byte
+	Wrapped   []byte
+}
+
+// A KeyProvider generates data keys, and unwraps data keys it has generated.
+type KeyProvider interface {
+	GenerateDataKey(ctx context.Context) (DataKey, error)
+	Decrypt(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// A Sealed value is the ciphertext of a JSON encoded value, with what is needed
+// to decrypt it given the KeyProvider.
+type Sealed struct {
+	KeyID      string
+	WrappedKey []byte
+	Nonce      []byte
+	Ciphertext []byte
+}
+
+type Envelope struct {
+	keys KeyProvider
+}
+
+func New(keys KeyProvider) *Envelope {
+	return &Envelope{keys: keys}
+}
+
+// Seal encrypts v, encoded as JSON, with a new data key.
+func (e *Envelope) Seal(ctx context.Context, v interface{}) (*Sealed, error) {
+	plaintext, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := e.keys.GenerateDataKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(dataKey.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := randomBytes(aead.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sealed{
+		KeyID:      dataKey.KeyID,
+		WrappedKey: dataKey.Wrapped,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, []byte(dataKey.KeyID)),
+	}, nil
+}
+
+// Open decrypts sealed into v.
+func (e *Envelope) Open(ctx context.Context, sealed *Sealed, v interface{}) error {
+	key, err := e.keys.Decrypt(ctx, sealed.KeyID, sealed.WrappedKey)
+	if err != nil {
+		return err
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	if len(sealed.Nonce) != aead.NonceSize() {
+		return errors.New("encryption: invalid nonce")
+	}
+
+	plaintext, err := aead.Open(nil, sealed.Nonce, sealed.Ciphertext, []byte(sealed.KeyID))
+	if err != nil {
+		return fmt.Errorf("encryption: could not decrypt: %w", err)
+	}
+
+	return json.Unmarshal(plaintext, v)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
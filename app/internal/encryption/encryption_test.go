@@ -0,0 +1,120 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	ctx         = context.Background()
+	key         = bytes.Repeat([]byte{1}, 32)
+	rotatedKey  = bytes.Repeat([]byte{2}, 32)
+	unknownKey  = bytes.Repeat([]byte{3}, 32)
+	errExpected = errors.New("err")
+)
+
+type testValue struct {
+	Name string
+	Age  int
+}
+
+type errorKeyProvider struct{}
+
+func (errorKeyProvider) GenerateDataKey(ctx context.Context) (DataKey, error) {
+	return DataKey{}, errExpected
+}
+
+func (errorKeyProvider) Decrypt(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	return nil, errExpected
+}
+
+func TestSealOpen(t *testing.T) {
+	keys, _ := NewLocalKeyProvider(key)
+	envelope := New(keys)
+
+	sealed, err := envelope.Seal(ctx, testValue{Name: "John", Age: 40})
+	assert.Nil(t, err)
+	assert.NotContains(t, string(sealed.Ciphertext), "John")
+	assert.Equal(t, localKeyID(key), sealed.KeyID)
+
+	var v testValue
+	assert.Nil(t, envelope.Open(ctx, sealed, &v))
+	assert.Equal(t, testValue{Name: "John", Age: 40}, v)
+}
+
+func TestSealUsesNewDataKeys(t *testing.T) {
+	keys, _ := NewLocalKeyProvider(key)
+	envelope := New(keys)
+
+	a, _ := envelope.Seal(ctx, testValue{Name: "John"})
+	b, _ := envelope.Seal(ctx, testValue{Name: "John"})
+
+	assert.NotEqual(t, a.WrappedKey, b.WrappedKey)
+	assert.NotEqual(t, a.Ciphertext, b.Ciphertext)
+}
+
+func TestSealWhenKeyProviderErrors(t *testing.T) {
+	_, err := New(errorKeyProvider{}).Seal(ctx, testValue{})
+	assert.Equal(t, errExpected, err)
+}
+
+func TestOpenAfterRotation(t *testing.T) {
+	keys, _ := NewLocalKeyProvider(key)
+	sealed, _ := New(keys).Seal(ctx, testValue{Name: "John"})
+
+	rotatedKeys, _ := NewLocalKeyProvider(rotatedKey, key)
+	envelope := New(rotatedKeys)
+
+	var v testValue
+	assert.Nil(t, envelope.Open(ctx, sealed, &v))
+	assert.Equal(t, testValue{Name: "John"}, v)
+
+	resealed, _ := envelope.Seal(ctx, v)
+	assert.Equal(t, localKeyID(rotatedKey), resealed.KeyID)
+}
+
+func TestOpenWhenKeyUnknown(t *testing.T) {
+	keys, _ := NewLocalKeyProvider(unknownKey)
+	sealed, _ := New(keys).Seal(ctx, testValue{Name: "John"})
+
+	otherKeys, _ := NewLocalKeyProvider(key)
+
+	var v testValue
+	assert.Equal(t, ErrUnknownKey, New(otherKeys).Open(ctx, sealed, &v))
+}
+
+func TestOpenWhenTampered(t *testing.T) {
+	keys, _ := NewLocalKeyProvider(key)
+	envelope := New(keys)
+
+	testcases := map[string]func(*Sealed){
+		"ciphertext":        func(s *Sealed) { s.Ciphertext[0] ^= 1 },
+		"nonce":             func(s *Sealed) { s.Nonce[0] ^= 1 },
+		"short nonce":       func(s *Sealed) { s.Nonce = s.Nonce[:1] },
+		"wrapped key":       func(s *Sealed) { s.WrappedKey[0] ^= 1 },
+		"short wrapped key": func(s *Sealed) { s.WrappedKey = s.WrappedKey[:1] },
+	}
+
+	for name, tamper := range testcases {
+		t.Run(name, func(t *testing.T) {
+			sealed, _ := envelope.Seal(ctx, testValue{Name: "John"})
+			tamper(sealed)
+
+			var v testValue
+			assert.NotNil(t, envelope.Open(ctx, sealed, &v))
+			assert.Equal(t, testValue{}, v)
+		})
+	}
+}
+
+func TestNewLocalKeyProviderWhenInvalid(t *testing.T) {
+	_, err := NewLocalKeyProvider()
+	assert.NotNil(t, err)
+
+	_, err = NewLocalKeyProvider(key, []byte("short"))
+	assert.NotNil(t, err)
+}
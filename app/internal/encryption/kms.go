@@ -0,0 +1,70 @@
+package encryption
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+type kmsClient interface {
+	GenerateDataKey(ctx context.Context, params *kms.GenerateDataKeyInput, optFns ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error)
+	Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+// A KMSKeyProvider wraps data keys with a KMS key. KMS keeps the material of
+// rotated keys, so data keys wrapped before a rotation can still be unwrapped.
+// Data keys wrapped by a LocalKeyProvider, from before KMS was used, are
+// unwrapped by previous.
+type KMSKeyProvider struct {
+	svc      kmsClient
+	keyID    string
+	previous KeyProvider
+}
+
+// NewKMSKeyProvider creates a provider that wraps data keys with the KMS key
+// keyID, which can be a key ID, key ARN or alias. previous may be nil when
+// there are no data keys wrapped by local keys.
+func NewKMSKeyProvider(cfg aws.Config, keyID string, previous KeyProvider) *KMSKeyProvider {
+	return &KMSKeyProvider{svc: kms.NewFromConfig(cfg), keyID: keyID, previous: previous}
+}
+
+func (p *KMSKeyProvider) GenerateDataKey(ctx context.Context) (DataKey, error) {
+	resp, err := p.svc.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{
+		KeyId:   aws.String(p.keyID),
+		KeySpec: types.DataKeySpecAes256,
+	})
+	if err != nil {
+		return DataKey{}, err
+	}
+
+	return DataKey{
+		KeyID:     aws.ToString(resp.KeyId),
+		Plaintext: resp.Plaintext,
+		Wrapped:   resp.CiphertextBlob,
+	}, nil
+}
+
+func (p *KMSKeyProvider) Decrypt(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	if strings.HasPrefix(keyID, localKeyIDPrefix) {
+		if p.previous == nil {
+			return nil, ErrUnknownKey
+		}
+
+		return p.previous.Decrypt(ctx, keyID, wrapped)
+	}
+
+	// The key is not given, as KMS reads it from the wrapped key. The keyID
+	// stored is the ARN in the region the data key was made, whereas a replica
+	// of a multi-region key has the same key ID but a different ARN.
+	resp, err := p.svc.Decrypt(ctx, &kms.DecryptInput{
+		CiphertextBlob: wrapped,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Plaintext, nil
+}
@@ -0,0 +1,104 @@
+package encryption
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeKMS struct {
+	err      error
+	generate *kms.GenerateDataKeyInput
+	decrypt  *kms.DecryptInput
+}
+
+func (f *fakeKMS) GenerateDataKey(ctx context.Context, params *kms.GenerateDataKeyInput, optFns ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error) {
+	f.generate = params
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return &kms.GenerateDataKeyOutput{
+		KeyId:          aws.String("arn:key"),
+		Plaintext:      key,
+		CiphertextBlob: []byte("wrapped"),
+	}, nil
+}
+
+func (f *fakeKMS) Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error) {
+	f.decrypt = params
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return &kms.DecryptOutput{Plaintext: key}, nil
+}
+
+func TestKMSKeyProviderGenerateDataKey(t *testing.T) {
+	svc := &fakeKMS{}
+	provider := &KMSKeyProvider{svc: svc, keyID: "alias/lpa"}
+
+	dataKey, err := provider.GenerateDataKey(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, DataKey{KeyID: "arn:key", Plaintext: key, Wrapped: []byte("wrapped")}, dataKey)
+	assert.Equal(t, &kms.GenerateDataKeyInput{KeyId: aws.String("alias/lpa"), KeySpec: types.DataKeySpecAes256}, svc.generate)
+}
+
+func TestKMSKeyProviderGenerateDataKeyWhenKMSErrors(t *testing.T) {
+	provider := &KMSKeyProvider{svc: &fakeKMS{err: errExpected}, keyID: "alias/lpa"}
+
+	_, err := provider.GenerateDataKey(ctx)
+	assert.Equal(t, errExpected, err)
+}
+
+func TestKMSKeyProviderDecrypt(t *testing.T) {
+	svc := &fakeKMS{}
+	provider := &KMSKeyProvider{svc: svc, keyID: "alias/lpa"}
+
+	plaintext, err := provider.Decrypt(ctx, "arn:key", []byte("wrapped"))
+	assert.Nil(t, err)
+	assert.Equal(t, key, plaintext)
+	assert.Equal(t, &kms.DecryptInput{CiphertextBlob: []byte("wrapped")}, svc.decrypt)
+}
+
+func TestKMSKeyProviderDecryptWhenKMSErrors(t *testing.T) {
+	provider := &KMSKeyProvider{svc: &fakeKMS{err: errExpected}, keyID: "alias/lpa"}
+
+	_, err := provider.Decrypt(ctx, "arn:key", []byte("wrapped"))
+	assert.Equal(t, errExpected, err)
+}
+
+func TestKMSKeyProviderOpensValuesSealedWithLocalKeys(t *testing.T) {
+	local, _ := NewLocalKeyProvider(key)
+
+	sealed, err := New(local).Seal(ctx, testValue{Name: "a"})
+	assert.Nil(t, err)
+
+	svc := &fakeKMS{}
+	var v testValue
+	assert.Nil(t, New(&KMSKeyProvider{svc: svc, keyID: "alias/lpa", previous: local}).Open(ctx, sealed, &v))
+	assert.Equal(t, testValue{Name: "a"}, v)
+	assert.Nil(t, svc.decrypt)
+}
+
+func TestKMSKeyProviderDecryptLocalKeyWithoutPrevious(t *testing.T) {
+	provider := &KMSKeyProvider{svc: &fakeKMS{}, keyID: "alias/lpa"}
+
+	_, err := provider.Decrypt(ctx, localKeyIDPrefix+"abc", []byte("wrapped"))
+	assert.Equal(t, ErrUnknownKey, err)
+}
+
+func TestKMSKeyProviderSealOpen(t *testing.T) {
+	envelope := New(&KMSKeyProvider{svc: &fakeKMS{}, keyID: "alias/lpa"})
+
+	sealed, err := envelope.Seal(ctx, testValue{Name: "a", Age: 3})
+	assert.Nil(t, err)
+
+	var v testValue
+	assert.Nil(t, envelope.Open(ctx, sealed, &v))
+	assert.Equal(t, testValue{Name: "a", Age: 3}, v)
+}
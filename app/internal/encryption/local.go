@@ -0,0 +1,94 @@
+package encryption
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// A LocalKeyProvider wraps data keys with AES-256 keys that it holds itself,
+// for when a key management service is not available.
+type LocalKeyProvider struct {
+	currentID string
+	keys      map[string]cipher.AEAD
+}
+
+// NewLocalKeyProvider creates a provider that wraps data keys with the first of
+// keys, and can unwrap data keys wrapped by any of keys. To rotate, add a new
+// key to the front and keep the previous keys until nothing uses them.
+func NewLocalKeyProvider(keys ...[]byte) (*LocalKeyProvider, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("encryption: at least one key is required")
+	}
+
+	p := &LocalKeyProvider{keys: map[string]cipher.AEAD{}}
+
+	for i, key := range keys {
+		if len(key) != dataKeyLength {
+			return nil, fmt.Errorf("encryption: key %d must be %d bytes", i, dataKeyLength)
+		}
+
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+
+		id := localKeyID(key)
+		if i == 0 {
+			p.currentID = id
+		}
+		p.keys[id] = aead
+	}
+
+	return p, nil
+}
+
+func (p *LocalKeyProvider) GenerateDataKey(ctx context.Context) (DataKey, error) {
+	plaintext, err := randomBytes(dataKeyLength)
+	if err != nil {
+		return DataKey{}, err
+	}
+
+	aead := p.keys[p.currentID]
+
+	nonce, err := randomBytes(aead.NonceSize())
+	if err != nil {
+		return DataKey{}, err
+	}
+
+	return DataKey{
+		KeyID:     p.currentID,
+		Plaintext: plaintext,
+		Wrapped:   aead.Seal(nonce, nonce, plaintext, []byte(p.currentID)),
+	}, nil
+}
+
+func (p *LocalKeyProvider) Decrypt(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	aead, ok := p.keys[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	if len(wrapped) < aead.NonceSize() {
+		return nil, errors.New("encryption: invalid wrapped key")
+	}
+
+	key, err := aead.Open(nil, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], []byte(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("encryption: could not unwrap key: %w", err)
+	}
+
+	return key, nil
+}
+
+const localKeyIDPrefix = "local:"
+
+// localKeyID identifies a key without revealing it, so that the ID does not
+// change when keys are reordered.
+func localKeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return localKeyIDPrefix + hex.EncodeToString(sum[:8])
+}
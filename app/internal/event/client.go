@@ -0,0 +1,64 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+)
+
+type eventbridgeClient interface {
+	PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error)
+}
+
+// A Client publishes events to an EventBridge event bus.
+type Client struct {
+	svc     eventbridgeClient
+	busName string
+}
+
+// NewClient creates a client that puts events on the named bus, using the
+// region, credentials and endpoint from cfg.
+func NewClient(cfg aws.Config, busName string) (*Client, error) {
+	if busName == "" {
+		return nil, errors.New("event: busName is required")
+	}
+
+	return &Client{svc: eventbridge.NewFromConfig(cfg), busName: busName}, nil
+}
+
+// Publish puts e on the event bus.
+func (c *Client) Publish(ctx context.Context, e Event) error {
+	detail, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.svc.PutEvents(ctx, &eventbridge.PutEventsInput{
+		Entries: []types.PutEventsRequestEntry{{
+			EventBusName: aws.String(c.busName),
+			Source:       aws.String(Source),
+			DetailType:   aws.String(e.DetailType()),
+			Detail:       aws.String(string(detail)),
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("event: error publishing %s: %w", e.DetailType(), err)
+	}
+
+	if resp.FailedEntryCount > 0 {
+		for _, entry := range resp.Entries {
+			if entry.ErrorCode != nil {
+				return fmt.Errorf("event: error publishing %s: %s %s", e.DetailType(), aws.ToString(entry.ErrorCode), aws.ToString(entry.ErrorMessage))
+			}
+		}
+
+		return fmt.Errorf("event: error publishing %s", e.DetailType())
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	ctx           = context.Background()
+	expectedError = errors.New("err")
+)
+
+type fakeEventBridge struct {
+	output *eventbridge.PutEventsOutput
+	err    error
+	input  *eventbridge.PutEventsInput
+}
+
+func (f *fakeEventBridge) PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+	f.input = params
+	return f.output, f.err
+}
+
+func TestNewClient(t *testing.T) {
+	client, err := NewClient(aws.Config{Region: "eu-west-1"}, "my-bus")
+	assert.Nil(t, err)
+	assert.NotNil(t, client.svc)
+	assert.Equal(t, "my-bus", client.busName)
+}
+
+func TestNewClientWithoutBusName(t *testing.T) {
+	_, err := NewClient(aws.Config{Region: "eu-west-1"}, "")
+	assert.NotNil(t, err)
+}
+
+func TestPublish(t *testing.T) {
+	svc := &fakeEventBridge{output: &eventbridge.PutEventsOutput{
+		Entries: []types.PutEventsResultEntry{{EventId: aws.String("abc")}},
+	}}
+	client := &Client{svc: svc, busName: "my-bus"}
+
+	err := client.Publish(ctx, LpaPaid{LpaID: "lpa-id", PaymentID: "payment-id", PaymentReference: "ref", Amount: 8200})
+	assert.Nil(t, err)
+	assert.Equal(t, &eventbridge.PutEventsInput{
+		Entries: []types.PutEventsRequestEntry{{
+			EventBusName: aws.String("my-bus"),
+			Source:       aws.String("opg.poas.makeregister"),
+			DetailType:   aws.String("lpa-paid"),
+			Detail:       aws.String(`{"lpaId":"lpa-id","paymentId":"payment-id","paymentReference":"ref","amount":8200}`),
+		}},
+	}, svc.input)
+}
+
+func TestPublishWhenErrors(t *testing.T) {
+	testCases := map[string]*fakeEventBridge{
+		"request": {
+			err: expectedError,
+		},
+		"failed entry": {
+			output: &eventbridge.PutEventsOutput{
+				FailedEntryCount: 1,
+				Entries:          []types.PutEventsResultEntry{{ErrorCode: aws.String("InternalFailure"), ErrorMessage: aws.String("oops")}},
+			},
+		},
+		"failed entry without code": {
+			output: &eventbridge.PutEventsOutput{FailedEntryCount: 1},
+		},
+	}
+
+	for name, svc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client := &Client{svc: svc, busName: "my-bus"}
+
+			err := client.Publish(ctx, LpaSigned{LpaID: "lpa-id"})
+			assert.NotNil(t, err)
+		})
+	}
+}
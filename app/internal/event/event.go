@@ -0,0 +1,75 @@
+// Package event describes the milestones in an LPA's life that other OPG
+// systems are told about, and the ways they are published.
+package event
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Source identifies this service as the source of published events.
+const Source = "opg.poas.makeregister"
+
+// An Event is something that has happened to an LPA. It is published as JSON,
+// with DetailType naming the kind of event.
+type Event interface {
+	DetailType() string
+}
+
+// LpaPaid is published when the fee for an LPA has been paid.
+type LpaPaid struct {
+	LpaID            string `json:"lpaId"`
+	PaymentID        string `json:"paymentId"`
+	PaymentReference string `json:"paymentReference"`
+	Amount           int    `json:"amount"`
+}
+
+func (LpaPaid) DetailType() string { return "lpa-paid" }
+
+// LpaSigned is published when the donor has signed their LPA.
+type LpaSigned struct {
+	LpaID string `json:"lpaId"`
+}
+
+func (LpaSigned) DetailType() string { return "lpa-signed" }
+
+// LpaWitnessed is published when the certificate provider has witnessed the
+// donor signing, by giving the witness code sent to them.
+type LpaWitnessed struct {
+	LpaID string `json:"lpaId"`
+}
+
+func (LpaWitnessed) DetailType() string { return "lpa-witnessed" }
+
+// LpaSubmitted is published when the LPA has been submitted for registration.
+type LpaSubmitted struct {
+	LpaID       string    `json:"lpaId"`
+	SubmittedAt time.Time `json:"submittedAt"`
+}
+
+func (LpaSubmitted) DetailType() string { return "lpa-submitted" }
+
+// CertificateProvided is published when the certificate provider has provided
+// their certificate.
+type CertificateProvided struct {
+	LpaID string `json:"lpaId"`
+}
+
+func (CertificateProvided) DetailType() string { return "certificate-provided" }
+
+// Raw is an event that has already been marshalled, such as one read back from
+// an outbox, so that it can be published again as it was.
+type Raw struct {
+	Type   string
+	Detail json.RawMessage
+}
+
+func (r Raw) DetailType() string { return r.Type }
+
+func (r Raw) MarshalJSON() ([]byte, error) {
+	if len(r.Detail) == 0 {
+		return []byte("null"), nil
+	}
+
+	return r.Detail, nil
+}
@@ -0,0 +1,21 @@
+package event
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRaw(t *testing.T) {
+	data, _ := json.Marshal(LpaSigned{LpaID: "lpa-id"})
+	raw := Raw{Type: "lpa-signed", Detail: data}
+
+	marshalled, err := json.Marshal(raw)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"lpaId":"lpa-id"}`, string(marshalled))
+	assert.Equal(t, "lpa-signed", raw.DetailType())
+
+	marshalled, _ = json.Marshal(Raw{Type: "empty"})
+	assert.Equal(t, "null", string(marshalled))
+}
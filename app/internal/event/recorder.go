@@ -0,0 +1,51 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+type Logger interface {
+	Print(v ...interface{})
+}
+
+// A Recorder keeps the events published to it, and logs them, for when there
+// is no event bus to publish to.
+type Recorder struct {
+	mu     sync.Mutex
+	logger Logger
+	events []Event
+}
+
+// NewRecorder creates a Recorder that logs each event to logger, if it is not
+// nil.
+func NewRecorder(logger Logger) *Recorder {
+	return &Recorder{logger: logger}
+}
+
+func (r *Recorder) Publish(ctx context.Context, e Event) error {
+	detail, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.events = append(r.events, e)
+	r.mu.Unlock()
+
+	if r.logger != nil {
+		r.logger.Print(fmt.Sprintf("recorded event %s: %s", e.DetailType(), detail))
+	}
+
+	return nil
+}
+
+// Events returns the events published so far, oldest first.
+func (r *Recorder) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Event(nil), r.events...)
+}
@@ -0,0 +1,31 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockLogger struct {
+	mock.Mock
+}
+
+func (m *mockLogger) Print(v ...interface{}) {
+	m.Called(v...)
+}
+
+func TestRecorder(t *testing.T) {
+	logger := &mockLogger{}
+	logger.
+		On("Print", `recorded event lpa-signed: {"lpaId":"lpa-id"}`)
+	logger.
+		On("Print", `recorded event lpa-witnessed: {"lpaId":"lpa-id"}`)
+
+	recorder := NewRecorder(logger)
+	assert.Nil(t, recorder.Publish(ctx, LpaSigned{LpaID: "lpa-id"}))
+	assert.Nil(t, recorder.Publish(ctx, LpaWitnessed{LpaID: "lpa-id"}))
+
+	assert.Equal(t, []Event{LpaSigned{LpaID: "lpa-id"}, LpaWitnessed{LpaID: "lpa-id"}}, recorder.Events())
+	mock.AssertExpectationsForObjects(t, logger)
+}
@@ -0,0 +1,118 @@
+package lpastore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+)
+
+const (
+	StatusReceived   = "received"
+	StatusRegistered = "registered"
+)
+
+type Doer interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// A Client submits LPAs to the LPA store to be registered.
+type Client struct {
+	baseURL     string
+	doer        Doer
+	maxAttempts int
+	backoff     time.Duration
+}
+
+func New(baseURL string, httpClient Doer) *Client {
+	return &Client{
+		baseURL:     baseURL,
+		doer:        httpClient,
+		maxAttempts: 3,
+		backoff:     200 * time.Millisecond,
+	}
+}
+
+type response struct {
+	Reference string `json:"reference"`
+	Status    string `json:"status"`
+}
+
+type errorResponse struct {
+	Message string `json:"message"`
+}
+
+// Register submits lpa to be registered, returning a ValidationError without
+// sending it if it does not follow the schema. The LPA's ID is sent as the
+// idempotency key, so the request is retried when it may not have been
+// received, and an LPA submitted again gets the same reference.
+func (c *Client) Register(ctx context.Context, lpa *page.Lpa) (page.Registration, error) {
+	body, err := Encode(lpa)
+	if err != nil {
+		return page.Registration{}, err
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < c.maxAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return page.Registration{}, ctx.Err()
+			case <-time.After(c.backoff << (attempt - 1)):
+			}
+		}
+
+		resp, retry, err := c.register(ctx, lpa.ID, body)
+		if err == nil {
+			return resp, nil
+		}
+		if !retry {
+			return page.Registration{}, err
+		}
+
+		lastErr = err
+	}
+
+	return page.Registration{}, fmt.Errorf("lpastore: gave up registering lpa %s after %d attempts: %w", lpa.ID, c.maxAttempts, lastErr)
+}
+
+func (c *Client) register(ctx context.Context, idempotencyKey string, body []byte) (page.Registration, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/lpas", bytes.NewReader(body))
+	if err != nil {
+		return page.Registration{}, false, err
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Idempotency-Key", idempotencyKey)
+
+	resp, err := c.doer.Do(req)
+	if err != nil {
+		return page.Registration{}, ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		data, _ := io.ReadAll(resp.Body)
+
+		var v errorResponse
+		message := string(data)
+		if json.Unmarshal(data, &v) == nil && v.Message != "" {
+			message = v.Message
+		}
+
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+		return page.Registration{}, retry, fmt.Errorf("lpastore: error registering lpa: %d %s", resp.StatusCode, message)
+	}
+
+	var v response
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return page.Registration{}, false, err
+	}
+
+	return page.Registration{Reference: v.Reference, Status: v.Status}, false, nil
+}
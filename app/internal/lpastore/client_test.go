@@ -0,0 +1,155 @@
+package lpastore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	ctx           = context.Background()
+	expectedError = errors.New("err")
+)
+
+type mockDoer struct {
+	mock.Mock
+}
+
+func (m *mockDoer) Do(req *http.Request) (*http.Response, error) {
+	args := m.Called(req)
+	return args.Get(0).(*http.Response), args.Error(1)
+}
+
+func newTestClient(url string, doer Doer) *Client {
+	client := New(url, doer)
+	client.backoff = 0
+	return client
+}
+
+func TestRegister(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var v Lpa
+		json.NewDecoder(r.Body).Decode(&v)
+
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/lpas", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "lpa-id", r.Header.Get("Idempotency-Key"))
+		assert.Equal(t, "lpa-id", v.ID)
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"reference":"M-1234-5678-9012","status":"received"}`))
+	}))
+	defer server.Close()
+
+	registration, err := newTestClient(server.URL, server.Client()).Register(ctx, testLpa())
+
+	assert.Nil(t, err)
+	assert.Equal(t, page.Registration{Reference: "M-1234-5678-9012", Status: StatusReceived}, registration)
+}
+
+func TestRegisterRetries(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+
+		if len(keys) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Write([]byte(`{"reference":"M-1234-5678-9012","status":"received"}`))
+	}))
+	defer server.Close()
+
+	registration, err := newTestClient(server.URL, server.Client()).Register(ctx, testLpa())
+
+	assert.Nil(t, err)
+	assert.Equal(t, page.Registration{Reference: "M-1234-5678-9012", Status: StatusReceived}, registration)
+	assert.Equal(t, []string{"lpa-id", "lpa-id", "lpa-id"}, keys)
+}
+
+func TestRegisterWhenRequestErrors(t *testing.T) {
+	doer := &mockDoer{}
+	doer.
+		On("Do", mock.Anything).
+		Return(&http.Response{}, expectedError).
+		Times(3)
+
+	_, err := newTestClient("http://lpa-store", doer).Register(ctx, testLpa())
+
+	assert.ErrorIs(t, err, expectedError)
+	mock.AssertExpectationsForObjects(t, doer)
+}
+
+func TestRegisterWhenServiceUnavailable(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server.URL, server.Client()).Register(ctx, testLpa())
+
+	assert.NotNil(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestRegisterWhenRejected(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"donor is required"}`))
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server.URL, server.Client()).Register(ctx, testLpa())
+
+	assert.Equal(t, errors.New("lpastore: error registering lpa: 400 donor is required"), err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRegisterWhenLpaInvalid(t *testing.T) {
+	doer := &mockDoer{}
+
+	_, err := newTestClient("http://lpa-store", doer).Register(ctx, &page.Lpa{ID: "lpa-id"})
+
+	assert.IsType(t, ValidationError{}, err)
+	mock.AssertExpectationsForObjects(t, doer)
+}
+
+func TestRegisterWhenInvalidResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`not json`))
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server.URL, server.Client()).Register(ctx, testLpa())
+
+	assert.NotNil(t, err)
+}
+
+func TestRegisterWhenContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	doer := &mockDoer{}
+	doer.
+		On("Do", mock.Anything).
+		Run(func(mock.Arguments) { cancel() }).
+		Return(&http.Response{}, context.Canceled).
+		Once()
+
+	_, err := newTestClient("http://lpa-store", doer).Register(ctx, testLpa())
+
+	assert.Equal(t, context.Canceled, err)
+	mock.AssertExpectationsForObjects(t, doer)
+}
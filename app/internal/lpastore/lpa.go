@@ -0,0 +1,362 @@
+package lpastore
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/date"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/place"
+)
+
+// An Lpa is the canonical form of an LPA, described by the schema in
+// docs/openapi/lpa-store.json, used to send it to be registered. Times are only
+// included once the event they record has happened.
+type Lpa struct {
+	SchemaVersion                        string              `json:"schemaVersion"`
+	ID                                   string              `json:"id"`
+	Type                                 string              `json:"type"`
+	WhenTheLpaCanBeUsed                  string              `json:"whenTheLpaCanBeUsed,omitempty"`
+	Restrictions                         string              `json:"restrictions"`
+	Donor                                Donor               `json:"donor"`
+	Attorneys                            []Attorney          `json:"attorneys"`
+	HowAttorneysMakeDecisions            Decision            `json:"howAttorneysMakeDecisions"`
+	ReplacementAttorneys                 []Attorney          `json:"replacementAttorneys"`
+	HowReplacementAttorneysMakeDecisions Decision            `json:"howReplacementAttorneysMakeDecisions"`
+	HowReplacementAttorneysStepIn        Decision            `json:"howReplacementAttorneysStepIn"`
+	CertificateProvider                  CertificateProvider `json:"certificateProvider"`
+	PeopleToNotify                       []PersonToNotify    `json:"peopleToNotify"`
+	Payment                              Payment             `json:"payment"`
+}
+
+type Address struct {
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	Line3      string `json:"line3,omitempty"`
+	TownOrCity string `json:"townOrCity"`
+	Postcode   string `json:"postcode"`
+}
+
+type Donor struct {
+	FirstNames  string     `json:"firstNames"`
+	LastName    string     `json:"lastName"`
+	OtherNames  string     `json:"otherNames,omitempty"`
+	DateOfBirth string     `json:"dateOfBirth"`
+	Email       string     `json:"email"`
+	Address     Address    `json:"address"`
+	SignedAt    *time.Time `json:"signedAt,omitempty"`
+}
+
+type Attorney struct {
+	ID          string     `json:"id"`
+	FirstNames  string     `json:"firstNames"`
+	LastName    string     `json:"lastName"`
+	DateOfBirth string     `json:"dateOfBirth"`
+	Email       string     `json:"email,omitempty"`
+	Address     Address    `json:"address"`
+	SignedAt    *time.Time `json:"signedAt,omitempty"`
+}
+
+type CertificateProvider struct {
+	FirstNames              string     `json:"firstNames"`
+	LastName                string     `json:"lastName"`
+	Email                   string     `json:"email,omitempty"`
+	Mobile                  string     `json:"mobile,omitempty"`
+	Address                 *Address   `json:"address,omitempty"`
+	CarryOutBy              string     `json:"carryOutBy,omitempty"`
+	Relationship            string     `json:"relationship"`
+	RelationshipDescription string     `json:"relationshipDescription,omitempty"`
+	RelationshipLength      string     `json:"relationshipLength,omitempty"`
+	WitnessedDonorSigning   bool       `json:"witnessedDonorSigning"`
+	SignedAt                *time.Time `json:"signedAt,omitempty"`
+}
+
+type PersonToNotify struct {
+	ID         string  `json:"id"`
+	FirstNames string  `json:"firstNames"`
+	LastName   string  `json:"lastName"`
+	Email      string  `json:"email,omitempty"`
+	Address    Address `json:"address"`
+}
+
+// A Decision is a choice made by the donor, with any details they gave for it.
+type Decision struct {
+	How     string `json:"how,omitempty"`
+	Details string `json:"details,omitempty"`
+}
+
+type Payment struct {
+	Reference string   `json:"reference,omitempty"`
+	Amount    int      `json:"amount"`
+	FeeType   string   `json:"feeType,omitempty"`
+	Evidence  []string `json:"evidence,omitempty"`
+}
+
+// Encode gives the canonical JSON for lpa, or a ValidationError if lpa cannot
+// be represented by the schema.
+func Encode(lpa *page.Lpa) ([]byte, error) {
+	v := FromLpa(lpa)
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(v)
+}
+
+// Decode reads an LPA from canonical JSON. Fields not in the schema are
+// rejected, as is an LPA that does not follow it.
+func Decode(data []byte) (*page.Lpa, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var v Lpa
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+
+	return v.ToLpa(), nil
+}
+
+// FromLpa gives the canonical form of lpa.
+func FromLpa(lpa *page.Lpa) Lpa {
+	v := Lpa{
+		SchemaVersion:       SchemaVersion,
+		ID:                  lpa.ID,
+		Type:                lpaTypes.encode(lpa.Type),
+		WhenTheLpaCanBeUsed: lpa.WhenCanTheLpaBeUsed,
+		Restrictions:        lpa.Restrictions,
+		Donor: Donor{
+			FirstNames:  lpa.You.FirstNames,
+			LastName:    lpa.You.LastName,
+			OtherNames:  lpa.You.OtherNames,
+			DateOfBirth: fromDate(lpa.You.DateOfBirth),
+			Email:       lpa.You.Email,
+			Address:     fromAddress(lpa.You.Address),
+			SignedAt:    optionalTime(lpa.Submitted),
+		},
+		Attorneys: fromAttorneys(lpa.Attorneys, lpa.AttorneyProvidedDetails),
+		HowAttorneysMakeDecisions: Decision{
+			How:     decisionTypes.encode(lpa.HowAttorneysMakeDecisions),
+			Details: lpa.HowAttorneysMakeDecisionsDetails,
+		},
+		ReplacementAttorneys: fromAttorneys(lpa.ReplacementAttorneys, lpa.ReplacementAttorneyProvidedDetails),
+		HowReplacementAttorneysMakeDecisions: Decision{
+			How:     decisionTypes.encode(lpa.HowReplacementAttorneysMakeDecisions),
+			Details: lpa.HowReplacementAttorneysMakeDecisionsDetails,
+		},
+		HowReplacementAttorneysStepIn: Decision{
+			How:     stepInTypes.encode(lpa.HowShouldReplacementAttorneysStepIn),
+			Details: lpa.HowShouldReplacementAttorneysStepInDetails,
+		},
+		CertificateProvider: CertificateProvider{
+			FirstNames:              lpa.CertificateProvider.FirstNames,
+			LastName:                lpa.CertificateProvider.LastName,
+			Email:                   lpa.CertificateProvider.Email,
+			Mobile:                  lpa.CertificateProvider.Mobile,
+			Address:                 optionalAddress(lpa.CertificateProvider.Address),
+			CarryOutBy:              lpa.CertificateProvider.CarryOutBy,
+			Relationship:            lpa.CertificateProvider.Relationship,
+			RelationshipDescription: lpa.CertificateProvider.RelationshipDescription,
+			RelationshipLength:      lpa.CertificateProvider.RelationshipLength,
+			WitnessedDonorSigning:   lpa.CPWitnessCodeValidated,
+			SignedAt:                optionalTime(lpa.Certificate.Agreed),
+		},
+		PeopleToNotify: []PersonToNotify{},
+		Payment: Payment{
+			Reference: lpa.PaymentDetails.PaymentReference,
+			Amount:    lpa.PaymentDetails.Amount,
+			FeeType:   feeTypes.encode(lpa.FeeType),
+			Evidence:  lpa.EvidenceRequired,
+		},
+	}
+
+	for _, person := range lpa.PeopleToNotify {
+		v.PeopleToNotify = append(v.PeopleToNotify, PersonToNotify{
+			ID:         person.ID,
+			FirstNames: person.FirstNames,
+			LastName:   person.LastName,
+			Email:      person.Email,
+			Address:    fromAddress(person.Address),
+		})
+	}
+
+	return v
+}
+
+// ToLpa gives the page.Lpa that l is the canonical form of.
+func (l Lpa) ToLpa() *page.Lpa {
+	lpa := &page.Lpa{
+		ID:                  l.ID,
+		Type:                lpaTypes.decode(l.Type),
+		WhenCanTheLpaBeUsed: l.WhenTheLpaCanBeUsed,
+		Restrictions:        l.Restrictions,
+		You: actor.Person{
+			FirstNames:  l.Donor.FirstNames,
+			LastName:    l.Donor.LastName,
+			OtherNames:  l.Donor.OtherNames,
+			DateOfBirth: toDate(l.Donor.DateOfBirth),
+			Email:       l.Donor.Email,
+			Address:     toAddress(l.Donor.Address),
+		},
+		HowAttorneysMakeDecisions:                   decisionTypes.decode(l.HowAttorneysMakeDecisions.How),
+		HowAttorneysMakeDecisionsDetails:            l.HowAttorneysMakeDecisions.Details,
+		HowReplacementAttorneysMakeDecisions:        decisionTypes.decode(l.HowReplacementAttorneysMakeDecisions.How),
+		HowReplacementAttorneysMakeDecisionsDetails: l.HowReplacementAttorneysMakeDecisions.Details,
+		HowShouldReplacementAttorneysStepIn:         stepInTypes.decode(l.HowReplacementAttorneysStepIn.How),
+		HowShouldReplacementAttorneysStepInDetails:  l.HowReplacementAttorneysStepIn.Details,
+		CertificateProvider: actor.CertificateProvider{
+			FirstNames:              l.CertificateProvider.FirstNames,
+			LastName:                l.CertificateProvider.LastName,
+			Email:                   l.CertificateProvider.Email,
+			Mobile:                  l.CertificateProvider.Mobile,
+			CarryOutBy:              l.CertificateProvider.CarryOutBy,
+			Relationship:            l.CertificateProvider.Relationship,
+			RelationshipDescription: l.CertificateProvider.RelationshipDescription,
+			RelationshipLength:      l.CertificateProvider.RelationshipLength,
+		},
+		CPWitnessCodeValidated: l.CertificateProvider.WitnessedDonorSigning,
+		PaymentDetails: page.PaymentDetails{
+			PaymentReference: l.Payment.Reference,
+			Amount:           l.Payment.Amount,
+		},
+		FeeType:          feeTypes.decode(l.Payment.FeeType),
+		EvidenceRequired: l.Payment.Evidence,
+	}
+
+	if l.Donor.SignedAt != nil {
+		lpa.Submitted = *l.Donor.SignedAt
+	}
+
+	if l.CertificateProvider.Address != nil {
+		lpa.CertificateProvider.Address = toAddress(*l.CertificateProvider.Address)
+	}
+
+	if l.CertificateProvider.SignedAt != nil {
+		lpa.Certificate.Agreed = *l.CertificateProvider.SignedAt
+	}
+
+	lpa.Attorneys, lpa.AttorneyProvidedDetails = toAttorneys(l.Attorneys)
+	lpa.ReplacementAttorneys, lpa.ReplacementAttorneyProvidedDetails = toAttorneys(l.ReplacementAttorneys)
+
+	for _, person := range l.PeopleToNotify {
+		lpa.PeopleToNotify = append(lpa.PeopleToNotify, actor.PersonToNotify{
+			ID:         person.ID,
+			FirstNames: person.FirstNames,
+			LastName:   person.LastName,
+			Email:      person.Email,
+			Address:    toAddress(person.Address),
+		})
+	}
+
+	return lpa
+}
+
+func fromAttorneys(attorneys actor.Attorneys, provided map[string]page.AttorneyProvidedDetails) []Attorney {
+	vs := []Attorney{}
+	for _, attorney := range attorneys {
+		vs = append(vs, Attorney{
+			ID:          attorney.ID,
+			FirstNames:  attorney.FirstNames,
+			LastName:    attorney.LastName,
+			DateOfBirth: fromDate(attorney.DateOfBirth),
+			Email:       attorney.Email,
+			Address:     fromAddress(attorney.Address),
+			SignedAt:    optionalTime(provided[attorney.ID].Confirmed),
+		})
+	}
+
+	return vs
+}
+
+func toAttorneys(vs []Attorney) (actor.Attorneys, map[string]page.AttorneyProvidedDetails) {
+	var (
+		attorneys actor.Attorneys
+		provided  map[string]page.AttorneyProvidedDetails
+	)
+
+	for _, v := range vs {
+		attorneys = append(attorneys, actor.Attorney{
+			ID:          v.ID,
+			FirstNames:  v.FirstNames,
+			LastName:    v.LastName,
+			DateOfBirth: toDate(v.DateOfBirth),
+			Email:       v.Email,
+			Address:     toAddress(v.Address),
+		})
+
+		if v.SignedAt != nil {
+			if provided == nil {
+				provided = map[string]page.AttorneyProvidedDetails{}
+			}
+			provided[v.ID] = page.AttorneyProvidedDetails{Confirmed: *v.SignedAt}
+		}
+	}
+
+	return attorneys, provided
+}
+
+func fromAddress(address place.Address) Address {
+	return Address{
+		Line1:      address.Line1,
+		Line2:      address.Line2,
+		Line3:      address.Line3,
+		TownOrCity: address.TownOrCity,
+		Postcode:   address.Postcode,
+	}
+}
+
+func optionalAddress(address place.Address) *Address {
+	if address == (place.Address{}) {
+		return nil
+	}
+
+	v := fromAddress(address)
+	return &v
+}
+
+func toAddress(address Address) place.Address {
+	return place.Address{
+		Line1:      address.Line1,
+		Line2:      address.Line2,
+		Line3:      address.Line3,
+		TownOrCity: address.TownOrCity,
+		Postcode:   address.Postcode,
+	}
+}
+
+// fromDate gives d as YYYY-MM-DD, however it was entered.
+func fromDate(d date.Date) string {
+	if d.IsZero() {
+		return ""
+	}
+
+	return d.Format("2006-01-02")
+}
+
+func toDate(s string) date.Date {
+	if s == "" {
+		return date.Date{}
+	}
+
+	parts := strings.SplitN(s, "-", 3)
+	if len(parts) != 3 {
+		return date.Date{}
+	}
+
+	return date.New(parts[0], parts[1], parts[2])
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
@@ -0,0 +1,260 @@
+package lpastore
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/date"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/place"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testSignedAt = time.Date(2023, time.January, 2, 3, 4, 5, 0, time.UTC)
+	testAddress  = place.Address{Line1: "1 Road", TownOrCity: "Town", Postcode: "A1 1AA"}
+)
+
+func testLpa() *page.Lpa {
+	return &page.Lpa{
+		ID:   "lpa-id",
+		Type: page.LpaTypePropertyFinance,
+		You: actor.Person{
+			FirstNames:  "Don",
+			LastName:    "Or",
+			DateOfBirth: date.New("1950", "1", "2"),
+			Email:       "donor@example.com",
+			Address:     testAddress,
+		},
+		Attorneys: actor.Attorneys{
+			{ID: "a1", FirstNames: "At", LastName: "Torney", DateOfBirth: date.New("1980", "3", "4"), Address: testAddress},
+			{ID: "a2", FirstNames: "Ot", LastName: "Her", DateOfBirth: date.New("1981", "3", "4"), Address: testAddress},
+		},
+		AttorneyProvidedDetails:          map[string]page.AttorneyProvidedDetails{"a1": {Confirmed: testSignedAt}},
+		HowAttorneysMakeDecisions:        page.JointlyForSomeSeverallyForOthers,
+		HowAttorneysMakeDecisionsDetails: "some details",
+		CertificateProvider: actor.CertificateProvider{
+			FirstNames:   "Cert",
+			LastName:     "Provider",
+			Address:      testAddress,
+			CarryOutBy:   "email",
+			Relationship: "friend",
+		},
+		PeopleToNotify: actor.PeopleToNotify{
+			{ID: "p1", FirstNames: "Per", LastName: "Son", Address: testAddress},
+		},
+		WhenCanTheLpaBeUsed:    page.UsedWhenRegistered,
+		Restrictions:           "none",
+		PaymentDetails:         page.PaymentDetails{PaymentReference: "ref", Amount: 8200},
+		FeeType:                page.FeeTypeFull,
+		Submitted:              testSignedAt,
+		CPWitnessCodeValidated: true,
+	}
+}
+
+const testLpaJSON = `{
+  "schemaVersion": "1",
+  "id": "lpa-id",
+  "type": "property-and-affairs",
+  "whenTheLpaCanBeUsed": "when-registered",
+  "restrictions": "none",
+  "donor": {
+    "firstNames": "Don",
+    "lastName": "Or",
+    "dateOfBirth": "1950-01-02",
+    "email": "donor@example.com",
+    "address": {"line1": "1 Road", "townOrCity": "Town", "postcode": "A1 1AA"},
+    "signedAt": "2023-01-02T03:04:05Z"
+  },
+  "attorneys": [{
+    "id": "a1",
+    "firstNames": "At",
+    "lastName": "Torney",
+    "dateOfBirth": "1980-03-04",
+    "address": {"line1": "1 Road", "townOrCity": "Town", "postcode": "A1 1AA"},
+    "signedAt": "2023-01-02T03:04:05Z"
+  }, {
+    "id": "a2",
+    "firstNames": "Ot",
+    "lastName": "Her",
+    "dateOfBirth": "1981-03-04",
+    "address": {"line1": "1 Road", "townOrCity": "Town", "postcode": "A1 1AA"}
+  }],
+  "howAttorneysMakeDecisions": {"how": "jointly-for-some-severally-for-others", "details": "some details"},
+  "replacementAttorneys": [],
+  "howReplacementAttorneysMakeDecisions": {},
+  "howReplacementAttorneysStepIn": {},
+  "certificateProvider": {
+    "firstNames": "Cert",
+    "lastName": "Provider",
+    "address": {"line1": "1 Road", "townOrCity": "Town", "postcode": "A1 1AA"},
+    "carryOutBy": "email",
+    "relationship": "friend",
+    "witnessedDonorSigning": true
+  },
+  "peopleToNotify": [{
+    "id": "p1",
+    "firstNames": "Per",
+    "lastName": "Son",
+    "address": {"line1": "1 Road", "townOrCity": "Town", "postcode": "A1 1AA"}
+  }],
+  "payment": {"reference": "ref", "amount": 8200, "feeType": "full"}
+}`
+
+func TestEncode(t *testing.T) {
+	data, err := Encode(testLpa())
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, testLpaJSON, string(data))
+}
+
+func TestEncodeWhenCertificateProviderHasNoAddress(t *testing.T) {
+	lpa := testLpa()
+	lpa.CertificateProvider.Address = place.Address{}
+
+	data, err := Encode(lpa)
+	assert.Nil(t, err)
+
+	var v Lpa
+	json.Unmarshal(data, &v)
+	assert.Nil(t, v.CertificateProvider.Address)
+}
+
+func TestEncodeWhenInvalid(t *testing.T) {
+	_, err := Encode(&page.Lpa{ID: "lpa-id"})
+
+	assert.IsType(t, ValidationError{}, err)
+}
+
+func TestDecode(t *testing.T) {
+	lpa, err := Decode([]byte(testLpaJSON))
+	assert.Nil(t, err)
+
+	expected := testLpa()
+	expected.You.DateOfBirth = date.New("1950", "01", "02")
+	expected.Attorneys[0].DateOfBirth = date.New("1980", "03", "04")
+	expected.Attorneys[1].DateOfBirth = date.New("1981", "03", "04")
+
+	assert.Equal(t, expected, lpa)
+}
+
+func TestDecodeRoundTrip(t *testing.T) {
+	lpa, _ := Decode([]byte(testLpaJSON))
+	data, err := Encode(lpa)
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, testLpaJSON, string(data))
+}
+
+func TestDecodeWhenUnknownField(t *testing.T) {
+	var v map[string]interface{}
+	json.Unmarshal([]byte(testLpaJSON), &v)
+	v["somethingElse"] = "hey"
+	data, _ := json.Marshal(v)
+
+	_, err := Decode(data)
+
+	assert.NotNil(t, err)
+}
+
+func TestDecodeWhenInvalidJSON(t *testing.T) {
+	_, err := Decode([]byte(`not json`))
+
+	assert.NotNil(t, err)
+}
+
+func TestDecodeWhenInvalid(t *testing.T) {
+	var v map[string]interface{}
+	json.Unmarshal([]byte(testLpaJSON), &v)
+	v["type"] = "pfa"
+	data, _ := json.Marshal(v)
+
+	_, err := Decode(data)
+
+	assert.Equal(t, ValidationError{`type has unknown value "pfa"`}, err)
+}
+
+func TestValidate(t *testing.T) {
+	testCases := map[string]struct {
+		change   func(*Lpa)
+		expected ValidationError
+	}{
+		"schema version": {
+			change:   func(l *Lpa) { l.SchemaVersion = "0" },
+			expected: ValidationError{`schemaVersion must be "1"`},
+		},
+		"missing fields": {
+			change: func(l *Lpa) {
+				l.ID = ""
+				l.Donor.FirstNames = ""
+				l.CertificateProvider.Address = &Address{}
+			},
+			expected: ValidationError{
+				"id is required",
+				"donor.firstNames is required",
+				"certificateProvider.address.line1 is required",
+				"certificateProvider.address.townOrCity is required",
+				"certificateProvider.address.postcode is required",
+			},
+		},
+		"certificate provider address": {
+			change: func(l *Lpa) {
+				l.CertificateProvider.CarryOutBy = "paper"
+				l.CertificateProvider.Address = nil
+			},
+			expected: ValidationError{"certificateProvider.address is required"},
+		},
+		"date of birth": {
+			change:   func(l *Lpa) { l.Attorneys[0].DateOfBirth = "1980-13-01" },
+			expected: ValidationError{"attorneys[0].dateOfBirth must be a date as YYYY-MM-DD"},
+		},
+		"no attorneys": {
+			change: func(l *Lpa) {
+				l.Attorneys = nil
+				l.HowAttorneysMakeDecisions = Decision{}
+			},
+			expected: ValidationError{"attorneys must have at least one attorney"},
+		},
+		"decision required for attorneys": {
+			change:   func(l *Lpa) { l.HowAttorneysMakeDecisions = Decision{} },
+			expected: ValidationError{"howAttorneysMakeDecisions.how is required"},
+		},
+		"decision details": {
+			change:   func(l *Lpa) { l.HowAttorneysMakeDecisions.Details = "" },
+			expected: ValidationError{"howAttorneysMakeDecisions.details is required"},
+		},
+		"step in details": {
+			change:   func(l *Lpa) { l.HowReplacementAttorneysStepIn = Decision{How: "another-way"} },
+			expected: ValidationError{"howReplacementAttorneysStepIn.details is required"},
+		},
+		"unknown values": {
+			change: func(l *Lpa) {
+				l.WhenTheLpaCanBeUsed = "sometimes"
+				l.CertificateProvider.Relationship = "enemy"
+				l.Payment.FeeType = "full-fee"
+				l.Payment.Evidence = []string{"photo"}
+			},
+			expected: ValidationError{
+				`whenTheLpaCanBeUsed has unknown value "sometimes"`,
+				`certificateProvider.relationship has unknown value "enemy"`,
+				`payment.feeType has unknown value "full-fee"`,
+				`payment.evidence[0] has unknown value "photo"`,
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			v := FromLpa(testLpa())
+			tc.change(&v)
+
+			assert.Equal(t, tc.expected, v.Validate())
+		})
+	}
+}
+
+func TestValidateWhenValid(t *testing.T) {
+	assert.Nil(t, FromLpa(testLpa()).Validate())
+}
@@ -0,0 +1,214 @@
+package lpastore
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+)
+
+// SchemaVersion is the version of the schema in docs/openapi/lpa-store.json
+// that Lpa follows. It changes whenever a change to the schema would stop
+// existing readers from understanding an LPA.
+const SchemaVersion = "1"
+
+// An enum maps the values used in page.Lpa to those in the schema.
+type enum map[string]string
+
+func (e enum) encode(v string) string {
+	if s, ok := e[v]; ok {
+		return s
+	}
+
+	return v
+}
+
+func (e enum) decode(s string) string {
+	for v, es := range e {
+		if es == s {
+			return v
+		}
+	}
+
+	return s
+}
+
+func (e enum) valid(s string) bool {
+	for _, es := range e {
+		if es == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func values(vs ...string) enum {
+	e := enum{}
+	for _, v := range vs {
+		e[v] = v
+	}
+
+	return e
+}
+
+var (
+	lpaTypes = enum{
+		page.LpaTypePropertyFinance: "property-and-affairs",
+		page.LpaTypeHealthWelfare:   "personal-welfare",
+		page.LpaTypeCombined:        "combined",
+	}
+	decisionTypes = enum{
+		page.Jointly:                          "jointly",
+		page.JointlyAndSeverally:              "jointly-and-severally",
+		page.JointlyForSomeSeverallyForOthers: "jointly-for-some-severally-for-others",
+	}
+	stepInTypes = enum{
+		page.AllCanNoLongerAct: "all-can-no-longer-act",
+		page.OneCanNoLongerAct: "one-can-no-longer-act",
+		page.SomeOtherWay:      "another-way",
+	}
+	whenCanBeUsedTypes = values(page.UsedWhenRegistered, page.UsedWhenCapacityLost)
+	feeTypes           = enum{
+		page.FeeTypeFull:      "full",
+		page.FeeTypeHalf:      "half",
+		page.FeeTypeExemption: "exemption",
+		page.FeeTypeHardship:  "hardship",
+	}
+	evidenceTypes     = values(page.EvidenceOfBenefits, page.EvidenceOfHardship, page.EvidenceOfIncome)
+	carryOutByTypes   = values("email", "paper")
+	relationshipTypes = values("friend", "neighbour", "colleague", "health-professional", "legal-professional", "other")
+	relationshipTerms = values("gte-2-years", "lt-2-years")
+)
+
+// A ValidationError lists each way in which an Lpa does not follow the
+// schema.
+type ValidationError []string
+
+func (e ValidationError) Error() string {
+	return "lpastore: invalid lpa: " + strings.Join(e, "; ")
+}
+
+type validator struct {
+	errors ValidationError
+}
+
+func (v *validator) add(field, message string) {
+	v.errors = append(v.errors, field+" "+message)
+}
+
+func (v *validator) required(field, s string) {
+	if s == "" {
+		v.add(field, "is required")
+	}
+}
+
+func (v *validator) oneOf(field, s string, e enum) {
+	if s != "" && !e.valid(s) {
+		v.add(field, fmt.Sprintf("has unknown value %q", s))
+	}
+}
+
+func (v *validator) date(field, s string) {
+	v.required(field, s)
+	if s == "" {
+		return
+	}
+
+	if _, err := time.Parse("2006-01-02", s); err != nil {
+		v.add(field, "must be a date as YYYY-MM-DD")
+	}
+}
+
+func (v *validator) address(field string, a Address) {
+	v.required(field+".line1", a.Line1)
+	v.required(field+".townOrCity", a.TownOrCity)
+	v.required(field+".postcode", a.Postcode)
+}
+
+func (v *validator) decision(field string, d Decision, required bool) {
+	if required {
+		v.required(field+".how", d.How)
+	}
+	v.oneOf(field+".how", d.How, decisionTypes)
+
+	if d.How == decisionTypes.encode(page.JointlyForSomeSeverallyForOthers) {
+		v.required(field+".details", d.Details)
+	}
+}
+
+func (v *validator) attorneys(field string, attorneys []Attorney) {
+	for i, a := range attorneys {
+		f := fmt.Sprintf("%s[%d]", field, i)
+		v.required(f+".id", a.ID)
+		v.required(f+".firstNames", a.FirstNames)
+		v.required(f+".lastName", a.LastName)
+		v.date(f+".dateOfBirth", a.DateOfBirth)
+		v.address(f+".address", a.Address)
+	}
+}
+
+// Validate checks that l follows the schema, returning a ValidationError if it
+// does not.
+func (l Lpa) Validate() error {
+	v := &validator{}
+
+	if l.SchemaVersion != SchemaVersion {
+		v.add("schemaVersion", fmt.Sprintf("must be %q", SchemaVersion))
+	}
+
+	v.required("id", l.ID)
+	v.required("type", l.Type)
+	v.oneOf("type", l.Type, lpaTypes)
+	v.oneOf("whenTheLpaCanBeUsed", l.WhenTheLpaCanBeUsed, whenCanBeUsedTypes)
+
+	v.required("donor.firstNames", l.Donor.FirstNames)
+	v.required("donor.lastName", l.Donor.LastName)
+	v.date("donor.dateOfBirth", l.Donor.DateOfBirth)
+	v.address("donor.address", l.Donor.Address)
+
+	if len(l.Attorneys) == 0 {
+		v.add("attorneys", "must have at least one attorney")
+	}
+	v.attorneys("attorneys", l.Attorneys)
+	v.decision("howAttorneysMakeDecisions", l.HowAttorneysMakeDecisions, len(l.Attorneys) > 1)
+
+	v.attorneys("replacementAttorneys", l.ReplacementAttorneys)
+	v.decision("howReplacementAttorneysMakeDecisions", l.HowReplacementAttorneysMakeDecisions, false)
+	v.oneOf("howReplacementAttorneysStepIn.how", l.HowReplacementAttorneysStepIn.How, stepInTypes)
+	if l.HowReplacementAttorneysStepIn.How == stepInTypes.encode(page.SomeOtherWay) {
+		v.required("howReplacementAttorneysStepIn.details", l.HowReplacementAttorneysStepIn.Details)
+	}
+
+	v.required("certificateProvider.firstNames", l.CertificateProvider.FirstNames)
+	v.required("certificateProvider.lastName", l.CertificateProvider.LastName)
+	if l.CertificateProvider.Address != nil {
+		v.address("certificateProvider.address", *l.CertificateProvider.Address)
+	} else if l.CertificateProvider.CarryOutBy == "paper" {
+		v.add("certificateProvider.address", "is required")
+	}
+	v.oneOf("certificateProvider.carryOutBy", l.CertificateProvider.CarryOutBy, carryOutByTypes)
+	v.required("certificateProvider.relationship", l.CertificateProvider.Relationship)
+	v.oneOf("certificateProvider.relationship", l.CertificateProvider.Relationship, relationshipTypes)
+	v.oneOf("certificateProvider.relationshipLength", l.CertificateProvider.RelationshipLength, relationshipTerms)
+
+	for i, p := range l.PeopleToNotify {
+		f := fmt.Sprintf("peopleToNotify[%d]", i)
+		v.required(f+".id", p.ID)
+		v.required(f+".firstNames", p.FirstNames)
+		v.required(f+".lastName", p.LastName)
+		v.address(f+".address", p.Address)
+	}
+
+	v.oneOf("payment.feeType", l.Payment.FeeType, feeTypes)
+	for i, e := range l.Payment.Evidence {
+		v.oneOf(fmt.Sprintf("payment.evidence[%d]", i), e, evidenceTypes)
+	}
+
+	if len(v.errors) > 0 {
+		return v.errors
+	}
+
+	return nil
+}
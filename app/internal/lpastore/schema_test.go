@@ -0,0 +1,126 @@
+package lpastore
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type schemaProperty struct {
+	Const string
+	Enum  []string
+	Items *schemaProperty
+}
+
+type schemaDocument struct {
+	Info struct {
+		Version string
+	}
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]schemaProperty
+		}
+	}
+}
+
+func readSchemaDocument(t *testing.T) schemaDocument {
+	data, err := os.ReadFile("../../../docs/openapi/lpa-store.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc schemaDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	return doc
+}
+
+func jsonFields(v interface{}) []string {
+	var names []string
+	typ := reflect.TypeOf(v)
+	for i := 0; i < typ.NumField(); i++ {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func enumValues(e enum) []string {
+	var vs []string
+	for _, v := range e {
+		vs = append(vs, v)
+	}
+
+	sort.Strings(vs)
+	return vs
+}
+
+func TestSchemaDocumentVersion(t *testing.T) {
+	doc := readSchemaDocument(t)
+
+	assert.Equal(t, SchemaVersion, doc.Info.Version)
+	assert.Equal(t, SchemaVersion, doc.Components.Schemas["Lpa"].Properties["schemaVersion"].Const)
+}
+
+func TestSchemaDocumentProperties(t *testing.T) {
+	doc := readSchemaDocument(t)
+
+	for name, v := range map[string]interface{}{
+		"Lpa":                 Lpa{},
+		"Address":             Address{},
+		"Donor":               Donor{},
+		"Attorney":            Attorney{},
+		"CertificateProvider": CertificateProvider{},
+		"PersonToNotify":      PersonToNotify{},
+		"Decision":            Decision{},
+		"StepIn":              Decision{},
+		"Payment":             Payment{},
+		"Registration":        response{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var properties []string
+			for property := range doc.Components.Schemas[name].Properties {
+				properties = append(properties, property)
+			}
+			sort.Strings(properties)
+
+			assert.Equal(t, jsonFields(v), properties)
+		})
+	}
+}
+
+func TestSchemaDocumentEnums(t *testing.T) {
+	schemas := readSchemaDocument(t).Components.Schemas
+
+	for name, tc := range map[string]struct {
+		property schemaProperty
+		enum     enum
+	}{
+		"type":                {schemas["Lpa"].Properties["type"], lpaTypes},
+		"whenTheLpaCanBeUsed": {schemas["Lpa"].Properties["whenTheLpaCanBeUsed"], whenCanBeUsedTypes},
+		"decision":            {schemas["Decision"].Properties["how"], decisionTypes},
+		"stepIn":              {schemas["StepIn"].Properties["how"], stepInTypes},
+		"carryOutBy":          {schemas["CertificateProvider"].Properties["carryOutBy"], carryOutByTypes},
+		"relationship":        {schemas["CertificateProvider"].Properties["relationship"], relationshipTypes},
+		"relationshipLength":  {schemas["CertificateProvider"].Properties["relationshipLength"], relationshipTerms},
+		"feeType":             {schemas["Payment"].Properties["feeType"], feeTypes},
+		"evidence":            {*schemas["Payment"].Properties["evidence"].Items, evidenceTypes},
+		"status":              {schemas["Registration"].Properties["status"], values(StatusReceived, StatusRegistered)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			expected := append([]string(nil), tc.property.Enum...)
+			sort.Strings(expected)
+
+			assert.Equal(t, expected, enumValues(tc.enum))
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package notify
+
+import "time"
+
+const (
+	StatusCreated          = "created"
+	StatusSending          = "sending"
+	StatusPending          = "pending"
+	StatusDelivered        = "delivered"
+	StatusPermanentFailure = "permanent-failure"
+	StatusTemporaryFailure = "temporary-failure"
+	StatusTechnicalFailure = "technical-failure"
+
+	// Letters have their own statuses, with received meaning that the letter
+	// has been posted.
+	StatusAccepted         = "accepted"
+	StatusReceived         = "received"
+	StatusCancelled        = "cancelled"
+	StatusValidationFailed = "validation-failed"
+)
+
+// A DeliveryReceipt is sent by GOV.UK Notify to the callback URL when a
+// notification has been delivered, or has failed to be.
+type DeliveryReceipt struct {
+	ID               string     `json:"id"`
+	Reference        string     `json:"reference"`
+	To               string     `json:"to"`
+	Status           string     `json:"status"`
+	CreatedAt        time.Time  `json:"created_at"`
+	CompletedAt      *time.Time `json:"completed_at"`
+	SentAt           *time.Time `json:"sent_at"`
+	NotificationType string     `json:"notification_type"`
+}
@@ -5,28 +5,46 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/localize"
 )
 
 type Doer interface {
 	Do(*http.Request) (*http.Response, error)
 }
 
+var (
+	ErrRateLimited      = errors.New("notify: rate limited")
+	ErrInvalidRecipient = errors.New("notify: invalid recipient")
+	ErrServiceDown      = errors.New("notify: service unavailable")
+
+	// ErrTemplateNotConfigured is returned when sending a message with a
+	// template that has no ID in this environment.
+	ErrTemplateNotConfigured = errors.New("notify: template not configured")
+)
+
 type Client struct {
-	isProduction bool
-	baseURL      string
-	doer         Doer
-	issuer       string
-	secretKey    []byte
-	now          func() time.Time
+	templates     Templates
+	baseURL       string
+	doer          Doer
+	issuer        string
+	secretKey     []byte
+	now           func() time.Time
+	timeout       time.Duration
+	maxAttempts   int
+	backoff       time.Duration
+	maxRetryAfter time.Duration
 }
 
-func New(isProduction bool, baseURL, apiKey string, httpClient Doer) (*Client, error) {
+func New(baseURL, apiKey string, httpClient Doer, templates Templates) (*Client, error) {
 	keyParts := strings.Split(apiKey, "-")
 	if len(keyParts) != 11 {
 		return nil, errors.New("invalid apiKey format")
@@ -37,12 +55,16 @@ func New(isProduction bool, baseURL, apiKey string, httpClient Doer) (*Client, e
 	}
 
 	return &Client{
-		isProduction: isProduction,
-		baseURL:      baseURL,
-		doer:         httpClient,
-		issuer:       strings.Join(keyParts[1:6], "-"),
-		secretKey:    []byte(strings.Join(keyParts[6:11], "-")),
-		now:          time.Now,
+		templates:     templates,
+		baseURL:       baseURL,
+		doer:          httpClient,
+		issuer:        strings.Join(keyParts[1:6], "-"),
+		secretKey:     []byte(strings.Join(keyParts[6:11], "-")),
+		now:           time.Now,
+		timeout:       10 * time.Second,
+		maxAttempts:   3,
+		backoff:       200 * time.Millisecond,
+		maxRetryAfter: 5 * time.Second,
 	}, nil
 }
 
@@ -61,12 +83,45 @@ type Sms struct {
 	Reference       string            `json:"reference,omitempty"`
 }
 
+// A Letter is posted using a letter template. Address is the lines of the
+// recipient's address, of which Notify needs at least 3 and at most 7, with the
+// last being the postcode.
+type Letter struct {
+	TemplateID      string
+	Address         []string
+	Personalisation map[string]string
+	Reference       string
+}
+
+// A PrecompiledLetter is posted as it is, so PDF must already include the
+// recipient's address in the place Notify expects it. Postage is "first" or
+// "second", or left empty to use the service's default.
+type PrecompiledLetter struct {
+	Reference string `json:"reference"`
+	PDF       []byte `json:"content"`
+	Postage   string `json:"postage,omitempty"`
+}
+
+type letterBody struct {
+	TemplateID      string            `json:"template_id"`
+	Personalisation map[string]string `json:"personalisation"`
+	Reference       string            `json:"reference"`
+}
+
 type response struct {
 	ID         string     `json:"id"`
 	StatusCode int        `json:"status_code,omitempty"`
 	Errors     errorsList `json:"errors,omitempty"`
 }
 
+type notificationsResponse struct {
+	Notifications []struct {
+		ID        string `json:"id"`
+		Reference string `json:"reference"`
+	} `json:"notifications"`
+	Errors errorsList `json:"errors,omitempty"`
+}
+
 type errorsList []errorItem
 
 func (es errorsList) Error() string {
@@ -82,50 +137,133 @@ type errorItem struct {
 	Message string `json:"message"`
 }
 
+// An Error is returned when Notify does not accept a message. It wraps
+// ErrRateLimited, ErrInvalidRecipient or ErrServiceDown when the reason is one
+// of those, so that callers can use errors.Is to decide what to show.
+type Error struct {
+	StatusCode int
+	Errors     errorsList
+	kind       error
+}
+
+func (e *Error) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("error sending message: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+
+	return e.Errors.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.kind
+}
+
 type TemplateId int
 
 const (
 	SignatureCodeEmail TemplateId = iota
 	SignatureCodeSms
 	CertificateProviderInviteEmail
+	DraftLpaDeletionWarningEmail
+	PersonToNotifyEmail
+	PersonToNotifyLetter
+	AttorneyInviteEmail
 )
 
-func (c *Client) TemplateID(id TemplateId) string {
-	if c.isProduction {
-		switch id {
-		case SignatureCodeEmail:
-			return "95f7b0a2-1c3a-4ad9-818b-b358c549c88b"
-		case SignatureCodeSms:
-			return "a0997cbf-cfd9-4f01-acb2-f33b07074662"
-		case CertificateProviderInviteEmail:
-			return "d2fc97a7-a69a-48e0-b092-2c1d31ab7a5b"
-		}
-	} else {
-		switch id {
-		case SignatureCodeEmail:
-			return "7e8564a0-2635-4f61-9155-0166ddbe5607"
-		case SignatureCodeSms:
-			return "0aa5b61c-ef30-410a-8473-915df9d343a5"
-		case CertificateProviderInviteEmail:
-			return "f719dfa9-6dc5-4848-b330-07e91770abd1"
-		}
+// String gives the name of the template, which does not change between
+// environments.
+func (id TemplateId) String() string {
+	switch id {
+	case SignatureCodeEmail:
+		return "signature-code-email"
+	case SignatureCodeSms:
+		return "signature-code-sms"
+	case CertificateProviderInviteEmail:
+		return "certificate-provider-invite-email"
+	case DraftLpaDeletionWarningEmail:
+		return "draft-lpa-deletion-warning-email"
+	case PersonToNotifyEmail:
+		return "person-to-notify-email"
+	case PersonToNotifyLetter:
+		return "person-to-notify-letter"
+	case AttorneyInviteEmail:
+		return "attorney-invite-email"
 	}
 
 	return ""
 }
 
+// TemplateID gives the Notify ID of the template in lang. When the template
+// has no Welsh ID the English one is given, and when it has no ID at all the
+// empty string is given, which Notify will not accept.
+func (c *Client) TemplateID(id TemplateId, lang localize.Lang) string {
+	if templateID := c.templates[id][lang]; templateID != "" {
+		return templateID
+	}
+
+	return c.templates[id][localize.En]
+}
+
+// CheckTemplates asks Notify for each template that has an ID, so that a
+// template missing from Notify is found when the app starts rather than when a
+// message is sent.
+func (c *Client) CheckTemplates(ctx context.Context) error {
+	var missing []string
+
+	for _, id := range allTemplates {
+		for _, lang := range allLangs {
+			templateID := c.templates[id][lang]
+			if templateID == "" {
+				continue
+			}
+
+			req, err := c.request(ctx, http.MethodGet, "/v2/template/"+templateID, nil)
+			if err != nil {
+				return err
+			}
+
+			resp, _, err := c.doRequest(req)
+			if err != nil {
+				var notifyErr *Error
+				if errors.As(err, &notifyErr) && (notifyErr.StatusCode == http.StatusNotFound || notifyErr.StatusCode == http.StatusBadRequest) {
+					missing = append(missing, id.String()+"/"+lang.String())
+					continue
+				}
+
+				return err
+			}
+
+			if resp.ID != templateID {
+				missing = append(missing, id.String()+"/"+lang.String())
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("notify: templates not found %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// Email sends an email, returning the ID given to it by Notify. The email
+// must have a Reference, which Notify keeps with it, so that a message that
+// may have been accepted before a failure can be found rather than sent again.
 func (c *Client) Email(ctx context.Context, email Email) (string, error) {
-	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(email); err != nil {
-		return "", err
+	if email.Reference == "" {
+		return "", errors.New("notify: email requires a reference")
+	}
+
+	if email.TemplateID == "" {
+		return "", ErrTemplateNotConfigured
 	}
 
-	req, err := c.request(ctx, "/v2/notifications/email", &buf)
+	body, err := json.Marshal(email)
 	if err != nil {
 		return "", err
 	}
 
-	resp, err := c.doRequest(req)
+	resp, err := c.send(ctx, "/v2/notifications/email", "email", email.Reference, body)
 	if err != nil {
 		return "", err
 	}
@@ -133,18 +271,84 @@ func (c *Client) Email(ctx context.Context, email Email) (string, error) {
 	return resp.ID, nil
 }
 
+// Sms sends a text message, returning the ID given to it by Notify. As with
+// Email, the message must have a Reference.
 func (c *Client) Sms(ctx context.Context, sms Sms) (string, error) {
-	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(sms); err != nil {
+	if sms.Reference == "" {
+		return "", errors.New("notify: sms requires a reference")
+	}
+
+	if sms.TemplateID == "" {
+		return "", ErrTemplateNotConfigured
+	}
+
+	body, err := json.Marshal(sms)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.send(ctx, "/v2/notifications/sms", "sms", sms.Reference, body)
+	if err != nil {
 		return "", err
 	}
 
-	req, err := c.request(ctx, "/v2/notifications/sms", &buf)
+	return resp.ID, nil
+}
+
+// Letter posts a letter, returning the ID given to it by Notify. As with Email,
+// the letter must have a Reference.
+func (c *Client) Letter(ctx context.Context, letter Letter) (string, error) {
+	if letter.Reference == "" {
+		return "", errors.New("notify: letter requires a reference")
+	}
+
+	if letter.TemplateID == "" {
+		return "", ErrTemplateNotConfigured
+	}
+
+	if len(letter.Address) < 3 || len(letter.Address) > 7 {
+		return "", fmt.Errorf("notify: letter requires 3 to 7 address lines, got %d", len(letter.Address))
+	}
+
+	personalisation := map[string]string{}
+	for k, v := range letter.Personalisation {
+		personalisation[k] = v
+	}
+	for i, line := range letter.Address {
+		personalisation[fmt.Sprintf("address_line_%d", i+1)] = line
+	}
+
+	body, err := json.Marshal(letterBody{
+		TemplateID:      letter.TemplateID,
+		Personalisation: personalisation,
+		Reference:       letter.Reference,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.send(ctx, "/v2/notifications/letter", "letter", letter.Reference, body)
+	if err != nil {
+		return "", err
+	}
+
+	return resp.ID, nil
+}
+
+// PrecompiledLetter posts a letter that has already been made as a PDF,
+// returning the ID given to it by Notify. As with Email, the letter must have a
+// Reference.
+func (c *Client) PrecompiledLetter(ctx context.Context, letter PrecompiledLetter) (string, error) {
+	if letter.Reference == "" {
+		return "", errors.New("notify: letter requires a reference")
+	}
+
+	body, err := json.Marshal(letter)
 	if err != nil {
 		return "", err
 	}
 
-	resp, err := c.doRequest(req)
+	resp, err := c.send(ctx, "/v2/notifications/letter", "letter", letter.Reference, body)
 	if err != nil {
 		return "", err
 	}
@@ -152,7 +356,134 @@ func (c *Client) Sms(ctx context.Context, sms Sms) (string, error) {
 	return resp.ID, nil
 }
 
-func (c *Client) request(ctx context.Context, url string, body io.Reader) (*http.Request, error) {
+// FindByReference gives the ID of the notification of notificationType
+// ("email", "sms" or "letter") that was sent with reference, or an empty string
+// if there is not one.
+func (c *Client) FindByReference(ctx context.Context, notificationType, reference string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	query := url.Values{"template_type": {notificationType}, "reference": {reference}}
+
+	req, err := c.request(ctx, http.MethodGet, "/v2/notifications?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.doer.Do(req)
+	if err != nil {
+		return "", &Error{Errors: errorsList{{Message: err.Error()}}, kind: ErrServiceDown}
+	}
+	defer resp.Body.Close()
+
+	var r notificationsResponse
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		json.NewDecoder(resp.Body).Decode(&r)
+
+		return "", &Error{
+			StatusCode: resp.StatusCode,
+			Errors:     r.Errors,
+			kind:       errorKind(resp.StatusCode, r.Errors),
+		}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return "", err
+	}
+
+	for _, n := range r.Notifications {
+		if n.Reference == reference {
+			return n.ID, nil
+		}
+	}
+
+	return "", nil
+}
+
+// send posts body to Notify, retrying with exponential backoff when Notify is
+// unavailable or rate limiting requests. When Notify says how long to wait with
+// Retry-After that is used instead, unless it is longer than maxRetryAfter in
+// which case there is no point waiting. Requests that Notify rejects are not
+// retried.
+//
+// A rate limited request was not accepted, so can be sent again. Any other
+// failure may have happened after Notify accepted the message, and Notify does
+// not stop the same reference being sent twice, so before trying again Notify
+// is asked for a notification with the reference. If one is found its ID is
+// returned, and if Notify can't be asked the original failure is returned
+// without trying again.
+func (c *Client) send(ctx context.Context, url, notificationType, reference string, body []byte) (response, error) {
+	var (
+		lastErr error
+		wait    time.Duration
+	)
+
+	for attempt := 0; attempt < c.maxAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return response{}, ctx.Err()
+			case <-time.After(wait):
+			}
+
+			if errors.Is(lastErr, ErrServiceDown) {
+				id, err := c.FindByReference(ctx, notificationType, reference)
+				if err != nil {
+					if ctx.Err() != nil {
+						return response{}, ctx.Err()
+					}
+
+					return response{}, lastErr
+				}
+
+				if id != "" {
+					return response{ID: id}, nil
+				}
+			}
+		}
+
+		resp, after, err := c.attempt(ctx, url, body)
+		if err == nil {
+			return resp, nil
+		}
+
+		if ctx.Err() != nil {
+			return response{}, ctx.Err()
+		}
+
+		if !errors.Is(err, ErrRateLimited) && !errors.Is(err, ErrServiceDown) {
+			return response{}, err
+		}
+
+		if after > c.maxRetryAfter {
+			return response{}, err
+		}
+
+		wait = c.backoff << attempt
+		if after > 0 {
+			wait = after
+		}
+
+		lastErr = err
+	}
+
+	return response{}, lastErr
+}
+
+func (c *Client) attempt(ctx context.Context, url string, body []byte) (response, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := c.request(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return response{}, 0, err
+	}
+
+	return c.doRequest(req)
+}
+
+func (c *Client) request(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
 	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &jwt.RegisteredClaims{
 		Issuer:   c.issuer,
 		IssuedAt: jwt.NewNumericDate(c.now()),
@@ -161,32 +492,85 @@ func (c *Client) request(ctx context.Context, url string, body io.Reader) (*http
 		return &http.Request{}, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+url, body)
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+url, body)
 	if err != nil {
 		return &http.Request{}, err
 	}
-	req.Header.Add("Content-Type", "application/json")
+	if body != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
 	req.Header.Add("Authorization", "Bearer "+token)
 
 	return req, nil
 }
 
-func (c *Client) doRequest(req *http.Request) (response, error) {
+// doRequest makes req, returning how long Notify asked to wait before trying
+// again, if it did.
+func (c *Client) doRequest(req *http.Request) (response, time.Duration, error) {
 	var r response
 
 	resp, err := c.doer.Do(req)
 	if err != nil {
-		return r, err
+		return r, 0, &Error{Errors: errorsList{{Message: err.Error()}}, kind: ErrServiceDown}
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		json.NewDecoder(resp.Body).Decode(&r)
+
+		return response{}, retryAfter(resp.Header.Get("Retry-After"), c.now()), &Error{
+			StatusCode: resp.StatusCode,
+			Errors:     r.Errors,
+			kind:       errorKind(resp.StatusCode, r.Errors),
+		}
+	}
+
 	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
-		return r, err
+		return r, 0, err
 	}
 
 	if len(r.Errors) > 0 {
-		return r, r.Errors
+		return r, 0, &Error{StatusCode: resp.StatusCode, Errors: r.Errors}
+	}
+
+	return r, 0, nil
+}
+
+func errorKind(statusCode int, errs errorsList) error {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case statusCode >= http.StatusInternalServerError:
+		return ErrServiceDown
+	}
+
+	for _, e := range errs {
+		if e.Error == "ValidationError" && (strings.HasPrefix(e.Message, "phone_number ") || strings.HasPrefix(e.Message, "email_address ")) {
+			return ErrInvalidRecipient
+		}
+
+		if e.Error == "BadRequestError" && strings.Contains(e.Message, "send to this recipient") {
+			return ErrInvalidRecipient
+		}
+	}
+
+	return nil
+}
+
+// retryAfter parses a Retry-After header, given either in seconds or as a
+// date.
+func retryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(header); err == nil && at.After(now) {
+		return at.Sub(now)
 	}
 
-	return r, nil
+	return 0
 }
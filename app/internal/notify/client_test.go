@@ -7,10 +7,12 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/localize"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -25,7 +27,7 @@ func (m *mockDoer) Do(req *http.Request) (*http.Response, error) {
 }
 
 func TestNew(t *testing.T) {
-	client, err := New(true, "http://base", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", http.DefaultClient)
+	client, err := New("http://base", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", http.DefaultClient, nil)
 
 	assert.Nil(t, err)
 	assert.Equal(t, "http://base", client.baseURL)
@@ -34,13 +36,13 @@ func TestNew(t *testing.T) {
 }
 
 func TestNewWithInvalidApiKey(t *testing.T) {
-	_, err := New(true, "", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f", http.DefaultClient)
+	_, err := New("", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f", http.DefaultClient, nil)
 
 	assert.NotNil(t, err)
 }
 
 func TestNewWithEmptyBaseURL(t *testing.T) {
-	client, _ := New(true, "", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", http.DefaultClient)
+	client, _ := New("", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", http.DefaultClient, nil)
 
 	assert.Equal(t, "https://api.notifications.service.gov.uk", client.baseURL)
 }
@@ -56,16 +58,18 @@ func TestEmail(t *testing.T) {
 			json.NewDecoder(req.Body).Decode(&v)
 
 			return assert.Equal("me@example.com", v["email_address"]) &&
-				assert.Equal("template-123", v["template_id"])
+				assert.Equal("template-123", v["template_id"]) &&
+				assert.Equal("a-reference", v["reference"])
 		})).
 		Return(&http.Response{
-			Body: io.NopCloser(strings.NewReader(`{"id":"xyz"}`)),
+			StatusCode: http.StatusCreated,
+			Body:       io.NopCloser(strings.NewReader(`{"id":"xyz"}`)),
 		}, nil)
 
-	client, _ := New(true, "", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", doer)
+	client, _ := New("", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", doer, nil)
 	client.now = func() time.Time { return time.Date(2020, time.January, 2, 3, 4, 5, 6, time.UTC) }
 
-	id, err := client.Email(ctx, Email{EmailAddress: "me@example.com", TemplateID: "template-123"})
+	id, err := client.Email(ctx, Email{EmailAddress: "me@example.com", TemplateID: "template-123", Reference: "a-reference"})
 	assert.Nil(err)
 	assert.Equal("xyz", id)
 }
@@ -78,23 +82,131 @@ func TestEmailWhenError(t *testing.T) {
 	doer.
 		On("Do", mock.Anything).
 		Return(&http.Response{
-			Body: io.NopCloser(strings.NewReader(`{"errors":[{"error":"SomeError","message":"This happened"}, {"error":"AndError","message":"Plus this"}]}`)),
+			StatusCode: http.StatusBadRequest,
+			Body:       io.NopCloser(strings.NewReader(`{"errors":[{"error":"SomeError","message":"This happened"}, {"error":"AndError","message":"Plus this"}]}`)),
 		}, nil)
 
-	client, _ := New(true, "", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", doer)
+	client, _ := New("", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", doer, nil)
 
-	_, err := client.Email(ctx, Email{EmailAddress: "me@example.com", TemplateID: "template-123"})
+	_, err := client.Email(ctx, Email{EmailAddress: "me@example.com", TemplateID: "template-123", Reference: "a-reference"})
 	assert.Equal(`error sending message: This happened: Plus this`, err.Error())
 }
 
 func TestTemplateID(t *testing.T) {
-	production, _ := New(true, "", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", nil)
-	assert.Equal(t, "95f7b0a2-1c3a-4ad9-818b-b358c549c88b", production.TemplateID(SignatureCodeEmail))
-	assert.Equal(t, "a0997cbf-cfd9-4f01-acb2-f33b07074662", production.TemplateID(SignatureCodeSms))
+	client, _ := New("", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", nil, Templates{
+		SignatureCodeEmail: {localize.En: "en-signature-code-email", localize.Cy: "cy-signature-code-email"},
+	})
 
-	test, _ := New(false, "", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", nil)
-	assert.Equal(t, "7e8564a0-2635-4f61-9155-0166ddbe5607", test.TemplateID(SignatureCodeEmail))
-	assert.Equal(t, "0aa5b61c-ef30-410a-8473-915df9d343a5", test.TemplateID(SignatureCodeSms))
+	assert.Equal(t, "en-signature-code-email", client.TemplateID(SignatureCodeEmail, localize.En))
+	assert.Equal(t, "cy-signature-code-email", client.TemplateID(SignatureCodeEmail, localize.Cy))
+}
+
+func TestTemplateIDWhenNotConfigured(t *testing.T) {
+	client, _ := New("", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", nil, Templates{
+		SignatureCodeEmail: {localize.En: "en-signature-code-email"},
+	})
+
+	assert.Equal(t, "en-signature-code-email", client.TemplateID(SignatureCodeEmail, localize.Cy))
+	assert.Equal(t, "", client.TemplateID(SignatureCodeSms, localize.En))
+}
+
+func testTemplates() Templates {
+	templates := Templates{}
+	for _, id := range allTemplates {
+		templates[id] = map[localize.Lang]string{
+			localize.En: "en-" + id.String(),
+			localize.Cy: "cy-" + id.String(),
+		}
+	}
+
+	return templates
+}
+
+func TestCheckTemplates(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		paths = append(paths, r.URL.Path)
+
+		json.NewEncoder(w).Encode(map[string]string{"id": strings.TrimPrefix(r.URL.Path, "/v2/template/")})
+	}))
+	defer server.Close()
+
+	client, _ := New(server.URL, "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", server.Client(), testTemplates())
+
+	err := client.CheckTemplates(context.Background())
+
+	assert.Nil(t, err)
+	assert.Len(t, paths, len(allTemplates)*len(allLangs))
+	assert.Contains(t, paths, "/v2/template/cy-certificate-provider-invite-email")
+}
+
+func TestCheckTemplatesWhenNotConfigured(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+
+		json.NewEncoder(w).Encode(map[string]string{"id": strings.TrimPrefix(r.URL.Path, "/v2/template/")})
+	}))
+	defer server.Close()
+
+	client, _ := New(server.URL, "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", server.Client(), Templates{
+		SignatureCodeEmail: {localize.En: "en-signature-code-email"},
+	})
+
+	err := client.CheckTemplates(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/v2/template/en-signature-code-email"}, paths)
+}
+
+func TestCheckTemplatesWhenMissing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/v2/template/")
+
+		switch id {
+		case "cy-signature-code-sms":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"status_code":404,"errors":[{"error":"NoResultFound","message":"No result found"}]}`))
+		case "en-certificate-provider-invite-email":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status_code":400,"errors":[{"error":"ValidationError","message":"id is not a valid UUID"}]}`))
+		default:
+			json.NewEncoder(w).Encode(map[string]string{"id": id})
+		}
+	}))
+	defer server.Close()
+
+	client, _ := New(server.URL, "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", server.Client(), testTemplates())
+
+	err := client.CheckTemplates(context.Background())
+
+	assert.Equal(t, errors.New("notify: templates not found signature-code-sms/cy, certificate-provider-invite-email/en"), err)
+}
+
+func TestCheckTemplatesWhenError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"status_code":403,"errors":[{"error":"AuthError","message":"Invalid token"}]}`))
+	}))
+	defer server.Close()
+
+	client, _ := New(server.URL, "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", server.Client(), testTemplates())
+
+	err := client.CheckTemplates(context.Background())
+
+	assert.Equal(t, "error sending message: Invalid token", err.Error())
+}
+
+func TestTemplateIdString(t *testing.T) {
+	assert.Equal(t, "signature-code-email", SignatureCodeEmail.String())
+	assert.Equal(t, "signature-code-sms", SignatureCodeSms.String())
+	assert.Equal(t, "certificate-provider-invite-email", CertificateProviderInviteEmail.String())
+	assert.Equal(t, "draft-lpa-deletion-warning-email", DraftLpaDeletionWarningEmail.String())
+	assert.Equal(t, "person-to-notify-email", PersonToNotifyEmail.String())
+	assert.Equal(t, "person-to-notify-letter", PersonToNotifyLetter.String())
+	assert.Equal(t, "attorney-invite-email", AttorneyInviteEmail.String())
+	assert.Equal(t, "", TemplateId(-1).String())
 }
 
 func TestRequest(t *testing.T) {
@@ -105,10 +217,10 @@ func TestRequest(t *testing.T) {
 	var jsonBody bytes.Buffer
 	jsonBody.WriteString(`{"some": "json"}`)
 
-	client, _ := New(true, "", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", doer)
+	client, _ := New("", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", doer, nil)
 	client.now = func() time.Time { return time.Date(2020, time.January, 2, 3, 4, 5, 6, time.UTC) }
 
-	req, err := client.request(ctx, "/an/url", &jsonBody)
+	req, err := client.request(ctx, http.MethodPost, "/an/url", &jsonBody)
 
 	assert.Nil(err)
 	assert.Equal(http.MethodPost, req.Method)
@@ -124,10 +236,10 @@ func TestRequestWhenNewRequestError(t *testing.T) {
 	var jsonBody bytes.Buffer
 	jsonBody.WriteString(`{"some": "json"}`)
 
-	client, _ := New(true, "", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", doer)
+	client, _ := New("", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", doer, nil)
 	client.now = func() time.Time { return time.Now().Add(-time.Minute) }
 
-	_, err := client.request(nil, "/an/url", &jsonBody)
+	_, err := client.request(nil, http.MethodPost, "/an/url", &jsonBody)
 
 	assert.Equal(errors.New("net/http: nil Context"), err)
 }
@@ -135,74 +247,66 @@ func TestRequestWhenNewRequestError(t *testing.T) {
 func TestDoRequest(t *testing.T) {
 	assert := assert.New(t)
 	ctx := context.Background()
-	jsonString := `{"id": "123", "status_code": 400}`
+	jsonString := `{"id": "123"}`
 
 	doer := &mockDoer{}
 	doer.
 		On("Do", mock.Anything).
 		Return(&http.Response{
-			Body: io.NopCloser(strings.NewReader(jsonString)),
+			StatusCode: http.StatusCreated,
+			Body:       io.NopCloser(strings.NewReader(jsonString)),
 		}, nil)
 
 	var jsonBody bytes.Buffer
 	jsonBody.WriteString(jsonString)
 
-	client, _ := New(true, "", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", doer)
+	client, _ := New("", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", doer, nil)
 	client.now = func() time.Time { return time.Date(2020, time.January, 2, 3, 4, 5, 6, time.UTC) }
 
-	req, _ := client.request(ctx, "/an/url", &jsonBody)
+	req, _ := client.request(ctx, http.MethodPost, "/an/url", &jsonBody)
 
-	response, err := client.doRequest(req)
+	response, _, err := client.doRequest(req)
 
 	assert.Nil(err)
 	assert.Equal(response.ID, "123")
-	assert.Equal(response.StatusCode, 400)
 }
 
 func TestDoRequestWhenContainsErrorList(t *testing.T) {
 	assert := assert.New(t)
 	ctx := context.Background()
-	jsonString := `{"id": "123", "status_code": 400, "errors": [{"error":"SomeError","message":"This happened"}, {"error":"AndError","message":"Plus this"}]}`
+	jsonString := `{"status_code": 400, "errors": [{"error":"SomeError","message":"This happened"}, {"error":"AndError","message":"Plus this"}]}`
 
 	doer := &mockDoer{}
 	doer.
 		On("Do", mock.Anything).
 		Return(&http.Response{
-			Body: io.NopCloser(strings.NewReader(jsonString)),
+			StatusCode: http.StatusBadRequest,
+			Body:       io.NopCloser(strings.NewReader(jsonString)),
 		}, nil)
 
 	var jsonBody bytes.Buffer
 	jsonBody.WriteString(jsonString)
 
-	client, _ := New(true, "", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", doer)
+	client, _ := New("", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", doer, nil)
 	client.now = func() time.Time { return time.Date(2020, time.January, 2, 3, 4, 5, 6, time.UTC) }
 
-	req, _ := client.request(ctx, "/an/url", &jsonBody)
-
-	response, err := client.doRequest(req)
-
-	assert.Equal(errorsList{
-		errorItem{
-			Error:   "SomeError",
-			Message: "This happened",
-		},
-		errorItem{
-			Error:   "AndError",
-			Message: "Plus this",
+	req, _ := client.request(ctx, http.MethodPost, "/an/url", &jsonBody)
+
+	_, _, err := client.doRequest(req)
+
+	assert.Equal(&Error{
+		StatusCode: http.StatusBadRequest,
+		Errors: errorsList{
+			errorItem{
+				Error:   "SomeError",
+				Message: "This happened",
+			},
+			errorItem{
+				Error:   "AndError",
+				Message: "Plus this",
+			},
 		},
 	}, err)
-	assert.Equal("123", response.ID)
-	assert.Equal(400, response.StatusCode)
-	assert.Equal(errorsList{
-		errorItem{
-			Error:   "SomeError",
-			Message: "This happened",
-		},
-		errorItem{
-			Error:   "AndError",
-			Message: "Plus this",
-		},
-	}, response.Errors)
 }
 
 func TestDoRequestWhenRequestError(t *testing.T) {
@@ -212,21 +316,20 @@ func TestDoRequestWhenRequestError(t *testing.T) {
 	doer := &mockDoer{}
 	doer.
 		On("Do", mock.Anything).
-		Return(&http.Response{
-			Body: io.NopCloser(strings.NewReader(`{"id": "123"}`)),
-		}, errors.New("err"))
+		Return(&http.Response{}, errors.New("err"))
 
 	var jsonBody bytes.Buffer
 	jsonBody.WriteString(`{"id": "123"}`)
 
-	client, _ := New(true, "", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", doer)
+	client, _ := New("", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", doer, nil)
 	client.now = func() time.Time { return time.Date(2020, time.January, 2, 3, 4, 5, 6, time.UTC) }
 
-	req, _ := client.request(ctx, "/an/url", &jsonBody)
+	req, _ := client.request(ctx, http.MethodPost, "/an/url", &jsonBody)
 
-	resp, err := client.doRequest(req)
+	resp, _, err := client.doRequest(req)
 
-	assert.Equal(errors.New("err"), err)
+	assert.ErrorIs(err, ErrServiceDown)
+	assert.Equal("error sending message: err", err.Error())
 	assert.Equal(response{}, resp)
 }
 
@@ -238,23 +341,60 @@ func TestDoRequestWhenJsonDecodeFails(t *testing.T) {
 	doer.
 		On("Do", mock.Anything).
 		Return(&http.Response{
-			Body: io.NopCloser(strings.NewReader(`not json`)),
+			StatusCode: http.StatusCreated,
+			Body:       io.NopCloser(strings.NewReader(`not json`)),
 		}, nil)
 
 	var jsonBody bytes.Buffer
 	jsonBody.WriteString(`not json`)
 
-	client, _ := New(true, "", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", doer)
+	client, _ := New("", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", doer, nil)
 	client.now = func() time.Time { return time.Date(2020, time.January, 2, 3, 4, 5, 6, time.UTC) }
 
-	req, _ := client.request(ctx, "/an/url", &jsonBody)
+	req, _ := client.request(ctx, http.MethodPost, "/an/url", &jsonBody)
 
-	resp, err := client.doRequest(req)
+	resp, _, err := client.doRequest(req)
 
 	assert.IsType(&json.SyntaxError{}, err)
 	assert.Equal(response{}, resp)
 }
 
+func TestDoRequestWhenRetryAfter(t *testing.T) {
+	now := time.Date(2020, time.January, 2, 3, 4, 5, 0, time.UTC)
+
+	testCases := map[string]time.Duration{
+		"":                              0,
+		"3":                             3 * time.Second,
+		"-1":                            0,
+		"Thu, 02 Jan 2020 03:04:15 GMT": 10 * time.Second,
+		"Thu, 02 Jan 2020 03:04:00 GMT": 0,
+		"soon":                          0,
+	}
+
+	for header, expected := range testCases {
+		t.Run(header, func(t *testing.T) {
+			doer := &mockDoer{}
+			doer.
+				On("Do", mock.Anything).
+				Return(&http.Response{
+					StatusCode: http.StatusTooManyRequests,
+					Header:     http.Header{"Retry-After": {header}},
+					Body:       io.NopCloser(strings.NewReader(`{}`)),
+				}, nil)
+
+			client, _ := New("", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", doer, nil)
+			client.now = func() time.Time { return now }
+
+			req, _ := client.request(context.Background(), http.MethodPost, "/an/url", strings.NewReader(`{}`))
+
+			_, after, err := client.doRequest(req)
+
+			assert.ErrorIs(t, err, ErrRateLimited)
+			assert.Equal(t, expected, after)
+		})
+	}
+}
+
 func TestSms(t *testing.T) {
 	assert := assert.New(t)
 	ctx := context.Background()
@@ -266,16 +406,18 @@ func TestSms(t *testing.T) {
 			json.NewDecoder(req.Body).Decode(&v)
 
 			return assert.Equal("+447535111111", v["phone_number"]) &&
-				assert.Equal("template-123", v["template_id"])
+				assert.Equal("template-123", v["template_id"]) &&
+				assert.Equal("a-reference", v["reference"])
 		})).
 		Return(&http.Response{
-			Body: io.NopCloser(strings.NewReader(`{"id":"xyz"}`)),
+			StatusCode: http.StatusCreated,
+			Body:       io.NopCloser(strings.NewReader(`{"id":"xyz"}`)),
 		}, nil)
 
-	client, _ := New(true, "", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", doer)
+	client, _ := New("", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", doer, nil)
 	client.now = func() time.Time { return time.Date(2020, time.January, 2, 3, 4, 5, 6, time.UTC) }
 
-	id, err := client.Sms(ctx, Sms{PhoneNumber: "+447535111111", TemplateID: "template-123"})
+	id, err := client.Sms(ctx, Sms{PhoneNumber: "+447535111111", TemplateID: "template-123", Reference: "a-reference"})
 
 	assert.Nil(err)
 	assert.Equal("xyz", id)
@@ -289,11 +431,391 @@ func TestSmsWhenError(t *testing.T) {
 	doer.
 		On("Do", mock.Anything).
 		Return(&http.Response{
-			Body: io.NopCloser(strings.NewReader(`{"errors":[{"error":"SomeError","message":"This happened"}, {"error":"AndError","message":"Plus this"}]}`)),
+			StatusCode: http.StatusBadRequest,
+			Body:       io.NopCloser(strings.NewReader(`{"errors":[{"error":"SomeError","message":"This happened"}, {"error":"AndError","message":"Plus this"}]}`)),
 		}, nil)
 
-	client, _ := New(true, "", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", doer)
+	client, _ := New("", "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", doer, nil)
 
-	_, err := client.Sms(ctx, Sms{PhoneNumber: "+447535111111", TemplateID: "template-123"})
+	_, err := client.Sms(ctx, Sms{PhoneNumber: "+447535111111", TemplateID: "template-123", Reference: "a-reference"})
 	assert.Equal(`error sending message: This happened: Plus this`, err.Error())
 }
+
+func newTestClient(url string, doer Doer) *Client {
+	client, _ := New(url, "my_client-f33517ff-2a88-4f6e-b855-c550268ce08a-740e5834-3a29-46b4-9a6f-16142fde533a", doer, nil)
+	client.backoff = 0
+	return client
+}
+
+func TestSendWhenTemplateNotConfigured(t *testing.T) {
+	doer := &mockDoer{}
+	client := newTestClient("", doer)
+
+	_, err := client.Email(context.Background(), Email{EmailAddress: "me@example.com", Reference: "a-reference"})
+	assert.Equal(t, ErrTemplateNotConfigured, err)
+
+	_, err = client.Sms(context.Background(), Sms{PhoneNumber: "+447535111111", Reference: "a-reference"})
+	assert.Equal(t, ErrTemplateNotConfigured, err)
+
+	_, err = client.Letter(context.Background(), Letter{Address: []string{"1 Road", "Town", "A1 1AA"}, Reference: "a-reference"})
+	assert.Equal(t, ErrTemplateNotConfigured, err)
+
+	mock.AssertExpectationsForObjects(t, doer)
+}
+
+func TestEmailWhenNoReference(t *testing.T) {
+	doer := &mockDoer{}
+
+	_, err := newTestClient("", doer).Email(context.Background(), Email{EmailAddress: "me@example.com", TemplateID: "template-123"})
+
+	assert.NotNil(t, err)
+	mock.AssertExpectationsForObjects(t, doer)
+}
+
+func TestSmsWhenNoReference(t *testing.T) {
+	doer := &mockDoer{}
+
+	_, err := newTestClient("", doer).Sms(context.Background(), Sms{PhoneNumber: "+447535111111", TemplateID: "template-123"})
+
+	assert.NotNil(t, err)
+	mock.AssertExpectationsForObjects(t, doer)
+}
+
+func TestEmailRetries(t *testing.T) {
+	for _, statusCode := range []int{http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable} {
+		t.Run(http.StatusText(statusCode), func(t *testing.T) {
+			var references []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					w.Write([]byte(`{"notifications":[]}`))
+					return
+				}
+
+				var v Email
+				json.NewDecoder(r.Body).Decode(&v)
+				references = append(references, v.Reference)
+
+				if len(references) < 3 {
+					w.WriteHeader(statusCode)
+					return
+				}
+
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`{"id":"xyz"}`))
+			}))
+			defer server.Close()
+
+			id, err := newTestClient(server.URL, server.Client()).Email(context.Background(), Email{EmailAddress: "me@example.com", TemplateID: "template-123", Reference: "a-reference"})
+
+			assert.Nil(t, err)
+			assert.Equal(t, "xyz", id)
+			assert.Equal(t, []string{"a-reference", "a-reference", "a-reference"}, references)
+		})
+	}
+}
+
+func TestEmailWhenServiceDownLooksForReferenceBeforeRetrying(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.String())
+
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"notifications":[{"id":"xyz","reference":"a-reference"}]}`))
+			return
+		}
+
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	id, err := newTestClient(server.URL, server.Client()).Email(context.Background(), Email{EmailAddress: "me@example.com", TemplateID: "template-123", Reference: "a-reference"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "xyz", id)
+	assert.Equal(t, []string{
+		"POST /v2/notifications/email",
+		"GET /v2/notifications?reference=a-reference&template_type=email",
+	}, requests)
+}
+
+func TestEmailWhenRateLimited(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"status_code":429,"errors":[{"error":"RateLimitError","message":"Exceeded rate limit for key type LIVE of 3000 requests per 60 seconds"}]}`))
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server.URL, server.Client()).Email(context.Background(), Email{EmailAddress: "me@example.com", TemplateID: "template-123", Reference: "a-reference"})
+
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestEmailWhenRetryAfter(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.Header().Add("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"xyz"}`))
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	_, err := newTestClient(server.URL, server.Client()).Email(context.Background(), Email{EmailAddress: "me@example.com", TemplateID: "template-123", Reference: "a-reference"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, attempts)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+}
+
+func TestEmailWhenRetryAfterTooLong(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Add("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server.URL, server.Client()).Email(context.Background(), Email{EmailAddress: "me@example.com", TemplateID: "template-123", Reference: "a-reference"})
+
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestEmailWhenServiceDown(t *testing.T) {
+	doer := &mockDoer{}
+	doer.
+		On("Do", mock.MatchedBy(func(r *http.Request) bool { return r.Method == http.MethodPost })).
+		Return(&http.Response{}, errors.New("connection refused")).
+		Once()
+	doer.
+		On("Do", mock.MatchedBy(func(r *http.Request) bool { return r.Method == http.MethodGet })).
+		Return(&http.Response{}, errors.New("connection refused")).
+		Once()
+
+	_, err := newTestClient("http://notify", doer).Email(context.Background(), Email{EmailAddress: "me@example.com", TemplateID: "template-123", Reference: "a-reference"})
+
+	assert.ErrorIs(t, err, ErrServiceDown)
+	mock.AssertExpectationsForObjects(t, doer)
+}
+
+func TestEmailWhenContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	doer := &mockDoer{}
+	doer.
+		On("Do", mock.Anything).
+		Run(func(mock.Arguments) { cancel() }).
+		Return(&http.Response{}, context.Canceled).
+		Once()
+
+	_, err := newTestClient("http://notify", doer).Email(ctx, Email{EmailAddress: "me@example.com", TemplateID: "template-123", Reference: "a-reference"})
+
+	assert.Equal(t, context.Canceled, err)
+	mock.AssertExpectationsForObjects(t, doer)
+}
+
+func TestEmailWhenRejected(t *testing.T) {
+	testCases := map[string]struct {
+		body     string
+		expected error
+	}{
+		"invalid email": {
+			body:     `{"status_code":400,"errors":[{"error":"ValidationError","message":"email_address Not a valid email address"}]}`,
+			expected: ErrInvalidRecipient,
+		},
+		"invalid phone number": {
+			body:     `{"status_code":400,"errors":[{"error":"ValidationError","message":"phone_number Not a UK mobile number"}]}`,
+			expected: ErrInvalidRecipient,
+		},
+		"team only": {
+			body:     `{"status_code":400,"errors":[{"error":"BadRequestError","message":"Can't send to this recipient using a team-only API key"}]}`,
+			expected: ErrInvalidRecipient,
+		},
+		"other": {
+			body: `{"status_code":400,"errors":[{"error":"BadRequestError","message":"Template not found"}]}`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			_, err := newTestClient(server.URL, server.Client()).Email(context.Background(), Email{EmailAddress: "me@example.com", TemplateID: "template-123", Reference: "a-reference"})
+
+			var notifyErr *Error
+			assert.ErrorAs(t, err, &notifyErr)
+			assert.Equal(t, http.StatusBadRequest, notifyErr.StatusCode)
+			assert.Equal(t, tc.expected, errors.Unwrap(err))
+			assert.Equal(t, 1, attempts)
+		})
+	}
+}
+
+func TestErrorWhenNoErrors(t *testing.T) {
+	assert.Equal(t, "error sending message: 503 Service Unavailable", (&Error{StatusCode: http.StatusServiceUnavailable}).Error())
+}
+
+func TestLetter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var v map[string]any
+		json.NewDecoder(r.Body).Decode(&v)
+
+		assert.Equal(t, "/v2/notifications/letter", r.URL.Path)
+		assert.Equal(t, map[string]any{
+			"template_id": "template-123",
+			"reference":   "a-reference",
+			"personalisation": map[string]any{
+				"address_line_1": "1 Road",
+				"address_line_2": "Town",
+				"address_line_3": "A1 1AA",
+				"name":           "John",
+			},
+		}, v)
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"xyz"}`))
+	}))
+	defer server.Close()
+
+	id, err := newTestClient(server.URL, server.Client()).Letter(context.Background(), Letter{
+		TemplateID:      "template-123",
+		Address:         []string{"1 Road", "Town", "A1 1AA"},
+		Personalisation: map[string]string{"name": "John"},
+		Reference:       "a-reference",
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "xyz", id)
+}
+
+func TestLetterWhenInvalid(t *testing.T) {
+	testCases := map[string]Letter{
+		"no reference":   {TemplateID: "template-123", Address: []string{"1 Road", "Town", "A1 1AA"}},
+		"too few lines":  {TemplateID: "template-123", Address: []string{"Town", "A1 1AA"}, Reference: "a-reference"},
+		"too many lines": {TemplateID: "template-123", Address: []string{"1", "2", "3", "4", "5", "6", "7", "8"}, Reference: "a-reference"},
+	}
+
+	for name, letter := range testCases {
+		t.Run(name, func(t *testing.T) {
+			doer := &mockDoer{}
+
+			_, err := newTestClient("", doer).Letter(context.Background(), letter)
+
+			assert.NotNil(t, err)
+			mock.AssertExpectationsForObjects(t, doer)
+		})
+	}
+}
+
+func TestLetterWhenError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status_code":400,"errors":[{"error":"ValidationError","message":"Last line of address must be a real UK postcode or another country"}]}`))
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server.URL, server.Client()).Letter(context.Background(), Letter{
+		TemplateID: "template-123",
+		Address:    []string{"1 Road", "Town", "Nowhere"},
+		Reference:  "a-reference",
+	})
+
+	assert.Equal(t, "error sending message: Last line of address must be a real UK postcode or another country", err.Error())
+}
+
+func TestPrecompiledLetter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var v map[string]any
+		json.NewDecoder(r.Body).Decode(&v)
+
+		assert.Equal(t, "/v2/notifications/letter", r.URL.Path)
+		assert.Equal(t, map[string]any{
+			"reference": "a-reference",
+			"content":   "JVBERi0=",
+			"postage":   "second",
+		}, v)
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"xyz"}`))
+	}))
+	defer server.Close()
+
+	id, err := newTestClient(server.URL, server.Client()).PrecompiledLetter(context.Background(), PrecompiledLetter{
+		Reference: "a-reference",
+		PDF:       []byte("%PDF-"),
+		Postage:   "second",
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "xyz", id)
+}
+
+func TestPrecompiledLetterWhenNoReference(t *testing.T) {
+	doer := &mockDoer{}
+
+	_, err := newTestClient("", doer).PrecompiledLetter(context.Background(), PrecompiledLetter{PDF: []byte("%PDF-")})
+
+	assert.NotNil(t, err)
+	mock.AssertExpectationsForObjects(t, doer)
+}
+
+func TestFindByReference(t *testing.T) {
+	testcases := map[string]struct {
+		body string
+		id   string
+	}{
+		"found": {
+			body: `{"notifications":[{"id":"abc","reference":"another-reference"},{"id":"xyz","reference":"a-reference"}]}`,
+			id:   "xyz",
+		},
+		"not found": {
+			body: `{"notifications":[]}`,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodGet, r.Method)
+				assert.Equal(t, "/v2/notifications", r.URL.Path)
+				assert.Equal(t, "letter", r.URL.Query().Get("template_type"))
+				assert.Equal(t, "a-reference", r.URL.Query().Get("reference"))
+
+				w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			id, err := newTestClient(server.URL, server.Client()).FindByReference(context.Background(), "letter", "a-reference")
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.id, id)
+		})
+	}
+}
+
+func TestFindByReferenceWhenError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server.URL, server.Client()).FindByReference(context.Background(), "email", "a-reference")
+
+	assert.ErrorIs(t, err, ErrServiceDown)
+}
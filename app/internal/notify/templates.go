@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/localize"
+)
+
+var allTemplates = []TemplateId{
+	SignatureCodeEmail,
+	SignatureCodeSms,
+	CertificateProviderInviteEmail,
+	DraftLpaDeletionWarningEmail,
+	PersonToNotifyEmail,
+	PersonToNotifyLetter,
+	AttorneyInviteEmail,
+}
+
+var allLangs = []localize.Lang{localize.En, localize.Cy}
+
+// Templates holds the Notify ID of each template in each language. Notify IDs
+// differ between environments, so they are read from a file like the following,
+// where a template that has not been made in Notify yet is left out:
+//
+//	{
+//	  "certificate-provider-invite-email": {
+//	    "en": "f719dfa9-6dc5-4848-b330-07e91770abd1",
+//	    "cy": "..."
+//	  }
+//	}
+type Templates map[TemplateId]map[localize.Lang]string
+
+// LoadTemplates reads Templates from the file at path.
+func LoadTemplates(path string) (Templates, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseTemplates(f)
+}
+
+// ParseTemplates reads Templates, returning an error if any name or language
+// is not known.
+func ParseTemplates(r io.Reader) (Templates, error) {
+	var v map[string]map[string]string
+	if err := json.NewDecoder(r).Decode(&v); err != nil {
+		return nil, err
+	}
+
+	templates := Templates{}
+	for name, ids := range v {
+		id, ok := templateIdFromString(name)
+		if !ok {
+			return nil, fmt.Errorf("notify: unknown template %q", name)
+		}
+
+		templates[id] = map[localize.Lang]string{}
+		for abbreviation, templateID := range ids {
+			lang, ok := langFromString(abbreviation)
+			if !ok {
+				return nil, fmt.Errorf("notify: unknown language %q for template %q", abbreviation, name)
+			}
+
+			templates[id][lang] = templateID
+		}
+	}
+
+	return templates, nil
+}
+
+// Missing lists the templates, as name/lang, that do not have an ID.
+func (t Templates) Missing() []string {
+	var missing []string
+	for _, id := range allTemplates {
+		for _, lang := range allLangs {
+			if t[id][lang] == "" {
+				missing = append(missing, id.String()+"/"+lang.String())
+			}
+		}
+	}
+
+	sort.Strings(missing)
+	return missing
+}
+
+func templateIdFromString(s string) (TemplateId, bool) {
+	for _, id := range allTemplates {
+		if id.String() == s {
+			return id, true
+		}
+	}
+
+	return 0, false
+}
+
+func langFromString(s string) (localize.Lang, bool) {
+	for _, lang := range allLangs {
+		if lang.String() == s {
+			return lang, true
+		}
+	}
+
+	return 0, false
+}
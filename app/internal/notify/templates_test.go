@@ -0,0 +1,106 @@
+package notify
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/localize"
+	"github.com/stretchr/testify/assert"
+)
+
+const validTemplates = `{
+  "signature-code-email": {"en": "a", "cy": "b"},
+  "signature-code-sms": {"en": "c", "cy": "d"},
+  "certificate-provider-invite-email": {"en": "e", "cy": "f"},
+  "draft-lpa-deletion-warning-email": {"en": "g", "cy": "h"},
+  "person-to-notify-email": {"en": "i", "cy": "j"},
+  "person-to-notify-letter": {"en": "k", "cy": "l"},
+  "attorney-invite-email": {"en": "m", "cy": "n"}
+}`
+
+func TestParseTemplates(t *testing.T) {
+	templates, err := ParseTemplates(strings.NewReader(validTemplates))
+
+	assert.Nil(t, err)
+	assert.Equal(t, Templates{
+		SignatureCodeEmail:             {localize.En: "a", localize.Cy: "b"},
+		SignatureCodeSms:               {localize.En: "c", localize.Cy: "d"},
+		CertificateProviderInviteEmail: {localize.En: "e", localize.Cy: "f"},
+		DraftLpaDeletionWarningEmail:   {localize.En: "g", localize.Cy: "h"},
+		PersonToNotifyEmail:            {localize.En: "i", localize.Cy: "j"},
+		PersonToNotifyLetter:           {localize.En: "k", localize.Cy: "l"},
+		AttorneyInviteEmail:            {localize.En: "m", localize.Cy: "n"},
+	}, templates)
+}
+
+func TestParseTemplatesWhenInvalid(t *testing.T) {
+	testCases := map[string]struct {
+		json  string
+		error string
+	}{
+		"unknown template": {
+			json:  `{"what-email": {"en": "a", "cy": "b"}}`,
+			error: `notify: unknown template "what-email"`,
+		},
+		"unknown language": {
+			json:  `{"signature-code-email": {"fr": "a"}}`,
+			error: `notify: unknown language "fr" for template "signature-code-email"`,
+		},
+		"not json": {
+			json:  `hey`,
+			error: `invalid character 'h' looking for beginning of value`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseTemplates(strings.NewReader(tc.json))
+
+			assert.EqualError(t, err, tc.error)
+		})
+	}
+}
+
+func TestParseTemplatesWhenIncomplete(t *testing.T) {
+	templates, err := ParseTemplates(strings.NewReader(`{"signature-code-email": {"en": "a"}}`))
+
+	assert.Nil(t, err)
+	assert.Equal(t, Templates{SignatureCodeEmail: {localize.En: "a"}}, templates)
+}
+
+func TestTemplatesMissing(t *testing.T) {
+	templates, _ := ParseTemplates(strings.NewReader(validTemplates))
+	assert.Nil(t, templates.Missing())
+
+	templates, _ = ParseTemplates(strings.NewReader(`{"signature-code-email": {"en": "a"}, "signature-code-sms": {"en": "c", "cy": "d"}, "certificate-provider-invite-email": {"en": "e", "cy": "f"}}`))
+	assert.Equal(t, []string{
+		"attorney-invite-email/cy",
+		"attorney-invite-email/en",
+		"draft-lpa-deletion-warning-email/cy",
+		"draft-lpa-deletion-warning-email/en",
+		"person-to-notify-email/cy",
+		"person-to-notify-email/en",
+		"person-to-notify-letter/cy",
+		"person-to-notify-letter/en",
+		"signature-code-email/cy",
+	}, templates.Missing())
+}
+
+func TestLoadTemplates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "templates.json")
+	os.WriteFile(path, []byte(validTemplates), 0o600)
+
+	templates, err := LoadTemplates(path)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "h", templates[DraftLpaDeletionWarningEmail][localize.Cy])
+}
+
+func TestLoadTemplatesWhenMissingFile(t *testing.T) {
+	_, err := LoadTemplates(filepath.Join(t.TempDir(), "templates.json"))
+
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
@@ -20,6 +20,8 @@ type AppData struct {
 	Paths            AppPaths
 	LpaID            string
 	CsrfToken        string
+	LpaChanged       bool
+	LpaWithdrawn     bool
 }
 
 func (d AppData) Redirect(w http.ResponseWriter, r *http.Request, lpa *Lpa, url string) error {
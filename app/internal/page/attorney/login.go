@@ -0,0 +1,53 @@
+package attorney
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/localize"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/sesh"
+)
+
+func Login(logger page.Logger, oneLoginClient page.OneLoginClient, store sesh.Store, randomString func(int) string, shareCodeStore page.ShareCodeStore) page.Handler {
+	return func(appData page.AppData, w http.ResponseWriter, r *http.Request) error {
+		shareCode := r.FormValue("share-code")
+
+		shareCodeData, err := shareCodeStore.Get(r.Context(), shareCode)
+		if err != nil {
+			return err
+		}
+
+		if shareCodeData.ActorType != page.ActorTypeAttorney {
+			return errors.New("share code is not for an attorney")
+		}
+
+		locale := "en"
+		if appData.Lang == localize.Cy {
+			locale = "cy"
+		}
+
+		state := randomString(12)
+		nonce := randomString(12)
+
+		authCodeURL := oneLoginClient.AuthCodeURL(state, nonce, locale, true)
+
+		if err := sesh.SetOneLogin(store, r, w, &sesh.OneLoginSession{
+			State:         state,
+			Nonce:         nonce,
+			Locale:        locale,
+			Attorney:      true,
+			Identity:      true,
+			ShareCode:     shareCode,
+			LpaID:         shareCodeData.LpaID,
+			AttorneyID:    shareCodeData.ActorID,
+			IsReplacement: shareCodeData.IsReplacementAttorney,
+		}); err != nil {
+			logger.Print(err)
+			return nil
+		}
+
+		http.Redirect(w, r, authCodeURL, http.StatusFound)
+		return nil
+	}
+}
@@ -0,0 +1,139 @@
+package attorney
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ministryofjustice/opg-go-common/template"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/sesh"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/validation"
+)
+
+type loginCallbackData struct {
+	App             page.AppData
+	Errors          validation.List
+	FullName        string
+	ConfirmedAt     time.Time
+	CouldNotConfirm bool
+}
+
+func LoginCallback(tmpl template.Template, oneLoginClient page.OneLoginClient, sessionStore sesh.Store, lpaStore page.LpaStore, shareCodeStore page.ShareCodeStore) page.Handler {
+	return func(appData page.AppData, w http.ResponseWriter, r *http.Request) error {
+		if r.Method == http.MethodPost {
+			attorneySession, err := sesh.Attorney(sessionStore, r)
+			if err != nil {
+				return err
+			}
+
+			ctx := page.ContextWithSessionData(r.Context(), &page.SessionData{
+				LpaID:     attorneySession.LpaID,
+				ActorType: page.ActorTypeAttorney,
+				Subject:   attorneySession.Sub,
+			})
+
+			lpa, err := lpaStore.Get(ctx)
+			if err != nil {
+				return err
+			}
+
+			if !lpa.GetAttorneyProvidedDetails(attorneySession.AttorneyID, attorneySession.IsReplacement).IdentityUserData.OK {
+				return appData.Redirect(w, r, lpa, page.Paths.AttorneyIdentityNotConfirmed)
+			}
+
+			return appData.Redirect(w, r, lpa, page.Paths.AttorneyReadTheLpa)
+		}
+
+		oneLoginSession, err := sesh.OneLogin(sessionStore, r)
+		if err != nil {
+			return err
+		}
+		if !oneLoginSession.Attorney || !oneLoginSession.Identity {
+			return errors.New("attorney callback with incorrect session")
+		}
+
+		data := &loginCallbackData{App: appData}
+
+		if r.FormValue("error") == "access_denied" {
+			data.CouldNotConfirm = true
+			return tmpl(w, data)
+		}
+
+		accessToken, err := oneLoginClient.Exchange(r.Context(), r.FormValue("code"), oneLoginSession.Nonce)
+		if err != nil {
+			return err
+		}
+
+		userInfo, err := oneLoginClient.UserInfo(r.Context(), accessToken)
+		if err != nil {
+			return err
+		}
+
+		ctx := page.ContextWithSessionData(r.Context(), &page.SessionData{
+			LpaID:     oneLoginSession.LpaID,
+			ActorType: page.ActorTypeAttorney,
+			Subject:   userInfo.Sub,
+		})
+
+		if _, err := shareCodeStore.Redeem(ctx, oneLoginSession.ShareCode); err != nil {
+			return err
+		}
+
+		if err := lpaStore.Link(ctx); err != nil {
+			return err
+		}
+
+		lpa, err := lpaStore.Get(ctx)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := getAttorney(lpa, oneLoginSession.AttorneyID, oneLoginSession.IsReplacement); !ok {
+			return errors.New("attorney callback for attorney not on lpa")
+		}
+
+		details := lpa.GetAttorneyProvidedDetails(oneLoginSession.AttorneyID, oneLoginSession.IsReplacement)
+		if !details.IdentityUserData.OK {
+			userData, err := oneLoginClient.ParseIdentityClaim(ctx, userInfo)
+			if err != nil {
+				return err
+			}
+
+			if !userData.OK {
+				data.CouldNotConfirm = true
+				return tmpl(w, data)
+			}
+
+			details.IdentityUserData = userData
+			lpa.PutAttorneyProvidedDetails(oneLoginSession.AttorneyID, oneLoginSession.IsReplacement, details)
+			if err := lpaStore.Put(ctx, lpa); err != nil {
+				return err
+			}
+		}
+
+		if err := sesh.SetAttorney(sessionStore, r, w, &sesh.AttorneySession{
+			Sub:           userInfo.Sub,
+			Email:         userInfo.Email,
+			LpaID:         oneLoginSession.LpaID,
+			AttorneyID:    oneLoginSession.AttorneyID,
+			IsReplacement: oneLoginSession.IsReplacement,
+		}); err != nil {
+			return err
+		}
+
+		data.FullName = details.IdentityUserData.FullName
+		data.ConfirmedAt = details.IdentityUserData.RetrievedAt
+
+		return tmpl(w, data)
+	}
+}
+
+func getAttorney(lpa *page.Lpa, attorneyID string, isReplacement bool) (actor.Attorney, bool) {
+	if isReplacement {
+		return lpa.ReplacementAttorneys.Get(attorneyID)
+	}
+
+	return lpa.Attorneys.Get(attorneyID)
+}
@@ -0,0 +1,708 @@
+package attorney
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/identity"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/onelogin"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/sesh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockTemplate struct {
+	mock.Mock
+}
+
+func (m *mockTemplate) Func(w io.Writer, data interface{}) error {
+	args := m.Called(w, data)
+	return args.Error(0)
+}
+
+type mockOneLoginClient struct {
+	mock.Mock
+}
+
+func (m *mockOneLoginClient) AuthCodeURL(state, nonce, locale string, identity bool) string {
+	args := m.Called(state, nonce, locale, identity)
+	return args.String(0)
+}
+
+func (m *mockOneLoginClient) Exchange(ctx context.Context, code, nonce string) (string, error) {
+	args := m.Called(ctx, code, nonce)
+	return args.Get(0).(string), args.Error(1)
+}
+
+func (m *mockOneLoginClient) UserInfo(ctx context.Context, accessToken string) (onelogin.UserInfo, error) {
+	args := m.Called(ctx, accessToken)
+	return args.Get(0).(onelogin.UserInfo), args.Error(1)
+}
+
+func (m *mockOneLoginClient) ParseIdentityClaim(ctx context.Context, userInfo onelogin.UserInfo) (identity.UserData, error) {
+	args := m.Called(ctx, userInfo)
+	return args.Get(0).(identity.UserData), args.Error(1)
+}
+
+type mockLpaStore struct {
+	mock.Mock
+}
+
+func (m *mockLpaStore) Create(ctx context.Context) (*page.Lpa, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*page.Lpa), args.Error(1)
+}
+
+func (m *mockLpaStore) GetPage(ctx context.Context, query page.LpaQuery) (page.LpaPage, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(page.LpaPage), args.Error(1)
+}
+
+func (m *mockLpaStore) Get(ctx context.Context) (*page.Lpa, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*page.Lpa), args.Error(1)
+}
+
+func (m *mockLpaStore) Put(ctx context.Context, v *page.Lpa) error {
+	return m.Called(ctx, v).Error(0)
+}
+
+func (m *mockLpaStore) Delete(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func (m *mockLpaStore) GetAllAs(ctx context.Context, actorType page.ActorType) ([]*page.Lpa, error) {
+	args := m.Called(ctx, actorType)
+	return args.Get(0).([]*page.Lpa), args.Error(1)
+}
+
+func (m *mockLpaStore) Link(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func (m *mockLpaStore) History(ctx context.Context) ([]page.LpaEvent, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]page.LpaEvent), args.Error(1)
+}
+
+var oneLoginSessionValues = map[any]any{
+	"one-login": &sesh.OneLoginSession{
+		State:      "a-state",
+		Nonce:      "a-nonce",
+		Attorney:   true,
+		Identity:   true,
+		LpaID:      "lpa-id",
+		ShareCode:  "a-share-code",
+		AttorneyID: "attorney-id",
+	},
+}
+
+func TestGetLoginCallback(t *testing.T) {
+	testCases := map[string]struct {
+		isReplacement bool
+		lpa           *page.Lpa
+		expectedLpa   *page.Lpa
+	}{
+		"attorney": {
+			lpa: &page.Lpa{Attorneys: actor.Attorneys{{ID: "attorney-id"}}},
+			expectedLpa: &page.Lpa{
+				Attorneys: actor.Attorneys{{ID: "attorney-id"}},
+				AttorneyProvidedDetails: map[string]page.AttorneyProvidedDetails{
+					"attorney-id": {IdentityUserData: identity.UserData{OK: true, FullName: "John Doe", RetrievedAt: time.Date(2020, time.January, 2, 3, 4, 5, 6, time.UTC)}},
+				},
+			},
+		},
+		"replacement attorney": {
+			isReplacement: true,
+			lpa:           &page.Lpa{ReplacementAttorneys: actor.Attorneys{{ID: "attorney-id"}}},
+			expectedLpa: &page.Lpa{
+				ReplacementAttorneys: actor.Attorneys{{ID: "attorney-id"}},
+				ReplacementAttorneyProvidedDetails: map[string]page.AttorneyProvidedDetails{
+					"attorney-id": {IdentityUserData: identity.UserData{OK: true, FullName: "John Doe", RetrievedAt: time.Date(2020, time.January, 2, 3, 4, 5, 6, time.UTC)}},
+				},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodGet, "/?code=a-code", nil)
+			now := time.Date(2020, time.January, 2, 3, 4, 5, 6, time.UTC)
+
+			userInfo := onelogin.UserInfo{Sub: "a-sub", Email: "a-email", CoreIdentityJWT: "an-identity-jwt"}
+			userData := identity.UserData{OK: true, FullName: "John Doe", RetrievedAt: now}
+
+			sessionStore := &mockSessionsStore{}
+			session := sessions.NewSession(sessionStore, "session")
+			session.Options = &sessions.Options{
+				Path:     "/",
+				MaxAge:   86400,
+				SameSite: http.SameSiteLaxMode,
+				HttpOnly: true,
+				Secure:   true,
+			}
+			session.Values = map[any]any{
+				"attorney": &sesh.AttorneySession{
+					Sub:           "a-sub",
+					Email:         "a-email",
+					LpaID:         "lpa-id",
+					AttorneyID:    "attorney-id",
+					IsReplacement: tc.isReplacement,
+				},
+			}
+
+			sessionStore.
+				On("Get", r, "params").
+				Return(&sessions.Session{
+					Values: map[any]any{
+						"one-login": &sesh.OneLoginSession{
+							State:         "a-state",
+							Nonce:         "a-nonce",
+							Attorney:      true,
+							Identity:      true,
+							LpaID:         "lpa-id",
+							ShareCode:     "a-share-code",
+							AttorneyID:    "attorney-id",
+							IsReplacement: tc.isReplacement,
+						},
+					},
+				}, nil)
+			sessionStore.
+				On("Save", r, w, session).
+				Return(nil)
+
+			ctxMatcher := mock.MatchedBy(func(ctx context.Context) bool {
+				session := page.SessionDataFromContext(ctx)
+
+				return assert.Equal(t, &page.SessionData{LpaID: "lpa-id", ActorType: page.ActorTypeAttorney, Subject: "a-sub"}, session)
+			})
+
+			shareCodeStore := &mockShareCodeStore{}
+			shareCodeStore.
+				On("Redeem", ctxMatcher, "a-share-code").
+				Return(page.ShareCodeData{}, nil)
+
+			lpaStore := &mockLpaStore{}
+			lpaStore.
+				On("Link", ctxMatcher).
+				Return(nil)
+			lpaStore.
+				On("Get", ctxMatcher).
+				Return(tc.lpa, nil)
+			lpaStore.
+				On("Put", ctxMatcher, tc.expectedLpa).
+				Return(nil)
+
+			oneLoginClient := &mockOneLoginClient{}
+			oneLoginClient.
+				On("Exchange", r.Context(), "a-code", "a-nonce").
+				Return("a-jwt", nil)
+			oneLoginClient.
+				On("UserInfo", r.Context(), "a-jwt").
+				Return(userInfo, nil)
+			oneLoginClient.
+				On("ParseIdentityClaim", ctxMatcher, userInfo).
+				Return(userData, nil)
+
+			template := &mockTemplate{}
+			template.
+				On("Func", w, &loginCallbackData{
+					App:         appData,
+					FullName:    "John Doe",
+					ConfirmedAt: now,
+				}).
+				Return(nil)
+
+			err := LoginCallback(template.Func, oneLoginClient, sessionStore, lpaStore, shareCodeStore)(appData, w, r)
+			resp := w.Result()
+
+			assert.Nil(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			mock.AssertExpectationsForObjects(t, lpaStore, oneLoginClient, template)
+		})
+	}
+}
+
+func TestGetLoginCallbackWhenIdentityAlreadyConfirmed(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/?code=a-code", nil)
+	now := time.Date(2020, time.January, 2, 3, 4, 5, 6, time.UTC)
+
+	sessionStore := &mockSessionsStore{}
+	sessionStore.
+		On("Get", r, "params").
+		Return(&sessions.Session{Values: oneLoginSessionValues}, nil)
+	sessionStore.
+		On("Save", r, w, mock.Anything).
+		Return(nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Redeem", mock.Anything, "a-share-code").
+		Return(page.ShareCodeData{}, nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Link", mock.Anything).
+		Return(nil)
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&page.Lpa{
+			Attorneys: actor.Attorneys{{ID: "attorney-id"}},
+			AttorneyProvidedDetails: map[string]page.AttorneyProvidedDetails{
+				"attorney-id": {IdentityUserData: identity.UserData{OK: true, FullName: "John Doe", RetrievedAt: now}},
+			},
+		}, nil)
+
+	oneLoginClient := &mockOneLoginClient{}
+	oneLoginClient.
+		On("Exchange", mock.Anything, mock.Anything, mock.Anything).
+		Return("a-jwt", nil)
+	oneLoginClient.
+		On("UserInfo", mock.Anything, mock.Anything).
+		Return(onelogin.UserInfo{Sub: "a-sub"}, nil)
+
+	template := &mockTemplate{}
+	template.
+		On("Func", w, &loginCallbackData{
+			App:         appData,
+			FullName:    "John Doe",
+			ConfirmedAt: now,
+		}).
+		Return(nil)
+
+	err := LoginCallback(template.Func, oneLoginClient, sessionStore, lpaStore, shareCodeStore)(appData, w, r)
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, lpaStore, oneLoginClient, template)
+}
+
+func TestGetLoginCallbackWhenIdentityNotConfirmed(t *testing.T) {
+	testCases := map[string]struct {
+		userData identity.UserData
+		url      string
+		error    error
+	}{
+		"not ok": {
+			url: "/?code=a-code",
+		},
+		"errored": {
+			url:      "/?code=a-code",
+			userData: identity.UserData{OK: true},
+			error:    expectedError,
+		},
+		"provider access denied": {
+			url:      "/?error=access_denied",
+			userData: identity.UserData{OK: true},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodGet, tc.url, nil)
+
+			shareCodeStore := &mockShareCodeStore{}
+			shareCodeStore.
+				On("Redeem", mock.Anything, "a-share-code").
+				Return(page.ShareCodeData{}, nil)
+
+			lpaStore := &mockLpaStore{}
+			lpaStore.
+				On("Link", mock.Anything).
+				Return(nil)
+			lpaStore.
+				On("Get", mock.Anything).
+				Return(&page.Lpa{Attorneys: actor.Attorneys{{ID: "attorney-id"}}}, nil)
+
+			sessionStore := &mockSessionsStore{}
+			sessionStore.
+				On("Get", mock.Anything, "params").
+				Return(&sessions.Session{Values: oneLoginSessionValues}, nil)
+
+			oneLoginClient := &mockOneLoginClient{}
+			oneLoginClient.
+				On("Exchange", mock.Anything, mock.Anything, mock.Anything).
+				Return("a-jwt", nil)
+			oneLoginClient.
+				On("UserInfo", mock.Anything, mock.Anything).
+				Return(onelogin.UserInfo{CoreIdentityJWT: "an-identity-jwt"}, nil)
+			oneLoginClient.
+				On("ParseIdentityClaim", mock.Anything, mock.Anything).
+				Return(tc.userData, tc.error)
+
+			template := &mockTemplate{}
+			template.
+				On("Func", w, &loginCallbackData{
+					App:             appData,
+					CouldNotConfirm: true,
+				}).
+				Return(nil)
+
+			err := LoginCallback(template.Func, oneLoginClient, sessionStore, lpaStore, shareCodeStore)(appData, w, r)
+			resp := w.Result()
+
+			assert.Equal(t, tc.error, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+		})
+	}
+}
+
+func TestGetLoginCallbackWhenIncorrectSession(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/?code=a-code", nil)
+
+	sessionStore := &mockSessionsStore{}
+	sessionStore.
+		On("Get", mock.Anything, "params").
+		Return(&sessions.Session{
+			Values: map[any]any{
+				"one-login": &sesh.OneLoginSession{
+					State:               "a-state",
+					Nonce:               "a-nonce",
+					CertificateProvider: true,
+					Identity:            true,
+					LpaID:               "lpa-id",
+					ShareCode:           "a-share-code",
+				},
+			},
+		}, nil)
+
+	err := LoginCallback(nil, nil, sessionStore, nil, nil)(appData, w, r)
+
+	assert.NotNil(t, err)
+}
+
+func TestGetLoginCallbackWhenAttorneyNotOnLpa(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/?code=a-code", nil)
+
+	sessionStore := &mockSessionsStore{}
+	sessionStore.
+		On("Get", mock.Anything, "params").
+		Return(&sessions.Session{Values: oneLoginSessionValues}, nil)
+
+	oneLoginClient := &mockOneLoginClient{}
+	oneLoginClient.
+		On("Exchange", mock.Anything, mock.Anything, mock.Anything).
+		Return("a-jwt", nil)
+	oneLoginClient.
+		On("UserInfo", mock.Anything, mock.Anything).
+		Return(onelogin.UserInfo{Sub: "a-sub"}, nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Redeem", mock.Anything, "a-share-code").
+		Return(page.ShareCodeData{}, nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Link", mock.Anything).
+		Return(nil)
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&page.Lpa{ReplacementAttorneys: actor.Attorneys{{ID: "attorney-id"}}}, nil)
+
+	err := LoginCallback(nil, oneLoginClient, sessionStore, lpaStore, shareCodeStore)(appData, w, r)
+
+	assert.NotNil(t, err)
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestGetLoginCallbackWhenExchangeError(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/?code=a-code", nil)
+
+	sessionStore := &mockSessionsStore{}
+	sessionStore.
+		On("Get", mock.Anything, "params").
+		Return(&sessions.Session{Values: oneLoginSessionValues}, nil)
+
+	oneLoginClient := &mockOneLoginClient{}
+	oneLoginClient.
+		On("Exchange", mock.Anything, mock.Anything, mock.Anything).
+		Return("", expectedError)
+
+	err := LoginCallback(nil, oneLoginClient, sessionStore, nil, nil)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, oneLoginClient)
+}
+
+func TestGetLoginCallbackWhenUserInfoError(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/?code=a-code", nil)
+
+	sessionStore := &mockSessionsStore{}
+	sessionStore.
+		On("Get", mock.Anything, "params").
+		Return(&sessions.Session{Values: oneLoginSessionValues}, nil)
+
+	oneLoginClient := &mockOneLoginClient{}
+	oneLoginClient.
+		On("Exchange", mock.Anything, mock.Anything, mock.Anything).
+		Return("a-jwt", nil)
+	oneLoginClient.
+		On("UserInfo", mock.Anything, mock.Anything).
+		Return(onelogin.UserInfo{}, expectedError)
+
+	err := LoginCallback(nil, oneLoginClient, sessionStore, nil, nil)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, oneLoginClient)
+}
+
+func TestGetLoginCallbackWhenRedeemError(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/?code=a-code", nil)
+
+	sessionStore := &mockSessionsStore{}
+	sessionStore.
+		On("Get", mock.Anything, "params").
+		Return(&sessions.Session{Values: oneLoginSessionValues}, nil)
+
+	oneLoginClient := &mockOneLoginClient{}
+	oneLoginClient.
+		On("Exchange", mock.Anything, mock.Anything, mock.Anything).
+		Return("a-jwt", nil)
+	oneLoginClient.
+		On("UserInfo", mock.Anything, mock.Anything).
+		Return(onelogin.UserInfo{Sub: "a-sub"}, nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Redeem", mock.Anything, "a-share-code").
+		Return(page.ShareCodeData{}, expectedError)
+
+	err := LoginCallback(nil, oneLoginClient, sessionStore, nil, shareCodeStore)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, sessionStore, shareCodeStore)
+}
+
+func TestGetLoginCallbackWhenLinkError(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/?code=a-code", nil)
+
+	sessionStore := &mockSessionsStore{}
+	sessionStore.
+		On("Get", mock.Anything, "params").
+		Return(&sessions.Session{Values: oneLoginSessionValues}, nil)
+
+	oneLoginClient := &mockOneLoginClient{}
+	oneLoginClient.
+		On("Exchange", mock.Anything, mock.Anything, mock.Anything).
+		Return("a-jwt", nil)
+	oneLoginClient.
+		On("UserInfo", mock.Anything, mock.Anything).
+		Return(onelogin.UserInfo{Sub: "a-sub"}, nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Redeem", mock.Anything, "a-share-code").
+		Return(page.ShareCodeData{}, nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.On("Link", mock.Anything).Return(expectedError)
+
+	err := LoginCallback(nil, oneLoginClient, sessionStore, lpaStore, shareCodeStore)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, sessionStore, lpaStore)
+}
+
+func TestGetLoginCallbackWhenGetDataStoreError(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/?code=a-code", nil)
+
+	sessionStore := &mockSessionsStore{}
+	sessionStore.
+		On("Get", mock.Anything, "params").
+		Return(&sessions.Session{Values: oneLoginSessionValues}, nil)
+
+	oneLoginClient := &mockOneLoginClient{}
+	oneLoginClient.
+		On("Exchange", mock.Anything, mock.Anything, mock.Anything).
+		Return("a-jwt", nil)
+	oneLoginClient.
+		On("UserInfo", mock.Anything, mock.Anything).
+		Return(onelogin.UserInfo{Sub: "a-sub"}, nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Redeem", mock.Anything, "a-share-code").
+		Return(page.ShareCodeData{}, nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.On("Link", mock.Anything).Return(nil)
+	lpaStore.On("Get", mock.Anything).Return(&page.Lpa{}, expectedError)
+
+	err := LoginCallback(nil, oneLoginClient, sessionStore, lpaStore, shareCodeStore)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, sessionStore, lpaStore)
+}
+
+func TestGetLoginCallbackWhenPutDataStoreError(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/?code=a-code", nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Redeem", mock.Anything, "a-share-code").
+		Return(page.ShareCodeData{}, nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Link", mock.Anything).
+		Return(nil)
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&page.Lpa{Attorneys: actor.Attorneys{{ID: "attorney-id"}}}, nil)
+	lpaStore.
+		On("Put", mock.Anything, mock.Anything).
+		Return(expectedError)
+
+	sessionStore := &mockSessionsStore{}
+	sessionStore.
+		On("Get", mock.Anything, "params").
+		Return(&sessions.Session{Values: oneLoginSessionValues}, nil)
+
+	oneLoginClient := &mockOneLoginClient{}
+	oneLoginClient.
+		On("Exchange", mock.Anything, mock.Anything, mock.Anything).
+		Return("a-jwt", nil)
+	oneLoginClient.
+		On("UserInfo", mock.Anything, mock.Anything).
+		Return(onelogin.UserInfo{}, nil)
+	oneLoginClient.
+		On("ParseIdentityClaim", mock.Anything, mock.Anything).
+		Return(identity.UserData{OK: true}, nil)
+
+	err := LoginCallback(nil, oneLoginClient, sessionStore, lpaStore, shareCodeStore)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore, oneLoginClient)
+}
+
+func TestGetLoginCallbackWhenSessionSaveError(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/?code=a-code", nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Redeem", mock.Anything, "a-share-code").
+		Return(page.ShareCodeData{}, nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Link", mock.Anything).
+		Return(nil)
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&page.Lpa{Attorneys: actor.Attorneys{{ID: "attorney-id"}}}, nil)
+	lpaStore.
+		On("Put", mock.Anything, mock.Anything).
+		Return(nil)
+
+	sessionStore := &mockSessionsStore{}
+	sessionStore.
+		On("Get", mock.Anything, "params").
+		Return(&sessions.Session{Values: oneLoginSessionValues}, nil)
+	sessionStore.
+		On("Save", mock.Anything, mock.Anything, mock.Anything).
+		Return(expectedError)
+
+	oneLoginClient := &mockOneLoginClient{}
+	oneLoginClient.
+		On("Exchange", mock.Anything, mock.Anything, mock.Anything).
+		Return("a-jwt", nil)
+	oneLoginClient.
+		On("UserInfo", mock.Anything, mock.Anything).
+		Return(onelogin.UserInfo{}, nil)
+	oneLoginClient.
+		On("ParseIdentityClaim", mock.Anything, mock.Anything).
+		Return(identity.UserData{OK: true}, nil)
+
+	err := LoginCallback(nil, oneLoginClient, sessionStore, lpaStore, shareCodeStore)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore, oneLoginClient, sessionStore)
+}
+
+func TestPostLoginCallback(t *testing.T) {
+	testCases := map[string]struct {
+		lpa      *page.Lpa
+		redirect string
+	}{
+		"identity confirmed": {
+			lpa: &page.Lpa{
+				AttorneyProvidedDetails: map[string]page.AttorneyProvidedDetails{
+					"attorney-id": {IdentityUserData: identity.UserData{OK: true}},
+				},
+			},
+			redirect: page.Paths.AttorneyReadTheLpa,
+		},
+		"identity not confirmed": {
+			lpa:      &page.Lpa{},
+			redirect: page.Paths.AttorneyIdentityNotConfirmed,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodPost, "/", nil)
+
+			sessionStore := &mockSessionsStore{}
+			sessionStore.
+				On("Get", r, "session").
+				Return(&sessions.Session{
+					Values: map[any]any{
+						"attorney": &sesh.AttorneySession{
+							Sub:        "a-sub",
+							LpaID:      "lpa-id",
+							AttorneyID: "attorney-id",
+						},
+					},
+				}, nil)
+
+			lpaStore := &mockLpaStore{}
+			lpaStore.
+				On("Get", mock.MatchedBy(func(ctx context.Context) bool {
+					return assert.Equal(t, &page.SessionData{LpaID: "lpa-id", ActorType: page.ActorTypeAttorney, Subject: "a-sub"}, page.SessionDataFromContext(ctx))
+				})).
+				Return(tc.lpa, nil)
+
+			err := LoginCallback(nil, nil, sessionStore, lpaStore, nil)(appData, w, r)
+			resp := w.Result()
+
+			assert.Nil(t, err)
+			assert.Equal(t, http.StatusFound, resp.StatusCode)
+			assert.Equal(t, tc.redirect, resp.Header.Get("Location"))
+			mock.AssertExpectationsForObjects(t, sessionStore, lpaStore)
+		})
+	}
+}
+
+func TestPostLoginCallbackWhenSessionError(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", nil)
+
+	sessionStore := &mockSessionsStore{}
+	sessionStore.
+		On("Get", r, "session").
+		Return(&sessions.Session{}, expectedError)
+
+	err := LoginCallback(nil, nil, sessionStore, nil, nil)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+}
@@ -0,0 +1,180 @@
+package attorney
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/localize"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/sesh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var appData = page.AppData{}
+
+func TestAttorneyLogin(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/?share-code=a-share-code", nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Get", r.Context(), "a-share-code").
+		Return(page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeAttorney, ActorID: "attorney-id"}, nil)
+
+	client := &mockOneLoginClient{}
+	client.
+		On("AuthCodeURL", "i am random", "i am random", "cy", true).
+		Return("http://auth")
+
+	sessionsStore := &mockSessionsStore{}
+
+	session := sessions.NewSession(sessionsStore, "params")
+
+	session.Options = &sessions.Options{
+		Path:     "/",
+		MaxAge:   600,
+		SameSite: http.SameSiteLaxMode,
+		HttpOnly: true,
+		Secure:   true,
+	}
+	session.Values = map[any]any{
+		"one-login": &sesh.OneLoginSession{
+			State:      "i am random",
+			Nonce:      "i am random",
+			Locale:     "cy",
+			Attorney:   true,
+			Identity:   true,
+			LpaID:      "lpa-id",
+			ShareCode:  "a-share-code",
+			AttorneyID: "attorney-id",
+		},
+	}
+
+	sessionsStore.
+		On("Save", r, w, session).
+		Return(nil)
+
+	Login(nil, client, sessionsStore, func(int) string { return "i am random" }, shareCodeStore)(page.AppData{Lang: localize.Cy, Paths: page.Paths}, w, r)
+	resp := w.Result()
+
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "http://auth", resp.Header.Get("Location"))
+
+	mock.AssertExpectationsForObjects(t, shareCodeStore, client, sessionsStore)
+}
+
+func TestAttorneyLoginDefaultLocale(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/?share-code=a-share-code", nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Get", r.Context(), "a-share-code").
+		Return(page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeAttorney, ActorID: "attorney-id", IsReplacementAttorney: true}, nil)
+
+	client := &mockOneLoginClient{}
+	client.
+		On("AuthCodeURL", "i am random", "i am random", "en", true).
+		Return("http://auth")
+
+	sessionsStore := &mockSessionsStore{}
+
+	session := sessions.NewSession(sessionsStore, "params")
+
+	session.Options = &sessions.Options{
+		Path:     "/",
+		MaxAge:   600,
+		SameSite: http.SameSiteLaxMode,
+		HttpOnly: true,
+		Secure:   true,
+	}
+	session.Values = map[any]any{
+		"one-login": &sesh.OneLoginSession{
+			State:         "i am random",
+			Nonce:         "i am random",
+			Locale:        "en",
+			Attorney:      true,
+			Identity:      true,
+			LpaID:         "lpa-id",
+			ShareCode:     "a-share-code",
+			AttorneyID:    "attorney-id",
+			IsReplacement: true,
+		},
+	}
+
+	sessionsStore.
+		On("Save", r, w, session).
+		Return(nil)
+
+	Login(nil, client, sessionsStore, func(int) string { return "i am random" }, shareCodeStore)(appData, w, r)
+	resp := w.Result()
+
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "http://auth", resp.Header.Get("Location"))
+
+	mock.AssertExpectationsForObjects(t, shareCodeStore, client, sessionsStore)
+}
+
+func TestAttorneyLoginWhenStoreSaveError(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/?share-code=a-share-code", nil)
+
+	logger := &mockLogger{}
+	logger.
+		On("Print", expectedError)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Get", r.Context(), "a-share-code").
+		Return(page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeAttorney, ActorID: "attorney-id"}, nil)
+
+	client := &mockOneLoginClient{}
+	client.
+		On("AuthCodeURL", "i am random", "i am random", "en", true).
+		Return("http://auth?locale=en")
+
+	sessionsStore := &mockSessionsStore{}
+	sessionsStore.
+		On("Save", r, w, mock.Anything).
+		Return(expectedError)
+
+	Login(logger, client, sessionsStore, func(int) string { return "i am random" }, shareCodeStore)(appData, w, r)
+	resp := w.Result()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	mock.AssertExpectationsForObjects(t, logger, shareCodeStore, client, sessionsStore)
+}
+
+func TestAttorneyLoginWhenGettingShareCodeErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/?share-code=a-share-code", nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Get", r.Context(), "a-share-code").
+		Return(page.ShareCodeData{}, expectedError)
+
+	err := Login(nil, nil, nil, nil, shareCodeStore)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, shareCodeStore)
+}
+
+func TestAttorneyLoginWhenShareCodeNotForAttorney(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/?share-code=a-share-code", nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Get", r.Context(), "a-share-code").
+		Return(page.ShareCodeData{LpaID: "lpa-id"}, nil)
+
+	err := Login(nil, nil, nil, nil, shareCodeStore)(appData, w, r)
+
+	assert.NotNil(t, err)
+	mock.AssertExpectationsForObjects(t, shareCodeStore)
+}
@@ -0,0 +1,92 @@
+package attorney
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ministryofjustice/opg-go-common/template"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/random"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/sesh"
+)
+
+func Register(
+	rootMux *http.ServeMux,
+	logger page.Logger,
+	tmpls template.Templates,
+	sessionStore sesh.Store,
+	lpaStore page.LpaStore,
+	oneLoginClient page.OneLoginClient,
+	shareCodeStore page.ShareCodeStore,
+) {
+	handleRoot := makeHandle(rootMux, logger, sessionStore, None)
+
+	handleRoot(page.Paths.AttorneyStart, None,
+		Start(tmpls.Get("attorney_start.gohtml"), shareCodeStore))
+	handleRoot(page.Paths.AttorneyLogin, None,
+		Login(logger, oneLoginClient, sessionStore, random.String, shareCodeStore))
+	handleRoot(page.Paths.AttorneyLoginCallback, None,
+		LoginCallback(tmpls.Get("identity_with_one_login_callback.gohtml"), oneLoginClient, sessionStore, lpaStore, shareCodeStore))
+	handleRoot(page.Paths.AttorneyIdentityNotConfirmed, RequireSession,
+		page.Guidance(tmpls.Get("attorney_identity_not_confirmed.gohtml"), "", nil))
+	handleRoot(page.Paths.AttorneyReadTheLpa, RequireSession,
+		page.Guidance(tmpls.Get("attorney_read_the_lpa.gohtml"), page.Paths.AttorneySign, lpaStore))
+	handleRoot(page.Paths.AttorneySign, RequireSession,
+		Sign(tmpls.Get("attorney_sign.gohtml"), lpaStore, sessionStore, time.Now))
+	handleRoot(page.Paths.AttorneyWhatHappensNext, RequireSession,
+		page.Guidance(tmpls.Get("attorney_what_happens_next.gohtml"), "", lpaStore))
+}
+
+type handleOpt byte
+
+const (
+	None handleOpt = 1 << iota
+	RequireSession
+	CanGoBack
+)
+
+func makeHandle(mux *http.ServeMux, logger page.Logger, store sesh.Store, defaultOptions handleOpt) func(string, handleOpt, page.Handler) {
+	return func(path string, opt handleOpt, h page.Handler) {
+		opt = opt | defaultOptions
+		h = page.WithLpaUnitOfWork(h)
+
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			appData := page.AppDataFromContext(ctx)
+			appData.Page = path
+			appData.CanGoBack = opt&CanGoBack != 0
+
+			if opt&RequireSession != 0 {
+				session, err := sesh.Attorney(store, r)
+				if err != nil {
+					logger.Print(err)
+					http.Redirect(w, r, page.Paths.Start, http.StatusFound)
+					return
+				}
+
+				appData.LpaID = session.LpaID
+
+				ctx = page.ContextWithSessionData(ctx, &page.SessionData{
+					LpaID:     appData.LpaID,
+					ActorType: page.ActorTypeAttorney,
+					Subject:   session.Sub,
+				})
+			}
+
+			if err := h(appData, w, r.WithContext(page.ContextWithAppData(ctx, appData))); err != nil {
+				if errors.Is(err, page.ErrLpaWithdrawn) {
+					http.Redirect(w, r, appData.BuildUrl(path)+"?lpaWithdrawn=1", http.StatusFound)
+					return
+				}
+
+				str := fmt.Sprintf("Error rendering page for path '%s': %s", path, err.Error())
+
+				logger.Print(str)
+				http.Error(w, "Encountered an error", http.StatusInternalServerError)
+			}
+		})
+	}
+}
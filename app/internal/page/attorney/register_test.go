@@ -0,0 +1,245 @@
+package attorney
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/dynamo"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/sesh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var expectedError = errors.New("err")
+
+type mockLogger struct {
+	mock.Mock
+}
+
+func (m *mockLogger) Print(v ...any) {
+	m.Called(v...)
+}
+
+type mockSessionsStore struct {
+	mock.Mock
+}
+
+func (m *mockSessionsStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	args := m.Called(r, name)
+	return args.Get(0).(*sessions.Session), args.Error(1)
+}
+
+func (m *mockSessionsStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	args := m.Called(r, name)
+	return args.Get(0).(*sessions.Session), args.Error(1)
+}
+
+func (m *mockSessionsStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	args := m.Called(r, w, session)
+	return args.Error(0)
+}
+
+func TestMakeHandle(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/path?a=b", nil)
+
+	sessionsStore := &mockSessionsStore{}
+	sessionsStore.
+		On("Get", r, "session").
+		Return(&sessions.Session{
+			Values: map[any]any{
+				"attorney": &sesh.AttorneySession{
+					Sub:        "random",
+					LpaID:      "lpa-id",
+					AttorneyID: "attorney-id",
+				},
+			},
+		}, nil)
+
+	mux := http.NewServeMux()
+	handle := makeHandle(mux, nil, sessionsStore, None)
+	handle("/path", RequireSession, func(appData page.AppData, hw http.ResponseWriter, hr *http.Request) error {
+		assert.Equal(t, page.AppData{
+			Page:      "/path",
+			LpaID:     "lpa-id",
+			CanGoBack: false,
+		}, appData)
+
+		assert.Equal(t, &page.SessionData{LpaID: "lpa-id", ActorType: page.ActorTypeAttorney, Subject: "random"}, page.SessionDataFromContext(hr.Context()))
+		hw.WriteHeader(http.StatusTeapot)
+		return nil
+	})
+
+	mux.ServeHTTP(w, r)
+	resp := w.Result()
+
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+	mock.AssertExpectationsForObjects(t, sessionsStore)
+}
+
+func TestMakeHandleExistingSessionData(t *testing.T) {
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "ignored-123"})
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/path?a=b", nil)
+
+	sessionsStore := &mockSessionsStore{}
+	sessionsStore.
+		On("Get", r, "session").
+		Return(&sessions.Session{Values: map[any]any{"attorney": &sesh.AttorneySession{Sub: "random", LpaID: "lpa-id", AttorneyID: "attorney-id"}}}, nil)
+
+	mux := http.NewServeMux()
+	handle := makeHandle(mux, nil, sessionsStore, None)
+	handle("/path", RequireSession|CanGoBack, func(appData page.AppData, hw http.ResponseWriter, hr *http.Request) error {
+		assert.Equal(t, page.AppData{
+			Page:      "/path",
+			CanGoBack: true,
+			LpaID:     "lpa-id",
+		}, appData)
+		assert.Equal(t, &page.SessionData{LpaID: "lpa-id", ActorType: page.ActorTypeAttorney, Subject: "random"}, page.SessionDataFromContext(hr.Context()))
+		hw.WriteHeader(http.StatusTeapot)
+		return nil
+	})
+
+	mux.ServeHTTP(w, r)
+	resp := w.Result()
+
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+	mock.AssertExpectationsForObjects(t, sessionsStore)
+}
+
+func TestMakeHandleErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/path", nil)
+
+	logger := &mockLogger{}
+	logger.
+		On("Print", fmt.Sprintf("Error rendering page for path '%s': %s", "/path", expectedError.Error()))
+
+	mux := http.NewServeMux()
+	handle := makeHandle(mux, logger, nil, None)
+	handle("/path", None, func(appData page.AppData, hw http.ResponseWriter, hr *http.Request) error {
+		return expectedError
+	})
+
+	mux.ServeHTTP(w, r)
+	resp := w.Result()
+
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
+
+func TestMakeHandleWhenConflict(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, page.Paths.AttorneySign, nil)
+
+	mux := http.NewServeMux()
+	handle := makeHandle(mux, nil, nil, None)
+	handle(page.Paths.AttorneySign, None, func(appData page.AppData, hw http.ResponseWriter, hr *http.Request) error {
+		if hr.Method == http.MethodPost {
+			return dynamo.ConflictError{PK: "a", SK: "b"}
+		}
+
+		assert.True(t, appData.LpaChanged)
+		hw.Write([]byte("changed"))
+		return nil
+	})
+
+	mux.ServeHTTP(w, r)
+	resp := w.Result()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "changed", w.Body.String())
+}
+
+func TestMakeHandleWhenWithdrawn(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, page.Paths.AttorneySign, nil)
+
+	mux := http.NewServeMux()
+	handle := makeHandle(mux, nil, nil, None)
+	handle(page.Paths.AttorneySign, None, func(appData page.AppData, hw http.ResponseWriter, hr *http.Request) error {
+		return page.ErrLpaWithdrawn
+	})
+
+	mux.ServeHTTP(w, r)
+	resp := w.Result()
+
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, page.Paths.AttorneySign+"?lpaWithdrawn=1", resp.Header.Get("Location"))
+}
+
+func TestMakeHandleSessionError(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/path", nil)
+
+	logger := &mockLogger{}
+	logger.
+		On("Print", expectedError)
+
+	sessionsStore := &mockSessionsStore{}
+	sessionsStore.
+		On("Get", r, "session").
+		Return(&sessions.Session{}, expectedError)
+
+	mux := http.NewServeMux()
+	handle := makeHandle(mux, logger, sessionsStore, None)
+	handle("/path", RequireSession, func(appData page.AppData, hw http.ResponseWriter, hr *http.Request) error { return nil })
+
+	mux.ServeHTTP(w, r)
+	resp := w.Result()
+
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, page.Paths.Start, resp.Header.Get("Location"))
+	mock.AssertExpectationsForObjects(t, sessionsStore, logger)
+}
+
+func TestMakeHandleSessionMissing(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/path", nil)
+
+	logger := &mockLogger{}
+	logger.
+		On("Print", sesh.MissingSessionError("attorney"))
+
+	sessionsStore := &mockSessionsStore{}
+	sessionsStore.
+		On("Get", r, "session").
+		Return(&sessions.Session{Values: map[any]any{}}, nil)
+
+	mux := http.NewServeMux()
+	handle := makeHandle(mux, logger, sessionsStore, None)
+	handle("/path", RequireSession, func(appData page.AppData, hw http.ResponseWriter, hr *http.Request) error { return nil })
+
+	mux.ServeHTTP(w, r)
+	resp := w.Result()
+
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, page.Paths.Start, resp.Header.Get("Location"))
+	mock.AssertExpectationsForObjects(t, sessionsStore, logger)
+}
+
+func TestMakeHandleNoSessionRequired(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/path", nil)
+
+	mux := http.NewServeMux()
+	handle := makeHandle(mux, nil, nil, None)
+	handle("/path", None, func(appData page.AppData, hw http.ResponseWriter, hr *http.Request) error {
+		assert.Equal(t, page.AppData{
+			Page: "/path",
+		}, appData)
+		assert.Equal(t, page.AppData{Page: "/path"}, page.AppDataFromContext(hr.Context()))
+		hw.WriteHeader(http.StatusTeapot)
+		return nil
+	})
+
+	mux.ServeHTTP(w, r)
+	resp := w.Result()
+
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+}
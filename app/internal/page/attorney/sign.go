@@ -0,0 +1,100 @@
+package attorney
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ministryofjustice/opg-go-common/template"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/sesh"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/validation"
+)
+
+type signData struct {
+	App           page.AppData
+	Errors        validation.List
+	Lpa           *page.Lpa
+	Attorney      actor.Attorney
+	IsReplacement bool
+	Form          *signForm
+}
+
+func Sign(tmpl template.Template, lpaStore page.LpaStore, sessionStore sesh.Store, now func() time.Time) page.Handler {
+	return func(appData page.AppData, w http.ResponseWriter, r *http.Request) error {
+		lpa, err := lpaStore.Get(r.Context())
+		if err != nil {
+			return err
+		}
+
+		attorneySession, err := sesh.Attorney(sessionStore, r)
+		if err != nil {
+			return err
+		}
+
+		attorney, ok := getAttorney(lpa, attorneySession.AttorneyID, attorneySession.IsReplacement)
+		if !ok {
+			return errors.New("attorney not found on lpa")
+		}
+
+		details := lpa.GetAttorneyProvidedDetails(attorneySession.AttorneyID, attorneySession.IsReplacement)
+
+		// An attorney can only sign once their identity has been confirmed, and
+		// once the donor has submitted the LPA.
+		if !details.IdentityUserData.OK {
+			return appData.Redirect(w, r, lpa, page.Paths.AttorneyIdentityNotConfirmed)
+		}
+
+		if lpa.Submitted.IsZero() {
+			return appData.Redirect(w, r, lpa, page.Paths.AttorneyReadTheLpa)
+		}
+
+		data := &signData{
+			App:           appData,
+			Lpa:           lpa,
+			Attorney:      attorney,
+			IsReplacement: attorneySession.IsReplacement,
+			Form: &signForm{
+				Confirm: !details.Confirmed.IsZero(),
+			},
+		}
+
+		if r.Method == http.MethodPost {
+			data.Form = readSignForm(r)
+			data.Errors = data.Form.Validate()
+
+			if data.Errors.None() {
+				details.Confirmed = now()
+				lpa.PutAttorneyProvidedDetails(attorneySession.AttorneyID, attorneySession.IsReplacement, details)
+
+				if err := lpaStore.Put(r.Context(), lpa); err != nil {
+					return err
+				}
+
+				return appData.Redirect(w, r, lpa, page.Paths.AttorneyWhatHappensNext)
+			}
+		}
+
+		return tmpl(w, data)
+	}
+}
+
+type signForm struct {
+	Confirm bool
+}
+
+func readSignForm(r *http.Request) *signForm {
+	return &signForm{
+		Confirm: page.PostFormString(r, "confirm") == "1",
+	}
+}
+
+func (f *signForm) Validate() validation.List {
+	var errors validation.List
+
+	errors.Bool("confirm", "attorneySignConfirm", f.Confirm,
+		validation.Selected())
+
+	return errors
+}
@@ -0,0 +1,399 @@
+package attorney
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/identity"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/sesh"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const formUrlEncoded = "application/x-www-form-urlencoded"
+
+var (
+	submitted         = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	identityConfirmed = page.AttorneyProvidedDetails{IdentityUserData: identity.UserData{OK: true}}
+)
+
+func attorneySessionStore(r *http.Request, isReplacement bool) *mockSessionsStore {
+	sessionStore := &mockSessionsStore{}
+	sessionStore.
+		On("Get", r, "session").
+		Return(&sessions.Session{
+			Values: map[any]any{
+				"attorney": &sesh.AttorneySession{
+					Sub:           "a-sub",
+					LpaID:         "lpa-id",
+					AttorneyID:    "attorney-id",
+					IsReplacement: isReplacement,
+				},
+			},
+		}, nil)
+
+	return sessionStore
+}
+
+func TestGetSign(t *testing.T) {
+	testCases := map[string]struct {
+		isReplacement bool
+		lpa           *page.Lpa
+	}{
+		"attorney": {
+			lpa: &page.Lpa{
+				Submitted:               submitted,
+				Attorneys:               actor.Attorneys{{ID: "attorney-id", FirstNames: "Bob"}},
+				AttorneyProvidedDetails: map[string]page.AttorneyProvidedDetails{"attorney-id": identityConfirmed},
+			},
+		},
+		"replacement attorney": {
+			isReplacement: true,
+			lpa: &page.Lpa{
+				Submitted:                          submitted,
+				ReplacementAttorneys:               actor.Attorneys{{ID: "attorney-id", FirstNames: "Bob"}},
+				ReplacementAttorneyProvidedDetails: map[string]page.AttorneyProvidedDetails{"attorney-id": identityConfirmed},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+			lpaStore := &mockLpaStore{}
+			lpaStore.
+				On("Get", r.Context()).
+				Return(tc.lpa, nil)
+
+			template := &mockTemplate{}
+			template.
+				On("Func", w, &signData{
+					App:           appData,
+					Lpa:           tc.lpa,
+					Attorney:      actor.Attorney{ID: "attorney-id", FirstNames: "Bob"},
+					IsReplacement: tc.isReplacement,
+					Form:          &signForm{},
+				}).
+				Return(nil)
+
+			err := Sign(template.Func, lpaStore, attorneySessionStore(r, tc.isReplacement), time.Now)(appData, w, r)
+			resp := w.Result()
+
+			assert.Nil(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			mock.AssertExpectationsForObjects(t, lpaStore, template)
+		})
+	}
+}
+
+func TestGetSignWhenAlreadySigned(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpa := &page.Lpa{
+		Submitted: submitted,
+		Attorneys: actor.Attorneys{{ID: "attorney-id"}},
+		AttorneyProvidedDetails: map[string]page.AttorneyProvidedDetails{
+			"attorney-id": {IdentityUserData: identity.UserData{OK: true}, Confirmed: time.Now()},
+		},
+	}
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(lpa, nil)
+
+	template := &mockTemplate{}
+	template.
+		On("Func", w, &signData{
+			App:      appData,
+			Lpa:      lpa,
+			Attorney: actor.Attorney{ID: "attorney-id"},
+			Form:     &signForm{Confirm: true},
+		}).
+		Return(nil)
+
+	err := Sign(template.Func, lpaStore, attorneySessionStore(r, false), time.Now)(appData, w, r)
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, lpaStore, template)
+}
+
+func TestGetSignWhenLpaStoreErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{}, expectedError)
+
+	err := Sign(nil, lpaStore, nil, time.Now)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestGetSignWhenSessionErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{}, nil)
+
+	sessionStore := &mockSessionsStore{}
+	sessionStore.
+		On("Get", r, "session").
+		Return(&sessions.Session{}, expectedError)
+
+	err := Sign(nil, lpaStore, sessionStore, time.Now)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore, sessionStore)
+}
+
+func TestGetSignWhenAttorneyNotFound(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{ReplacementAttorneys: actor.Attorneys{{ID: "attorney-id"}}}, nil)
+
+	err := Sign(nil, lpaStore, attorneySessionStore(r, false), time.Now)(appData, w, r)
+
+	assert.NotNil(t, err)
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestGetSignWhenCannotSign(t *testing.T) {
+	testCases := map[string]struct {
+		lpa      *page.Lpa
+		redirect string
+	}{
+		"identity not confirmed": {
+			lpa: &page.Lpa{
+				Submitted: submitted,
+				Attorneys: actor.Attorneys{{ID: "attorney-id"}},
+			},
+			redirect: page.Paths.AttorneyIdentityNotConfirmed,
+		},
+		"identity could not be confirmed": {
+			lpa: &page.Lpa{
+				Submitted:               submitted,
+				Attorneys:               actor.Attorneys{{ID: "attorney-id"}},
+				AttorneyProvidedDetails: map[string]page.AttorneyProvidedDetails{"attorney-id": {IdentityUserData: identity.UserData{OK: false}}},
+			},
+			redirect: page.Paths.AttorneyIdentityNotConfirmed,
+		},
+		"not submitted": {
+			lpa: &page.Lpa{
+				Attorneys:               actor.Attorneys{{ID: "attorney-id"}},
+				AttorneyProvidedDetails: map[string]page.AttorneyProvidedDetails{"attorney-id": identityConfirmed},
+			},
+			redirect: page.Paths.AttorneyReadTheLpa,
+		},
+	}
+
+	for name, tc := range testCases {
+		for _, method := range []string{http.MethodGet, http.MethodPost} {
+			t.Run(name+"/"+method, func(t *testing.T) {
+				form := url.Values{
+					"confirm": {"1"},
+				}
+
+				w := httptest.NewRecorder()
+				r, _ := http.NewRequest(method, "/", strings.NewReader(form.Encode()))
+				r.Header.Add("Content-Type", formUrlEncoded)
+
+				lpaStore := &mockLpaStore{}
+				lpaStore.
+					On("Get", r.Context()).
+					Return(tc.lpa, nil)
+
+				err := Sign(nil, lpaStore, attorneySessionStore(r, false), time.Now)(appData, w, r)
+				resp := w.Result()
+
+				assert.Nil(t, err)
+				assert.Equal(t, http.StatusFound, resp.StatusCode)
+				assert.Equal(t, tc.redirect, resp.Header.Get("Location"))
+				mock.AssertExpectationsForObjects(t, lpaStore)
+			})
+		}
+	}
+}
+
+func TestPostSign(t *testing.T) {
+	now := time.Date(2020, time.January, 2, 3, 4, 5, 6, time.UTC)
+
+	testCases := map[string]struct {
+		isReplacement bool
+		lpa           *page.Lpa
+		updatedLpa    *page.Lpa
+	}{
+		"attorney": {
+			lpa: &page.Lpa{
+				Submitted:               submitted,
+				Attorneys:               actor.Attorneys{{ID: "attorney-id"}},
+				AttorneyProvidedDetails: map[string]page.AttorneyProvidedDetails{"attorney-id": identityConfirmed},
+			},
+			updatedLpa: &page.Lpa{
+				Submitted: submitted,
+				Attorneys: actor.Attorneys{{ID: "attorney-id"}},
+				AttorneyProvidedDetails: map[string]page.AttorneyProvidedDetails{
+					"attorney-id": {IdentityUserData: identity.UserData{OK: true}, Confirmed: now},
+				},
+			},
+		},
+		"replacement attorney": {
+			isReplacement: true,
+			lpa: &page.Lpa{
+				Submitted:                          submitted,
+				ReplacementAttorneys:               actor.Attorneys{{ID: "attorney-id"}},
+				ReplacementAttorneyProvidedDetails: map[string]page.AttorneyProvidedDetails{"attorney-id": identityConfirmed},
+			},
+			updatedLpa: &page.Lpa{
+				Submitted:            submitted,
+				ReplacementAttorneys: actor.Attorneys{{ID: "attorney-id"}},
+				ReplacementAttorneyProvidedDetails: map[string]page.AttorneyProvidedDetails{
+					"attorney-id": {IdentityUserData: identity.UserData{OK: true}, Confirmed: now},
+				},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			form := url.Values{
+				"confirm": {"1"},
+			}
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+			r.Header.Add("Content-Type", formUrlEncoded)
+
+			lpaStore := &mockLpaStore{}
+			lpaStore.
+				On("Get", r.Context()).
+				Return(tc.lpa, nil)
+			lpaStore.
+				On("Put", r.Context(), tc.updatedLpa).
+				Return(nil)
+
+			err := Sign(nil, lpaStore, attorneySessionStore(r, tc.isReplacement), func() time.Time { return now })(appData, w, r)
+			resp := w.Result()
+
+			assert.Nil(t, err)
+			assert.Equal(t, http.StatusFound, resp.StatusCode)
+			assert.Equal(t, page.Paths.AttorneyWhatHappensNext, resp.Header.Get("Location"))
+			mock.AssertExpectationsForObjects(t, lpaStore)
+		})
+	}
+}
+
+func TestPostSignWhenValidationErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(""))
+	r.Header.Add("Content-Type", formUrlEncoded)
+
+	lpa := &page.Lpa{
+		Submitted:               submitted,
+		Attorneys:               actor.Attorneys{{ID: "attorney-id"}},
+		AttorneyProvidedDetails: map[string]page.AttorneyProvidedDetails{"attorney-id": identityConfirmed},
+	}
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(lpa, nil)
+
+	template := &mockTemplate{}
+	template.
+		On("Func", w, &signData{
+			App:      appData,
+			Lpa:      lpa,
+			Attorney: actor.Attorney{ID: "attorney-id"},
+			Form:     &signForm{},
+			Errors:   validation.With("confirm", validation.SelectError{Label: "attorneySignConfirm"}),
+		}).
+		Return(nil)
+
+	err := Sign(template.Func, lpaStore, attorneySessionStore(r, false), time.Now)(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	mock.AssertExpectationsForObjects(t, lpaStore, template)
+}
+
+func TestPostSignWhenLpaStorePutErrors(t *testing.T) {
+	form := url.Values{
+		"confirm": {"1"},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Add("Content-Type", formUrlEncoded)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{
+			Submitted:               submitted,
+			Attorneys:               actor.Attorneys{{ID: "attorney-id"}},
+			AttorneyProvidedDetails: map[string]page.AttorneyProvidedDetails{"attorney-id": identityConfirmed},
+		}, nil)
+	lpaStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(expectedError)
+
+	err := Sign(nil, lpaStore, attorneySessionStore(r, false), time.Now)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestReadSignForm(t *testing.T) {
+	form := url.Values{
+		"confirm": {"1"},
+	}
+
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Add("Content-Type", formUrlEncoded)
+
+	assert.Equal(t, &signForm{Confirm: true}, readSignForm(r))
+}
+
+func TestSignFormValidate(t *testing.T) {
+	testCases := map[string]struct {
+		form   *signForm
+		errors validation.List
+	}{
+		"valid": {
+			form: &signForm{Confirm: true},
+		},
+		"missing": {
+			form:   &signForm{},
+			errors: validation.With("confirm", validation.SelectError{Label: "attorneySignConfirm"}),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.errors, tc.form.Validate())
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package attorney
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/ministryofjustice/opg-go-common/template"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/validation"
+)
+
+type startData struct {
+	App    page.AppData
+	Errors validation.List
+	Start  string
+}
+
+func Start(tmpl template.Template, shareCodeStore page.ShareCodeStore) page.Handler {
+	return func(appData page.AppData, w http.ResponseWriter, r *http.Request) error {
+		shareCode := r.FormValue("share-code")
+
+		shareCodeData, err := shareCodeStore.Get(r.Context(), shareCode)
+		if err != nil {
+			return err
+		}
+
+		if shareCodeData.ActorType != page.ActorTypeAttorney {
+			return errors.New("share code is not for an attorney")
+		}
+
+		data := &startData{
+			App:   appData,
+			Start: page.Paths.AttorneyLogin + "?" + url.Values{"share-code": {shareCode}}.Encode(),
+		}
+
+		return tmpl(w, data)
+	}
+}
@@ -0,0 +1,123 @@
+package attorney
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockShareCodeStore struct {
+	mock.Mock
+}
+
+func (m *mockShareCodeStore) Create(ctx context.Context, data page.ShareCodeData) (string, error) {
+	args := m.Called(ctx, data)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockShareCodeStore) Get(ctx context.Context, shareCode string) (page.ShareCodeData, error) {
+	args := m.Called(ctx, shareCode)
+	return args.Get(0).(page.ShareCodeData), args.Error(1)
+}
+
+func (m *mockShareCodeStore) Redeem(ctx context.Context, shareCode string) (page.ShareCodeData, error) {
+	args := m.Called(ctx, shareCode)
+	return args.Get(0).(page.ShareCodeData), args.Error(1)
+}
+
+func (m *mockShareCodeStore) Revoke(ctx context.Context, lpaID string, actorType page.ActorType, actorID, keepShareCode string) error {
+	return m.Called(ctx, lpaID, actorType, actorID, keepShareCode).Error(0)
+}
+
+func (m *mockShareCodeStore) RevokeAll(ctx context.Context, lpaID string) error {
+	return m.Called(ctx, lpaID).Error(0)
+}
+
+func TestStart(t *testing.T) {
+	testCases := map[string]page.ShareCodeData{
+		"attorney":             {LpaID: "lpa-id", ActorType: page.ActorTypeAttorney, ActorID: "attorney-id"},
+		"replacement attorney": {LpaID: "lpa-id", ActorType: page.ActorTypeAttorney, ActorID: "attorney-id", IsReplacementAttorney: true},
+	}
+
+	for name, shareCodeData := range testCases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodGet, "/?share-code=a-share-code", nil)
+
+			shareCodeStore := &mockShareCodeStore{}
+			shareCodeStore.
+				On("Get", r.Context(), "a-share-code").
+				Return(shareCodeData, nil)
+
+			template := &mockTemplate{}
+			template.
+				On("Func", w, &startData{
+					App:   appData,
+					Start: page.Paths.AttorneyLogin + "?share-code=a-share-code",
+				}).
+				Return(nil)
+
+			err := Start(template.Func, shareCodeStore)(appData, w, r)
+			resp := w.Result()
+
+			assert.Nil(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			mock.AssertExpectationsForObjects(t, shareCodeStore, template)
+		})
+	}
+}
+
+func TestStartWhenShareCodeNotForAttorney(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/?share-code=a-share-code", nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Get", r.Context(), "a-share-code").
+		Return(page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeCertificateProvider}, nil)
+
+	err := Start(nil, shareCodeStore)(appData, w, r)
+
+	assert.NotNil(t, err)
+	mock.AssertExpectationsForObjects(t, shareCodeStore)
+}
+
+func TestStartWhenGettingShareCodeErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/?share-code=a-share-code", nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Get", r.Context(), "a-share-code").
+		Return(page.ShareCodeData{}, expectedError)
+
+	err := Start(nil, shareCodeStore)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, shareCodeStore)
+}
+
+func TestStartWhenTemplateErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/?share-code=a-share-code", nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Get", r.Context(), "a-share-code").
+		Return(page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeAttorney}, nil)
+
+	template := &mockTemplate{}
+	template.
+		On("Func", mock.Anything, mock.Anything).
+		Return(expectedError)
+
+	err := Start(template.Func, shareCodeStore)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, shareCodeStore, template)
+}
@@ -0,0 +1,63 @@
+package page
+
+import (
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// MaxAttorneyInviteAttempts is how many times sending an invite to an attorney
+// is tried before giving up.
+const MaxAttorneyInviteAttempts = 5
+
+// An AttorneyInvite records emailing an attorney, or replacement attorney, the
+// share code they use to sign in once the LPA has been submitted. MessageID is
+// the ID given by Notify once it has accepted the invite, until then Attempts
+// counts the tries that failed.
+type AttorneyInvite struct {
+	AttorneyID    string
+	IsReplacement bool
+	MessageID     string
+	SentAt        time.Time
+	Attempts      int
+	LastAttemptAt time.Time
+	LastError     string
+	Rejected      bool
+}
+
+func (i AttorneyInvite) Sent() bool {
+	return i.MessageID != ""
+}
+
+// GaveUp is true when the invite could not be sent and will not be tried
+// again.
+func (i AttorneyInvite) GaveUp() bool {
+	return !i.Sent() && (i.Rejected || i.Attempts >= MaxAttorneyInviteAttempts)
+}
+
+type AttorneyInvites []AttorneyInvite
+
+func (is AttorneyInvites) Get(attorneyID string, isReplacement bool) (AttorneyInvite, bool) {
+	idx := slices.IndexFunc(is, func(i AttorneyInvite) bool {
+		return i.AttorneyID == attorneyID && i.IsReplacement == isReplacement
+	})
+	if idx == -1 {
+		return AttorneyInvite{AttorneyID: attorneyID, IsReplacement: isReplacement}, false
+	}
+
+	return is[idx], true
+}
+
+// Put replaces the invite for the same attorney, or adds it if there is not
+// one.
+func (is *AttorneyInvites) Put(invite AttorneyInvite) {
+	idx := slices.IndexFunc(*is, func(i AttorneyInvite) bool {
+		return i.AttorneyID == invite.AttorneyID && i.IsReplacement == invite.IsReplacement
+	})
+	if idx == -1 {
+		*is = append(*is, invite)
+		return
+	}
+
+	(*is)[idx] = invite
+}
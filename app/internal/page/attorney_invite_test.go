@@ -0,0 +1,69 @@
+package page
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttorneyInvite(t *testing.T) {
+	testCases := map[string]struct {
+		invite       AttorneyInvite
+		sent, gaveUp bool
+	}{
+		"not tried": {},
+		"failed": {
+			invite: AttorneyInvite{Attempts: 1},
+		},
+		"failed every attempt": {
+			invite: AttorneyInvite{Attempts: MaxAttorneyInviteAttempts},
+			gaveUp: true,
+		},
+		"sent": {
+			invite: AttorneyInvite{MessageID: "a", Attempts: 1},
+			sent:   true,
+		},
+		"rejected": {
+			invite: AttorneyInvite{Attempts: 1, Rejected: true},
+			gaveUp: true,
+		},
+		"sent on last attempt": {
+			invite: AttorneyInvite{MessageID: "a", Attempts: MaxAttorneyInviteAttempts},
+			sent:   true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.sent, tc.invite.Sent())
+			assert.Equal(t, tc.gaveUp, tc.invite.GaveUp())
+		})
+	}
+}
+
+func TestAttorneyInvitesGet(t *testing.T) {
+	invites := AttorneyInvites{{AttorneyID: "1", MessageID: "a"}}
+
+	invite, ok := invites.Get("1", false)
+	assert.True(t, ok)
+	assert.Equal(t, AttorneyInvite{AttorneyID: "1", MessageID: "a"}, invite)
+
+	invite, ok = invites.Get("2", false)
+	assert.False(t, ok)
+	assert.Equal(t, AttorneyInvite{AttorneyID: "2"}, invite)
+}
+
+func TestAttorneyInvitesPut(t *testing.T) {
+	var invites AttorneyInvites
+
+	invites.Put(AttorneyInvite{AttorneyID: "1", Attempts: 1})
+	invites.Put(AttorneyInvite{AttorneyID: "2", Attempts: 1})
+	invites.Put(AttorneyInvite{AttorneyID: "1", IsReplacement: true, Attempts: 1})
+	invites.Put(AttorneyInvite{AttorneyID: "1", MessageID: "a", Attempts: 2})
+
+	assert.Equal(t, AttorneyInvites{
+		{AttorneyID: "1", MessageID: "a", Attempts: 2},
+		{AttorneyID: "2", Attempts: 1},
+		{AttorneyID: "1", IsReplacement: true, Attempts: 1},
+	}, invites)
+}
@@ -29,6 +29,8 @@ func AuthRedirect(logger Logger, oneLoginClient OneLoginClient, store sesh.Store
 
 		if oneLoginSession.CertificateProvider {
 			appData.Redirect(w, r, nil, Paths.CertificateProviderLoginCallback+"?"+r.URL.RawQuery)
+		} else if oneLoginSession.Attorney {
+			appData.Redirect(w, r, nil, Paths.AttorneyLoginCallback+"?"+r.URL.RawQuery)
 		} else if oneLoginSession.Identity {
 			appData.Redirect(w, r, nil, Paths.IdentityWithOneLoginCallback+"?"+r.URL.RawQuery)
 		} else {
@@ -110,11 +110,12 @@ func TestAuthRedirectWithIdentity(t *testing.T) {
 		Return(&sessions.Session{
 			Values: map[any]any{
 				"one-login": &sesh.OneLoginSession{
-					State:    "my-state",
-					Nonce:    "my-nonce",
-					Locale:   "en",
-					Identity: true,
-					LpaID:    "123",
+					State:     "my-state",
+					Nonce:     "my-nonce",
+					Locale:    "en",
+					Identity:  true,
+					LpaID:     "123",
+					ShareCode: "a-share-code",
 				},
 			},
 		}, nil)
@@ -143,8 +144,8 @@ func TestAuthRedirectWithCertificateProvider(t *testing.T) {
 					Locale:              "en",
 					Identity:            true,
 					CertificateProvider: true,
-					SessionID:           "456",
 					LpaID:               "123",
+					ShareCode:           "a-share-code",
 				},
 			},
 		}, nil)
@@ -157,6 +158,37 @@ func TestAuthRedirectWithCertificateProvider(t *testing.T) {
 	mock.AssertExpectationsForObjects(t, sessionsStore)
 }
 
+func TestAuthRedirectWithAttorney(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/?code=auth-code&state=my-state", nil)
+
+	sessionsStore := &mockSessionsStore{}
+
+	sessionsStore.
+		On("Get", r, "params").
+		Return(&sessions.Session{
+			Values: map[any]any{
+				"one-login": &sesh.OneLoginSession{
+					State:      "my-state",
+					Nonce:      "my-nonce",
+					Locale:     "en",
+					Identity:   true,
+					Attorney:   true,
+					LpaID:      "123",
+					ShareCode:  "a-share-code",
+					AttorneyID: "789",
+				},
+			},
+		}, nil)
+
+	AuthRedirect(nil, nil, sessionsStore)(w, r)
+	resp := w.Result()
+
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, Paths.AttorneyLoginCallback+"?code=auth-code&state=my-state", resp.Header.Get("Location"))
+	mock.AssertExpectationsForObjects(t, sessionsStore)
+}
+
 func TestAuthRedirectWithCyLocale(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodGet, "/?code=auth-code&state=my-state", nil)
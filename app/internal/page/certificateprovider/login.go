@@ -1,6 +1,7 @@
 package certificateprovider
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/localize"
@@ -8,8 +9,19 @@ import (
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/sesh"
 )
 
-func Login(logger page.Logger, oneLoginClient page.OneLoginClient, store sesh.Store, randomString func(int) string) page.Handler {
+func Login(logger page.Logger, oneLoginClient page.OneLoginClient, store sesh.Store, randomString func(int) string, shareCodeStore page.ShareCodeStore) page.Handler {
 	return func(appData page.AppData, w http.ResponseWriter, r *http.Request) error {
+		shareCode := r.FormValue("share-code")
+
+		shareCodeData, err := shareCodeStore.Get(r.Context(), shareCode)
+		if err != nil {
+			return err
+		}
+
+		if shareCodeData.ActorType != page.ActorTypeCertificateProvider {
+			return errors.New("share code is not for a certificate provider")
+		}
+
 		locale := "en"
 		if appData.Lang == localize.Cy {
 			locale = "cy"
@@ -20,17 +32,14 @@ func Login(logger page.Logger, oneLoginClient page.OneLoginClient, store sesh.St
 
 		authCodeURL := oneLoginClient.AuthCodeURL(state, nonce, locale, true)
 
-		sessionID := r.FormValue("sessionId")
-		lpaID := r.FormValue("lpaId")
-
 		if err := sesh.SetOneLogin(store, r, w, &sesh.OneLoginSession{
 			State:               state,
 			Nonce:               nonce,
 			Locale:              locale,
 			CertificateProvider: true,
 			Identity:            true,
-			SessionID:           sessionID,
-			LpaID:               lpaID,
+			ShareCode:           shareCode,
+			LpaID:               shareCodeData.LpaID,
 		}); err != nil {
 			logger.Print(err)
 			return nil
@@ -19,7 +19,7 @@ type loginCallbackData struct {
 	CouldNotConfirm bool
 }
 
-func LoginCallback(tmpl template.Template, oneLoginClient page.OneLoginClient, sessionStore sesh.Store, lpaStore page.LpaStore) page.Handler {
+func LoginCallback(tmpl template.Template, oneLoginClient page.OneLoginClient, sessionStore sesh.Store, lpaStore page.LpaStore, shareCodeStore page.ShareCodeStore) page.Handler {
 	return func(appData page.AppData, w http.ResponseWriter, r *http.Request) error {
 		if r.Method == http.MethodPost {
 			certificateProviderSession, err := sesh.CertificateProvider(sessionStore, r)
@@ -28,8 +28,9 @@ func LoginCallback(tmpl template.Template, oneLoginClient page.OneLoginClient, s
 			}
 
 			ctx := page.ContextWithSessionData(r.Context(), &page.SessionData{
-				SessionID: certificateProviderSession.DonorSessionID,
 				LpaID:     certificateProviderSession.LpaID,
+				ActorType: page.ActorTypeCertificateProvider,
+				Subject:   certificateProviderSession.Sub,
 			})
 
 			lpa, err := lpaStore.Get(ctx)
@@ -52,16 +53,6 @@ func LoginCallback(tmpl template.Template, oneLoginClient page.OneLoginClient, s
 			return errors.New("certificate-provider callback with incorrect session")
 		}
 
-		ctx := page.ContextWithSessionData(r.Context(), &page.SessionData{
-			SessionID: oneLoginSession.SessionID,
-			LpaID:     oneLoginSession.LpaID,
-		})
-
-		lpa, err := lpaStore.Get(ctx)
-		if err != nil {
-			return err
-		}
-
 		data := &loginCallbackData{App: appData}
 
 		if r.FormValue("error") == "access_denied" {
@@ -70,12 +61,31 @@ func LoginCallback(tmpl template.Template, oneLoginClient page.OneLoginClient, s
 			return tmpl(w, data)
 		}
 
-		accessToken, err := oneLoginClient.Exchange(ctx, r.FormValue("code"), oneLoginSession.Nonce)
+		accessToken, err := oneLoginClient.Exchange(r.Context(), r.FormValue("code"), oneLoginSession.Nonce)
+		if err != nil {
+			return err
+		}
+
+		userInfo, err := oneLoginClient.UserInfo(r.Context(), accessToken)
 		if err != nil {
 			return err
 		}
 
-		userInfo, err := oneLoginClient.UserInfo(ctx, accessToken)
+		ctx := page.ContextWithSessionData(r.Context(), &page.SessionData{
+			LpaID:     oneLoginSession.LpaID,
+			ActorType: page.ActorTypeCertificateProvider,
+			Subject:   userInfo.Sub,
+		})
+
+		if _, err := shareCodeStore.Redeem(ctx, oneLoginSession.ShareCode); err != nil {
+			return err
+		}
+
+		if err := lpaStore.Link(ctx); err != nil {
+			return err
+		}
+
+		lpa, err := lpaStore.Get(ctx)
 		if err != nil {
 			return err
 		}
@@ -101,10 +111,9 @@ func LoginCallback(tmpl template.Template, oneLoginClient page.OneLoginClient, s
 		}
 
 		if err := sesh.SetCertificateProvider(sessionStore, r, w, &sesh.CertificateProviderSession{
-			Sub:            userInfo.Sub,
-			Email:          userInfo.Email,
-			LpaID:          oneLoginSession.LpaID,
-			DonorSessionID: oneLoginSession.SessionID,
+			Sub:   userInfo.Sub,
+			Email: userInfo.Email,
+			LpaID: oneLoginSession.LpaID,
 		}); err != nil {
 			return err
 		}
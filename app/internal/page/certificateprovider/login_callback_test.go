@@ -60,9 +60,9 @@ func (m *mockLpaStore) Create(ctx context.Context) (*page.Lpa, error) {
 	return args.Get(0).(*page.Lpa), args.Error(1)
 }
 
-func (m *mockLpaStore) GetAll(ctx context.Context) ([]*page.Lpa, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]*page.Lpa), args.Error(1)
+func (m *mockLpaStore) GetPage(ctx context.Context, query page.LpaQuery) (page.LpaPage, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(page.LpaPage), args.Error(1)
 }
 
 func (m *mockLpaStore) Get(ctx context.Context) (*page.Lpa, error) {
@@ -74,6 +74,24 @@ func (m *mockLpaStore) Put(ctx context.Context, v *page.Lpa) error {
 	return m.Called(ctx, v).Error(0)
 }
 
+func (m *mockLpaStore) Delete(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func (m *mockLpaStore) GetAllAs(ctx context.Context, actorType page.ActorType) ([]*page.Lpa, error) {
+	args := m.Called(ctx, actorType)
+	return args.Get(0).([]*page.Lpa), args.Error(1)
+}
+
+func (m *mockLpaStore) Link(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func (m *mockLpaStore) History(ctx context.Context) ([]page.LpaEvent, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]page.LpaEvent), args.Error(1)
+}
+
 func TestGetLoginCallback(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodGet, "/?code=a-code", nil)
@@ -93,10 +111,9 @@ func TestGetLoginCallback(t *testing.T) {
 	}
 	session.Values = map[any]any{
 		"certificate-provider": &sesh.CertificateProviderSession{
-			Sub:            "a-sub",
-			Email:          "a-email",
-			LpaID:          "lpa-id",
-			DonorSessionID: "session-id",
+			Sub:   "a-sub",
+			Email: "a-email",
+			LpaID: "lpa-id",
 		},
 	}
 
@@ -110,7 +127,7 @@ func TestGetLoginCallback(t *testing.T) {
 					CertificateProvider: true,
 					Identity:            true,
 					LpaID:               "lpa-id",
-					SessionID:           "session-id",
+					ShareCode:           "a-share-code",
 				},
 			},
 		}, nil)
@@ -121,10 +138,18 @@ func TestGetLoginCallback(t *testing.T) {
 	ctxMatcher := mock.MatchedBy(func(ctx context.Context) bool {
 		session := page.SessionDataFromContext(ctx)
 
-		return assert.Equal(t, &page.SessionData{SessionID: "session-id", LpaID: "lpa-id"}, session)
+		return assert.Equal(t, &page.SessionData{LpaID: "lpa-id", ActorType: page.ActorTypeCertificateProvider, Subject: "a-sub"}, session)
 	})
 
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Redeem", ctxMatcher, "a-share-code").
+		Return(page.ShareCodeData{}, nil)
+
 	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Link", ctxMatcher).
+		Return(nil)
 	lpaStore.
 		On("Get", ctxMatcher).
 		Return(&page.Lpa{}, nil)
@@ -136,10 +161,10 @@ func TestGetLoginCallback(t *testing.T) {
 
 	oneLoginClient := &mockOneLoginClient{}
 	oneLoginClient.
-		On("Exchange", ctxMatcher, "a-code", "a-nonce").
+		On("Exchange", r.Context(), "a-code", "a-nonce").
 		Return("a-jwt", nil)
 	oneLoginClient.
-		On("UserInfo", ctxMatcher, "a-jwt").
+		On("UserInfo", r.Context(), "a-jwt").
 		Return(userInfo, nil)
 	oneLoginClient.
 		On("ParseIdentityClaim", ctxMatcher, userInfo).
@@ -154,7 +179,7 @@ func TestGetLoginCallback(t *testing.T) {
 		}).
 		Return(nil)
 
-	err := LoginCallback(template.Func, oneLoginClient, sessionStore, lpaStore)(appData, w, r)
+	err := LoginCallback(template.Func, oneLoginClient, sessionStore, lpaStore, shareCodeStore)(appData, w, r)
 	resp := w.Result()
 
 	assert.Nil(t, err)
@@ -188,7 +213,15 @@ func TestGetLoginCallbackWhenIdentityNotConfirmed(t *testing.T) {
 			r, _ := http.NewRequest(http.MethodGet, tc.url, nil)
 			userInfo := onelogin.UserInfo{CoreIdentityJWT: "an-identity-jwt"}
 
+			shareCodeStore := &mockShareCodeStore{}
+			shareCodeStore.
+				On("Redeem", mock.Anything, "a-share-code").
+				Return(page.ShareCodeData{}, nil)
+
 			lpaStore := &mockLpaStore{}
+			lpaStore.
+				On("Link", mock.Anything).
+				Return(nil)
 			lpaStore.
 				On("Get", mock.Anything).
 				Return(&page.Lpa{}, nil)
@@ -204,7 +237,7 @@ func TestGetLoginCallbackWhenIdentityNotConfirmed(t *testing.T) {
 							CertificateProvider: true,
 							Identity:            true,
 							LpaID:               "lpa-id",
-							SessionID:           "session-id",
+							ShareCode:           "a-share-code",
 						},
 					},
 				}, nil)
@@ -228,7 +261,7 @@ func TestGetLoginCallbackWhenIdentityNotConfirmed(t *testing.T) {
 				}).
 				Return(nil)
 
-			err := LoginCallback(template.Func, oneLoginClient, sessionStore, lpaStore)(appData, w, r)
+			err := LoginCallback(template.Func, oneLoginClient, sessionStore, lpaStore, shareCodeStore)(appData, w, r)
 			resp := w.Result()
 
 			assert.Equal(t, tc.error, err)
@@ -241,11 +274,6 @@ func TestGetLoginCallbackWhenExchangeError(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodGet, "/?code=a-code", nil)
 
-	lpaStore := &mockLpaStore{}
-	lpaStore.
-		On("Get", mock.Anything).
-		Return(&page.Lpa{}, nil)
-
 	sessionStore := &mockSessionsStore{}
 	sessionStore.
 		On("Get", mock.Anything, "params").
@@ -257,7 +285,7 @@ func TestGetLoginCallbackWhenExchangeError(t *testing.T) {
 					CertificateProvider: true,
 					Identity:            true,
 					LpaID:               "lpa-id",
-					SessionID:           "session-id",
+					ShareCode:           "a-share-code",
 				},
 			},
 		}, nil)
@@ -267,21 +295,16 @@ func TestGetLoginCallbackWhenExchangeError(t *testing.T) {
 		On("Exchange", mock.Anything, mock.Anything, mock.Anything).
 		Return("", expectedError)
 
-	err := LoginCallback(nil, oneLoginClient, sessionStore, lpaStore)(appData, w, r)
+	err := LoginCallback(nil, oneLoginClient, sessionStore, nil, nil)(appData, w, r)
 
 	assert.Equal(t, expectedError, err)
-	mock.AssertExpectationsForObjects(t, lpaStore, oneLoginClient)
+	mock.AssertExpectationsForObjects(t, oneLoginClient)
 }
 
 func TestGetLoginCallbackWhenUserInfoError(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodGet, "/?code=a-code", nil)
 
-	lpaStore := &mockLpaStore{}
-	lpaStore.
-		On("Get", mock.Anything).
-		Return(&page.Lpa{}, nil)
-
 	sessionStore := &mockSessionsStore{}
 	sessionStore.
 		On("Get", mock.Anything, "params").
@@ -293,7 +316,7 @@ func TestGetLoginCallbackWhenUserInfoError(t *testing.T) {
 					CertificateProvider: true,
 					Identity:            true,
 					LpaID:               "lpa-id",
-					SessionID:           "session-id",
+					ShareCode:           "a-share-code",
 				},
 			},
 		}, nil)
@@ -306,10 +329,91 @@ func TestGetLoginCallbackWhenUserInfoError(t *testing.T) {
 		On("UserInfo", mock.Anything, mock.Anything).
 		Return(onelogin.UserInfo{}, expectedError)
 
-	err := LoginCallback(nil, oneLoginClient, sessionStore, lpaStore)(appData, w, r)
+	err := LoginCallback(nil, oneLoginClient, sessionStore, nil, nil)(appData, w, r)
 
 	assert.Equal(t, expectedError, err)
-	mock.AssertExpectationsForObjects(t, lpaStore, oneLoginClient)
+	mock.AssertExpectationsForObjects(t, oneLoginClient)
+}
+
+func TestGetLoginCallbackWhenRedeemError(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/?code=a-code", nil)
+
+	sessionStore := &mockSessionsStore{}
+	sessionStore.
+		On("Get", mock.Anything, "params").
+		Return(&sessions.Session{
+			Values: map[any]any{
+				"one-login": &sesh.OneLoginSession{
+					State:               "a-state",
+					Nonce:               "a-nonce",
+					CertificateProvider: true,
+					Identity:            true,
+					LpaID:               "lpa-id",
+					ShareCode:           "a-share-code",
+				},
+			},
+		}, nil)
+
+	oneLoginClient := &mockOneLoginClient{}
+	oneLoginClient.
+		On("Exchange", mock.Anything, mock.Anything, mock.Anything).
+		Return("a-jwt", nil)
+	oneLoginClient.
+		On("UserInfo", mock.Anything, mock.Anything).
+		Return(onelogin.UserInfo{Sub: "a-sub"}, nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Redeem", mock.Anything, "a-share-code").
+		Return(page.ShareCodeData{}, expectedError)
+
+	err := LoginCallback(nil, oneLoginClient, sessionStore, nil, shareCodeStore)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, sessionStore, shareCodeStore)
+}
+
+func TestGetLoginCallbackWhenLinkError(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/?code=a-code", nil)
+
+	sessionStore := &mockSessionsStore{}
+	sessionStore.
+		On("Get", mock.Anything, "params").
+		Return(&sessions.Session{
+			Values: map[any]any{
+				"one-login": &sesh.OneLoginSession{
+					State:               "a-state",
+					Nonce:               "a-nonce",
+					CertificateProvider: true,
+					Identity:            true,
+					LpaID:               "lpa-id",
+					ShareCode:           "a-share-code",
+				},
+			},
+		}, nil)
+
+	oneLoginClient := &mockOneLoginClient{}
+	oneLoginClient.
+		On("Exchange", mock.Anything, mock.Anything, mock.Anything).
+		Return("a-jwt", nil)
+	oneLoginClient.
+		On("UserInfo", mock.Anything, mock.Anything).
+		Return(onelogin.UserInfo{Sub: "a-sub"}, nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Redeem", mock.Anything, "a-share-code").
+		Return(page.ShareCodeData{}, nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.On("Link", mock.Anything).Return(expectedError)
+
+	err := LoginCallback(nil, oneLoginClient, sessionStore, lpaStore, shareCodeStore)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, sessionStore, lpaStore)
 }
 
 func TestGetLoginCallbackWhenGetDataStoreError(t *testing.T) {
@@ -327,15 +431,29 @@ func TestGetLoginCallbackWhenGetDataStoreError(t *testing.T) {
 					CertificateProvider: true,
 					Identity:            true,
 					LpaID:               "lpa-id",
-					SessionID:           "session-id",
+					ShareCode:           "a-share-code",
 				},
 			},
 		}, nil)
 
+	oneLoginClient := &mockOneLoginClient{}
+	oneLoginClient.
+		On("Exchange", mock.Anything, mock.Anything, mock.Anything).
+		Return("a-jwt", nil)
+	oneLoginClient.
+		On("UserInfo", mock.Anything, mock.Anything).
+		Return(onelogin.UserInfo{Sub: "a-sub"}, nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Redeem", mock.Anything, "a-share-code").
+		Return(page.ShareCodeData{}, nil)
+
 	lpaStore := &mockLpaStore{}
+	lpaStore.On("Link", mock.Anything).Return(nil)
 	lpaStore.On("Get", mock.Anything).Return(&page.Lpa{}, expectedError)
 
-	err := LoginCallback(nil, nil, sessionStore, lpaStore)(appData, w, r)
+	err := LoginCallback(nil, oneLoginClient, sessionStore, lpaStore, shareCodeStore)(appData, w, r)
 
 	assert.Equal(t, expectedError, err)
 	mock.AssertExpectationsForObjects(t, sessionStore, lpaStore)
@@ -346,7 +464,13 @@ func TestGetLoginCallbackWhenPutDataStoreError(t *testing.T) {
 	r, _ := http.NewRequest(http.MethodGet, "/?code=a-code", nil)
 	userInfo := onelogin.UserInfo{CoreIdentityJWT: "an-identity-jwt"}
 
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Redeem", mock.Anything, "a-share-code").
+		Return(page.ShareCodeData{}, nil)
+
 	lpaStore := &mockLpaStore{}
+	lpaStore.On("Link", mock.Anything).Return(nil)
 	lpaStore.
 		On("Get", mock.Anything).
 		Return(&page.Lpa{}, nil)
@@ -365,7 +489,7 @@ func TestGetLoginCallbackWhenPutDataStoreError(t *testing.T) {
 					CertificateProvider: true,
 					Identity:            true,
 					LpaID:               "lpa-id",
-					SessionID:           "session-id",
+					ShareCode:           "a-share-code",
 				},
 			},
 		}, nil)
@@ -381,7 +505,7 @@ func TestGetLoginCallbackWhenPutDataStoreError(t *testing.T) {
 		On("ParseIdentityClaim", mock.Anything, mock.Anything).
 		Return(identity.UserData{OK: true}, nil)
 
-	err := LoginCallback(nil, oneLoginClient, sessionStore, lpaStore)(appData, w, r)
+	err := LoginCallback(nil, oneLoginClient, sessionStore, lpaStore, shareCodeStore)(appData, w, r)
 
 	assert.Equal(t, expectedError, err)
 	mock.AssertExpectationsForObjects(t, lpaStore, oneLoginClient)
@@ -414,10 +538,9 @@ func TestGetLoginCallbackWhenReturning(t *testing.T) {
 	}
 	session.Values = map[any]any{
 		"certificate-provider": &sesh.CertificateProviderSession{
-			Sub:            "a-sub",
-			Email:          "a-email",
-			LpaID:          "lpa-id",
-			DonorSessionID: "session-id",
+			Sub:   "a-sub",
+			Email: "a-email",
+			LpaID: "lpa-id",
 		},
 	}
 
@@ -431,7 +554,7 @@ func TestGetLoginCallbackWhenReturning(t *testing.T) {
 					CertificateProvider: true,
 					Identity:            true,
 					LpaID:               "lpa-id",
-					SessionID:           "session-id",
+					ShareCode:           "a-share-code",
 				},
 			},
 		}, nil)
@@ -439,7 +562,13 @@ func TestGetLoginCallbackWhenReturning(t *testing.T) {
 		On("Save", r, w, session).
 		Return(nil)
 
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Redeem", mock.Anything, "a-share-code").
+		Return(page.ShareCodeData{}, nil)
+
 	lpaStore := &mockLpaStore{}
+	lpaStore.On("Link", mock.Anything).Return(nil)
 	lpaStore.On("Get", mock.Anything).Return(&page.Lpa{CertificateProviderUserData: userData}, nil)
 
 	template := &mockTemplate{}
@@ -451,7 +580,7 @@ func TestGetLoginCallbackWhenReturning(t *testing.T) {
 		}).
 		Return(nil)
 
-	err := LoginCallback(template.Func, oneLoginClient, sessionStore, lpaStore)(appData, w, r)
+	err := LoginCallback(template.Func, oneLoginClient, sessionStore, lpaStore, shareCodeStore)(appData, w, r)
 	resp := w.Result()
 
 	assert.Nil(t, err)
@@ -469,9 +598,8 @@ func TestPostCertificateProviderLoginCallback(t *testing.T) {
 		Return(&sessions.Session{
 			Values: map[any]any{
 				"certificate-provider": &sesh.CertificateProviderSession{
-					Sub:            "xyz",
-					LpaID:          "lpa-id",
-					DonorSessionID: "session-id",
+					Sub:   "xyz",
+					LpaID: "lpa-id",
 				},
 			},
 		}, nil)
@@ -481,11 +609,11 @@ func TestPostCertificateProviderLoginCallback(t *testing.T) {
 		On("Get", mock.MatchedBy(func(ctx context.Context) bool {
 			session := page.SessionDataFromContext(ctx)
 
-			return assert.Equal(t, &page.SessionData{SessionID: "session-id", LpaID: "lpa-id"}, session)
+			return assert.Equal(t, &page.SessionData{LpaID: "lpa-id", ActorType: page.ActorTypeCertificateProvider, Subject: "xyz"}, session)
 		})).
 		Return(&page.Lpa{CertificateProviderUserData: identity.UserData{OK: true}}, nil)
 
-	err := LoginCallback(nil, nil, sessionStore, lpaStore)(appData, w, r)
+	err := LoginCallback(nil, nil, sessionStore, lpaStore, nil)(appData, w, r)
 	resp := w.Result()
 
 	assert.Nil(t, err)
@@ -503,9 +631,8 @@ func TestPostCertificateProviderLoginCallbackNotConfirmed(t *testing.T) {
 		Return(&sessions.Session{
 			Values: map[any]any{
 				"certificate-provider": &sesh.CertificateProviderSession{
-					Sub:            "xyz",
-					LpaID:          "lpa-id",
-					DonorSessionID: "session-id",
+					Sub:   "xyz",
+					LpaID: "lpa-id",
 				},
 			},
 		}, nil)
@@ -513,7 +640,7 @@ func TestPostCertificateProviderLoginCallbackNotConfirmed(t *testing.T) {
 	lpaStore := &mockLpaStore{}
 	lpaStore.On("Get", mock.Anything).Return(&page.Lpa{}, nil)
 
-	err := LoginCallback(nil, nil, sessionStore, lpaStore)(appData, w, r)
+	err := LoginCallback(nil, nil, sessionStore, lpaStore, nil)(appData, w, r)
 	resp := w.Result()
 
 	assert.Nil(t, err)
@@ -17,7 +17,12 @@ var appData = page.AppData{}
 
 func TestCertificateProviderLogin(t *testing.T) {
 	w := httptest.NewRecorder()
-	r, _ := http.NewRequest(http.MethodGet, "/?sessionId=session-id&lpaId=lpa-id", nil)
+	r, _ := http.NewRequest(http.MethodGet, "/?share-code=a-share-code", nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Get", r.Context(), "a-share-code").
+		Return(page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeCertificateProvider}, nil)
 
 	client := &mockOneLoginClient{}
 	client.
@@ -42,8 +47,8 @@ func TestCertificateProviderLogin(t *testing.T) {
 			Locale:              "cy",
 			CertificateProvider: true,
 			Identity:            true,
-			SessionID:           "session-id",
 			LpaID:               "lpa-id",
+			ShareCode:           "a-share-code",
 		},
 	}
 
@@ -51,18 +56,23 @@ func TestCertificateProviderLogin(t *testing.T) {
 		On("Save", r, w, session).
 		Return(nil)
 
-	Login(nil, client, sessionsStore, func(int) string { return "i am random" })(page.AppData{Lang: localize.Cy, Paths: page.Paths}, w, r)
+	Login(nil, client, sessionsStore, func(int) string { return "i am random" }, shareCodeStore)(page.AppData{Lang: localize.Cy, Paths: page.Paths}, w, r)
 	resp := w.Result()
 
 	assert.Equal(t, http.StatusFound, resp.StatusCode)
 	assert.Equal(t, "http://auth", resp.Header.Get("Location"))
 
-	mock.AssertExpectationsForObjects(t, client, sessionsStore)
+	mock.AssertExpectationsForObjects(t, shareCodeStore, client, sessionsStore)
 }
 
 func TestCertificateProviderLoginDefaultLocale(t *testing.T) {
 	w := httptest.NewRecorder()
-	r, _ := http.NewRequest(http.MethodGet, "/?sessionId=session-id&lpaId=lpa-id", nil)
+	r, _ := http.NewRequest(http.MethodGet, "/?share-code=a-share-code", nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Get", r.Context(), "a-share-code").
+		Return(page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeCertificateProvider}, nil)
 
 	client := &mockOneLoginClient{}
 	client.
@@ -87,8 +97,8 @@ func TestCertificateProviderLoginDefaultLocale(t *testing.T) {
 			Locale:              "en",
 			CertificateProvider: true,
 			Identity:            true,
-			SessionID:           "session-id",
 			LpaID:               "lpa-id",
+			ShareCode:           "a-share-code",
 		},
 	}
 
@@ -96,23 +106,28 @@ func TestCertificateProviderLoginDefaultLocale(t *testing.T) {
 		On("Save", r, w, session).
 		Return(nil)
 
-	Login(nil, client, sessionsStore, func(int) string { return "i am random" })(appData, w, r)
+	Login(nil, client, sessionsStore, func(int) string { return "i am random" }, shareCodeStore)(appData, w, r)
 	resp := w.Result()
 
 	assert.Equal(t, http.StatusFound, resp.StatusCode)
 	assert.Equal(t, "http://auth", resp.Header.Get("Location"))
 
-	mock.AssertExpectationsForObjects(t, client, sessionsStore)
+	mock.AssertExpectationsForObjects(t, shareCodeStore, client, sessionsStore)
 }
 
 func TestCertificateProviderLoginWhenStoreSaveError(t *testing.T) {
 	w := httptest.NewRecorder()
-	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r, _ := http.NewRequest(http.MethodGet, "/?share-code=a-share-code", nil)
 
 	logger := &mockLogger{}
 	logger.
 		On("Print", expectedError)
 
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Get", r.Context(), "a-share-code").
+		Return(page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeCertificateProvider}, nil)
+
 	client := &mockOneLoginClient{}
 	client.
 		On("AuthCodeURL", "i am random", "i am random", "en", true).
@@ -123,10 +138,40 @@ func TestCertificateProviderLoginWhenStoreSaveError(t *testing.T) {
 		On("Save", r, w, mock.Anything).
 		Return(expectedError)
 
-	Login(logger, client, sessionsStore, func(int) string { return "i am random" })(appData, w, r)
+	Login(logger, client, sessionsStore, func(int) string { return "i am random" }, shareCodeStore)(appData, w, r)
 	resp := w.Result()
 
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 
-	mock.AssertExpectationsForObjects(t, logger, client, sessionsStore)
+	mock.AssertExpectationsForObjects(t, logger, shareCodeStore, client, sessionsStore)
+}
+
+func TestCertificateProviderLoginWhenGettingShareCodeErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/?share-code=a-share-code", nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Get", r.Context(), "a-share-code").
+		Return(page.ShareCodeData{}, expectedError)
+
+	err := Login(nil, nil, nil, nil, shareCodeStore)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, shareCodeStore)
+}
+
+func TestCertificateProviderLoginWhenShareCodeNotForCertificateProvider(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/?share-code=a-share-code", nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Get", r.Context(), "a-share-code").
+		Return(page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeAttorney}, nil)
+
+	err := Login(nil, nil, nil, nil, shareCodeStore)(appData, w, r)
+
+	assert.NotNil(t, err)
+	mock.AssertExpectationsForObjects(t, shareCodeStore)
 }
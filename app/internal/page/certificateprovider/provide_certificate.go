@@ -0,0 +1,113 @@
+package certificateprovider
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ministryofjustice/opg-go-common/template"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/event"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/validation"
+)
+
+type provideCertificateData struct {
+	App    page.AppData
+	Errors validation.List
+	Lpa    *page.Lpa
+	Form   *provideCertificateForm
+}
+
+func ProvideCertificate(tmpl template.Template, lpaStore page.LpaStore, eventPublisher page.EventPublisher, now func() time.Time) page.Handler {
+	return func(appData page.AppData, w http.ResponseWriter, r *http.Request) error {
+		lpa, err := lpaStore.Get(r.Context())
+		if err != nil {
+			return err
+		}
+
+		if !lpa.Certificate.Agreed.IsZero() {
+			return appData.Redirect(w, r, lpa, page.Paths.CertificateProviderCertificateProvided)
+		}
+
+		// The certificate can only be provided once the certificate provider's
+		// identity has been confirmed, and once the donor has signed and
+		// submitted the LPA.
+		if !lpa.CertificateProviderUserData.OK {
+			return appData.Redirect(w, r, lpa, page.Paths.CertificateProviderIdentityNotConfirmed)
+		}
+
+		if lpa.Submitted.IsZero() {
+			return appData.Redirect(w, r, lpa, page.Paths.CertificateProviderReadTheLpa)
+		}
+
+		data := &provideCertificateData{
+			App: appData,
+			Lpa: lpa,
+			Form: &provideCertificateForm{
+				DiscussedLpaWithDonor:   lpa.Certificate.DiscussedLpaWithDonor,
+				DonorUnderstandsLpa:     lpa.Certificate.DonorUnderstandsLpa,
+				NoFraudOrUndueInfluence: lpa.Certificate.NoFraudOrUndueInfluence,
+			},
+		}
+
+		if r.Method == http.MethodPost {
+			data.Form = readProvideCertificateForm(r)
+			data.Errors = data.Form.Validate()
+
+			if data.Errors.None() {
+				lpa.Certificate = page.Certificate{
+					DiscussedLpaWithDonor:   data.Form.DiscussedLpaWithDonor,
+					DonorUnderstandsLpa:     data.Form.DonorUnderstandsLpa,
+					NoFraudOrUndueInfluence: data.Form.NoFraudOrUndueInfluence,
+					Agreed:                  now(),
+				}
+
+				if err := lpaStore.Put(r.Context(), lpa); err != nil {
+					return err
+				}
+
+				if err := eventPublisher.Publish(r.Context(), event.CertificateProvided{LpaID: lpa.ID}); err != nil {
+					return err
+				}
+
+				return appData.Redirect(w, r, lpa, page.Paths.CertificateProviderCertificateProvided)
+			}
+		}
+
+		return tmpl(w, data)
+	}
+}
+
+type provideCertificateForm struct {
+	DiscussedLpaWithDonor   bool
+	DonorUnderstandsLpa     bool
+	NoFraudOrUndueInfluence bool
+}
+
+func readProvideCertificateForm(r *http.Request) *provideCertificateForm {
+	r.ParseForm()
+
+	f := &provideCertificateForm{}
+
+	for _, statement := range r.PostForm["agree-to-statement"] {
+		switch statement {
+		case "discussed-lpa-with-donor":
+			f.DiscussedLpaWithDonor = true
+		case "donor-understands-lpa":
+			f.DonorUnderstandsLpa = true
+		case "no-fraud-or-undue-influence":
+			f.NoFraudOrUndueInfluence = true
+		}
+	}
+
+	return f
+}
+
+func (f *provideCertificateForm) Validate() validation.List {
+	var errors validation.List
+
+	if !f.DiscussedLpaWithDonor || !f.DonorUnderstandsLpa || !f.NoFraudOrUndueInfluence {
+		errors.Add("agree-to-statement", validation.CustomError{Label: "agreeToAllCertificateStatements"})
+	}
+
+	return errors
+}
@@ -0,0 +1,310 @@
+package certificateprovider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/event"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/identity"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const formUrlEncoded = "application/x-www-form-urlencoded"
+
+var (
+	submitted         = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	identityConfirmed = identity.UserData{OK: true}
+)
+
+type mockEventPublisher struct {
+	mock.Mock
+}
+
+func (m *mockEventPublisher) Publish(ctx context.Context, e event.Event) error {
+	return m.Called(ctx, e).Error(0)
+}
+
+func TestGetProvideCertificate(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpa := &page.Lpa{ID: "lpa-id", Submitted: submitted, CertificateProviderUserData: identityConfirmed}
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(lpa, nil)
+
+	template := &mockTemplate{}
+	template.
+		On("Func", w, &provideCertificateData{
+			App:  appData,
+			Lpa:  lpa,
+			Form: &provideCertificateForm{},
+		}).
+		Return(nil)
+
+	err := ProvideCertificate(template.Func, lpaStore, nil, time.Now)(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	mock.AssertExpectationsForObjects(t, lpaStore, template)
+}
+
+func TestGetProvideCertificateWhenAlreadyAgreed(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{ID: "lpa-id", Certificate: page.Certificate{Agreed: time.Now()}}, nil)
+
+	err := ProvideCertificate(nil, lpaStore, nil, time.Now)(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, page.Paths.CertificateProviderCertificateProvided, resp.Header.Get("Location"))
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestProvideCertificateWhenCannotProvide(t *testing.T) {
+	testCases := map[string]struct {
+		lpa      *page.Lpa
+		redirect string
+	}{
+		"identity not confirmed": {
+			lpa:      &page.Lpa{ID: "lpa-id", Submitted: submitted},
+			redirect: page.Paths.CertificateProviderIdentityNotConfirmed,
+		},
+		"not submitted": {
+			lpa:      &page.Lpa{ID: "lpa-id", CertificateProviderUserData: identityConfirmed},
+			redirect: page.Paths.CertificateProviderReadTheLpa,
+		},
+	}
+
+	for name, tc := range testCases {
+		for _, method := range []string{http.MethodGet, http.MethodPost} {
+			t.Run(name+"/"+method, func(t *testing.T) {
+				form := url.Values{
+					"agree-to-statement": {"discussed-lpa-with-donor", "donor-understands-lpa", "no-fraud-or-undue-influence"},
+				}
+
+				w := httptest.NewRecorder()
+				r, _ := http.NewRequest(method, "/", strings.NewReader(form.Encode()))
+				r.Header.Add("Content-Type", formUrlEncoded)
+
+				lpaStore := &mockLpaStore{}
+				lpaStore.
+					On("Get", r.Context()).
+					Return(tc.lpa, nil)
+
+				err := ProvideCertificate(nil, lpaStore, nil, time.Now)(appData, w, r)
+				resp := w.Result()
+
+				assert.Nil(t, err)
+				assert.Equal(t, http.StatusFound, resp.StatusCode)
+				assert.Equal(t, tc.redirect, resp.Header.Get("Location"))
+				mock.AssertExpectationsForObjects(t, lpaStore)
+			})
+		}
+	}
+}
+
+func TestGetProvideCertificateWhenLpaStoreErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{}, expectedError)
+
+	err := ProvideCertificate(nil, lpaStore, nil, time.Now)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestPostProvideCertificate(t *testing.T) {
+	now := time.Date(2020, time.January, 2, 3, 4, 5, 6, time.UTC)
+
+	form := url.Values{
+		"agree-to-statement": {"discussed-lpa-with-donor", "donor-understands-lpa", "no-fraud-or-undue-influence"},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Add("Content-Type", formUrlEncoded)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{ID: "lpa-id", Submitted: submitted, CertificateProviderUserData: identityConfirmed}, nil)
+	lpaStore.
+		On("Put", r.Context(), &page.Lpa{
+			ID:                          "lpa-id",
+			Submitted:                   submitted,
+			CertificateProviderUserData: identityConfirmed,
+			Certificate: page.Certificate{
+				DiscussedLpaWithDonor:   true,
+				DonorUnderstandsLpa:     true,
+				NoFraudOrUndueInfluence: true,
+				Agreed:                  now,
+			},
+		}).
+		Return(nil)
+
+	eventPublisher := &mockEventPublisher{}
+	eventPublisher.
+		On("Publish", r.Context(), event.CertificateProvided{LpaID: "lpa-id"}).
+		Return(nil)
+
+	err := ProvideCertificate(nil, lpaStore, eventPublisher, func() time.Time { return now })(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, page.Paths.CertificateProviderCertificateProvided, resp.Header.Get("Location"))
+	mock.AssertExpectationsForObjects(t, lpaStore, eventPublisher)
+}
+
+func TestPostProvideCertificateWhenPublishErrors(t *testing.T) {
+	form := url.Values{
+		"agree-to-statement": {"discussed-lpa-with-donor", "donor-understands-lpa", "no-fraud-or-undue-influence"},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Add("Content-Type", formUrlEncoded)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{ID: "lpa-id", Submitted: submitted, CertificateProviderUserData: identityConfirmed}, nil)
+	lpaStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(nil)
+
+	eventPublisher := &mockEventPublisher{}
+	eventPublisher.
+		On("Publish", r.Context(), mock.Anything).
+		Return(expectedError)
+
+	err := ProvideCertificate(nil, lpaStore, eventPublisher, time.Now)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+}
+
+func TestPostProvideCertificateWhenValidationErrors(t *testing.T) {
+	form := url.Values{
+		"agree-to-statement": {"discussed-lpa-with-donor"},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Add("Content-Type", formUrlEncoded)
+
+	lpa := &page.Lpa{ID: "lpa-id", Submitted: submitted, CertificateProviderUserData: identityConfirmed}
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(lpa, nil)
+
+	template := &mockTemplate{}
+	template.
+		On("Func", w, &provideCertificateData{
+			App:    appData,
+			Lpa:    lpa,
+			Errors: validation.With("agree-to-statement", validation.CustomError{Label: "agreeToAllCertificateStatements"}),
+			Form:   &provideCertificateForm{DiscussedLpaWithDonor: true},
+		}).
+		Return(nil)
+
+	err := ProvideCertificate(template.Func, lpaStore, nil, time.Now)(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	mock.AssertExpectationsForObjects(t, lpaStore, template)
+}
+
+func TestPostProvideCertificateWhenLpaStoreErrors(t *testing.T) {
+	form := url.Values{
+		"agree-to-statement": {"discussed-lpa-with-donor", "donor-understands-lpa", "no-fraud-or-undue-influence"},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Add("Content-Type", formUrlEncoded)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{Submitted: submitted, CertificateProviderUserData: identityConfirmed}, nil)
+	lpaStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(expectedError)
+
+	err := ProvideCertificate(nil, lpaStore, nil, time.Now)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestReadProvideCertificateForm(t *testing.T) {
+	form := url.Values{
+		"agree-to-statement": {"discussed-lpa-with-donor", "no-fraud-or-undue-influence", "what"},
+	}
+
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Add("Content-Type", formUrlEncoded)
+
+	assert.Equal(t, &provideCertificateForm{
+		DiscussedLpaWithDonor:   true,
+		NoFraudOrUndueInfluence: true,
+	}, readProvideCertificateForm(r))
+}
+
+func TestProvideCertificateFormValidate(t *testing.T) {
+	testCases := map[string]struct {
+		form   *provideCertificateForm
+		errors validation.List
+	}{
+		"valid": {
+			form: &provideCertificateForm{
+				DiscussedLpaWithDonor:   true,
+				DonorUnderstandsLpa:     true,
+				NoFraudOrUndueInfluence: true,
+			},
+		},
+		"none selected": {
+			form:   &provideCertificateForm{},
+			errors: validation.With("agree-to-statement", validation.CustomError{Label: "agreeToAllCertificateStatements"}),
+		},
+		"some selected": {
+			form: &provideCertificateForm{
+				DiscussedLpaWithDonor: true,
+				DonorUnderstandsLpa:   true,
+			},
+			errors: validation.With("agree-to-statement", validation.CustomError{Label: "agreeToAllCertificateStatements"}),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.errors, tc.form.Validate())
+		})
+	}
+}
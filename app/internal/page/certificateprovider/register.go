@@ -1,8 +1,10 @@
 package certificateprovider
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/ministryofjustice/opg-go-common/template"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
@@ -17,18 +19,27 @@ func Register(
 	sessionStore sesh.Store,
 	lpaStore page.LpaStore,
 	oneLoginClient page.OneLoginClient,
-	dataStore page.DataStore,
+	shareCodeStore page.ShareCodeStore,
+	eventPublisher page.EventPublisher,
 ) {
 	handleRoot := makeHandle(rootMux, logger, sessionStore, None)
 
 	handleRoot(page.Paths.CertificateProviderStart, None,
-		Start(tmpls.Get("certificate_provider_start.gohtml"), lpaStore, dataStore))
+		Start(tmpls.Get("certificate_provider_start.gohtml"), shareCodeStore))
 	handleRoot(page.Paths.CertificateProviderLogin, None,
-		Login(logger, oneLoginClient, sessionStore, random.String))
+		Login(logger, oneLoginClient, sessionStore, random.String, shareCodeStore))
 	handleRoot(page.Paths.CertificateProviderLoginCallback, None,
-		LoginCallback(tmpls.Get("identity_with_one_login_callback.gohtml"), oneLoginClient, sessionStore, lpaStore))
+		LoginCallback(tmpls.Get("identity_with_one_login_callback.gohtml"), oneLoginClient, sessionStore, lpaStore, shareCodeStore))
+	handleRoot(page.Paths.CertificateProviderIdentityNotConfirmed, RequireSession,
+		page.Guidance(tmpls.Get("certificate_provider_identity_not_confirmed.gohtml"), "", nil))
 	handleRoot(page.Paths.CertificateProviderYourDetails, RequireSession,
-		page.Guidance(tmpls.Get("certificate_provider_your_details.gohtml"), "", lpaStore))
+		page.Guidance(tmpls.Get("certificate_provider_your_details.gohtml"), page.Paths.CertificateProviderReadTheLpa, lpaStore))
+	handleRoot(page.Paths.CertificateProviderReadTheLpa, RequireSession,
+		page.Guidance(tmpls.Get("certificate_provider_read_the_lpa.gohtml"), page.Paths.CertificateProviderProvideCertificate, lpaStore))
+	handleRoot(page.Paths.CertificateProviderProvideCertificate, RequireSession,
+		ProvideCertificate(tmpls.Get("provide_certificate.gohtml"), lpaStore, eventPublisher, time.Now))
+	handleRoot(page.Paths.CertificateProviderCertificateProvided, RequireSession,
+		page.Guidance(tmpls.Get("certificate_provided.gohtml"), "", lpaStore))
 }
 
 type handleOpt byte
@@ -42,6 +53,7 @@ const (
 func makeHandle(mux *http.ServeMux, logger page.Logger, store sesh.Store, defaultOptions handleOpt) func(string, handleOpt, page.Handler) {
 	return func(path string, opt handleOpt, h page.Handler) {
 		opt = opt | defaultOptions
+		h = page.WithLpaUnitOfWork(h)
 
 		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
@@ -58,13 +70,21 @@ func makeHandle(mux *http.ServeMux, logger page.Logger, store sesh.Store, defaul
 					return
 				}
 
-				appData.SessionID = session.DonorSessionID
 				appData.LpaID = session.LpaID
 
-				ctx = page.ContextWithSessionData(ctx, &page.SessionData{SessionID: appData.SessionID, LpaID: appData.LpaID})
+				ctx = page.ContextWithSessionData(ctx, &page.SessionData{
+					LpaID:     appData.LpaID,
+					ActorType: page.ActorTypeCertificateProvider,
+					Subject:   session.Sub,
+				})
 			}
 
 			if err := h(appData, w, r.WithContext(page.ContextWithAppData(ctx, appData))); err != nil {
+				if errors.Is(err, page.ErrLpaWithdrawn) {
+					http.Redirect(w, r, appData.BuildUrl(path)+"?lpaWithdrawn=1", http.StatusFound)
+					return
+				}
+
 				str := fmt.Sprintf("Error rendering page for path '%s': %s", path, err.Error())
 
 				logger.Print(str)
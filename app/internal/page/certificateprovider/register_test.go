@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/gorilla/sessions"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/dynamo"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/sesh"
 	"github.com/stretchr/testify/assert"
@@ -54,9 +55,8 @@ func TestMakeHandle(t *testing.T) {
 		Return(&sessions.Session{
 			Values: map[any]any{
 				"certificate-provider": &sesh.CertificateProviderSession{
-					Sub:            "random",
-					DonorSessionID: "session-id",
-					LpaID:          "lpa-id",
+					Sub:   "random",
+					LpaID: "lpa-id",
 				},
 			},
 		}, nil)
@@ -66,13 +66,11 @@ func TestMakeHandle(t *testing.T) {
 	handle("/path", RequireSession, func(appData page.AppData, hw http.ResponseWriter, hr *http.Request) error {
 		assert.Equal(t, page.AppData{
 			Page:      "/path",
-			SessionID: "session-id",
 			LpaID:     "lpa-id",
 			CanGoBack: false,
 		}, appData)
-		assert.Equal(t, w, hw)
 
-		assert.Equal(t, &page.SessionData{SessionID: "session-id", LpaID: "lpa-id"}, page.SessionDataFromContext(hr.Context()))
+		assert.Equal(t, &page.SessionData{LpaID: "lpa-id", ActorType: page.ActorTypeCertificateProvider, Subject: "random"}, page.SessionDataFromContext(hr.Context()))
 		hw.WriteHeader(http.StatusTeapot)
 		return nil
 	})
@@ -85,26 +83,24 @@ func TestMakeHandle(t *testing.T) {
 }
 
 func TestMakeHandleExistingSessionData(t *testing.T) {
-	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "ignored-123", SessionID: "ignored-session-id"})
+	ctx := page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "ignored-123"})
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/path?a=b", nil)
 
 	sessionsStore := &mockSessionsStore{}
 	sessionsStore.
 		On("Get", r, "session").
-		Return(&sessions.Session{Values: map[any]any{"certificate-provider": &sesh.CertificateProviderSession{Sub: "random", LpaID: "lpa-id", DonorSessionID: "session-id"}}}, nil)
+		Return(&sessions.Session{Values: map[any]any{"certificate-provider": &sesh.CertificateProviderSession{Sub: "random", LpaID: "lpa-id"}}}, nil)
 
 	mux := http.NewServeMux()
 	handle := makeHandle(mux, nil, sessionsStore, None)
 	handle("/path", RequireSession|CanGoBack, func(appData page.AppData, hw http.ResponseWriter, hr *http.Request) error {
 		assert.Equal(t, page.AppData{
 			Page:      "/path",
-			SessionID: "session-id",
 			CanGoBack: true,
 			LpaID:     "lpa-id",
 		}, appData)
-		assert.Equal(t, w, hw)
-		assert.Equal(t, &page.SessionData{LpaID: "lpa-id", SessionID: "session-id"}, page.SessionDataFromContext(hr.Context()))
+		assert.Equal(t, &page.SessionData{LpaID: "lpa-id", ActorType: page.ActorTypeCertificateProvider, Subject: "random"}, page.SessionDataFromContext(hr.Context()))
 		hw.WriteHeader(http.StatusTeapot)
 		return nil
 	})
@@ -136,6 +132,46 @@ func TestMakeHandleErrors(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
 }
 
+func TestMakeHandleWhenConflict(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, page.Paths.CertificateProviderProvideCertificate, nil)
+
+	mux := http.NewServeMux()
+	handle := makeHandle(mux, nil, nil, None)
+	handle(page.Paths.CertificateProviderProvideCertificate, None, func(appData page.AppData, hw http.ResponseWriter, hr *http.Request) error {
+		if hr.Method == http.MethodPost {
+			return dynamo.ConflictError{PK: "a", SK: "b"}
+		}
+
+		assert.True(t, appData.LpaChanged)
+		hw.Write([]byte("changed"))
+		return nil
+	})
+
+	mux.ServeHTTP(w, r)
+	resp := w.Result()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "changed", w.Body.String())
+}
+
+func TestMakeHandleWhenWithdrawn(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, page.Paths.CertificateProviderProvideCertificate, nil)
+
+	mux := http.NewServeMux()
+	handle := makeHandle(mux, nil, nil, None)
+	handle(page.Paths.CertificateProviderProvideCertificate, None, func(appData page.AppData, hw http.ResponseWriter, hr *http.Request) error {
+		return page.ErrLpaWithdrawn
+	})
+
+	mux.ServeHTTP(w, r)
+	resp := w.Result()
+
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, page.Paths.CertificateProviderProvideCertificate+"?lpaWithdrawn=1", resp.Header.Get("Location"))
+}
+
 func TestMakeHandleSessionError(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodGet, "/path", nil)
@@ -196,8 +232,7 @@ func TestMakeHandleNoSessionRequired(t *testing.T) {
 		assert.Equal(t, page.AppData{
 			Page: "/path",
 		}, appData)
-		assert.Equal(t, w, hw)
-		assert.Equal(t, r.WithContext(page.ContextWithAppData(r.Context(), page.AppData{Page: "/path"})), hr)
+		assert.Equal(t, page.AppData{Page: "/path"}, page.AppDataFromContext(hr.Context()))
 		hw.WriteHeader(http.StatusTeapot)
 		return nil
 	})
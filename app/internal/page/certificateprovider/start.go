@@ -1,6 +1,7 @@
 package certificateprovider
 
 import (
+	"errors"
 	"net/http"
 	"net/url"
 
@@ -15,26 +16,22 @@ type startData struct {
 	Start  string
 }
 
-func Start(tmpl template.Template, lpaStore page.LpaStore, dataStore page.DataStore) page.Handler {
+func Start(tmpl template.Template, shareCodeStore page.ShareCodeStore) page.Handler {
 	return func(appData page.AppData, w http.ResponseWriter, r *http.Request) error {
 		shareCode := r.FormValue("share-code")
 
-		var v page.ShareCodeData
-		if err := dataStore.Get(r.Context(), "SHARECODE#"+shareCode, "#METADATA#"+shareCode, &v); err != nil {
+		shareCodeData, err := shareCodeStore.Get(r.Context(), shareCode)
+		if err != nil {
 			return err
 		}
 
-		_, err := lpaStore.Get(page.ContextWithSessionData(r.Context(), &page.SessionData{
-			SessionID: v.SessionID,
-			LpaID:     v.LpaID,
-		}))
-		if err != nil {
-			return err
+		if shareCodeData.ActorType != page.ActorTypeCertificateProvider {
+			return errors.New("share code is not for a certificate provider")
 		}
 
 		data := &startData{
 			App:   appData,
-			Start: page.Paths.CertificateProviderLogin + "?" + url.Values{"lpaId": {v.LpaID}, "sessionId": {v.SessionID}}.Encode(),
+			Start: page.Paths.CertificateProviderLogin + "?" + url.Values{"share-code": {shareCode}}.Encode(),
 		}
 
 		return tmpl(w, data)
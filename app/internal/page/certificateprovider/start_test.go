@@ -2,7 +2,6 @@ package certificateprovider
 
 import (
 	"context"
-	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -12,125 +11,104 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
-type mockDataStore struct {
-	data interface{}
+type mockShareCodeStore struct {
 	mock.Mock
 }
 
-func (m *mockDataStore) GetAll(ctx context.Context, pk string, v interface{}) error {
-	data, _ := json.Marshal(m.data)
-	json.Unmarshal(data, v)
-	return m.Called(ctx, pk).Error(0)
+func (m *mockShareCodeStore) Create(ctx context.Context, data page.ShareCodeData) (string, error) {
+	args := m.Called(ctx, data)
+	return args.String(0), args.Error(1)
 }
 
-func (m *mockDataStore) Get(ctx context.Context, pk, sk string, v interface{}) error {
-	data, _ := json.Marshal(m.data)
-	json.Unmarshal(data, v)
-	return m.Called(ctx, pk, sk).Error(0)
+func (m *mockShareCodeStore) Get(ctx context.Context, shareCode string) (page.ShareCodeData, error) {
+	args := m.Called(ctx, shareCode)
+	return args.Get(0).(page.ShareCodeData), args.Error(1)
 }
 
-func (m *mockDataStore) Put(ctx context.Context, pk, sk string, v interface{}) error {
-	return m.Called(ctx, pk, sk, v).Error(0)
+func (m *mockShareCodeStore) Redeem(ctx context.Context, shareCode string) (page.ShareCodeData, error) {
+	args := m.Called(ctx, shareCode)
+	return args.Get(0).(page.ShareCodeData), args.Error(1)
+}
+
+func (m *mockShareCodeStore) Revoke(ctx context.Context, lpaID string, actorType page.ActorType, actorID, keepShareCode string) error {
+	return m.Called(ctx, lpaID, actorType, actorID, keepShareCode).Error(0)
+}
+
+func (m *mockShareCodeStore) RevokeAll(ctx context.Context, lpaID string) error {
+	return m.Called(ctx, lpaID).Error(0)
 }
 
 func TestStart(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodGet, "/?share-code=a-share-code", nil)
 
-	dataStore := &mockDataStore{
-		data: page.ShareCodeData{LpaID: "lpa-id", SessionID: "session-id"},
-	}
-	dataStore.
-		On("Get", r.Context(), "SHARECODE#a-share-code", "#METADATA#a-share-code").
-		Return(nil)
-
-	lpaStore := &mockLpaStore{}
-	lpaStore.
-		On("Get", mock.MatchedBy(func(ctx context.Context) bool {
-			session := page.SessionDataFromContext(ctx)
-
-			return assert.Equal(t, &page.SessionData{SessionID: "session-id", LpaID: "lpa-id"}, session)
-		})).
-		Return(&page.Lpa{}, nil)
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Get", r.Context(), "a-share-code").
+		Return(page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeCertificateProvider}, nil)
 
 	template := &mockTemplate{}
 	template.
 		On("Func", w, &startData{
 			App:   appData,
-			Start: page.Paths.CertificateProviderLogin + "?lpaId=lpa-id&sessionId=session-id",
+			Start: page.Paths.CertificateProviderLogin + "?share-code=a-share-code",
 		}).
 		Return(nil)
 
-	err := Start(template.Func, lpaStore, dataStore)(appData, w, r)
+	err := Start(template.Func, shareCodeStore)(appData, w, r)
 	resp := w.Result()
 
 	assert.Nil(t, err)
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
-	mock.AssertExpectationsForObjects(t, dataStore, lpaStore, template)
+	mock.AssertExpectationsForObjects(t, shareCodeStore, template)
 }
 
-func TestStartWhenGettingShareCodeErrors(t *testing.T) {
+func TestStartWhenShareCodeNotForCertificateProvider(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodGet, "/?share-code=a-share-code", nil)
 
-	dataStore := &mockDataStore{
-		data: page.ShareCodeData{LpaID: "lpa-id", SessionID: "session-id"},
-	}
-	dataStore.
-		On("Get", mock.Anything, mock.Anything, mock.Anything).
-		Return(expectedError)
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Get", r.Context(), "a-share-code").
+		Return(page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeAttorney}, nil)
 
-	err := Start(nil, nil, dataStore)(appData, w, r)
+	err := Start(nil, shareCodeStore)(appData, w, r)
 
-	assert.Equal(t, expectedError, err)
-	mock.AssertExpectationsForObjects(t, dataStore)
+	assert.NotNil(t, err)
+	mock.AssertExpectationsForObjects(t, shareCodeStore)
 }
 
-func TestStartWhenGettingLpaErrors(t *testing.T) {
+func TestStartWhenGettingShareCodeErrors(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodGet, "/?share-code=a-share-code", nil)
 
-	dataStore := &mockDataStore{
-		data: page.ShareCodeData{LpaID: "lpa-id", SessionID: "session-id"},
-	}
-	dataStore.
-		On("Get", mock.Anything, mock.Anything, mock.Anything).
-		Return(nil)
-
-	lpaStore := &mockLpaStore{}
-	lpaStore.
-		On("Get", mock.Anything).
-		Return(&page.Lpa{}, expectedError)
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Get", r.Context(), "a-share-code").
+		Return(page.ShareCodeData{}, expectedError)
 
-	err := Start(nil, lpaStore, dataStore)(appData, w, r)
+	err := Start(nil, shareCodeStore)(appData, w, r)
 
 	assert.Equal(t, expectedError, err)
-	mock.AssertExpectationsForObjects(t, dataStore, lpaStore)
+	mock.AssertExpectationsForObjects(t, shareCodeStore)
 }
 
 func TestStartWhenTemplateErrors(t *testing.T) {
 	w := httptest.NewRecorder()
-	r, _ := http.NewRequest(http.MethodGet, "/", nil)
-
-	dataStore := &mockDataStore{
-		data: page.ShareCodeData{LpaID: "lpa-id", SessionID: "session-id"},
-	}
-	dataStore.
-		On("Get", mock.Anything, mock.Anything, mock.Anything).
-		Return(nil)
+	r, _ := http.NewRequest(http.MethodGet, "/?share-code=a-share-code", nil)
 
-	lpaStore := &mockLpaStore{}
-	lpaStore.
-		On("Get", mock.Anything).
-		Return(&page.Lpa{}, nil)
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Get", r.Context(), "a-share-code").
+		Return(page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeCertificateProvider}, nil)
 
 	template := &mockTemplate{}
 	template.
 		On("Func", mock.Anything, mock.Anything).
 		Return(expectedError)
 
-	err := Start(template.Func, lpaStore, dataStore)(appData, w, r)
+	err := Start(template.Func, shareCodeStore)(appData, w, r)
 
 	assert.Equal(t, expectedError, err)
-	mock.AssertExpectationsForObjects(t, lpaStore, template)
+	mock.AssertExpectationsForObjects(t, shareCodeStore, template)
 }
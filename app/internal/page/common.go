@@ -5,7 +5,10 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/dynamo"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/event"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/identity"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/localize"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/onelogin"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/pay"
@@ -22,8 +25,17 @@ type AddressClient interface {
 
 type DataStore interface {
 	GetAll(context.Context, string, interface{}) error
+	GetAllByKeyPrefix(context.Context, string, string, interface{}) error
+	GetAllBySK(context.Context, string, interface{}) error
+	GetPageBySK(context.Context, string, string, int, interface{}) (string, error)
+	ScanByKeyPrefix(context.Context, string, string, string, interface{}) (string, error)
+	ScanKeysByPrefix(context.Context, string, string, string) ([]dynamo.Key, string, error)
 	Get(context.Context, string, string, interface{}) error
 	Put(context.Context, string, string, interface{}) error
+	PutVersioned(context.Context, string, string, interface{}, int) error
+	Create(context.Context, string, string, interface{}) error
+	Delete(context.Context, string, string) error
+	WriteTransaction(context.Context, *dynamo.Transaction) error
 }
 
 type YotiClient interface {
@@ -40,7 +52,23 @@ type PayClient interface {
 type NotifyClient interface {
 	Email(ctx context.Context, email notify.Email) (string, error)
 	Sms(ctx context.Context, sms notify.Sms) (string, error)
-	TemplateID(id notify.TemplateId) string
+	Letter(ctx context.Context, letter notify.Letter) (string, error)
+	TemplateID(id notify.TemplateId, lang localize.Lang) string
+	FindByReference(ctx context.Context, notificationType, reference string) (string, error)
+}
+
+type EventPublisher interface {
+	Publish(ctx context.Context, e event.Event) error
+}
+
+type RegistrationClient interface {
+	Register(ctx context.Context, lpa *Lpa) (Registration, error)
+}
+
+type RegistrationStore interface {
+	PutPending(ctx context.Context) error
+	DeletePending(ctx context.Context) error
+	GetAllPending(ctx context.Context) ([]PendingRegistration, error)
 }
 
 type OneLoginClient interface {
@@ -3,20 +3,35 @@ package page
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"strings"
 	"time"
 
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/identity"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/localize"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/pay"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/place"
 )
 
 const (
 	AllCanNoLongerAct                = "all"
 	CostOfLpaPence                   = 8200
+	EvidenceOfBenefits               = "benefits"
+	EvidenceOfHardship               = "hardship"
+	EvidenceOfIncome                 = "income"
+	FeeTypeExemption                 = "no-fee"
+	FeeTypeFull                      = "full-fee"
+	FeeTypeHalf                      = "half-fee"
+	FeeTypeHardship                  = "hardship-fee"
 	Jointly                          = "jointly"
 	JointlyAndSeverally              = "jointly-and-severally"
 	JointlyForSomeSeverallyForOthers = "mixed"
+	LpaStatusDraft                   = "draft"
+	LpaStatusPaid                    = "paid"
+	LpaStatusSigned                  = "signed"
+	LpaStatusSubmitted               = "submitted"
+	LpaStatusWithdrawn               = "withdrawn"
 	LpaTypeCombined                  = "both"
 	LpaTypeHealthWelfare             = "hw"
 	LpaTypePropertyFinance           = "pfa"
@@ -28,6 +43,14 @@ const (
 	UsedWhenRegistered               = "when-registered"
 )
 
+// ErrLpaWithdrawn is returned when writing an LPA that has been withdrawn, as
+// it can no longer be changed or submitted.
+var ErrLpaWithdrawn = errors.New("lpa has been withdrawn")
+
+// ErrLpaNotDeletable is returned when deleting an LPA that has gone too far to
+// be deleted, see Lpa.CanBeDeleted, or has a payment in-flight.
+var ErrLpaNotDeletable = errors.New("lpa can not be deleted")
+
 type TaskState int
 
 const (
@@ -54,6 +77,7 @@ func (t TaskState) String() string {
 type Lpa struct {
 	ID                                          string
 	UpdatedAt                                   time.Time
+	Version                                     int
 	You                                         actor.Person
 	Attorneys                                   actor.Attorneys
 	CertificateProvider                         actor.CertificateProvider
@@ -67,6 +91,9 @@ type Lpa struct {
 	Checked                                     bool
 	HappyToShare                                bool
 	PaymentDetails                              PaymentDetails
+	FeeType                                     string
+	EvidenceRequired                            []string
+	FeeEvidenceReview                           TaskState
 	CheckedAgain                                bool
 	ConfirmFreeWill                             bool
 	SignatureCode                               string
@@ -89,14 +116,89 @@ type Lpa struct {
 	WantToApplyForLpa                           bool
 	WantToSignLpa                               bool
 	Submitted                                   time.Time
+	WithdrawnAt                                 time.Time
 	CPWitnessCodeValidated                      bool
+	// ContactLanguagePreference is the language the donor was using when they
+	// gave their details. Messages about the LPA are sent in this language,
+	// including to the people the donor names, as they are not asked.
+	ContactLanguagePreference localize.Lang
+	PersonToNotifyNotices     PersonToNotifyNotices
+	AttorneyInvites           AttorneyInvites
 
 	CertificateProviderUserData identity.UserData
+	Certificate                 Certificate
+
+	AttorneyProvidedDetails            map[string]AttorneyProvidedDetails
+	ReplacementAttorneyProvidedDetails map[string]AttorneyProvidedDetails
+
+	Registration Registration
+}
+
+// Registration records the LPA being sent to the LPA store to be registered.
+// The reference is given by the LPA store when it receives the LPA.
+type Registration struct {
+	Reference   string
+	Status      string
+	SubmittedAt time.Time
+}
+
+// PendingRegistration marks a submitted LPA that has not yet been sent to the
+// LPA store, so that RegisterPendingLpas can send it.
+type PendingRegistration struct {
+	LpaID   string
+	Sub     string
+	Created time.Time
+}
+
+type Certificate struct {
+	DiscussedLpaWithDonor   bool
+	DonorUnderstandsLpa     bool
+	NoFraudOrUndueInfluence bool
+	Agreed                  time.Time
+}
+
+type AttorneyProvidedDetails struct {
+	IdentityUserData identity.UserData
+	Confirmed        time.Time
 }
 
 type PaymentDetails struct {
 	PaymentReference string
 	PaymentId        string
+	Amount           int
+	Status           string
+	StatusHistory    []PaymentStatusChange
+	SettledAt        time.Time
+}
+
+// A PaymentStatusChange records when a payment was seen to have a status. The
+// code and message are given by GOV.UK Pay when a payment did not succeed.
+type PaymentStatusChange struct {
+	PaymentId string
+	Status    string
+	Code      string
+	Message   string
+	At        time.Time
+}
+
+// Record updates the details with the latest state of payment, adding to the
+// status history when the payment or its status has changed.
+func (d *PaymentDetails) Record(payment pay.GetPaymentResponse, now time.Time) {
+	if d.PaymentId != payment.PaymentId || d.Status != payment.State.Status {
+		d.StatusHistory = append(d.StatusHistory, PaymentStatusChange{
+			PaymentId: payment.PaymentId,
+			Status:    payment.State.Status,
+			Code:      payment.State.Code,
+			Message:   payment.State.Message,
+			At:        now,
+		})
+	}
+
+	d.PaymentReference = payment.Reference
+	d.PaymentId = payment.PaymentId
+	d.Amount = payment.Amount
+	d.Status = payment.State.Status
+	d.SettledAt = payment.SettlementSummary.SettledAt()
 }
 
 type Tasks struct {
@@ -132,14 +234,59 @@ func (w *WitnessCode) HasExpired() bool {
 
 type LpaStore interface {
 	Create(context.Context) (*Lpa, error)
-	GetAll(context.Context) ([]*Lpa, error)
+	GetPage(context.Context, LpaQuery) (LpaPage, error)
+	GetAllAs(context.Context, ActorType) ([]*Lpa, error)
 	Get(context.Context) (*Lpa, error)
 	Put(context.Context, *Lpa) error
+	Delete(context.Context) error
+	Link(context.Context) error
+	History(context.Context) ([]LpaEvent, error)
+}
+
+// An LpaQuery asks for a page of up to Limit LPAs that the current subject is
+// linked to as ActorType, most recently linked first. When Status is set only
+// LPAs with that status are included. Cursor is empty for the first page, or
+// the Next cursor of the previous page.
+type LpaQuery struct {
+	ActorType ActorType
+	Status    string
+	Cursor    string
+	Limit     int
+}
+
+// An LpaPage is a page of LPAs. Next is empty when there are no more pages.
+type LpaPage struct {
+	Lpas []*Lpa
+	Next string
+}
+
+type ShareCodeStore interface {
+	Create(context.Context, ShareCodeData) (string, error)
+	Get(context.Context, string) (ShareCodeData, error)
+	Redeem(context.Context, string) (ShareCodeData, error)
+	Revoke(ctx context.Context, lpaID string, actorType ActorType, actorID, keepShareCode string) error
+	RevokeAll(ctx context.Context, lpaID string) error
+}
+
+type PaymentStore interface {
+	PutInFlight(ctx context.Context, paymentID string) error
+	DeleteInFlight(context.Context) error
+	GetAllInFlight(context.Context) ([]InFlightPayment, error)
+}
+
+// An InFlightPayment is a payment that had not finished when last checked, so
+// that it can be checked again if the donor does not return from GOV.UK Pay.
+type InFlightPayment struct {
+	LpaID     string
+	Sub       string
+	PaymentID string
+	Created   time.Time
 }
 
 type SessionData struct {
-	SessionID string
 	LpaID     string
+	ActorType ActorType
+	Subject   string
 }
 
 func SessionDataFromContext(ctx context.Context) *SessionData {
@@ -174,6 +321,96 @@ func (l *Lpa) TypeLegalTermTransKey() string {
 	return ""
 }
 
+// A FeeItem is the fee for one LPA. A combined application is for two LPAs,
+// so is charged for each of them.
+type FeeItem struct {
+	Type   string
+	Amount int
+}
+
+// FeeItems returns the fee for each LPA being applied for, after any fee
+// reduction. An exemption or hardship application is free to submit, as the
+// fee is decided when the evidence is reviewed.
+func (l *Lpa) FeeItems() []FeeItem {
+	amount := CostOfLpaPence
+	switch l.FeeType {
+	case FeeTypeHalf:
+		amount = CostOfLpaPence / 2
+	case FeeTypeExemption, FeeTypeHardship:
+		amount = 0
+	}
+
+	if l.Type == LpaTypeCombined {
+		return []FeeItem{
+			{Type: LpaTypePropertyFinance, Amount: amount},
+			{Type: LpaTypeHealthWelfare, Amount: amount},
+		}
+	}
+
+	return []FeeItem{{Type: l.Type, Amount: amount}}
+}
+
+// Cost returns the amount, in pence, the donor must pay.
+func (l *Lpa) Cost() int {
+	cost := 0
+	for _, item := range l.FeeItems() {
+		cost += item.Amount
+	}
+
+	return cost
+}
+
+// FeeEvidenceReviewPending reports whether evidence for a fee reduction has
+// been sent but not yet reviewed.
+func (l *Lpa) FeeEvidenceReviewPending() bool {
+	return l.FeeEvidenceReview == TaskInProgress
+}
+
+// FeePaid reports whether a payment for the LPA has succeeded.
+func (l *Lpa) FeePaid() bool {
+	return l.PaymentDetails.Status == pay.StatusSuccess
+}
+
+// FeeTypeLocked reports whether the fee type can no longer be changed, as the
+// fee has been paid or settled.
+func (l *Lpa) FeeTypeLocked() bool {
+	return l.FeePaid() || l.Tasks.PayForLpa.Completed()
+}
+
+// FeeSettled reports whether the fee has been paid, and any evidence for a fee
+// reduction accepted, so that the LPA can be signed and submitted.
+func (l *Lpa) FeeSettled() bool {
+	return l.Tasks.PayForLpa.Completed() && !l.FeeEvidenceReviewPending()
+}
+
+// Status gives how far the LPA has got: a draft until it is paid for, then
+// signed once the donor has signed, and submitted once the certificate provider
+// has witnessed their signature. A withdrawn LPA can go no further.
+func (l *Lpa) Status() string {
+	switch {
+	case !l.WithdrawnAt.IsZero():
+		return LpaStatusWithdrawn
+	case !l.Submitted.IsZero():
+		return LpaStatusSubmitted
+	case l.Tasks.ConfirmYourIdentityAndSign.Completed():
+		return LpaStatusSigned
+	case l.Tasks.PayForLpa.Completed():
+		return LpaStatusPaid
+	default:
+		return LpaStatusDraft
+	}
+}
+
+// CanBeDeleted reports whether the LPA can be deleted outright, rather than
+// withdrawn. Only a draft that the donor has not started to pay for, or sent
+// evidence for a fee reduction for, can be.
+func (l *Lpa) CanBeDeleted() bool {
+	return l.Status() == LpaStatusDraft &&
+		l.PaymentDetails.PaymentId == "" &&
+		l.Tasks.PayForLpa == TaskNotStarted &&
+		l.FeeEvidenceReview == TaskNotStarted
+}
+
 func (l *Lpa) AttorneysAndCpSigningDeadline() time.Time {
 	return l.Submitted.Add((24 * time.Hour) * 28)
 }
@@ -193,7 +430,7 @@ func (l *Lpa) CanGoTo(url string) bool {
 			l.Tasks.Restrictions.Completed() &&
 			l.Tasks.CertificateProvider.Completed() &&
 			l.Tasks.PeopleToNotify.Completed()
-	case Paths.AboutPayment:
+	case Paths.AboutPayment, Paths.WhichFeeTypeAreYouApplyingFor, Paths.EvidenceRequired:
 		return l.Tasks.YourDetails.Completed() &&
 			l.Tasks.ChooseAttorneys.Completed() &&
 			l.Tasks.ChooseReplacementAttorneys.Completed() &&
@@ -202,8 +439,9 @@ func (l *Lpa) CanGoTo(url string) bool {
 			l.Tasks.CertificateProvider.Completed() &&
 			l.Tasks.PeopleToNotify.Completed() &&
 			l.Tasks.CheckYourLpa.Completed()
-	case Paths.SelectYourIdentityOptions, Paths.HowToConfirmYourIdentityAndSign:
-		return l.Tasks.PayForLpa.Completed()
+	case Paths.SelectYourIdentityOptions, Paths.HowToConfirmYourIdentityAndSign, Paths.ReadYourLpa, Paths.SignYourLpa,
+		Paths.WitnessingYourSignature, Paths.WitnessingAsCertificateProvider:
+		return l.FeeSettled()
 	case "":
 		return false
 	default:
@@ -227,6 +465,19 @@ func (l *Lpa) Progress() Progress {
 
 	if p.LpaSigned.Completed() {
 		p.CertificateProviderDeclared = TaskInProgress
+		p.AttorneysDeclared = TaskInProgress
+	}
+
+	if !l.Certificate.Agreed.IsZero() {
+		p.CertificateProviderDeclared = TaskCompleted
+	}
+
+	if l.AllAttorneysDeclared() {
+		p.AttorneysDeclared = TaskCompleted
+	}
+
+	if l.Registration.Reference != "" {
+		p.LpaSubmitted = TaskCompleted
 	}
 
 	// Further logic to be added as we build the rest of the flow
@@ -234,7 +485,62 @@ func (l *Lpa) Progress() Progress {
 	return p
 }
 
+func (l *Lpa) GetAttorneyProvidedDetails(id string, isReplacement bool) AttorneyProvidedDetails {
+	if isReplacement {
+		return l.ReplacementAttorneyProvidedDetails[id]
+	}
+
+	return l.AttorneyProvidedDetails[id]
+}
+
+func (l *Lpa) PutAttorneyProvidedDetails(id string, isReplacement bool, details AttorneyProvidedDetails) {
+	if isReplacement {
+		if l.ReplacementAttorneyProvidedDetails == nil {
+			l.ReplacementAttorneyProvidedDetails = map[string]AttorneyProvidedDetails{}
+		}
+		l.ReplacementAttorneyProvidedDetails[id] = details
+	} else {
+		if l.AttorneyProvidedDetails == nil {
+			l.AttorneyProvidedDetails = map[string]AttorneyProvidedDetails{}
+		}
+		l.AttorneyProvidedDetails[id] = details
+	}
+}
+
+func (l *Lpa) AllAttorneysDeclared() bool {
+	if len(l.Attorneys) == 0 {
+		return false
+	}
+
+	for _, a := range l.Attorneys {
+		if l.AttorneyProvidedDetails[a.ID].Confirmed.IsZero() {
+			return false
+		}
+	}
+
+	for _, a := range l.ReplacementAttorneys {
+		if l.ReplacementAttorneyProvidedDetails[a.ID].Confirmed.IsZero() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ShareCodeData is stored against a share code sent to someone so they can
+// access an LPA. A code can only be redeemed by one person, and stops working
+// once it has expired or been revoked.
 type ShareCodeData struct {
-	SessionID string
-	LpaID     string
+	LpaID                 string
+	ActorType             ActorType
+	ActorID               string
+	IsReplacementAttorney bool
+	Expires               time.Time
+	RedeemedBy            string
+	Revoked               bool
+	Version               int
+}
+
+func (d ShareCodeData) ExpiresAt() time.Time {
+	return d.Expires
 }
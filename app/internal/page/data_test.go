@@ -8,7 +8,9 @@ import (
 
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/date"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/dynamo"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/identity"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/pay"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/place"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -41,6 +43,38 @@ func (m *mockDataStore) GetAll(ctx context.Context, pk string, v interface{}) er
 	return m.Called(ctx, pk).Error(0)
 }
 
+func (m *mockDataStore) GetPageBySK(ctx context.Context, sk, cursor string, limit int, v interface{}) (string, error) {
+	data, _ := json.Marshal(m.data)
+	json.Unmarshal(data, v)
+	args := m.Called(ctx, sk, cursor, limit)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockDataStore) ScanByKeyPrefix(ctx context.Context, pkPrefix, skPrefix, cursor string, v interface{}) (string, error) {
+	data, _ := json.Marshal(m.data)
+	json.Unmarshal(data, v)
+	args := m.Called(ctx, pkPrefix, skPrefix, cursor)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockDataStore) ScanKeysByPrefix(ctx context.Context, pkPrefix, skPrefix, cursor string) ([]dynamo.Key, string, error) {
+	args := m.Called(ctx, pkPrefix, skPrefix, cursor)
+	keys, _ := args.Get(0).([]dynamo.Key)
+	return keys, args.String(1), args.Error(2)
+}
+
+func (m *mockDataStore) GetAllByKeyPrefix(ctx context.Context, pk, skPrefix string, v interface{}) error {
+	data, _ := json.Marshal(m.data)
+	json.Unmarshal(data, v)
+	return m.Called(ctx, pk, skPrefix).Error(0)
+}
+
+func (m *mockDataStore) GetAllBySK(ctx context.Context, sk string, v interface{}) error {
+	data, _ := json.Marshal(m.data)
+	json.Unmarshal(data, v)
+	return m.Called(ctx, sk).Error(0)
+}
+
 func (m *mockDataStore) Get(ctx context.Context, pk, sk string, v interface{}) error {
 	data, _ := json.Marshal(m.data)
 	json.Unmarshal(data, v)
@@ -51,6 +85,22 @@ func (m *mockDataStore) Put(ctx context.Context, pk, sk string, v interface{}) e
 	return m.Called(ctx, pk, sk, v).Error(0)
 }
 
+func (m *mockDataStore) PutVersioned(ctx context.Context, pk, sk string, v interface{}, version int) error {
+	return m.Called(ctx, pk, sk, v, version).Error(0)
+}
+
+func (m *mockDataStore) Delete(ctx context.Context, pk, sk string) error {
+	return m.Called(ctx, pk, sk).Error(0)
+}
+
+func (m *mockDataStore) Create(ctx context.Context, pk, sk string, v interface{}) error {
+	return m.Called(ctx, pk, sk, v).Error(0)
+}
+
+func (m *mockDataStore) WriteTransaction(ctx context.Context, transaction *dynamo.Transaction) error {
+	return m.Called(ctx, transaction).Error(0)
+}
+
 func TestIdentityConfirmed(t *testing.T) {
 	testCases := map[string]struct {
 		lpa      *Lpa
@@ -159,6 +209,89 @@ func TestAttorneysSigningDeadline(t *testing.T) {
 	assert.Equal(t, expected, lpa.AttorneysAndCpSigningDeadline())
 }
 
+func TestLpaCost(t *testing.T) {
+	testCases := map[string]int{
+		"":               8200,
+		FeeTypeFull:      8200,
+		FeeTypeHalf:      4100,
+		FeeTypeExemption: 0,
+		FeeTypeHardship:  0,
+	}
+
+	for feeType, cost := range testCases {
+		t.Run(feeType, func(t *testing.T) {
+			lpa := &Lpa{Type: LpaTypePropertyFinance, FeeType: feeType}
+			assert.Equal(t, cost, lpa.Cost())
+
+			lpa.Type = LpaTypeCombined
+			assert.Equal(t, cost*2, lpa.Cost())
+		})
+	}
+}
+
+func TestLpaFeeItems(t *testing.T) {
+	testCases := map[string]struct {
+		lpa      *Lpa
+		expected []FeeItem
+	}{
+		"property and finance": {
+			lpa:      &Lpa{Type: LpaTypePropertyFinance},
+			expected: []FeeItem{{Type: LpaTypePropertyFinance, Amount: 8200}},
+		},
+		"health and welfare with half fee": {
+			lpa:      &Lpa{Type: LpaTypeHealthWelfare, FeeType: FeeTypeHalf},
+			expected: []FeeItem{{Type: LpaTypeHealthWelfare, Amount: 4100}},
+		},
+		"combined": {
+			lpa: &Lpa{Type: LpaTypeCombined},
+			expected: []FeeItem{
+				{Type: LpaTypePropertyFinance, Amount: 8200},
+				{Type: LpaTypeHealthWelfare, Amount: 8200},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.lpa.FeeItems())
+		})
+	}
+}
+
+func TestLpaStatus(t *testing.T) {
+	testCases := map[string]struct {
+		lpa      *Lpa
+		expected string
+	}{
+		"draft": {
+			lpa:      &Lpa{Tasks: Tasks{PayForLpa: TaskInProgress}},
+			expected: LpaStatusDraft,
+		},
+		"paid": {
+			lpa:      &Lpa{Tasks: Tasks{PayForLpa: TaskCompleted, ConfirmYourIdentityAndSign: TaskInProgress}},
+			expected: LpaStatusPaid,
+		},
+		"signed": {
+			lpa:      &Lpa{Tasks: Tasks{PayForLpa: TaskCompleted, ConfirmYourIdentityAndSign: TaskCompleted}},
+			expected: LpaStatusSigned,
+		},
+		"submitted": {
+			lpa:      &Lpa{Tasks: Tasks{PayForLpa: TaskCompleted, ConfirmYourIdentityAndSign: TaskCompleted}, Submitted: time.Now()},
+			expected: LpaStatusSubmitted,
+		},
+		"withdrawn": {
+			lpa:      &Lpa{Tasks: Tasks{PayForLpa: TaskCompleted}, Submitted: time.Now(), WithdrawnAt: time.Now()},
+			expected: LpaStatusWithdrawn,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.lpa.Status())
+		})
+	}
+}
+
 func TestCanGoTo(t *testing.T) {
 	testCases := map[string]struct {
 		lpa      *Lpa
@@ -194,6 +327,11 @@ func TestCanGoTo(t *testing.T) {
 			url:      Paths.AboutPayment,
 			expected: true,
 		},
+		"which fee type without task": {
+			lpa:      &Lpa{},
+			url:      Paths.WhichFeeTypeAreYouApplyingFor,
+			expected: false,
+		},
 		"select your identity options without task": {
 			lpa:      &Lpa{},
 			url:      Paths.SelectYourIdentityOptions,
@@ -204,6 +342,16 @@ func TestCanGoTo(t *testing.T) {
 			url:      Paths.SelectYourIdentityOptions,
 			expected: true,
 		},
+		"sign your lpa while fee evidence is reviewed": {
+			lpa:      &Lpa{FeeEvidenceReview: TaskInProgress, Tasks: Tasks{PayForLpa: TaskCompleted}},
+			url:      Paths.SignYourLpa,
+			expected: false,
+		},
+		"sign your lpa once fee evidence is reviewed": {
+			lpa:      &Lpa{FeeEvidenceReview: TaskCompleted, Tasks: Tasks{PayForLpa: TaskCompleted}},
+			url:      Paths.SignYourLpa,
+			expected: true,
+		},
 	}
 
 	for name, tc := range testCases {
@@ -213,6 +361,42 @@ func TestCanGoTo(t *testing.T) {
 	}
 }
 
+func TestLpaFeeSettled(t *testing.T) {
+	testCases := map[string]struct {
+		lpa     *Lpa
+		locked  bool
+		settled bool
+	}{
+		"not paid": {
+			lpa: &Lpa{},
+		},
+		"paid": {
+			lpa:     &Lpa{PaymentDetails: PaymentDetails{Status: pay.StatusSuccess}, Tasks: Tasks{PayForLpa: TaskCompleted}},
+			locked:  true,
+			settled: true,
+		},
+		"paid with evidence to review": {
+			lpa:    &Lpa{PaymentDetails: PaymentDetails{Status: pay.StatusSuccess}, FeeEvidenceReview: TaskInProgress, Tasks: Tasks{PayForLpa: TaskInProgress}},
+			locked: true,
+		},
+		"no fee with evidence to review": {
+			lpa: &Lpa{FeeType: FeeTypeExemption, FeeEvidenceReview: TaskInProgress, Tasks: Tasks{PayForLpa: TaskInProgress}},
+		},
+		"no fee with evidence accepted": {
+			lpa:     &Lpa{FeeType: FeeTypeExemption, FeeEvidenceReview: TaskCompleted, Tasks: Tasks{PayForLpa: TaskCompleted}},
+			locked:  true,
+			settled: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.locked, tc.lpa.FeeTypeLocked())
+			assert.Equal(t, tc.settled, tc.lpa.FeeSettled())
+		})
+	}
+}
+
 func TestTaskStateString(t *testing.T) {
 	testCases := []struct {
 		State    TaskState
@@ -238,3 +422,194 @@ func TestTaskStateString(t *testing.T) {
 		})
 	}
 }
+
+func TestProgress(t *testing.T) {
+	testCases := map[string]struct {
+		lpa              *Lpa
+		expectedProgress Progress
+	}{
+		"initial state": {
+			lpa: &Lpa{},
+			expectedProgress: Progress{
+				LpaSigned:                   TaskInProgress,
+				CertificateProviderDeclared: TaskNotStarted,
+				AttorneysDeclared:           TaskNotStarted,
+				LpaSubmitted:                TaskNotStarted,
+				StatutoryWaitingPeriod:      TaskNotStarted,
+				LpaRegistered:               TaskNotStarted,
+			},
+		},
+		"lpa signed": {
+			lpa: &Lpa{Submitted: time.Now()},
+			expectedProgress: Progress{
+				LpaSigned:                   TaskCompleted,
+				CertificateProviderDeclared: TaskInProgress,
+				AttorneysDeclared:           TaskInProgress,
+				LpaSubmitted:                TaskNotStarted,
+				StatutoryWaitingPeriod:      TaskNotStarted,
+				LpaRegistered:               TaskNotStarted,
+			},
+		},
+		"certificate provided": {
+			lpa: &Lpa{
+				Submitted:   time.Now(),
+				Certificate: Certificate{Agreed: time.Now()},
+			},
+			expectedProgress: Progress{
+				LpaSigned:                   TaskCompleted,
+				CertificateProviderDeclared: TaskCompleted,
+				AttorneysDeclared:           TaskInProgress,
+				LpaSubmitted:                TaskNotStarted,
+				StatutoryWaitingPeriod:      TaskNotStarted,
+				LpaRegistered:               TaskNotStarted,
+			},
+		},
+		"attorneys declared": {
+			lpa: &Lpa{
+				Submitted:               time.Now(),
+				Attorneys:               actor.Attorneys{{ID: "a1"}},
+				ReplacementAttorneys:    actor.Attorneys{{ID: "r1"}},
+				AttorneyProvidedDetails: map[string]AttorneyProvidedDetails{"a1": {Confirmed: time.Now()}},
+				ReplacementAttorneyProvidedDetails: map[string]AttorneyProvidedDetails{
+					"r1": {Confirmed: time.Now()},
+				},
+			},
+			expectedProgress: Progress{
+				LpaSigned:                   TaskCompleted,
+				CertificateProviderDeclared: TaskInProgress,
+				AttorneysDeclared:           TaskCompleted,
+				LpaSubmitted:                TaskNotStarted,
+				StatutoryWaitingPeriod:      TaskNotStarted,
+				LpaRegistered:               TaskNotStarted,
+			},
+		},
+		"lpa submitted": {
+			lpa: &Lpa{
+				Submitted:    time.Now(),
+				Registration: Registration{Reference: "M-1234", Status: "received"},
+			},
+			expectedProgress: Progress{
+				LpaSigned:                   TaskCompleted,
+				CertificateProviderDeclared: TaskInProgress,
+				AttorneysDeclared:           TaskInProgress,
+				LpaSubmitted:                TaskCompleted,
+				StatutoryWaitingPeriod:      TaskNotStarted,
+				LpaRegistered:               TaskNotStarted,
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedProgress, tc.lpa.Progress())
+		})
+	}
+}
+
+func TestAllAttorneysDeclared(t *testing.T) {
+	testCases := map[string]struct {
+		lpa      *Lpa
+		expected bool
+	}{
+		"no attorneys": {
+			lpa: &Lpa{},
+		},
+		"attorney not declared": {
+			lpa: &Lpa{
+				Attorneys: actor.Attorneys{{ID: "a1"}, {ID: "a2"}},
+				AttorneyProvidedDetails: map[string]AttorneyProvidedDetails{
+					"a1": {Confirmed: time.Now()},
+				},
+			},
+		},
+		"replacement attorney not declared": {
+			lpa: &Lpa{
+				Attorneys:               actor.Attorneys{{ID: "a1"}},
+				ReplacementAttorneys:    actor.Attorneys{{ID: "r1"}},
+				AttorneyProvidedDetails: map[string]AttorneyProvidedDetails{"a1": {Confirmed: time.Now()}},
+			},
+		},
+		"all declared": {
+			lpa: &Lpa{
+				Attorneys:               actor.Attorneys{{ID: "a1"}},
+				AttorneyProvidedDetails: map[string]AttorneyProvidedDetails{"a1": {Confirmed: time.Now()}},
+			},
+			expected: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.lpa.AllAttorneysDeclared())
+		})
+	}
+}
+
+func TestPutAttorneyProvidedDetails(t *testing.T) {
+	lpa := &Lpa{}
+	lpa.PutAttorneyProvidedDetails("a1", false, AttorneyProvidedDetails{IdentityUserData: identity.UserData{OK: true}})
+	lpa.PutAttorneyProvidedDetails("r1", true, AttorneyProvidedDetails{IdentityUserData: identity.UserData{FullName: "a"}})
+
+	assert.Equal(t, AttorneyProvidedDetails{IdentityUserData: identity.UserData{OK: true}}, lpa.GetAttorneyProvidedDetails("a1", false))
+	assert.Equal(t, AttorneyProvidedDetails{IdentityUserData: identity.UserData{FullName: "a"}}, lpa.GetAttorneyProvidedDetails("r1", true))
+	assert.Equal(t, AttorneyProvidedDetails{}, lpa.GetAttorneyProvidedDetails("r1", false))
+}
+
+func TestPaymentDetailsRecord(t *testing.T) {
+	now := time.Date(2023, time.January, 2, 3, 4, 5, 6, time.UTC)
+	later := now.Add(time.Minute)
+
+	details := PaymentDetails{}
+	details.Record(pay.GetPaymentResponse{
+		PaymentId: "payment-id",
+		Reference: "ref",
+		Amount:    8200,
+		State:     pay.State{Status: pay.StatusStarted},
+	}, now)
+	details.Record(pay.GetPaymentResponse{
+		PaymentId: "payment-id",
+		Reference: "ref",
+		Amount:    8200,
+		State:     pay.State{Status: pay.StatusStarted},
+	}, later)
+	details.Record(pay.GetPaymentResponse{
+		PaymentId:         "payment-id",
+		Reference:         "ref",
+		Amount:            8200,
+		State:             pay.State{Status: pay.StatusSuccess, Finished: true},
+		SettlementSummary: pay.SettlementSummary{SettledDate: "2023-01-03"},
+	}, later)
+
+	assert.Equal(t, PaymentDetails{
+		PaymentReference: "ref",
+		PaymentId:        "payment-id",
+		Amount:           8200,
+		Status:           pay.StatusSuccess,
+		StatusHistory: []PaymentStatusChange{
+			{PaymentId: "payment-id", Status: pay.StatusStarted, At: now},
+			{PaymentId: "payment-id", Status: pay.StatusSuccess, At: later},
+		},
+		SettledAt: time.Date(2023, time.January, 3, 0, 0, 0, 0, time.UTC),
+	}, details)
+}
+
+func TestPaymentDetailsRecordWhenNewPayment(t *testing.T) {
+	now := time.Date(2023, time.January, 2, 3, 4, 5, 6, time.UTC)
+
+	details := PaymentDetails{
+		PaymentId: "payment-id",
+		Status:    pay.StatusFailed,
+		StatusHistory: []PaymentStatusChange{
+			{PaymentId: "payment-id", Status: pay.StatusFailed, Code: "P0010", Message: "Payment method rejected"},
+		},
+	}
+	details.Record(pay.GetPaymentResponse{
+		PaymentId: "other-payment-id",
+		State:     pay.State{Status: pay.StatusFailed, Code: "P0030", Message: "Payment was cancelled by the user"},
+	}, now)
+
+	assert.Equal(t, []PaymentStatusChange{
+		{PaymentId: "payment-id", Status: pay.StatusFailed, Code: "P0010", Message: "Payment method rejected"},
+		{PaymentId: "other-payment-id", Status: pay.StatusFailed, Code: "P0030", Message: "Payment was cancelled by the user", At: now},
+	}, details.StatusHistory)
+}
@@ -14,32 +14,47 @@ import (
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/validation"
 )
 
+var paymentDescriptions = map[string]string{
+	page.LpaTypePropertyFinance: "Property and Finance LPA",
+	page.LpaTypeHealthWelfare:   "Health and Welfare LPA",
+	page.LpaTypeCombined:        "Property and Finance LPA and Health and Welfare LPA",
+}
+
 type aboutPaymentData struct {
 	App                 page.AppData
 	Errors              validation.List
 	CertificateProvider actor.CertificateProvider
 }
 
-func AboutPayment(logger page.Logger, tmpl template.Template, sessionStore sessions.Store, payClient page.PayClient, appPublicUrl string, randomString func(int) string, lpaStore page.LpaStore) page.Handler {
+func AboutPayment(logger page.Logger, tmpl template.Template, sessionStore sessions.Store, payClient page.PayClient, appPublicUrl string, randomString func(int) string, lpaStore page.LpaStore, paymentStore page.PaymentStore) page.Handler {
 	return func(appData page.AppData, w http.ResponseWriter, r *http.Request) error {
 		lpa, err := lpaStore.Get(r.Context())
 		if err != nil {
 			return err
 		}
 
+		if lpa.FeeTypeLocked() {
+			return appData.Redirect(w, r, lpa, page.Paths.TaskList)
+		}
+
 		data := &aboutPaymentData{
 			App:                 appData,
 			CertificateProvider: lpa.CertificateProvider,
 		}
 
 		if r.Method == http.MethodPost {
+			if lpa.Cost() == 0 {
+				return appData.Redirect(w, r, lpa, page.Paths.EvidenceRequired)
+			}
+
 			createPaymentBody := pay.CreatePaymentBody{
-				Amount:      page.CostOfLpaPence,
+				Amount:      lpa.Cost(),
 				Reference:   randomString(12),
-				Description: "Property and Finance LPA",
+				Description: paymentDescriptions[lpa.Type],
 				ReturnUrl:   appPublicUrl + appData.BuildUrl(page.Paths.PaymentConfirmation),
-				Email:       "a@b.com",
+				Email:       lpa.You.Email,
 				Language:    appData.Lang.String(),
+				Metadata:    map[string]string{"lpa_id": lpa.ID},
 			}
 
 			resp, err := payClient.CreatePayment(createPaymentBody)
@@ -52,6 +67,10 @@ func AboutPayment(logger page.Logger, tmpl template.Template, sessionStore sessi
 				return err
 			}
 
+			if err = paymentStore.PutInFlight(r.Context(), resp.PaymentId); err != nil {
+				return err
+			}
+
 			nextUrl := resp.Links["next_url"].Href
 			// If URL matches expected domain for GOV UK PAY redirect there. If not,
 			// redirect to the confirmation code and carry on with flow.
@@ -37,7 +37,7 @@ func TestGetAboutPayment(t *testing.T) {
 
 		payClient := mockPayClient{BaseURL: "http://base.url"}
 
-		err := AboutPayment(&mockLogger{}, template.Func, &mockSessionsStore{}, &payClient, publicUrl, random, lpaStore)(appData, w, r)
+		err := AboutPayment(&mockLogger{}, template.Func, &mockSessionsStore{}, &payClient, publicUrl, random, lpaStore, nil)(appData, w, r)
 		resp := w.Result()
 
 		assert.Nil(t, err)
@@ -58,7 +58,7 @@ func TestGetAboutPayment(t *testing.T) {
 
 		payClient := mockPayClient{BaseURL: "http://base.url"}
 
-		err := AboutPayment(&mockLogger{}, template.Func, &mockSessionsStore{}, &payClient, publicUrl, random, lpaStore)(appData, w, r)
+		err := AboutPayment(&mockLogger{}, template.Func, &mockSessionsStore{}, &payClient, publicUrl, random, lpaStore, nil)(appData, w, r)
 		resp := w.Result()
 
 		assert.Equal(t, err, expectedError)
@@ -82,7 +82,7 @@ func TestGetAboutPayment(t *testing.T) {
 
 		payClient := mockPayClient{BaseURL: "http://base.url"}
 
-		err := AboutPayment(&mockLogger{}, template.Func, &mockSessionsStore{}, &payClient, publicUrl, random, lpaStore)(appData, w, r)
+		err := AboutPayment(&mockLogger{}, template.Func, &mockSessionsStore{}, &payClient, publicUrl, random, lpaStore, nil)(appData, w, r)
 		resp := w.Result()
 
 		assert.Equal(t, expectedError, err)
@@ -117,7 +117,7 @@ func TestPostAboutPayment(t *testing.T) {
 				lpaStore := &mockLpaStore{}
 				lpaStore.
 					On("Get", r.Context()).
-					Return(&page.Lpa{CertificateProvider: actor.CertificateProvider{}}, nil)
+					Return(&page.Lpa{ID: "lpa-id", Type: page.LpaTypePropertyFinance, You: actor.Person{Email: "donor@example.com"}}, nil)
 
 				template := &mockTemplate{}
 				template.
@@ -149,8 +149,9 @@ func TestPostAboutPayment(t *testing.T) {
 						Reference:   "123456789012",
 						Description: "Property and Finance LPA",
 						ReturnUrl:   "http://example.org/lpa/lpa-id/payment-confirmation",
-						Email:       "a@b.com",
+						Email:       "donor@example.com",
 						Language:    "en",
+						Metadata:    map[string]string{"lpa_id": "lpa-id"},
 					}).
 					Return(pay.CreatePaymentResponse{
 						PaymentId: "a-fake-id",
@@ -161,18 +162,118 @@ func TestPostAboutPayment(t *testing.T) {
 						},
 					}, nil)
 
-				err := AboutPayment(&mockLogger{}, template.Func, sessionsStore, &payClient, publicUrl, random, lpaStore)(appData, w, r)
+				paymentStore := &mockPaymentStore{}
+				paymentStore.
+					On("PutInFlight", r.Context(), "a-fake-id").
+					Return(nil)
+
+				err := AboutPayment(&mockLogger{}, template.Func, sessionsStore, &payClient, publicUrl, random, lpaStore, paymentStore)(appData, w, r)
 				resp := w.Result()
 
 				assert.Nil(t, err)
 				assert.Equal(t, http.StatusFound, resp.StatusCode)
 				assert.Equal(t, tc.expectedNextUrlPath, resp.Header.Get("Location"))
 
-				mock.AssertExpectationsForObjects(t, template, &payClient, sessionsStore)
+				mock.AssertExpectationsForObjects(t, template, &payClient, sessionsStore, paymentStore)
 			})
 		}
 	})
 
+	t.Run("Charges for each LPA in a combined application", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodPost, "/about-payment", nil)
+
+		lpaStore := &mockLpaStore{}
+		lpaStore.
+			On("Get", r.Context()).
+			Return(&page.Lpa{ID: "lpa-id", Type: page.LpaTypeCombined, You: actor.Person{Email: "donor@example.com"}}, nil)
+
+		sessionsStore := &mockSessionsStore{}
+		sessionsStore.
+			On("Save", mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+
+		payClient := mockPayClient{BaseURL: "http://base.url"}
+		payClient.
+			On("CreatePayment", pay.CreatePaymentBody{
+				Amount:      16400,
+				Reference:   "123456789012",
+				Description: "Property and Finance LPA and Health and Welfare LPA",
+				ReturnUrl:   "http://example.org/lpa/lpa-id/payment-confirmation",
+				Email:       "donor@example.com",
+				Language:    "en",
+				Metadata:    map[string]string{"lpa_id": "lpa-id"},
+			}).
+			Return(pay.CreatePaymentResponse{PaymentId: "a-fake-id"}, nil)
+
+		paymentStore := &mockPaymentStore{}
+		paymentStore.
+			On("PutInFlight", r.Context(), "a-fake-id").
+			Return(nil)
+
+		template := &mockTemplate{}
+		template.
+			On("Func", w, mock.Anything).
+			Return(nil)
+
+		err := AboutPayment(&mockLogger{}, template.Func, sessionsStore, &payClient, publicUrl, random, lpaStore, paymentStore)(appData, w, r)
+
+		assert.Nil(t, err)
+		mock.AssertExpectationsForObjects(t, &payClient, paymentStore)
+	})
+
+	t.Run("Charges the reduced fee", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodPost, "/about-payment", nil)
+
+		lpaStore := &mockLpaStore{}
+		lpaStore.
+			On("Get", r.Context()).
+			Return(&page.Lpa{FeeType: page.FeeTypeHalf}, nil)
+
+		sessionsStore := &mockSessionsStore{}
+		sessionsStore.
+			On("Save", mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+
+		payClient := mockPayClient{BaseURL: "http://base.url"}
+		payClient.
+			On("CreatePayment", mock.MatchedBy(func(body pay.CreatePaymentBody) bool { return body.Amount == 4100 })).
+			Return(pay.CreatePaymentResponse{PaymentId: "a-fake-id"}, nil)
+
+		paymentStore := &mockPaymentStore{}
+		paymentStore.
+			On("PutInFlight", r.Context(), "a-fake-id").
+			Return(nil)
+
+		template := &mockTemplate{}
+		template.
+			On("Func", w, mock.Anything).
+			Return(nil)
+
+		err := AboutPayment(&mockLogger{}, template.Func, sessionsStore, &payClient, publicUrl, random, lpaStore, paymentStore)(appData, w, r)
+
+		assert.Nil(t, err)
+		mock.AssertExpectationsForObjects(t, &payClient, paymentStore)
+	})
+
+	t.Run("Skips payment when there is no fee", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodPost, "/about-payment", nil)
+
+		lpaStore := &mockLpaStore{}
+		lpaStore.
+			On("Get", r.Context()).
+			Return(&page.Lpa{ID: "lpa-id", FeeType: page.FeeTypeExemption, Tasks: readyToPay}, nil)
+
+		err := AboutPayment(&mockLogger{}, nil, nil, nil, publicUrl, random, lpaStore, nil)(appData, w, r)
+		resp := w.Result()
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+		assert.Equal(t, "/lpa/lpa-id"+page.Paths.EvidenceRequired, resp.Header.Get("Location"))
+	})
+
 	t.Run("Returns error when cannot create payment", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		r, _ := http.NewRequest(http.MethodPost, "/about-payment", nil)
@@ -196,7 +297,7 @@ func TestPostAboutPayment(t *testing.T) {
 			On("CreatePayment", mock.Anything).
 			Return(pay.CreatePaymentResponse{}, expectedError)
 
-		err := AboutPayment(logger, template.Func, sessionsStore, &payClient, publicUrl, random, lpaStore)(appData, w, r)
+		err := AboutPayment(logger, template.Func, sessionsStore, &payClient, publicUrl, random, lpaStore, nil)(appData, w, r)
 
 		assert.Equal(t, expectedError, err, "Expected error was not returned")
 		mock.AssertExpectationsForObjects(t, logger, &payClient)
@@ -227,9 +328,39 @@ func TestPostAboutPayment(t *testing.T) {
 			On("CreatePayment", mock.Anything).
 			Return(pay.CreatePaymentResponse{Links: map[string]pay.Link{"next_url": {Href: "http://example.url"}}}, nil)
 
-		err := AboutPayment(logger, template.Func, sessionsStore, &payClient, publicUrl, random, lpaStore)(appData, w, r)
+		err := AboutPayment(logger, template.Func, sessionsStore, &payClient, publicUrl, random, lpaStore, nil)(appData, w, r)
 
 		assert.Equal(t, expectedError, err, "Expected error was not returned")
 		mock.AssertExpectationsForObjects(t, sessionsStore, &payClient)
 	})
+
+	t.Run("Returns error when cannot put in-flight payment", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodPost, "/about-payment", nil)
+
+		lpaStore := &mockLpaStore{}
+		lpaStore.
+			On("Get", r.Context()).
+			Return(&page.Lpa{CertificateProvider: actor.CertificateProvider{}}, nil)
+
+		sessionsStore := &mockSessionsStore{}
+		sessionsStore.
+			On("Save", mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+
+		payClient := mockPayClient{BaseURL: "http://base.url"}
+		payClient.
+			On("CreatePayment", mock.Anything).
+			Return(pay.CreatePaymentResponse{PaymentId: "a-fake-id"}, nil)
+
+		paymentStore := &mockPaymentStore{}
+		paymentStore.
+			On("PutInFlight", r.Context(), "a-fake-id").
+			Return(expectedError)
+
+		err := AboutPayment(&mockLogger{}, nil, sessionsStore, &payClient, publicUrl, random, lpaStore, paymentStore)(appData, w, r)
+
+		assert.Equal(t, expectedError, err)
+		mock.AssertExpectationsForObjects(t, sessionsStore, &payClient, paymentStore)
+	})
 }
@@ -0,0 +1,41 @@
+package donor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+)
+
+var errNoFeeEvidenceReview = errors.New("lpa has no fee evidence to review")
+
+// ApproveFeeEvidence records that the evidence sent for lpa's fee reduction has
+// been accepted. When there is no fee left to pay, or it has been paid, the pay
+// task is completed so the LPA can be signed, and the certificate provider is
+// invited; otherwise that happens once the payment is recorded.
+func ApproveFeeEvidence(ctx context.Context, lpaStore page.LpaStore, notifyClient page.NotifyClient, shareCodeStore page.ShareCodeStore, messageStore page.MessageStore, appPublicURL string, lpa *page.Lpa) error {
+	if !lpa.FeeEvidenceReviewPending() {
+		return errNoFeeEvidenceReview
+	}
+
+	lpa.FeeEvidenceReview = page.TaskCompleted
+
+	invite := false
+	if lpa.Tasks.PayForLpa != page.TaskCompleted && (lpa.Cost() == 0 || lpa.FeePaid()) {
+		lpa.Tasks.PayForLpa = page.TaskCompleted
+		invite = true
+	}
+
+	if err := lpaStore.Put(ctx, lpa); err != nil {
+		return err
+	}
+
+	if invite {
+		if _, err := sendCertificateProviderInvite(ctx, notifyClient, shareCodeStore, messageStore, appPublicURL, lpa); err != nil {
+			return fmt.Errorf("error email certificate provider after fee evidence approved: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package donor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/localize"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/pay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestApproveFeeEvidence(t *testing.T) {
+	ctx := context.Background()
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Put", ctx, &page.Lpa{
+			ID:                  "lpa-id",
+			CertificateProvider: actor.CertificateProvider{Email: "certificateprovider@example.com"},
+			FeeType:             page.FeeTypeExemption,
+			FeeEvidenceReview:   page.TaskCompleted,
+			Tasks:               page.Tasks{PayForLpa: page.TaskCompleted},
+		}).
+		Return(nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Create", ctx, page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeCertificateProvider}).
+		Return("123", nil)
+
+	notifyClient := &mockNotifyClient{}
+	notifyClient.
+		On("TemplateID", notify.CertificateProviderInviteEmail, localize.En).
+		Return("template-id")
+	notifyClient.
+		On("Email", ctx, notify.Email{
+			TemplateID:   "template-id",
+			EmailAddress: "certificateprovider@example.com",
+			Reference:    "lpa-id/certificate-provider-invite-email/a665a459",
+			Personalisation: map[string]string{
+				"link": fmt.Sprintf("http://app%s?share-code=123", page.Paths.CertificateProviderStart),
+			},
+		}).
+		Return("email-id", nil)
+
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("Put", ctx, page.Message{
+			ID:            "email-id",
+			LpaID:         "lpa-id",
+			RecipientRole: page.ActorTypeCertificateProvider,
+			Template:      notify.CertificateProviderInviteEmail.String(),
+			Status:        notify.StatusCreated,
+		}).
+		Return(nil)
+
+	err := ApproveFeeEvidence(ctx, lpaStore, notifyClient, shareCodeStore, messageStore, "http://app", &page.Lpa{
+		ID:                  "lpa-id",
+		CertificateProvider: actor.CertificateProvider{Email: "certificateprovider@example.com"},
+		FeeType:             page.FeeTypeExemption,
+		FeeEvidenceReview:   page.TaskInProgress,
+		Tasks:               page.Tasks{PayForLpa: page.TaskInProgress},
+	})
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, lpaStore, shareCodeStore, notifyClient, messageStore)
+}
+
+func TestApproveFeeEvidenceWhenPaid(t *testing.T) {
+	ctx := context.Background()
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Put", ctx, mock.MatchedBy(func(lpa *page.Lpa) bool {
+			return lpa.FeeEvidenceReview == page.TaskCompleted && lpa.Tasks.PayForLpa == page.TaskCompleted
+		})).
+		Return(nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Create", ctx, mock.Anything).
+		Return("", expectedError)
+
+	err := ApproveFeeEvidence(ctx, lpaStore, nil, shareCodeStore, nil, "http://app", &page.Lpa{
+		ID:                "lpa-id",
+		FeeType:           page.FeeTypeHalf,
+		FeeEvidenceReview: page.TaskInProgress,
+		PaymentDetails:    page.PaymentDetails{Status: pay.StatusSuccess},
+		Tasks:             page.Tasks{PayForLpa: page.TaskInProgress},
+	})
+
+	assert.Equal(t, fmt.Errorf("error email certificate provider after fee evidence approved: %w", expectedError), err)
+	mock.AssertExpectationsForObjects(t, lpaStore, shareCodeStore)
+}
+
+func TestApproveFeeEvidenceWhenFeeStillToPay(t *testing.T) {
+	ctx := context.Background()
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Put", ctx, &page.Lpa{
+			ID:                "lpa-id",
+			FeeType:           page.FeeTypeHalf,
+			FeeEvidenceReview: page.TaskCompleted,
+		}).
+		Return(nil)
+
+	err := ApproveFeeEvidence(ctx, lpaStore, nil, nil, nil, "http://app", &page.Lpa{
+		ID:                "lpa-id",
+		FeeType:           page.FeeTypeHalf,
+		FeeEvidenceReview: page.TaskInProgress,
+	})
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestApproveFeeEvidenceWhenNotPending(t *testing.T) {
+	err := ApproveFeeEvidence(context.Background(), nil, nil, nil, nil, "http://app", &page.Lpa{FeeEvidenceReview: page.TaskCompleted})
+
+	assert.Equal(t, errNoFeeEvidenceReview, err)
+}
+
+func TestApproveFeeEvidenceWhenStoreErrors(t *testing.T) {
+	ctx := context.Background()
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Put", ctx, mock.Anything).
+		Return(expectedError)
+
+	err := ApproveFeeEvidence(ctx, lpaStore, nil, nil, nil, "http://app", &page.Lpa{FeeType: page.FeeTypeExemption, FeeEvidenceReview: page.TaskInProgress})
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
@@ -0,0 +1,152 @@
+package donor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+)
+
+// sendAttorneyInvites emails each attorney and replacement attorney that has
+// not been sent one a share code to sign in with. Like the notices to people to
+// notify, each attempt is saved on the LPA before the invite is sent, so if the
+// result could not be saved the next attempt asks Notify for the invite instead
+// of sending it twice. It returns true when an invite could not be sent but
+// should be tried again.
+func sendAttorneyInvites(ctx context.Context, notifyClient page.NotifyClient, shareCodeStore page.ShareCodeStore, lpaStore page.LpaStore, messageStore page.MessageStore, appPublicURL string, lpa *page.Lpa, now time.Time) (bool, error) {
+	pending := false
+
+	for _, invitee := range attorneyInvitees(lpa) {
+		invite, _ := lpa.AttorneyInvites.Get(invitee.attorney.ID, invitee.isReplacement)
+		if invite.Sent() || invite.GaveUp() || invitee.attorney.Email == "" {
+			continue
+		}
+
+		if invite.Attempts > 0 {
+			id, err := notifyClient.FindByReference(ctx, "email", attorneyInviteReference(lpa.ID, invitee, invite.Attempts))
+			if err != nil {
+				return pending, err
+			}
+
+			if id != "" {
+				if err := recordAttorneyInvite(ctx, lpaStore, messageStore, lpa, invite, id, invite.LastAttemptAt); err != nil {
+					return pending, err
+				}
+				continue
+			}
+		}
+
+		invite.Attempts++
+		invite.LastAttemptAt = now
+		lpa.AttorneyInvites.Put(invite)
+
+		if err := lpaStore.Put(ctx, lpa); err != nil {
+			return pending, err
+		}
+
+		if err := page.CommitLpa(ctx); err != nil {
+			return pending, err
+		}
+
+		id, err := sendAttorneyInvite(ctx, notifyClient, shareCodeStore, appPublicURL, lpa, invitee, attorneyInviteReference(lpa.ID, invitee, invite.Attempts))
+		if err != nil {
+			invite.LastError = err.Error()
+			invite.Rejected = errors.Is(err, notify.ErrInvalidRecipient)
+			lpa.AttorneyInvites.Put(invite)
+
+			if err := lpaStore.Put(ctx, lpa); err != nil {
+				return pending, err
+			}
+
+			if !invite.GaveUp() {
+				pending = true
+			}
+			continue
+		}
+
+		if err := recordAttorneyInvite(ctx, lpaStore, messageStore, lpa, invite, id, now); err != nil {
+			return pending, err
+		}
+	}
+
+	return pending, nil
+}
+
+type attorneyInvitee struct {
+	attorney      actor.Attorney
+	isReplacement bool
+}
+
+func attorneyInvitees(lpa *page.Lpa) []attorneyInvitee {
+	var invitees []attorneyInvitee
+	for _, attorney := range lpa.Attorneys {
+		invitees = append(invitees, attorneyInvitee{attorney: attorney})
+	}
+	for _, attorney := range lpa.ReplacementAttorneys {
+		invitees = append(invitees, attorneyInvitee{attorney: attorney, isReplacement: true})
+	}
+
+	return invitees
+}
+
+// attorneyInviteReference is the Notify reference for an attempt to invite the
+// attorney. Each attempt is sent with a new share code, so has its own
+// reference, so that Notify is only found to have sent the invite with the
+// same code.
+func attorneyInviteReference(lpaID string, invitee attorneyInvitee, attempt int) string {
+	reference := lpaID + "/" + notify.AttorneyInviteEmail.String() + "/" + invitee.attorney.ID
+	if invitee.isReplacement {
+		reference += "/replacement"
+	}
+
+	return fmt.Sprintf("%s/%d", reference, attempt)
+}
+
+// sendAttorneyInvite creates a share code for the attorney and emails it to
+// them.
+func sendAttorneyInvite(ctx context.Context, notifyClient page.NotifyClient, shareCodeStore page.ShareCodeStore, appPublicURL string, lpa *page.Lpa, invitee attorneyInvitee, reference string) (string, error) {
+	shareCode, err := shareCodeStore.Create(ctx, page.ShareCodeData{
+		LpaID:                 lpa.ID,
+		ActorType:             page.ActorTypeAttorney,
+		ActorID:               invitee.attorney.ID,
+		IsReplacementAttorney: invitee.isReplacement,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return notifyClient.Email(ctx, notify.Email{
+		TemplateID:   notifyClient.TemplateID(notify.AttorneyInviteEmail, lpa.ContactLanguagePreference),
+		EmailAddress: invitee.attorney.Email,
+		Reference:    reference,
+		Personalisation: map[string]string{
+			"attorneyFullName": invitee.attorney.FullName(),
+			"donorFullName":    lpa.You.FullName(),
+			"link":             fmt.Sprintf("%s%s?share-code=%s", appPublicURL, page.Paths.AttorneyStart, shareCode),
+		},
+	})
+}
+
+// recordAttorneyInvite saves that Notify accepted the invite with id.
+func recordAttorneyInvite(ctx context.Context, lpaStore page.LpaStore, messageStore page.MessageStore, lpa *page.Lpa, invite page.AttorneyInvite, id string, sentAt time.Time) error {
+	if err := messageStore.Put(ctx, page.Message{
+		ID:            id,
+		LpaID:         lpa.ID,
+		RecipientRole: page.ActorTypeAttorney,
+		Template:      notify.AttorneyInviteEmail.String(),
+		Status:        notify.StatusCreated,
+	}); err != nil {
+		return err
+	}
+
+	invite.MessageID = id
+	invite.SentAt = sentAt
+	invite.LastError = ""
+	lpa.AttorneyInvites.Put(invite)
+
+	return lpaStore.Put(ctx, lpa)
+}
@@ -0,0 +1,332 @@
+package donor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/localize"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSendAttorneyInvites(t *testing.T) {
+	ctx := context.Background()
+	lpa := &page.Lpa{
+		ID:                        "lpa-id",
+		You:                       actor.Person{FirstNames: "John", LastName: "Smith"},
+		Attorneys:                 actor.Attorneys{{ID: "1", FirstNames: "Alan", LastName: "Jones", Email: "alan@example.com"}},
+		ReplacementAttorneys:      actor.Attorneys{{ID: "2", FirstNames: "Ann", LastName: "Jones", Email: "ann@example.com"}},
+		ContactLanguagePreference: localize.Cy,
+	}
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Create", ctx, page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeAttorney, ActorID: "1"}).
+		Return("abc", nil)
+	shareCodeStore.
+		On("Create", ctx, page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeAttorney, ActorID: "2", IsReplacementAttorney: true}).
+		Return("def", nil)
+
+	notifyClient := &mockNotifyClient{}
+	notifyClient.
+		On("TemplateID", notify.AttorneyInviteEmail, localize.Cy).
+		Return("template-id")
+	notifyClient.
+		On("Email", ctx, notify.Email{
+			TemplateID:   "template-id",
+			EmailAddress: "alan@example.com",
+			Reference:    "lpa-id/attorney-invite-email/1/1",
+			Personalisation: map[string]string{
+				"attorneyFullName": "Alan Jones",
+				"donorFullName":    "John Smith",
+				"link":             "http://app" + page.Paths.AttorneyStart + "?share-code=abc",
+			},
+		}).
+		Return("email-1", nil)
+	notifyClient.
+		On("Email", ctx, notify.Email{
+			TemplateID:   "template-id",
+			EmailAddress: "ann@example.com",
+			Reference:    "lpa-id/attorney-invite-email/2/replacement/1",
+			Personalisation: map[string]string{
+				"attorneyFullName": "Ann Jones",
+				"donorFullName":    "John Smith",
+				"link":             "http://app" + page.Paths.AttorneyStart + "?share-code=def",
+			},
+		}).
+		Return("email-2", nil)
+
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("Put", ctx, page.Message{ID: "email-1", LpaID: "lpa-id", RecipientRole: page.ActorTypeAttorney, Template: "attorney-invite-email", Status: notify.StatusCreated}).
+		Return(nil)
+	messageStore.
+		On("Put", ctx, page.Message{ID: "email-2", LpaID: "lpa-id", RecipientRole: page.ActorTypeAttorney, Template: "attorney-invite-email", Status: notify.StatusCreated}).
+		Return(nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Put", ctx, lpa).
+		Return(nil)
+
+	pending, err := sendAttorneyInvites(ctx, notifyClient, shareCodeStore, lpaStore, messageStore, "http://app", lpa, noticeNow)
+
+	assert.Nil(t, err)
+	assert.False(t, pending)
+	assert.Equal(t, page.AttorneyInvites{
+		{AttorneyID: "1", MessageID: "email-1", SentAt: noticeNow, Attempts: 1, LastAttemptAt: noticeNow},
+		{AttorneyID: "2", IsReplacement: true, MessageID: "email-2", SentAt: noticeNow, Attempts: 1, LastAttemptAt: noticeNow},
+	}, lpa.AttorneyInvites)
+	mock.AssertExpectationsForObjects(t, shareCodeStore, notifyClient, messageStore, lpaStore)
+	lpaStore.AssertNumberOfCalls(t, "Put", 4)
+}
+
+func TestSendAttorneyInvitesSkipsSentGivenUpAndNoEmail(t *testing.T) {
+	invites := page.AttorneyInvites{
+		{AttorneyID: "1", MessageID: "email-id", Attempts: 1},
+		{AttorneyID: "2", Attempts: page.MaxAttorneyInviteAttempts},
+		{AttorneyID: "3", IsReplacement: true, Attempts: 1, Rejected: true},
+	}
+
+	lpa := &page.Lpa{
+		Attorneys:            actor.Attorneys{{ID: "1", Email: "a"}, {ID: "2", Email: "b"}, {ID: "4"}},
+		ReplacementAttorneys: actor.Attorneys{{ID: "3", Email: "c"}},
+		AttorneyInvites:      append(page.AttorneyInvites{}, invites...),
+	}
+
+	pending, err := sendAttorneyInvites(context.Background(), nil, nil, nil, nil, "", lpa, noticeNow)
+
+	assert.Nil(t, err)
+	assert.False(t, pending)
+	assert.Equal(t, invites, lpa.AttorneyInvites)
+}
+
+func TestSendAttorneyInvitesWhenAlreadyAccepted(t *testing.T) {
+	ctx := context.Background()
+	lastAttemptAt := noticeNow.Add(-time.Hour)
+	lpa := &page.Lpa{
+		ID:              "lpa-id",
+		Attorneys:       actor.Attorneys{{ID: "1", Email: "alan@example.com"}},
+		AttorneyInvites: page.AttorneyInvites{{AttorneyID: "1", Attempts: 1, LastAttemptAt: lastAttemptAt}},
+	}
+
+	notifyClient := &mockNotifyClient{}
+	notifyClient.
+		On("FindByReference", ctx, "email", "lpa-id/attorney-invite-email/1/1").
+		Return("email-id", nil)
+
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("Put", ctx, page.Message{ID: "email-id", LpaID: "lpa-id", RecipientRole: page.ActorTypeAttorney, Template: "attorney-invite-email", Status: notify.StatusCreated}).
+		Return(nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Put", ctx, lpa).
+		Return(nil).
+		Once()
+
+	pending, err := sendAttorneyInvites(ctx, notifyClient, nil, lpaStore, messageStore, "http://app", lpa, noticeNow)
+
+	assert.Nil(t, err)
+	assert.False(t, pending)
+	assert.Equal(t, page.AttorneyInvites{
+		{AttorneyID: "1", MessageID: "email-id", SentAt: lastAttemptAt, Attempts: 1, LastAttemptAt: lastAttemptAt},
+	}, lpa.AttorneyInvites)
+	mock.AssertExpectationsForObjects(t, notifyClient, messageStore, lpaStore)
+}
+
+func TestSendAttorneyInvitesWhenRetrying(t *testing.T) {
+	ctx := context.Background()
+	lpa := &page.Lpa{
+		ID:              "lpa-id",
+		Attorneys:       actor.Attorneys{{ID: "1", Email: "alan@example.com"}},
+		AttorneyInvites: page.AttorneyInvites{{AttorneyID: "1", Attempts: 1, LastAttemptAt: noticeNow.Add(-time.Hour), LastError: "err"}},
+	}
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Create", ctx, mock.Anything).
+		Return("abc", nil)
+
+	notifyClient := &mockNotifyClient{}
+	notifyClient.
+		On("FindByReference", ctx, "email", "lpa-id/attorney-invite-email/1/1").
+		Return("", nil)
+	notifyClient.
+		On("TemplateID", mock.Anything, mock.Anything).
+		Return("template-id")
+	notifyClient.
+		On("Email", ctx, mock.MatchedBy(func(email notify.Email) bool {
+			return email.Reference == "lpa-id/attorney-invite-email/1/2"
+		})).
+		Return("email-id", nil)
+
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("Put", ctx, mock.Anything).
+		Return(nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Put", ctx, lpa).
+		Return(nil)
+
+	pending, err := sendAttorneyInvites(ctx, notifyClient, shareCodeStore, lpaStore, messageStore, "http://app", lpa, noticeNow)
+
+	assert.Nil(t, err)
+	assert.False(t, pending)
+	assert.Equal(t, page.AttorneyInvites{
+		{AttorneyID: "1", MessageID: "email-id", SentAt: noticeNow, Attempts: 2, LastAttemptAt: noticeNow},
+	}, lpa.AttorneyInvites)
+	mock.AssertExpectationsForObjects(t, shareCodeStore, notifyClient, messageStore, lpaStore)
+}
+
+func TestSendAttorneyInvitesWhenSendErrors(t *testing.T) {
+	testCases := map[string]struct {
+		err     error
+		invite  page.AttorneyInvite
+		pending bool
+	}{
+		"will retry": {
+			err:     expectedError,
+			invite:  page.AttorneyInvite{AttorneyID: "1", Attempts: 1, LastAttemptAt: noticeNow, LastError: "err"},
+			pending: true,
+		},
+		"invalid recipient": {
+			err:     notify.ErrInvalidRecipient,
+			invite:  page.AttorneyInvite{AttorneyID: "1", Attempts: 1, LastAttemptAt: noticeNow, LastError: notify.ErrInvalidRecipient.Error(), Rejected: true},
+			pending: false,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			lpa := &page.Lpa{Attorneys: actor.Attorneys{{ID: "1", Email: "alan@example.com"}}}
+
+			shareCodeStore := &mockShareCodeStore{}
+			shareCodeStore.
+				On("Create", ctx, mock.Anything).
+				Return("abc", nil)
+
+			notifyClient := &mockNotifyClient{}
+			notifyClient.
+				On("TemplateID", mock.Anything, mock.Anything).
+				Return("template-id")
+			notifyClient.
+				On("Email", ctx, mock.Anything).
+				Return("", tc.err)
+
+			lpaStore := &mockLpaStore{}
+			lpaStore.
+				On("Put", ctx, lpa).
+				Return(nil).
+				Twice()
+
+			pending, err := sendAttorneyInvites(ctx, notifyClient, shareCodeStore, lpaStore, nil, "http://app", lpa, noticeNow)
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.pending, pending)
+			assert.Equal(t, page.AttorneyInvites{tc.invite}, lpa.AttorneyInvites)
+			mock.AssertExpectationsForObjects(t, shareCodeStore, notifyClient, lpaStore)
+		})
+	}
+}
+
+func TestSendAttorneyInvitesWhenShareCodeStoreErrors(t *testing.T) {
+	ctx := context.Background()
+	lpa := &page.Lpa{Attorneys: actor.Attorneys{{ID: "1", Email: "alan@example.com"}}}
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Create", ctx, mock.Anything).
+		Return("", expectedError)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Put", ctx, lpa).
+		Return(nil).
+		Twice()
+
+	pending, err := sendAttorneyInvites(ctx, nil, shareCodeStore, lpaStore, nil, "http://app", lpa, noticeNow)
+
+	assert.Nil(t, err)
+	assert.True(t, pending)
+	assert.Equal(t, page.AttorneyInvites{{AttorneyID: "1", Attempts: 1, LastAttemptAt: noticeNow, LastError: "err"}}, lpa.AttorneyInvites)
+	mock.AssertExpectationsForObjects(t, shareCodeStore, lpaStore)
+}
+
+func TestSendAttorneyInvitesWhenErrors(t *testing.T) {
+	testCases := map[string]struct {
+		attempts     int
+		notifyClient func() *mockNotifyClient
+		lpaStore     func() *mockLpaStore
+		messageStore func() *mockMessageStore
+	}{
+		"finding by reference": {
+			attempts: 1,
+			notifyClient: func() *mockNotifyClient {
+				notifyClient := &mockNotifyClient{}
+				notifyClient.On("FindByReference", mock.Anything, mock.Anything, mock.Anything).Return("", expectedError)
+				return notifyClient
+			},
+			lpaStore:     func() *mockLpaStore { return &mockLpaStore{} },
+			messageStore: func() *mockMessageStore { return &mockMessageStore{} },
+		},
+		"putting lpa": {
+			notifyClient: func() *mockNotifyClient { return &mockNotifyClient{} },
+			lpaStore: func() *mockLpaStore {
+				lpaStore := &mockLpaStore{}
+				lpaStore.On("Put", mock.Anything, mock.Anything).Return(expectedError)
+				return lpaStore
+			},
+			messageStore: func() *mockMessageStore { return &mockMessageStore{} },
+		},
+		"recording message": {
+			notifyClient: func() *mockNotifyClient {
+				notifyClient := &mockNotifyClient{}
+				notifyClient.On("TemplateID", mock.Anything, mock.Anything).Return("template-id")
+				notifyClient.On("Email", mock.Anything, mock.Anything).Return("email-id", nil)
+				return notifyClient
+			},
+			lpaStore: func() *mockLpaStore {
+				lpaStore := &mockLpaStore{}
+				lpaStore.On("Put", mock.Anything, mock.Anything).Return(nil).Once()
+				return lpaStore
+			},
+			messageStore: func() *mockMessageStore {
+				messageStore := &mockMessageStore{}
+				messageStore.On("Put", mock.Anything, mock.Anything).Return(expectedError)
+				return messageStore
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			lpa := &page.Lpa{
+				Attorneys:       actor.Attorneys{{ID: "1", Email: "alan@example.com"}},
+				AttorneyInvites: page.AttorneyInvites{{AttorneyID: "1", Attempts: tc.attempts}},
+			}
+
+			shareCodeStore := &mockShareCodeStore{}
+			shareCodeStore.
+				On("Create", mock.Anything, mock.Anything).
+				Return("abc", nil)
+
+			notifyClient := tc.notifyClient()
+			lpaStore := tc.lpaStore()
+			messageStore := tc.messageStore()
+
+			_, err := sendAttorneyInvites(context.Background(), notifyClient, shareCodeStore, lpaStore, messageStore, "http://app", lpa, noticeNow)
+
+			assert.Equal(t, expectedError, err)
+			mock.AssertExpectationsForObjects(t, notifyClient, lpaStore, messageStore)
+		})
+	}
+}
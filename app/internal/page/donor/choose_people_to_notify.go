@@ -124,9 +124,10 @@ func (f *choosePeopleToNotifyForm) Validate() validation.List {
 		validation.Empty(),
 		validation.StringTooLong(61))
 
-	errors.String("email", "email", f.Email,
-		validation.Empty(),
-		validation.Email())
+	if f.Email != "" {
+		errors.String("email", "email", f.Email,
+			validation.Email())
+	}
 
 	return errors
 }
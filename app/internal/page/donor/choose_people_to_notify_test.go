@@ -381,11 +381,12 @@ func TestPostChoosePeopleToNotifyWhenInputRequired(t *testing.T) {
 			form: url.Values{
 				"first-names":         {"Jane"},
 				"last-name":           {"Doe"},
+				"email":               {"name@"},
 				"ignore-name-warning": {"errorDonorMatchesActor|aPersonToNotify|Jane|Doe"},
 			},
 			dataMatcher: func(t *testing.T, data *choosePeopleToNotifyData) bool {
 				return assert.Equal(t, actor.NewSameNameWarning(actor.TypePersonToNotify, actor.TypeDonor, "Jane", "Doe"), data.NameWarning) &&
-					assert.Equal(t, validation.With("email", validation.EnterError{Label: "email"}), data.Errors)
+					assert.Equal(t, validation.With("email", validation.EmailError{Label: "email"}), data.Errors)
 			},
 		},
 		"other name warning ignored": {
@@ -499,8 +500,13 @@ func TestChoosePeopleToNotifyFormValidate(t *testing.T) {
 			form: &choosePeopleToNotifyForm{},
 			errors: validation.
 				With("first-names", validation.EnterError{Label: "firstNames"}).
-				With("last-name", validation.EnterError{Label: "lastName"}).
-				With("email", validation.EnterError{Label: "email"}),
+				With("last-name", validation.EnterError{Label: "lastName"}),
+		},
+		"without email": {
+			form: &choosePeopleToNotifyForm{
+				FirstNames: "A",
+				LastName:   "B",
+			},
 		},
 		"too long": {
 			form: &choosePeopleToNotifyForm{
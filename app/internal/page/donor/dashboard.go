@@ -1,17 +1,31 @@
 package donor
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/ministryofjustice/opg-go-common/template"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/dynamo"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/validation"
+	"golang.org/x/exp/slices"
 )
 
+// dashboardPageSize is the number of LPAs the donor sees on each page of the
+// dashboard.
+const dashboardPageSize = 10
+
+var dashboardStatuses = []string{page.LpaStatusDraft, page.LpaStatusPaid, page.LpaStatusSigned, page.LpaStatusSubmitted, page.LpaStatusWithdrawn}
+
 type dashboardData struct {
-	App    page.AppData
-	Errors validation.List
-	Lpas   []*page.Lpa
+	App                     page.AppData
+	Errors                  validation.List
+	Lpas                    []*page.Lpa
+	Status                  string
+	Cursor                  string
+	NextCursor              string
+	CertificateProviderLpas []*page.Lpa
+	AttorneyLpas            []*page.Lpa
 }
 
 func Dashboard(tmpl template.Template, lpaStore page.LpaStore) page.Handler {
@@ -25,14 +39,49 @@ func Dashboard(tmpl template.Template, lpaStore page.LpaStore) page.Handler {
 			return appData.Redirect(w, r, lpa, page.Paths.YourDetails)
 		}
 
-		lpas, err := lpaStore.GetAll(r.Context())
+		status := r.URL.Query().Get("status")
+		if !slices.Contains(dashboardStatuses, status) {
+			status = ""
+		}
+		cursor := r.URL.Query().Get("cursor")
+
+		query := page.LpaQuery{
+			ActorType: page.ActorTypeDonor,
+			Status:    status,
+			Cursor:    cursor,
+			Limit:     dashboardPageSize,
+		}
+
+		donorLpas, err := lpaStore.GetPage(r.Context(), query)
+		if errors.Is(err, dynamo.ErrInvalidCursor) {
+			// a cursor that has been changed, or is from before the order
+			// changed, shows the first page
+			cursor = ""
+			query.Cursor = ""
+			donorLpas, err = lpaStore.GetPage(r.Context(), query)
+		}
+		if err != nil {
+			return err
+		}
+
+		certificateProviderLpas, err := lpaStore.GetAllAs(r.Context(), page.ActorTypeCertificateProvider)
+		if err != nil {
+			return err
+		}
+
+		attorneyLpas, err := lpaStore.GetAllAs(r.Context(), page.ActorTypeAttorney)
 		if err != nil {
 			return err
 		}
 
 		data := &dashboardData{
-			App:  appData,
-			Lpas: lpas,
+			App:                     appData,
+			Lpas:                    donorLpas.Lpas,
+			Status:                  status,
+			Cursor:                  cursor,
+			NextCursor:              donorLpas.Next,
+			CertificateProviderLpas: certificateProviderLpas,
+			AttorneyLpas:            attorneyLpas,
 		}
 
 		return tmpl(w, data)
@@ -5,6 +5,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/dynamo"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -16,14 +17,28 @@ func TestGetDashboard(t *testing.T) {
 
 	lpas := []*page.Lpa{{ID: "123"}, {ID: "456"}}
 
+	certificateProviderLpas := []*page.Lpa{{ID: "789"}}
+	attorneyLpas := []*page.Lpa{{ID: "012"}}
+
 	lpaStore := &mockLpaStore{}
 	lpaStore.
-		On("GetAll", r.Context()).
-		Return(lpas, nil)
+		On("GetPage", r.Context(), page.LpaQuery{ActorType: page.ActorTypeDonor, Limit: 10}).
+		Return(page.LpaPage{Lpas: lpas}, nil)
+	lpaStore.
+		On("GetAllAs", r.Context(), page.ActorTypeCertificateProvider).
+		Return(certificateProviderLpas, nil)
+	lpaStore.
+		On("GetAllAs", r.Context(), page.ActorTypeAttorney).
+		Return(attorneyLpas, nil)
 
 	template := &mockTemplate{}
 	template.
-		On("Func", w, &dashboardData{App: appData, Lpas: lpas}).
+		On("Func", w, &dashboardData{
+			App:                     appData,
+			Lpas:                    lpas,
+			CertificateProviderLpas: certificateProviderLpas,
+			AttorneyLpas:            attorneyLpas,
+		}).
 		Return(nil)
 
 	err := Dashboard(template.Func, lpaStore)(appData, w, r)
@@ -34,16 +49,133 @@ func TestGetDashboard(t *testing.T) {
 	mock.AssertExpectationsForObjects(t, lpaStore, template)
 }
 
+func TestGetDashboardWithFilterAndCursor(t *testing.T) {
+	testCases := map[string]struct {
+		query  string
+		status string
+	}{
+		"known status": {
+			query:  "?status=paid&cursor=a-cursor",
+			status: page.LpaStatusPaid,
+		},
+		"unknown status": {
+			query:  "?status=what&cursor=a-cursor",
+			status: "",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodGet, "/"+tc.query, nil)
+
+			lpas := []*page.Lpa{{ID: "123"}}
+
+			lpaStore := &mockLpaStore{}
+			lpaStore.
+				On("GetPage", r.Context(), page.LpaQuery{ActorType: page.ActorTypeDonor, Status: tc.status, Cursor: "a-cursor", Limit: 10}).
+				Return(page.LpaPage{Lpas: lpas, Next: "next-cursor"}, nil)
+			lpaStore.
+				On("GetAllAs", r.Context(), mock.Anything).
+				Return([]*page.Lpa(nil), nil)
+
+			template := &mockTemplate{}
+			template.
+				On("Func", w, &dashboardData{
+					App:        appData,
+					Lpas:       lpas,
+					Status:     tc.status,
+					Cursor:     "a-cursor",
+					NextCursor: "next-cursor",
+				}).
+				Return(nil)
+
+			err := Dashboard(template.Func, lpaStore)(appData, w, r)
+
+			assert.Nil(t, err)
+			mock.AssertExpectationsForObjects(t, lpaStore, template)
+		})
+	}
+}
+
+func TestGetDashboardWhenCursorInvalid(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/?status=draft&cursor=changed", nil)
+
+	lpas := []*page.Lpa{{ID: "123"}}
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("GetPage", r.Context(), page.LpaQuery{ActorType: page.ActorTypeDonor, Status: page.LpaStatusDraft, Cursor: "changed", Limit: 10}).
+		Return(page.LpaPage{}, dynamo.ErrInvalidCursor)
+	lpaStore.
+		On("GetPage", r.Context(), page.LpaQuery{ActorType: page.ActorTypeDonor, Status: page.LpaStatusDraft, Limit: 10}).
+		Return(page.LpaPage{Lpas: lpas, Next: "next"}, nil)
+	lpaStore.
+		On("GetAllAs", r.Context(), mock.Anything).
+		Return([]*page.Lpa{}, nil)
+
+	template := &mockTemplate{}
+	template.
+		On("Func", w, &dashboardData{
+			App:                     appData,
+			Lpas:                    lpas,
+			Status:                  page.LpaStatusDraft,
+			NextCursor:              "next",
+			CertificateProviderLpas: []*page.Lpa{},
+			AttorneyLpas:            []*page.Lpa{},
+		}).
+		Return(nil)
+
+	err := Dashboard(template.Func, lpaStore)(appData, w, r)
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, lpaStore, template)
+}
+
+func TestGetDashboardWhenGetAllAsErrors(t *testing.T) {
+	testCases := map[string]struct {
+		certificateProviderError error
+		attorneyError            error
+	}{
+		"certificate provider": {certificateProviderError: expectedError},
+		"attorney":             {attorneyError: expectedError},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+			lpaStore := &mockLpaStore{}
+			lpaStore.
+				On("GetPage", r.Context(), mock.Anything).
+				Return(page.LpaPage{}, nil)
+			lpaStore.
+				On("GetAllAs", r.Context(), page.ActorTypeCertificateProvider).
+				Return([]*page.Lpa{}, tc.certificateProviderError)
+			if tc.certificateProviderError == nil {
+				lpaStore.
+					On("GetAllAs", r.Context(), page.ActorTypeAttorney).
+					Return([]*page.Lpa{}, tc.attorneyError)
+			}
+
+			err := Dashboard(nil, lpaStore)(appData, w, r)
+
+			assert.Equal(t, expectedError, err)
+			mock.AssertExpectationsForObjects(t, lpaStore)
+		})
+	}
+}
+
 func TestGetDashboardWhenDataStoreErrors(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodGet, "/", nil)
 
-	lpas := []*page.Lpa{{}}
-
 	lpaStore := &mockLpaStore{}
 	lpaStore.
-		On("GetAll", r.Context()).
-		Return(lpas, expectedError)
+		On("GetPage", r.Context(), mock.Anything).
+		Return(page.LpaPage{}, expectedError)
 
 	err := Dashboard(nil, lpaStore)(appData, w, r)
 
@@ -59,8 +191,11 @@ func TestGetDashboardWhenTemplateErrors(t *testing.T) {
 
 	lpaStore := &mockLpaStore{}
 	lpaStore.
-		On("GetAll", r.Context()).
-		Return(lpas, nil)
+		On("GetPage", r.Context(), mock.Anything).
+		Return(page.LpaPage{Lpas: lpas}, nil)
+	lpaStore.
+		On("GetAllAs", r.Context(), mock.Anything).
+		Return([]*page.Lpa(nil), nil)
 
 	template := &mockTemplate{}
 	template.
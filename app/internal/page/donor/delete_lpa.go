@@ -0,0 +1,67 @@
+package donor
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ministryofjustice/opg-go-common/template"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/validation"
+)
+
+type deleteLpaData struct {
+	App    page.AppData
+	Errors validation.List
+	Lpa    *page.Lpa
+}
+
+// DeleteLpa removes an LPA from the donor's dashboard. Drafts that the donor
+// has not started to pay for are deleted outright, anything further along is
+// withdrawn so that its history is kept, and any share codes still outstanding
+// for it are revoked.
+func DeleteLpa(tmpl template.Template, lpaStore page.LpaStore, shareCodeStore page.ShareCodeStore, now func() time.Time) page.Handler {
+	return func(appData page.AppData, w http.ResponseWriter, r *http.Request) error {
+		lpa, err := lpaStore.Get(r.Context())
+		if err != nil {
+			return err
+		}
+
+		if lpa.Status() == page.LpaStatusWithdrawn {
+			return appData.Redirect(w, r, nil, page.Paths.Dashboard)
+		}
+
+		if r.Method == http.MethodPost {
+			if lpa.CanBeDeleted() {
+				err := lpaStore.Delete(r.Context())
+				if err == nil {
+					return appData.Redirect(w, r, nil, page.Paths.Dashboard)
+				}
+
+				if !errors.Is(err, page.ErrLpaNotDeletable) {
+					return err
+				}
+			}
+
+			lpa.WithdrawnAt = now()
+			if err := lpaStore.Put(r.Context(), lpa); err != nil {
+				return err
+			}
+
+			if err := page.CommitLpa(r.Context()); err != nil {
+				return err
+			}
+
+			if err := shareCodeStore.RevokeAll(r.Context(), lpa.ID); err != nil {
+				return err
+			}
+
+			return appData.Redirect(w, r, nil, page.Paths.Dashboard)
+		}
+
+		return tmpl(w, &deleteLpaData{
+			App: appData,
+			Lpa: lpa,
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package donor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetDeleteLpa(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpa := &page.Lpa{ID: "lpa-id"}
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(lpa, nil)
+
+	template := &mockTemplate{}
+	template.
+		On("Func", w, &deleteLpaData{App: appData, Lpa: lpa}).
+		Return(nil)
+
+	err := DeleteLpa(template.Func, lpaStore, nil, nil)(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	mock.AssertExpectationsForObjects(t, template, lpaStore)
+}
+
+func TestGetDeleteLpaWhenStoreErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{}, expectedError)
+
+	err := DeleteLpa(nil, lpaStore, nil, nil)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+}
+
+func TestGetDeleteLpaWhenWithdrawn(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{ID: "lpa-id", WithdrawnAt: time.Now()}, nil)
+
+	err := DeleteLpa(nil, lpaStore, nil, nil)(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, page.Paths.Dashboard, resp.Header.Get("Location"))
+}
+
+func TestPostDeleteLpaWhenDraft(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{ID: "lpa-id"}, nil)
+	lpaStore.
+		On("Delete", r.Context()).
+		Return(nil)
+
+	err := DeleteLpa(nil, lpaStore, nil, nil)(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, page.Paths.Dashboard, resp.Header.Get("Location"))
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestPostDeleteLpaWhenDraftAndStoreErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{ID: "lpa-id"}, nil)
+	lpaStore.
+		On("Delete", r.Context()).
+		Return(expectedError)
+
+	err := DeleteLpa(nil, lpaStore, nil, nil)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+}
+
+func TestPostDeleteLpaWhenPaid(t *testing.T) {
+	now := time.Date(2023, time.January, 2, 3, 4, 5, 6, time.UTC)
+	submitted := time.Date(2022, time.December, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := map[string]*page.Lpa{
+		"paid": {
+			ID:    "lpa-id",
+			Tasks: page.Tasks{PayForLpa: page.TaskCompleted},
+		},
+		"payment started": {
+			ID:    "lpa-id",
+			Tasks: page.Tasks{PayForLpa: page.TaskInProgress},
+		},
+		"payment details": {
+			ID:             "lpa-id",
+			PaymentDetails: page.PaymentDetails{PaymentId: "abc123"},
+		},
+		"submitted": {
+			ID:        "lpa-id",
+			Tasks:     page.Tasks{PayForLpa: page.TaskCompleted, ConfirmYourIdentityAndSign: page.TaskCompleted},
+			Submitted: submitted,
+		},
+	}
+
+	for name, lpa := range testCases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodPost, "/", nil)
+
+			withdrawn := *lpa
+			withdrawn.WithdrawnAt = now
+
+			lpaStore := &mockLpaStore{}
+			lpaStore.
+				On("Get", r.Context()).
+				Return(lpa, nil)
+			lpaStore.
+				On("Put", r.Context(), &withdrawn).
+				Return(nil)
+
+			shareCodeStore := &mockShareCodeStore{}
+			shareCodeStore.
+				On("RevokeAll", r.Context(), "lpa-id").
+				Return(nil)
+
+			err := DeleteLpa(nil, lpaStore, shareCodeStore, func() time.Time { return now })(appData, w, r)
+			resp := w.Result()
+
+			assert.Nil(t, err)
+			assert.Equal(t, http.StatusFound, resp.StatusCode)
+			assert.Equal(t, page.Paths.Dashboard, resp.Header.Get("Location"))
+			mock.AssertExpectationsForObjects(t, lpaStore, shareCodeStore)
+		})
+	}
+}
+
+func TestPostDeleteLpaWhenDraftIsNotDeletable(t *testing.T) {
+	now := time.Date(2023, time.January, 2, 3, 4, 5, 6, time.UTC)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{ID: "lpa-id"}, nil)
+	lpaStore.
+		On("Delete", r.Context()).
+		Return(page.ErrLpaNotDeletable)
+	lpaStore.
+		On("Put", r.Context(), &page.Lpa{ID: "lpa-id", WithdrawnAt: now}).
+		Return(nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("RevokeAll", r.Context(), "lpa-id").
+		Return(nil)
+
+	err := DeleteLpa(nil, lpaStore, shareCodeStore, func() time.Time { return now })(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, page.Paths.Dashboard, resp.Header.Get("Location"))
+	mock.AssertExpectationsForObjects(t, lpaStore, shareCodeStore)
+}
+
+func TestPostDeleteLpaWhenPaidAndStoreErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{ID: "lpa-id", Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}, nil)
+	lpaStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(expectedError)
+
+	err := DeleteLpa(nil, lpaStore, nil, time.Now)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+}
+
+func TestPostDeleteLpaWhenPaidAndShareCodeStoreErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{ID: "lpa-id", Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}, nil)
+	lpaStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("RevokeAll", r.Context(), "lpa-id").
+		Return(expectedError)
+
+	err := DeleteLpa(nil, lpaStore, shareCodeStore, time.Now)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+}
@@ -0,0 +1,61 @@
+package donor
+
+import (
+	"net/http"
+
+	"github.com/ministryofjustice/opg-go-common/template"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/validation"
+)
+
+type evidenceRequiredData struct {
+	App    page.AppData
+	Errors validation.List
+	Lpa    *page.Lpa
+}
+
+// EvidenceRequired tells the donor what they need to send to support their fee
+// reduction. When there is still a fee to pay they continue to payment,
+// otherwise GOV.UK Pay is skipped. Either way the LPA cannot be signed, and the
+// certificate provider is not invited, until the evidence has been reviewed.
+func EvidenceRequired(tmpl template.Template, lpaStore page.LpaStore) page.Handler {
+	return func(appData page.AppData, w http.ResponseWriter, r *http.Request) error {
+		lpa, err := lpaStore.Get(r.Context())
+		if err != nil {
+			return err
+		}
+
+		if lpa.FeeTypeLocked() {
+			return appData.Redirect(w, r, lpa, page.Paths.TaskList)
+		}
+
+		if len(lpa.EvidenceRequired) == 0 {
+			return appData.Redirect(w, r, lpa, page.Paths.WhichFeeTypeAreYouApplyingFor)
+		}
+
+		if r.Method == http.MethodPost {
+			lpa.FeeEvidenceReview = page.TaskInProgress
+
+			if lpa.Cost() > 0 {
+				if err := lpaStore.Put(r.Context(), lpa); err != nil {
+					return err
+				}
+
+				return appData.Redirect(w, r, lpa, page.Paths.AboutPayment)
+			}
+
+			lpa.Tasks.PayForLpa = page.TaskInProgress
+
+			if err := lpaStore.Put(r.Context(), lpa); err != nil {
+				return err
+			}
+
+			return appData.Redirect(w, r, lpa, page.Paths.PendingEvidenceReview)
+		}
+
+		return tmpl(w, &evidenceRequiredData{
+			App: appData,
+			Lpa: lpa,
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package donor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/pay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetEvidenceRequired(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpa := &page.Lpa{FeeType: page.FeeTypeHalf, EvidenceRequired: []string{page.EvidenceOfIncome}}
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(lpa, nil)
+
+	template := &mockTemplate{}
+	template.
+		On("Func", w, &evidenceRequiredData{App: appData, Lpa: lpa}).
+		Return(nil)
+
+	err := EvidenceRequired(template.Func, lpaStore)(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	mock.AssertExpectationsForObjects(t, template, lpaStore)
+}
+
+func TestGetEvidenceRequiredWhenNoEvidenceRequired(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{ID: "lpa-id", FeeType: page.FeeTypeFull, Tasks: readyToPay}, nil)
+
+	err := EvidenceRequired(nil, lpaStore)(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/lpa/lpa-id"+page.Paths.WhichFeeTypeAreYouApplyingFor, resp.Header.Get("Location"))
+}
+
+func TestGetEvidenceRequiredWhenStoreErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{}, expectedError)
+
+	err := EvidenceRequired(nil, lpaStore)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+}
+
+func TestPostEvidenceRequiredWhenReducedFee(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{ID: "lpa-id", FeeType: page.FeeTypeHalf, EvidenceRequired: []string{page.EvidenceOfIncome}, Tasks: readyToPay}, nil)
+	lpaStore.
+		On("Put", r.Context(), &page.Lpa{
+			ID:                "lpa-id",
+			FeeType:           page.FeeTypeHalf,
+			EvidenceRequired:  []string{page.EvidenceOfIncome},
+			FeeEvidenceReview: page.TaskInProgress,
+			Tasks:             readyToPay,
+		}).
+		Return(nil)
+
+	err := EvidenceRequired(nil, lpaStore)(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/lpa/lpa-id"+page.Paths.AboutPayment, resp.Header.Get("Location"))
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestGetEvidenceRequiredWhenFeeTypeLocked(t *testing.T) {
+	testCases := map[string]page.Lpa{
+		"paid": {
+			ID:               "lpa-id",
+			FeeType:          page.FeeTypeHalf,
+			EvidenceRequired: []string{page.EvidenceOfIncome},
+			PaymentDetails:   page.PaymentDetails{Status: pay.StatusSuccess},
+		},
+		"completed": {
+			ID:               "lpa-id",
+			FeeType:          page.FeeTypeExemption,
+			EvidenceRequired: []string{page.EvidenceOfBenefits},
+			Tasks:            page.Tasks{PayForLpa: page.TaskCompleted},
+		},
+	}
+
+	for name, lpa := range testCases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodPost, "/", nil)
+
+			lpa := lpa
+			lpaStore := &mockLpaStore{}
+			lpaStore.
+				On("Get", r.Context()).
+				Return(&lpa, nil)
+
+			err := EvidenceRequired(nil, lpaStore)(appData, w, r)
+			resp := w.Result()
+
+			assert.Nil(t, err)
+			assert.Equal(t, http.StatusFound, resp.StatusCode)
+			assert.Equal(t, "/lpa/lpa-id"+page.Paths.TaskList, resp.Header.Get("Location"))
+			mock.AssertExpectationsForObjects(t, lpaStore)
+		})
+	}
+}
+
+func TestPostEvidenceRequiredWhenNoFee(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{
+			ID:                  "lpa-id",
+			CertificateProvider: actor.CertificateProvider{Email: "certificateprovider@example.com"},
+			FeeType:             page.FeeTypeExemption,
+			EvidenceRequired:    []string{page.EvidenceOfBenefits},
+		}, nil)
+	lpaStore.
+		On("Put", r.Context(), &page.Lpa{
+			ID:                  "lpa-id",
+			CertificateProvider: actor.CertificateProvider{Email: "certificateprovider@example.com"},
+			FeeType:             page.FeeTypeExemption,
+			EvidenceRequired:    []string{page.EvidenceOfBenefits},
+			FeeEvidenceReview:   page.TaskInProgress,
+			Tasks:               page.Tasks{PayForLpa: page.TaskInProgress},
+		}).
+		Return(nil)
+
+	err := EvidenceRequired(nil, lpaStore)(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/lpa/lpa-id"+page.Paths.PendingEvidenceReview, resp.Header.Get("Location"))
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestPostEvidenceRequiredWhenStoreErrors(t *testing.T) {
+	testCases := map[string]string{
+		"reduced fee": page.FeeTypeHalf,
+		"no fee":      page.FeeTypeHardship,
+	}
+
+	for name, feeType := range testCases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodPost, "/", nil)
+
+			lpaStore := &mockLpaStore{}
+			lpaStore.
+				On("Get", r.Context()).
+				Return(&page.Lpa{
+					FeeType:          feeType,
+					EvidenceRequired: []string{page.EvidenceOfIncome},
+				}, nil)
+			lpaStore.
+				On("Put", r.Context(), mock.Anything).
+				Return(expectedError)
+
+			err := EvidenceRequired(nil, lpaStore)(appData, w, r)
+
+			assert.Equal(t, expectedError, err)
+			mock.AssertExpectationsForObjects(t, lpaStore)
+		})
+	}
+}
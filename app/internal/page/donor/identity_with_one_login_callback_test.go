@@ -283,6 +283,7 @@ func TestPostIdentityWithOneLoginCallback(t *testing.T) {
 	lpaStore := &mockLpaStore{}
 	lpaStore.On("Get", r.Context()).Return(&page.Lpa{
 		OneLoginUserData: identity.UserData{OK: true},
+		Tasks:            page.Tasks{PayForLpa: page.TaskCompleted},
 	}, nil)
 
 	err := IdentityWithOneLoginCallback(nil, nil, nil, lpaStore)(appData, w, r)
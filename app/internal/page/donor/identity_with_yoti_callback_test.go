@@ -123,6 +123,7 @@ func TestPostIdentityWithYotiCallback(t *testing.T) {
 	lpaStore := &mockLpaStore{}
 	lpaStore.On("Get", r.Context()).Return(&page.Lpa{
 		IdentityOption: identity.EasyID,
+		Tasks:          page.Tasks{PayForLpa: page.TaskCompleted},
 	}, nil)
 
 	err := IdentityWithYotiCallback(nil, nil, lpaStore)(appData, w, r)
@@ -0,0 +1,70 @@
+package donor
+
+import (
+	"net/http"
+
+	"github.com/ministryofjustice/opg-go-common/template"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/validation"
+)
+
+type lpaProgressData struct {
+	App                       page.AppData
+	Errors                    validation.List
+	Lpa                       *page.Lpa
+	CertificateProviderInvite *page.Message
+	WitnessCode               *page.Message
+	PeopleToNotify            []personToNotifyNoticeProgress
+	PeopleToNotifyFailed      bool
+}
+
+type personToNotifyNoticeProgress struct {
+	Person  actor.PersonToNotify
+	Notice  page.PersonToNotifyNotice
+	Message *page.Message
+}
+
+// LpaProgress shows the donor how far their LPA has got, along with whether
+// the messages sent to their certificate provider and, once it is submitted,
+// the notices sent to their people to notify were delivered.
+func LpaProgress(tmpl template.Template, lpaStore page.LpaStore, messageStore page.MessageStore) page.Handler {
+	return func(appData page.AppData, w http.ResponseWriter, r *http.Request) error {
+		lpa, err := lpaStore.Get(r.Context())
+		if err != nil {
+			return err
+		}
+
+		messages, err := messageStore.GetAll(r.Context())
+		if err != nil {
+			return err
+		}
+
+		data := &lpaProgressData{
+			App:                       appData,
+			Lpa:                       lpa,
+			CertificateProviderInvite: page.LatestMessage(messages, notify.CertificateProviderInviteEmail),
+			WitnessCode:               page.LatestMessage(messages, notify.SignatureCodeSms),
+		}
+
+		if !lpa.Submitted.IsZero() {
+			for _, person := range lpa.PeopleToNotify {
+				notice, _ := lpa.PersonToNotifyNotices.Get(person.ID)
+				message := page.MessageByID(messages, notice.MessageID)
+
+				data.PeopleToNotify = append(data.PeopleToNotify, personToNotifyNoticeProgress{
+					Person:  person,
+					Notice:  notice,
+					Message: message,
+				})
+
+				if notice.GaveUp() || (message != nil && message.Failed()) {
+					data.PeopleToNotifyFailed = true
+				}
+			}
+		}
+
+		return tmpl(w, data)
+	}
+}
@@ -0,0 +1,188 @@
+package donor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetLpaProgress(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpa := &page.Lpa{ID: "lpa-id"}
+	messages := []page.Message{
+		{ID: "1", Template: notify.CertificateProviderInviteEmail.String(), Status: notify.StatusPermanentFailure},
+		{ID: "2", Template: notify.SignatureCodeSms.String(), Status: notify.StatusDelivered},
+		{ID: "3", Template: notify.CertificateProviderInviteEmail.String(), Status: notify.StatusCreated},
+	}
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(lpa, nil)
+
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("GetAll", r.Context()).
+		Return(messages, nil)
+
+	template := &mockTemplate{}
+	template.
+		On("Func", w, &lpaProgressData{
+			App:                       appData,
+			Lpa:                       lpa,
+			CertificateProviderInvite: &messages[2],
+			WitnessCode:               &messages[1],
+		}).
+		Return(nil)
+
+	err := LpaProgress(template.Func, lpaStore, messageStore)(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	mock.AssertExpectationsForObjects(t, lpaStore, messageStore, template)
+}
+
+func TestGetLpaProgressWhenNoMessages(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpa := &page.Lpa{ID: "lpa-id"}
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(lpa, nil)
+
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("GetAll", r.Context()).
+		Return([]page.Message(nil), nil)
+
+	template := &mockTemplate{}
+	template.
+		On("Func", w, &lpaProgressData{App: appData, Lpa: lpa}).
+		Return(nil)
+
+	err := LpaProgress(template.Func, lpaStore, messageStore)(appData, w, r)
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, lpaStore, messageStore, template)
+}
+
+func TestGetLpaProgressWhenSubmitted(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpa := &page.Lpa{
+		ID:        "lpa-id",
+		Submitted: time.Now(),
+		PeopleToNotify: actor.PeopleToNotify{
+			{ID: "1", FirstNames: "Jane"},
+			{ID: "2", FirstNames: "Joan"},
+			{ID: "3", FirstNames: "John"},
+		},
+		PersonToNotifyNotices: page.PersonToNotifyNotices{
+			{PersonToNotifyID: "1", MessageID: "4"},
+			{PersonToNotifyID: "2", Attempts: 1},
+		},
+	}
+	messages := []page.Message{
+		{ID: "4", Template: notify.PersonToNotifyEmail.String(), Status: notify.StatusPermanentFailure},
+	}
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(lpa, nil)
+
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("GetAll", r.Context()).
+		Return(messages, nil)
+
+	template := &mockTemplate{}
+	template.
+		On("Func", w, &lpaProgressData{
+			App: appData,
+			Lpa: lpa,
+			PeopleToNotify: []personToNotifyNoticeProgress{
+				{Person: lpa.PeopleToNotify[0], Notice: lpa.PersonToNotifyNotices[0], Message: &messages[0]},
+				{Person: lpa.PeopleToNotify[1], Notice: lpa.PersonToNotifyNotices[1]},
+				{Person: lpa.PeopleToNotify[2], Notice: page.PersonToNotifyNotice{PersonToNotifyID: "3"}},
+			},
+			PeopleToNotifyFailed: true,
+		}).
+		Return(nil)
+
+	err := LpaProgress(template.Func, lpaStore, messageStore)(appData, w, r)
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, lpaStore, messageStore, template)
+}
+
+func TestGetLpaProgressWhenLpaStoreErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{}, expectedError)
+
+	err := LpaProgress(nil, lpaStore, nil)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+}
+
+func TestGetLpaProgressWhenMessageStoreErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{}, nil)
+
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("GetAll", r.Context()).
+		Return([]page.Message(nil), expectedError)
+
+	err := LpaProgress(nil, lpaStore, messageStore)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+}
+
+func TestGetLpaProgressWhenTemplateErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{}, nil)
+
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("GetAll", r.Context()).
+		Return([]page.Message(nil), nil)
+
+	template := &mockTemplate{}
+	template.
+		On("Func", w, mock.Anything).
+		Return(expectedError)
+
+	err := LpaProgress(template.Func, lpaStore, messageStore)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+}
@@ -2,12 +2,13 @@ package donor
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/sessions"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/event"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/identity"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/localize"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
@@ -40,9 +41,9 @@ func (m *mockLpaStore) Create(ctx context.Context) (*page.Lpa, error) {
 	return args.Get(0).(*page.Lpa), args.Error(1)
 }
 
-func (m *mockLpaStore) GetAll(ctx context.Context) ([]*page.Lpa, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]*page.Lpa), args.Error(1)
+func (m *mockLpaStore) GetPage(ctx context.Context, query page.LpaQuery) (page.LpaPage, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(page.LpaPage), args.Error(1)
 }
 
 func (m *mockLpaStore) Get(ctx context.Context) (*page.Lpa, error) {
@@ -54,6 +55,24 @@ func (m *mockLpaStore) Put(ctx context.Context, v *page.Lpa) error {
 	return m.Called(ctx, v).Error(0)
 }
 
+func (m *mockLpaStore) Delete(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func (m *mockLpaStore) GetAllAs(ctx context.Context, actorType page.ActorType) ([]*page.Lpa, error) {
+	args := m.Called(ctx, actorType)
+	return args.Get(0).([]*page.Lpa), args.Error(1)
+}
+
+func (m *mockLpaStore) Link(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func (m *mockLpaStore) History(ctx context.Context) ([]page.LpaEvent, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]page.LpaEvent), args.Error(1)
+}
+
 type mockTemplate struct {
 	mock.Mock
 }
@@ -160,8 +179,13 @@ type mockNotifyClient struct {
 	mock.Mock
 }
 
-func (m *mockNotifyClient) TemplateID(id notify.TemplateId) string {
-	return m.Called(id).String(0)
+func (m *mockNotifyClient) TemplateID(id notify.TemplateId, lang localize.Lang) string {
+	return m.Called(id, lang).String(0)
+}
+
+func (m *mockNotifyClient) Letter(ctx context.Context, letter notify.Letter) (string, error) {
+	args := m.Called(ctx, letter)
+	return args.String(0), args.Error(1)
 }
 
 func (m *mockNotifyClient) Email(ctx context.Context, email notify.Email) (string, error) {
@@ -174,23 +198,119 @@ func (m *mockNotifyClient) Sms(ctx context.Context, sms notify.Sms) (string, err
 	return args.String(0), args.Error(1)
 }
 
-type mockDataStore struct {
-	data interface{}
+func (m *mockNotifyClient) FindByReference(ctx context.Context, notificationType, reference string) (string, error) {
+	args := m.Called(ctx, notificationType, reference)
+	return args.String(0), args.Error(1)
+}
+
+type mockEventPublisher struct {
 	mock.Mock
 }
 
-func (m *mockDataStore) GetAll(ctx context.Context, pk string, v interface{}) error {
-	data, _ := json.Marshal(m.data)
-	json.Unmarshal(data, v)
-	return m.Called(ctx, pk).Error(0)
+func (m *mockEventPublisher) Publish(ctx context.Context, e event.Event) error {
+	return m.Called(ctx, e).Error(0)
 }
 
-func (m *mockDataStore) Get(ctx context.Context, pk, sk string, v interface{}) error {
-	data, _ := json.Marshal(m.data)
-	json.Unmarshal(data, v)
-	return m.Called(ctx, pk, sk).Error(0)
+type mockRegistrationClient struct {
+	mock.Mock
+}
+
+func (m *mockRegistrationClient) Register(ctx context.Context, lpa *page.Lpa) (page.Registration, error) {
+	args := m.Called(ctx, lpa)
+	return args.Get(0).(page.Registration), args.Error(1)
+}
+
+type mockRegistrationStore struct {
+	mock.Mock
+}
+
+func (m *mockRegistrationStore) PutPending(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func (m *mockRegistrationStore) DeletePending(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func (m *mockRegistrationStore) GetAllPending(ctx context.Context) ([]page.PendingRegistration, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]page.PendingRegistration), args.Error(1)
+}
+
+type mockShareCodeStore struct {
+	mock.Mock
+}
+
+func (m *mockShareCodeStore) Create(ctx context.Context, data page.ShareCodeData) (string, error) {
+	args := m.Called(ctx, data)
+	return args.String(0), args.Error(1)
 }
 
-func (m *mockDataStore) Put(ctx context.Context, pk, sk string, v interface{}) error {
-	return m.Called(ctx, pk, sk, v).Error(0)
+func (m *mockShareCodeStore) Get(ctx context.Context, shareCode string) (page.ShareCodeData, error) {
+	args := m.Called(ctx, shareCode)
+	return args.Get(0).(page.ShareCodeData), args.Error(1)
+}
+
+func (m *mockShareCodeStore) Redeem(ctx context.Context, shareCode string) (page.ShareCodeData, error) {
+	args := m.Called(ctx, shareCode)
+	return args.Get(0).(page.ShareCodeData), args.Error(1)
+}
+
+func (m *mockShareCodeStore) Revoke(ctx context.Context, lpaID string, actorType page.ActorType, actorID, keepShareCode string) error {
+	return m.Called(ctx, lpaID, actorType, actorID, keepShareCode).Error(0)
+}
+
+func (m *mockShareCodeStore) RevokeAll(ctx context.Context, lpaID string) error {
+	return m.Called(ctx, lpaID).Error(0)
+}
+
+type mockPaymentStore struct {
+	mock.Mock
+}
+
+func (m *mockPaymentStore) PutInFlight(ctx context.Context, paymentID string) error {
+	return m.Called(ctx, paymentID).Error(0)
+}
+
+func (m *mockPaymentStore) DeleteInFlight(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func (m *mockPaymentStore) GetAllInFlight(ctx context.Context) ([]page.InFlightPayment, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]page.InFlightPayment), args.Error(1)
+}
+
+type mockMessageStore struct {
+	mock.Mock
+}
+
+func (m *mockMessageStore) Put(ctx context.Context, message page.Message) error {
+	return m.Called(ctx, message).Error(0)
+}
+
+func (m *mockMessageStore) GetAll(ctx context.Context) ([]page.Message, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]page.Message), args.Error(1)
+}
+
+func (m *mockMessageStore) UpdateStatus(ctx context.Context, id, status string, completedAt time.Time) error {
+	return m.Called(ctx, id, status, completedAt).Error(0)
+}
+
+type mockNoticeStore struct {
+	mock.Mock
+}
+
+func (m *mockNoticeStore) PutPending(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func (m *mockNoticeStore) DeletePending(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func (m *mockNoticeStore) GetAllPending(ctx context.Context) ([]page.PendingNotices, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]page.PendingNotices), args.Error(1)
 }
@@ -0,0 +1,22 @@
+package donor
+
+import (
+	"errors"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/validation"
+)
+
+// notifyError converts an error from Notify into one to show the donor, when
+// it is something they can act on: either by correcting the recipient's details
+// or by trying again later.
+func notifyError(err error, invalidRecipientLabel string) (validation.FormattableError, bool) {
+	switch {
+	case errors.Is(err, notify.ErrInvalidRecipient):
+		return validation.CustomError{Label: invalidRecipientLabel}, true
+	case errors.Is(err, notify.ErrRateLimited), errors.Is(err, notify.ErrServiceDown):
+		return validation.CustomError{Label: "messageCouldNotBeSentTryAgainLater"}, true
+	default:
+		return nil, false
+	}
+}
@@ -1,13 +1,16 @@
 package donor
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/sessions"
 	"github.com/ministryofjustice/opg-go-common/template"
-	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/event"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/pay"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/sesh"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/validation"
 )
@@ -16,10 +19,12 @@ type paymentConfirmationData struct {
 	App              page.AppData
 	Errors           validation.List
 	PaymentReference string
+	FeeItems         []page.FeeItem
+	Amount           int
 	Continue         string
 }
 
-func PaymentConfirmation(logger page.Logger, tmpl template.Template, payClient page.PayClient, notifyClient page.NotifyClient, lpaStore page.LpaStore, sessionStore sessions.Store, appPublicURL string, dataStore page.DataStore, randomString func(int) string) page.Handler {
+func PaymentConfirmation(logger page.Logger, tmpl template.Template, payClient page.PayClient, notifyClient page.NotifyClient, eventPublisher page.EventPublisher, lpaStore page.LpaStore, sessionStore sessions.Store, appPublicURL string, shareCodeStore page.ShareCodeStore, paymentStore page.PaymentStore, messageStore page.MessageStore, now func() time.Time) page.Handler {
 	return func(appData page.AppData, w http.ResponseWriter, r *http.Request) error {
 		lpa, err := lpaStore.Get(r.Context())
 		if err != nil {
@@ -39,47 +44,87 @@ func PaymentConfirmation(logger page.Logger, tmpl template.Template, payClient p
 			return err
 		}
 
-		shareCode := randomString(12)
-
-		if err := dataStore.Put(r.Context(), "SHARECODE#"+shareCode, "#METADATA#"+shareCode, page.ShareCodeData{
-			SessionID: appData.SessionID,
-			LpaID:     appData.LpaID,
-		}); err != nil {
+		if err := recordPayment(r.Context(), lpa, payment, now(), lpaStore, notifyClient, eventPublisher, shareCodeStore, paymentStore, messageStore, appPublicURL); err != nil {
 			return err
 		}
 
-		if _, err := notifyClient.Email(r.Context(), notify.Email{
-			TemplateID:   notifyClient.TemplateID(notify.CertificateProviderInviteEmail),
-			EmailAddress: lpa.CertificateProvider.Email,
-			Personalisation: map[string]string{
-				"link": fmt.Sprintf("%s%s?share-code=%s", appPublicURL, page.Paths.CertificateProviderStart, shareCode),
-			},
-		}); err != nil {
-			return fmt.Errorf("error email certificate provider after payment: %w", err)
+		if payment.State.InProgress() {
+			return appData.Redirect(w, r, lpa, page.Paths.PaymentPending)
 		}
 
-		lpa.PaymentDetails = page.PaymentDetails{
-			PaymentReference: payment.Reference,
-			PaymentId:        payment.PaymentId,
+		if err := sesh.ClearPayment(sessionStore, r, w); err != nil {
+			logger.Print(fmt.Sprintf("unable to expire cookie in session: %s", err.Error()))
+		}
+
+		if payment.State.Status != pay.StatusSuccess {
+			return appData.Redirect(w, r, lpa, page.Paths.PaymentFailed)
 		}
 
 		data := &paymentConfirmationData{
 			App:              appData,
 			PaymentReference: payment.Reference,
+			FeeItems:         lpa.FeeItems(),
+			Amount:           payment.Amount,
 			Continue:         appData.Paths.TaskList,
 		}
 
-		if err := sesh.ClearPayment(sessionStore, r, w); err != nil {
-			logger.Print(fmt.Sprintf("unable to expire cookie in session: %s", err.Error()))
+		return tmpl(w, data)
+	}
+}
+
+// recordPayment updates the LPA with the latest state of its payment. The
+// first time the payment is seen to have succeeded an event is published. The
+// pay task is only completed once any evidence for a fee reduction has also
+// been accepted; until then the LPA waits for the review. Once the LPA is
+// written the certificate provider is sent an invite; if that fails the payment
+// is still recorded, and the donor can resend the invite from the progress
+// page. Finished payments are no longer in-flight, so will not be checked
+// again.
+func recordPayment(ctx context.Context, lpa *page.Lpa, payment pay.GetPaymentResponse, now time.Time, lpaStore page.LpaStore, notifyClient page.NotifyClient, eventPublisher page.EventPublisher, shareCodeStore page.ShareCodeStore, paymentStore page.PaymentStore, messageStore page.MessageStore, appPublicURL string) error {
+	alreadyPaid := lpa.FeePaid()
+	lpa.PaymentDetails.Record(payment, now)
+	paid := !alreadyPaid && lpa.FeePaid()
+
+	invite := false
+	if lpa.Tasks.PayForLpa != page.TaskCompleted {
+		if lpa.FeePaid() && !lpa.FeeEvidenceReviewPending() {
+			lpa.Tasks.PayForLpa = page.TaskCompleted
+			invite = true
+		} else {
+			lpa.Tasks.PayForLpa = page.TaskInProgress
 		}
+	}
 
-		lpa.Tasks.PayForLpa = page.TaskCompleted
+	if err := lpaStore.Put(ctx, lpa); err != nil {
+		return fmt.Errorf("unable to update lpa with payment: %w", err)
+	}
 
-		if err := lpaStore.Put(r.Context(), lpa); err != nil {
-			logger.Print(fmt.Sprintf("unable to update lpa in dataStore: %s", err.Error()))
-			return err
+	if paid {
+		if err := eventPublisher.Publish(ctx, event.LpaPaid{
+			LpaID:            lpa.ID,
+			PaymentID:        lpa.PaymentDetails.PaymentId,
+			PaymentReference: lpa.PaymentDetails.PaymentReference,
+			Amount:           lpa.PaymentDetails.Amount,
+		}); err != nil {
+			return fmt.Errorf("unable to publish payment event: %w", err)
 		}
+	}
 
-		return tmpl(w, data)
+	if err := page.CommitLpa(ctx); err != nil {
+		return fmt.Errorf("unable to update lpa with payment: %w", err)
+	}
+
+	if !payment.State.InProgress() {
+		if err := paymentStore.DeleteInFlight(ctx); err != nil {
+			return fmt.Errorf("unable to delete in-flight payment: %w", err)
+		}
 	}
+
+	if invite {
+		if _, err := sendCertificateProviderInvite(ctx, notifyClient, shareCodeStore, messageStore, appPublicURL, lpa); err != nil {
+			return fmt.Errorf("error email certificate provider after payment: %w", err)
+		}
+	}
+
+	return nil
 }
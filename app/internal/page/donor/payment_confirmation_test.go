@@ -1,14 +1,19 @@
 package donor
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gorilla/sessions"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/dynamo"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/event"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/localize"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/pay"
@@ -17,6 +22,10 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
+var paymentTime = time.Date(2023, time.January, 2, 3, 4, 5, 6, time.UTC)
+
+func mockPaymentNow() time.Time { return paymentTime }
+
 func TestGetPaymentConfirmation(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodGet, "/payment-confirmation", nil)
@@ -26,21 +35,39 @@ func TestGetPaymentConfirmation(t *testing.T) {
 
 	notifyClient := &mockNotifyClient{}
 	notifyClient.
-		On("TemplateID", notify.CertificateProviderInviteEmail).
+		On("TemplateID", notify.CertificateProviderInviteEmail, localize.En).
 		Return("template-id")
 	notifyClient.
 		On("Email", r.Context(), notify.Email{
 			TemplateID:   "template-id",
 			EmailAddress: "certificateprovider@example.com",
+			Reference:    "lpa-id/certificate-provider-invite-email/a665a459",
 			Personalisation: map[string]string{
 				"link": fmt.Sprintf("http://app%s?share-code=123", page.Paths.CertificateProviderStart),
 			},
 		}).
-		Return("", nil)
+		Return("email-id", nil)
+
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("Put", r.Context(), page.Message{
+			ID:            "email-id",
+			LpaID:         "lpa-id",
+			RecipientRole: page.ActorTypeCertificateProvider,
+			Template:      notify.CertificateProviderInviteEmail.String(),
+			Status:        notify.StatusCreated,
+		}).
+		Return(nil)
 
 	template := &mockTemplate{}
 	template.
-		On("Func", w, &paymentConfirmationData{App: appData, PaymentReference: "123456789012", Continue: appData.Paths.TaskList}).
+		On("Func", w, &paymentConfirmationData{
+			App:              appData,
+			PaymentReference: "123456789012",
+			FeeItems:         []page.FeeItem{{Amount: 8200}},
+			Amount:           8200,
+			Continue:         appData.Paths.TaskList,
+		}).
 		Return(nil)
 
 	sessionsStore := (&mockSessionsStore{}).
@@ -51,35 +78,252 @@ func TestGetPaymentConfirmation(t *testing.T) {
 		willReturnEmptyLpa(r).
 		withCompletedPaymentLpaData(r, "abc123", "123456789012")
 
-	dataStore := &mockDataStore{}
-	dataStore.
-		On("Put", r.Context(), "SHARECODE#123", "#METADATA#123", page.ShareCodeData{SessionID: "session-id", LpaID: "lpa-id"}).
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Create", r.Context(), page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeCertificateProvider}).
+		Return("123", nil)
+
+	paymentStore := &mockPaymentStore{}
+	paymentStore.
+		On("DeleteInFlight", r.Context()).
+		Return(nil)
+
+	eventPublisher := &mockEventPublisher{}
+	eventPublisher.
+		On("Publish", r.Context(), event.LpaPaid{LpaID: "lpa-id", PaymentID: "abc123", PaymentReference: "123456789012", Amount: 8200}).
 		Return(nil)
 
-	err := PaymentConfirmation(&mockLogger{}, template.Func, payClient, notifyClient, lpaStore, sessionsStore, "http://app", dataStore, mockRandom)(appData, w, r)
+	err := PaymentConfirmation(&mockLogger{}, template.Func, payClient, notifyClient, eventPublisher, lpaStore, sessionsStore, "http://app", shareCodeStore, paymentStore, messageStore, mockPaymentNow)(appData, w, r)
 	resp := w.Result()
 
 	assert.Nil(t, err)
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
-	mock.AssertExpectationsForObjects(t, template, dataStore, payClient, lpaStore, sessionsStore)
+	mock.AssertExpectationsForObjects(t, template, shareCodeStore, paymentStore, payClient, lpaStore, sessionsStore, eventPublisher)
 }
 
-func TestGetPaymentConfirmationGettingLpaErrors(t *testing.T) {
+func TestGetPaymentConfirmationWhenLpaChanged(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequestWithContext(page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "lpa-id"}), http.MethodGet, "/payment-confirmation", nil)
+
+	payClient := (&mockPayClient{}).
+		withASuccessfulPayment("abc123", "123456789012")
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&page.Lpa{ID: "lpa-id", CertificateProvider: actor.CertificateProvider{Email: "certificateprovider@example.com"}}, nil)
+	lpaStore.
+		On("Put", mock.Anything, mock.Anything).
+		Return(dynamo.ConflictError{}).
+		Once()
+
+	sessionsStore := &mockSessionsStore{}
+	sessionsStore.
+		On("Get", mock.Anything, "pay").
+		Return(&sessions.Session{Values: map[any]any{"payment": &sesh.PaymentSession{PaymentID: "abc123"}}}, nil)
+
+	notifyClient := &mockNotifyClient{}
+	shareCodeStore := &mockShareCodeStore{}
+	paymentStore := &mockPaymentStore{}
+	messageStore := &mockMessageStore{}
+	eventPublisher := &mockEventPublisher{}
+
+	handler := PaymentConfirmation(&mockLogger{}, nil, payClient, notifyClient, page.UnitOfWorkEventPublisher(eventPublisher), page.UnitOfWorkLpaStore(lpaStore), sessionsStore, "http://app", shareCodeStore, paymentStore, messageStore, mockPaymentNow)
+
+	err := page.WithLpaUnitOfWork(handler)(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/payment-confirmation", resp.Header.Get("Location"))
+	paymentStore.AssertNotCalled(t, "DeleteInFlight", mock.Anything)
+	shareCodeStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	notifyClient.AssertNotCalled(t, "Email", mock.Anything, mock.Anything)
+	eventPublisher.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	mock.AssertExpectationsForObjects(t, lpaStore, payClient)
+}
+
+func TestGetPaymentConfirmationWhenAlreadyPaid(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodGet, "/payment-confirmation", nil)
 
+	payClient := (&mockPayClient{}).
+		withASuccessfulPayment("abc123", "123456789012")
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{
+			ID:             "lpa-id",
+			PaymentDetails: page.PaymentDetails{PaymentId: "abc123", Status: pay.StatusSuccess},
+			Tasks:          page.Tasks{PayForLpa: page.TaskCompleted},
+		}, nil)
+	lpaStore.
+		On("Put", r.Context(), mock.MatchedBy(func(lpa *page.Lpa) bool {
+			return lpa.Tasks.PayForLpa == page.TaskCompleted && lpa.PaymentDetails.Status == pay.StatusSuccess
+		})).
+		Return(nil)
+
+	sessionsStore := (&mockSessionsStore{}).
+		withPaySession(r).
+		withExpiredPaySession(r, w)
+
+	paymentStore := &mockPaymentStore{}
+	paymentStore.
+		On("DeleteInFlight", r.Context()).
+		Return(nil)
+
 	template := &mockTemplate{}
+	template.
+		On("Func", w, &paymentConfirmationData{
+			App:              appData,
+			PaymentReference: "123456789012",
+			FeeItems:         []page.FeeItem{{Amount: 8200}},
+			Amount:           8200,
+			Continue:         appData.Paths.TaskList,
+		}).
+		Return(nil)
+
+	err := PaymentConfirmation(&mockLogger{}, template.Func, payClient, nil, nil, lpaStore, sessionsStore, "http://app", nil, paymentStore, nil, mockPaymentNow)(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	mock.AssertExpectationsForObjects(t, template, paymentStore, payClient, lpaStore, sessionsStore)
+}
+
+func TestGetPaymentConfirmationWhenFeeEvidenceReviewPending(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/payment-confirmation", nil)
+
+	payClient := (&mockPayClient{}).
+		withASuccessfulPayment("abc123", "123456789012")
+
 	lpaStore := &mockLpaStore{}
 	lpaStore.
 		On("Get", r.Context()).
-		Return(&page.Lpa{}, expectedError)
+		Return(&page.Lpa{ID: "lpa-id", FeeType: page.FeeTypeHalf, FeeEvidenceReview: page.TaskInProgress}, nil)
+	lpaStore.
+		On("Put", r.Context(), mock.MatchedBy(func(lpa *page.Lpa) bool {
+			return lpa.Tasks.PayForLpa == page.TaskInProgress && lpa.PaymentDetails.Status == pay.StatusSuccess
+		})).
+		Return(nil)
 
-	logger := &mockLogger{}
-	logger.
-		On("Print", fmt.Sprintf("unable to retrieve item from data store using key '%s': %s", "session-id", expectedError.Error())).
+	sessionsStore := (&mockSessionsStore{}).
+		withPaySession(r).
+		withExpiredPaySession(r, w)
+
+	paymentStore := &mockPaymentStore{}
+	paymentStore.
+		On("DeleteInFlight", r.Context()).
+		Return(nil)
+
+	eventPublisher := &mockEventPublisher{}
+	eventPublisher.
+		On("Publish", r.Context(), event.LpaPaid{LpaID: "lpa-id", PaymentID: "abc123", PaymentReference: "123456789012", Amount: 8200}).
+		Return(nil)
+
+	template := &mockTemplate{}
+	template.
+		On("Func", w, mock.Anything).
 		Return(nil)
 
-	err := PaymentConfirmation(logger, template.Func, &mockPayClient{}, nil, lpaStore, &mockSessionsStore{}, "http://app", nil, mockRandom)(appData, w, r)
+	err := PaymentConfirmation(&mockLogger{}, template.Func, payClient, nil, eventPublisher, lpaStore, sessionsStore, "http://app", nil, paymentStore, nil, mockPaymentNow)(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	mock.AssertExpectationsForObjects(t, template, paymentStore, payClient, lpaStore, sessionsStore, eventPublisher)
+}
+
+func TestGetPaymentConfirmationWhenPaymentInProgress(t *testing.T) {
+	for _, status := range []string{pay.StatusCreated, pay.StatusStarted, pay.StatusSubmitted, pay.StatusCapturable} {
+		t.Run(status, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodGet, "/payment-confirmation", nil)
+
+			payClient := (&mockPayClient{}).
+				withPayment("abc123", status)
+
+			lpaStore := (&mockLpaStore{}).
+				willReturnEmptyLpa(r)
+			lpaStore.
+				On("Put", r.Context(), &page.Lpa{
+					ID:                  "lpa-id",
+					CertificateProvider: actor.CertificateProvider{Email: "certificateprovider@example.com"},
+					PaymentDetails: page.PaymentDetails{
+						PaymentReference: "123456789012",
+						PaymentId:        "abc123",
+						Amount:           8200,
+						Status:           status,
+						StatusHistory:    []page.PaymentStatusChange{{PaymentId: "abc123", Status: status, At: paymentTime}},
+					},
+					Tasks: page.Tasks{PayForLpa: page.TaskInProgress},
+				}).
+				Return(nil)
+
+			sessionsStore := (&mockSessionsStore{}).
+				withPaySession(r)
+
+			err := PaymentConfirmation(&mockLogger{}, nil, payClient, nil, nil, lpaStore, sessionsStore, "http://app", nil, nil, nil, mockPaymentNow)(appData, w, r)
+			resp := w.Result()
+
+			assert.Nil(t, err)
+			assert.Equal(t, http.StatusFound, resp.StatusCode)
+			assert.Equal(t, "/lpa/lpa-id"+page.Paths.PaymentPending, resp.Header.Get("Location"))
+			mock.AssertExpectationsForObjects(t, payClient, lpaStore, sessionsStore)
+		})
+	}
+}
+
+func TestGetPaymentConfirmationWhenPaymentNotSuccessful(t *testing.T) {
+	for _, status := range []string{pay.StatusFailed, pay.StatusCancelled, pay.StatusError} {
+		t.Run(status, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodGet, "/payment-confirmation", nil)
+
+			payClient := (&mockPayClient{}).
+				withPayment("abc123", status)
+
+			lpaStore := (&mockLpaStore{}).
+				willReturnEmptyLpa(r)
+			lpaStore.
+				On("Put", r.Context(), mock.MatchedBy(func(lpa *page.Lpa) bool {
+					return lpa.Tasks.PayForLpa == page.TaskInProgress && lpa.PaymentDetails.Status == status
+				})).
+				Return(nil)
+
+			sessionsStore := (&mockSessionsStore{}).
+				withPaySession(r).
+				withExpiredPaySession(r, w)
+
+			paymentStore := &mockPaymentStore{}
+			paymentStore.
+				On("DeleteInFlight", r.Context()).
+				Return(nil)
+
+			err := PaymentConfirmation(&mockLogger{}, nil, payClient, nil, nil, lpaStore, sessionsStore, "http://app", nil, paymentStore, nil, mockPaymentNow)(appData, w, r)
+			resp := w.Result()
+
+			assert.Nil(t, err)
+			assert.Equal(t, http.StatusFound, resp.StatusCode)
+			assert.Equal(t, "/lpa/lpa-id"+page.Paths.PaymentFailed, resp.Header.Get("Location"))
+			mock.AssertExpectationsForObjects(t, payClient, lpaStore, sessionsStore, paymentStore)
+		})
+	}
+}
+
+func TestGetPaymentConfirmationGettingLpaErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/payment-confirmation", nil)
+
+	template := &mockTemplate{}
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{}, expectedError)
+
+	err := PaymentConfirmation(nil, template.Func, &mockPayClient{}, nil, nil, lpaStore, &mockSessionsStore{}, "http://app", nil, nil, nil, mockPaymentNow)(appData, w, r)
 	resp := w.Result()
 
 	assert.Equal(t, expectedError, err)
@@ -100,7 +344,7 @@ func TestGetPaymentConfirmationWhenErrorGettingSession(t *testing.T) {
 		On("Get", r, "pay").
 		Return(&sessions.Session{}, expectedError)
 
-	err := PaymentConfirmation(nil, template.Func, &mockPayClient{}, nil, lpaStore, sessionsStore, "http://app", nil, mockRandom)(appData, w, r)
+	err := PaymentConfirmation(nil, template.Func, &mockPayClient{}, nil, nil, lpaStore, sessionsStore, "http://app", nil, nil, nil, mockPaymentNow)(appData, w, r)
 	resp := w.Result()
 
 	assert.Equal(t, expectedError, err)
@@ -130,7 +374,7 @@ func TestGetPaymentConfirmationWhenErrorGettingPayment(t *testing.T) {
 
 	template := &mockTemplate{}
 
-	err := PaymentConfirmation(logger, template.Func, payClient, nil, lpaStore, sessionsStore, "http://app", nil, mockRandom)(appData, w, r)
+	err := PaymentConfirmation(logger, template.Func, payClient, nil, nil, lpaStore, sessionsStore, "http://app", nil, nil, nil, mockPaymentNow)(appData, w, r)
 	resp := w.Result()
 
 	assert.Equal(t, expectedError, err)
@@ -138,12 +382,25 @@ func TestGetPaymentConfirmationWhenErrorGettingPayment(t *testing.T) {
 	mock.AssertExpectationsForObjects(t, lpaStore, sessionsStore, logger, payClient)
 }
 
-func TestGetPaymentConfirmationWhenErrorPuttingShareCode(t *testing.T) {
+func TestGetPaymentConfirmationWhenErrorCreatingShareCode(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodGet, "/payment-confirmation", nil)
 
 	lpaStore := (&mockLpaStore{}).
 		willReturnEmptyLpa(r)
+	lpaStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(nil)
+
+	eventPublisher := &mockEventPublisher{}
+	eventPublisher.
+		On("Publish", r.Context(), mock.Anything).
+		Return(nil)
+
+	paymentStore := &mockPaymentStore{}
+	paymentStore.
+		On("DeleteInFlight", r.Context()).
+		Return(nil)
 
 	sessionsStore := (&mockSessionsStore{}).
 		withPaySession(r)
@@ -151,20 +408,15 @@ func TestGetPaymentConfirmationWhenErrorPuttingShareCode(t *testing.T) {
 	payClient := (&mockPayClient{}).
 		withASuccessfulPayment("abc123", "123456789012")
 
-	dataStore := &mockDataStore{}
-	dataStore.
-		On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
-		Return(expectedError)
-
-	template := &mockTemplate{}
-	template.
-		On("Func", w, mock.Anything).
-		Return(nil)
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Create", mock.Anything, mock.Anything).
+		Return("", expectedError)
 
-	err := PaymentConfirmation(nil, template.Func, payClient, nil, lpaStore, sessionsStore, "http://app", dataStore, mockRandom)(appData, w, r)
+	err := PaymentConfirmation(nil, nil, payClient, nil, eventPublisher, lpaStore, sessionsStore, "http://app", shareCodeStore, paymentStore, nil, mockPaymentNow)(appData, w, r)
 
-	assert.Equal(t, expectedError, err)
-	mock.AssertExpectationsForObjects(t, dataStore, lpaStore, sessionsStore, payClient)
+	assert.Equal(t, expectedError, errors.Unwrap(err))
+	mock.AssertExpectationsForObjects(t, shareCodeStore, lpaStore, eventPublisher, paymentStore, sessionsStore, payClient)
 }
 
 func TestGetPaymentConfirmationWhenErrorSendingEmail(t *testing.T) {
@@ -173,7 +425,7 @@ func TestGetPaymentConfirmationWhenErrorSendingEmail(t *testing.T) {
 
 	notifyClient := &mockNotifyClient{}
 	notifyClient.
-		On("TemplateID", mock.Anything).
+		On("TemplateID", mock.Anything, mock.Anything).
 		Return("template-id")
 	notifyClient.
 		On("Email", mock.Anything, mock.Anything).
@@ -181,6 +433,19 @@ func TestGetPaymentConfirmationWhenErrorSendingEmail(t *testing.T) {
 
 	lpaStore := (&mockLpaStore{}).
 		willReturnEmptyLpa(r)
+	lpaStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(nil)
+
+	eventPublisher := &mockEventPublisher{}
+	eventPublisher.
+		On("Publish", r.Context(), mock.Anything).
+		Return(nil)
+
+	paymentStore := &mockPaymentStore{}
+	paymentStore.
+		On("DeleteInFlight", r.Context()).
+		Return(nil)
 
 	sessionsStore := (&mockSessionsStore{}).
 		withPaySession(r)
@@ -188,20 +453,64 @@ func TestGetPaymentConfirmationWhenErrorSendingEmail(t *testing.T) {
 	payClient := (&mockPayClient{}).
 		withASuccessfulPayment("abc123", "123456789012")
 
-	dataStore := &mockDataStore{}
-	dataStore.
-		On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
-		Return(nil)
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Create", mock.Anything, mock.Anything).
+		Return("123", nil)
 
-	template := &mockTemplate{}
-	template.
-		On("Func", w, mock.Anything).
+	err := PaymentConfirmation(nil, nil, payClient, notifyClient, eventPublisher, lpaStore, sessionsStore, "http://app", shareCodeStore, paymentStore, nil, mockPaymentNow)(appData, w, r)
+
+	assert.Equal(t, expectedError, errors.Unwrap(err))
+	mock.AssertExpectationsForObjects(t, shareCodeStore, lpaStore, eventPublisher, paymentStore, sessionsStore, payClient)
+}
+
+func TestGetPaymentConfirmationWhenErrorPuttingLpa(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/payment-confirmation", nil)
+
+	lpaStore := (&mockLpaStore{}).
+		willReturnEmptyLpa(r)
+	lpaStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(expectedError)
+
+	sessionsStore := (&mockSessionsStore{}).
+		withPaySession(r)
+
+	payClient := (&mockPayClient{}).
+		withPayment("abc123", pay.StatusFailed)
+
+	err := PaymentConfirmation(nil, nil, payClient, nil, nil, lpaStore, sessionsStore, "http://app", nil, nil, nil, mockPaymentNow)(appData, w, r)
+
+	assert.Equal(t, expectedError, errors.Unwrap(err))
+	mock.AssertExpectationsForObjects(t, lpaStore, sessionsStore, payClient)
+}
+
+func TestGetPaymentConfirmationWhenErrorDeletingInFlightPayment(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/payment-confirmation", nil)
+
+	lpaStore := (&mockLpaStore{}).
+		willReturnEmptyLpa(r)
+	lpaStore.
+		On("Put", r.Context(), mock.Anything).
 		Return(nil)
 
-	err := PaymentConfirmation(nil, template.Func, payClient, notifyClient, lpaStore, sessionsStore, "http://app", dataStore, mockRandom)(appData, w, r)
+	sessionsStore := (&mockSessionsStore{}).
+		withPaySession(r)
+
+	payClient := (&mockPayClient{}).
+		withPayment("abc123", pay.StatusFailed)
+
+	paymentStore := &mockPaymentStore{}
+	paymentStore.
+		On("DeleteInFlight", r.Context()).
+		Return(expectedError)
+
+	err := PaymentConfirmation(nil, nil, payClient, nil, nil, lpaStore, sessionsStore, "http://app", nil, paymentStore, nil, mockPaymentNow)(appData, w, r)
 
 	assert.Equal(t, expectedError, errors.Unwrap(err))
-	mock.AssertExpectationsForObjects(t, dataStore, lpaStore, sessionsStore, payClient)
+	mock.AssertExpectationsForObjects(t, lpaStore, sessionsStore, payClient, paymentStore)
 }
 
 func TestGetPaymentConfirmationWhenErrorExpiringSession(t *testing.T) {
@@ -210,12 +519,17 @@ func TestGetPaymentConfirmationWhenErrorExpiringSession(t *testing.T) {
 
 	notifyClient := &mockNotifyClient{}
 	notifyClient.
-		On("TemplateID", mock.Anything).
+		On("TemplateID", mock.Anything, mock.Anything).
 		Return("template-id")
 	notifyClient.
 		On("Email", mock.Anything, mock.Anything).
 		Return("", nil)
 
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("Put", mock.Anything, mock.Anything).
+		Return(nil)
+
 	lpaStore := (&mockLpaStore{}).
 		willReturnEmptyLpa(r).
 		withCompletedPaymentLpaData(r, "abc123", "123456789012")
@@ -235,9 +549,14 @@ func TestGetPaymentConfirmationWhenErrorExpiringSession(t *testing.T) {
 	payClient := (&mockPayClient{}).
 		withASuccessfulPayment("abc123", "123456789012")
 
-	dataStore := &mockDataStore{}
-	dataStore.
-		On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Create", mock.Anything, mock.Anything).
+		Return("123", nil)
+
+	paymentStore := &mockPaymentStore{}
+	paymentStore.
+		On("DeleteInFlight", r.Context()).
 		Return(nil)
 
 	template := &mockTemplate{}
@@ -245,18 +564,24 @@ func TestGetPaymentConfirmationWhenErrorExpiringSession(t *testing.T) {
 		On("Func", w, mock.Anything).
 		Return(nil)
 
-	err := PaymentConfirmation(logger, template.Func, payClient, notifyClient, lpaStore, sessionsStore, "http://app", dataStore, mockRandom)(appData, w, r)
+	eventPublisher := &mockEventPublisher{}
+	eventPublisher.
+		On("Publish", r.Context(), mock.Anything).
+		Return(nil)
+
+	err := PaymentConfirmation(logger, template.Func, payClient, notifyClient, eventPublisher, lpaStore, sessionsStore, "http://app", shareCodeStore, paymentStore, messageStore, mockPaymentNow)(appData, w, r)
 	resp := w.Result()
 
 	assert.Nil(t, err)
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
-	mock.AssertExpectationsForObjects(t, dataStore, lpaStore, sessionsStore, logger, payClient)
+	mock.AssertExpectationsForObjects(t, shareCodeStore, paymentStore, lpaStore, sessionsStore, logger, payClient)
 }
 
 func (m *mockLpaStore) willReturnEmptyLpa(r *http.Request) *mockLpaStore {
 	m.
 		On("Get", r.Context()).
 		Return(&page.Lpa{
+			ID: "lpa-id",
 			CertificateProvider: actor.CertificateProvider{
 				Email: "certificateprovider@example.com",
 			},
@@ -268,12 +593,18 @@ func (m *mockLpaStore) willReturnEmptyLpa(r *http.Request) *mockLpaStore {
 func (m *mockLpaStore) withCompletedPaymentLpaData(r *http.Request, paymentId, paymentReference string) *mockLpaStore {
 	m.
 		On("Put", r.Context(), &page.Lpa{
+			ID: "lpa-id",
 			CertificateProvider: actor.CertificateProvider{
 				Email: "certificateprovider@example.com",
 			},
 			PaymentDetails: page.PaymentDetails{
-				PaymentId:        paymentId,
 				PaymentReference: paymentReference,
+				PaymentId:        paymentId,
+				Amount:           8200,
+				Status:           pay.StatusSuccess,
+				StatusHistory: []page.PaymentStatusChange{
+					{PaymentId: paymentId, Status: pay.StatusSuccess, At: paymentTime},
+				},
 			},
 			Tasks: page.Tasks{
 				PayForLpa: page.TaskCompleted,
@@ -294,6 +625,20 @@ func (m *mockPayClient) withASuccessfulPayment(paymentId, reference string) *moc
 			},
 			PaymentId: paymentId,
 			Reference: reference,
+			Amount:    8200,
+		}, nil)
+
+	return m
+}
+
+func (m *mockPayClient) withPayment(paymentId, status string) *mockPayClient {
+	m.
+		On("GetPayment", paymentId).
+		Return(pay.GetPaymentResponse{
+			State:     pay.State{Status: status},
+			PaymentId: paymentId,
+			Reference: "123456789012",
+			Amount:    8200,
 		}, nil)
 
 	return m
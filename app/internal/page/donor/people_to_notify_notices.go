@@ -0,0 +1,206 @@
+package donor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+)
+
+// A person to notify has 3 weeks from when they are sent the notice to object
+// to the LPA being registered.
+const objectionPeriod = 21 * 24 * time.Hour
+
+// SendPendingNotices sends the notices to people to notify, and the invites to
+// attorneys, for LPAs that have been submitted. An LPA whose notices cannot be
+// sent is logged and left to be tried again on the next run, until each notice
+// has been tried page.MaxPersonToNotifyNoticeAttempts times. Notices are not
+// sent for an LPA that has since been withdrawn.
+func SendPendingNotices(ctx context.Context, logger page.Logger, notifyClient page.NotifyClient, lpaStore page.LpaStore, messageStore page.MessageStore, noticeStore page.NoticeStore, shareCodeStore page.ShareCodeStore, appPublicURL string, now func() time.Time) error {
+	pending, err := noticeStore.GetAllPending(ctx)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, p := range pending {
+		if err := sendPendingNotices(ctx, p, notifyClient, lpaStore, messageStore, noticeStore, shareCodeStore, appPublicURL, now); err != nil {
+			logger.Print(fmt.Sprintf("unable to send notices for lpa %s: %s", p.LpaID, err.Error()))
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("unable to send notices for %d of %d lpas", failed, len(pending))
+	}
+
+	return nil
+}
+
+func sendPendingNotices(ctx context.Context, p page.PendingNotices, notifyClient page.NotifyClient, lpaStore page.LpaStore, messageStore page.MessageStore, noticeStore page.NoticeStore, shareCodeStore page.ShareCodeStore, appPublicURL string, now func() time.Time) error {
+	ctx = page.ContextWithSessionData(ctx, &page.SessionData{
+		LpaID:     p.LpaID,
+		ActorType: page.ActorTypeDonor,
+		Subject:   p.Sub,
+	})
+
+	lpa, err := lpaStore.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	if lpa.Status() == page.LpaStatusWithdrawn {
+		return noticeStore.DeletePending(ctx)
+	}
+
+	// The marker is written just before the submission is saved, so leave it
+	// for when the LPA is submitted.
+	if lpa.Submitted.IsZero() {
+		return nil
+	}
+
+	noticesPending, err := sendPeopleToNotifyNotices(ctx, notifyClient, lpaStore, messageStore, lpa, now())
+	if err != nil {
+		return err
+	}
+
+	invitesPending, err := sendAttorneyInvites(ctx, notifyClient, shareCodeStore, lpaStore, messageStore, appPublicURL, lpa, now())
+	if err != nil {
+		return err
+	}
+
+	if noticesPending || invitesPending {
+		return nil
+	}
+
+	return noticeStore.DeletePending(ctx)
+}
+
+// sendPeopleToNotifyNotices sends the statutory notice to each person to notify
+// that has not been sent it. Each attempt is saved on the LPA before the notice
+// is sent, and written straight away when in a unit of work, so if the result
+// could not be saved the next attempt asks Notify for the notice instead of
+// sending it twice. It returns true when a notice could not be sent but should
+// be tried again.
+func sendPeopleToNotifyNotices(ctx context.Context, notifyClient page.NotifyClient, lpaStore page.LpaStore, messageStore page.MessageStore, lpa *page.Lpa, now time.Time) (bool, error) {
+	pending := false
+
+	for _, person := range lpa.PeopleToNotify {
+		notice, _ := lpa.PersonToNotifyNotices.Get(person.ID)
+		if notice.Sent() || notice.GaveUp() {
+			continue
+		}
+
+		template, notificationType := personToNotifyNoticeTemplate(person)
+		reference := lpa.ID + "/" + template.String() + "/" + person.ID
+
+		if notice.Attempts > 0 {
+			id, err := notifyClient.FindByReference(ctx, notificationType, reference)
+			if err != nil {
+				return pending, err
+			}
+
+			if id != "" {
+				if err := recordPersonToNotifyNotice(ctx, lpaStore, messageStore, lpa, notice, template, id, notice.LastAttemptAt); err != nil {
+					return pending, err
+				}
+				continue
+			}
+		}
+
+		notice.Attempts++
+		notice.LastAttemptAt = now
+		lpa.PersonToNotifyNotices.Put(notice)
+
+		if err := lpaStore.Put(ctx, lpa); err != nil {
+			return pending, err
+		}
+
+		if err := page.CommitLpa(ctx); err != nil {
+			return pending, err
+		}
+
+		id, err := sendPersonToNotifyNotice(ctx, notifyClient, lpa, person, template, reference, now)
+		if err != nil {
+			notice.LastError = err.Error()
+			notice.Rejected = errors.Is(err, notify.ErrInvalidRecipient)
+			lpa.PersonToNotifyNotices.Put(notice)
+
+			if err := lpaStore.Put(ctx, lpa); err != nil {
+				return pending, err
+			}
+
+			if !notice.GaveUp() {
+				pending = true
+			}
+			continue
+		}
+
+		if err := recordPersonToNotifyNotice(ctx, lpaStore, messageStore, lpa, notice, template, id, now); err != nil {
+			return pending, err
+		}
+	}
+
+	return pending, nil
+}
+
+// recordPersonToNotifyNotice saves that Notify accepted the notice with id.
+func recordPersonToNotifyNotice(ctx context.Context, lpaStore page.LpaStore, messageStore page.MessageStore, lpa *page.Lpa, notice page.PersonToNotifyNotice, template notify.TemplateId, id string, sentAt time.Time) error {
+	if err := messageStore.Put(ctx, page.Message{
+		ID:            id,
+		LpaID:         lpa.ID,
+		RecipientRole: page.ActorTypePersonToNotify,
+		Template:      template.String(),
+		Status:        notify.StatusCreated,
+	}); err != nil {
+		return err
+	}
+
+	notice.MessageID = id
+	notice.Template = template.String()
+	notice.SentAt = sentAt
+	notice.LastError = ""
+	lpa.PersonToNotifyNotices.Put(notice)
+
+	return lpaStore.Put(ctx, lpa)
+}
+
+// personToNotifyNoticeTemplate gives the email template if the donor gave an
+// email address for the person, otherwise the notice is sent as a letter.
+func personToNotifyNoticeTemplate(person actor.PersonToNotify) (notify.TemplateId, string) {
+	if person.Email != "" {
+		return notify.PersonToNotifyEmail, "email"
+	}
+
+	return notify.PersonToNotifyLetter, "letter"
+}
+
+func sendPersonToNotifyNotice(ctx context.Context, notifyClient page.NotifyClient, lpa *page.Lpa, person actor.PersonToNotify, template notify.TemplateId, reference string, now time.Time) (string, error) {
+	personalisation := map[string]string{
+		"personToNotifyFullName": person.FullName(),
+		"donorFullName":          lpa.You.FullName(),
+		"attorneysFullNames":     lpa.Attorneys.FullNames(),
+		"lpaReference":           lpa.ID,
+		"objectionDeadline":      now.Add(objectionPeriod).Format("2 January 2006"),
+	}
+
+	if template == notify.PersonToNotifyEmail {
+		return notifyClient.Email(ctx, notify.Email{
+			TemplateID:      notifyClient.TemplateID(template, lpa.ContactLanguagePreference),
+			EmailAddress:    person.Email,
+			Reference:       reference,
+			Personalisation: personalisation,
+		})
+	}
+
+	return notifyClient.Letter(ctx, notify.Letter{
+		TemplateID:      notifyClient.TemplateID(template, lpa.ContactLanguagePreference),
+		Address:         person.Address.Lines(),
+		Reference:       reference,
+		Personalisation: personalisation,
+	})
+}
@@ -0,0 +1,503 @@
+package donor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/localize"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/place"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var noticeNow = time.Date(2023, time.February, 1, 12, 0, 0, 0, time.UTC)
+
+func TestSendPendingNotices(t *testing.T) {
+	ctx := context.Background()
+	lpaCtx := page.ContextWithSessionData(ctx, &page.SessionData{LpaID: "lpa-id", ActorType: page.ActorTypeDonor, Subject: "a-sub"})
+	otherCtx := page.ContextWithSessionData(ctx, &page.SessionData{LpaID: "other-id", ActorType: page.ActorTypeDonor, Subject: "other-sub"})
+
+	lpa := &page.Lpa{
+		ID:                    "lpa-id",
+		Submitted:             noticeNow,
+		PeopleToNotify:        actor.PeopleToNotify{{ID: "1", Email: "a@example.com"}},
+		PersonToNotifyNotices: page.PersonToNotifyNotices{{PersonToNotifyID: "1", Attempts: 1}},
+	}
+	otherLpa := &page.Lpa{
+		ID:             "other-id",
+		Submitted:      noticeNow,
+		PeopleToNotify: actor.PeopleToNotify{{ID: "2", Email: "b@example.com"}},
+	}
+
+	noticeStore := &mockNoticeStore{}
+	noticeStore.
+		On("GetAllPending", ctx).
+		Return([]page.PendingNotices{
+			{LpaID: "lpa-id", Sub: "a-sub"},
+			{LpaID: "other-id", Sub: "other-sub"},
+		}, nil)
+	noticeStore.
+		On("DeletePending", lpaCtx).
+		Return(nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", lpaCtx).
+		Return(lpa, nil)
+	lpaStore.
+		On("Put", lpaCtx, lpa).
+		Return(nil)
+	lpaStore.
+		On("Get", otherCtx).
+		Return(otherLpa, nil)
+	lpaStore.
+		On("Put", otherCtx, otherLpa).
+		Return(nil)
+
+	notifyClient := &mockNotifyClient{}
+	notifyClient.
+		On("TemplateID", mock.Anything, mock.Anything).
+		Return("template-id")
+	notifyClient.
+		On("FindByReference", lpaCtx, "email", "lpa-id/person-to-notify-email/1").
+		Return("", nil)
+	notifyClient.
+		On("Email", lpaCtx, mock.Anything).
+		Return("email-id", nil)
+	notifyClient.
+		On("Email", otherCtx, mock.Anything).
+		Return("", expectedError)
+
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("Put", lpaCtx, mock.Anything).
+		Return(nil)
+
+	err := SendPendingNotices(ctx, nil, notifyClient, lpaStore, messageStore, noticeStore, nil, "", func() time.Time { return noticeNow })
+
+	assert.Nil(t, err)
+	assert.Equal(t, page.PersonToNotifyNotices{
+		{PersonToNotifyID: "1", MessageID: "email-id", Template: "person-to-notify-email", SentAt: noticeNow, Attempts: 2, LastAttemptAt: noticeNow},
+	}, lpa.PersonToNotifyNotices)
+	assert.Equal(t, page.PersonToNotifyNotices{
+		{PersonToNotifyID: "2", Attempts: 1, LastAttemptAt: noticeNow, LastError: "err"},
+	}, otherLpa.PersonToNotifyNotices)
+	mock.AssertExpectationsForObjects(t, noticeStore, lpaStore, notifyClient, messageStore)
+}
+
+func TestSendPendingNoticesWhenNotSubmitted(t *testing.T) {
+	ctx := context.Background()
+	lpaCtx := page.ContextWithSessionData(ctx, &page.SessionData{LpaID: "lpa-id", ActorType: page.ActorTypeDonor, Subject: "a-sub"})
+
+	noticeStore := &mockNoticeStore{}
+	noticeStore.
+		On("GetAllPending", ctx).
+		Return([]page.PendingNotices{{LpaID: "lpa-id", Sub: "a-sub"}}, nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", lpaCtx).
+		Return(&page.Lpa{
+			ID:             "lpa-id",
+			PeopleToNotify: actor.PeopleToNotify{{ID: "1", Email: "a@example.com"}},
+		}, nil)
+
+	err := SendPendingNotices(ctx, nil, nil, lpaStore, nil, noticeStore, nil, "", time.Now)
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, noticeStore, lpaStore)
+}
+
+func TestSendPendingNoticesWhenGetAllPendingErrors(t *testing.T) {
+	noticeStore := &mockNoticeStore{}
+	noticeStore.
+		On("GetAllPending", mock.Anything).
+		Return([]page.PendingNotices{}, expectedError)
+
+	err := SendPendingNotices(context.Background(), nil, nil, nil, nil, noticeStore, nil, "", time.Now)
+
+	assert.Equal(t, expectedError, err)
+}
+
+func TestSendPendingNoticesWhenWithdrawn(t *testing.T) {
+	ctx := context.Background()
+	lpaCtx := page.ContextWithSessionData(ctx, &page.SessionData{LpaID: "lpa-id", ActorType: page.ActorTypeDonor, Subject: "a-sub"})
+
+	noticeStore := &mockNoticeStore{}
+	noticeStore.
+		On("GetAllPending", ctx).
+		Return([]page.PendingNotices{{LpaID: "lpa-id", Sub: "a-sub"}}, nil)
+	noticeStore.
+		On("DeletePending", lpaCtx).
+		Return(nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", lpaCtx).
+		Return(&page.Lpa{
+			ID:             "lpa-id",
+			WithdrawnAt:    time.Now(),
+			PeopleToNotify: actor.PeopleToNotify{{ID: "1", Email: "a@example.com"}},
+		}, nil)
+
+	err := SendPendingNotices(ctx, nil, nil, lpaStore, nil, noticeStore, nil, "", time.Now)
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, noticeStore, lpaStore)
+}
+
+func TestSendPendingNoticesWhenErrors(t *testing.T) {
+	testCases := map[string]struct {
+		lpaStore     func() *mockLpaStore
+		messageStore func() *mockMessageStore
+		noticeStore  func(*mockNoticeStore)
+	}{
+		"getting lpa": {
+			lpaStore: func() *mockLpaStore {
+				lpaStore := &mockLpaStore{}
+				lpaStore.On("Get", mock.Anything).Return(&page.Lpa{}, expectedError)
+				return lpaStore
+			},
+			messageStore: func() *mockMessageStore { return &mockMessageStore{} },
+			noticeStore:  func(*mockNoticeStore) {},
+		},
+		"recording message": {
+			lpaStore: func() *mockLpaStore {
+				lpaStore := &mockLpaStore{}
+				lpaStore.On("Get", mock.Anything).Return(&page.Lpa{Submitted: noticeNow, PeopleToNotify: actor.PeopleToNotify{{ID: "1", Email: "a@example.com"}}}, nil)
+				lpaStore.On("Put", mock.Anything, mock.Anything).Return(nil)
+				return lpaStore
+			},
+			messageStore: func() *mockMessageStore {
+				messageStore := &mockMessageStore{}
+				messageStore.On("Put", mock.Anything, mock.Anything).Return(expectedError)
+				return messageStore
+			},
+			noticeStore: func(*mockNoticeStore) {},
+		},
+		"putting lpa": {
+			lpaStore: func() *mockLpaStore {
+				lpaStore := &mockLpaStore{}
+				lpaStore.On("Get", mock.Anything).Return(&page.Lpa{Submitted: noticeNow, PeopleToNotify: actor.PeopleToNotify{{ID: "1", Email: "a@example.com"}}}, nil)
+				lpaStore.On("Put", mock.Anything, mock.Anything).Return(expectedError)
+				return lpaStore
+			},
+			messageStore: func() *mockMessageStore { return &mockMessageStore{} },
+			noticeStore:  func(*mockNoticeStore) {},
+		},
+		"deleting pending": {
+			lpaStore: func() *mockLpaStore {
+				lpaStore := &mockLpaStore{}
+				lpaStore.On("Get", mock.Anything).Return(&page.Lpa{Submitted: noticeNow}, nil)
+				return lpaStore
+			},
+			messageStore: func() *mockMessageStore { return &mockMessageStore{} },
+			noticeStore: func(noticeStore *mockNoticeStore) {
+				noticeStore.On("DeletePending", mock.Anything).Return(expectedError)
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			noticeStore := &mockNoticeStore{}
+			noticeStore.
+				On("GetAllPending", mock.Anything).
+				Return([]page.PendingNotices{{LpaID: "lpa-id", Sub: "a-sub"}}, nil)
+			tc.noticeStore(noticeStore)
+
+			notifyClient := &mockNotifyClient{}
+			notifyClient.On("TemplateID", mock.Anything, mock.Anything).Return("template-id")
+			notifyClient.On("Email", mock.Anything, mock.Anything).Return("email-id", nil)
+
+			logger := &mockLogger{}
+			logger.
+				On("Print", "unable to send notices for lpa lpa-id: err")
+
+			lpaStore := tc.lpaStore()
+			messageStore := tc.messageStore()
+
+			err := SendPendingNotices(context.Background(), logger, notifyClient, lpaStore, messageStore, noticeStore, nil, "", time.Now)
+
+			assert.Equal(t, "unable to send notices for 1 of 1 lpas", err.Error())
+			mock.AssertExpectationsForObjects(t, noticeStore, lpaStore, messageStore, logger)
+		})
+	}
+}
+
+func TestSendPeopleToNotifyNotices(t *testing.T) {
+	ctx := context.Background()
+	lpa := &page.Lpa{
+		ID:        "lpa-id",
+		You:       actor.Person{FirstNames: "John", LastName: "Smith"},
+		Attorneys: actor.Attorneys{{FirstNames: "Alan", LastName: "Jones"}, {FirstNames: "Ann", LastName: "Jones"}},
+		PeopleToNotify: actor.PeopleToNotify{
+			{ID: "1", FirstNames: "Jane", LastName: "Smith", Email: "jane@example.com"},
+			{ID: "2", FirstNames: "Joan", LastName: "Smith", Address: place.Address{Line1: "1 Road", TownOrCity: "Town", Postcode: "A1 1AA"}},
+		},
+		ContactLanguagePreference: localize.Cy,
+	}
+
+	notifyClient := &mockNotifyClient{}
+	notifyClient.
+		On("TemplateID", notify.PersonToNotifyEmail, localize.Cy).
+		Return("email-template-id")
+	notifyClient.
+		On("TemplateID", notify.PersonToNotifyLetter, localize.Cy).
+		Return("letter-template-id")
+	notifyClient.
+		On("Email", ctx, notify.Email{
+			TemplateID:   "email-template-id",
+			EmailAddress: "jane@example.com",
+			Reference:    "lpa-id/person-to-notify-email/1",
+			Personalisation: map[string]string{
+				"personToNotifyFullName": "Jane Smith",
+				"donorFullName":          "John Smith",
+				"attorneysFullNames":     "Alan Jones and Ann Jones",
+				"lpaReference":           "lpa-id",
+				"objectionDeadline":      "22 February 2023",
+			},
+		}).
+		Return("email-id", nil)
+	notifyClient.
+		On("Letter", ctx, notify.Letter{
+			TemplateID: "letter-template-id",
+			Address:    []string{"1 Road", "Town", "A1 1AA"},
+			Reference:  "lpa-id/person-to-notify-letter/2",
+			Personalisation: map[string]string{
+				"personToNotifyFullName": "Joan Smith",
+				"donorFullName":          "John Smith",
+				"attorneysFullNames":     "Alan Jones and Ann Jones",
+				"lpaReference":           "lpa-id",
+				"objectionDeadline":      "22 February 2023",
+			},
+		}).
+		Return("letter-id", nil)
+
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("Put", ctx, page.Message{
+			ID:            "email-id",
+			LpaID:         "lpa-id",
+			RecipientRole: page.ActorTypePersonToNotify,
+			Template:      "person-to-notify-email",
+			Status:        notify.StatusCreated,
+		}).
+		Return(nil)
+	messageStore.
+		On("Put", ctx, page.Message{
+			ID:            "letter-id",
+			LpaID:         "lpa-id",
+			RecipientRole: page.ActorTypePersonToNotify,
+			Template:      "person-to-notify-letter",
+			Status:        notify.StatusCreated,
+		}).
+		Return(nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Put", ctx, lpa).
+		Return(nil)
+
+	pending, err := sendPeopleToNotifyNotices(ctx, notifyClient, lpaStore, messageStore, lpa, noticeNow)
+
+	assert.Nil(t, err)
+	assert.False(t, pending)
+	assert.Equal(t, page.PersonToNotifyNotices{
+		{PersonToNotifyID: "1", MessageID: "email-id", Template: "person-to-notify-email", SentAt: noticeNow, Attempts: 1, LastAttemptAt: noticeNow},
+		{PersonToNotifyID: "2", MessageID: "letter-id", Template: "person-to-notify-letter", SentAt: noticeNow, Attempts: 1, LastAttemptAt: noticeNow},
+	}, lpa.PersonToNotifyNotices)
+	mock.AssertExpectationsForObjects(t, notifyClient, messageStore, lpaStore)
+	lpaStore.AssertNumberOfCalls(t, "Put", 4)
+}
+
+func TestSendPeopleToNotifyNoticesWhenAlreadyAccepted(t *testing.T) {
+	ctx := context.Background()
+	lastAttemptAt := noticeNow.Add(-time.Hour)
+	lpa := &page.Lpa{
+		ID:                    "lpa-id",
+		PeopleToNotify:        actor.PeopleToNotify{{ID: "1", Email: "jane@example.com"}},
+		PersonToNotifyNotices: page.PersonToNotifyNotices{{PersonToNotifyID: "1", Attempts: 1, LastAttemptAt: lastAttemptAt}},
+	}
+
+	notifyClient := &mockNotifyClient{}
+	notifyClient.
+		On("FindByReference", ctx, "email", "lpa-id/person-to-notify-email/1").
+		Return("email-id", nil)
+
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("Put", ctx, page.Message{
+			ID:            "email-id",
+			LpaID:         "lpa-id",
+			RecipientRole: page.ActorTypePersonToNotify,
+			Template:      "person-to-notify-email",
+			Status:        notify.StatusCreated,
+		}).
+		Return(nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Put", ctx, lpa).
+		Return(nil).
+		Once()
+
+	pending, err := sendPeopleToNotifyNotices(ctx, notifyClient, lpaStore, messageStore, lpa, noticeNow)
+
+	assert.Nil(t, err)
+	assert.False(t, pending)
+	assert.Equal(t, page.PersonToNotifyNotices{
+		{PersonToNotifyID: "1", MessageID: "email-id", Template: "person-to-notify-email", SentAt: lastAttemptAt, Attempts: 1, LastAttemptAt: lastAttemptAt},
+	}, lpa.PersonToNotifyNotices)
+	mock.AssertExpectationsForObjects(t, notifyClient, messageStore, lpaStore)
+}
+
+func TestSendPeopleToNotifyNoticesWhenFindByReferenceErrors(t *testing.T) {
+	ctx := context.Background()
+	lpa := &page.Lpa{
+		ID:                    "lpa-id",
+		PeopleToNotify:        actor.PeopleToNotify{{ID: "1"}},
+		PersonToNotifyNotices: page.PersonToNotifyNotices{{PersonToNotifyID: "1", Attempts: 1}},
+	}
+
+	notifyClient := &mockNotifyClient{}
+	notifyClient.
+		On("FindByReference", ctx, "letter", "lpa-id/person-to-notify-letter/1").
+		Return("", expectedError)
+
+	_, err := sendPeopleToNotifyNotices(ctx, notifyClient, nil, nil, lpa, noticeNow)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, notifyClient)
+}
+
+func TestSendPeopleToNotifyNoticesWhenLpaStoreErrors(t *testing.T) {
+	ctx := context.Background()
+	lpa := &page.Lpa{PeopleToNotify: actor.PeopleToNotify{{ID: "1"}}}
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Put", ctx, lpa).
+		Return(expectedError)
+
+	_, err := sendPeopleToNotifyNotices(ctx, nil, lpaStore, nil, lpa, noticeNow)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestSendPeopleToNotifyNoticesSkipsSentAndGivenUp(t *testing.T) {
+	notices := page.PersonToNotifyNotices{
+		{PersonToNotifyID: "1", MessageID: "email-id", Attempts: 1},
+		{PersonToNotifyID: "2", Attempts: page.MaxPersonToNotifyNoticeAttempts},
+		{PersonToNotifyID: "3", Attempts: 1, Rejected: true},
+	}
+
+	lpa := &page.Lpa{
+		PeopleToNotify:        actor.PeopleToNotify{{ID: "1"}, {ID: "2"}, {ID: "3"}},
+		PersonToNotifyNotices: append(page.PersonToNotifyNotices{}, notices...),
+	}
+
+	pending, err := sendPeopleToNotifyNotices(context.Background(), nil, nil, nil, lpa, noticeNow)
+
+	assert.Nil(t, err)
+	assert.False(t, pending)
+	assert.Equal(t, notices, lpa.PersonToNotifyNotices)
+}
+
+func TestSendPeopleToNotifyNoticesWhenSendErrors(t *testing.T) {
+	testCases := map[string]struct {
+		attempts int
+		err      error
+		notice   page.PersonToNotifyNotice
+		pending  bool
+	}{
+		"will retry": {
+			attempts: 1,
+			err:      expectedError,
+			notice:   page.PersonToNotifyNotice{PersonToNotifyID: "1", Attempts: 2, LastAttemptAt: noticeNow, LastError: "err"},
+			pending:  true,
+		},
+		"last attempt": {
+			attempts: page.MaxPersonToNotifyNoticeAttempts - 1,
+			err:      expectedError,
+			notice:   page.PersonToNotifyNotice{PersonToNotifyID: "1", Attempts: page.MaxPersonToNotifyNoticeAttempts, LastAttemptAt: noticeNow, LastError: "err"},
+			pending:  false,
+		},
+		"invalid recipient": {
+			attempts: 0,
+			err:      notify.ErrInvalidRecipient,
+			notice:   page.PersonToNotifyNotice{PersonToNotifyID: "1", Attempts: 1, LastAttemptAt: noticeNow, LastError: notify.ErrInvalidRecipient.Error(), Rejected: true},
+			pending:  false,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			lpa := &page.Lpa{
+				PeopleToNotify:        actor.PeopleToNotify{{ID: "1"}},
+				PersonToNotifyNotices: page.PersonToNotifyNotices{{PersonToNotifyID: "1", Attempts: tc.attempts}},
+			}
+
+			notifyClient := &mockNotifyClient{}
+			notifyClient.
+				On("TemplateID", mock.Anything, mock.Anything).
+				Return("template-id")
+			notifyClient.
+				On("Letter", ctx, mock.Anything).
+				Return("", tc.err)
+			if tc.attempts > 0 {
+				notifyClient.
+					On("FindByReference", ctx, "letter", "/person-to-notify-letter/1").
+					Return("", nil)
+			}
+
+			lpaStore := &mockLpaStore{}
+			lpaStore.
+				On("Put", ctx, lpa).
+				Return(nil).
+				Twice()
+
+			pending, err := sendPeopleToNotifyNotices(ctx, notifyClient, lpaStore, nil, lpa, noticeNow)
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.pending, pending)
+			assert.Equal(t, page.PersonToNotifyNotices{tc.notice}, lpa.PersonToNotifyNotices)
+			mock.AssertExpectationsForObjects(t, notifyClient, lpaStore)
+		})
+	}
+}
+
+func TestSendPeopleToNotifyNoticesWhenMessageStoreErrors(t *testing.T) {
+	ctx := context.Background()
+	lpa := &page.Lpa{PeopleToNotify: actor.PeopleToNotify{{ID: "1"}}}
+
+	notifyClient := &mockNotifyClient{}
+	notifyClient.
+		On("TemplateID", mock.Anything, mock.Anything).
+		Return("template-id")
+	notifyClient.
+		On("Letter", ctx, mock.Anything).
+		Return("letter-id", nil)
+
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("Put", ctx, mock.Anything).
+		Return(expectedError)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Put", ctx, lpa).
+		Return(nil).
+		Once()
+
+	_, err := sendPeopleToNotifyNotices(ctx, notifyClient, lpaStore, messageStore, lpa, noticeNow)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, notifyClient, messageStore, lpaStore)
+}
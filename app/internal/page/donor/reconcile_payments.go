@@ -0,0 +1,62 @@
+package donor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+)
+
+// ReconcilePayments checks the state of each in-flight payment with GOV.UK
+// Pay, so that a payment is recorded against its LPA even when the donor does
+// not return to the service after paying. A payment that cannot be checked is
+// logged and left to be tried again on the next run. Each payment is recorded
+// in a unit of work, see page.RunLpaUnitOfWork, so its event is written along
+// with the LPA.
+func ReconcilePayments(ctx context.Context, logger page.Logger, payClient page.PayClient, notifyClient page.NotifyClient, eventPublisher page.EventPublisher, lpaStore page.LpaStore, shareCodeStore page.ShareCodeStore, paymentStore page.PaymentStore, messageStore page.MessageStore, appPublicURL string, now func() time.Time) error {
+	payments, err := paymentStore.GetAllInFlight(ctx)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, inFlight := range payments {
+		if err := reconcilePayment(ctx, inFlight, payClient, notifyClient, eventPublisher, lpaStore, shareCodeStore, paymentStore, messageStore, appPublicURL, now); err != nil {
+			logger.Print(fmt.Sprintf("unable to reconcile payment %s for lpa %s: %s", inFlight.PaymentID, inFlight.LpaID, err.Error()))
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("unable to reconcile %d of %d payments", failed, len(payments))
+	}
+
+	return nil
+}
+
+func reconcilePayment(ctx context.Context, inFlight page.InFlightPayment, payClient page.PayClient, notifyClient page.NotifyClient, eventPublisher page.EventPublisher, lpaStore page.LpaStore, shareCodeStore page.ShareCodeStore, paymentStore page.PaymentStore, messageStore page.MessageStore, appPublicURL string, now func() time.Time) error {
+	ctx = page.ContextWithSessionData(ctx, &page.SessionData{
+		LpaID:     inFlight.LpaID,
+		ActorType: page.ActorTypeDonor,
+		Subject:   inFlight.Sub,
+	})
+
+	return page.RunLpaUnitOfWork(ctx, func(ctx context.Context) error {
+		lpa, err := lpaStore.Get(ctx)
+		if err != nil {
+			return err
+		}
+
+		payment, err := payClient.GetPayment(inFlight.PaymentID)
+		if err != nil {
+			return err
+		}
+
+		if payment.State.InProgress() && lpa.PaymentDetails.PaymentId == payment.PaymentId && lpa.PaymentDetails.Status == payment.State.Status {
+			return nil
+		}
+
+		return recordPayment(ctx, lpa, payment, now(), lpaStore, notifyClient, eventPublisher, shareCodeStore, paymentStore, messageStore, appPublicURL)
+	})
+}
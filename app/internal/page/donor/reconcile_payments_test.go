@@ -0,0 +1,135 @@
+package donor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/pay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// withSessionData matches a context with the given session data, as each
+// payment is reconciled in its own unit of work.
+func withSessionData(data page.SessionData) interface{} {
+	return mock.MatchedBy(func(ctx context.Context) bool {
+		actual := page.SessionDataFromContext(ctx)
+		return actual != nil && *actual == data
+	})
+}
+
+func TestReconcilePayments(t *testing.T) {
+	ctx := context.Background()
+	lpaCtx := withSessionData(page.SessionData{LpaID: "lpa-id", ActorType: page.ActorTypeDonor, Subject: "a-sub"})
+	otherCtx := withSessionData(page.SessionData{LpaID: "other-id", ActorType: page.ActorTypeDonor, Subject: "other-sub"})
+
+	paymentStore := &mockPaymentStore{}
+	paymentStore.
+		On("GetAllInFlight", ctx).
+		Return([]page.InFlightPayment{
+			{LpaID: "lpa-id", Sub: "a-sub", PaymentID: "abc123"},
+			{LpaID: "other-id", Sub: "other-sub", PaymentID: "def456"},
+		}, nil)
+	paymentStore.
+		On("DeleteInFlight", lpaCtx).
+		Return(nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", lpaCtx).
+		Return(&page.Lpa{ID: "lpa-id"}, nil)
+	lpaStore.
+		On("Put", lpaCtx, mock.MatchedBy(func(lpa *page.Lpa) bool {
+			return lpa.Tasks.PayForLpa == page.TaskInProgress && lpa.PaymentDetails.Status == pay.StatusCancelled
+		})).
+		Return(nil)
+	lpaStore.
+		On("Get", otherCtx).
+		Return(&page.Lpa{ID: "other-id", PaymentDetails: page.PaymentDetails{PaymentId: "def456", Status: pay.StatusStarted}}, nil)
+
+	payClient := &mockPayClient{}
+	payClient.
+		On("GetPayment", "abc123").
+		Return(pay.GetPaymentResponse{PaymentId: "abc123", State: pay.State{Status: pay.StatusCancelled}}, nil)
+	payClient.
+		On("GetPayment", "def456").
+		Return(pay.GetPaymentResponse{PaymentId: "def456", State: pay.State{Status: pay.StatusStarted}}, nil)
+
+	err := ReconcilePayments(ctx, nil, payClient, nil, nil, lpaStore, nil, paymentStore, nil, "http://app", mockPaymentNow)
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, paymentStore, lpaStore, payClient)
+}
+
+func TestReconcilePaymentsWhenGetAllInFlightErrors(t *testing.T) {
+	paymentStore := &mockPaymentStore{}
+	paymentStore.
+		On("GetAllInFlight", mock.Anything).
+		Return([]page.InFlightPayment{}, expectedError)
+
+	err := ReconcilePayments(context.Background(), nil, nil, nil, nil, nil, nil, paymentStore, nil, "http://app", mockPaymentNow)
+
+	assert.Equal(t, expectedError, err)
+}
+
+func TestReconcilePaymentsWhenPaymentErrors(t *testing.T) {
+	testCases := map[string]struct {
+		lpaStore  func() *mockLpaStore
+		payClient func() *mockPayClient
+		logged    string
+	}{
+		"getting lpa": {
+			lpaStore: func() *mockLpaStore {
+				lpaStore := &mockLpaStore{}
+				lpaStore.On("Get", mock.Anything).Return(&page.Lpa{}, expectedError)
+				return lpaStore
+			},
+			payClient: func() *mockPayClient { return &mockPayClient{} },
+			logged:    "unable to reconcile payment abc123 for lpa lpa-id: err",
+		},
+		"getting payment": {
+			lpaStore: func() *mockLpaStore {
+				lpaStore := &mockLpaStore{}
+				lpaStore.On("Get", mock.Anything).Return(&page.Lpa{}, nil)
+				return lpaStore
+			},
+			payClient: func() *mockPayClient {
+				payClient := &mockPayClient{}
+				payClient.On("GetPayment", "abc123").Return(pay.GetPaymentResponse{}, expectedError)
+				return payClient
+			},
+			logged: "unable to reconcile payment abc123 for lpa lpa-id: err",
+		},
+		"recording payment": {
+			lpaStore: func() *mockLpaStore {
+				lpaStore := &mockLpaStore{}
+				lpaStore.On("Get", mock.Anything).Return(&page.Lpa{}, nil)
+				lpaStore.On("Put", mock.Anything, mock.Anything).Return(expectedError)
+				return lpaStore
+			},
+			payClient: func() *mockPayClient {
+				return (&mockPayClient{}).withPayment("abc123", pay.StatusFailed)
+			},
+			logged: "unable to reconcile payment abc123 for lpa lpa-id: unable to update lpa with payment: err",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			paymentStore := &mockPaymentStore{}
+			paymentStore.
+				On("GetAllInFlight", mock.Anything).
+				Return([]page.InFlightPayment{{LpaID: "lpa-id", Sub: "a-sub", PaymentID: "abc123"}}, nil)
+
+			logger := &mockLogger{}
+			logger.
+				On("Print", tc.logged)
+
+			err := ReconcilePayments(context.Background(), logger, tc.payClient(), nil, nil, tc.lpaStore(), nil, paymentStore, nil, "http://app", mockPaymentNow)
+
+			assert.EqualError(t, err, "unable to reconcile 1 of 1 payments")
+			mock.AssertExpectationsForObjects(t, logger)
+		})
+	}
+}
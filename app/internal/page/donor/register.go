@@ -2,6 +2,7 @@ package donor
 
 import (
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -12,6 +13,7 @@ import (
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/identity"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/random"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/reference"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/sesh"
 )
 
@@ -28,7 +30,12 @@ func Register(
 	yotiClient page.YotiClient,
 	yotiScenarioID string,
 	notifyClient page.NotifyClient,
-	dataStore page.DataStore,
+	shareCodeStore page.ShareCodeStore,
+	paymentStore page.PaymentStore,
+	messageStore page.MessageStore,
+	noticeStore page.NoticeStore,
+	eventPublisher page.EventPublisher,
+	registrationStore page.RegistrationStore,
 ) {
 	handleRoot := makeHandle(rootMux, logger, sessionStore, None)
 
@@ -111,9 +118,19 @@ func Register(
 		CheckYourLpa(tmpls.Get("check_your_lpa.gohtml"), lpaStore))
 
 	handleLpa(page.Paths.AboutPayment, CanGoBack,
-		AboutPayment(logger, tmpls.Get("about_payment.gohtml"), sessionStore, payClient, appPublicUrl, random.String, lpaStore))
+		AboutPayment(logger, tmpls.Get("about_payment.gohtml"), sessionStore, payClient, appPublicUrl, random.String, lpaStore, paymentStore))
 	handleLpa(page.Paths.PaymentConfirmation, CanGoBack,
-		PaymentConfirmation(logger, tmpls.Get("payment_confirmation.gohtml"), payClient, notifyClient, lpaStore, sessionStore, appPublicUrl, dataStore, random.String))
+		PaymentConfirmation(logger, tmpls.Get("payment_confirmation.gohtml"), payClient, notifyClient, eventPublisher, lpaStore, sessionStore, appPublicUrl, shareCodeStore, paymentStore, messageStore, time.Now))
+	handleLpa(page.Paths.WhichFeeTypeAreYouApplyingFor, CanGoBack,
+		WhichFeeTypeAreYouApplyingFor(tmpls.Get("which_fee_type_are_you_applying_for.gohtml"), lpaStore))
+	handleLpa(page.Paths.EvidenceRequired, CanGoBack,
+		EvidenceRequired(tmpls.Get("evidence_required.gohtml"), lpaStore))
+	handleLpa(page.Paths.PendingEvidenceReview, None,
+		page.Guidance(tmpls.Get("pending_evidence_review.gohtml"), page.Paths.TaskList, lpaStore))
+	handleLpa(page.Paths.PaymentPending, None,
+		page.Guidance(tmpls.Get("payment_pending.gohtml"), page.Paths.PaymentConfirmation, lpaStore))
+	handleLpa(page.Paths.PaymentFailed, None,
+		page.Guidance(tmpls.Get("payment_failed.gohtml"), page.Paths.AboutPayment, lpaStore))
 
 	handleLpa(page.Paths.HowToConfirmYourIdentityAndSign, CanGoBack,
 		page.Guidance(tmpls.Get("how_to_confirm_your_identity_and_sign.gohtml"), page.Paths.WhatYoullNeedToConfirmYourIdentity, lpaStore))
@@ -156,16 +173,22 @@ func Register(
 	handleLpa(page.Paths.YourLegalRightsAndResponsibilities, CanGoBack,
 		page.Guidance(tmpls.Get("your_legal_rights_and_responsibilities.gohtml"), page.Paths.SignYourLpa, lpaStore))
 	handleLpa(page.Paths.SignYourLpa, CanGoBack,
-		SignYourLpa(tmpls.Get("sign_your_lpa.gohtml"), lpaStore))
+		SignYourLpa(tmpls.Get("sign_your_lpa.gohtml"), lpaStore, eventPublisher))
 	handleLpa(page.Paths.WitnessingYourSignature, CanGoBack,
-		WitnessingYourSignature(tmpls.Get("witnessing_your_signature.gohtml"), lpaStore, notifyClient, random.Code, time.Now))
+		WitnessingYourSignature(tmpls.Get("witnessing_your_signature.gohtml"), lpaStore, notifyClient, messageStore, random.Code, time.Now))
 	handleLpa(page.Paths.WitnessingAsCertificateProvider, CanGoBack,
-		WitnessingAsCertificateProvider(tmpls.Get("witnessing_as_certificate_provider.gohtml"), lpaStore, time.Now))
+		WitnessingAsCertificateProvider(logger, tmpls.Get("witnessing_as_certificate_provider.gohtml"), lpaStore, noticeStore, eventPublisher, registrationStore, notifyClient, messageStore, shareCodeStore, appPublicUrl, time.Now))
 	handleLpa(page.Paths.YouHaveSubmittedYourLpa, CanGoBack,
 		page.Guidance(tmpls.Get("you_have_submitted_your_lpa.gohtml"), page.Paths.TaskList, lpaStore))
 
 	handleLpa(page.Paths.Progress, CanGoBack,
-		page.Guidance(tmpls.Get("lpa_progress.gohtml"), page.Paths.Dashboard, lpaStore))
+		LpaProgress(tmpls.Get("lpa_progress.gohtml"), lpaStore, messageStore))
+	handleLpa(page.Paths.LpaHistory, CanGoBack,
+		page.LpaHistory(tmpls.Get("lpa_history.gohtml"), lpaStore))
+	handleLpa(page.Paths.ResendCertificateProviderInvite, CanGoBack,
+		ResendCertificateProviderInvite(tmpls.Get("resend_certificate_provider_invite.gohtml"), lpaStore, shareCodeStore, notifyClient, messageStore, appPublicUrl))
+	handleLpa(page.Paths.DeleteLpa, CanGoBack,
+		DeleteLpa(tmpls.Get("delete_lpa.gohtml"), lpaStore, shareCodeStore, time.Now))
 }
 
 type handleOpt byte
@@ -179,6 +202,7 @@ const (
 func makeHandle(mux *http.ServeMux, logger page.Logger, store sesh.Store, defaultOptions handleOpt) func(string, handleOpt, page.Handler) {
 	return func(path string, opt handleOpt, h page.Handler) {
 		opt = opt | defaultOptions
+		h = page.WithLpaUnitOfWork(h)
 
 		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
@@ -199,16 +223,22 @@ func makeHandle(mux *http.ServeMux, logger page.Logger, store sesh.Store, defaul
 
 				data := page.SessionDataFromContext(ctx)
 				if data != nil {
-					data.SessionID = appData.SessionID
+					data.ActorType = page.ActorTypeDonor
+					data.Subject = session.Sub
 					ctx = page.ContextWithSessionData(ctx, data)
 
 					appData.LpaID = data.LpaID
 				} else {
-					ctx = page.ContextWithSessionData(ctx, &page.SessionData{SessionID: appData.SessionID})
+					ctx = page.ContextWithSessionData(ctx, &page.SessionData{ActorType: page.ActorTypeDonor, Subject: session.Sub})
 				}
 			}
 
 			if err := h(appData, w, r.WithContext(page.ContextWithAppData(ctx, appData))); err != nil {
+				if errors.Is(err, page.ErrLpaWithdrawn) {
+					http.Redirect(w, r, appData.BuildUrl(path)+"?lpaWithdrawn=1", http.StatusFound)
+					return
+				}
+
 				str := fmt.Sprintf("Error rendering page for path '%s': %s", path, err.Error())
 
 				logger.Print(str)
@@ -229,6 +259,10 @@ func routeToLpa(mux http.Handler) http.HandlerFunc {
 		}
 
 		id, path := parts[2], "/"+parts[3]
+		if !reference.Valid(id) && !reference.ValidLegacy(id) {
+			http.NotFound(w, r)
+			return
+		}
 
 		r2 := new(http.Request)
 		*r2 = *r
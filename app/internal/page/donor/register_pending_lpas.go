@@ -0,0 +1,80 @@
+package donor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+)
+
+// A pending marker is written just before the submission is saved. If the LPA
+// has still not been submitted this long after, saving it failed and the marker
+// is removed; submitting again writes a new one.
+const abandonedPendingMarkerAge = 24 * time.Hour
+
+// RegisterPendingLpas sends submitted LPAs to the LPA store to be registered.
+// An LPA that cannot be sent is logged and left to be tried again on the next
+// run; the LPA's ID is the idempotency key, so trying again does not register
+// it twice. LPAs that have since been withdrawn are not sent.
+func RegisterPendingLpas(ctx context.Context, logger page.Logger, registrationClient page.RegistrationClient, lpaStore page.LpaStore, registrationStore page.RegistrationStore, now func() time.Time) error {
+	pending, err := registrationStore.GetAllPending(ctx)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, p := range pending {
+		if err := registerPendingLpa(ctx, p, registrationClient, lpaStore, registrationStore, now); err != nil {
+			logger.Print(fmt.Sprintf("unable to register lpa %s: %s", p.LpaID, err.Error()))
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("unable to register %d of %d lpas", failed, len(pending))
+	}
+
+	return nil
+}
+
+func registerPendingLpa(ctx context.Context, p page.PendingRegistration, registrationClient page.RegistrationClient, lpaStore page.LpaStore, registrationStore page.RegistrationStore, now func() time.Time) error {
+	ctx = page.ContextWithSessionData(ctx, &page.SessionData{
+		LpaID:     p.LpaID,
+		ActorType: page.ActorTypeDonor,
+		Subject:   p.Sub,
+	})
+
+	lpa, err := lpaStore.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	if lpa.Status() == page.LpaStatusWithdrawn || lpa.Registration.Reference != "" {
+		return registrationStore.DeletePending(ctx)
+	}
+
+	// The marker is written just before the submission is saved, so leave it
+	// for when the LPA is submitted, unless that has been abandoned.
+	if lpa.Submitted.IsZero() {
+		if now().Sub(p.Created) > abandonedPendingMarkerAge {
+			return registrationStore.DeletePending(ctx)
+		}
+
+		return nil
+	}
+
+	registration, err := registrationClient.Register(ctx, lpa)
+	if err != nil {
+		return err
+	}
+
+	registration.SubmittedAt = lpa.Submitted
+	lpa.Registration = registration
+
+	if err := lpaStore.Put(ctx, lpa); err != nil {
+		return err
+	}
+
+	return registrationStore.DeletePending(ctx)
+}
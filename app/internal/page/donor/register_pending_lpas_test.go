@@ -0,0 +1,209 @@
+package donor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRegisterPendingLpas(t *testing.T) {
+	ctx := context.Background()
+	lpaCtx := page.ContextWithSessionData(ctx, &page.SessionData{LpaID: "lpa-id", ActorType: page.ActorTypeDonor, Subject: "a-sub"})
+	submitted := time.Date(2023, time.February, 1, 12, 0, 0, 0, time.UTC)
+
+	registrationStore := &mockRegistrationStore{}
+	registrationStore.
+		On("GetAllPending", ctx).
+		Return([]page.PendingRegistration{{LpaID: "lpa-id", Sub: "a-sub"}}, nil)
+	registrationStore.
+		On("DeletePending", lpaCtx).
+		Return(nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", lpaCtx).
+		Return(&page.Lpa{ID: "lpa-id", Submitted: submitted}, nil)
+	lpaStore.
+		On("Put", lpaCtx, &page.Lpa{
+			ID:           "lpa-id",
+			Submitted:    submitted,
+			Registration: page.Registration{Reference: "M-1234", Status: "received", SubmittedAt: submitted},
+		}).
+		Return(nil)
+
+	registrationClient := &mockRegistrationClient{}
+	registrationClient.
+		On("Register", lpaCtx, mock.Anything).
+		Return(page.Registration{Reference: "M-1234", Status: "received"}, nil)
+
+	err := RegisterPendingLpas(ctx, nil, registrationClient, lpaStore, registrationStore, time.Now)
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, registrationStore, lpaStore, registrationClient)
+}
+
+func TestRegisterPendingLpasWhenNothingToSend(t *testing.T) {
+	testCases := map[string]*page.Lpa{
+		"withdrawn":  {ID: "lpa-id", Submitted: time.Now(), WithdrawnAt: time.Now()},
+		"registered": {ID: "lpa-id", Submitted: time.Now(), Registration: page.Registration{Reference: "M-1234"}},
+	}
+
+	for name, lpa := range testCases {
+		t.Run(name, func(t *testing.T) {
+			registrationStore := &mockRegistrationStore{}
+			registrationStore.
+				On("GetAllPending", mock.Anything).
+				Return([]page.PendingRegistration{{LpaID: "lpa-id", Sub: "a-sub"}}, nil)
+			registrationStore.
+				On("DeletePending", mock.Anything).
+				Return(nil)
+
+			lpaStore := &mockLpaStore{}
+			lpaStore.
+				On("Get", mock.Anything).
+				Return(lpa, nil)
+
+			err := RegisterPendingLpas(context.Background(), nil, nil, lpaStore, registrationStore, time.Now)
+
+			assert.Nil(t, err)
+			mock.AssertExpectationsForObjects(t, registrationStore, lpaStore)
+		})
+	}
+}
+
+func TestRegisterPendingLpasWhenNotSubmitted(t *testing.T) {
+	registrationStore := &mockRegistrationStore{}
+	registrationStore.
+		On("GetAllPending", mock.Anything).
+		Return([]page.PendingRegistration{{LpaID: "lpa-id", Sub: "a-sub", Created: time.Now().Add(-time.Hour)}}, nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&page.Lpa{ID: "lpa-id"}, nil)
+
+	err := RegisterPendingLpas(context.Background(), nil, nil, lpaStore, registrationStore, time.Now)
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, registrationStore, lpaStore)
+}
+
+func TestRegisterPendingLpasWhenNotSubmittedAndAbandoned(t *testing.T) {
+	now := time.Date(2023, time.February, 2, 12, 0, 0, 0, time.UTC)
+
+	registrationStore := &mockRegistrationStore{}
+	registrationStore.
+		On("GetAllPending", mock.Anything).
+		Return([]page.PendingRegistration{{LpaID: "lpa-id", Sub: "a-sub", Created: now.Add(-25 * time.Hour)}}, nil)
+	registrationStore.
+		On("DeletePending", mock.Anything).
+		Return(nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&page.Lpa{ID: "lpa-id"}, nil)
+
+	err := RegisterPendingLpas(context.Background(), nil, nil, lpaStore, registrationStore, func() time.Time { return now })
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, registrationStore, lpaStore)
+}
+
+func TestRegisterPendingLpasWhenGetAllPendingErrors(t *testing.T) {
+	registrationStore := &mockRegistrationStore{}
+	registrationStore.
+		On("GetAllPending", mock.Anything).
+		Return([]page.PendingRegistration{}, expectedError)
+
+	err := RegisterPendingLpas(context.Background(), nil, nil, nil, registrationStore, time.Now)
+
+	assert.Equal(t, expectedError, err)
+}
+
+func TestRegisterPendingLpasWhenErrors(t *testing.T) {
+	testCases := map[string]struct {
+		lpaStore           func() *mockLpaStore
+		registrationClient func() *mockRegistrationClient
+		registrationStore  func(*mockRegistrationStore)
+	}{
+		"getting lpa": {
+			lpaStore: func() *mockLpaStore {
+				lpaStore := &mockLpaStore{}
+				lpaStore.On("Get", mock.Anything).Return(&page.Lpa{}, expectedError)
+				return lpaStore
+			},
+			registrationClient: func() *mockRegistrationClient { return &mockRegistrationClient{} },
+			registrationStore:  func(*mockRegistrationStore) {},
+		},
+		"registering": {
+			lpaStore: func() *mockLpaStore {
+				lpaStore := &mockLpaStore{}
+				lpaStore.On("Get", mock.Anything).Return(&page.Lpa{Submitted: time.Now()}, nil)
+				return lpaStore
+			},
+			registrationClient: func() *mockRegistrationClient {
+				registrationClient := &mockRegistrationClient{}
+				registrationClient.On("Register", mock.Anything, mock.Anything).Return(page.Registration{}, expectedError)
+				return registrationClient
+			},
+			registrationStore: func(*mockRegistrationStore) {},
+		},
+		"putting lpa": {
+			lpaStore: func() *mockLpaStore {
+				lpaStore := &mockLpaStore{}
+				lpaStore.On("Get", mock.Anything).Return(&page.Lpa{Submitted: time.Now()}, nil)
+				lpaStore.On("Put", mock.Anything, mock.Anything).Return(expectedError)
+				return lpaStore
+			},
+			registrationClient: func() *mockRegistrationClient {
+				registrationClient := &mockRegistrationClient{}
+				registrationClient.On("Register", mock.Anything, mock.Anything).Return(page.Registration{Reference: "M-1234"}, nil)
+				return registrationClient
+			},
+			registrationStore: func(*mockRegistrationStore) {},
+		},
+		"deleting pending": {
+			lpaStore: func() *mockLpaStore {
+				lpaStore := &mockLpaStore{}
+				lpaStore.On("Get", mock.Anything).Return(&page.Lpa{Submitted: time.Now()}, nil)
+				lpaStore.On("Put", mock.Anything, mock.Anything).Return(nil)
+				return lpaStore
+			},
+			registrationClient: func() *mockRegistrationClient {
+				registrationClient := &mockRegistrationClient{}
+				registrationClient.On("Register", mock.Anything, mock.Anything).Return(page.Registration{Reference: "M-1234"}, nil)
+				return registrationClient
+			},
+			registrationStore: func(registrationStore *mockRegistrationStore) {
+				registrationStore.On("DeletePending", mock.Anything).Return(expectedError)
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			registrationStore := &mockRegistrationStore{}
+			registrationStore.
+				On("GetAllPending", mock.Anything).
+				Return([]page.PendingRegistration{{LpaID: "lpa-id", Sub: "a-sub"}}, nil)
+			tc.registrationStore(registrationStore)
+
+			logger := &mockLogger{}
+			logger.
+				On("Print", "unable to register lpa lpa-id: err")
+
+			lpaStore := tc.lpaStore()
+			registrationClient := tc.registrationClient()
+
+			err := RegisterPendingLpas(context.Background(), logger, registrationClient, lpaStore, registrationStore, time.Now)
+
+			assert.Equal(t, "unable to register 1 of 1 lpas", err.Error())
+			mock.AssertExpectationsForObjects(t, registrationStore, lpaStore, registrationClient, logger)
+		})
+	}
+}
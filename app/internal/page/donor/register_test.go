@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/gorilla/sessions"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/dynamo"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/sesh"
 	"github.com/stretchr/testify/assert"
@@ -31,8 +32,7 @@ func TestMakeHandle(t *testing.T) {
 			CanGoBack: true,
 			SessionID: "cmFuZG9t",
 		}, appData)
-		assert.Equal(t, w, hw)
-		assert.Equal(t, &page.SessionData{SessionID: "cmFuZG9t"}, page.SessionDataFromContext(hr.Context()))
+		assert.Equal(t, &page.SessionData{ActorType: page.ActorTypeDonor, Subject: "random"}, page.SessionDataFromContext(hr.Context()))
 		hw.WriteHeader(http.StatusTeapot)
 		return nil
 	})
@@ -63,8 +63,7 @@ func TestMakeHandleExistingSessionData(t *testing.T) {
 			CanGoBack: true,
 			LpaID:     "123",
 		}, appData)
-		assert.Equal(t, w, hw)
-		assert.Equal(t, &page.SessionData{LpaID: "123", SessionID: "cmFuZG9t"}, page.SessionDataFromContext(hr.Context()))
+		assert.Equal(t, &page.SessionData{LpaID: "123", ActorType: page.ActorTypeDonor, Subject: "random"}, page.SessionDataFromContext(hr.Context()))
 		hw.WriteHeader(http.StatusTeapot)
 		return nil
 	})
@@ -102,6 +101,57 @@ func TestMakeHandleErrors(t *testing.T) {
 	mock.AssertExpectationsForObjects(t, sessionsStore)
 }
 
+func TestMakeHandleWhenConflict(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequestWithContext(page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "lpa-id"}), http.MethodPost, page.Paths.YourDetails, nil)
+
+	sessionsStore := &mockSessionsStore{}
+	sessionsStore.
+		On("Get", r, "session").
+		Return(&sessions.Session{Values: map[interface{}]interface{}{"donor": &sesh.DonorSession{Sub: "random"}}}, nil)
+
+	mux := http.NewServeMux()
+	handle := makeHandle(mux, nil, sessionsStore, None)
+	handle(page.Paths.YourDetails, RequireSession, func(appData page.AppData, hw http.ResponseWriter, hr *http.Request) error {
+		if hr.Method == http.MethodPost {
+			return dynamo.ConflictError{PK: "a", SK: "b"}
+		}
+
+		assert.True(t, appData.LpaChanged)
+		hw.Write([]byte("changed"))
+		return nil
+	})
+
+	mux.ServeHTTP(w, r)
+	resp := w.Result()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "changed", w.Body.String())
+	mock.AssertExpectationsForObjects(t, sessionsStore)
+}
+
+func TestMakeHandleWhenWithdrawn(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequestWithContext(page.ContextWithSessionData(context.Background(), &page.SessionData{LpaID: "lpa-id"}), http.MethodPost, page.Paths.YourDetails, nil)
+
+	sessionsStore := &mockSessionsStore{}
+	sessionsStore.
+		On("Get", r, "session").
+		Return(&sessions.Session{Values: map[interface{}]interface{}{"donor": &sesh.DonorSession{Sub: "random"}}}, nil)
+
+	mux := http.NewServeMux()
+	handle := makeHandle(mux, nil, sessionsStore, None)
+	handle(page.Paths.YourDetails, RequireSession, func(appData page.AppData, hw http.ResponseWriter, hr *http.Request) error {
+		return fmt.Errorf("unable to update lpa: %w", page.ErrLpaWithdrawn)
+	})
+
+	mux.ServeHTTP(w, r)
+	resp := w.Result()
+
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/lpa/lpa-id/your-details?lpaWithdrawn=1", resp.Header.Get("Location"))
+}
+
 func TestMakeHandleSessionError(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodGet, "/path", nil)
@@ -162,8 +212,7 @@ func TestMakeHandleNoSessionRequired(t *testing.T) {
 		assert.Equal(t, page.AppData{
 			Page: "/path",
 		}, appData)
-		assert.Equal(t, w, hw)
-		assert.Equal(t, r.WithContext(page.ContextWithAppData(r.Context(), page.AppData{Page: "/path"})), hr)
+		assert.Equal(t, page.AppData{Page: "/path"}, page.AppDataFromContext(hr.Context()))
 		hw.WriteHeader(http.StatusTeapot)
 		return nil
 	})
@@ -175,19 +224,41 @@ func TestMakeHandleNoSessionRequired(t *testing.T) {
 }
 
 func TestRouteToLpa(t *testing.T) {
-	w := httptest.NewRecorder()
-	r, _ := http.NewRequest(http.MethodGet, "/lpa/123/somewhere%2Fwhat", nil)
+	for _, id := range []string{"M-0000-0000-001X", "1012345"} {
+		t.Run(id, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodGet, "/lpa/"+id+"/somewhere%2Fwhat", nil)
 
-	routeToLpa(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		assert.Equal(t, "/somewhere/what", r.URL.Path)
-		assert.Equal(t, "/somewhere%2Fwhat", r.URL.RawPath)
+			routeToLpa(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/somewhere/what", r.URL.Path)
+				assert.Equal(t, "/somewhere%2Fwhat", r.URL.RawPath)
+				assert.Equal(t, id, page.SessionDataFromContext(r.Context()).LpaID)
 
-		w.WriteHeader(http.StatusTeapot)
-	})).ServeHTTP(w, r)
+				w.WriteHeader(http.StatusTeapot)
+			})).ServeHTTP(w, r)
 
-	res := w.Result()
+			res := w.Result()
+
+			assert.Equal(t, http.StatusTeapot, res.StatusCode)
+		})
+	}
+}
+
+func TestRouteToLpaWithInvalidID(t *testing.T) {
+	for _, id := range []string{"M-0000-0000-0010", "123", "abc"} {
+		t.Run(id, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodGet, "/lpa/"+id+"/somewhere", nil)
+
+			routeToLpa(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+			})).ServeHTTP(w, r)
+
+			res := w.Result()
 
-	assert.Equal(t, http.StatusTeapot, res.StatusCode)
+			assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		})
+	}
 }
 
 func TestRouteToLpaWithoutID(t *testing.T) {
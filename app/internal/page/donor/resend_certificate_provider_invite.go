@@ -0,0 +1,109 @@
+package donor
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+
+	"github.com/ministryofjustice/opg-go-common/template"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/validation"
+)
+
+type resendCertificateProviderInviteData struct {
+	App    page.AppData
+	Errors validation.List
+	Lpa    *page.Lpa
+	Sent   bool
+}
+
+// ResendCertificateProviderInvite sends the certificate provider a new invite,
+// then revokes the share codes sent to them before. The old codes are only
+// revoked once the new one has been sent, so a failed send leaves the
+// certificate provider with a code that works.
+func ResendCertificateProviderInvite(tmpl template.Template, lpaStore page.LpaStore, shareCodeStore page.ShareCodeStore, notifyClient page.NotifyClient, messageStore page.MessageStore, appPublicURL string) page.Handler {
+	return func(appData page.AppData, w http.ResponseWriter, r *http.Request) error {
+		lpa, err := lpaStore.Get(r.Context())
+		if err != nil {
+			return err
+		}
+
+		if lpa.Tasks.PayForLpa != page.TaskCompleted {
+			return appData.Redirect(w, r, lpa, page.Paths.Progress)
+		}
+
+		data := &resendCertificateProviderInviteData{
+			App: appData,
+			Lpa: lpa,
+		}
+
+		if r.Method == http.MethodPost {
+			shareCode, err := sendCertificateProviderInvite(r.Context(), notifyClient, shareCodeStore, messageStore, appPublicURL, lpa)
+			if err != nil {
+				notifyErr, ok := notifyError(err, "certificateProviderEmailNotAccepted")
+				if !ok {
+					return err
+				}
+
+				data.Errors.Add("resend", notifyErr)
+				return tmpl(w, data)
+			}
+
+			if err := shareCodeStore.Revoke(r.Context(), lpa.ID, page.ActorTypeCertificateProvider, "", shareCode); err != nil {
+				return err
+			}
+
+			data.Sent = true
+		}
+
+		return tmpl(w, data)
+	}
+}
+
+// sendCertificateProviderInvite emails the certificate provider a new share
+// code, recording the message so that the donor can see whether it arrived. The
+// share code sent is returned.
+func sendCertificateProviderInvite(ctx context.Context, notifyClient page.NotifyClient, shareCodeStore page.ShareCodeStore, messageStore page.MessageStore, appPublicURL string, lpa *page.Lpa) (string, error) {
+	shareCode, err := shareCodeStore.Create(ctx, page.ShareCodeData{
+		LpaID:     lpa.ID,
+		ActorType: page.ActorTypeCertificateProvider,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	emailID, err := notifyClient.Email(ctx, notify.Email{
+		TemplateID:   notifyClient.TemplateID(notify.CertificateProviderInviteEmail, lpa.ContactLanguagePreference),
+		EmailAddress: lpa.CertificateProvider.Email,
+		Reference:    shareCodeReference(lpa.ID, notify.CertificateProviderInviteEmail, shareCode),
+		Personalisation: map[string]string{
+			"link": fmt.Sprintf("%s%s?share-code=%s", appPublicURL, page.Paths.CertificateProviderStart, shareCode),
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err := messageStore.Put(ctx, page.Message{
+		ID:            emailID,
+		LpaID:         lpa.ID,
+		RecipientRole: page.ActorTypeCertificateProvider,
+		Template:      notify.CertificateProviderInviteEmail.String(),
+		Status:        notify.StatusCreated,
+	}); err != nil {
+		return "", err
+	}
+
+	return shareCode, nil
+}
+
+// shareCodeReference is the Notify reference for a message sending shareCode.
+// It differs for each code, so that Notify is never found to have sent an
+// earlier message, whose code may have been revoked, in place of this one. A
+// hash of the code is used, as references are not kept secret.
+func shareCodeReference(lpaID string, template notify.TemplateId, shareCode string) string {
+	sum := sha256.Sum256([]byte(shareCode))
+	return fmt.Sprintf("%s/%s/%x", lpaID, template, sum[:4])
+}
@@ -0,0 +1,340 @@
+package donor
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/localize"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetResendCertificateProviderInvite(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpa := &page.Lpa{ID: "lpa-id", Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(lpa, nil)
+
+	template := &mockTemplate{}
+	template.
+		On("Func", w, &resendCertificateProviderInviteData{
+			App: appData,
+			Lpa: lpa,
+		}).
+		Return(nil)
+
+	err := ResendCertificateProviderInvite(template.Func, lpaStore, nil, nil, nil, "http://app")(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	mock.AssertExpectationsForObjects(t, lpaStore, template)
+}
+
+func TestGetResendCertificateProviderInviteWhenNotPaid(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{ID: "lpa-id"}, nil)
+
+	err := ResendCertificateProviderInvite(nil, lpaStore, nil, nil, nil, "http://app")(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/lpa/lpa-id"+page.Paths.Progress, resp.Header.Get("Location"))
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestGetResendCertificateProviderInviteWhenLpaStoreErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{}, expectedError)
+
+	err := ResendCertificateProviderInvite(nil, lpaStore, nil, nil, nil, "http://app")(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestPostResendCertificateProviderInvite(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", nil)
+
+	lpa := &page.Lpa{
+		ID:                  "lpa-id",
+		CertificateProvider: actor.CertificateProvider{Email: "certificateprovider@example.com"},
+		Tasks:               page.Tasks{PayForLpa: page.TaskCompleted},
+	}
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(lpa, nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Create", r.Context(), page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeCertificateProvider}).
+		Return("123", nil)
+	shareCodeStore.
+		On("Revoke", r.Context(), "lpa-id", page.ActorTypeCertificateProvider, "", "123").
+		Return(nil)
+
+	notifyClient := &mockNotifyClient{}
+	notifyClient.
+		On("TemplateID", notify.CertificateProviderInviteEmail, localize.En).
+		Return("template-id")
+	notifyClient.
+		On("Email", r.Context(), notify.Email{
+			TemplateID:   "template-id",
+			EmailAddress: "certificateprovider@example.com",
+			Reference:    "lpa-id/certificate-provider-invite-email/a665a459",
+			Personalisation: map[string]string{
+				"link": fmt.Sprintf("http://app%s?share-code=123", page.Paths.CertificateProviderStart),
+			},
+		}).
+		Return("email-id", nil)
+
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("Put", r.Context(), page.Message{
+			ID:            "email-id",
+			LpaID:         "lpa-id",
+			RecipientRole: page.ActorTypeCertificateProvider,
+			Template:      notify.CertificateProviderInviteEmail.String(),
+			Status:        notify.StatusCreated,
+		}).
+		Return(nil)
+
+	template := &mockTemplate{}
+	template.
+		On("Func", w, &resendCertificateProviderInviteData{
+			App:  appData,
+			Lpa:  lpa,
+			Sent: true,
+		}).
+		Return(nil)
+
+	err := ResendCertificateProviderInvite(template.Func, lpaStore, shareCodeStore, notifyClient, messageStore, "http://app")(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	mock.AssertExpectationsForObjects(t, lpaStore, shareCodeStore, notifyClient, template)
+}
+
+func TestPostResendCertificateProviderInviteWhenCreateErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{ID: "lpa-id", Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}, nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Create", r.Context(), mock.Anything).
+		Return("", expectedError)
+
+	err := ResendCertificateProviderInvite(nil, lpaStore, shareCodeStore, nil, nil, "http://app")(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore, shareCodeStore)
+}
+
+func TestPostResendCertificateProviderInviteWhenEmailErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{ID: "lpa-id", Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}, nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Create", r.Context(), mock.Anything).
+		Return("123", nil)
+
+	notifyClient := &mockNotifyClient{}
+	notifyClient.
+		On("TemplateID", mock.Anything, mock.Anything).
+		Return("template-id")
+	notifyClient.
+		On("Email", r.Context(), mock.Anything).
+		Return("", expectedError)
+
+	err := ResendCertificateProviderInvite(nil, lpaStore, shareCodeStore, notifyClient, nil, "http://app")(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore, shareCodeStore, notifyClient)
+}
+
+func TestPostResendCertificateProviderInviteWhenNotifyRejects(t *testing.T) {
+	testCases := map[string]struct {
+		err      error
+		expected validation.List
+	}{
+		"invalid recipient": {
+			err:      notify.ErrInvalidRecipient,
+			expected: validation.With("resend", validation.CustomError{Label: "certificateProviderEmailNotAccepted"}),
+		},
+		"rate limited": {
+			err:      notify.ErrRateLimited,
+			expected: validation.With("resend", validation.CustomError{Label: "messageCouldNotBeSentTryAgainLater"}),
+		},
+		"service down": {
+			err:      notify.ErrServiceDown,
+			expected: validation.With("resend", validation.CustomError{Label: "messageCouldNotBeSentTryAgainLater"}),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodPost, "/", nil)
+
+			lpa := &page.Lpa{ID: "lpa-id", Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}
+
+			lpaStore := &mockLpaStore{}
+			lpaStore.
+				On("Get", r.Context()).
+				Return(lpa, nil)
+
+			shareCodeStore := &mockShareCodeStore{}
+			shareCodeStore.
+				On("Create", r.Context(), mock.Anything).
+				Return("123", nil)
+
+			notifyClient := &mockNotifyClient{}
+			notifyClient.
+				On("TemplateID", mock.Anything, mock.Anything).
+				Return("template-id")
+			notifyClient.
+				On("Email", r.Context(), mock.Anything).
+				Return("", fmt.Errorf("err: %w", tc.err))
+
+			template := &mockTemplate{}
+			template.
+				On("Func", w, &resendCertificateProviderInviteData{
+					App:    appData,
+					Errors: tc.expected,
+					Lpa:    lpa,
+				}).
+				Return(nil)
+
+			err := ResendCertificateProviderInvite(template.Func, lpaStore, shareCodeStore, notifyClient, nil, "http://app")(appData, w, r)
+
+			assert.Nil(t, err)
+			mock.AssertExpectationsForObjects(t, lpaStore, shareCodeStore, notifyClient, template)
+		})
+	}
+}
+
+func TestPostResendCertificateProviderInviteWhenMessageStoreErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{ID: "lpa-id", Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}, nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Create", r.Context(), mock.Anything).
+		Return("123", nil)
+
+	notifyClient := &mockNotifyClient{}
+	notifyClient.
+		On("TemplateID", mock.Anything, mock.Anything).
+		Return("template-id")
+	notifyClient.
+		On("Email", r.Context(), mock.Anything).
+		Return("email-id", nil)
+
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(expectedError)
+
+	err := ResendCertificateProviderInvite(nil, lpaStore, shareCodeStore, notifyClient, messageStore, "http://app")(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore, shareCodeStore, notifyClient, messageStore)
+}
+
+func TestPostResendCertificateProviderInviteWhenRevokeErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{ID: "lpa-id", Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}, nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Create", r.Context(), mock.Anything).
+		Return("123", nil)
+	shareCodeStore.
+		On("Revoke", r.Context(), "lpa-id", page.ActorTypeCertificateProvider, "", "123").
+		Return(expectedError)
+
+	notifyClient := &mockNotifyClient{}
+	notifyClient.
+		On("TemplateID", mock.Anything, mock.Anything).
+		Return("template-id")
+	notifyClient.
+		On("Email", r.Context(), mock.Anything).
+		Return("email-id", nil)
+
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(nil)
+
+	err := ResendCertificateProviderInvite(nil, lpaStore, shareCodeStore, notifyClient, messageStore, "http://app")(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore, shareCodeStore, notifyClient, messageStore)
+}
+
+func TestGetResendCertificateProviderInviteWhenTemplateErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{ID: "lpa-id", Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}, nil)
+
+	template := &mockTemplate{}
+	template.
+		On("Func", w, mock.Anything).
+		Return(expectedError)
+
+	err := ResendCertificateProviderInvite(template.Func, lpaStore, nil, nil, nil, "http://app")(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore, template)
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/ministryofjustice/opg-go-common/template"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/event"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/validation"
 )
@@ -22,13 +23,17 @@ const (
 	WantToApplyForLpa = "want-to-apply"
 )
 
-func SignYourLpa(tmpl template.Template, lpaStore page.LpaStore) page.Handler {
+func SignYourLpa(tmpl template.Template, lpaStore page.LpaStore, eventPublisher page.EventPublisher) page.Handler {
 	return func(appData page.AppData, w http.ResponseWriter, r *http.Request) error {
 		lpa, err := lpaStore.Get(r.Context())
 		if err != nil {
 			return err
 		}
 
+		if !lpa.FeeSettled() {
+			return appData.Redirect(w, r, lpa, page.Paths.TaskList)
+		}
+
 		data := &signYourLpaData{
 			App: appData,
 			Lpa: lpa,
@@ -48,15 +53,22 @@ func SignYourLpa(tmpl template.Template, lpaStore page.LpaStore) page.Handler {
 
 			lpa.WantToApplyForLpa = data.Form.WantToApply
 			lpa.WantToSignLpa = data.Form.WantToSign
+			signed := data.Errors.None() && !lpa.Tasks.ConfirmYourIdentityAndSign.Completed()
+			if data.Errors.None() {
+				lpa.Tasks.ConfirmYourIdentityAndSign = page.TaskCompleted
+			}
+
 			if err = lpaStore.Put(r.Context(), lpa); err != nil {
 				return err
 			}
 
-			if data.Errors.None() {
-				lpa.Tasks.ConfirmYourIdentityAndSign = page.TaskCompleted
-				if err = lpaStore.Put(r.Context(), lpa); err != nil {
+			if signed {
+				if err := eventPublisher.Publish(r.Context(), event.LpaSigned{LpaID: lpa.ID}); err != nil {
 					return err
 				}
+			}
+
+			if data.Errors.None() {
 				return appData.Redirect(w, r, lpa, page.Paths.WitnessingYourSignature)
 			}
 		}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/event"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/validation"
 	"github.com/stretchr/testify/assert"
@@ -20,20 +21,20 @@ func TestGetSignYourLpa(t *testing.T) {
 	lpaStore := &mockLpaStore{}
 	lpaStore.
 		On("Get", r.Context()).
-		Return(&page.Lpa{}, nil)
+		Return(&page.Lpa{Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}, nil)
 
 	template := &mockTemplate{}
 	template.
 		On("Func", w, &signYourLpaData{
 			App:                  appData,
 			Form:                 &signYourLpaForm{},
-			Lpa:                  &page.Lpa{},
+			Lpa:                  &page.Lpa{Tasks: page.Tasks{PayForLpa: page.TaskCompleted}},
 			WantToSignFormValue:  WantToSignLpa,
 			WantToApplyFormValue: WantToApplyForLpa,
 		}).
 		Return(nil)
 
-	err := SignYourLpa(template.Func, lpaStore)(appData, w, r)
+	err := SignYourLpa(template.Func, lpaStore, nil)(appData, w, r)
 	resp := w.Result()
 
 	assert.Nil(t, err)
@@ -50,7 +51,7 @@ func TestGetSignYourLpaWhenStoreErrors(t *testing.T) {
 		On("Get", r.Context()).
 		Return(&page.Lpa{}, expectedError)
 
-	err := SignYourLpa(nil, lpaStore)(appData, w, r)
+	err := SignYourLpa(nil, lpaStore, nil)(appData, w, r)
 	resp := w.Result()
 
 	assert.Equal(t, expectedError, err)
@@ -58,6 +59,24 @@ func TestGetSignYourLpaWhenStoreErrors(t *testing.T) {
 	mock.AssertExpectationsForObjects(t, lpaStore)
 }
 
+func TestGetSignYourLpaWhenFeeNotSettled(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{ID: "lpa-id", FeeEvidenceReview: page.TaskInProgress, Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}, nil)
+
+	err := SignYourLpa(nil, lpaStore, nil)(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/lpa/lpa-id"+page.Paths.TaskList, resp.Header.Get("Location"))
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
 func TestGetSignYourLpaFromStore(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodGet, "/", nil)
@@ -65,6 +84,7 @@ func TestGetSignYourLpaFromStore(t *testing.T) {
 	lpa := &page.Lpa{
 		WantToSignLpa:     true,
 		WantToApplyForLpa: false,
+		Tasks:             page.Tasks{PayForLpa: page.TaskCompleted},
 	}
 
 	lpaStore := &mockLpaStore{}
@@ -86,7 +106,7 @@ func TestGetSignYourLpaFromStore(t *testing.T) {
 		}).
 		Return(nil)
 
-	err := SignYourLpa(template.Func, lpaStore)(appData, w, r)
+	err := SignYourLpa(template.Func, lpaStore, nil)(appData, w, r)
 	resp := w.Result()
 
 	assert.Nil(t, err)
@@ -106,16 +126,10 @@ func TestPostSignYourLpa(t *testing.T) {
 	lpaStore := &mockLpaStore{}
 	lpaStore.
 		On("Get", r.Context()).
-		Return(&page.Lpa{}, nil)
-	lpaStore.
-		On("Put", r.Context(), &page.Lpa{
-			WantToSignLpa:     true,
-			WantToApplyForLpa: true,
-		}).
-		Return(nil)
+		Return(&page.Lpa{Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}, nil)
 	lpaStore.
 		On("Put", r.Context(), &page.Lpa{
-			Tasks: page.Tasks{
+			Tasks: page.Tasks{PayForLpa: page.TaskCompleted,
 				ConfirmYourIdentityAndSign: page.TaskCompleted,
 			},
 			WantToSignLpa:     true,
@@ -123,15 +137,72 @@ func TestPostSignYourLpa(t *testing.T) {
 		}).
 		Return(nil)
 
-	err := SignYourLpa(nil, lpaStore)(appData, w, r)
+	eventPublisher := &mockEventPublisher{}
+	eventPublisher.
+		On("Publish", r.Context(), event.LpaSigned{}).
+		Return(nil)
+
+	err := SignYourLpa(nil, lpaStore, eventPublisher)(appData, w, r)
 	resp := w.Result()
 
 	assert.Nil(t, err)
 	assert.Equal(t, http.StatusFound, resp.StatusCode)
 	assert.Equal(t, "/lpa/lpa-id"+page.Paths.WitnessingYourSignature, resp.Header.Get("Location"))
+	mock.AssertExpectationsForObjects(t, lpaStore, eventPublisher)
+}
+
+func TestPostSignYourLpaWhenAlreadySigned(t *testing.T) {
+	form := url.Values{
+		"sign-lpa": {"want-to-sign", "want-to-apply"},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Add("Content-Type", formUrlEncoded)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{Tasks: page.Tasks{PayForLpa: page.TaskCompleted, ConfirmYourIdentityAndSign: page.TaskCompleted}}, nil)
+	lpaStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(nil)
+
+	err := SignYourLpa(nil, lpaStore, nil)(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
 	mock.AssertExpectationsForObjects(t, lpaStore)
 }
 
+func TestPostSignYourLpaWhenPublishErrors(t *testing.T) {
+	form := url.Values{
+		"sign-lpa": {"want-to-sign", "want-to-apply"},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Add("Content-Type", formUrlEncoded)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}, nil)
+	lpaStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(nil)
+
+	eventPublisher := &mockEventPublisher{}
+	eventPublisher.
+		On("Publish", r.Context(), mock.Anything).
+		Return(expectedError)
+
+	err := SignYourLpa(nil, lpaStore, eventPublisher)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+}
+
 func TestPostSignYourLpaWhenStoreErrors(t *testing.T) {
 	form := url.Values{
 		"sign-lpa": {"want-to-sign", "want-to-apply"},
@@ -144,12 +215,12 @@ func TestPostSignYourLpaWhenStoreErrors(t *testing.T) {
 	lpaStore := &mockLpaStore{}
 	lpaStore.
 		On("Get", r.Context()).
-		Return(&page.Lpa{}, nil)
+		Return(&page.Lpa{Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}, nil)
 	lpaStore.
 		On("Put", r.Context(), mock.Anything).
 		Return(expectedError)
 
-	err := SignYourLpa(nil, lpaStore)(appData, w, r)
+	err := SignYourLpa(nil, lpaStore, nil)(appData, w, r)
 
 	assert.Equal(t, expectedError, err)
 	mock.AssertExpectationsForObjects(t, lpaStore)
@@ -167,11 +238,12 @@ func TestPostSignYourLpaWhenValidationErrors(t *testing.T) {
 	lpaStore := &mockLpaStore{}
 	lpaStore.
 		On("Get", r.Context()).
-		Return(&page.Lpa{}, nil)
+		Return(&page.Lpa{Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}, nil)
 	lpaStore.
 		On("Put", r.Context(), &page.Lpa{
 			WantToSignLpa:     false,
 			WantToApplyForLpa: false,
+			Tasks:             page.Tasks{PayForLpa: page.TaskCompleted},
 		}).
 		Return(nil)
 
@@ -182,7 +254,7 @@ func TestPostSignYourLpaWhenValidationErrors(t *testing.T) {
 		})).
 		Return(nil)
 
-	err := SignYourLpa(template.Func, lpaStore)(appData, w, r)
+	err := SignYourLpa(template.Func, lpaStore, nil)(appData, w, r)
 	resp := w.Result()
 
 	assert.Nil(t, err)
@@ -0,0 +1,81 @@
+package donor
+
+import (
+	"net/http"
+
+	"github.com/ministryofjustice/opg-go-common/template"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/validation"
+)
+
+var feeEvidenceRequired = map[string][]string{
+	page.FeeTypeHalf:      {page.EvidenceOfIncome},
+	page.FeeTypeExemption: {page.EvidenceOfBenefits},
+	page.FeeTypeHardship:  {page.EvidenceOfIncome, page.EvidenceOfHardship},
+}
+
+type whichFeeTypeAreYouApplyingForData struct {
+	App     page.AppData
+	Errors  validation.List
+	FeeType string
+}
+
+func WhichFeeTypeAreYouApplyingFor(tmpl template.Template, lpaStore page.LpaStore) page.Handler {
+	return func(appData page.AppData, w http.ResponseWriter, r *http.Request) error {
+		lpa, err := lpaStore.Get(r.Context())
+		if err != nil {
+			return err
+		}
+
+		if lpa.FeeTypeLocked() {
+			return appData.Redirect(w, r, lpa, page.Paths.TaskList)
+		}
+
+		data := &whichFeeTypeAreYouApplyingForData{
+			App:     appData,
+			FeeType: lpa.FeeType,
+		}
+
+		if r.Method == http.MethodPost {
+			form := readWhichFeeTypeAreYouApplyingForForm(r)
+			data.Errors = form.Validate()
+
+			if data.Errors.None() {
+				lpa.FeeType = form.FeeType
+				lpa.EvidenceRequired = feeEvidenceRequired[form.FeeType]
+				lpa.FeeEvidenceReview = page.TaskNotStarted
+
+				if err := lpaStore.Put(r.Context(), lpa); err != nil {
+					return err
+				}
+
+				if form.FeeType == page.FeeTypeFull {
+					return appData.Redirect(w, r, lpa, page.Paths.AboutPayment)
+				}
+
+				return appData.Redirect(w, r, lpa, page.Paths.EvidenceRequired)
+			}
+		}
+
+		return tmpl(w, data)
+	}
+}
+
+type whichFeeTypeAreYouApplyingForForm struct {
+	FeeType string
+}
+
+func readWhichFeeTypeAreYouApplyingForForm(r *http.Request) *whichFeeTypeAreYouApplyingForForm {
+	return &whichFeeTypeAreYouApplyingForForm{
+		FeeType: page.PostFormString(r, "fee-type"),
+	}
+}
+
+func (f *whichFeeTypeAreYouApplyingForForm) Validate() validation.List {
+	var errors validation.List
+
+	errors.String("fee-type", "whichFeeTypeYouAreApplyingFor", f.FeeType,
+		validation.Select(page.FeeTypeFull, page.FeeTypeHalf, page.FeeTypeExemption, page.FeeTypeHardship))
+
+	return errors
+}
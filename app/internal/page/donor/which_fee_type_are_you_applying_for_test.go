@@ -0,0 +1,193 @@
+package donor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/pay"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var readyToPay = page.Tasks{
+	YourDetails:                page.TaskCompleted,
+	ChooseAttorneys:            page.TaskCompleted,
+	ChooseReplacementAttorneys: page.TaskCompleted,
+	WhenCanTheLpaBeUsed:        page.TaskCompleted,
+	Restrictions:               page.TaskCompleted,
+	CertificateProvider:        page.TaskCompleted,
+	PeopleToNotify:             page.TaskCompleted,
+	CheckYourLpa:               page.TaskCompleted,
+}
+
+func TestGetWhichFeeTypeAreYouApplyingFor(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{FeeType: page.FeeTypeHalf}, nil)
+
+	template := &mockTemplate{}
+	template.
+		On("Func", w, &whichFeeTypeAreYouApplyingForData{
+			App:     appData,
+			FeeType: page.FeeTypeHalf,
+		}).
+		Return(nil)
+
+	err := WhichFeeTypeAreYouApplyingFor(template.Func, lpaStore)(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	mock.AssertExpectationsForObjects(t, template, lpaStore)
+}
+
+func TestGetWhichFeeTypeAreYouApplyingForWhenStoreErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{}, expectedError)
+
+	err := WhichFeeTypeAreYouApplyingFor(nil, lpaStore)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestPostWhichFeeTypeAreYouApplyingForWhenPaid(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{ID: "lpa-id", FeeType: page.FeeTypeFull, PaymentDetails: page.PaymentDetails{Status: pay.StatusSuccess}}, nil)
+
+	err := WhichFeeTypeAreYouApplyingFor(nil, lpaStore)(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/lpa/lpa-id"+page.Paths.TaskList, resp.Header.Get("Location"))
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestPostWhichFeeTypeAreYouApplyingFor(t *testing.T) {
+	testCases := map[string]struct {
+		evidenceRequired []string
+		redirect         string
+	}{
+		page.FeeTypeFull: {
+			redirect: page.Paths.AboutPayment,
+		},
+		page.FeeTypeHalf: {
+			evidenceRequired: []string{page.EvidenceOfIncome},
+			redirect:         page.Paths.EvidenceRequired,
+		},
+		page.FeeTypeExemption: {
+			evidenceRequired: []string{page.EvidenceOfBenefits},
+			redirect:         page.Paths.EvidenceRequired,
+		},
+		page.FeeTypeHardship: {
+			evidenceRequired: []string{page.EvidenceOfIncome, page.EvidenceOfHardship},
+			redirect:         page.Paths.EvidenceRequired,
+		},
+	}
+
+	for feeType, tc := range testCases {
+		t.Run(feeType, func(t *testing.T) {
+			form := url.Values{
+				"fee-type": {feeType},
+			}
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+			r.Header.Add("Content-Type", formUrlEncoded)
+
+			lpaStore := &mockLpaStore{}
+			lpaStore.
+				On("Get", r.Context()).
+				Return(&page.Lpa{
+					FeeType:           page.FeeTypeHardship,
+					EvidenceRequired:  []string{page.EvidenceOfHardship},
+					FeeEvidenceReview: page.TaskInProgress,
+					Tasks:             readyToPay,
+				}, nil)
+			lpaStore.
+				On("Put", r.Context(), &page.Lpa{
+					FeeType:          feeType,
+					EvidenceRequired: tc.evidenceRequired,
+					Tasks:            readyToPay,
+				}).
+				Return(nil)
+
+			err := WhichFeeTypeAreYouApplyingFor(nil, lpaStore)(appData, w, r)
+			resp := w.Result()
+
+			assert.Nil(t, err)
+			assert.Equal(t, http.StatusFound, resp.StatusCode)
+			assert.Equal(t, "/lpa/lpa-id"+tc.redirect, resp.Header.Get("Location"))
+			mock.AssertExpectationsForObjects(t, lpaStore)
+		})
+	}
+}
+
+func TestPostWhichFeeTypeAreYouApplyingForWhenStoreErrors(t *testing.T) {
+	form := url.Values{
+		"fee-type": {page.FeeTypeFull},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Add("Content-Type", formUrlEncoded)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{}, nil)
+	lpaStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(expectedError)
+
+	err := WhichFeeTypeAreYouApplyingFor(nil, lpaStore)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestPostWhichFeeTypeAreYouApplyingForWhenValidationErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(""))
+	r.Header.Add("Content-Type", formUrlEncoded)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{}, nil)
+
+	template := &mockTemplate{}
+	template.
+		On("Func", w, &whichFeeTypeAreYouApplyingForData{
+			App:    appData,
+			Errors: validation.With("fee-type", validation.SelectError{Label: "whichFeeTypeYouAreApplyingFor"}),
+		}).
+		Return(nil)
+
+	err := WhichFeeTypeAreYouApplyingFor(template.Func, lpaStore)(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	mock.AssertExpectationsForObjects(t, template, lpaStore)
+}
@@ -1,10 +1,12 @@
 package donor
 
 import (
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/ministryofjustice/opg-go-common/template"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/event"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/validation"
 )
@@ -16,13 +18,17 @@ type witnessingAsCertificateProviderData struct {
 	Lpa    *page.Lpa
 }
 
-func WitnessingAsCertificateProvider(tmpl template.Template, lpaStore page.LpaStore, now func() time.Time) page.Handler {
+func WitnessingAsCertificateProvider(logger page.Logger, tmpl template.Template, lpaStore page.LpaStore, noticeStore page.NoticeStore, eventPublisher page.EventPublisher, registrationStore page.RegistrationStore, notifyClient page.NotifyClient, messageStore page.MessageStore, shareCodeStore page.ShareCodeStore, appPublicURL string, now func() time.Time) page.Handler {
 	return func(appData page.AppData, w http.ResponseWriter, r *http.Request) error {
 		lpa, err := lpaStore.Get(r.Context())
 		if err != nil {
 			return err
 		}
 
+		if !lpa.FeeSettled() {
+			return appData.Redirect(w, r, lpa, page.Paths.TaskList)
+		}
+
 		data := &witnessingAsCertificateProviderData{
 			App:  appData,
 			Lpa:  lpa,
@@ -42,10 +48,48 @@ func WitnessingAsCertificateProvider(tmpl template.Template, lpaStore page.LpaSt
 			if data.Errors.None() {
 				lpa.CPWitnessCodeValidated = true
 				lpa.Submitted = now()
+
 				if err := lpaStore.Put(r.Context(), lpa); err != nil {
 					return err
 				}
 
+				if err := eventPublisher.Publish(r.Context(), event.LpaWitnessed{LpaID: lpa.ID}); err != nil {
+					return err
+				}
+
+				if err := eventPublisher.Publish(r.Context(), event.LpaSubmitted{LpaID: lpa.ID, SubmittedAt: lpa.Submitted}); err != nil {
+					return err
+				}
+
+				// The notices to people to notify, the invites to attorneys
+				// and the registration are marked as pending before the
+				// submission is saved, then sent by SendPendingNotices and
+				// RegisterPendingLpas, so that none are missed and all can be
+				// tried again.
+				if err := noticeStore.PutPending(r.Context()); err != nil {
+					return err
+				}
+
+				if err := registrationStore.PutPending(r.Context()); err != nil {
+					return err
+				}
+
+				if err := page.CommitLpa(r.Context()); err != nil {
+					return err
+				}
+
+				// The notices and invites are also sent now, so that people to
+				// notify and attorneys hear of the LPA as soon as it is
+				// submitted. Any that cannot be sent are left to
+				// SendPendingNotices.
+				if _, err := sendPeopleToNotifyNotices(r.Context(), notifyClient, lpaStore, messageStore, lpa, now()); err != nil {
+					logger.Print(fmt.Sprintf("unable to send notices for lpa %s: %s", lpa.ID, err.Error()))
+				}
+
+				if _, err := sendAttorneyInvites(r.Context(), notifyClient, shareCodeStore, lpaStore, messageStore, appPublicURL, lpa, now()); err != nil {
+					logger.Print(fmt.Sprintf("unable to send attorney invites for lpa %s: %s", lpa.ID, err.Error()))
+				}
+
 				return appData.Redirect(w, r, lpa, page.Paths.YouHaveSubmittedYourLpa)
 			}
 		}
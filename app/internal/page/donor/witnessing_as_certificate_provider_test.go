@@ -9,6 +9,9 @@ import (
 	"time"
 
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/event"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/localize"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/validation"
 	"github.com/stretchr/testify/assert"
@@ -22,18 +25,18 @@ func TestGetWitnessingAsCertificateProvider(t *testing.T) {
 	lpaStore := &mockLpaStore{}
 	lpaStore.
 		On("Get", r.Context()).
-		Return(&page.Lpa{}, nil)
+		Return(&page.Lpa{Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}, nil)
 
 	template := &mockTemplate{}
 	template.
 		On("Func", w, &witnessingAsCertificateProviderData{
 			App:  appData,
-			Lpa:  &page.Lpa{},
+			Lpa:  &page.Lpa{Tasks: page.Tasks{PayForLpa: page.TaskCompleted}},
 			Form: &witnessingAsCertificateProviderForm{},
 		}).
 		Return(nil)
 
-	err := WitnessingAsCertificateProvider(template.Func, lpaStore, time.Now)(appData, w, r)
+	err := WitnessingAsCertificateProvider(nil, template.Func, lpaStore, nil, nil, nil, nil, nil, nil, "", time.Now)(appData, w, r)
 	resp := w.Result()
 
 	assert.Nil(t, err)
@@ -52,7 +55,7 @@ func TestGetWitnessingAsCertificateProviderWhenStoreErrors(t *testing.T) {
 
 	template := &mockTemplate{}
 
-	err := WitnessingAsCertificateProvider(template.Func, lpaStore, time.Now)(appData, w, r)
+	err := WitnessingAsCertificateProvider(nil, template.Func, lpaStore, nil, nil, nil, nil, nil, nil, "", time.Now)(appData, w, r)
 	resp := w.Result()
 
 	assert.Equal(t, expectedError, err)
@@ -60,6 +63,24 @@ func TestGetWitnessingAsCertificateProviderWhenStoreErrors(t *testing.T) {
 	mock.AssertExpectationsForObjects(t, lpaStore, template)
 }
 
+func TestGetWitnessingAsCertificateProviderWhenFeeNotSettled(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{ID: "lpa-id", FeeEvidenceReview: page.TaskInProgress, Tasks: page.Tasks{PayForLpa: page.TaskInProgress}}, nil)
+
+	err := WitnessingAsCertificateProvider(nil, nil, lpaStore, nil, nil, nil, nil, nil, nil, "", nil)(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/lpa/lpa-id"+page.Paths.TaskList, resp.Header.Get("Location"))
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
 func TestGetWitnessingAsCertificateProviderFromStore(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodGet, "/", nil)
@@ -69,6 +90,7 @@ func TestGetWitnessingAsCertificateProviderFromStore(t *testing.T) {
 		On("Get", r.Context()).
 		Return(&page.Lpa{
 			CertificateProvider: actor.CertificateProvider{FirstNames: "Joan"},
+			Tasks:               page.Tasks{PayForLpa: page.TaskCompleted},
 		}, nil)
 
 	template := &mockTemplate{}
@@ -77,12 +99,13 @@ func TestGetWitnessingAsCertificateProviderFromStore(t *testing.T) {
 			App: appData,
 			Lpa: &page.Lpa{
 				CertificateProvider: actor.CertificateProvider{FirstNames: "Joan"},
+				Tasks:               page.Tasks{PayForLpa: page.TaskCompleted},
 			},
 			Form: &witnessingAsCertificateProviderForm{},
 		}).
 		Return(nil)
 
-	err := WitnessingAsCertificateProvider(template.Func, lpaStore, time.Now)(appData, w, r)
+	err := WitnessingAsCertificateProvider(nil, template.Func, lpaStore, nil, nil, nil, nil, nil, nil, "", time.Now)(appData, w, r)
 	resp := w.Result()
 
 	assert.Nil(t, err)
@@ -97,18 +120,18 @@ func TestGetWitnessingAsCertificateProviderWhenTemplateErrors(t *testing.T) {
 	lpaStore := &mockLpaStore{}
 	lpaStore.
 		On("Get", r.Context()).
-		Return(&page.Lpa{}, nil)
+		Return(&page.Lpa{Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}, nil)
 
 	template := &mockTemplate{}
 	template.
 		On("Func", w, &witnessingAsCertificateProviderData{
 			App:  appData,
-			Lpa:  &page.Lpa{},
+			Lpa:  &page.Lpa{Tasks: page.Tasks{PayForLpa: page.TaskCompleted}},
 			Form: &witnessingAsCertificateProviderForm{},
 		}).
 		Return(expectedError)
 
-	err := WitnessingAsCertificateProvider(template.Func, lpaStore, time.Now)(appData, w, r)
+	err := WitnessingAsCertificateProvider(nil, template.Func, lpaStore, nil, nil, nil, nil, nil, nil, "", time.Now)(appData, w, r)
 	resp := w.Result()
 
 	assert.Equal(t, expectedError, err)
@@ -131,24 +154,373 @@ func TestPostWitnessingAsCertificateProvider(t *testing.T) {
 		On("Get", r.Context()).
 		Return(&page.Lpa{
 			WitnessCode: page.WitnessCode{Code: "1234", Created: now},
+			Tasks:       page.Tasks{PayForLpa: page.TaskCompleted},
 		}, nil)
 	lpaStore.
 		On("Put", r.Context(), &page.Lpa{
 			WitnessCode:            page.WitnessCode{Code: "1234", Created: now},
 			CPWitnessCodeValidated: true,
 			Submitted:              now,
+			Tasks:                  page.Tasks{PayForLpa: page.TaskCompleted},
 		}).
 		Return(nil)
 
-	err := WitnessingAsCertificateProvider(nil, lpaStore, func() time.Time { return now })(appData, w, r)
+	eventPublisher := &mockEventPublisher{}
+	eventPublisher.
+		On("Publish", r.Context(), event.LpaWitnessed{}).
+		Return(nil)
+	eventPublisher.
+		On("Publish", r.Context(), event.LpaSubmitted{SubmittedAt: now}).
+		Return(nil)
+
+	noticeStore := &mockNoticeStore{}
+	noticeStore.
+		On("PutPending", r.Context()).
+		Return(nil)
+
+	registrationStore := &mockRegistrationStore{}
+	registrationStore.
+		On("PutPending", r.Context()).
+		Return(nil)
+
+	err := WitnessingAsCertificateProvider(nil, nil, lpaStore, noticeStore, eventPublisher, registrationStore, nil, nil, nil, "", func() time.Time { return now })(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/lpa/lpa-id"+page.Paths.YouHaveSubmittedYourLpa, resp.Header.Get("Location"))
+	mock.AssertExpectationsForObjects(t, lpaStore, noticeStore, registrationStore, eventPublisher)
+}
+
+func TestPostWitnessingAsCertificateProviderSendsNotices(t *testing.T) {
+	form := url.Values{
+		"witness-code": {"1234"},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Add("Content-Type", formUrlEncoded)
+	now := time.Now()
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{
+			ID:             "lpa-id",
+			WitnessCode:    page.WitnessCode{Code: "1234", Created: now},
+			Tasks:          page.Tasks{PayForLpa: page.TaskCompleted},
+			PeopleToNotify: actor.PeopleToNotify{{ID: "1", Email: "a@example.com"}},
+		}, nil)
+	lpaStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(nil)
+
+	eventPublisher := &mockEventPublisher{}
+	eventPublisher.
+		On("Publish", r.Context(), mock.Anything).
+		Return(nil)
+
+	noticeStore := &mockNoticeStore{}
+	noticeStore.
+		On("PutPending", r.Context()).
+		Return(nil)
+
+	registrationStore := &mockRegistrationStore{}
+	registrationStore.
+		On("PutPending", r.Context()).
+		Return(nil)
+
+	notifyClient := &mockNotifyClient{}
+	notifyClient.
+		On("TemplateID", notify.PersonToNotifyEmail, localize.En).
+		Return("template-id")
+	notifyClient.
+		On("Email", r.Context(), mock.MatchedBy(func(email notify.Email) bool {
+			return email.EmailAddress == "a@example.com" && email.Reference == "lpa-id/person-to-notify-email/1"
+		})).
+		Return("email-id", nil)
+
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("Put", r.Context(), mock.MatchedBy(func(message page.Message) bool { return message.ID == "email-id" })).
+		Return(nil)
+
+	err := WitnessingAsCertificateProvider(nil, nil, lpaStore, noticeStore, eventPublisher, registrationStore, notifyClient, messageStore, nil, "", func() time.Time { return now })(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/lpa/lpa-id"+page.Paths.YouHaveSubmittedYourLpa, resp.Header.Get("Location"))
+	mock.AssertExpectationsForObjects(t, lpaStore, noticeStore, registrationStore, eventPublisher, notifyClient, messageStore)
+}
+
+func TestPostWitnessingAsCertificateProviderSendsAttorneyInvites(t *testing.T) {
+	form := url.Values{
+		"witness-code": {"1234"},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Add("Content-Type", formUrlEncoded)
+	now := time.Now()
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{
+			ID:          "lpa-id",
+			WitnessCode: page.WitnessCode{Code: "1234", Created: now},
+			Tasks:       page.Tasks{PayForLpa: page.TaskCompleted},
+			Attorneys:   actor.Attorneys{{ID: "1", Email: "a@example.com"}},
+		}, nil)
+	lpaStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(nil)
+
+	eventPublisher := &mockEventPublisher{}
+	eventPublisher.
+		On("Publish", r.Context(), mock.Anything).
+		Return(nil)
+
+	noticeStore := &mockNoticeStore{}
+	noticeStore.
+		On("PutPending", r.Context()).
+		Return(nil)
+
+	registrationStore := &mockRegistrationStore{}
+	registrationStore.
+		On("PutPending", r.Context()).
+		Return(nil)
+
+	shareCodeStore := &mockShareCodeStore{}
+	shareCodeStore.
+		On("Create", r.Context(), page.ShareCodeData{LpaID: "lpa-id", ActorType: page.ActorTypeAttorney, ActorID: "1"}).
+		Return("123", nil)
+
+	notifyClient := &mockNotifyClient{}
+	notifyClient.
+		On("TemplateID", notify.AttorneyInviteEmail, localize.En).
+		Return("template-id")
+	notifyClient.
+		On("Email", r.Context(), mock.MatchedBy(func(email notify.Email) bool {
+			return email.EmailAddress == "a@example.com" && email.Reference == "lpa-id/attorney-invite-email/1/1"
+		})).
+		Return("email-id", nil)
+
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("Put", r.Context(), mock.MatchedBy(func(message page.Message) bool { return message.ID == "email-id" })).
+		Return(nil)
+
+	err := WitnessingAsCertificateProvider(nil, nil, lpaStore, noticeStore, eventPublisher, registrationStore, notifyClient, messageStore, shareCodeStore, "http://app", func() time.Time { return now })(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/lpa/lpa-id"+page.Paths.YouHaveSubmittedYourLpa, resp.Header.Get("Location"))
+	mock.AssertExpectationsForObjects(t, lpaStore, noticeStore, registrationStore, eventPublisher, shareCodeStore, notifyClient, messageStore)
+}
+
+func TestPostWitnessingAsCertificateProviderWhenNoticesCannotBeSent(t *testing.T) {
+	form := url.Values{
+		"witness-code": {"1234"},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Add("Content-Type", formUrlEncoded)
+	now := time.Now()
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{
+			ID:             "lpa-id",
+			WitnessCode:    page.WitnessCode{Code: "1234", Created: now},
+			Tasks:          page.Tasks{PayForLpa: page.TaskCompleted},
+			PeopleToNotify: actor.PeopleToNotify{{ID: "1", Email: "a@example.com"}},
+		}, nil)
+	lpaStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(nil).
+		Twice()
+	lpaStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(expectedError).
+		Once()
+
+	eventPublisher := &mockEventPublisher{}
+	eventPublisher.
+		On("Publish", r.Context(), mock.Anything).
+		Return(nil)
+
+	noticeStore := &mockNoticeStore{}
+	noticeStore.
+		On("PutPending", r.Context()).
+		Return(nil)
+
+	registrationStore := &mockRegistrationStore{}
+	registrationStore.
+		On("PutPending", r.Context()).
+		Return(nil)
+
+	notifyClient := &mockNotifyClient{}
+	notifyClient.
+		On("TemplateID", mock.Anything, mock.Anything).
+		Return("template-id")
+	notifyClient.
+		On("Email", r.Context(), mock.Anything).
+		Return("", expectedError)
+
+	logger := &mockLogger{}
+	logger.
+		On("Print", "unable to send notices for lpa lpa-id: err")
+
+	err := WitnessingAsCertificateProvider(logger, nil, lpaStore, noticeStore, eventPublisher, registrationStore, notifyClient, nil, nil, "", func() time.Time { return now })(appData, w, r)
 	resp := w.Result()
 
 	assert.Nil(t, err)
 	assert.Equal(t, http.StatusFound, resp.StatusCode)
 	assert.Equal(t, "/lpa/lpa-id"+page.Paths.YouHaveSubmittedYourLpa, resp.Header.Get("Location"))
+	mock.AssertExpectationsForObjects(t, lpaStore, noticeStore, registrationStore, eventPublisher, notifyClient, logger)
+}
+
+func TestPostWitnessingAsCertificateProviderWhenRegistrationStoreErrors(t *testing.T) {
+	form := url.Values{
+		"witness-code": {"1234"},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Add("Content-Type", formUrlEncoded)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{WitnessCode: page.WitnessCode{Code: "1234", Created: time.Now()}, Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}, nil)
+	lpaStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(nil)
+
+	eventPublisher := &mockEventPublisher{}
+	eventPublisher.
+		On("Publish", r.Context(), mock.Anything).
+		Return(nil)
+
+	noticeStore := &mockNoticeStore{}
+	noticeStore.
+		On("PutPending", r.Context()).
+		Return(nil)
+
+	registrationStore := &mockRegistrationStore{}
+	registrationStore.
+		On("PutPending", r.Context()).
+		Return(expectedError)
+
+	err := WitnessingAsCertificateProvider(nil, nil, lpaStore, noticeStore, eventPublisher, registrationStore, nil, nil, nil, "", time.Now)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore, eventPublisher, noticeStore, registrationStore)
+}
+
+func TestPostWitnessingAsCertificateProviderWhenWithdrawn(t *testing.T) {
+	form := url.Values{
+		"witness-code": {"1234"},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Add("Content-Type", formUrlEncoded)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{WitnessCode: page.WitnessCode{Code: "1234", Created: time.Now()}, Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}, nil)
+	lpaStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(page.ErrLpaWithdrawn)
+
+	err := WitnessingAsCertificateProvider(nil, nil, lpaStore, nil, nil, nil, nil, nil, nil, "", time.Now)(appData, w, r)
+
+	assert.Equal(t, page.ErrLpaWithdrawn, err)
 	mock.AssertExpectationsForObjects(t, lpaStore)
 }
 
+func TestPostWitnessingAsCertificateProviderWhenPublishErrors(t *testing.T) {
+	testCases := map[string]func(*mockEventPublisher){
+		"witnessed": func(eventPublisher *mockEventPublisher) {
+			eventPublisher.On("Publish", mock.Anything, event.LpaWitnessed{}).Return(expectedError)
+		},
+		"submitted": func(eventPublisher *mockEventPublisher) {
+			eventPublisher.On("Publish", mock.Anything, event.LpaWitnessed{}).Return(nil)
+			eventPublisher.On("Publish", mock.Anything, mock.Anything).Return(expectedError)
+		},
+	}
+
+	for name, setup := range testCases {
+		t.Run(name, func(t *testing.T) {
+			form := url.Values{
+				"witness-code": {"1234"},
+			}
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+			r.Header.Add("Content-Type", formUrlEncoded)
+
+			lpaStore := &mockLpaStore{}
+			lpaStore.
+				On("Get", r.Context()).
+				Return(&page.Lpa{WitnessCode: page.WitnessCode{Code: "1234", Created: time.Now()}, Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}, nil)
+			lpaStore.
+				On("Put", r.Context(), mock.Anything).
+				Return(nil)
+
+			eventPublisher := &mockEventPublisher{}
+			setup(eventPublisher)
+
+			err := WitnessingAsCertificateProvider(nil, nil, lpaStore, nil, eventPublisher, nil, nil, nil, nil, "", time.Now)(appData, w, r)
+			assert.Equal(t, expectedError, err)
+		})
+	}
+}
+
+func TestPostWitnessingAsCertificateProviderWhenNoticeStoreErrors(t *testing.T) {
+	form := url.Values{
+		"witness-code": {"1234"},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Add("Content-Type", formUrlEncoded)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{
+			WitnessCode:    page.WitnessCode{Code: "1234", Created: time.Now()},
+			PeopleToNotify: actor.PeopleToNotify{{ID: "1", Email: "a@example.com"}},
+			Tasks:          page.Tasks{PayForLpa: page.TaskCompleted},
+		}, nil)
+	lpaStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(nil)
+
+	eventPublisher := &mockEventPublisher{}
+	eventPublisher.
+		On("Publish", r.Context(), mock.Anything).
+		Return(nil)
+
+	noticeStore := &mockNoticeStore{}
+	noticeStore.
+		On("PutPending", r.Context()).
+		Return(expectedError)
+
+	err := WitnessingAsCertificateProvider(nil, nil, lpaStore, noticeStore, eventPublisher, nil, nil, nil, nil, "", time.Now)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore, eventPublisher, noticeStore)
+}
+
 func TestPostWitnessingAsCertificateProviderCodeTooOld(t *testing.T) {
 	form := url.Values{
 		"witness-code": {"1234"},
@@ -166,6 +538,7 @@ func TestPostWitnessingAsCertificateProviderCodeTooOld(t *testing.T) {
 		On("Get", r.Context()).
 		Return(&page.Lpa{
 			WitnessCode: page.WitnessCode{Code: "1234", Created: invalidCreated},
+			Tasks:       page.Tasks{PayForLpa: page.TaskCompleted},
 		}, nil)
 
 	template := &mockTemplate{}
@@ -174,13 +547,14 @@ func TestPostWitnessingAsCertificateProviderCodeTooOld(t *testing.T) {
 			App: appData,
 			Lpa: &page.Lpa{
 				WitnessCode: page.WitnessCode{Code: "1234", Created: invalidCreated},
+				Tasks:       page.Tasks{PayForLpa: page.TaskCompleted},
 			},
 			Errors: validation.With("witness-code", validation.CustomError{Label: "witnessCodeExpired"}),
 			Form:   &witnessingAsCertificateProviderForm{Code: "1234"},
 		}).
 		Return(nil)
 
-	err := WitnessingAsCertificateProvider(template.Func, lpaStore, time.Now)(appData, w, r)
+	err := WitnessingAsCertificateProvider(nil, template.Func, lpaStore, nil, nil, nil, nil, nil, nil, "", time.Now)(appData, w, r)
 	resp := w.Result()
 
 	assert.Nil(t, err)
@@ -205,6 +579,7 @@ func TestPostWitnessingAsCertificateProviderExpiryTrumpsMismatch(t *testing.T) {
 		On("Get", r.Context()).
 		Return(&page.Lpa{
 			WitnessCode: page.WitnessCode{Code: "1234", Created: invalidCreated},
+			Tasks:       page.Tasks{PayForLpa: page.TaskCompleted},
 		}, nil)
 
 	template := &mockTemplate{}
@@ -213,13 +588,14 @@ func TestPostWitnessingAsCertificateProviderExpiryTrumpsMismatch(t *testing.T) {
 			App: appData,
 			Lpa: &page.Lpa{
 				WitnessCode: page.WitnessCode{Code: "1234", Created: invalidCreated},
+				Tasks:       page.Tasks{PayForLpa: page.TaskCompleted},
 			},
 			Errors: validation.With("witness-code", validation.CustomError{Label: "witnessCodeExpired"}),
 			Form:   &witnessingAsCertificateProviderForm{Code: "4321"},
 		}).
 		Return(nil)
 
-	err := WitnessingAsCertificateProvider(template.Func, lpaStore, time.Now)(appData, w, r)
+	err := WitnessingAsCertificateProvider(nil, template.Func, lpaStore, nil, nil, nil, nil, nil, nil, "", time.Now)(appData, w, r)
 	resp := w.Result()
 
 	assert.Nil(t, err)
@@ -243,6 +619,7 @@ func TestPostWitnessingAsCertificateProviderCodeDoesNotMatch(t *testing.T) {
 		On("Get", r.Context()).
 		Return(&page.Lpa{
 			WitnessCode: page.WitnessCode{Code: "1234", Created: now},
+			Tasks:       page.Tasks{PayForLpa: page.TaskCompleted},
 		}, nil)
 
 	template := &mockTemplate{}
@@ -251,13 +628,14 @@ func TestPostWitnessingAsCertificateProviderCodeDoesNotMatch(t *testing.T) {
 			App: appData,
 			Lpa: &page.Lpa{
 				WitnessCode: page.WitnessCode{Code: "1234", Created: now},
+				Tasks:       page.Tasks{PayForLpa: page.TaskCompleted},
 			},
 			Errors: validation.With("witness-code", validation.CustomError{"witnessCodeDoesNotMatch"}),
 			Form:   &witnessingAsCertificateProviderForm{Code: "4321"},
 		}).
 		Return(nil)
 
-	err := WitnessingAsCertificateProvider(template.Func, lpaStore, time.Now)(appData, w, r)
+	err := WitnessingAsCertificateProvider(nil, template.Func, lpaStore, nil, nil, nil, nil, nil, nil, "", time.Now)(appData, w, r)
 	resp := w.Result()
 
 	assert.Nil(t, err)
@@ -1,6 +1,7 @@
 package donor
 
 import (
+	"fmt"
 	"net/http"
 	"time"
 
@@ -16,31 +17,67 @@ type witnessingYourSignatureData struct {
 	Lpa    *page.Lpa
 }
 
-func WitnessingYourSignature(tmpl template.Template, lpaStore page.LpaStore, notifyClient page.NotifyClient, randomCode func(int) string, now func() time.Time) page.Handler {
+func WitnessingYourSignature(tmpl template.Template, lpaStore page.LpaStore, notifyClient page.NotifyClient, messageStore page.MessageStore, randomCode func(int) string, now func() time.Time) page.Handler {
 	return func(appData page.AppData, w http.ResponseWriter, r *http.Request) error {
 		lpa, err := lpaStore.Get(r.Context())
 		if err != nil {
 			return err
 		}
 
+		if !lpa.FeeSettled() {
+			return appData.Redirect(w, r, lpa, page.Paths.TaskList)
+		}
+
+		data := &witnessingYourSignatureData{
+			App: appData,
+			Lpa: lpa,
+		}
+
 		if r.Method == http.MethodPost {
 			code := randomCode(4)
 			lpa.WitnessCode = page.WitnessCode{Code: code, Created: now()}
 
+			// The code is written before it is sent, so that the certificate
+			// provider is never sent a code that was not kept.
+			if err := lpaStore.Put(r.Context(), lpa); err != nil {
+				return err
+			}
+
+			if err := page.CommitLpa(r.Context()); err != nil {
+				return err
+			}
+
 			smsID, err := notifyClient.Sms(r.Context(), notify.Sms{
 				PhoneNumber: lpa.CertificateProvider.Mobile,
-				TemplateID:  notifyClient.TemplateID(notify.SignatureCodeSms),
+				TemplateID:  notifyClient.TemplateID(notify.SignatureCodeSms, lpa.ContactLanguagePreference),
+				Reference:   fmt.Sprintf("%s/%s/%d", lpa.ID, notify.SignatureCodeSms, lpa.WitnessCode.Created.Unix()),
 				Personalisation: map[string]string{
 					"code": code,
 				},
 			})
 
 			if err != nil {
-				return err
+				notifyErr, ok := notifyError(err, "certificateProviderMobileNotAccepted")
+				if !ok {
+					return err
+				}
+
+				data.Errors.Add("witness-code", notifyErr)
+				return tmpl(w, data)
 			}
 
 			lpa.SignatureSmsID = smsID
 
+			if err := messageStore.Put(r.Context(), page.Message{
+				ID:            smsID,
+				LpaID:         lpa.ID,
+				RecipientRole: page.ActorTypeCertificateProvider,
+				Template:      notify.SignatureCodeSms.String(),
+				Status:        notify.StatusCreated,
+			}); err != nil {
+				return err
+			}
+
 			if err := lpaStore.Put(r.Context(), lpa); err != nil {
 				return err
 			}
@@ -48,11 +85,6 @@ func WitnessingYourSignature(tmpl template.Template, lpaStore page.LpaStore, not
 			return appData.Redirect(w, r, lpa, page.Paths.WitnessingAsCertificateProvider)
 		}
 
-		data := &witnessingYourSignatureData{
-			App: appData,
-			Lpa: lpa,
-		}
-
 		return tmpl(w, data)
 	}
 }
@@ -1,14 +1,17 @@
 package donor
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/localize"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/validation"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -19,7 +22,7 @@ func TestGetWitnessingYourSignature(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodGet, "/", nil)
 
-	lpa := &page.Lpa{CertificateProvider: actor.CertificateProvider{Mobile: "07535111111"}}
+	lpa := &page.Lpa{CertificateProvider: actor.CertificateProvider{Mobile: "07535111111"}, Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}
 
 	lpaStore := &mockLpaStore{}
 	lpaStore.
@@ -31,7 +34,7 @@ func TestGetWitnessingYourSignature(t *testing.T) {
 		On("Func", w, &witnessingYourSignatureData{App: appData, Lpa: lpa}).
 		Return(nil)
 
-	err := WitnessingYourSignature(template.Func, lpaStore, nil, nil, nil)(appData, w, r)
+	err := WitnessingYourSignature(template.Func, lpaStore, nil, nil, nil, nil)(appData, w, r)
 	resp := w.Result()
 
 	assert.Nil(t, err)
@@ -48,17 +51,35 @@ func TestGetWitnessingYourSignatureWhenLpaStoreErrors(t *testing.T) {
 		On("Get", r.Context()).
 		Return(&page.Lpa{}, expectedError)
 
-	err := WitnessingYourSignature(nil, lpaStore, nil, nil, nil)(appData, w, r)
+	err := WitnessingYourSignature(nil, lpaStore, nil, nil, nil, nil)(appData, w, r)
 
 	assert.Equal(t, expectedError, err)
 	mock.AssertExpectationsForObjects(t, lpaStore)
 }
 
+func TestGetWitnessingYourSignatureWhenFeeNotSettled(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{ID: "lpa-id", Tasks: page.Tasks{PayForLpa: page.TaskInProgress}}, nil)
+
+	err := WitnessingYourSignature(nil, lpaStore, nil, nil, nil, nil)(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/lpa/lpa-id"+page.Paths.TaskList, resp.Header.Get("Location"))
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
 func TestGetWitnessingYourSignatureWhenTemplateErrors(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodGet, "/", nil)
 
-	lpa := &page.Lpa{CertificateProvider: actor.CertificateProvider{Mobile: "07535111111"}}
+	lpa := &page.Lpa{CertificateProvider: actor.CertificateProvider{Mobile: "07535111111"}, Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}
 
 	lpaStore := &mockLpaStore{}
 	lpaStore.
@@ -70,7 +91,7 @@ func TestGetWitnessingYourSignatureWhenTemplateErrors(t *testing.T) {
 		On("Func", w, &witnessingYourSignatureData{App: appData, Lpa: lpa}).
 		Return(expectedError)
 
-	err := WitnessingYourSignature(template.Func, lpaStore, nil, nil, nil)(appData, w, r)
+	err := WitnessingYourSignature(template.Func, lpaStore, nil, nil, nil, nil)(appData, w, r)
 
 	assert.Equal(t, expectedError, err)
 	mock.AssertExpectationsForObjects(t, lpaStore, template)
@@ -80,12 +101,23 @@ func TestPostWitnessingYourSignature(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodPost, "/", nil)
 
-	lpa := &page.Lpa{CertificateProvider: actor.CertificateProvider{Mobile: "07535111111"}}
+	lpa := &page.Lpa{CertificateProvider: actor.CertificateProvider{Mobile: "07535111111"}, Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}
 
 	lpaStore := &mockLpaStore{}
 	lpaStore.
 		On("Get", r.Context()).
 		Return(lpa, nil)
+	lpaStore.
+		On("Put", r.Context(), &page.Lpa{
+			CertificateProvider: actor.CertificateProvider{Mobile: "07535111111"},
+			WitnessCode: page.WitnessCode{
+				Code:    "1234",
+				Created: now,
+			},
+			Tasks: page.Tasks{PayForLpa: page.TaskCompleted},
+		}).
+		Return(nil).
+		Once()
 	lpaStore.
 		On("Put", r.Context(), &page.Lpa{
 			CertificateProvider: actor.CertificateProvider{Mobile: "07535111111"},
@@ -94,60 +126,137 @@ func TestPostWitnessingYourSignature(t *testing.T) {
 				Created: now,
 			},
 			SignatureSmsID: "sms-id",
+			Tasks:          page.Tasks{PayForLpa: page.TaskCompleted},
 		}).
-		Return(nil)
+		Return(nil).
+		Once()
 
 	notifyClient := &mockNotifyClient{}
 	notifyClient.
-		On("TemplateID", notify.SignatureCodeSms).
+		On("TemplateID", notify.SignatureCodeSms, localize.En).
 		Return("xyz")
 	notifyClient.
 		On("Sms", mock.Anything, notify.Sms{
 			PhoneNumber:     "07535111111",
 			TemplateID:      "xyz",
+			Reference:       fmt.Sprintf("/signature-code-sms/%d", now.Unix()),
 			Personalisation: map[string]string{"code": "1234"},
 		}).
 		Return("sms-id", nil)
 
-	err := WitnessingYourSignature(nil, lpaStore, notifyClient, func(l int) string { return "1234" }, func() time.Time { return now })(appData, w, r)
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("Put", r.Context(), page.Message{
+			ID:            "sms-id",
+			RecipientRole: page.ActorTypeCertificateProvider,
+			Template:      notify.SignatureCodeSms.String(),
+			Status:        notify.StatusCreated,
+		}).
+		Return(nil)
+
+	err := WitnessingYourSignature(nil, lpaStore, notifyClient, messageStore, func(l int) string { return "1234" }, func() time.Time { return now })(appData, w, r)
 	resp := w.Result()
 
 	assert.Nil(t, err)
 	assert.Equal(t, http.StatusFound, resp.StatusCode)
 	assert.Equal(t, "/lpa/lpa-id"+page.Paths.WitnessingAsCertificateProvider, resp.Header.Get("Location"))
-	mock.AssertExpectationsForObjects(t, lpaStore, notifyClient)
+	mock.AssertExpectationsForObjects(t, lpaStore, notifyClient, messageStore)
 }
 
 func TestPostWitnessingYourSignatureWhenNotifyErrors(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodPost, "/", nil)
 
-	lpa := &page.Lpa{CertificateProvider: actor.CertificateProvider{Mobile: "07535111111"}}
+	lpa := &page.Lpa{CertificateProvider: actor.CertificateProvider{Mobile: "07535111111"}, Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}
 
 	lpaStore := &mockLpaStore{}
 	lpaStore.
 		On("Get", r.Context()).
 		Return(lpa, nil)
+	lpaStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(nil)
 
 	notifyClient := &mockNotifyClient{}
 	notifyClient.
-		On("TemplateID", notify.SignatureCodeSms).
+		On("TemplateID", notify.SignatureCodeSms, localize.En).
 		Return("xyz")
 	notifyClient.
 		On("Sms", mock.Anything, mock.Anything).
 		Return("", expectedError)
 
-	err := WitnessingYourSignature(nil, lpaStore, notifyClient, func(l int) string { return "1234" }, func() time.Time { return now })(appData, w, r)
+	err := WitnessingYourSignature(nil, lpaStore, notifyClient, nil, func(l int) string { return "1234" }, func() time.Time { return now })(appData, w, r)
 
 	assert.Equal(t, expectedError, err)
 	mock.AssertExpectationsForObjects(t, lpaStore, notifyClient)
 }
 
+func TestPostWitnessingYourSignatureWhenNotifyRejects(t *testing.T) {
+	testCases := map[string]struct {
+		err      error
+		expected validation.List
+	}{
+		"invalid recipient": {
+			err:      notify.ErrInvalidRecipient,
+			expected: validation.With("witness-code", validation.CustomError{Label: "certificateProviderMobileNotAccepted"}),
+		},
+		"rate limited": {
+			err:      notify.ErrRateLimited,
+			expected: validation.With("witness-code", validation.CustomError{Label: "messageCouldNotBeSentTryAgainLater"}),
+		},
+		"service down": {
+			err:      notify.ErrServiceDown,
+			expected: validation.With("witness-code", validation.CustomError{Label: "messageCouldNotBeSentTryAgainLater"}),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodPost, "/", nil)
+
+			lpa := &page.Lpa{CertificateProvider: actor.CertificateProvider{Mobile: "07535111111"}, Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}
+
+			lpaStore := &mockLpaStore{}
+			lpaStore.
+				On("Get", r.Context()).
+				Return(lpa, nil)
+			lpaStore.
+				On("Put", r.Context(), lpa).
+				Return(nil)
+
+			notifyClient := &mockNotifyClient{}
+			notifyClient.
+				On("TemplateID", notify.SignatureCodeSms, localize.En).
+				Return("xyz")
+			notifyClient.
+				On("Sms", mock.Anything, mock.Anything).
+				Return("", fmt.Errorf("err: %w", tc.err))
+
+			template := &mockTemplate{}
+			template.
+				On("Func", w, &witnessingYourSignatureData{
+					App:    appData,
+					Errors: tc.expected,
+					Lpa:    lpa,
+				}).
+				Return(nil)
+
+			err := WitnessingYourSignature(template.Func, lpaStore, notifyClient, nil, func(l int) string { return "1234" }, func() time.Time { return now })(appData, w, r)
+			resp := w.Result()
+
+			assert.Nil(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			mock.AssertExpectationsForObjects(t, lpaStore, notifyClient, template)
+		})
+	}
+}
+
 func TestPostWitnessingYourSignatureWhenLpaStoreErrors(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodPost, "/", nil)
 
-	lpa := &page.Lpa{CertificateProvider: actor.CertificateProvider{Mobile: "07535111111"}}
+	lpa := &page.Lpa{CertificateProvider: actor.CertificateProvider{Mobile: "07535111111"}, Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}
 
 	lpaStore := &mockLpaStore{}
 	lpaStore.
@@ -157,16 +266,79 @@ func TestPostWitnessingYourSignatureWhenLpaStoreErrors(t *testing.T) {
 		On("Put", r.Context(), mock.Anything).
 		Return(expectedError)
 
+	err := WitnessingYourSignature(nil, lpaStore, nil, nil, func(l int) string { return "1234" }, func() time.Time { return now })(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestPostWitnessingYourSignatureWhenLpaStoreErrorsAfterSending(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", nil)
+
+	lpa := &page.Lpa{CertificateProvider: actor.CertificateProvider{Mobile: "07535111111"}, Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(lpa, nil)
+	lpaStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(nil).
+		Once()
+	lpaStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(expectedError).
+		Once()
+
 	notifyClient := &mockNotifyClient{}
 	notifyClient.
-		On("TemplateID", notify.SignatureCodeSms).
+		On("TemplateID", notify.SignatureCodeSms, localize.En).
 		Return("xyz")
 	notifyClient.
 		On("Sms", mock.Anything, mock.Anything).
 		Return("sms-id", nil)
 
-	err := WitnessingYourSignature(nil, lpaStore, notifyClient, func(l int) string { return "1234" }, func() time.Time { return now })(appData, w, r)
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("Put", mock.Anything, mock.Anything).
+		Return(nil)
+
+	err := WitnessingYourSignature(nil, lpaStore, notifyClient, messageStore, func(l int) string { return "1234" }, func() time.Time { return now })(appData, w, r)
 
 	assert.Equal(t, expectedError, err)
-	mock.AssertExpectationsForObjects(t, lpaStore, notifyClient)
+	mock.AssertExpectationsForObjects(t, lpaStore, notifyClient, messageStore)
+}
+
+func TestPostWitnessingYourSignatureWhenMessageStoreErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", nil)
+
+	lpa := &page.Lpa{CertificateProvider: actor.CertificateProvider{Mobile: "07535111111"}, Tasks: page.Tasks{PayForLpa: page.TaskCompleted}}
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(lpa, nil)
+	lpaStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(nil)
+
+	notifyClient := &mockNotifyClient{}
+	notifyClient.
+		On("TemplateID", notify.SignatureCodeSms, localize.En).
+		Return("xyz")
+	notifyClient.
+		On("Sms", mock.Anything, mock.Anything).
+		Return("sms-id", nil)
+
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("Put", r.Context(), mock.Anything).
+		Return(expectedError)
+
+	err := WitnessingYourSignature(nil, lpaStore, notifyClient, messageStore, func(l int) string { return "1234" }, func() time.Time { return now })(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore, notifyClient, messageStore)
 }
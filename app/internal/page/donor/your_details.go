@@ -72,6 +72,7 @@ func YourDetails(tmpl template.Template, lpaStore page.LpaStore, sessionStore se
 				lpa.You.OtherNames = data.Form.OtherNames
 				lpa.You.DateOfBirth = data.Form.Dob
 				lpa.You.Email = donorSession.Email
+				lpa.ContactLanguagePreference = appData.Lang
 				lpa.Tasks.YourDetails = page.TaskInProgress
 
 				if err := lpaStore.Put(r.Context(), lpa); err != nil {
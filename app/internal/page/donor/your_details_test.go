@@ -12,6 +12,7 @@ import (
 	"github.com/gorilla/sessions"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/date"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/localize"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/place"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/sesh"
@@ -199,6 +200,55 @@ func TestPostYourDetails(t *testing.T) {
 	}
 }
 
+func TestPostYourDetailsInWelsh(t *testing.T) {
+	validBirthYear := strconv.Itoa(time.Now().Year() - 40)
+	form := url.Values{
+		"first-names":         {"John"},
+		"last-name":           {"Doe"},
+		"date-of-birth-day":   {"2"},
+		"date-of-birth-month": {"1"},
+		"date-of-birth-year":  {validBirthYear},
+	}
+
+	w := httptest.NewRecorder()
+
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Add("Content-Type", formUrlEncoded)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&page.Lpa{}, nil)
+	lpaStore.
+		On("Put", r.Context(), &page.Lpa{
+			You: actor.Person{
+				FirstNames:  "John",
+				LastName:    "Doe",
+				DateOfBirth: date.New(validBirthYear, "1", "2"),
+				Email:       "name@example.com",
+			},
+			Tasks:                     page.Tasks{YourDetails: page.TaskInProgress},
+			ContactLanguagePreference: localize.Cy,
+		}).
+		Return(nil)
+
+	sessionStore := &mockSessionsStore{}
+	sessionStore.
+		On("Get", r, "session").
+		Return(&sessions.Session{Values: map[any]any{"donor": &sesh.DonorSession{Sub: "xyz", Email: "name@example.com"}}}, nil)
+
+	welshAppData := appData
+	welshAppData.Lang = localize.Cy
+
+	err := YourDetails(nil, lpaStore, sessionStore)(welshAppData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/cy/lpa/lpa-id"+page.Paths.YourAddress, resp.Header.Get("Location"))
+	mock.AssertExpectationsForObjects(t, lpaStore, sessionStore)
+}
+
 func TestPostYourDetailsWhenInputRequired(t *testing.T) {
 	testCases := map[string]struct {
 		form        url.Values
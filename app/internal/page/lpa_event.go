@@ -0,0 +1,132 @@
+package page
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+type ActorType string
+
+const (
+	ActorTypeDonor               = ActorType("donor")
+	ActorTypeCertificateProvider = ActorType("certificate-provider")
+	ActorTypeAttorney            = ActorType("attorney")
+	ActorTypePersonToNotify      = ActorType("person-to-notify")
+	// ActorTypeSupport is an OPG user who can read any LPA, but not change it.
+	ActorTypeSupport = ActorType("support")
+	// ActorTypeSystem is a change made by a job rather than by a signed in
+	// user.
+	ActorTypeSystem = ActorType("system")
+)
+
+func (t ActorType) TransKey() string {
+	switch t {
+	case ActorTypeDonor:
+		return "donor"
+	case ActorTypeCertificateProvider:
+		return "certificateProvider"
+	case ActorTypeAttorney:
+		return "attorney"
+	case ActorTypePersonToNotify:
+		return "personToNotify"
+	case ActorTypeSupport:
+		return "supportUser"
+	case ActorTypeSystem:
+		return "systemActor"
+	}
+
+	return "unknownActor"
+}
+
+// An LpaEvent records the fields of an LPA that were changed by a single write,
+// along with who made the change.
+type LpaEvent struct {
+	LpaID     string
+	Version   int
+	ActorType ActorType
+	Subject   string
+	Changes   []LpaChange
+	Time      time.Time
+}
+
+// An LpaChange holds the JSON encoded value a field was changed to.
+type LpaChange struct {
+	Field string
+	Value string
+}
+
+// fields that change on every write so are not worth recording
+var ignoredLpaFields = map[string]bool{"UpdatedAt": true, "Version": true}
+
+// DiffLpa returns the top-level fields of next that differ from previous. A nil
+// previous is treated as an empty LPA.
+func DiffLpa(previous, next *Lpa) ([]LpaChange, error) {
+	if previous == nil {
+		previous = &Lpa{}
+	}
+
+	previousFields, err := lpaFields(previous)
+	if err != nil {
+		return nil, err
+	}
+
+	nextFields, err := lpaFields(next)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []LpaChange
+	for field, value := range nextFields {
+		if ignoredLpaFields[field] || string(previousFields[field]) == string(value) {
+			continue
+		}
+
+		changes = append(changes, LpaChange{Field: field, Value: string(value)})
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+
+	return changes, nil
+}
+
+// ReplayLpaEvents rebuilds an LPA by applying the changes from each event in
+// order.
+func ReplayLpaEvents(events []LpaEvent) (*Lpa, error) {
+	fields := map[string]json.RawMessage{}
+
+	for _, event := range events {
+		for _, change := range event.Changes {
+			fields[change.Field] = json.RawMessage(change.Value)
+		}
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	var lpa Lpa
+	if err := json.Unmarshal(data, &lpa); err != nil {
+		return nil, err
+	}
+
+	if len(events) > 0 {
+		lpa.Version = events[len(events)-1].Version
+		lpa.UpdatedAt = events[len(events)-1].Time
+	}
+
+	return &lpa, nil
+}
+
+func lpaFields(lpa *Lpa) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(lpa)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(data, &fields)
+
+	return fields, err
+}
@@ -0,0 +1,100 @@
+package page
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActorTypeTransKey(t *testing.T) {
+	assert.Equal(t, "donor", ActorTypeDonor.TransKey())
+	assert.Equal(t, "certificateProvider", ActorTypeCertificateProvider.TransKey())
+	assert.Equal(t, "attorney", ActorTypeAttorney.TransKey())
+	assert.Equal(t, "personToNotify", ActorTypePersonToNotify.TransKey())
+	assert.Equal(t, "supportUser", ActorTypeSupport.TransKey())
+	assert.Equal(t, "systemActor", ActorTypeSystem.TransKey())
+	assert.Equal(t, "unknownActor", ActorType("").TransKey())
+}
+
+func TestDiffLpa(t *testing.T) {
+	testCases := map[string]struct {
+		previous *Lpa
+		next     *Lpa
+		changes  []LpaChange
+	}{
+		"no previous": {
+			next: &Lpa{ID: "lpa-id", Type: "pfa"},
+			changes: []LpaChange{
+				{Field: "ID", Value: `"lpa-id"`},
+				{Field: "Type", Value: `"pfa"`},
+			},
+		},
+		"changed": {
+			previous: &Lpa{ID: "lpa-id", Type: "pfa", WhoFor: "me"},
+			next:     &Lpa{ID: "lpa-id", Type: "hw", WhoFor: "someone-else"},
+			changes: []LpaChange{
+				{Field: "Type", Value: `"hw"`},
+				{Field: "WhoFor", Value: `"someone-else"`},
+			},
+		},
+		"only metadata changed": {
+			previous: &Lpa{ID: "lpa-id", Version: 1},
+			next:     &Lpa{ID: "lpa-id", Version: 2, UpdatedAt: time.Now()},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			changes, err := DiffLpa(tc.previous, tc.next)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.changes, changes)
+		})
+	}
+}
+
+func TestReplayLpaEvents(t *testing.T) {
+	now := time.Date(2020, time.January, 2, 3, 4, 5, 0, time.UTC)
+
+	lpa, err := ReplayLpaEvents([]LpaEvent{
+		{Version: 1, Changes: []LpaChange{{Field: "ID", Value: `"lpa-id"`}, {Field: "Type", Value: `"pfa"`}}},
+		{Version: 2, Changes: []LpaChange{{Field: "WhoFor", Value: `"me"`}}},
+		{Version: 3, Time: now, Changes: []LpaChange{{Field: "Type", Value: `"hw"`}}},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Lpa{ID: "lpa-id", Type: "hw", WhoFor: "me", Version: 3, UpdatedAt: now}, lpa)
+}
+
+func TestReplayLpaEventsWhenNoEvents(t *testing.T) {
+	lpa, err := ReplayLpaEvents(nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Lpa{}, lpa)
+}
+
+func TestReplayLpaEventsWhenInvalidValue(t *testing.T) {
+	_, err := ReplayLpaEvents([]LpaEvent{
+		{Version: 1, Changes: []LpaChange{{Field: "Type", Value: `{`}}},
+	})
+
+	assert.NotNil(t, err)
+}
+
+func TestDiffThenReplay(t *testing.T) {
+	first := &Lpa{ID: "lpa-id", Type: "pfa"}
+	second := &Lpa{ID: "lpa-id", Type: "pfa", WhoFor: "me", Attorneys: actor.Attorneys{{ID: "a", FirstNames: "John"}}}
+
+	firstChanges, _ := DiffLpa(nil, first)
+	secondChanges, _ := DiffLpa(first, second)
+
+	lpa, err := ReplayLpaEvents([]LpaEvent{
+		{Version: 1, Changes: firstChanges},
+		{Version: 2, Changes: secondChanges},
+	})
+
+	second.Version = 2
+	assert.Nil(t, err)
+	assert.Equal(t, second, lpa)
+}
@@ -0,0 +1,44 @@
+package page
+
+import (
+	"net/http"
+
+	"github.com/ministryofjustice/opg-go-common/template"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/validation"
+)
+
+type lpaHistoryData struct {
+	App    AppData
+	Errors validation.List
+	Lpa    *Lpa
+	Events []LpaEvent
+}
+
+// LpaHistory shows the changes made to the LPA, most recent first, to anyone
+// who can read it.
+func LpaHistory(tmpl template.Template, lpaStore LpaStore) Handler {
+	return func(appData AppData, w http.ResponseWriter, r *http.Request) error {
+		lpa, err := lpaStore.Get(r.Context())
+		if err != nil {
+			return err
+		}
+
+		events, err := lpaStore.History(r.Context())
+		if err != nil {
+			return err
+		}
+
+		// show the most recent change first
+		for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+			events[i], events[j] = events[j], events[i]
+		}
+
+		data := &lpaHistoryData{
+			App:    appData,
+			Lpa:    lpa,
+			Events: events,
+		}
+
+		return tmpl(w, data)
+	}
+}
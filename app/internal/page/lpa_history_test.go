@@ -0,0 +1,74 @@
+package page
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetLpaHistory(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpa := &Lpa{ID: "lpa-id"}
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(lpa, nil)
+	lpaStore.
+		On("History", r.Context()).
+		Return([]LpaEvent{{Version: 1}, {Version: 2}, {Version: 3}}, nil)
+
+	template := &mockTemplate{}
+	template.
+		On("Func", w, &lpaHistoryData{
+			App:    appData,
+			Lpa:    lpa,
+			Events: []LpaEvent{{Version: 3}, {Version: 2}, {Version: 1}},
+		}).
+		Return(nil)
+
+	err := LpaHistory(template.Func, lpaStore)(appData, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	mock.AssertExpectationsForObjects(t, lpaStore, template)
+}
+
+func TestGetLpaHistoryWhenLpaStoreGetErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&Lpa{}, expectedError)
+
+	err := LpaHistory(nil, lpaStore)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestGetLpaHistoryWhenLpaStoreHistoryErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", r.Context()).
+		Return(&Lpa{}, nil)
+	lpaStore.
+		On("History", r.Context()).
+		Return([]LpaEvent{}, expectedError)
+
+	err := LpaHistory(nil, lpaStore)(appData, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
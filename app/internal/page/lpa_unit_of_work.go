@@ -0,0 +1,333 @@
+package page
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/dynamo"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/event"
+)
+
+// An lpaUnitOfWork holds the LPA in the session data for the length of a
+// request, so that it is read at most once and written at most once. Events
+// published during the request are held until the LPA has been written, and
+// are written with it when the store is an LpaEventWriter.
+type lpaUnitOfWork struct {
+	lpaID       string
+	lpa         *Lpa
+	store       LpaStore
+	dirty       bool
+	sessionData *SessionData
+	events      []pendingEvent
+	readOnly    bool
+}
+
+// An LpaEventWriter can write an LPA and add events to the outbox in one
+// transaction, so that the events are kept if, and only if, the LPA is
+// written. The events are then published, with any that cannot be left in the
+// outbox to be retried.
+type LpaEventWriter interface {
+	PutWithEvents(ctx context.Context, lpa *Lpa, events []event.Event) error
+}
+
+// errReadOnlyUnitOfWork is returned by CommitLpa, and by Delete, while a page
+// is shown again after a conflicting write, so that the handler stops before
+// any side effect that depends on the LPA having been written.
+var errReadOnlyUnitOfWork = errors.New("unit of work is read only")
+
+type pendingEvent struct {
+	publisher EventPublisher
+	event     event.Event
+}
+
+func contextWithLpaUnitOfWork(ctx context.Context, uow *lpaUnitOfWork) context.Context {
+	return context.WithValue(ctx, contextKey("lpaUnitOfWork"), uow)
+}
+
+func unitOfWorkFromContext(ctx context.Context) *lpaUnitOfWork {
+	uow, _ := ctx.Value(contextKey("lpaUnitOfWork")).(*lpaUnitOfWork)
+	return uow
+}
+
+func lpaUnitOfWorkFromContext(ctx context.Context) (*lpaUnitOfWork, string) {
+	uow := unitOfWorkFromContext(ctx)
+	if uow == nil {
+		return nil, ""
+	}
+
+	data := SessionDataFromContext(ctx)
+	if data == nil || data.LpaID == "" || (uow.lpa != nil && uow.lpaID != data.LpaID) {
+		return nil, ""
+	}
+
+	return uow, data.LpaID
+}
+
+type unitOfWorkLpaStore struct {
+	LpaStore
+}
+
+// UnitOfWorkLpaStore wraps store so that, for requests handled with
+// WithLpaUnitOfWork, Get only loads the LPA in the session data the first time
+// it is called, and Put only records the LPA to be written once the handler
+// has finished. Outside of such a request calls are passed to store.
+func UnitOfWorkLpaStore(store LpaStore) LpaStore {
+	return &unitOfWorkLpaStore{LpaStore: store}
+}
+
+func (s *unitOfWorkLpaStore) Get(ctx context.Context) (*Lpa, error) {
+	uow, lpaID := lpaUnitOfWorkFromContext(ctx)
+	if uow == nil {
+		return s.LpaStore.Get(ctx)
+	}
+
+	if uow.lpa != nil {
+		return uow.lpa, nil
+	}
+
+	lpa, err := s.LpaStore.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	uow.lpaID = lpaID
+	uow.lpa = lpa
+	uow.store = s.LpaStore
+	return lpa, nil
+}
+
+func (s *unitOfWorkLpaStore) Put(ctx context.Context, lpa *Lpa) error {
+	uow, _ := lpaUnitOfWorkFromContext(ctx)
+	if uow == nil || uow.lpa == nil || lpa.ID != uow.lpaID {
+		return s.LpaStore.Put(ctx, lpa)
+	}
+
+	uow.lpa = lpa
+	uow.dirty = true
+	uow.sessionData = SessionDataFromContext(ctx)
+	return nil
+}
+
+func (s *unitOfWorkLpaStore) Delete(ctx context.Context) error {
+	if uow, _ := lpaUnitOfWorkFromContext(ctx); uow != nil {
+		if uow.readOnly {
+			return errReadOnlyUnitOfWork
+		}
+
+		uow.lpa = nil
+		uow.dirty = false
+	}
+
+	return s.LpaStore.Delete(ctx)
+}
+
+type unitOfWorkEventPublisher struct {
+	EventPublisher
+}
+
+// UnitOfWorkEventPublisher wraps publisher so that, for requests handled with
+// WithLpaUnitOfWork, events are only published once the LPA has been written.
+// Outside of such a request events are passed to publisher.
+func UnitOfWorkEventPublisher(publisher EventPublisher) EventPublisher {
+	return &unitOfWorkEventPublisher{EventPublisher: publisher}
+}
+
+func (p *unitOfWorkEventPublisher) Publish(ctx context.Context, e event.Event) error {
+	uow := unitOfWorkFromContext(ctx)
+	if uow == nil {
+		return p.EventPublisher.Publish(ctx, e)
+	}
+
+	uow.events = append(uow.events, pendingEvent{publisher: p.EventPublisher, event: e})
+	return nil
+}
+
+// WithLpaUnitOfWork runs h so that a store from UnitOfWorkLpaStore loads the
+// LPA at most once, and writes it, if it was Put, in a single conditional write
+// after h returns, or when h calls CommitLpa. Events from a
+// UnitOfWorkEventPublisher are written in the same transaction, when the store
+// is an LpaEventWriter, or otherwise published after it. The response is held
+// back until then, so that a failed write is returned as the error instead of
+// the handler's response.
+//
+// When the write fails as the LPA was changed by someone else, the page is
+// shown again with AppData.LpaChanged set, see showLpaChanged.
+func WithLpaUnitOfWork(h Handler) Handler {
+	return func(appData AppData, w http.ResponseWriter, r *http.Request) error {
+		uow := &lpaUnitOfWork{}
+		ctx := contextWithLpaUnitOfWork(r.Context(), uow)
+
+		buf := &bufferedResponseWriter{header: http.Header{}}
+		err := h(appData, buf, r.WithContext(ctx))
+		if err == nil {
+			err = uow.commit(ctx)
+		}
+
+		if errors.As(err, &dynamo.ConflictError{}) {
+			return showLpaChanged(appData, w, r, h, uow)
+		}
+		if err != nil {
+			return err
+		}
+
+		buf.flush(w)
+		return nil
+	}
+}
+
+// showLpaChanged shows the page again with the LPA as it now is, so that the
+// user can see what was changed and submit again. When the LPA had been read
+// it is read again, and for a POST the handler is first run against it so that
+// the values posted are laid over it. The handler is then run as if the page
+// had been requested with GET. Nothing is written, or published, while doing
+// so. A handler that would commit the LPA, to go on to a side effect, is
+// stopped, and the page is requested again instead.
+func showLpaChanged(appData AppData, w http.ResponseWriter, r *http.Request, h Handler, failed *lpaUnitOfWork) error {
+	uow := &lpaUnitOfWork{readOnly: true}
+	ctx := contextWithLpaUnitOfWork(r.Context(), uow)
+
+	if failed.store != nil {
+		lpa, err := failed.store.Get(r.Context())
+		if err != nil {
+			return err
+		}
+
+		uow.lpaID = failed.lpaID
+		uow.lpa = lpa
+		uow.store = failed.store
+
+		if r.Method == http.MethodPost {
+			err := h(appData, &bufferedResponseWriter{header: http.Header{}}, r.Clone(ctx))
+			if err != nil && !errors.Is(err, errReadOnlyUnitOfWork) {
+				return err
+			}
+		}
+	}
+
+	get := r.Clone(ctx)
+	get.Method = http.MethodGet
+
+	appData.LpaChanged = true
+
+	buf := &bufferedResponseWriter{header: http.Header{}}
+	if err := h(appData, buf, get); err != nil {
+		if errors.Is(err, errReadOnlyUnitOfWork) {
+			http.Redirect(w, r, r.URL.String(), http.StatusFound)
+			return nil
+		}
+
+		return err
+	}
+
+	buf.flush(w)
+	return nil
+}
+
+// RunLpaUnitOfWork runs f in the same way as a handler given to
+// WithLpaUnitOfWork, for work done outside of a request such as a scheduled
+// task.
+func RunLpaUnitOfWork(ctx context.Context, f func(context.Context) error) error {
+	uow := &lpaUnitOfWork{}
+	ctx = contextWithLpaUnitOfWork(ctx, uow)
+
+	if err := f(ctx); err != nil {
+		return err
+	}
+
+	return uow.commit(ctx)
+}
+
+// CommitLpa writes the LPA held by the unit of work in ctx, and any pending
+// events, now rather than when the handler returns. Handlers call it before a
+// side effect, such as sending a message, that must only happen once the LPA
+// has been written. Later changes are held as before. Outside of a unit of work
+// it does nothing, as Put will already have written the LPA. While a page is
+// shown again after a conflicting write it returns an error, so that the
+// handler stops.
+func CommitLpa(ctx context.Context) error {
+	uow := unitOfWorkFromContext(ctx)
+	if uow == nil {
+		return nil
+	}
+
+	return uow.commit(ctx)
+}
+
+// commit writes the LPA, if it was Put, then publishes the pending events. The
+// LPA is written with the session data it was Put with, as a handler may have
+// set that after the unit of work began.
+func (uow *lpaUnitOfWork) commit(ctx context.Context) error {
+	if uow.readOnly {
+		uow.dirty = false
+		uow.events = nil
+		return errReadOnlyUnitOfWork
+	}
+
+	if uow.dirty {
+		if uow.sessionData != nil {
+			ctx = ContextWithSessionData(ctx, uow.sessionData)
+		}
+
+		if writer, ok := uow.store.(LpaEventWriter); ok && len(uow.events) > 0 {
+			events := make([]event.Event, len(uow.events))
+			for i, pending := range uow.events {
+				events[i] = pending.event
+			}
+
+			if err := writer.PutWithEvents(ctx, uow.lpa, events); err != nil {
+				return err
+			}
+
+			uow.events = nil
+		} else if err := uow.store.Put(ctx, uow.lpa); err != nil {
+			return err
+		}
+
+		uow.dirty = false
+	}
+
+	for _, pending := range uow.events {
+		if err := pending.publisher.Publish(ctx, pending.event); err != nil {
+			return err
+		}
+	}
+
+	uow.events = nil
+	return nil
+}
+
+// A bufferedResponseWriter keeps a response until it is flushed.
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
+
+func (w *bufferedResponseWriter) flush(to http.ResponseWriter) {
+	for key, values := range w.header {
+		to.Header()[key] = values
+	}
+
+	if w.status != 0 {
+		to.WriteHeader(w.status)
+	}
+
+	to.Write(w.body.Bytes())
+}
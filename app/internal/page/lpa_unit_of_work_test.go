@@ -0,0 +1,592 @@
+package page
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/dynamo"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWithLpaUnitOfWork(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequestWithContext(ContextWithSessionData(context.Background(), &SessionData{LpaID: "lpa-id"}), http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&Lpa{ID: "lpa-id"}, nil).
+		Once()
+	lpaStore.
+		On("Put", mock.Anything, &Lpa{ID: "lpa-id", WantToSignLpa: true, WantToApplyForLpa: true}).
+		Return(nil).
+		Once()
+
+	store := UnitOfWorkLpaStore(lpaStore)
+
+	err := WithLpaUnitOfWork(func(appData AppData, hw http.ResponseWriter, hr *http.Request) error {
+		lpa, _ := store.Get(hr.Context())
+		lpa.WantToSignLpa = true
+		assert.Nil(t, store.Put(hr.Context(), lpa))
+
+		lpa, _ = store.Get(hr.Context())
+		lpa.WantToApplyForLpa = true
+		assert.Nil(t, store.Put(hr.Context(), lpa))
+
+		http.Redirect(hw, hr, "/next", http.StatusFound)
+		return nil
+	})(AppData{}, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/next", resp.Header.Get("Location"))
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestWithLpaUnitOfWorkWhenNotPut(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequestWithContext(ContextWithSessionData(context.Background(), &SessionData{LpaID: "lpa-id"}), http.MethodGet, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&Lpa{ID: "lpa-id"}, nil).
+		Once()
+
+	store := UnitOfWorkLpaStore(lpaStore)
+
+	err := WithLpaUnitOfWork(func(appData AppData, hw http.ResponseWriter, hr *http.Request) error {
+		store.Get(hr.Context())
+		store.Get(hr.Context())
+		hw.Write([]byte("hey"))
+		return nil
+	})(AppData{}, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "hey", w.Body.String())
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestWithLpaUnitOfWorkWhenConflict(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequestWithContext(ContextWithSessionData(context.Background(), &SessionData{LpaID: "lpa-id"}), http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&Lpa{ID: "lpa-id"}, nil).
+		Once()
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&Lpa{ID: "lpa-id", WantToApplyForLpa: true}, nil).
+		Once()
+	lpaStore.
+		On("Put", mock.Anything, mock.Anything).
+		Return(dynamo.ConflictError{}).
+		Once()
+
+	store := UnitOfWorkLpaStore(lpaStore)
+
+	err := WithLpaUnitOfWork(func(appData AppData, hw http.ResponseWriter, hr *http.Request) error {
+		lpa, _ := store.Get(hr.Context())
+
+		if hr.Method == http.MethodPost {
+			lpa.WantToSignLpa = true
+			store.Put(hr.Context(), lpa)
+			http.Redirect(hw, hr, "/next", http.StatusFound)
+			return nil
+		}
+
+		assert.True(t, appData.LpaChanged)
+		assert.True(t, lpa.WantToSignLpa)
+		assert.True(t, lpa.WantToApplyForLpa)
+		store.Put(hr.Context(), lpa)
+		hw.Write([]byte("changed"))
+		return nil
+	})(AppData{}, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "", resp.Header.Get("Location"))
+	assert.Equal(t, "changed", w.Body.String())
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestWithLpaUnitOfWorkWhenConflictStopsBeforeSideEffects(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequestWithContext(ContextWithSessionData(context.Background(), &SessionData{LpaID: "lpa-id"}), http.MethodGet, "/confirm?a=b", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&Lpa{ID: "lpa-id"}, nil).
+		Twice()
+	lpaStore.
+		On("Put", mock.Anything, mock.Anything).
+		Return(dynamo.ConflictError{}).
+		Once()
+
+	store := UnitOfWorkLpaStore(lpaStore)
+
+	sideEffects := 0
+	err := WithLpaUnitOfWork(func(appData AppData, hw http.ResponseWriter, hr *http.Request) error {
+		lpa, _ := store.Get(hr.Context())
+		lpa.WantToSignLpa = true
+		store.Put(hr.Context(), lpa)
+
+		if err := CommitLpa(hr.Context()); err != nil {
+			return err
+		}
+
+		sideEffects++
+		hw.Write([]byte("done"))
+		return nil
+	})(AppData{}, w, r)
+	resp := w.Result()
+
+	assert.Nil(t, err)
+	assert.Equal(t, 0, sideEffects)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/confirm?a=b", resp.Header.Get("Location"))
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestWithLpaUnitOfWorkWhenConflictDoesNotDelete(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequestWithContext(ContextWithSessionData(context.Background(), &SessionData{LpaID: "lpa-id"}), http.MethodGet, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&Lpa{ID: "lpa-id"}, nil).
+		Twice()
+	lpaStore.
+		On("Put", mock.Anything, mock.Anything).
+		Return(dynamo.ConflictError{}).
+		Once()
+
+	store := UnitOfWorkLpaStore(lpaStore)
+
+	err := WithLpaUnitOfWork(func(appData AppData, hw http.ResponseWriter, hr *http.Request) error {
+		lpa, _ := store.Get(hr.Context())
+
+		if appData.LpaChanged {
+			return store.Delete(hr.Context())
+		}
+
+		return store.Put(hr.Context(), lpa)
+	})(AppData{}, w, r)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusFound, w.Result().StatusCode)
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestWithLpaUnitOfWorkWhenConflictAndRereadErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequestWithContext(ContextWithSessionData(context.Background(), &SessionData{LpaID: "lpa-id"}), http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&Lpa{ID: "lpa-id"}, nil).
+		Once()
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&Lpa{}, expectedError).
+		Once()
+	lpaStore.
+		On("Put", mock.Anything, mock.Anything).
+		Return(dynamo.ConflictError{}).
+		Once()
+
+	store := UnitOfWorkLpaStore(lpaStore)
+
+	err := WithLpaUnitOfWork(func(appData AppData, hw http.ResponseWriter, hr *http.Request) error {
+		lpa, _ := store.Get(hr.Context())
+		return store.Put(hr.Context(), lpa)
+	})(AppData{}, w, r)
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestWithLpaUnitOfWorkWhenConflictAgain(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequestWithContext(ContextWithSessionData(context.Background(), &SessionData{LpaID: "lpa-id"}), http.MethodPost, "/", nil)
+
+	err := WithLpaUnitOfWork(func(appData AppData, hw http.ResponseWriter, hr *http.Request) error {
+		return dynamo.ConflictError{}
+	})(AppData{}, w, r)
+	resp := w.Result()
+
+	assert.Equal(t, dynamo.ConflictError{}, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "", w.Body.String())
+}
+
+func TestWithLpaUnitOfWorkWhenPutErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequestWithContext(ContextWithSessionData(context.Background(), &SessionData{LpaID: "lpa-id"}), http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&Lpa{ID: "lpa-id"}, nil)
+	lpaStore.
+		On("Put", mock.Anything, mock.Anything).
+		Return(expectedError)
+
+	store := UnitOfWorkLpaStore(lpaStore)
+
+	err := WithLpaUnitOfWork(func(appData AppData, hw http.ResponseWriter, hr *http.Request) error {
+		lpa, _ := store.Get(hr.Context())
+		store.Put(hr.Context(), lpa)
+		http.Redirect(hw, hr, "/next", http.StatusFound)
+		return nil
+	})(AppData{}, w, r)
+	resp := w.Result()
+
+	assert.Equal(t, expectedError, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "", resp.Header.Get("Location"))
+}
+
+func TestWithLpaUnitOfWorkWhenHandlerErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequestWithContext(ContextWithSessionData(context.Background(), &SessionData{LpaID: "lpa-id"}), http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&Lpa{ID: "lpa-id"}, nil)
+
+	store := UnitOfWorkLpaStore(lpaStore)
+
+	err := WithLpaUnitOfWork(func(appData AppData, hw http.ResponseWriter, hr *http.Request) error {
+		lpa, _ := store.Get(hr.Context())
+		store.Put(hr.Context(), lpa)
+		hw.Write([]byte("partial"))
+		return expectedError
+	})(AppData{}, w, r)
+
+	assert.Equal(t, expectedError, err)
+	assert.Equal(t, "", w.Body.String())
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestWithLpaUnitOfWorkWhenDeleted(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequestWithContext(ContextWithSessionData(context.Background(), &SessionData{LpaID: "lpa-id"}), http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&Lpa{ID: "lpa-id"}, nil)
+	lpaStore.
+		On("Delete", mock.Anything).
+		Return(nil)
+
+	store := UnitOfWorkLpaStore(lpaStore)
+
+	err := WithLpaUnitOfWork(func(appData AppData, hw http.ResponseWriter, hr *http.Request) error {
+		lpa, _ := store.Get(hr.Context())
+		store.Put(hr.Context(), lpa)
+		return store.Delete(hr.Context())
+	})(AppData{}, w, r)
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestUnitOfWorkLpaStoreWithoutUnitOfWork(t *testing.T) {
+	ctx := ContextWithSessionData(context.Background(), &SessionData{LpaID: "lpa-id"})
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", ctx).
+		Return(&Lpa{ID: "lpa-id"}, nil).
+		Twice()
+	lpaStore.
+		On("Put", ctx, &Lpa{ID: "lpa-id"}).
+		Return(nil)
+
+	store := UnitOfWorkLpaStore(lpaStore)
+	store.Get(ctx)
+	lpa, _ := store.Get(ctx)
+	assert.Nil(t, store.Put(ctx, lpa))
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestUnitOfWorkLpaStorePutOtherLpa(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequestWithContext(ContextWithSessionData(context.Background(), &SessionData{LpaID: "lpa-id"}), http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&Lpa{ID: "lpa-id"}, nil)
+	lpaStore.
+		On("Put", mock.Anything, &Lpa{ID: "other-id"}).
+		Return(nil)
+
+	store := UnitOfWorkLpaStore(lpaStore)
+
+	err := WithLpaUnitOfWork(func(appData AppData, hw http.ResponseWriter, hr *http.Request) error {
+		store.Get(hr.Context())
+		return store.Put(hr.Context(), &Lpa{ID: "other-id"})
+	})(AppData{}, w, r)
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+type mockLpaEventWriter struct {
+	mockLpaStore
+}
+
+func (m *mockLpaEventWriter) PutWithEvents(ctx context.Context, lpa *Lpa, events []event.Event) error {
+	return m.Called(ctx, lpa, events).Error(0)
+}
+
+type mockEventPublisher struct {
+	mock.Mock
+}
+
+func (m *mockEventPublisher) Publish(ctx context.Context, e event.Event) error {
+	return m.Called(ctx, e).Error(0)
+}
+
+func TestWithLpaUnitOfWorkWithEvents(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequestWithContext(ContextWithSessionData(context.Background(), &SessionData{LpaID: "lpa-id"}), http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaEventWriter{}
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&Lpa{ID: "lpa-id"}, nil)
+	lpaStore.
+		On("PutWithEvents", mock.Anything, &Lpa{ID: "lpa-id", WantToSignLpa: true}, []event.Event{event.LpaSigned{LpaID: "lpa-id"}}).
+		Return(nil)
+
+	store := UnitOfWorkLpaStore(lpaStore)
+	publisher := UnitOfWorkEventPublisher(&mockEventPublisher{})
+
+	err := WithLpaUnitOfWork(func(appData AppData, hw http.ResponseWriter, hr *http.Request) error {
+		lpa, _ := store.Get(hr.Context())
+		lpa.WantToSignLpa = true
+		store.Put(hr.Context(), lpa)
+		return publisher.Publish(hr.Context(), event.LpaSigned{LpaID: "lpa-id"})
+	})(AppData{}, w, r)
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestWithLpaUnitOfWorkWithEventsWhenNotEventWriter(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequestWithContext(ContextWithSessionData(context.Background(), &SessionData{LpaID: "lpa-id"}), http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&Lpa{ID: "lpa-id"}, nil)
+	lpaStore.
+		On("Put", mock.Anything, &Lpa{ID: "lpa-id"}).
+		Return(nil)
+
+	eventPublisher := &mockEventPublisher{}
+	eventPublisher.
+		On("Publish", mock.Anything, event.LpaSigned{LpaID: "lpa-id"}).
+		Return(nil)
+
+	store := UnitOfWorkLpaStore(lpaStore)
+	publisher := UnitOfWorkEventPublisher(eventPublisher)
+
+	err := WithLpaUnitOfWork(func(appData AppData, hw http.ResponseWriter, hr *http.Request) error {
+		lpa, _ := store.Get(hr.Context())
+		store.Put(hr.Context(), lpa)
+		return publisher.Publish(hr.Context(), event.LpaSigned{LpaID: "lpa-id"})
+	})(AppData{}, w, r)
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, lpaStore, eventPublisher)
+}
+
+func TestWithLpaUnitOfWorkWithEventsWhenPutWithEventsErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequestWithContext(ContextWithSessionData(context.Background(), &SessionData{LpaID: "lpa-id"}), http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaEventWriter{}
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&Lpa{ID: "lpa-id"}, nil)
+	lpaStore.
+		On("PutWithEvents", mock.Anything, mock.Anything, mock.Anything).
+		Return(expectedError)
+
+	store := UnitOfWorkLpaStore(lpaStore)
+	publisher := UnitOfWorkEventPublisher(&mockEventPublisher{})
+
+	err := WithLpaUnitOfWork(func(appData AppData, hw http.ResponseWriter, hr *http.Request) error {
+		lpa, _ := store.Get(hr.Context())
+		store.Put(hr.Context(), lpa)
+		hw.Write([]byte("done"))
+		return publisher.Publish(hr.Context(), event.LpaSigned{LpaID: "lpa-id"})
+	})(AppData{}, w, r)
+
+	assert.Equal(t, expectedError, err)
+	assert.Equal(t, "", w.Body.String())
+}
+
+func TestRunLpaUnitOfWork(t *testing.T) {
+	ctx := ContextWithSessionData(context.Background(), &SessionData{LpaID: "lpa-id"})
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&Lpa{ID: "lpa-id"}, nil).
+		Once()
+	lpaStore.
+		On("Put", mock.Anything, &Lpa{ID: "lpa-id", WantToSignLpa: true}).
+		Return(nil).
+		Once()
+
+	store := UnitOfWorkLpaStore(lpaStore)
+
+	err := RunLpaUnitOfWork(ctx, func(ctx context.Context) error {
+		lpa, _ := store.Get(ctx)
+		lpa.WantToSignLpa = true
+		store.Put(ctx, lpa)
+		store.Get(ctx)
+		return nil
+	})
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestRunLpaUnitOfWorkWhenErrors(t *testing.T) {
+	ctx := ContextWithSessionData(context.Background(), &SessionData{LpaID: "lpa-id"})
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&Lpa{ID: "lpa-id"}, nil)
+
+	store := UnitOfWorkLpaStore(lpaStore)
+
+	err := RunLpaUnitOfWork(ctx, func(ctx context.Context) error {
+		lpa, _ := store.Get(ctx)
+		store.Put(ctx, lpa)
+		return expectedError
+	})
+
+	assert.Equal(t, expectedError, err)
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestCommitLpa(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequestWithContext(ContextWithSessionData(context.Background(), &SessionData{LpaID: "lpa-id"}), http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&Lpa{ID: "lpa-id"}, nil).
+		Once()
+	lpaStore.
+		On("Put", mock.Anything, &Lpa{ID: "lpa-id", WantToSignLpa: true}).
+		Return(nil).
+		Once()
+	lpaStore.
+		On("Put", mock.Anything, &Lpa{ID: "lpa-id", WantToSignLpa: true, WantToApplyForLpa: true}).
+		Return(nil).
+		Once()
+
+	store := UnitOfWorkLpaStore(lpaStore)
+
+	err := WithLpaUnitOfWork(func(appData AppData, hw http.ResponseWriter, hr *http.Request) error {
+		lpa, _ := store.Get(hr.Context())
+		lpa.WantToSignLpa = true
+		store.Put(hr.Context(), lpa)
+
+		assert.Nil(t, CommitLpa(hr.Context()))
+		lpaStore.AssertNumberOfCalls(t, "Put", 1)
+
+		assert.Nil(t, CommitLpa(hr.Context()))
+		lpaStore.AssertNumberOfCalls(t, "Put", 1)
+
+		lpa, _ = store.Get(hr.Context())
+		lpa.WantToApplyForLpa = true
+		return store.Put(hr.Context(), lpa)
+	})(AppData{}, w, r)
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
+
+func TestCommitLpaWhenPutErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequestWithContext(ContextWithSessionData(context.Background(), &SessionData{LpaID: "lpa-id"}), http.MethodPost, "/", nil)
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&Lpa{ID: "lpa-id"}, nil)
+	lpaStore.
+		On("Put", mock.Anything, mock.Anything).
+		Return(expectedError)
+
+	store := UnitOfWorkLpaStore(lpaStore)
+
+	err := WithLpaUnitOfWork(func(appData AppData, hw http.ResponseWriter, hr *http.Request) error {
+		lpa, _ := store.Get(hr.Context())
+		store.Put(hr.Context(), lpa)
+
+		return CommitLpa(hr.Context())
+	})(AppData{}, w, r)
+
+	assert.Equal(t, expectedError, err)
+}
+
+func TestCommitLpaWithoutUnitOfWork(t *testing.T) {
+	assert.Nil(t, CommitLpa(context.Background()))
+}
+
+func TestWithLpaUnitOfWorkWritesWithSessionDataFromPut(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/", nil)
+
+	sessionData := &SessionData{LpaID: "lpa-id", ActorType: ActorTypeCertificateProvider, Subject: "a-sub"}
+
+	lpaStore := &mockLpaStore{}
+	lpaStore.
+		On("Get", mock.Anything).
+		Return(&Lpa{ID: "lpa-id"}, nil)
+	lpaStore.
+		On("Put", mock.MatchedBy(func(ctx context.Context) bool {
+			return SessionDataFromContext(ctx) == sessionData
+		}), mock.Anything).
+		Return(nil)
+
+	store := UnitOfWorkLpaStore(lpaStore)
+
+	err := WithLpaUnitOfWork(func(appData AppData, hw http.ResponseWriter, hr *http.Request) error {
+		ctx := ContextWithSessionData(hr.Context(), sessionData)
+
+		lpa, _ := store.Get(ctx)
+		return store.Put(ctx, lpa)
+	})(AppData{}, w, r)
+
+	assert.Nil(t, err)
+	mock.AssertExpectationsForObjects(t, lpaStore)
+}
@@ -0,0 +1,77 @@
+package page
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
+)
+
+var ErrMessageNotFound = errors.New("message not found")
+
+// A Message records a notification sent through GOV.UK Notify for an LPA, so
+// that the donor can see whether it reached the person it was sent to. ID is
+// the ID given by Notify, and Status is updated from its delivery receipts.
+type Message struct {
+	ID            string
+	LpaID         string
+	RecipientRole ActorType
+	Template      string
+	Status        string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	CompletedAt   time.Time
+	Version       int
+}
+
+func (m Message) Delivered() bool {
+	return m.Status == notify.StatusDelivered ||
+		m.Status == notify.StatusReceived
+}
+
+func (m Message) Failed() bool {
+	return m.Status == notify.StatusPermanentFailure ||
+		m.Status == notify.StatusTemporaryFailure ||
+		m.Status == notify.StatusTechnicalFailure ||
+		m.Status == notify.StatusCancelled ||
+		m.Status == notify.StatusValidationFailed
+}
+
+func (m Message) Pending() bool {
+	return !m.Delivered() && !m.Failed()
+}
+
+type MessageStore interface {
+	Put(ctx context.Context, message Message) error
+	GetAll(ctx context.Context) ([]Message, error)
+	UpdateStatus(ctx context.Context, id, status string, completedAt time.Time) error
+}
+
+// MessageByID returns the message with the ID given by Notify, or nil when there
+// is not one.
+func MessageByID(messages []Message, id string) *Message {
+	if id == "" {
+		return nil
+	}
+
+	for i := range messages {
+		if messages[i].ID == id {
+			return &messages[i]
+		}
+	}
+
+	return nil
+}
+
+// LatestMessage returns the most recently sent message using the template, or
+// nil when none has been sent. messages are expected oldest first.
+func LatestMessage(messages []Message, template notify.TemplateId) *Message {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Template == template.String() {
+			return &messages[i]
+		}
+	}
+
+	return nil
+}
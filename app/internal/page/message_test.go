@@ -0,0 +1,55 @@
+package page
+
+import (
+	"testing"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageStatus(t *testing.T) {
+	testCases := map[string]struct {
+		delivered, failed, pending bool
+	}{
+		notify.StatusCreated:          {pending: true},
+		notify.StatusSending:          {pending: true},
+		notify.StatusDelivered:        {delivered: true},
+		notify.StatusPermanentFailure: {failed: true},
+		notify.StatusTemporaryFailure: {failed: true},
+		notify.StatusTechnicalFailure: {failed: true},
+		notify.StatusAccepted:         {pending: true},
+		notify.StatusReceived:         {delivered: true},
+		notify.StatusCancelled:        {failed: true},
+		notify.StatusValidationFailed: {failed: true},
+	}
+
+	for status, tc := range testCases {
+		t.Run(status, func(t *testing.T) {
+			message := Message{Status: status}
+
+			assert.Equal(t, tc.delivered, message.Delivered())
+			assert.Equal(t, tc.failed, message.Failed())
+			assert.Equal(t, tc.pending, message.Pending())
+		})
+	}
+}
+
+func TestLatestMessage(t *testing.T) {
+	messages := []Message{
+		{ID: "1", Template: notify.CertificateProviderInviteEmail.String()},
+		{ID: "2", Template: notify.SignatureCodeSms.String()},
+		{ID: "3", Template: notify.CertificateProviderInviteEmail.String()},
+	}
+
+	assert.Equal(t, &messages[2], LatestMessage(messages, notify.CertificateProviderInviteEmail))
+	assert.Equal(t, &messages[1], LatestMessage(messages, notify.SignatureCodeSms))
+	assert.Nil(t, LatestMessage(messages, notify.SignatureCodeEmail))
+}
+
+func TestMessageByID(t *testing.T) {
+	messages := []Message{{ID: "1"}, {ID: "2"}}
+
+	assert.Equal(t, &messages[1], MessageByID(messages, "2"))
+	assert.Nil(t, MessageByID(messages, "3"))
+	assert.Nil(t, MessageByID(messages, ""))
+}
@@ -0,0 +1,57 @@
+package page
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
+)
+
+// NotifyCallback receives delivery receipts from GOV.UK Notify, updating the
+// status of the message they are for. Notify sends the token configured for
+// the callback as a bearer token, and retries any request that does not
+// succeed. A receipt can arrive before the message it is for has been saved,
+// or can be read from the index, so receipts for messages that are not
+// recognised are refused with a 404 to be sent again later.
+func NotifyCallback(logger Logger, token string, messageStore MessageStore) http.HandlerFunc {
+	expected := []byte("Bearer " + token)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		if token == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var receipt notify.DeliveryReceipt
+		if err := json.NewDecoder(r.Body).Decode(&receipt); err != nil || receipt.ID == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var completedAt time.Time
+		if receipt.CompletedAt != nil {
+			completedAt = *receipt.CompletedAt
+		}
+
+		if err := messageStore.UpdateStatus(r.Context(), receipt.ID, receipt.Status, completedAt); err != nil {
+			if errors.Is(err, ErrMessageNotFound) {
+				logger.Print(fmt.Sprintf("notify callback for unknown message %s", receipt.ID))
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			logger.Print(fmt.Sprintf("unable to update status of message %s: %s", receipt.ID, err.Error()))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package page
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testDeliveryReceipt = `{
+  "id": "notify-id",
+  "reference": "",
+  "to": "someone@example.com",
+  "status": "delivered",
+  "created_at": "2023-01-02T03:04:05.000000Z",
+  "completed_at": "2023-01-02T03:05:06.000000Z",
+  "sent_at": "2023-01-02T03:04:06.000000Z",
+  "notification_type": "email"
+}`
+
+type mockMessageStore struct {
+	mock.Mock
+}
+
+func (m *mockMessageStore) Put(ctx context.Context, message Message) error {
+	return m.Called(ctx, message).Error(0)
+}
+
+func (m *mockMessageStore) GetAll(ctx context.Context) ([]Message, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Message), args.Error(1)
+}
+
+func (m *mockMessageStore) UpdateStatus(ctx context.Context, id, status string, completedAt time.Time) error {
+	return m.Called(ctx, id, status, completedAt).Error(0)
+}
+
+func TestNotifyCallback(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/notify-callback", strings.NewReader(testDeliveryReceipt))
+	r.Header.Add("Authorization", "Bearer a-token")
+
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("UpdateStatus", r.Context(), "notify-id", notify.StatusDelivered, time.Date(2023, time.January, 2, 3, 5, 6, 0, time.UTC)).
+		Return(nil)
+
+	NotifyCallback(nil, "a-token", messageStore)(w, r)
+	resp := w.Result()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	mock.AssertExpectationsForObjects(t, messageStore)
+}
+
+func TestNotifyCallbackWhenNotPost(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/notify-callback", nil)
+	r.Header.Add("Authorization", "Bearer a-token")
+
+	NotifyCallback(nil, "a-token", nil)(w, r)
+	resp := w.Result()
+
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestNotifyCallbackWhenUnauthorized(t *testing.T) {
+	testCases := map[string]struct {
+		token  string
+		header string
+	}{
+		"missing":  {token: "a-token"},
+		"wrong":    {token: "a-token", header: "Bearer b-token"},
+		"no token": {header: "Bearer "},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodPost, "/notify-callback", strings.NewReader(testDeliveryReceipt))
+			if tc.header != "" {
+				r.Header.Add("Authorization", tc.header)
+			}
+
+			NotifyCallback(nil, tc.token, nil)(w, r)
+			resp := w.Result()
+
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		})
+	}
+}
+
+func TestNotifyCallbackWhenInvalidBody(t *testing.T) {
+	testCases := map[string]string{
+		"not json": `hey`,
+		"no id":    `{"status":"delivered"}`,
+	}
+
+	for name, body := range testCases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodPost, "/notify-callback", strings.NewReader(body))
+			r.Header.Add("Authorization", "Bearer a-token")
+
+			NotifyCallback(nil, "a-token", nil)(w, r)
+			resp := w.Result()
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+	}
+}
+
+func TestNotifyCallbackWhenMessageNotFound(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/notify-callback", strings.NewReader(testDeliveryReceipt))
+	r.Header.Add("Authorization", "Bearer a-token")
+
+	logger := &mockLogger{}
+	logger.
+		On("Print", "notify callback for unknown message notify-id")
+
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("UpdateStatus", r.Context(), mock.Anything, mock.Anything, mock.Anything).
+		Return(ErrMessageNotFound)
+
+	NotifyCallback(logger, "a-token", messageStore)(w, r)
+	resp := w.Result()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	mock.AssertExpectationsForObjects(t, logger, messageStore)
+}
+
+func TestNotifyCallbackWhenMessageStoreErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/notify-callback", strings.NewReader(testDeliveryReceipt))
+	r.Header.Add("Authorization", "Bearer a-token")
+
+	logger := &mockLogger{}
+	logger.
+		On("Print", "unable to update status of message notify-id: err")
+
+	messageStore := &mockMessageStore{}
+	messageStore.
+		On("UpdateStatus", r.Context(), mock.Anything, mock.Anything, mock.Anything).
+		Return(expectedError)
+
+	NotifyCallback(logger, "a-token", messageStore)(w, r)
+	resp := w.Result()
+
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	mock.AssertExpectationsForObjects(t, logger, messageStore)
+}
@@ -4,12 +4,23 @@ import "strings"
 
 type AppPaths struct {
 	AboutPayment                                         string
+	AttorneyIdentityNotConfirmed                         string
+	AttorneyLogin                                        string
+	AttorneyLoginCallback                                string
+	AttorneyReadTheLpa                                   string
+	AttorneySign                                         string
+	AttorneyStart                                        string
+	AttorneyWhatHappensNext                              string
 	Auth                                                 string
 	AuthRedirect                                         string
 	CertificateProviderAddress                           string
+	CertificateProviderCertificateProvided               string
 	CertificateProviderDetails                           string
+	CertificateProviderIdentityNotConfirmed              string
 	CertificateProviderLogin                             string
 	CertificateProviderLoginCallback                     string
+	CertificateProviderProvideCertificate                string
+	CertificateProviderReadTheLpa                        string
 	CertificateProviderStart                             string
 	CertificateProviderYourDetails                       string
 	CheckYourLpa                                         string
@@ -24,8 +35,10 @@ type AppPaths struct {
 	ChooseReplacementAttorneysSummary                    string
 	CookiesConsent                                       string
 	Dashboard                                            string
+	DeleteLpa                                            string
 	DoYouWantReplacementAttorneys                        string
 	DoYouWantToNotifyPeople                              string
+	EvidenceRequired                                     string
 	HealthCheck                                          string
 	HowDoYouKnowYourCertificateProvider                  string
 	HowLongHaveYouKnownCertificateProvider               string
@@ -44,13 +57,19 @@ type AppPaths struct {
 	IdentityWithPassport                                 string
 	IdentityWithYoti                                     string
 	IdentityWithYotiCallback                             string
+	LpaHistory                                           string
 	LpaType                                              string
+	NotifyCallback                                       string
 	PaymentConfirmation                                  string
+	PaymentFailed                                        string
+	PaymentPending                                       string
+	PendingEvidenceReview                                string
 	Progress                                             string
 	ReadYourLpa                                          string
 	RemoveAttorney                                       string
 	RemovePersonToNotify                                 string
 	RemoveReplacementAttorney                            string
+	ResendCertificateProviderInvite                      string
 	Restrictions                                         string
 	Root                                                 string
 	SelectYourIdentityOptions                            string
@@ -58,10 +77,12 @@ type AppPaths struct {
 	SelectYourIdentityOptions2                           string
 	SignYourLpa                                          string
 	Start                                                string
+	SupportLpaHistory                                    string
 	TaskList                                             string
 	TestingStart                                         string
 	WhatYoullNeedToConfirmYourIdentity                   string
 	WhenCanTheLpaBeUsed                                  string
+	WhichFeeTypeAreYouApplyingFor                        string
 	WhoDoYouWantToBeCertificateProviderGuidance          string
 	WhoIsTheLpaFor                                       string
 	WitnessingAsCertificateProvider                      string
@@ -75,12 +96,23 @@ type AppPaths struct {
 
 var Paths = AppPaths{
 	AboutPayment:                                         "/about-payment",
+	AttorneyIdentityNotConfirmed:                         "/attorney-identity-not-confirmed",
+	AttorneyLogin:                                        "/attorney-login",
+	AttorneyLoginCallback:                                "/attorney-login-callback",
+	AttorneyReadTheLpa:                                   "/attorney-read-the-lpa",
+	AttorneySign:                                         "/attorney-sign",
+	AttorneyStart:                                        "/attorney-start",
+	AttorneyWhatHappensNext:                              "/attorney-what-happens-next",
 	Auth:                                                 "/auth",
 	AuthRedirect:                                         "/auth/redirect",
 	CertificateProviderAddress:                           "/certificate-provider-address",
+	CertificateProviderCertificateProvided:               "/certificate-provided",
 	CertificateProviderDetails:                           "/certificate-provider-details",
+	CertificateProviderIdentityNotConfirmed:              "/certificate-provider-identity-not-confirmed",
 	CertificateProviderLogin:                             "/certificate-provider-login",
 	CertificateProviderLoginCallback:                     "/certificate-provider-login-callback",
+	CertificateProviderProvideCertificate:                "/provide-certificate",
+	CertificateProviderReadTheLpa:                        "/certificate-provider-read-the-lpa",
 	CertificateProviderStart:                             "/certificate-provider-start",
 	CertificateProviderYourDetails:                       "/certificate-provider-your-details",
 	CheckYourLpa:                                         "/check-your-lpa",
@@ -95,8 +127,10 @@ var Paths = AppPaths{
 	ChooseReplacementAttorneysSummary:                    "/choose-replacement-attorneys-summary",
 	CookiesConsent:                                       "/cookies-consent",
 	Dashboard:                                            "/dashboard",
+	DeleteLpa:                                            "/delete-lpa",
 	DoYouWantReplacementAttorneys:                        "/do-you-want-replacement-attorneys",
 	DoYouWantToNotifyPeople:                              "/do-you-want-to-notify-people",
+	EvidenceRequired:                                     "/evidence-required",
 	HealthCheck:                                          "/health-check",
 	HowDoYouKnowYourCertificateProvider:                  "/how-do-you-know-your-certificate-provider",
 	HowLongHaveYouKnownCertificateProvider:               "/how-long-have-you-known-certificate-provider",
@@ -115,13 +149,19 @@ var Paths = AppPaths{
 	IdentityWithPassport:                                 "/id/passport",
 	IdentityWithYoti:                                     "/id/yoti",
 	IdentityWithYotiCallback:                             "/id/yoti/callback",
+	LpaHistory:                                           "/lpa-history",
 	LpaType:                                              "/lpa-type",
+	NotifyCallback:                                       "/notify-callback",
 	PaymentConfirmation:                                  "/payment-confirmation",
+	PaymentFailed:                                        "/payment-failed",
+	PaymentPending:                                       "/payment-pending",
+	PendingEvidenceReview:                                "/pending-evidence-review",
 	Progress:                                             "/progress",
 	ReadYourLpa:                                          "/read-your-lpa",
 	RemoveAttorney:                                       "/remove-attorney",
 	RemovePersonToNotify:                                 "/remove-person-to-notify",
 	RemoveReplacementAttorney:                            "/remove-replacement-attorney",
+	ResendCertificateProviderInvite:                      "/resend-certificate-provider-invite",
 	Restrictions:                                         "/restrictions",
 	Root:                                                 "/",
 	SelectYourIdentityOptions1:                           "/select-your-identity-options-1",
@@ -129,10 +169,12 @@ var Paths = AppPaths{
 	SelectYourIdentityOptions:                            "/select-your-identity-options",
 	SignYourLpa:                                          "/sign-your-lpa",
 	Start:                                                "/start",
+	SupportLpaHistory:                                    "/support/lpa-history",
 	TaskList:                                             "/task-list",
 	TestingStart:                                         "/testing-start",
 	WhatYoullNeedToConfirmYourIdentity:                   "/what-youll-need-to-confirm-your-identity",
 	WhenCanTheLpaBeUsed:                                  "/when-can-the-lpa-be-used",
+	WhichFeeTypeAreYouApplyingFor:                        "/which-fee-type-are-you-applying-for",
 	WhoDoYouWantToBeCertificateProviderGuidance:          "/who-do-you-want-to-be-certificate-provider-guidance",
 	WhoIsTheLpaFor:                                       "/who-is-the-lpa-for",
 	WitnessingAsCertificateProvider:                      "/witnessing-as-certificate-provider",
@@ -149,5 +191,8 @@ func IsLpaPath(url string) bool {
 
 	return path != Paths.Auth && path != Paths.AuthRedirect &&
 		path != Paths.Dashboard && path != Paths.Start &&
-		path != Paths.CertificateProviderStart && path != Paths.CertificateProviderLogin && path != Paths.CertificateProviderLoginCallback && path != Paths.CertificateProviderYourDetails
+		path != Paths.CertificateProviderStart && path != Paths.CertificateProviderLogin && path != Paths.CertificateProviderLoginCallback && path != Paths.CertificateProviderIdentityNotConfirmed && path != Paths.CertificateProviderYourDetails &&
+		path != Paths.CertificateProviderReadTheLpa && path != Paths.CertificateProviderProvideCertificate && path != Paths.CertificateProviderCertificateProvided &&
+		path != Paths.AttorneyStart && path != Paths.AttorneyLogin && path != Paths.AttorneyIdentityNotConfirmed && path != Paths.AttorneyLoginCallback && path != Paths.AttorneyReadTheLpa && path != Paths.AttorneySign && path != Paths.AttorneyWhatHappensNext &&
+		path != Paths.SupportLpaHistory
 }
@@ -19,6 +19,10 @@ func TestIsLpaPath(t *testing.T) {
 			url:               Paths.Start + "?someQuery=6",
 			expectedIsLpaPage: false,
 		},
+		"support": {
+			url:               Paths.SupportLpaHistory + "?id=M-0000-0000-001X",
+			expectedIsLpaPage: false,
+		},
 		"any other page": {
 			url:               "/other?someQuery=7",
 			expectedIsLpaPage: true,
@@ -0,0 +1,75 @@
+package page
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// MaxPersonToNotifyNoticeAttempts is how many times sending a notice is tried
+// before giving up, after which the donor is shown that it could not be sent.
+const MaxPersonToNotifyNoticeAttempts = 5
+
+// A PersonToNotifyNotice records sending the statutory notice to one of the
+// people to notify. MessageID is the ID given by Notify once it has accepted
+// the notice, until then Attempts counts the tries that failed. Rejected is set
+// when Notify will not send to the person's address, so there is no point
+// trying again.
+type PersonToNotifyNotice struct {
+	PersonToNotifyID string
+	MessageID        string
+	Template         string
+	SentAt           time.Time
+	Attempts         int
+	LastAttemptAt    time.Time
+	LastError        string
+	Rejected         bool
+}
+
+func (n PersonToNotifyNotice) Sent() bool {
+	return n.MessageID != ""
+}
+
+// GaveUp is true when the notice could not be sent and will not be tried
+// again.
+func (n PersonToNotifyNotice) GaveUp() bool {
+	return !n.Sent() && (n.Rejected || n.Attempts >= MaxPersonToNotifyNoticeAttempts)
+}
+
+type PersonToNotifyNotices []PersonToNotifyNotice
+
+func (ns PersonToNotifyNotices) Get(personToNotifyID string) (PersonToNotifyNotice, bool) {
+	idx := slices.IndexFunc(ns, func(n PersonToNotifyNotice) bool { return n.PersonToNotifyID == personToNotifyID })
+	if idx == -1 {
+		return PersonToNotifyNotice{PersonToNotifyID: personToNotifyID}, false
+	}
+
+	return ns[idx], true
+}
+
+// Put replaces the notice for the same person to notify, or adds it if there
+// is not one.
+func (ns *PersonToNotifyNotices) Put(notice PersonToNotifyNotice) {
+	idx := slices.IndexFunc(*ns, func(n PersonToNotifyNotice) bool { return n.PersonToNotifyID == notice.PersonToNotifyID })
+	if idx == -1 {
+		*ns = append(*ns, notice)
+		return
+	}
+
+	(*ns)[idx] = notice
+}
+
+type NoticeStore interface {
+	PutPending(ctx context.Context) error
+	DeletePending(ctx context.Context) error
+	GetAllPending(ctx context.Context) ([]PendingNotices, error)
+}
+
+// PendingNotices marks an LPA that has notices to people to notify which could
+// not be sent, so that they can be tried again.
+type PendingNotices struct {
+	LpaID   string
+	Sub     string
+	Created time.Time
+}
@@ -0,0 +1,67 @@
+package page
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersonToNotifyNotice(t *testing.T) {
+	testCases := map[string]struct {
+		notice       PersonToNotifyNotice
+		sent, gaveUp bool
+	}{
+		"not tried": {},
+		"failed": {
+			notice: PersonToNotifyNotice{Attempts: 1},
+		},
+		"failed every attempt": {
+			notice: PersonToNotifyNotice{Attempts: MaxPersonToNotifyNoticeAttempts},
+			gaveUp: true,
+		},
+		"sent": {
+			notice: PersonToNotifyNotice{MessageID: "a", Attempts: 1},
+			sent:   true,
+		},
+		"rejected": {
+			notice: PersonToNotifyNotice{Attempts: 1, Rejected: true},
+			gaveUp: true,
+		},
+		"sent on last attempt": {
+			notice: PersonToNotifyNotice{MessageID: "a", Attempts: MaxPersonToNotifyNoticeAttempts},
+			sent:   true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.sent, tc.notice.Sent())
+			assert.Equal(t, tc.gaveUp, tc.notice.GaveUp())
+		})
+	}
+}
+
+func TestPersonToNotifyNoticesGet(t *testing.T) {
+	notices := PersonToNotifyNotices{{PersonToNotifyID: "1", MessageID: "a"}}
+
+	notice, ok := notices.Get("1")
+	assert.True(t, ok)
+	assert.Equal(t, PersonToNotifyNotice{PersonToNotifyID: "1", MessageID: "a"}, notice)
+
+	notice, ok = notices.Get("2")
+	assert.False(t, ok)
+	assert.Equal(t, PersonToNotifyNotice{PersonToNotifyID: "2"}, notice)
+}
+
+func TestPersonToNotifyNoticesPut(t *testing.T) {
+	var notices PersonToNotifyNotices
+
+	notices.Put(PersonToNotifyNotice{PersonToNotifyID: "1", Attempts: 1})
+	notices.Put(PersonToNotifyNotice{PersonToNotifyID: "2", Attempts: 1})
+	notices.Put(PersonToNotifyNotice{PersonToNotifyID: "1", MessageID: "a", Attempts: 2})
+
+	assert.Equal(t, PersonToNotifyNotices{
+		{PersonToNotifyID: "1", MessageID: "a", Attempts: 2},
+		{PersonToNotifyID: "2", Attempts: 1},
+	}, notices)
+}
@@ -0,0 +1,75 @@
+package support
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ministryofjustice/opg-go-common/template"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/reference"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/sesh"
+	"golang.org/x/exp/slices"
+)
+
+// Register adds the pages for OPG support users, who sign in with One Login in
+// the same way as a donor. Only those with an email address in supportEmails
+// can see the pages, everyone else is told they do not exist.
+func Register(
+	rootMux *http.ServeMux,
+	logger page.Logger,
+	tmpls template.Templates,
+	sessionStore sesh.Store,
+	lpaStore page.LpaStore,
+	supportEmails []string,
+) {
+	handle := makeHandle(rootMux, logger, sessionStore, supportEmails)
+
+	handle(page.Paths.SupportLpaHistory,
+		page.LpaHistory(tmpls.Get("lpa_history.gohtml"), lpaStore))
+}
+
+// makeHandle serves h for the LPA given by the id query parameter, so that a
+// support user can look up any LPA by its reference, or by the ID it was given
+// before LPAs had references.
+func makeHandle(mux *http.ServeMux, logger page.Logger, store sesh.Store, supportEmails []string) func(string, page.Handler) {
+	return func(path string, h page.Handler) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			session, err := sesh.Donor(store, r)
+			if err != nil {
+				logger.Print(err)
+				http.Redirect(w, r, page.Paths.Start, http.StatusFound)
+				return
+			}
+
+			if session.Email == "" || !slices.Contains(supportEmails, session.Email) {
+				http.NotFound(w, r)
+				return
+			}
+
+			lpaID := r.FormValue("id")
+			if !reference.Valid(lpaID) && !reference.ValidLegacy(lpaID) {
+				http.NotFound(w, r)
+				return
+			}
+
+			appData := page.AppDataFromContext(ctx)
+			appData.Page = path
+			appData.LpaID = lpaID
+
+			ctx = page.ContextWithSessionData(ctx, &page.SessionData{
+				LpaID:     lpaID,
+				ActorType: page.ActorTypeSupport,
+				Subject:   session.Sub,
+			})
+
+			if err := h(appData, w, r.WithContext(page.ContextWithAppData(ctx, appData))); err != nil {
+				str := fmt.Sprintf("Error rendering page for path '%s': %s", path, err.Error())
+
+				logger.Print(str)
+				http.Error(w, "Encountered an error", http.StatusInternalServerError)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package support
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/sesh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var expectedError = errors.New("err")
+
+type mockLogger struct {
+	mock.Mock
+}
+
+func (m *mockLogger) Print(v ...any) {
+	m.Called(v...)
+}
+
+type mockSessionsStore struct {
+	mock.Mock
+}
+
+func (m *mockSessionsStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	args := m.Called(r, name)
+	return args.Get(0).(*sessions.Session), args.Error(1)
+}
+
+func (m *mockSessionsStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	args := m.Called(r, name)
+	return args.Get(0).(*sessions.Session), args.Error(1)
+}
+
+func (m *mockSessionsStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	args := m.Called(r, w, session)
+	return args.Error(0)
+}
+
+func supportSession(email string) *sessions.Session {
+	return &sessions.Session{Values: map[any]any{"donor": &sesh.DonorSession{Sub: "random", Email: email}}}
+}
+
+func TestMakeHandle(t *testing.T) {
+	testcases := map[string]string{
+		"reference": "M-0000-0000-001X",
+		"legacy":    "1012345",
+	}
+
+	for name, lpaID := range testcases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodGet, "/path?id="+lpaID, nil)
+
+			sessionsStore := &mockSessionsStore{}
+			sessionsStore.
+				On("Get", r, "session").
+				Return(supportSession("support@example.com"), nil)
+
+			mux := http.NewServeMux()
+			handle := makeHandle(mux, nil, sessionsStore, []string{"other@example.com", "support@example.com"})
+			handle("/path", func(appData page.AppData, hw http.ResponseWriter, hr *http.Request) error {
+				assert.Equal(t, page.AppData{
+					Page:  "/path",
+					LpaID: lpaID,
+				}, appData)
+
+				assert.Equal(t, &page.SessionData{LpaID: lpaID, ActorType: page.ActorTypeSupport, Subject: "random"}, page.SessionDataFromContext(hr.Context()))
+				hw.WriteHeader(http.StatusTeapot)
+				return nil
+			})
+
+			mux.ServeHTTP(w, r)
+			resp := w.Result()
+
+			assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+			mock.AssertExpectationsForObjects(t, sessionsStore)
+		})
+	}
+}
+
+func TestMakeHandleWhenNotSupport(t *testing.T) {
+	testcases := map[string]*sessions.Session{
+		"not in list": supportSession("donor@example.com"),
+		"no email":    supportSession(""),
+	}
+
+	for name, session := range testcases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodGet, "/path?id=M-0000-0000-001X", nil)
+
+			sessionsStore := &mockSessionsStore{}
+			sessionsStore.
+				On("Get", r, "session").
+				Return(session, nil)
+
+			mux := http.NewServeMux()
+			handle := makeHandle(mux, nil, sessionsStore, []string{"support@example.com"})
+			handle("/path", func(appData page.AppData, hw http.ResponseWriter, hr *http.Request) error {
+				t.Fail()
+				return nil
+			})
+
+			mux.ServeHTTP(w, r)
+			resp := w.Result()
+
+			assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+			mock.AssertExpectationsForObjects(t, sessionsStore)
+		})
+	}
+}
+
+func TestMakeHandleWhenInvalidID(t *testing.T) {
+	testcases := map[string]string{
+		"missing":  "/path",
+		"checksum": "/path?id=M-0000-0000-0010",
+		"legacy":   "/path?id=2012345",
+		"other":    "/path?id=../x",
+	}
+
+	for name, url := range testcases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest(http.MethodGet, url, nil)
+
+			sessionsStore := &mockSessionsStore{}
+			sessionsStore.
+				On("Get", r, "session").
+				Return(supportSession("support@example.com"), nil)
+
+			mux := http.NewServeMux()
+			handle := makeHandle(mux, nil, sessionsStore, []string{"support@example.com"})
+			handle("/path", func(appData page.AppData, hw http.ResponseWriter, hr *http.Request) error {
+				t.Fail()
+				return nil
+			})
+
+			mux.ServeHTTP(w, r)
+			resp := w.Result()
+
+			assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		})
+	}
+}
+
+func TestMakeHandleErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/path?id=M-0000-0000-001X", nil)
+
+	sessionsStore := &mockSessionsStore{}
+	sessionsStore.
+		On("Get", r, "session").
+		Return(supportSession("support@example.com"), nil)
+
+	logger := &mockLogger{}
+	logger.
+		On("Print", fmt.Sprintf("Error rendering page for path '%s': %s", "/path", expectedError.Error()))
+
+	mux := http.NewServeMux()
+	handle := makeHandle(mux, logger, sessionsStore, []string{"support@example.com"})
+	handle("/path", func(appData page.AppData, hw http.ResponseWriter, hr *http.Request) error {
+		return expectedError
+	})
+
+	mux.ServeHTTP(w, r)
+	resp := w.Result()
+
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	mock.AssertExpectationsForObjects(t, logger)
+}
+
+func TestMakeHandleSessionMissing(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/path?id=M-0000-0000-001X", nil)
+
+	logger := &mockLogger{}
+	logger.
+		On("Print", sesh.MissingSessionError("donor"))
+
+	sessionsStore := &mockSessionsStore{}
+	sessionsStore.
+		On("Get", r, "session").
+		Return(&sessions.Session{Values: map[any]any{}}, nil)
+
+	mux := http.NewServeMux()
+	handle := makeHandle(mux, logger, sessionsStore, []string{"support@example.com"})
+	handle("/path", func(appData page.AppData, hw http.ResponseWriter, hr *http.Request) error { return nil })
+
+	mux.ServeHTTP(w, r)
+	resp := w.Result()
+
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, page.Paths.Start, resp.Header.Get("Location"))
+	mock.AssertExpectationsForObjects(t, sessionsStore, logger)
+}
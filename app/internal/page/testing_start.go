@@ -1,7 +1,6 @@
 package page
 
 import (
-	"encoding/base64"
 	"net/http"
 	"time"
 
@@ -15,11 +14,10 @@ import (
 func TestingStart(store sesh.Store, lpaStore LpaStore, randomString func(int) string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		sub := randomString(12)
-		sessionID := base64.StdEncoding.EncodeToString([]byte(sub))
 
 		_ = sesh.SetDonor(store, r, w, &sesh.DonorSession{Sub: sub, Email: "simulate-delivered@notifications.service.gov.uk"})
 
-		ctx := ContextWithSessionData(r.Context(), &SessionData{SessionID: sessionID})
+		ctx := ContextWithSessionData(r.Context(), &SessionData{ActorType: ActorTypeDonor, Subject: sub})
 
 		lpa, _ := lpaStore.Create(ctx)
 
@@ -170,12 +168,60 @@ func TestingStart(store sesh.Store, lpaStore LpaStore, randomString func(int) st
 		}
 
 		if r.FormValue("asCertificateProvider") == "1" {
+			certificateProviderSub := randomString(12)
+
+			_ = lpaStore.Link(ContextWithSessionData(r.Context(), &SessionData{
+				LpaID:     lpa.ID,
+				ActorType: ActorTypeCertificateProvider,
+				Subject:   certificateProviderSub,
+			}))
+
 			_ = sesh.SetCertificateProvider(store, r, w, &sesh.CertificateProviderSession{
-				Sub:            randomString(12),
-				Email:          "simulate-delivered@notifications.service.gov.uk",
-				DonorSessionID: sessionID,
-				LpaID:          lpa.ID,
+				Sub:   certificateProviderSub,
+				Email: "simulate-delivered@notifications.service.gov.uk",
+				LpaID: lpa.ID,
+			})
+
+			lpa.CertificateProviderUserData = identity.UserData{
+				OK:          true,
+				RetrievedAt: time.Date(2023, time.January, 2, 3, 4, 5, 6, time.UTC),
+				FullName:    "Barbara Smith",
+			}
+			_ = lpaStore.Put(ctx, lpa)
+		}
+
+		if r.FormValue("asAttorney") == "1" || r.FormValue("asReplacementAttorney") == "1" {
+			isReplacement := r.FormValue("asReplacementAttorney") == "1"
+
+			attorneyID := lpa.Attorneys[0].ID
+			if isReplacement {
+				attorneyID = lpa.ReplacementAttorneys[0].ID
+			}
+
+			attorneySub := randomString(12)
+
+			_ = lpaStore.Link(ContextWithSessionData(r.Context(), &SessionData{
+				LpaID:     lpa.ID,
+				ActorType: ActorTypeAttorney,
+				Subject:   attorneySub,
+			}))
+
+			_ = sesh.SetAttorney(store, r, w, &sesh.AttorneySession{
+				Sub:           attorneySub,
+				Email:         "simulate-delivered@notifications.service.gov.uk",
+				LpaID:         lpa.ID,
+				AttorneyID:    attorneyID,
+				IsReplacement: isReplacement,
+			})
+
+			lpa.PutAttorneyProvidedDetails(attorneyID, isReplacement, AttorneyProvidedDetails{
+				IdentityUserData: identity.UserData{
+					OK:          true,
+					RetrievedAt: time.Date(2023, time.January, 2, 3, 4, 5, 6, time.UTC),
+					FullName:    "Sam Smith",
+				},
 			})
+			_ = lpaStore.Put(ctx, lpa)
 		}
 
 		random.UseTestCode = true
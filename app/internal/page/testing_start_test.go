@@ -13,6 +13,7 @@ import (
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/date"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/identity"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/place"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/sesh"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -29,9 +30,9 @@ func (m *mockLpaStore) Create(ctx context.Context) (*Lpa, error) {
 	return args.Get(0).(*Lpa), args.Error(1)
 }
 
-func (m *mockLpaStore) GetAll(ctx context.Context) ([]*Lpa, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]*Lpa), args.Error(1)
+func (m *mockLpaStore) GetPage(ctx context.Context, query LpaQuery) (LpaPage, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(LpaPage), args.Error(1)
 }
 
 func (m *mockLpaStore) Get(ctx context.Context) (*Lpa, error) {
@@ -43,6 +44,24 @@ func (m *mockLpaStore) Put(ctx context.Context, v *Lpa) error {
 	return m.Called(ctx, v).Error(0)
 }
 
+func (m *mockLpaStore) Delete(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func (m *mockLpaStore) GetAllAs(ctx context.Context, actorType ActorType) ([]*Lpa, error) {
+	args := m.Called(ctx, actorType)
+	return args.Get(0).([]*Lpa), args.Error(1)
+}
+
+func (m *mockLpaStore) Link(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func (m *mockLpaStore) History(ctx context.Context) ([]LpaEvent, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]LpaEvent), args.Error(1)
+}
+
 type mockSessionsStore struct {
 	mock.Mock
 }
@@ -66,7 +85,7 @@ func TestTestingStart(t *testing.T) {
 	t.Run("payment not complete", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		r, _ := http.NewRequest(http.MethodGet, "/?redirect=/somewhere", nil)
-		ctx := ContextWithSessionData(r.Context(), &SessionData{SessionID: "MTIz"})
+		ctx := ContextWithSessionData(r.Context(), &SessionData{ActorType: ActorTypeDonor, Subject: "123"})
 
 		lpaStore := &mockLpaStore{}
 		lpaStore.
@@ -92,7 +111,7 @@ func TestTestingStart(t *testing.T) {
 	t.Run("payment complete", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		r, _ := http.NewRequest(http.MethodGet, "/?redirect=/somewhere&paymentComplete=1", nil)
-		ctx := ContextWithSessionData(r.Context(), &SessionData{SessionID: "MTIz"})
+		ctx := ContextWithSessionData(r.Context(), &SessionData{ActorType: ActorTypeDonor, Subject: "123"})
 
 		lpaStore := &mockLpaStore{}
 		lpaStore.
@@ -121,7 +140,7 @@ func TestTestingStart(t *testing.T) {
 	t.Run("with payment", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		r, _ := http.NewRequest(http.MethodGet, "/?redirect=/somewhere&withPayment=1", nil)
-		ctx := ContextWithSessionData(r.Context(), &SessionData{SessionID: "MTIz"})
+		ctx := ContextWithSessionData(r.Context(), &SessionData{ActorType: ActorTypeDonor, Subject: "123"})
 
 		sessionsStore := &mockSessionsStore{}
 		sessionsStore.
@@ -150,7 +169,7 @@ func TestTestingStart(t *testing.T) {
 	t.Run("with attorney", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		r, _ := http.NewRequest(http.MethodGet, "/?redirect=/somewhere&withAttorney=1", nil)
-		ctx := ContextWithSessionData(r.Context(), &SessionData{SessionID: "MTIz"})
+		ctx := ContextWithSessionData(r.Context(), &SessionData{ActorType: ActorTypeDonor, Subject: "123"})
 
 		sessionsStore := &mockSessionsStore{}
 		sessionsStore.
@@ -197,7 +216,7 @@ func TestTestingStart(t *testing.T) {
 	t.Run("with incomplete attorneys", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		r, _ := http.NewRequest(http.MethodGet, "/?redirect=/somewhere&withIncompleteAttorneys=1", nil)
-		ctx := ContextWithSessionData(r.Context(), &SessionData{SessionID: "MTIz"})
+		ctx := ContextWithSessionData(r.Context(), &SessionData{ActorType: ActorTypeDonor, Subject: "123"})
 
 		attorneys := actor.Attorneys{
 			{
@@ -262,7 +281,7 @@ func TestTestingStart(t *testing.T) {
 	t.Run("with attorneys", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		r, _ := http.NewRequest(http.MethodGet, "/?redirect=/somewhere&withAttorneys=1", nil)
-		ctx := ContextWithSessionData(r.Context(), &SessionData{SessionID: "MTIz"})
+		ctx := ContextWithSessionData(r.Context(), &SessionData{ActorType: ActorTypeDonor, Subject: "123"})
 
 		attorneys := actor.Attorneys{
 			{
@@ -337,7 +356,7 @@ func TestTestingStart(t *testing.T) {
 			t.Run(tc.DecisionsType, func(t *testing.T) {
 				w := httptest.NewRecorder()
 				r, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/?redirect=/somewhere&howAttorneysAct=%s", tc.DecisionsType), nil)
-				ctx := ContextWithSessionData(r.Context(), &SessionData{SessionID: "MTIz"})
+				ctx := ContextWithSessionData(r.Context(), &SessionData{ActorType: ActorTypeDonor, Subject: "123"})
 
 				sessionsStore := &mockSessionsStore{}
 				sessionsStore.
@@ -369,7 +388,7 @@ func TestTestingStart(t *testing.T) {
 	t.Run("with Certificate Provider", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		r, _ := http.NewRequest(http.MethodGet, "/?redirect=/somewhere&withCP=1", nil)
-		ctx := ContextWithSessionData(r.Context(), &SessionData{SessionID: "MTIz"})
+		ctx := ContextWithSessionData(r.Context(), &SessionData{ActorType: ActorTypeDonor, Subject: "123"})
 
 		sessionsStore := &mockSessionsStore{}
 		sessionsStore.
@@ -408,7 +427,7 @@ func TestTestingStart(t *testing.T) {
 	t.Run("with donor details", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		r, _ := http.NewRequest(http.MethodGet, "/?redirect=/somewhere&withDonorDetails=1", nil)
-		ctx := ContextWithSessionData(r.Context(), &SessionData{SessionID: "MTIz"})
+		ctx := ContextWithSessionData(r.Context(), &SessionData{ActorType: ActorTypeDonor, Subject: "123"})
 
 		sessionsStore := &mockSessionsStore{}
 		sessionsStore.
@@ -452,7 +471,7 @@ func TestTestingStart(t *testing.T) {
 	t.Run("with replacement attorneys", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		r, _ := http.NewRequest(http.MethodGet, "/?redirect=/somewhere&withReplacementAttorneys=1", nil)
-		ctx := ContextWithSessionData(r.Context(), &SessionData{SessionID: "MTIz"})
+		ctx := ContextWithSessionData(r.Context(), &SessionData{ActorType: ActorTypeDonor, Subject: "123"})
 
 		sessionsStore := &mockSessionsStore{}
 		sessionsStore.
@@ -514,7 +533,7 @@ func TestTestingStart(t *testing.T) {
 	t.Run("when can be used completed", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		r, _ := http.NewRequest(http.MethodGet, "/?redirect=/somewhere&whenCanBeUsedComplete=1", nil)
-		ctx := ContextWithSessionData(r.Context(), &SessionData{SessionID: "MTIz"})
+		ctx := ContextWithSessionData(r.Context(), &SessionData{ActorType: ActorTypeDonor, Subject: "123"})
 
 		sessionsStore := &mockSessionsStore{}
 		sessionsStore.
@@ -544,7 +563,7 @@ func TestTestingStart(t *testing.T) {
 	t.Run("with restrictions", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		r, _ := http.NewRequest(http.MethodGet, "/?redirect=/somewhere&withRestrictions=1", nil)
-		ctx := ContextWithSessionData(r.Context(), &SessionData{SessionID: "MTIz"})
+		ctx := ContextWithSessionData(r.Context(), &SessionData{ActorType: ActorTypeDonor, Subject: "123"})
 
 		sessionsStore := &mockSessionsStore{}
 		sessionsStore.
@@ -574,7 +593,7 @@ func TestTestingStart(t *testing.T) {
 	t.Run("with people to notify", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		r, _ := http.NewRequest(http.MethodGet, "/?redirect=/somewhere&withPeopleToNotify=1", nil)
-		ctx := ContextWithSessionData(r.Context(), &SessionData{SessionID: "MTIz"})
+		ctx := ContextWithSessionData(r.Context(), &SessionData{ActorType: ActorTypeDonor, Subject: "123"})
 
 		sessionsStore := &mockSessionsStore{}
 		sessionsStore.
@@ -632,7 +651,7 @@ func TestTestingStart(t *testing.T) {
 	t.Run("with incomplete people to notify", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		r, _ := http.NewRequest(http.MethodGet, "/?redirect=/somewhere&withIncompletePeopleToNotify=1", nil)
-		ctx := ContextWithSessionData(r.Context(), &SessionData{SessionID: "MTIz"})
+		ctx := ContextWithSessionData(r.Context(), &SessionData{ActorType: ActorTypeDonor, Subject: "123"})
 
 		sessionsStore := &mockSessionsStore{}
 		sessionsStore.
@@ -670,7 +689,7 @@ func TestTestingStart(t *testing.T) {
 	t.Run("lpa checked", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		r, _ := http.NewRequest(http.MethodGet, "/?redirect=/somewhere&lpaChecked=1", nil)
-		ctx := ContextWithSessionData(r.Context(), &SessionData{SessionID: "MTIz"})
+		ctx := ContextWithSessionData(r.Context(), &SessionData{ActorType: ActorTypeDonor, Subject: "123"})
 
 		sessionsStore := &mockSessionsStore{}
 		sessionsStore.
@@ -701,7 +720,7 @@ func TestTestingStart(t *testing.T) {
 	t.Run("id confirmed and signed", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		r, _ := http.NewRequest(http.MethodGet, "/?redirect=/somewhere&idConfirmedAndSigned=1", nil)
-		ctx := ContextWithSessionData(r.Context(), &SessionData{SessionID: "MTIz"})
+		ctx := ContextWithSessionData(r.Context(), &SessionData{ActorType: ActorTypeDonor, Subject: "123"})
 
 		sessionsStore := &mockSessionsStore{}
 		sessionsStore.
@@ -739,7 +758,7 @@ func TestTestingStart(t *testing.T) {
 	t.Run("complete LPA", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		r, _ := http.NewRequest(http.MethodGet, "/?redirect=/somewhere&completeLpa=1", nil)
-		ctx := ContextWithSessionData(r.Context(), &SessionData{SessionID: "MTIz"})
+		ctx := ContextWithSessionData(r.Context(), &SessionData{ActorType: ActorTypeDonor, Subject: "123"})
 
 		sessionsStore := &mockSessionsStore{}
 		sessionsStore.
@@ -906,4 +925,97 @@ func TestTestingStart(t *testing.T) {
 		assert.Equal(t, "/lpa/123/somewhere", resp.Header.Get("Location"))
 		mock.AssertExpectationsForObjects(t, sessionsStore, lpaStore)
 	})
+
+	t.Run("as certificate provider", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodGet, "/?redirect=/certificate-provider-your-details&asCertificateProvider=1", nil)
+		ctx := ContextWithSessionData(r.Context(), &SessionData{ActorType: ActorTypeDonor, Subject: "123"})
+
+		lpaStore := &mockLpaStore{}
+		lpaStore.
+			On("Create", ctx).
+			Return(&Lpa{ID: "123"}, nil)
+		lpaStore.
+			On("Put", ctx, mock.Anything).
+			Return(nil)
+		lpaStore.
+			On("Link", mock.MatchedBy(func(ctx context.Context) bool {
+				return assert.Equal(t, &SessionData{LpaID: "123", ActorType: ActorTypeCertificateProvider, Subject: "123"}, SessionDataFromContext(ctx))
+			})).
+			Return(nil)
+
+		sessionsStore := &mockSessionsStore{}
+		sessionsStore.
+			On("Save", r, w, mock.MatchedBy(func(session *sessions.Session) bool {
+				return session.Name() == "session"
+			})).
+			Return(nil).
+			Once()
+		sessionsStore.
+			On("Save", r, w, mock.MatchedBy(func(session *sessions.Session) bool {
+				certificateProviderSession, ok := session.Values["certificate-provider"].(*sesh.CertificateProviderSession)
+
+				return ok && assert.Equal(t, &sesh.CertificateProviderSession{
+					Sub:   "123",
+					Email: "simulate-delivered@notifications.service.gov.uk",
+					LpaID: "123",
+				}, certificateProviderSession)
+			})).
+			Return(nil).
+			Once()
+
+		TestingStart(sessionsStore, lpaStore, mockRandom).ServeHTTP(w, r)
+		resp := w.Result()
+
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+		assert.Equal(t, Paths.CertificateProviderYourDetails, resp.Header.Get("Location"))
+		mock.AssertExpectationsForObjects(t, sessionsStore, lpaStore)
+	})
+
+	t.Run("as attorney", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodGet, "/?redirect=/attorney-sign&withAttorney=1&asAttorney=1", nil)
+		ctx := ContextWithSessionData(r.Context(), &SessionData{ActorType: ActorTypeDonor, Subject: "123"})
+
+		lpaStore := &mockLpaStore{}
+		lpaStore.
+			On("Create", ctx).
+			Return(&Lpa{ID: "123"}, nil)
+		lpaStore.
+			On("Put", ctx, mock.Anything).
+			Return(nil)
+		lpaStore.
+			On("Link", mock.MatchedBy(func(ctx context.Context) bool {
+				return assert.Equal(t, &SessionData{LpaID: "123", ActorType: ActorTypeAttorney, Subject: "123"}, SessionDataFromContext(ctx))
+			})).
+			Return(nil)
+
+		sessionsStore := &mockSessionsStore{}
+		sessionsStore.
+			On("Save", r, w, mock.MatchedBy(func(session *sessions.Session) bool {
+				return session.Name() == "session"
+			})).
+			Return(nil).
+			Once()
+		sessionsStore.
+			On("Save", r, w, mock.MatchedBy(func(session *sessions.Session) bool {
+				attorneySession, ok := session.Values["attorney"].(*sesh.AttorneySession)
+
+				return ok && assert.Equal(t, &sesh.AttorneySession{
+					Sub:        "123",
+					Email:      "simulate-delivered@notifications.service.gov.uk",
+					LpaID:      "123",
+					AttorneyID: "JohnSmith",
+				}, attorneySession)
+			})).
+			Return(nil).
+			Once()
+
+		TestingStart(sessionsStore, lpaStore, mockRandom).ServeHTTP(w, r)
+		resp := w.Result()
+
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+		assert.Equal(t, Paths.AttorneySign, resp.Header.Get("Location"))
+		mock.AssertExpectationsForObjects(t, sessionsStore, lpaStore)
+	})
 }
@@ -1,21 +1,49 @@
 package pay
 
+import "time"
+
 const (
 	PaymentPublicServiceUrl = "https://www.payments.service.gov.uk"
 )
 
+// The statuses a payment moves through, see
+// https://docs.payments.service.gov.uk/api_reference/#payment-status-lifecycle
+const (
+	StatusCreated    = "created"
+	StatusStarted    = "started"
+	StatusSubmitted  = "submitted"
+	StatusCapturable = "capturable"
+	StatusSuccess    = "success"
+	StatusFailed     = "failed"
+	StatusCancelled  = "cancelled"
+	StatusError      = "error"
+)
+
 type CreatePaymentBody struct {
-	Amount      int    `json:"amount"`
-	Reference   string `json:"reference"`
-	Description string `json:"description"`
-	ReturnUrl   string `json:"return_url"`
-	Email       string `json:"email"`
-	Language    string `json:"language"`
+	Amount      int               `json:"amount"`
+	Reference   string            `json:"reference"`
+	Description string            `json:"description"`
+	ReturnUrl   string            `json:"return_url"`
+	Email       string            `json:"email"`
+	Language    string            `json:"language"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
 }
 
 type State struct {
 	Status   string `json:"status"`
 	Finished bool   `json:"finished"`
+	Code     string `json:"code,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// InProgress reports whether the payment has yet to reach a final status.
+func (s State) InProgress() bool {
+	switch s.Status {
+	case StatusSuccess, StatusFailed, StatusCancelled, StatusError:
+		return false
+	default:
+		return true
+	}
 }
 
 type Link struct {
@@ -73,6 +101,13 @@ type SettlementSummary struct {
 	SettledDate       string `json:"settled_date"`
 }
 
+// SettledAt returns the date the payment was settled, or the zero time if it
+// has not been.
+func (s SettlementSummary) SettledAt() time.Time {
+	t, _ := time.Parse("2006-01-02", s.SettledDate)
+	return t
+}
+
 type GetPaymentResponse struct {
 	CreatedDate GovUKPayTime `json:"created_date"`
 	Amount      int          `json:"amount"`
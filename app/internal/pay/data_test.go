@@ -0,0 +1,32 @@
+package pay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateInProgress(t *testing.T) {
+	testCases := map[string]bool{
+		StatusCreated:    true,
+		StatusStarted:    true,
+		StatusSubmitted:  true,
+		StatusCapturable: true,
+		StatusSuccess:    false,
+		StatusFailed:     false,
+		StatusCancelled:  false,
+		StatusError:      false,
+	}
+
+	for status, inProgress := range testCases {
+		t.Run(status, func(t *testing.T) {
+			assert.Equal(t, inProgress, State{Status: status}.InProgress())
+		})
+	}
+}
+
+func TestSettlementSummarySettledAt(t *testing.T) {
+	assert.Equal(t, time.Date(2022, time.July, 28, 0, 0, 0, 0, time.UTC), SettlementSummary{SettledDate: "2022-07-28"}.SettledAt())
+	assert.True(t, SettlementSummary{}.SettledAt().IsZero())
+}
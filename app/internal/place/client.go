@@ -115,25 +115,30 @@ func (a Address) Encode() string {
 }
 
 func (a Address) String() string {
-	var parts []string
+	return strings.Join(a.Lines(), ", ")
+}
+
+// Lines gives the parts of the address that are not empty, in order.
+func (a Address) Lines() []string {
+	var lines []string
 
 	if a.Line1 != "" {
-		parts = append(parts, a.Line1)
+		lines = append(lines, a.Line1)
 	}
 	if a.Line2 != "" {
-		parts = append(parts, a.Line2)
+		lines = append(lines, a.Line2)
 	}
 	if a.Line3 != "" {
-		parts = append(parts, a.Line3)
+		lines = append(lines, a.Line3)
 	}
 	if a.TownOrCity != "" {
-		parts = append(parts, a.TownOrCity)
+		lines = append(lines, a.TownOrCity)
 	}
 	if a.Postcode != "" {
-		parts = append(parts, a.Postcode)
+		lines = append(lines, a.Postcode)
 	}
 
-	return strings.Join(parts, ", ")
+	return lines
 }
 
 func (ad *addressDetails) transformToAddress() Address {
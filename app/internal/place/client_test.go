@@ -179,6 +179,16 @@ func TestAddress(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("Lines", func(t *testing.T) {
+		assert.Equal(t, []string{"Line 1", "Line 3", "Town", "Postcode"}, Address{
+			Line1:      "Line 1",
+			Line3:      "Line 3",
+			TownOrCity: "Town",
+			Postcode:   "Postcode",
+		}.Lines())
+		assert.Nil(t, Address{}.Lines())
+	})
 }
 
 func TestTransformAddressDetailsToAddress(t *testing.T) {
@@ -0,0 +1,136 @@
+// Package reference generates and validates the references used to identify
+// LPAs, in the format M-XXXX-XXXX-XXXX.
+//
+// The final character of a reference is a check character calculated with the
+// Luhn mod N algorithm, so that any single mistyped character, and most
+// transpositions of adjacent characters, are detected without needing to look
+// the reference up.
+package reference
+
+import (
+	"crypto/rand"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// charset excludes I, O, S and Z so that references can't be confused with
+// the digits 1, 0, 5 and 2. Its length divides 256, so taking a random byte
+// modulo its length gives each character with equal probability.
+const charset = "0123456789ABCDEFGHJKLMNPQRTUVWXY"
+
+const (
+	prefix      = "M"
+	groups      = 3
+	groupLength = 4
+	length      = groups * groupLength
+)
+
+var ErrInvalid = errors.New("reference is invalid")
+
+// legacy matches the IDs given to LPAs before they were given references.
+var legacy = regexp.MustCompile(`^10[0-9]{1,5}$`)
+
+// confusable maps characters that are not in charset to those they are likely
+// to have been mistaken for.
+var confusable = strings.NewReplacer("I", "1", "O", "0", "S", "5", "Z", "2")
+
+// Generate returns a new random reference.
+func Generate() string {
+	bytes := make([]byte, length-1)
+	if _, err := rand.Read(bytes); err != nil {
+		panic(err)
+	}
+
+	code := make([]byte, length-1, length)
+	for i, b := range bytes {
+		code[i] = charset[int(b)%len(charset)]
+	}
+
+	return format(append(code, checkCharacter(code)))
+}
+
+// Valid reports whether s is a reference in its canonical format with a
+// correct check character.
+func Valid(s string) bool {
+	if !strings.HasPrefix(s, prefix+"-") {
+		return false
+	}
+
+	code := strings.ReplaceAll(strings.TrimPrefix(s, prefix+"-"), "-", "")
+
+	return len(code) == length && format([]byte(code)) == s && checksum([]byte(code)) == 0
+}
+
+// ValidLegacy reports whether s is an ID given to an LPA before LPAs were given
+// references.
+func ValidLegacy(s string) bool {
+	return legacy.MatchString(s)
+}
+
+// Parse accepts a reference as a person might type it, ignoring case,
+// whitespace and hyphens, and returns the reference in its canonical format.
+func Parse(s string) (string, error) {
+	s = strings.ToUpper(strings.Join(strings.Fields(s), ""))
+	s = strings.ReplaceAll(s, "-", "")
+
+	code := strings.TrimPrefix(s, prefix)
+	if len(code) != length || code == s {
+		return "", ErrInvalid
+	}
+
+	ref := format([]byte(confusable.Replace(code)))
+	if !Valid(ref) {
+		return "", ErrInvalid
+	}
+
+	return ref, nil
+}
+
+func format(code []byte) string {
+	var sb strings.Builder
+	sb.WriteString(prefix)
+
+	for i := 0; i < len(code); i += groupLength {
+		end := i + groupLength
+		if end > len(code) {
+			end = len(code)
+		}
+
+		sb.WriteByte('-')
+		sb.Write(code[i:end])
+	}
+
+	return sb.String()
+}
+
+func checkCharacter(code []byte) byte {
+	return charset[(len(charset)-luhn(code, 2))%len(charset)]
+}
+
+func checksum(code []byte) int {
+	return luhn(code, 1)
+}
+
+// luhn sums the code points of code from the right, doubling every other one
+// starting with the given factor, and returns the sum modulo len(charset). An
+// unknown character makes the sum non-zero.
+func luhn(code []byte, factor int) int {
+	n := len(charset)
+	sum := 0
+
+	for i := len(code) - 1; i >= 0; i-- {
+		codePoint := strings.IndexByte(charset, code[i])
+		if codePoint < 0 {
+			return -1
+		}
+
+		addend := factor * codePoint
+		addend = addend/n + addend%n
+		sum += addend
+
+		factor = 3 - factor
+	}
+
+	return sum % n
+}
@@ -0,0 +1,122 @@
+package reference
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	format := regexp.MustCompile(`^M-[0-9A-HJ-NP-RT-Y]{4}-[0-9A-HJ-NP-RT-Y]{4}-[0-9A-HJ-NP-RT-Y]{4}$`)
+
+	seen := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		ref := Generate()
+
+		assert.Regexp(t, format, ref)
+		assert.True(t, Valid(ref), ref)
+		assert.False(t, seen[ref], ref)
+		seen[ref] = true
+	}
+}
+
+func TestCheckCharacter(t *testing.T) {
+	assert.Equal(t, byte('0'), checkCharacter([]byte("00000000000")))
+	assert.Equal(t, byte('X'), checkCharacter([]byte("00000000001")))
+	assert.Equal(t, byte('Y'), checkCharacter([]byte("00000000010")))
+}
+
+func TestValid(t *testing.T) {
+	testCases := map[string]bool{
+		"M-0000-0000-0000":  true,
+		"M-0000-0000-001X":  true,
+		"M-0000-0000-0010":  false,
+		"M-0000-0000-010X":  false,
+		"M-0000-0000-00X1":  false,
+		"m-0000-0000-001X":  false,
+		"M-000000000-01Y":   false,
+		"M-0000-0000-001":   false,
+		"M-0000-0000-001XY": false,
+		"M-0000-0000-00IY":  false,
+		"0000-0000-001X":    false,
+		"1012345":           false,
+		"":                  false,
+	}
+
+	for ref, valid := range testCases {
+		t.Run(ref, func(t *testing.T) {
+			assert.Equal(t, valid, Valid(ref))
+		})
+	}
+}
+
+func TestValidLegacy(t *testing.T) {
+	testCases := map[string]bool{
+		"101":              true,
+		"1012345":          true,
+		"10123456":         false,
+		"10":               false,
+		"2012345":          false,
+		"10a":              false,
+		"M-0000-0000-001X": false,
+		"":                 false,
+	}
+
+	for id, valid := range testCases {
+		t.Run(id, func(t *testing.T) {
+			assert.Equal(t, valid, ValidLegacy(id))
+		})
+	}
+}
+
+func TestValidDetectsSingleCharacterErrors(t *testing.T) {
+	ref := Generate()
+
+	for i := 2; i < len(ref); i++ {
+		if ref[i] == '-' {
+			continue
+		}
+
+		for _, c := range []byte(charset) {
+			if c == ref[i] {
+				continue
+			}
+
+			mistyped := []byte(ref)
+			mistyped[i] = c
+
+			assert.False(t, Valid(string(mistyped)), string(mistyped))
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	testCases := map[string]string{
+		"M-0000-0000-001X":   "M-0000-0000-001X",
+		"m-0000-0000-001x":   "M-0000-0000-001X",
+		" M 0000 0000 001X ": "M-0000-0000-001X",
+		"M00000000001X":      "M-0000-0000-001X",
+		"M-OOOO-OOOO-OO1X":   "M-0000-0000-001X",
+		"M-0000-0000-0I0Y":   "M-0000-0000-010Y",
+	}
+
+	for input, expected := range testCases {
+		t.Run(input, func(t *testing.T) {
+			ref, err := Parse(input)
+
+			assert.Nil(t, err)
+			assert.Equal(t, expected, ref)
+		})
+	}
+}
+
+func TestParseWhenInvalid(t *testing.T) {
+	for _, input := range []string{"", "M", "M-0000-0000-0010", "X-0000-0000-001X", "M-0000-0000-001", "1012345"} {
+		t.Run(input, func(t *testing.T) {
+			_, err := Parse(input)
+
+			assert.Equal(t, ErrInvalid, err)
+		})
+	}
+}
@@ -14,6 +14,7 @@ import (
 
 const (
 	GovUkNotify                    = "gov-uk-notify-api-key"
+	GovUkNotifyCallbackToken       = "gov-uk-notify-callback-token"
 	GovUkPay                       = "gov-uk-pay-api-key"
 	GovUkOneLoginPrivateKey        = "private-jwt-key-base64"
 	GovUkOneLoginIdentityPublicKey = "gov-uk-onelogin-identity-public-key"
@@ -21,6 +22,7 @@ const (
 	YotiPrivateKey                 = "yoti-private-key"
 
 	cookieSessionKeys = "cookie-session-keys"
+	lpaEncryptionKeys = "lpa-encryption-keys"
 
 	delay = time.Second
 )
@@ -106,7 +108,19 @@ func (c *Client) SecretBytes(ctx context.Context, name string) ([]byte, error) {
 }
 
 func (c *Client) CookieSessionKeys(ctx context.Context) ([][]byte, error) {
-	secret, err := c.Secret(ctx, cookieSessionKeys)
+	return c.keys(ctx, cookieSessionKeys)
+}
+
+// LpaEncryptionKeys returns the keys used to wrap the data keys that encrypt
+// personal data in LPAs. The first key is current, the rest are kept so that
+// data encrypted before they were rotated can still be read.
+func (c *Client) LpaEncryptionKeys(ctx context.Context) ([][]byte, error) {
+	return c.keys(ctx, lpaEncryptionKeys)
+}
+
+// keys reads a secret holding a JSON list of base64 encoded keys.
+func (c *Client) keys(ctx context.Context, name string) ([][]byte, error) {
+	secret, err := c.Secret(ctx, name)
 	if err != nil {
 		return nil, err
 	}
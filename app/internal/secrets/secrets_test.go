@@ -256,3 +256,16 @@ func TestCookieSessionKeysNotBase64(t *testing.T) {
 	_, err := c.CookieSessionKeys(ctx)
 	assert.NotNil(t, err)
 }
+
+func TestLpaEncryptionKeys(t *testing.T) {
+	secretsManager := &mockSecretsManager{}
+	secretsManager.
+		On("GetSecretValue", ctx, "lpa-encryption-keys").
+		Return(`["aGV5","YW5vdGhlcg=="]`, nil)
+
+	c := &Client{svc: secretsManager, cache: map[string]*cacheItem{}}
+
+	result, err := c.LpaEncryptionKeys(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{[]byte("hey"), []byte("another")}, result)
+}
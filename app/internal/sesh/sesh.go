@@ -52,6 +52,7 @@ func init() {
 	gob.Register(&OneLoginSession{})
 	gob.Register(&DonorSession{})
 	gob.Register(&CertificateProviderSession{})
+	gob.Register(&AttorneySession{})
 	gob.Register(&PaymentSession{})
 }
 
@@ -61,14 +62,20 @@ type OneLoginSession struct {
 	Locale              string
 	Identity            bool
 	CertificateProvider bool
-	SessionID           string
+	Attorney            bool
+	ShareCode           string
 	LpaID               string
+	AttorneyID          string
+	IsReplacement       bool
 }
 
 func (s OneLoginSession) Valid() bool {
 	ok := s.State != "" && s.Nonce != ""
 	if s.CertificateProvider {
-		ok = ok && s.SessionID != "" && s.LpaID != ""
+		ok = ok && s.ShareCode != "" && s.LpaID != ""
+	}
+	if s.Attorney {
+		ok = ok && s.ShareCode != "" && s.LpaID != "" && s.AttorneyID != ""
 	}
 
 	return ok
@@ -142,10 +149,9 @@ func SetDonor(store sessions.Store, r *http.Request, w http.ResponseWriter, dono
 }
 
 type CertificateProviderSession struct {
-	Sub            string
-	Email          string
-	LpaID          string
-	DonorSessionID string
+	Sub   string
+	Email string
+	LpaID string
 }
 
 func (s CertificateProviderSession) Valid() bool {
@@ -181,6 +187,47 @@ func SetCertificateProvider(store sessions.Store, r *http.Request, w http.Respon
 	return store.Save(r, w, session)
 }
 
+type AttorneySession struct {
+	Sub           string
+	Email         string
+	LpaID         string
+	AttorneyID    string
+	IsReplacement bool
+}
+
+func (s AttorneySession) Valid() bool {
+	return s.Sub != "" && s.AttorneyID != ""
+}
+
+func Attorney(store sessions.Store, r *http.Request) (*AttorneySession, error) {
+	params, err := store.Get(r, "session")
+	if err != nil {
+		return nil, err
+	}
+
+	session, ok := params.Values["attorney"]
+	if !ok {
+		return nil, MissingSessionError("attorney")
+	}
+
+	attorneySession, ok := session.(*AttorneySession)
+	if !ok {
+		return nil, MissingSessionError("attorney")
+	}
+	if !attorneySession.Valid() {
+		return nil, InvalidSessionError("attorney")
+	}
+
+	return attorneySession, nil
+}
+
+func SetAttorney(store sessions.Store, r *http.Request, w http.ResponseWriter, attorneySession *AttorneySession) error {
+	session := sessions.NewSession(store, "session")
+	session.Values = map[any]any{"attorney": attorneySession}
+	session.Options = sessionCookieOptions
+	return store.Save(r, w, session)
+}
+
 type PaymentSession struct {
 	PaymentID string
 }
@@ -35,12 +35,14 @@ var All = map[string]interface{}{
 	"addDays":            addDays,
 	"formatDate":         formatDate,
 	"formatDateTime":     formatDateTime,
+	"formatPence":        formatPence,
 	"lowerFirst":         lowerFirst,
 	"listAttorneys":      listAttorneys,
 	"warning":            warning,
 	"listPeopleToNotify": listPeopleToNotify,
 	"progressBar":        progressBar,
 	"peopleNamedOnLpa":   peopleNamedOnLpa,
+	"messageStatus":      messageStatus,
 }
 
 func isEnglish(lang localize.Lang) bool {
@@ -236,6 +238,10 @@ func formatDateTime(t time.Time) string {
 	return t.Format("2 January 2006 at 15:04")
 }
 
+func formatPence(pence int) string {
+	return fmt.Sprintf("£%d.%02d", pence/100, pence%100)
+}
+
 func lowerFirst(s string) string {
 	r, n := utf8.DecodeRuneInString(s)
 	return string(unicode.ToLower(r)) + s[n:]
@@ -292,3 +298,12 @@ func peopleNamedOnLpa(app page.AppData, lpa *page.Lpa, showPeopleHeaders bool) m
 		"ShowPeopleHeaders": showPeopleHeaders,
 	}
 }
+
+func messageStatus(app page.AppData, message *page.Message, label, resendPath string) map[string]interface{} {
+	return map[string]interface{}{
+		"App":        app,
+		"Message":    message,
+		"Label":      label,
+		"ResendPath": resendPath,
+	}
+}
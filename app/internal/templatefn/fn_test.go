@@ -243,6 +243,12 @@ func TestFormatDateTime(t *testing.T) {
 	assert.Equal(t, "7 March 2020 at 03:04", formatDateTime(time.Date(2020, time.March, 7, 3, 4, 5, 6, time.UTC)))
 }
 
+func TestFormatPence(t *testing.T) {
+	assert.Equal(t, "£82.00", formatPence(8200))
+	assert.Equal(t, "£41.05", formatPence(4105))
+	assert.Equal(t, "£0.00", formatPence(0))
+}
+
 func TestLowerFirst(t *testing.T) {
 	assert.Equal(t, "hELLO", lowerFirst("HELLO"))
 	assert.Equal(t, "hello", lowerFirst("hello"))
@@ -357,3 +363,19 @@ func TestListPeopleNamedOnLpa(t *testing.T) {
 
 	assert.Equal(t, want, got)
 }
+
+func TestMessageStatus(t *testing.T) {
+	app := page.AppData{SessionID: "abc"}
+	message := &page.Message{ID: "notify-id"}
+
+	want := map[string]interface{}{
+		"App":        app,
+		"Message":    message,
+		"Label":      "a-label",
+		"ResendPath": "/resend",
+	}
+
+	got := messageStatus(app, message, "a-label", "/resend")
+
+	assert.Equal(t, want, got)
+}
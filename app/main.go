@@ -2,11 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -19,8 +21,11 @@ import (
 	"github.com/ministryofjustice/opg-go-common/template"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/app"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/dynamo"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/encryption"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/event"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/identity"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/localize"
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/lpastore"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/onelogin"
 	"github.com/ministryofjustice/opg-modernising-lpa/internal/page"
@@ -49,8 +54,14 @@ func main() {
 		clientID              = env.Get("CLIENT_ID", "client-id-value")
 		issuer                = env.Get("ISSUER", "http://sign-in-mock:7012")
 		dynamoTableLpas       = env.Get("DYNAMODB_TABLE_LPAS", "")
+		dataStoreType         = env.Get("DATA_STORE", "dynamo")
+		dataStoreFile         = env.Get("DATA_STORE_FILE", "")
+		eventBusName          = env.Get("EVENT_BUS_NAME", "")
+		lpaEncryptionKeyID    = env.Get("LPA_ENCRYPTION_KMS_KEY_ID", "")
+		lpaStoreBaseURL       = env.Get("LPA_STORE_BASE_URL", "")
 		notifyBaseURL         = env.Get("GOVUK_NOTIFY_BASE_URL", "")
 		notifyIsProduction    = env.Get("GOVUK_NOTIFY_IS_PRODUCTION", "") == "1"
+		notifyTemplatesPath   = env.Get("GOVUK_NOTIFY_TEMPLATES", "")
 		ordnanceSurveyBaseUrl = env.Get("ORDNANCE_SURVEY_BASE_URL", "http://ordnance-survey-mock:4011")
 		payBaseUrl            = env.Get("GOVUK_PAY_BASE_URL", "http://pay-mock:4010")
 		port                  = env.Get("APP_PORT", "8080")
@@ -114,9 +125,24 @@ func main() {
 		})
 	}
 
-	dynamoClient, err := dynamo.NewClient(cfg, dynamoTableLpas)
-	if err != nil {
-		logger.Fatal(err)
+	// DATA_STORE=memory keeps data in memory instead of Dynamo, optionally
+	// persisted to DATA_STORE_FILE, for local development.
+	var dataStore page.DataStore
+	switch dataStoreType {
+	case "dynamo":
+		dynamoClient, err := dynamo.NewClient(cfg, dynamoTableLpas)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		dataStore = dynamoClient
+	case "memory":
+		memoryClient, err := dynamo.NewMemoryClient(dataStoreFile)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		dataStore = memoryClient
+	default:
+		logger.Fatal(fmt.Errorf("unknown DATA_STORE %q", dataStoreType))
 	}
 
 	secretsClient, err := secrets.NewClient(cfg, time.Hour)
@@ -131,6 +157,23 @@ func main() {
 
 	sessionStore := sessions.NewCookieStore(sessionKeys...)
 
+	lpaEncryptionKeys, err := secretsClient.LpaEncryptionKeys(ctx)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	localKeyProvider, err := encryption.NewLocalKeyProvider(lpaEncryptionKeys...)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	// Data keys are wrapped with the KMS key when one is given. The local keys
+	// are still needed to read values sealed before it was.
+	var keyProvider encryption.KeyProvider = localKeyProvider
+	if lpaEncryptionKeyID != "" {
+		keyProvider = encryption.NewKMSKeyProvider(cfg, lpaEncryptionKeyID, localKeyProvider)
+	}
+
 	redirectURL := authRedirectBaseURL + page.Paths.AuthRedirect
 
 	signInClient, err := onelogin.Discover(ctx, logger, httpClient, secretsClient, issuer, clientID, redirectURL)
@@ -176,11 +219,163 @@ func main() {
 		logger.Fatal(err)
 	}
 
-	notifyClient, err := notify.New(notifyIsProduction, notifyBaseURL, notifyApiKey, httpClient)
+	notifyCallbackToken, err := secretsClient.Secret(ctx, secrets.GovUkNotifyCallbackToken)
 	if err != nil {
 		logger.Fatal(err)
 	}
 
+	// Template IDs are read from GOVUK_NOTIFY_TEMPLATES, otherwise from the file
+	// for production or non-production. Each ID given must exist in Notify for
+	// the app to start; templates without an ID are logged, and sending with
+	// them fails until one is added.
+	if notifyTemplatesPath == "" {
+		notifyTemplatesPath = "notify-templates/non-production.json"
+		if notifyIsProduction {
+			notifyTemplatesPath = "notify-templates/production.json"
+		}
+	}
+
+	notifyTemplates, err := notify.LoadTemplates(notifyTemplatesPath)
+	if err != nil {
+		logger.Fatal(fmt.Errorf("unable to load %s: %w", notifyTemplatesPath, err))
+	}
+
+	notifyClient, err := notify.New(notifyBaseURL, notifyApiKey, httpClient, notifyTemplates)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	// Without EVENT_BUS_NAME events are logged rather than published.
+	var eventPublisher page.EventPublisher = event.NewRecorder(logger)
+	if eventBusName != "" {
+		eventPublisher, err = event.NewClient(cfg, eventBusName)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	registrationClient := lpastore.New(lpaStoreBaseURL, httpClient)
+
+	// Running with the reconcile-payments argument checks any payments that
+	// have not finished, then exits, so it can be run as a scheduled task.
+	if len(os.Args) > 1 && os.Args[1] == "reconcile-payments" {
+		if err := app.ReconcilePayments(ctx, logger, dataStore, keyProvider, payClient, notifyClient, eventPublisher, appPublicURL); err != nil {
+			logger.Fatal(err)
+		}
+
+		return
+	}
+
+	// Running with the notify-people argument sends the notices to people to
+	// notify and the invites to attorneys for submitted LPAs, including any
+	// that failed before, then exits, so it can be run as a scheduled task.
+	if len(os.Args) > 1 && os.Args[1] == "notify-people" {
+		if err := app.SendPendingNotices(ctx, logger, dataStore, keyProvider, notifyClient, appPublicURL); err != nil {
+			logger.Fatal(err)
+		}
+
+		return
+	}
+
+	// Running with the register-lpas argument sends any submitted LPAs that
+	// have not been registered to the LPA store, then exits, so it can be run
+	// as a scheduled task. There is no default LPA_STORE_BASE_URL, so that LPAs
+	// are never sent to the mock outside local development.
+	if len(os.Args) > 1 && os.Args[1] == "register-lpas" {
+		if lpaStoreBaseURL == "" {
+			logger.Fatal(errors.New("LPA_STORE_BASE_URL must be set to register LPAs"))
+		}
+
+		if err := app.RegisterLpas(ctx, logger, dataStore, keyProvider, registrationClient); err != nil {
+			logger.Fatal(err)
+		}
+
+		return
+	}
+
+	// Running with the approve-fee-evidence argument and an LPA ID records that
+	// the evidence sent for the LPA's fee reduction has been accepted, then
+	// exits.
+	if len(os.Args) > 2 && os.Args[1] == "approve-fee-evidence" {
+		if err := app.ApproveFeeEvidence(ctx, dataStore, keyProvider, notifyClient, appPublicURL, os.Args[2]); err != nil {
+			logger.Fatal(err)
+		}
+
+		return
+	}
+
+	// Running with the migrate-lpas argument rewrites LPAs stored with an older
+	// schema version, and gives share codes stored before they expired an
+	// expiry, then exits. With -dry-run it only reports what would change.
+	if len(os.Args) > 1 && os.Args[1] == "migrate-lpas" {
+		dryRun := len(os.Args) > 2 && os.Args[2] == "-dry-run"
+
+		if err := app.MigrateLpas(ctx, logger, dataStore, keyProvider, dryRun); err != nil {
+			logger.Fatal(err)
+		}
+
+		if err := app.MigrateShareCodes(ctx, logger, dataStore, time.Now(), dryRun); err != nil {
+			logger.Fatal(err)
+		}
+
+		return
+	}
+
+	// Running with the publish-events argument publishes any events that could
+	// not be published when they happened, then exits, so it can be run as a
+	// scheduled task.
+	if len(os.Args) > 1 && os.Args[1] == "publish-events" {
+		if err := app.PublishOutbox(ctx, logger, dataStore, eventPublisher); err != nil {
+			logger.Fatal(err)
+		}
+
+		return
+	}
+
+	// Running with the purge-expired argument applies the retention policy,
+	// then exits, so it can be run as a scheduled task. Each rule can be set
+	// with a duration such as 8760h, otherwise the default is used.
+	if len(os.Args) > 1 && os.Args[1] == "purge-expired" {
+		policy := app.DefaultRetentionPolicy
+		for name, rule := range map[string]*time.Duration{
+			"RETENTION_DRAFT_LPA":         &policy.DraftLpa,
+			"RETENTION_DRAFT_LPA_WARNING": &policy.DraftLpaWarning,
+			"RETENTION_SHARE_CODE":        &policy.ShareCode,
+			"RETENTION_WITNESS_CODE":      &policy.WitnessCode,
+		} {
+			if value := env.Get(name, ""); value != "" {
+				if *rule, err = time.ParseDuration(value); err != nil {
+					logger.Fatal(fmt.Errorf("invalid %s: %w", name, err))
+				}
+			}
+		}
+
+		if err := app.PurgeExpired(ctx, logger, dataStore, keyProvider, notifyClient, appPublicURL, policy, time.Now()); err != nil {
+			logger.Fatal(err)
+		}
+
+		return
+	}
+
+	// The templates are only checked when starting the web server, so that the
+	// jobs do not each call Notify before doing their work.
+	if err := notifyClient.CheckTemplates(ctx); err != nil {
+		logger.Fatal(err)
+	}
+
+	if missing := notifyTemplates.Missing(); len(missing) > 0 {
+		logger.Print("notify templates not configured: ", strings.Join(missing, ", "))
+	}
+
+	// SUPPORT_EMAILS is a comma separated list of the One Login email addresses
+	// that can use the support pages.
+	var supportEmails []string
+	for _, email := range strings.Split(env.Get("SUPPORT_EMAILS", ""), ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			supportEmails = append(supportEmails, email)
+		}
+	}
+
 	mux := http.NewServeMux()
 	mux.HandleFunc(page.Paths.HealthCheck, func(w http.ResponseWriter, r *http.Request) {})
 	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
@@ -190,8 +385,9 @@ func main() {
 	mux.Handle(page.Paths.AuthRedirect, page.AuthRedirect(logger, signInClient, sessionStore))
 	mux.Handle(page.Paths.Auth, donor.Login(logger, signInClient, sessionStore, random.String))
 	mux.Handle(page.Paths.CookiesConsent, page.CookieConsent(page.Paths))
-	mux.Handle("/cy/", http.StripPrefix("/cy", app.App(logger, bundle.For("cy"), localize.Cy, tmpls, sessionStore, dynamoClient, appPublicURL, payClient, yotiClient, yotiScenarioID, notifyClient, addressClient, rumConfig, staticHash, page.Paths, signInClient)))
-	mux.Handle("/", app.App(logger, bundle.For("en"), localize.En, tmpls, sessionStore, dynamoClient, appPublicURL, payClient, yotiClient, yotiScenarioID, notifyClient, addressClient, rumConfig, staticHash, page.Paths, signInClient))
+	mux.Handle(page.Paths.NotifyCallback, app.NotifyCallback(logger, dataStore, notifyCallbackToken))
+	mux.Handle("/cy/", http.StripPrefix("/cy", app.App(logger, bundle.For("cy"), localize.Cy, tmpls, sessionStore, dataStore, keyProvider, appPublicURL, payClient, yotiClient, yotiScenarioID, notifyClient, eventPublisher, addressClient, rumConfig, staticHash, page.Paths, signInClient, supportEmails)))
+	mux.Handle("/", app.App(logger, bundle.For("en"), localize.En, tmpls, sessionStore, dataStore, keyProvider, appPublicURL, payClient, yotiClient, yotiScenarioID, notifyClient, eventPublisher, addressClient, rumConfig, staticHash, page.Paths, signInClient, supportEmails))
 
 	var handler http.Handler = mux
 	if xrayEnabled {
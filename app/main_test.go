@@ -5,6 +5,8 @@ import (
 	"os"
 	"strings"
 	"testing"
+
+	"github.com/ministryofjustice/opg-modernising-lpa/internal/notify"
 )
 
 func TestLanguageFilesMatch(t *testing.T) {
@@ -45,6 +47,15 @@ func TestApostrophesAreCurly(t *testing.T) {
 	}
 }
 
+func TestNotifyTemplatesAreValid(t *testing.T) {
+	for _, path := range []string{"notify-templates/production.json", "notify-templates/non-production.json"} {
+		if _, err := notify.LoadTemplates(path); err != nil {
+			t.Fail()
+			t.Log(path, err)
+		}
+	}
+}
+
 func loadTranslations(path string) map[string]string {
 	data, _ := os.ReadFile(path)
 	var v map[string]string
@@ -0,0 +1,27 @@
+describe('Sign', () => {
+    beforeEach(() => {
+        cy.visit('/testing-start?redirect=/attorney-read-the-lpa&completeLpa=1&asAttorney=1');
+    });
+
+    it('can be signed', () => {
+        cy.contains('h1', 'Read the LPA');
+        cy.contains('a', 'Continue').click();
+
+        cy.url().should('contain', '/attorney-sign');
+        cy.contains('h1', 'Sign as an attorney');
+        cy.get('#f-confirm').check();
+        cy.contains('button', 'Submit my signature').click();
+
+        cy.url().should('contain', '/attorney-what-happens-next');
+        cy.contains('You have signed the LPA');
+    });
+
+    it('errors when not confirmed', () => {
+        cy.visit('/attorney-sign');
+        cy.contains('button', 'Submit my signature').click();
+
+        cy.get('.govuk-error-summary').within(() => {
+            cy.contains('Select the box to sign as an attorney');
+        });
+    });
+});
@@ -0,0 +1,30 @@
+describe('Provide the certificate', () => {
+    beforeEach(() => {
+        cy.visit('/testing-start?redirect=/certificate-provider-read-the-lpa&completeLpa=1&asCertificateProvider=1');
+    });
+
+    it('can be provided', () => {
+        cy.contains('h1', 'Read the LPA');
+        cy.contains('a', 'Continue').click();
+
+        cy.url().should('contain', '/provide-certificate');
+        cy.contains('h1', 'Provide the certificate for this LPA');
+        cy.get('#f-agree-to-statement').check();
+        cy.get('#f-agree-to-statement-2').check();
+        cy.get('#f-agree-to-statement-3').check();
+        cy.contains('button', 'Submit my signature').click();
+
+        cy.url().should('contain', '/certificate-provided');
+        cy.contains('You have provided the certificate');
+    });
+
+    it('errors when not all statements agreed', () => {
+        cy.visit('/provide-certificate');
+        cy.get('#f-agree-to-statement').check();
+        cy.contains('button', 'Submit my signature').click();
+
+        cy.get('.govuk-error-summary').within(() => {
+            cy.contains('Select all the statements to provide the certificate');
+        });
+    });
+});
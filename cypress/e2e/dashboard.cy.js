@@ -34,6 +34,16 @@ describe('Dashboard', () => {
             cy.contains('Property and affairs: Jose Smith');
             cy.contains('Personal welfare: Jane Smith');
         });
+
+        it('can delete a draft', () => {
+            cy.contains('a', 'Delete LPA').click();
+
+            cy.url().should('contain', '/delete-lpa');
+            cy.contains('button', 'Delete LPA').click();
+
+            cy.url().should('contain', '/dashboard');
+            cy.contains('Jose Smith').should('not.exist');
+        });
     })
 
     context('with completed LPA', () => {
@@ -48,5 +58,19 @@ describe('Dashboard', () => {
 
             cy.url().should('contain', '/progress');
         });
+
+        it('can withdraw', () => {
+            cy.visit('/testing-start?redirect=/dashboard&completeLpa=1')
+
+            cy.contains('a', 'Withdraw LPA').click();
+
+            cy.url().should('contain', '/delete-lpa');
+            cy.contains('button', 'Withdraw LPA').click();
+
+            cy.url().should('contain', '/dashboard');
+            cy.contains('Jose Smith');
+            cy.contains('Withdrawn');
+            cy.contains('a', 'Track LPA progress').should('not.exist');
+        });
     })
 });
@@ -0,0 +1,15 @@
+describe('LPA history', () => {
+    it('shows the changes made to the LPA', () => {
+        cy.visit('/testing-start?redirect=/progress&completeLpa=1');
+        cy.contains('a', 'View the history of changes to this LPA').click();
+
+        cy.url().should('contain', '/lpa-history');
+
+        cy.injectAxe();
+        cy.checkA11y(null, { rules: { region: { enabled: false } } });
+
+        cy.contains('h1', 'LPA history');
+        cy.contains('h2', 'Version 1');
+        cy.contains('Changed by: Donor');
+    });
+});
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/ministryofjustice/opg-go-common/env"
 )
@@ -25,6 +26,24 @@ func main() {
 		json.NewEncoder(w).Encode(map[string]string{"id": "an-sms-id"})
 	})
 
+	http.HandleFunc("/v2/notifications/letter", func(w http.ResponseWriter, r *http.Request) {
+		var v map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&v)
+		delete(v, "content")
+		log.Println("letter:", v)
+		json.NewEncoder(w).Encode(map[string]string{"id": "a-letter-id"})
+	})
+
+	http.HandleFunc("/v2/notifications", func(w http.ResponseWriter, r *http.Request) {
+		log.Println("notifications:", r.URL.Query())
+		json.NewEncoder(w).Encode(map[string]interface{}{"notifications": []interface{}{}})
+	})
+
+	http.HandleFunc("/v2/template/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/v2/template/")
+		json.NewEncoder(w).Encode(map[string]string{"id": id})
+	})
+
 	if err := http.ListenAndServe(":"+port, nil); err != nil {
 		log.Fatal(err)
 	}
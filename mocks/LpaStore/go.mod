@@ -0,0 +1,7 @@
+module github.com/ministryofjustice/opg-modernising-lpa/mocks/LpaStore
+
+go 1.19
+
+require github.com/ministryofjustice/opg-go-common v0.0.0-20220816144329-763497f29f90
+
+require github.com/stretchr/testify v1.8.0 // indirect
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/ministryofjustice/opg-go-common/env"
+)
+
+func main() {
+	port := env.Get("PORT", "8080")
+
+	var (
+		mu         sync.Mutex
+		references = map[string]string{}
+	)
+
+	http.HandleFunc("/lpas", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Idempotency-Key is required"})
+			return
+		}
+
+		var v map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"message": "invalid lpa"})
+			return
+		}
+
+		mu.Lock()
+		reference, ok := references[key]
+		if !ok {
+			reference = fmt.Sprintf("M-0000-0000-%04d", len(references)+1)
+			references[key] = reference
+		}
+		mu.Unlock()
+
+		log.Println("lpa:", key, reference, v)
+
+		if ok {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusCreated)
+		}
+		json.NewEncoder(w).Encode(map[string]string{"reference": reference, "status": "received"})
+	})
+
+	if err := http.ListenAndServe(":"+port, nil); err != nil {
+		log.Fatal(err)
+	}
+}
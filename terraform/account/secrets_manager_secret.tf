@@ -38,6 +38,16 @@ resource "aws_secretsmanager_secret" "cookie_session_keys" {
   provider = aws.eu_west_1
 }
 
+resource "aws_secretsmanager_secret" "lpa_encryption_keys" {
+  name       = "lpa-encryption-keys"
+  kms_key_id = aws_kms_key.secrets_manager.key_id
+  replica {
+    kms_key_id = aws_kms_replica_key.secrets_manager_replica.key_id
+    region     = data.aws_region.eu_west_2.name
+  }
+  provider = aws.eu_west_1
+}
+
 resource "aws_secretsmanager_secret" "gov_uk_pay_api_key" {
   name       = "gov-uk-pay-api-key"
   kms_key_id = aws_kms_key.secrets_manager.key_id
@@ -67,3 +77,13 @@ resource "aws_secretsmanager_secret" "gov_uk_notify_api_key" {
   }
   provider = aws.eu_west_1
 }
+
+resource "aws_secretsmanager_secret" "gov_uk_notify_callback_token" {
+  name       = "gov-uk-notify-callback-token"
+  kms_key_id = aws_kms_key.secrets_manager.key_id
+  replica {
+    kms_key_id = aws_kms_replica_key.secrets_manager_replica.key_id
+    region     = data.aws_region.eu_west_2.name
+  }
+  provider = aws.eu_west_1
+}
@@ -42,6 +42,30 @@ resource "aws_dynamodb_table" "lpas_table" {
     type = "S"
   }
 
+  attribute {
+    name = "OrderedAt"
+    type = "S"
+  }
+
+  global_secondary_index {
+    name            = "SKIndex"
+    hash_key        = "SK"
+    range_key       = "PK"
+    projection_type = "ALL"
+  }
+
+  global_secondary_index {
+    name            = "SKOrderedAtIndex"
+    hash_key        = "SK"
+    range_key       = "OrderedAt"
+    projection_type = "ALL"
+  }
+
+  ttl {
+    attribute_name = "ExpiresAt"
+    enabled        = true
+  }
+
   point_in_time_recovery {
     enabled = true
   }
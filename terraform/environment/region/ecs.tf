@@ -31,6 +31,7 @@ module "app" {
   lpas_table                      = var.lpas_table
   container_port                  = 8080
   public_access_enabled           = var.public_access_enabled
+  scheduled_tasks_enabled         = var.scheduled_tasks_enabled
   network = {
     vpc_id              = data.aws_vpc.main.id
     application_subnets = data.aws_subnet.application.*.id
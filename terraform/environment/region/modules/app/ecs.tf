@@ -102,6 +102,11 @@ data "aws_kms_alias" "dynamodb_encryption_key" {
   provider = aws.region
 }
 
+data "aws_kms_alias" "lpa_data_encryption_key" {
+  name     = "alias/${data.aws_default_tags.current.tags.application}_lpa_data_encryption"
+  provider = aws.region
+}
+
 data "aws_secretsmanager_secret" "private_jwt_key" {
   name     = "private-jwt-key-base64"
   provider = aws.region
@@ -117,6 +122,11 @@ data "aws_secretsmanager_secret" "cookie_session_keys" {
   provider = aws.region
 }
 
+data "aws_secretsmanager_secret" "lpa_encryption_keys" {
+  name     = "lpa-encryption-keys"
+  provider = aws.region
+}
+
 data "aws_secretsmanager_secret" "gov_uk_pay_api_key" {
   name     = "gov-uk-pay-api-key"
   provider = aws.region
@@ -132,6 +142,11 @@ data "aws_secretsmanager_secret" "gov_uk_notify_api_key" {
   provider = aws.region
 }
 
+data "aws_secretsmanager_secret" "gov_uk_notify_callback_token" {
+  name     = "gov-uk-notify-callback-token"
+  provider = aws.region
+}
+
 data "aws_secretsmanager_secret" "os_postcode_lookup_api_key" {
   name     = "os-postcode-lookup-api-key"
   provider = aws.region
@@ -188,6 +203,20 @@ data "aws_iam_policy_document" "task_role_access_policy" {
     ]
   }
 
+  statement {
+    sid    = "LpaDataEncryptionAccess"
+    effect = "Allow"
+
+    actions = [
+      "kms:Decrypt",
+      "kms:GenerateDataKey",
+    ]
+
+    resources = [
+      data.aws_kms_alias.lpa_data_encryption_key.target_key_arn,
+    ]
+  }
+
   statement {
     sid    = "EcsSecretAccess"
     effect = "Allow"
@@ -200,14 +229,27 @@ data "aws_iam_policy_document" "task_role_access_policy" {
     resources = [
       data.aws_secretsmanager_secret.cookie_session_keys.arn,
       data.aws_secretsmanager_secret.gov_uk_notify_api_key.arn,
+      data.aws_secretsmanager_secret.gov_uk_notify_callback_token.arn,
       data.aws_secretsmanager_secret.gov_uk_onelogin_identity_public_key.arn,
       data.aws_secretsmanager_secret.gov_uk_pay_api_key.arn,
+      data.aws_secretsmanager_secret.lpa_encryption_keys.arn,
       data.aws_secretsmanager_secret.os_postcode_lookup_api_key.arn,
       data.aws_secretsmanager_secret.private_jwt_key.arn,
       data.aws_secretsmanager_secret.yoti_private_key.arn,
     ]
   }
 
+  statement {
+    sid    = "EventBridgeAccess"
+    effect = "Allow"
+
+    actions = ["events:PutEvents"]
+
+    resources = [
+      aws_cloudwatch_event_bus.main.arn,
+    ]
+  }
+
   statement {
     sid = "Allow"
 
@@ -288,6 +330,18 @@ locals {
           name  = "DYNAMODB_TABLE_LPAS",
           value = var.lpas_table.name
         },
+        {
+          name  = "LPA_ENCRYPTION_KMS_KEY_ID",
+          value = data.aws_kms_alias.lpa_data_encryption_key.target_key_arn
+        },
+        {
+          name  = "EVENT_BUS_NAME",
+          value = aws_cloudwatch_event_bus.main.name
+        },
+        {
+          name  = "LPA_STORE_BASE_URL",
+          value = var.app_env_vars.lpa_store_base_url
+        },
         {
           name  = "GOVUK_PAY_BASE_URL",
           value = "https://publicapi.payments.service.gov.uk"
@@ -0,0 +1,4 @@
+resource "aws_cloudwatch_event_bus" "main" {
+  name     = local.name_prefix
+  provider = aws.region
+}
@@ -0,0 +1,104 @@
+locals {
+  # Each job is run by starting the app task with the job's name as its
+  # argument, so it exits once the job is done.
+  scheduled_tasks = var.scheduled_tasks_enabled ? {
+    notify-people      = "rate(5 minutes)"
+    publish-events     = "rate(5 minutes)"
+    purge-expired      = "cron(0 3 * * ? *)"
+    reconcile-payments = "rate(15 minutes)"
+    register-lpas      = "rate(5 minutes)"
+  } : {}
+}
+
+resource "aws_cloudwatch_event_rule" "scheduled_task" {
+  for_each            = local.scheduled_tasks
+  name                = "${local.name_prefix}-${each.key}"
+  description         = "Run the app with ${each.key}"
+  schedule_expression = each.value
+  provider            = aws.region
+}
+
+resource "aws_cloudwatch_event_target" "scheduled_task" {
+  for_each  = local.scheduled_tasks
+  rule      = aws_cloudwatch_event_rule.scheduled_task[each.key].name
+  target_id = each.key
+  arn       = var.ecs_cluster
+  role_arn  = aws_iam_role.scheduled_task.arn
+
+  ecs_target {
+    task_count          = 1
+    task_definition_arn = aws_ecs_task_definition.app.arn
+    launch_type         = "FARGATE"
+    platform_version    = "1.4.0"
+    propagate_tags      = "TASK_DEFINITION"
+
+    network_configuration {
+      security_groups  = [aws_security_group.app_ecs_service.id]
+      subnets          = var.network.application_subnets
+      assign_public_ip = false
+    }
+  }
+
+  input = jsonencode({
+    containerOverrides = [
+      {
+        name    = "app",
+        command = [each.key]
+      }
+    ]
+  })
+  provider = aws.region
+}
+
+resource "aws_iam_role" "scheduled_task" {
+  name               = "${local.name_prefix}-scheduled-task"
+  assume_role_policy = data.aws_iam_policy_document.scheduled_task_assume_policy.json
+  provider           = aws.region
+}
+
+data "aws_iam_policy_document" "scheduled_task_assume_policy" {
+  statement {
+    effect  = "Allow"
+    actions = ["sts:AssumeRole"]
+
+    principals {
+      identifiers = ["events.amazonaws.com"]
+      type        = "Service"
+    }
+  }
+  provider = aws.region
+}
+
+resource "aws_iam_role_policy" "scheduled_task" {
+  name     = "${local.name_prefix}-scheduled-task"
+  policy   = data.aws_iam_policy_document.scheduled_task.json
+  role     = aws_iam_role.scheduled_task.id
+  provider = aws.region
+}
+
+data "aws_iam_policy_document" "scheduled_task" {
+  statement {
+    sid       = "RunTask"
+    effect    = "Allow"
+    actions   = ["ecs:RunTask"]
+    resources = ["${replace(aws_ecs_task_definition.app.arn, "/:\\d+$/", "")}:*"]
+
+    condition {
+      test     = "ArnEquals"
+      variable = "ecs:cluster"
+      values   = [var.ecs_cluster]
+    }
+  }
+
+  statement {
+    sid     = "PassRole"
+    effect  = "Allow"
+    actions = ["iam:PassRole"]
+
+    resources = [
+      var.ecs_execution_role.arn,
+      var.ecs_task_role.arn,
+    ]
+  }
+  provider = aws.region
+}
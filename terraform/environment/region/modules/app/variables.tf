@@ -95,3 +95,8 @@ variable "rum_monitor_application_id_secretsmanager_secret_arn" {
   description = "ARN of the AWS Secrets Manager secret containing the RUM monitor application ID"
   nullable    = true
 }
+
+variable "scheduled_tasks_enabled" {
+  type        = bool
+  description = "Schedule the app's jobs, such as registering LPAs and sending notices, to run in this region. They should run in only one region."
+}
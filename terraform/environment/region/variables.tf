@@ -70,3 +70,8 @@ variable "rum_monitor_application_id_secretsmanager_secret_id" {
   description = "ARN of the AWS Secrets Manager secret containing the RUM monitor identity pool ID"
   nullable    = true
 }
+
+variable "scheduled_tasks_enabled" {
+  type        = bool
+  description = "Schedule the app's jobs to run in this region. They should run in only one region."
+}
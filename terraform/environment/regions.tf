@@ -23,6 +23,7 @@ module "eu_west_1" {
   lpas_table                                            = aws_dynamodb_table.lpas_table
   app_env_vars                                          = local.environment.app.env
   public_access_enabled                                 = local.environment.app.public_access_enabled
+  scheduled_tasks_enabled                               = true
   rum_monitor_identity_pool_id_secretsmanager_secret_id = data.aws_secretsmanager_secret.rum_monitor_identity_pool_id_eu_west_1.arn
   rum_monitor_application_id_secretsmanager_secret_id   = aws_secretsmanager_secret.rum_monitor_application_id_eu_west_1.id
   providers = {
@@ -47,6 +48,7 @@ module "eu_west_2" {
   lpas_table                                            = aws_dynamodb_table.lpas_table
   app_env_vars                                          = local.environment.app.env
   public_access_enabled                                 = local.environment.app.public_access_enabled
+  scheduled_tasks_enabled                               = !contains(local.environment.regions, "eu-west-1")
   rum_monitor_identity_pool_id_secretsmanager_secret_id = data.aws_secretsmanager_secret.rum_monitor_identity_pool_id_eu_west_1.arn # would be updated to eu_west_2 when that region exists
   rum_monitor_application_id_secretsmanager_secret_id   = aws_secretsmanager_secret.rum_monitor_application_id_eu_west_2.id
   providers = {
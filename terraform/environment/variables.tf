@@ -27,6 +27,7 @@ variable "environments" {
           yoti_client_sdk_id     = string
           yoti_scenario_id       = string
           yoti_sandbox           = string
+          lpa_store_base_url     = string
         })
       })
       backups = object({